@@ -23,7 +23,9 @@ func (cli *DockerCli) CmdAttach(args ...string) error {
 	)
 	cmd.Require(flag.Exact, 1)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 	name := cmd.Arg(0)
 
 	stream, _, err := cli.call("GET", "/containers/"+name+"/json", nil, nil)
@@ -1,12 +1,16 @@
 package client
 
 import (
+	"archive/tar"
 	"bufio"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -16,20 +20,25 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/graph/tags"
+	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/homedir"
 	"github.com/docker/docker/pkg/jsonmessage"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/progressreader"
 	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/units"
 	"github.com/docker/docker/pkg/urlutil"
 	"github.com/docker/docker/registry"
+	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
 
@@ -40,6 +49,7 @@ const (
 // CmdBuild builds a new image from the source code at a given path.
 //
 // If '-' is provided instead of a path or URL, Docker will build an image from either a Dockerfile or tar archive read from STDIN.
+// With '-f -' the Dockerfile is read from STDIN and the context is still read from PATH or URL.
 //
 // Usage: docker build [OPTIONS] PATH | URL | -
 func (cli *DockerCli) CmdBuild(args ...string) error {
@@ -50,7 +60,7 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	rm := cmd.Bool([]string{"#rm", "-rm"}, true, "Remove intermediate containers after a successful build")
 	forceRm := cmd.Bool([]string{"-force-rm"}, false, "Always remove intermediate containers")
 	pull := cmd.Bool([]string{"-pull"}, false, "Always attempt to pull a newer version of the image")
-	dockerfileName := cmd.String([]string{"f", "-file"}, "", "Name of the Dockerfile (Default is 'PATH/Dockerfile')")
+	dockerfileName := cmd.String([]string{"f", "-file"}, "", "Name of the Dockerfile (Default is 'PATH/Dockerfile', '-' to read it from STDIN)")
 	flMemoryString := cmd.String([]string{"m", "-memory"}, "", "Memory limit")
 	flMemorySwap := cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
 	flCPUShares := cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
@@ -59,9 +69,27 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	flCPUSetCpus := cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
 	flCPUSetMems := cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
 	flCgroupParent := cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+	flBuildArg := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flBuildArg, []string{"-build-arg"}, "Set build-time variables")
+	flLabels := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flLabels, []string{"-label"}, "Set metadata for an image")
+	flTarget := cmd.String([]string{"-target"}, "", "Set the target build stage to build")
+	flPlatform := cmd.String([]string{"-platform"}, "", "Set the platform the base images are pulled for, such as linux/arm64")
+	flCacheFrom := opts.NewListOpts(nil)
+	cmd.Var(&flCacheFrom, []string{"-cache-from"}, "Images to consider as cache sources")
+	flSecrets := opts.NewListOpts(nil)
+	cmd.Var(&flSecrets, []string{"-secret"}, "Secret file to expose to RUN --mount=type=secret (id=mysecret,src=/local/secret)")
+	flSquash := cmd.Bool([]string{"-squash"}, false, "Squash the layers of the build into a single new layer")
+	flOutput := cmd.String([]string{"o", "-output"}, "", "Export the build result to a directory or a tar archive instead of creating an image (type=local,dest=path)")
+	flNetwork := cmd.String([]string{"-network"}, "default", "Set the networking mode for the RUN instructions during build")
+	flExtraHosts := opts.NewListOpts(opts.ValidateExtraHost)
+	cmd.Var(&flExtraHosts, []string{"-add-host"}, "Add a custom host-to-IP mapping (host:ip)")
+	flSSH := cmd.String([]string{"-ssh"}, "", "SSH agent socket to forward to RUN --mount=type=ssh ('default' for $SSH_AUTH_SOCK)")
 
 	cmd.Require(flag.Exact, 1)
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	var (
 		context  archive.Archive
@@ -69,8 +97,33 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		err      error
 	)
 
+	var outputType, outputDest string
+	if *flOutput != "" {
+		if *tag != "" {
+			return fmt.Errorf("Conflicting options: --output and --tag")
+		}
+		if outputType, outputDest, err = parseBuildOutput(*flOutput); err != nil {
+			return err
+		}
+	}
+
 	_, err = exec.LookPath("git")
 	hasGit := err == nil
+
+	var stdinDockerfile []byte
+	if *dockerfileName == "-" {
+		if cmd.Arg(0) == "-" {
+			return fmt.Errorf("Invalid argument: can't use stdin for both the build context and the Dockerfile")
+		}
+		if urlutil.IsURL(cmd.Arg(0)) && (!urlutil.IsGitURL(cmd.Arg(0)) || !hasGit) {
+			return fmt.Errorf("Invalid argument: can't read the Dockerfile from stdin with a remote build context")
+		}
+		if stdinDockerfile, err = ioutil.ReadAll(cli.in); err != nil {
+			return fmt.Errorf("failed to read Dockerfile from STDIN: %v", err)
+		}
+		*dockerfileName = stdinDockerfileName(stdinDockerfile)
+	}
+
 	if cmd.Arg(0) == "-" {
 		// As a special case, 'docker build -' will build from either an empty context with the
 		// contents of stdin as a Dockerfile, or a tar-ed context from stdin.
@@ -97,67 +150,26 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	} else {
 		root := cmd.Arg(0)
 		if urlutil.IsGitURL(root) {
-			root, err = utils.GitClone(root)
+			repoRoot, contextDir, err := utils.GitClone(root)
 			if err != nil {
 				return err
 			}
-			defer os.RemoveAll(root)
+			defer os.RemoveAll(repoRoot)
+			root = contextDir
 		}
 		if _, err := os.Stat(root); err != nil {
 			return err
 		}
 
-		absRoot, err := filepath.Abs(root)
-		if err != nil {
-			return err
-		}
-
-		filename := *dockerfileName // path to Dockerfile
-
-		if *dockerfileName == "" {
-			// No -f/--file was specified so use the default
-			*dockerfileName = api.DefaultDockerfileName
-			filename = filepath.Join(absRoot, *dockerfileName)
-
-			// Just to be nice ;-) look for 'dockerfile' too but only
-			// use it if we found it, otherwise ignore this check
-			if _, err = os.Lstat(filename); os.IsNotExist(err) {
-				tmpFN := path.Join(absRoot, strings.ToLower(*dockerfileName))
-				if _, err = os.Lstat(tmpFN); err == nil {
-					*dockerfileName = strings.ToLower(*dockerfileName)
-					filename = tmpFN
-				}
+		if stdinDockerfile == nil {
+			if *dockerfileName, err = getDockerfileRelPath(root, *dockerfileName); err != nil {
+				return err
 			}
 		}
-
-		origDockerfile := *dockerfileName // used for error msg
-		if filename, err = filepath.Abs(filename); err != nil {
-			return err
-		}
-
-		// Verify that 'filename' is within the build context
-		filename, err = symlink.FollowSymlinkInScope(filename, absRoot)
-		if err != nil {
-			return fmt.Errorf("The Dockerfile (%s) must be within the build context (%s)", origDockerfile, root)
-		}
-
-		// Now reset the dockerfileName to be relative to the build context
-		*dockerfileName, err = filepath.Rel(absRoot, filename)
-		if err != nil {
-			return err
-		}
-		// And canonicalize dockerfile name to a platform-independent one
-		*dockerfileName, err = archive.CanonicalTarNameForPath(*dockerfileName)
-		if err != nil {
-			return fmt.Errorf("Cannot canonicalize dockerfile path %s: %v", *dockerfileName, err)
-		}
-
-		if _, err = os.Lstat(filename); os.IsNotExist(err) {
-			return fmt.Errorf("Cannot locate Dockerfile: %s", origDockerfile)
-		}
 		var includes = []string{"."}
 
-		excludes, err := utils.ReadDockerIgnore(path.Join(root, ".dockerignore"))
+		dockerIgnore := utils.DockerIgnoreFile(root, *dockerfileName)
+		excludes, err := utils.ReadDockerIgnore(path.Join(root, dockerIgnore))
 		if err != nil {
 			return err
 		}
@@ -168,10 +180,10 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		// .dockerignore is needed to know if either one needs to be
 		// removed.  The deamon will remove them for us, if needed, after it
 		// parses the Dockerfile.
-		keepThem1, _ := fileutils.Matches(".dockerignore", excludes)
+		keepThem1, _ := fileutils.Matches(dockerIgnore, excludes)
 		keepThem2, _ := fileutils.Matches(*dockerfileName, excludes)
 		if keepThem1 || keepThem2 {
-			includes = append(includes, ".dockerignore", *dockerfileName)
+			includes = append(includes, dockerIgnore, *dockerfileName)
 		}
 
 		if err := utils.ValidateContextDirectory(root, excludes); err != nil {
@@ -186,6 +198,9 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		if err != nil {
 			return err
 		}
+		if stdinDockerfile != nil {
+			context = addDockerfileToContext(context, *dockerfileName, stdinDockerfile)
+		}
 	}
 
 	// windows: show error message about modified file permissions
@@ -279,16 +294,85 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	v.Set("memory", strconv.FormatInt(memory, 10))
 	v.Set("memswap", strconv.FormatInt(memorySwap, 10))
 	v.Set("cgroupparent", *flCgroupParent)
+	if *flNetwork != "default" {
+		v.Set("networkmode", *flNetwork)
+	}
+	if *flTarget != "" {
+		v.Set("target", *flTarget)
+	}
+	if *flPlatform != "" {
+		v.Set("platform", *flPlatform)
+	}
+	if *flSquash {
+		v.Set("squash", "1")
+	}
 
 	v.Set("dockerfile", *dockerfileName)
 
+	// collect all the build-time environment variables for the container
+	buildArgs := runconfig.ConvertKVStringsToMap(flBuildArg.GetAll())
+	buildArgsJSON, err := json.Marshal(buildArgs)
+	if err != nil {
+		return err
+	}
+	v.Set("buildargs", string(buildArgsJSON))
+
+	if labels := flLabels.GetAll(); len(labels) > 0 {
+		labelsJSON, err := json.Marshal(runconfig.ConvertKVStringsToMap(labels))
+		if err != nil {
+			return err
+		}
+		v.Set("labels", string(labelsJSON))
+	}
+
+	if *flSSH != "" {
+		session, closeSession, err := cli.forwardSSHAgent(*flSSH)
+		if err != nil {
+			return err
+		}
+		defer closeSession()
+		v.Set("ssh", session)
+	}
+
+	if cacheFrom := flCacheFrom.GetAll(); len(cacheFrom) > 0 {
+		cacheFromJSON, err := json.Marshal(cacheFrom)
+		if err != nil {
+			return err
+		}
+		v.Set("cachefrom", string(cacheFromJSON))
+	}
+
+	if extraHosts := flExtraHosts.GetAll(); len(extraHosts) > 0 {
+		extraHostsJSON, err := json.Marshal(extraHosts)
+		if err != nil {
+			return err
+		}
+		v.Set("extrahosts", string(extraHostsJSON))
+	}
+
 	headers := http.Header(make(map[string][]string))
-	buf, err := json.Marshal(cli.configFile.AuthConfigs)
+	authConfigs, err := cli.configFile.GetAllAuthConfigs()
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(authConfigs)
 	if err != nil {
 		return err
 	}
 	headers.Add("X-Registry-Config", base64.URLEncoding.EncodeToString(buf))
 
+	if specs := flSecrets.GetAll(); len(specs) > 0 {
+		secrets, err := readBuildSecrets(specs)
+		if err != nil {
+			return err
+		}
+		buf, err := json.Marshal(secrets)
+		if err != nil {
+			return err
+		}
+		headers.Add("X-Build-Secrets", base64.URLEncoding.EncodeToString(buf))
+	}
+
 	if context != nil {
 		headers.Set("Content-Type", "application/tar")
 	}
@@ -298,7 +382,26 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		out:         cli.out,
 		headers:     headers,
 	}
+
+	var waitOutput func(error) error
+	if outputType != "" {
+		var session string
+		if session, waitOutput, err = cli.receiveBuildOutput(outputType, outputDest); err != nil {
+			return err
+		}
+		v.Set("output", session)
+		if outputType == "tar" && outputDest == "-" {
+			// the archive goes to stdout
+			sopts.out = cli.err
+		}
+	}
+
 	err = cli.stream("POST", fmt.Sprintf("/build?%s", v.Encode()), sopts)
+	if waitOutput != nil {
+		if oerr := waitOutput(err); err == nil {
+			err = oerr
+		}
+	}
 	if jerr, ok := err.(*jsonmessage.JSONError); ok {
 		// If no error code is set, default to 1
 		if jerr.Code == 0 {
@@ -308,3 +411,327 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	}
 	return err
 }
+
+// getDockerfileRelPath returns the path of the Dockerfile dockerfileName,
+// which defaults to PATH/Dockerfile, relative to the build context root and
+// in its platform-independent form. The Dockerfile must be within root.
+func getDockerfileRelPath(root, dockerfileName string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	filename := dockerfileName // path to Dockerfile
+
+	if dockerfileName == "" {
+		// No -f/--file was specified so use the default
+		dockerfileName = api.DefaultDockerfileName
+		filename = filepath.Join(absRoot, dockerfileName)
+
+		// Just to be nice ;-) look for 'dockerfile' too but only
+		// use it if we found it, otherwise ignore this check
+		if _, err = os.Lstat(filename); os.IsNotExist(err) {
+			tmpFN := path.Join(absRoot, strings.ToLower(dockerfileName))
+			if _, err = os.Lstat(tmpFN); err == nil {
+				dockerfileName = strings.ToLower(dockerfileName)
+				filename = tmpFN
+			}
+		}
+	}
+
+	origDockerfile := dockerfileName // used for error msg
+	if filename, err = filepath.Abs(filename); err != nil {
+		return "", err
+	}
+
+	// Verify that 'filename' is within the build context
+	filename, err = symlink.FollowSymlinkInScope(filename, absRoot)
+	if err != nil {
+		return "", fmt.Errorf("The Dockerfile (%s) must be within the build context (%s)", origDockerfile, root)
+	}
+
+	// Now reset the dockerfileName to be relative to the build context
+	dockerfileName, err = filepath.Rel(absRoot, filename)
+	if err != nil {
+		return "", err
+	}
+	// And canonicalize dockerfile name to a platform-independent one
+	dockerfileName, err = archive.CanonicalTarNameForPath(dockerfileName)
+	if err != nil {
+		return "", fmt.Errorf("Cannot canonicalize dockerfile path %s: %v", dockerfileName, err)
+	}
+
+	if _, err = os.Lstat(filename); os.IsNotExist(err) {
+		return "", fmt.Errorf("Cannot locate Dockerfile: %s", origDockerfile)
+	}
+	return dockerfileName, nil
+}
+
+// stdinDockerfileName returns the name under which a Dockerfile read from
+// stdin is added to the build context. It depends on the contents of the
+// Dockerfile only, so that the context, and thus the build cache, stays the
+// same between builds with the same Dockerfile.
+func stdinDockerfileName(dockerfile []byte) string {
+	sum := sha256.Sum256(dockerfile)
+	return ".dockerfile." + hex.EncodeToString(sum[:])[:20]
+}
+
+// addDockerfileToContext adds the Dockerfile read from stdin to the context
+// as name, and adds name to the .dockerignore of the context so that the
+// daemon removes it from the context once it has read it, like it does for
+// an excluded Dockerfile.
+func addDockerfileToContext(context archive.Archive, name string, dockerfile []byte) archive.Archive {
+	pr, pw := io.Pipe()
+	go func() {
+		defer context.Close()
+		pw.CloseWithError(copyContextWithDockerfile(pw, context, name, dockerfile))
+	}()
+	return pr
+}
+
+func copyContextWithDockerfile(w io.Writer, context io.Reader, name string, dockerfile []byte) error {
+	var (
+		tr         = tar.NewReader(context)
+		tw         = tar.NewWriter(w)
+		now        = time.Now()
+		hasIgnore  bool
+		ignoreLine = []byte("\n" + name + "\n")
+	)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if filepath.Clean(hdr.Name) != ".dockerignore" || hdr.Typeflag != tar.TypeReg {
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		excludes, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		excludes = append(excludes, ignoreLine...)
+		hdr.Size = int64(len(excludes))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(excludes); err != nil {
+			return err
+		}
+		hasIgnore = true
+	}
+
+	addFile := func(name string, content []byte) error {
+		hdr := &tar.Header{
+			Name:       name,
+			Mode:       0600,
+			Size:       int64(len(content)),
+			ModTime:    now,
+			AccessTime: now,
+			ChangeTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(content)
+		return err
+	}
+	if err := addFile(name, dockerfile); err != nil {
+		return err
+	}
+	if !hasIgnore {
+		// a .dockerignore of our own, which excludes itself too
+		if err := addFile(".dockerignore", append([]byte(".dockerignore"), ignoreLine...)); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// parseBuildOutput parses the value of --output, either type=<type>,dest=<path>
+// or a plain path for a local directory, "-" being a tar archive on stdout.
+func parseBuildOutput(spec string) (string, string, error) {
+	if !strings.Contains(spec, "=") {
+		if spec == "-" {
+			return "tar", spec, nil
+		}
+		return "local", spec, nil
+	}
+
+	var typ, dest string
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return "", "", fmt.Errorf("Invalid field %q in --output, must be key=value", field)
+		}
+		switch strings.ToLower(parts[0]) {
+		case "type":
+			typ = parts[1]
+		case "dest":
+			dest = parts[1]
+		default:
+			return "", "", fmt.Errorf("Unknown key %q in --output", parts[0])
+		}
+	}
+	switch typ {
+	case "local", "tar":
+	case "":
+		return "", "", fmt.Errorf("--output requires type=local or type=tar: %s", spec)
+	default:
+		return "", "", fmt.Errorf("Unknown --output type %q, must be local or tar", typ)
+	}
+	if dest == "" {
+		return "", "", fmt.Errorf("--output requires dest=<path>: %s", spec)
+	}
+	if typ == "local" && dest == "-" {
+		return "", "", fmt.Errorf("--output type=local requires a directory as dest")
+	}
+	return typ, dest, nil
+}
+
+// receiveBuildOutput asks the daemon to export the result of a build to the
+// client, and writes it to dest as a directory for the local type or as a
+// tar archive for the tar type. It returns the session to pass to the build
+// and a function to call with the result of the build, which waits for the
+// export to be over and cleans up after a failed build.
+func (cli *DockerCli) receiveBuildOutput(typ, dest string) (string, func(error) error, error) {
+	session := stringid.GenerateRandomID()
+	conn, br, resp, err := cli.hijackConn("POST", "/build/output?session="+session, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return "", nil, fmt.Errorf("Error exporting the build result: %s", resp.Status)
+	}
+
+	var (
+		f *os.File
+		w io.Writer = cli.out
+	)
+	if typ == "local" {
+		err = os.MkdirAll(dest, 0755)
+	} else if dest != "-" {
+		f, err = os.Create(dest)
+		w = f
+	}
+	if err != nil {
+		conn.Close()
+		return "", nil, err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if typ == "local" {
+			errCh <- archive.Untar(br, dest, &archive.TarOptions{NoLchown: true})
+			return
+		}
+		_, err := io.Copy(w, br)
+		errCh <- err
+	}()
+
+	return session, func(buildErr error) error {
+		if buildErr == nil {
+			err = <-errCh
+		}
+		conn.Close()
+		if f != nil {
+			f.Close()
+			if buildErr != nil || err != nil {
+				os.Remove(dest)
+			}
+		}
+		if buildErr == nil && err != nil {
+			return fmt.Errorf("Error exporting the build result: %v", err)
+		}
+		return nil
+	}, nil
+}
+
+// readBuildSecrets reads the files of the --secret id=<id>,src=<file> flags.
+// The id defaults to the base name of the file.
+func readBuildSecrets(specs []string) (map[string][]byte, error) {
+	secrets := make(map[string][]byte)
+	for _, spec := range specs {
+		var id, src string
+		for _, field := range strings.Split(spec, ",") {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("Invalid field %q in --secret, must be key=value", field)
+			}
+			switch strings.ToLower(parts[0]) {
+			case "id":
+				id = parts[1]
+			case "src", "source":
+				src = parts[1]
+			default:
+				return nil, fmt.Errorf("Unknown key %q in --secret", parts[0])
+			}
+		}
+		if src == "" {
+			return nil, fmt.Errorf("--secret requires src=<file>: %s", spec)
+		}
+		if strings.HasPrefix(src, "~/") {
+			src = filepath.Join(homedir.Get(), src[2:])
+		}
+		if id == "" {
+			id = filepath.Base(src)
+		}
+		if _, exists := secrets[id]; exists {
+			return nil, fmt.Errorf("Duplicate --secret id: %s", id)
+		}
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading secret %s: %v", id, err)
+		}
+		secrets[id] = data
+	}
+	return secrets, nil
+}
+
+// forwardSSHAgent connects to the SSH agent listening on socket, or on
+// $SSH_AUTH_SOCK if socket is "default", and forwards it to the daemon for
+// the RUN --mount=type=ssh instructions of a build. It returns the session
+// to pass to the build and a function closing it.
+func (cli *DockerCli) forwardSSHAgent(socket string) (string, func(), error) {
+	if socket == "default" {
+		socket = os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return "", nil, fmt.Errorf("--ssh=default requires SSH_AUTH_SOCK to be set")
+		}
+	}
+	agent, err := net.Dial("unix", socket)
+	if err != nil {
+		return "", nil, fmt.Errorf("Error connecting to the SSH agent: %v", err)
+	}
+
+	session := stringid.GenerateRandomID()
+	conn, br, resp, err := cli.hijackConn("POST", "/build/ssh?session="+session, nil)
+	if err != nil {
+		agent.Close()
+		return "", nil, err
+	}
+	if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		conn.Close()
+		agent.Close()
+		return "", nil, fmt.Errorf("Error forwarding the SSH agent: %s", resp.Status)
+	}
+
+	go io.Copy(agent, br)
+	go io.Copy(conn, agent)
+
+	return session, func() {
+		conn.Close()
+		agent.Close()
+	}, nil
+}
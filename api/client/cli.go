@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/docker/docker/pkg/homedir"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/term"
+	"github.com/docker/docker/pkg/version"
 )
 
 // DockerCli represents the docker command line client.
@@ -54,6 +57,14 @@ type DockerCli struct {
 	isTerminalOut bool
 	// transport holds the client transport instance.
 	transport *http.Transport
+	// version holds the API version negotiated with the daemon.
+	version version.Version
+	// negotiateOnce ensures the API version is negotiated once.
+	negotiateOnce sync.Once
+	// collectFlags makes the usage of the subcommands record them in
+	// collected instead of printing them, to generate the shell completions.
+	collectFlags bool
+	collected    *completionCommand
 }
 
 var funcMap = template.FuncMap{
@@ -74,10 +85,15 @@ func (cli *DockerCli) Err() io.Writer {
 func (cli *DockerCli) getMethod(args ...string) (func(...string) error, bool) {
 	camelArgs := make([]string, len(args))
 	for i, s := range args {
-		if len(s) == 0 {
-			return nil, false
+		// the words of the names are separated by dashes, such as dial-stdio
+		words := strings.Split(s, "-")
+		for j, w := range words {
+			if len(w) == 0 {
+				return nil, false
+			}
+			words[j] = strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
 		}
-		camelArgs[i] = strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+		camelArgs[i] = strings.Join(words, "")
 	}
 	methodName := "Cmd" + strings.Join(camelArgs, "")
 	method := reflect.ValueOf(cli).MethodByName(methodName)
@@ -117,6 +133,15 @@ func (cli *DockerCli) Subcmd(name, signature, description string, exitOnError bo
 	} else {
 		errorHandling = flag.ContinueOnError
 	}
+	if cli.collectFlags {
+		// the command returns the error of its flags once they are recorded
+		flags := flag.NewFlagSet(name, flag.ContinueOnError)
+		flags.SetOutput(ioutil.Discard)
+		flags.Usage = func() {
+			cli.collected = &completionCommand{name: name, signature: signature, description: description, flags: flags}
+		}
+		return flags
+	}
 	flags := flag.NewFlagSet(name, errorHandling)
 	flags.Usage = func() {
 		options := ""
@@ -187,6 +212,10 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, keyFile string, proto, a
 		tr.Dial = func(_, _ string) (net.Conn, error) {
 			return net.DialTimeout(proto, addr, timeout)
 		}
+	} else if proto == "ssh" {
+		// ssh compresses the tunnel on demand
+		tr.DisableCompression = true
+		tr.Dial = sshDialer(addr)
 	} else {
 		tr.Proxy = http.ProxyFromEnvironment
 		tr.Dial = (&net.Dialer{Timeout: timeout}).Dial
@@ -27,7 +27,9 @@ func (cli *DockerCli) CmdCommit(args ...string) error {
 	flConfig := cmd.String([]string{"#run", "#-run"}, "", "This option is deprecated and will be removed in a future version in favor of inline Dockerfile-compatible commands")
 	cmd.Require(flag.Max, 2)
 	cmd.Require(flag.Min, 1)
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	var (
 		name            = cmd.Arg(0)
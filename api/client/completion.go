@@ -0,0 +1,498 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// completionCommand is a command of the client, with its flags, as reported
+// by its usage in the collect mode of the client.
+type completionCommand struct {
+	name        string
+	signature   string
+	description string
+	flags       *flag.FlagSet
+}
+
+// completionOption is a flag of a command, with the names to complete.
+type completionOption struct {
+	names      []string
+	usage      string
+	takesValue bool
+}
+
+type completionCommandsByName []*completionCommand
+
+func (c completionCommandsByName) Len() int           { return len(c) }
+func (c completionCommandsByName) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c completionCommandsByName) Less(i, j int) bool { return c[i].name < c[j].name }
+
+// CmdCompletion outputs the script completing the docker commands in the
+// shell. The script is generated from the flags of the commands.
+//
+// Usage: docker completion bash|zsh|fish
+func (cli *DockerCli) CmdCompletion(args ...string) error {
+	cmd := cli.Subcmd("completion", "bash|zsh|fish", "Output the shell completion code for the docker commands", true)
+	cmd.Require(flag.Exact, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var write func(io.Writer, []*completionCommand)
+	switch shell := cmd.Arg(0); shell {
+	case "bash":
+		write = writeBashCompletion
+	case "zsh":
+		write = writeZshCompletion
+	case "fish":
+		write = writeFishCompletion
+	default:
+		return fmt.Errorf("Error: unsupported shell '%s', must be one of bash, zsh or fish", shell)
+	}
+
+	// the options of docker itself are completed as the command ""
+	commands := append([]*completionCommand{{flags: flag.CommandLine}}, collectCompletionCommands()...)
+	write(cli.out, commands)
+	return nil
+}
+
+// collectCompletionCommands returns the commands of the client sorted by
+// name, asking each Cmd method for its usage in the collect mode of the
+// client. The methods which don't report a usage aren't completed.
+func collectCompletionCommands() []*completionCommand {
+	collector := &DockerCli{
+		in:           ioutil.NopCloser(strings.NewReader("")),
+		out:          ioutil.Discard,
+		err:          ioutil.Discard,
+		collectFlags: true,
+	}
+	value := reflect.ValueOf(collector)
+	var commands []*completionCommand
+	for i := 0; i < value.NumMethod(); i++ {
+		if !strings.HasPrefix(value.Type().Method(i).Name, "Cmd") {
+			continue
+		}
+		method, ok := value.Method(i).Interface().(func(...string) error)
+		if !ok {
+			continue
+		}
+		if command := collector.collectCompletionCommand(method); command != nil {
+			commands = append(commands, command)
+		}
+	}
+	sort.Sort(completionCommandsByName(commands))
+	return commands
+}
+
+// collectCompletionCommand runs the method of a command for its usage, which
+// records the command in the collector instead of printing it. The command
+// returns at once with the error of its flags.
+func (cli *DockerCli) collectCompletionCommand(method func(...string) error) *completionCommand {
+	cli.collected = nil
+	method("--help")
+	return cli.collected
+}
+
+// options returns the flags of the command, without the deprecated names.
+func (c *completionCommand) options() []completionOption {
+	var options []completionOption
+	c.flags.VisitAll(func(f *flag.Flag) {
+		option := completionOption{usage: f.Usage}
+		for _, name := range f.Names {
+			if !strings.HasPrefix(name, "#") {
+				option.names = append(option.names, "-"+name)
+			}
+		}
+		if len(option.names) == 0 {
+			return
+		}
+		if b, ok := f.Value.(interface {
+			IsBoolFlag() bool
+		}); !ok || !b.IsBoolFlag() {
+			option.takesValue = true
+		}
+		options = append(options, option)
+	})
+	return options
+}
+
+// optionNames returns the names of the flags of the command, only those
+// taking a value when values is true.
+func (c *completionCommand) optionNames(values bool) []string {
+	var names []string
+	for _, option := range c.options() {
+		if option.takesValue || !values {
+			names = append(names, option.names...)
+		}
+	}
+	return names
+}
+
+// summary returns the first line of the description of the command.
+func (c *completionCommand) summary() string {
+	return strings.TrimSuffix(strings.SplitN(c.description, "\n", 2)[0], ".")
+}
+
+// arguments returns the kind of the arguments of the command, "container",
+// "image" or "volume" as named in its signature, or "" for files.
+func (c *completionCommand) arguments() string {
+	switch {
+	case strings.Contains(c.signature, "CONTAINER"):
+		return "container"
+	case strings.Contains(c.signature, "IMAGE"):
+		return "image"
+	case strings.Contains(c.signature, "VOLUME"):
+		return "volume"
+	}
+	return ""
+}
+
+// subcommands returns the commands one word below the command, such as the
+// volume commands of "volume", or the commands of docker for "".
+func (c *completionCommand) subcommands(commands []*completionCommand) []*completionCommand {
+	var subcommands []*completionCommand
+	for _, command := range commands {
+		name := command.name
+		if c.name != "" {
+			if !strings.HasPrefix(name, c.name+" ") {
+				continue
+			}
+			name = strings.TrimPrefix(name, c.name+" ")
+		}
+		if name != "" && !strings.Contains(name, " ") {
+			subcommands = append(subcommands, command)
+		}
+	}
+	return subcommands
+}
+
+// lastWord returns the name of the command within its parent command.
+func (c *completionCommand) lastWord() string {
+	return c.name[strings.LastIndex(c.name, " ")+1:]
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// writeShellCase writes a shell function printing the lines returned by
+// lines for the command given as its argument.
+func writeShellCase(w io.Writer, function string, commands []*completionCommand, lines func(*completionCommand) []string) {
+	fmt.Fprintf(w, "%s() {\n\tcase \"$1\" in\n", function)
+	for _, command := range commands {
+		l := lines(command)
+		if len(l) == 0 {
+			continue
+		}
+		quoted := make([]string, len(l))
+		for i, line := range l {
+			quoted[i] = shellQuote(line)
+		}
+		fmt.Fprintf(w, "\t\t%s)\n\t\t\tprintf '%%s\\n' %s\n\t\t\t;;\n", shellQuote(command.name), strings.Join(quoted, " "))
+	}
+	fmt.Fprint(w, "\tesac\n}\n\n")
+}
+
+// writeShellHelpers writes the functions used by both the bash and the zsh
+// completions.
+func writeShellHelpers(w io.Writer, commands []*completionCommand) {
+	writeShellCase(w, "__docker_subcommands", commands, func(c *completionCommand) []string {
+		var names []string
+		for _, subcommand := range c.subcommands(commands) {
+			names = append(names, subcommand.lastWord())
+		}
+		return names
+	})
+	writeShellCase(w, "__docker_value_options", commands, func(c *completionCommand) []string {
+		return c.optionNames(true)
+	})
+	writeShellCase(w, "__docker_arguments", commands, func(c *completionCommand) []string {
+		if kind := c.arguments(); kind != "" {
+			return []string{kind}
+		}
+		return nil
+	})
+	fmt.Fprint(w, shellListFunctions)
+}
+
+const shellListFunctions = `__docker_containers() {
+	docker ps -a --format '{{.Names}}' 2>/dev/null
+}
+
+__docker_images() {
+	docker images --format '{{.Repository}}:{{.Tag}}' 2>/dev/null | grep -v '<none>'
+}
+
+__docker_volumes() {
+	docker volume ls -q 2>/dev/null
+}
+
+`
+
+func writeBashCompletion(w io.Writer, commands []*completionCommand) {
+	fmt.Fprint(w, "# bash completion for docker, generated by `docker completion bash`\n\n")
+	writeShellHelpers(w, commands)
+	writeShellCase(w, "__docker_options", commands, func(c *completionCommand) []string {
+		return c.optionNames(false)
+	})
+	fmt.Fprint(w, bashCompletion)
+}
+
+const bashCompletion = `_docker() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+	local command="" word i=1
+	# the command is made of the words which aren't options, their values
+	# or the arguments of the command
+	while (( i < COMP_CWORD )); do
+		word="${COMP_WORDS[i]}"
+		case "$word" in
+			-*=*)
+				;;
+			-*)
+				if [[ " $(__docker_value_options "$command" | tr '\n' ' ') " == *" $word "* ]]; then
+					(( i++ ))
+				fi
+				;;
+			*)
+				if [[ -z "$command" || " $(__docker_subcommands "$command" | tr '\n' ' ') " == *" $word "* ]]; then
+					command="${command:+$command }$word"
+				fi
+				;;
+		esac
+		(( i++ ))
+	done
+
+	if [[ "$prev" == -* && " $(__docker_value_options "$command" | tr '\n' ' ') " == *" $prev "* ]]; then
+		COMPREPLY=( $(compgen -f -- "$cur") )
+		return
+	fi
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=( $(compgen -W "$(__docker_options "$command")" -- "$cur") )
+		return
+	fi
+	local subcommands="$(__docker_subcommands "$command")"
+	if [[ -n "$subcommands" ]]; then
+		COMPREPLY=( $(compgen -W "$subcommands" -- "$cur") )
+		return
+	fi
+	case "$(__docker_arguments "$command")" in
+		container)
+			COMPREPLY=( $(compgen -W "$(__docker_containers)" -- "$cur") )
+			;;
+		image)
+			COMPREPLY=( $(compgen -W "$(__docker_images)" -- "$cur") )
+			;;
+		volume)
+			COMPREPLY=( $(compgen -W "$(__docker_volumes)" -- "$cur") )
+			;;
+		*)
+			COMPREPLY=( $(compgen -f -- "$cur") )
+			;;
+	esac
+}
+
+complete -F _docker docker
+`
+
+func writeZshCompletion(w io.Writer, commands []*completionCommand) {
+	fmt.Fprint(w, "#compdef docker\n# zsh completion for docker, generated by `docker completion zsh`\n\n")
+	writeShellHelpers(w, commands)
+	writeShellCase(w, "__docker_option_descriptions", commands, func(c *completionCommand) []string {
+		var lines []string
+		for _, option := range c.options() {
+			for _, name := range option.names {
+				lines = append(lines, name+":"+option.usage)
+			}
+		}
+		return lines
+	})
+	writeShellCase(w, "__docker_subcommand_descriptions", commands, func(c *completionCommand) []string {
+		var lines []string
+		for _, subcommand := range c.subcommands(commands) {
+			lines = append(lines, subcommand.lastWord()+":"+subcommand.summary())
+		}
+		return lines
+	})
+	fmt.Fprint(w, zshCompletion)
+}
+
+const zshCompletion = `_docker() {
+	local command="" word i=2
+	# the command is made of the words which aren't options, their values
+	# or the arguments of the command
+	while (( i < CURRENT )); do
+		word="${words[i]}"
+		case "$word" in
+			-*=*)
+				;;
+			-*)
+				if [[ " $(__docker_value_options "$command" | tr '\n' ' ') " == *" $word "* ]]; then
+					(( i++ ))
+				fi
+				;;
+			*)
+				if [[ -z "$command" || " $(__docker_subcommands "$command" | tr '\n' ' ') " == *" $word "* ]]; then
+					command="${command:+$command }$word"
+				fi
+				;;
+		esac
+		(( i++ ))
+	done
+
+	local prev="${words[CURRENT-1]}"
+	if [[ "$prev" == -* && " $(__docker_value_options "$command" | tr '\n' ' ') " == *" $prev "* ]]; then
+		_files
+		return
+	fi
+	local -a descriptions
+	if [[ "$PREFIX" == -* ]]; then
+		descriptions=(${(f)"$(__docker_option_descriptions "$command")"})
+		_describe -t options 'option' descriptions
+		return
+	fi
+	descriptions=(${(f)"$(__docker_subcommand_descriptions "$command")"})
+	if (( ${#descriptions} )); then
+		_describe -t commands 'command' descriptions
+		return
+	fi
+	local -a names
+	case "$(__docker_arguments "$command")" in
+		container)
+			names=(${(f)"$(__docker_containers)"})
+			;;
+		image)
+			names=(${(f)"$(__docker_images)"})
+			;;
+		volume)
+			names=(${(f)"$(__docker_volumes)"})
+			;;
+		*)
+			_files
+			return
+			;;
+	esac
+	compadd -a names
+}
+
+if [[ "$funcstack[1]" == "_docker" ]]; then
+	_docker "$@"
+else
+	compdef _docker docker
+fi
+`
+
+// writeFishCase writes a fish function printing the lines returned by lines
+// for the command given as its argument.
+func writeFishCase(w io.Writer, function string, commands []*completionCommand, lines func(*completionCommand) []string) {
+	fmt.Fprintf(w, "function %s\n\tswitch \"$argv[1]\"\n", function)
+	for _, command := range commands {
+		l := lines(command)
+		if len(l) == 0 {
+			continue
+		}
+		quoted := make([]string, len(l))
+		for i, line := range l {
+			quoted[i] = fishQuote(line)
+		}
+		fmt.Fprintf(w, "\t\tcase %s\n\t\t\tprintf '%%s\\n' %s\n", fishQuote(command.name), strings.Join(quoted, " "))
+	}
+	fmt.Fprint(w, "\tend\nend\n\n")
+}
+
+func writeFishCompletion(w io.Writer, commands []*completionCommand) {
+	fmt.Fprint(w, "# fish completion for docker, generated by `docker completion fish`\n\n")
+	writeFishCase(w, "__fish_docker_subcommands", commands, func(c *completionCommand) []string {
+		var names []string
+		for _, subcommand := range c.subcommands(commands) {
+			names = append(names, subcommand.lastWord())
+		}
+		return names
+	})
+	writeFishCase(w, "__fish_docker_value_options", commands, func(c *completionCommand) []string {
+		return c.optionNames(true)
+	})
+	fmt.Fprint(w, fishFunctions)
+
+	for _, command := range commands {
+		condition := fishQuote(strings.TrimSpace("__fish_docker_command_is " + command.name))
+		for _, subcommand := range command.subcommands(commands) {
+			fmt.Fprintf(w, "complete -c docker -f -n %s -a %s -d %s\n", condition, fishQuote(subcommand.lastWord()), fishQuote(subcommand.summary()))
+		}
+		for _, option := range command.options() {
+			line := "complete -c docker -n " + condition
+			for _, name := range option.names {
+				switch {
+				case strings.HasPrefix(name, "--"):
+					line += " -l " + fishQuote(name[2:])
+				case len(name) == 2:
+					line += " -s " + fishQuote(name[1:])
+				default:
+					line += " -o " + fishQuote(name[1:])
+				}
+			}
+			if option.takesValue {
+				line += " -r"
+			}
+			fmt.Fprintf(w, "%s -d %s\n", line, fishQuote(option.usage))
+		}
+		if kind := command.arguments(); kind != "" {
+			fmt.Fprintf(w, "complete -c docker -f -n %s -a '(__fish_docker_%ss)'\n", condition, kind)
+		}
+	}
+}
+
+const fishFunctions = `function __fish_docker_command --description 'Print the docker command being completed'
+	set -l words (commandline -opc)
+	set -e words[1]
+	set -l command
+	set -l skip
+	# the command is made of the words which aren't options, their values
+	# or the arguments of the command
+	for word in $words
+		if set -q skip[1]
+			set -e skip
+			continue
+		end
+		switch $word
+			case '-*=*'
+			case '-*'
+				if contains -- $word (__fish_docker_value_options "$command")
+					set skip 1
+				end
+			case '*'
+				if test -z "$command"; or contains -- $word (__fish_docker_subcommands "$command")
+					set command $command $word
+				end
+		end
+	end
+	echo $command
+end
+
+function __fish_docker_command_is
+	set -l command (__fish_docker_command)
+	test "$command" = "$argv"
+end
+
+function __fish_docker_containers
+	docker ps -a --format '{{.Names}}' 2>/dev/null
+end
+
+function __fish_docker_images
+	docker images --format '{{.Repository}}:{{.Tag}}' 2>/dev/null | grep -v '<none>'
+end
+
+function __fish_docker_volumes
+	docker volume ls -q 2>/dev/null
+end
+
+`
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/units"
+)
+
+// CmdContainer is the parent subcommand for all container commands.
+//
+// Usage: docker container <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdContainer(args ...string) error {
+	description := "Manage Docker containers\n\nCommands:\n"
+	commands := [][]string{
+		{"prune", "Remove all stopped containers"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker container COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("container", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+const containerPruneWarning = "WARNING! This will remove all stopped containers.\nAre you sure you want to continue? [y/N] "
+
+// CmdContainerPrune removes all the stopped containers.
+//
+// Usage: docker container prune [OPTIONS]
+func (cli *DockerCli) CmdContainerPrune(args ...string) error {
+	cmd := cli.Subcmd("container prune", "", "Remove all stopped containers", true)
+	force := cmd.Bool([]string{"f", "-force"}, false, "Do not prompt for confirmation")
+
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"-filter"}, "Provide filter values (e.g. 'until=<timestamp>')")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	v, err := pruneFilterValues(flFilter.GetAll())
+	if err != nil {
+		return err
+	}
+	if !*force && !cli.confirmPrune(containerPruneWarning) {
+		return nil
+	}
+
+	report, err := cli.pruneContainers(v)
+	if err != nil {
+		return err
+	}
+	cli.printContainersPruneReport(report)
+	fmt.Fprintf(cli.out, "Total reclaimed space: %s\n", units.HumanSize(float64(report.SpaceReclaimed)))
+	return nil
+}
+
+func (cli *DockerCli) pruneContainers(v url.Values) (*types.ContainersPruneReport, error) {
+	body, _, err := readBody(cli.call("POST", "/containers/prune?"+v.Encode(), nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	var report types.ContainersPruneReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (cli *DockerCli) printContainersPruneReport(report *types.ContainersPruneReport) {
+	if len(report.ContainersDeleted) > 0 {
+		fmt.Fprintln(cli.out, "Deleted Containers:")
+		for _, id := range report.ContainersDeleted {
+			fmt.Fprintln(cli.out, id)
+		}
+		fmt.Fprintln(cli.out)
+	}
+}
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+var validContextName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.+-]*$`)
+
+// CmdContext is the parent subcommand for all context commands.
+//
+// Usage: docker context <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdContext(args ...string) error {
+	description := "Manage the contexts, the daemons the client connects to by name\n\nCommands:\n"
+	commands := [][]string{
+		{"create", "Create a context"},
+		{"ls", "List contexts"},
+		{"rm", "Remove one or more contexts"},
+		{"use", "Set the current context"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker context COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("context", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdContextCreate creates a context, connecting to a daemon with its TLS
+// configuration.
+//
+// Usage: docker context create [OPTIONS] NAME
+func (cli *DockerCli) CmdContextCreate(args ...string) error {
+	cmd := cli.Subcmd("context create", "NAME", "Create a context", true)
+	host := cmd.String([]string{"H", "-host"}, "", "Daemon socket to connect to")
+	description := cmd.String([]string{"-description"}, "", "Description of the context")
+	tls := cmd.Bool([]string{"-tls"}, false, "Use TLS; implied by --tlsverify")
+	tlsVerify := cmd.Bool([]string{"-tlsverify"}, false, "Use TLS and verify the remote")
+	caCert := cmd.String([]string{"-tlscacert"}, "", "Trust certs signed only by this CA")
+	cert := cmd.String([]string{"-tlscert"}, "", "Path to TLS certificate file")
+	key := cmd.String([]string{"-tlskey"}, "", "Path to TLS key file")
+	cmd.Require(flag.Exact, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	name := cmd.Arg(0)
+	if !validContextName.MatchString(name) || name == cliconfig.DefaultContext {
+		return fmt.Errorf("Invalid context name %s, only [a-zA-Z0-9][a-zA-Z0-9_.+-] are allowed, and %s is reserved", name, cliconfig.DefaultContext)
+	}
+	if _, exists := cli.configFile.Contexts[name]; exists {
+		return fmt.Errorf("Context %s already exists", name)
+	}
+	if *host == "" {
+		return fmt.Errorf("The daemon of the context is required, given by --host")
+	}
+	addr, err := opts.ValidateHost(*host)
+	if err != nil {
+		return err
+	}
+
+	ctx := cliconfig.Context{
+		Description: *description,
+		Host:        addr,
+		TLS:         *tls || *tlsVerify,
+		TLSVerify:   *tlsVerify,
+	}
+	// the files are found from any directory the client runs in
+	for _, f := range []struct {
+		path  string
+		field *string
+	}{
+		{*caCert, &ctx.TLSCACert},
+		{*cert, &ctx.TLSCert},
+		{*key, &ctx.TLSKey},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if *f.field, err = filepath.Abs(f.path); err != nil {
+			return err
+		}
+	}
+
+	if cli.configFile.Contexts == nil {
+		cli.configFile.Contexts = make(map[string]cliconfig.Context)
+	}
+	cli.configFile.Contexts[name] = ctx
+	if err := cli.configFile.Save(); err != nil {
+		return fmt.Errorf("Error saving the config file: %v", err)
+	}
+	fmt.Fprintf(cli.out, "%s\n", name)
+	return nil
+}
+
+// currentContext returns the name of the current context: the context of
+// DOCKER_CONTEXT, or the one set by docker context use.
+func (cli *DockerCli) currentContext() string {
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return name
+	}
+	if cli.configFile.CurrentContext != "" {
+		return cli.configFile.CurrentContext
+	}
+	return cliconfig.DefaultContext
+}
+
+// CmdContextLs lists the contexts, the current one marked with a star.
+//
+// Usage: docker context ls [OPTIONS]
+func (cli *DockerCli) CmdContextLs(args ...string) error {
+	cmd := cli.Subcmd("context ls", "", "List contexts", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display context names")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	current := cli.currentContext()
+	names := []string{cliconfig.DefaultContext}
+	for name := range cli.configFile.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintln(w, "NAME\tDESCRIPTION\tDOCKER ENDPOINT")
+	}
+	for _, name := range names {
+		if *quiet {
+			fmt.Fprintln(w, name)
+			continue
+		}
+		ctx := cli.configFile.Contexts[name]
+		if name == cliconfig.DefaultContext {
+			ctx.Description = "The daemon of DOCKER_HOST, or the local daemon"
+			ctx.Host = os.Getenv("DOCKER_HOST")
+			if ctx.Host == "" {
+				ctx.Host = "unix://" + opts.DefaultUnixSocket
+			}
+		}
+		if name == current {
+			name += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, ctx.Description, ctx.Host)
+	}
+	w.Flush()
+	return nil
+}
+
+// CmdContextRm removes one or more contexts.
+//
+// Usage: docker context rm [OPTIONS] NAME [NAME...]
+func (cli *DockerCli) CmdContextRm(args ...string) error {
+	cmd := cli.Subcmd("context rm", "NAME [NAME...]", "Remove one or more contexts", true)
+	force := cmd.Bool([]string{"f", "-force"}, false, "Remove the current context, switching to the default one")
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var errNames []string
+	for _, name := range cmd.Args() {
+		var err error
+		if _, exists := cli.configFile.Contexts[name]; !exists {
+			err = fmt.Errorf("No such context: %s", name)
+		} else if name == cli.configFile.CurrentContext && !*force {
+			err = fmt.Errorf("Cannot remove the current context %s, switch to another one or use --force", name)
+		}
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			errNames = append(errNames, name)
+			continue
+		}
+		delete(cli.configFile.Contexts, name)
+		if name == cli.configFile.CurrentContext {
+			cli.configFile.CurrentContext = ""
+		}
+		fmt.Fprintf(cli.out, "%s\n", name)
+	}
+	if err := cli.configFile.Save(); err != nil {
+		return fmt.Errorf("Error saving the config file: %v", err)
+	}
+	if len(errNames) > 0 {
+		return fmt.Errorf("Error: failed to remove contexts: %v", errNames)
+	}
+	return nil
+}
+
+// CmdContextUse sets the current context, used by the commands given no -H,
+// no --context, no DOCKER_HOST and no DOCKER_CONTEXT.
+//
+// Usage: docker context use NAME
+func (cli *DockerCli) CmdContextUse(args ...string) error {
+	cmd := cli.Subcmd("context use", "NAME", "Set the current context", true)
+	cmd.Require(flag.Exact, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	name := cmd.Arg(0)
+	if name == cliconfig.DefaultContext {
+		cli.configFile.CurrentContext = ""
+	} else if _, exists := cli.configFile.Contexts[name]; exists {
+		cli.configFile.CurrentContext = name
+	} else {
+		return fmt.Errorf("No such context: %s", name)
+	}
+	if err := cli.configFile.Save(); err != nil {
+		return fmt.Errorf("Error saving the config file: %v", err)
+	}
+	fmt.Fprintf(cli.out, "%s\n", name)
+	if os.Getenv("DOCKER_HOST") != "" || os.Getenv("DOCKER_CONTEXT") != "" {
+		fmt.Fprintf(cli.err, "WARNING: DOCKER_HOST or DOCKER_CONTEXT is set, and overrides the current context\n")
+	}
+	return nil
+}
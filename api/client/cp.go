@@ -1,8 +1,14 @@
 package client
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
 	"strings"
 
 	"github.com/docker/docker/api/types"
@@ -10,48 +16,203 @@ import (
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
-// CmdCp copies files/folders from a path on the container to a directory on the host running the command.
+// CmdCp copies files/folders between a container and the local filesystem.
 //
-// If HOSTDIR is '-', the data is written as a tar file to STDOUT.
+// If the destination is '-', the data is written as a tar file to STDOUT.
+// If the source is '-', a tar file read from STDIN is extracted in the
+// directory of the container.
 //
-// Usage: docker cp CONTAINER:PATH HOSTDIR
+// Usage: docker cp [OPTIONS] CONTAINER:SRC_PATH DEST_PATH|-
+//        docker cp [OPTIONS] SRC_PATH|- CONTAINER:DEST_PATH
 func (cli *DockerCli) CmdCp(args ...string) error {
-	cmd := cli.Subcmd("cp", "CONTAINER:PATH HOSTDIR|-", "Copy files/folders from a PATH on the container to a HOSTDIR on the host\nrunning the command. Use '-' to write the data as a tar file to STDOUT.", true)
+	cmd := cli.Subcmd("cp", "CONTAINER:SRC_PATH DEST_PATH|-\n\tdocker cp [OPTIONS] SRC_PATH|- CONTAINER:DEST_PATH", "Copy files/folders between a container and the local filesystem.\nUse '-' as the source to read a tar archive from STDIN and extract it to a\ndirectory destination in the container. Use '-' as the destination to write\na tar archive of the container source to STDOUT.", true)
+	copyUIDGID := cmd.Bool([]string{"a", "-archive"}, false, "Archive mode (copy all uid/gid information)")
 	cmd.Require(flag.Exact, 2)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	srcContainer, srcPath := splitCpArg(cmd.Arg(0))
+	dstContainer, dstPath := splitCpArg(cmd.Arg(1))
+
+	switch {
+	case srcPath == "" || dstPath == "":
+		return errors.New("Error: Path not specified")
+	case srcContainer != "" && dstContainer != "":
+		return errors.New("Error: copying between containers is not supported")
+	case srcContainer != "":
+		return cli.copyFromContainer(srcContainer, srcPath, dstPath, *copyUIDGID)
+	case dstContainer != "":
+		return cli.copyToContainer(srcPath, dstContainer, dstPath, *copyUIDGID)
+	}
+	return errors.New("Error: must specify at least one container source")
+}
 
-	// deal with path name with `:`
-	info := strings.SplitN(cmd.Arg(0), ":", 2)
+// splitCpArg splits an argument of cp into a container and a path, or
+// returns only the path of a local argument. A local path with a colon can
+// be given as an absolute path, or as a relative path starting with ".".
+func splitCpArg(arg string) (container, path string) {
+	if filepath.IsAbs(arg) || strings.HasPrefix(arg, ".") {
+		return "", arg
+	}
+	parts := strings.SplitN(arg, ":", 2)
+	if len(parts) == 1 {
+		return "", arg
+	}
+	return parts[0], parts[1]
+}
 
-	if len(info) != 2 {
-		return fmt.Errorf("Error: Path not specified")
+// statContainerPath returns the stat of the path in the container, or nil
+// if it doesn't exist.
+func (cli *DockerCli) statContainerPath(container, path string) (*types.ContainerPathStat, error) {
+	v := url.Values{}
+	v.Set("path", path)
+	resp, statusCode, err := cli.clientResponse("HEAD", "/containers/"+container+"/archive?"+v.Encode(), nil, nil)
+	if statusCode == http.StatusNotFound {
+		// the container is checked by the copy itself
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	resp.Body.Close()
+	return getContainerPathStat(resp.Header)
+}
 
+func getContainerPathStat(header http.Header) (*types.ContainerPathStat, error) {
+	statJSON, err := base64.StdEncoding.DecodeString(header.Get("X-Docker-Container-Path-Stat"))
+	if err != nil {
+		return nil, err
+	}
+	var stat types.ContainerPathStat
+	if err := json.Unmarshal(statJSON, &stat); err != nil {
+		return nil, fmt.Errorf("unable to decode the stat of the path: %v", err)
+	}
+	return &stat, nil
+}
+
+func (cli *DockerCli) copyFromContainer(container, srcPath, dstPath string, copyUIDGID bool) error {
+	if cli.apiVersion().LessThan("1.19") {
+		return cli.copyFromContainerLegacy(container, srcPath, dstPath)
+	}
+
+	v := url.Values{}
+	v.Set("path", srcPath)
+	resp, _, err := cli.clientResponse("GET", "/containers/"+container+"/archive?"+v.Encode(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if dstPath == "-" {
+		_, err := io.Copy(cli.out, resp.Body)
+		return err
+	}
+
+	stat, err := getContainerPathStat(resp.Header)
+	if err != nil {
+		return err
+	}
+	srcInfo := archive.CopyInfo{Path: srcPath, Exists: true, IsDir: stat.Mode.IsDir()}
+	dstInfo, err := archive.CopyInfoDestinationPath(dstPath)
+	if err != nil {
+		return err
+	}
+
+	dstDir, content, err := archive.PrepareArchiveCopy(resp.Body, srcInfo, dstInfo)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return archive.Untar(content, dstDir, &archive.TarOptions{
+		NoLchown:             !copyUIDGID,
+		NoOverwriteDirNonDir: true,
+	})
+}
+
+// copyFromContainerLegacy copies the path of the container into the local
+// directory, creating it as needed, with a daemon without the archive API.
+func (cli *DockerCli) copyFromContainerLegacy(container, srcPath, dstPath string) error {
 	cfg := &types.CopyConfig{
-		Resource: info[1],
+		Resource: srcPath,
 	}
-	stream, statusCode, err := cli.call("POST", "/containers/"+info[0]+"/copy", cfg, nil)
+	stream, statusCode, err := cli.call("POST", "/containers/"+container+"/copy", cfg, nil)
 	if stream != nil {
 		defer stream.Close()
 	}
-	if statusCode == 404 {
-		return fmt.Errorf("No such container: %v", info[0])
+	if statusCode == http.StatusNotFound {
+		return fmt.Errorf("No such container: %v", container)
 	}
 	if err != nil {
 		return err
 	}
 
-	hostPath := cmd.Arg(1)
-	if statusCode == 200 {
-		if hostPath == "-" {
-			_, err = io.Copy(cli.out, stream)
-		} else {
-			err = archive.Untar(stream, hostPath, &archive.TarOptions{NoLchown: true})
+	if dstPath == "-" {
+		_, err = io.Copy(cli.out, stream)
+		return err
+	}
+	return archive.Untar(stream, dstPath, &archive.TarOptions{NoLchown: true})
+}
+
+func (cli *DockerCli) copyToContainer(srcPath, container, dstPath string, copyUIDGID bool) error {
+	if cli.apiVersion().LessThan("1.19") {
+		return errors.New("Error: copying to a container is not supported by the daemon")
+	}
+
+	var (
+		content io.Reader
+		dstDir  = dstPath
+	)
+	if srcPath == "-" {
+		// the archive is extracted as is in the directory
+		content = cli.in
+	} else {
+		srcInfo, err := archive.CopyInfoSourcePath(srcPath)
+		if err != nil {
+			return err
+		}
+
+		srcArchive, err := archive.TarResource(srcPath)
+		if err != nil {
+			return err
 		}
+		defer srcArchive.Close()
+
+		dstInfo := archive.CopyInfo{Path: dstPath}
+		dstStat, err := cli.statContainerPath(container, dstPath)
 		if err != nil {
 			return err
 		}
+		if dstStat != nil {
+			if dstStat.LinkTarget != "" {
+				// copy to the target of the symlink, rather than over it
+				dstInfo.Path = archive.PreserveTrailingDotOrSeparator(dstStat.LinkTarget, dstPath)
+			}
+			dstInfo.Exists = true
+			dstInfo.IsDir = dstStat.Mode.IsDir()
+		}
+
+		var preparedArchive io.ReadCloser
+		if dstDir, preparedArchive, err = archive.PrepareArchiveCopy(srcArchive, srcInfo, dstInfo); err != nil {
+			return err
+		}
+		defer preparedArchive.Close()
+		content = preparedArchive
+	}
+
+	v := url.Values{}
+	v.Set("path", dstDir)
+	v.Set("noOverwriteDirNonDir", "1")
+	if copyUIDGID {
+		v.Set("copyUIDGID", "1")
+	}
+	resp, _, _, err := cli.clientRequest("PUT", "/containers/"+container+"/archive?"+v.Encode(), content, map[string][]string{
+		"Content-Type": {"application/x-tar"},
+	})
+	if err != nil {
+		return err
 	}
-	return nil
+	return resp.Close()
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/graph/tags"
+	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
@@ -18,10 +19,10 @@ import (
 )
 
 func (cli *DockerCli) pullImage(image string) error {
-	return cli.pullImageCustomOut(image, cli.out)
+	return cli.pullImageCustomOut(image, "", cli.out)
 }
 
-func (cli *DockerCli) pullImageCustomOut(image string, out io.Writer) error {
+func (cli *DockerCli) pullImageCustomOut(image, platform string, out io.Writer) error {
 	v := url.Values{}
 	repos, tag := parsers.ParseRepositoryTag(image)
 	// pull only the image tagged 'latest' if no tag was specified
@@ -30,6 +31,9 @@ func (cli *DockerCli) pullImageCustomOut(image string, out io.Writer) error {
 	}
 	v.Set("fromImage", repos)
 	v.Set("tag", tag)
+	if platform != "" {
+		v.Set("platform", platform)
+	}
 
 	// Resolve the Repository name from fqn to RepositoryInfo
 	repoInfo, err := registry.ParseRepositoryInfo(repos)
@@ -77,11 +81,14 @@ func newCIDFile(path string) (*cidFile, error) {
 	return &cidFile{path: path, file: f}, nil
 }
 
-func (cli *DockerCli) createContainer(config *runconfig.Config, hostConfig *runconfig.HostConfig, cidfile, name string) (*types.ContainerCreateResponse, error) {
+func (cli *DockerCli) createContainer(config *runconfig.Config, hostConfig *runconfig.HostConfig, cidfile, name, platform string) (*types.ContainerCreateResponse, error) {
 	containerValues := url.Values{}
 	if name != "" {
 		containerValues.Set("name", name)
 	}
+	if platform != "" {
+		containerValues.Set("platform", platform)
+	}
 
 	mergedConfig := runconfig.MergeConfigs(config, hostConfig)
 
@@ -96,7 +103,7 @@ func (cli *DockerCli) createContainer(config *runconfig.Config, hostConfig *runc
 
 	//create the container
 	stream, statusCode, err := cli.call("POST", "/containers/create?"+containerValues.Encode(), mergedConfig, nil)
-	//if image not found try to pull it
+	//if image not found, or not of the platform, try to pull it
 	if statusCode == 404 && strings.Contains(err.Error(), config.Image) {
 		repo, tag := parsers.ParseRepositoryTag(config.Image)
 		if tag == "" {
@@ -105,7 +112,7 @@ func (cli *DockerCli) createContainer(config *runconfig.Config, hostConfig *runc
 		fmt.Fprintf(cli.err, "Unable to find image '%s' locally\n", utils.ImageReference(repo, tag))
 
 		// we don't want to write to stdout anything apart from container.ID
-		if err = cli.pullImageCustomOut(config.Image, cli.err); err != nil {
+		if err = cli.pullImageCustomOut(config.Image, platform, cli.err); err != nil {
 			return nil, err
 		}
 		// Retry
@@ -139,10 +146,14 @@ func (cli *DockerCli) CmdCreate(args ...string) error {
 
 	// These are flags not stored in Config/HostConfig
 	var (
-		flName = cmd.String([]string{"-name"}, "", "Assign a name to the container")
+		flName     = cmd.String([]string{"-name"}, "", "Assign a name to the container")
+		flPlatform = cmd.String([]string{"-platform"}, "", "Set the platform of the image, such as linux/arm64")
 	)
 
 	config, hostConfig, cmd, err := runconfig.Parse(cmd, args)
+	if err == flag.ErrHelp {
+		return err
+	}
 	if err != nil {
 		cmd.ReportError(err.Error(), true)
 	}
@@ -150,7 +161,7 @@ func (cli *DockerCli) CmdCreate(args ...string) error {
 		cmd.Usage()
 		return nil
 	}
-	response, err := cli.createContainer(config, hostConfig, hostConfig.ContainerIDFile, *flName)
+	response, err := cli.createContainer(config, hostConfig, hostConfig.ContainerIDFile, *flName, *flPlatform)
 	if err != nil {
 		return err
 	}
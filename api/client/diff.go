@@ -19,7 +19,9 @@ import (
 func (cli *DockerCli) CmdDiff(args ...string) error {
 	cmd := cli.Subcmd("diff", "CONTAINER", "Inspect changes on a container's filesystem", true)
 	cmd.Require(flag.Exact, 1)
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	if cmd.Arg(0) == "" {
 		return fmt.Errorf("Container name cannot be empty")
@@ -19,7 +19,7 @@ func (cli *DockerCli) CmdExec(args ...string) error {
 
 	execConfig, err := runconfig.ParseExec(cmd, args)
 	// just in case the ParseExec does not exit
-	if execConfig.Container == "" || err != nil {
+	if err != nil || execConfig.Container == "" {
 		return StatusError{StatusCode: 1}
 	}
 
@@ -18,7 +18,9 @@ func (cli *DockerCli) CmdExport(args ...string) error {
 	outfile := cmd.String([]string{"o", "-output"}, "", "Write to a file, instead of STDOUT")
 	cmd.Require(flag.Exact, 1)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	var (
 		output io.Writer = cli.out
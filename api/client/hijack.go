@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bufio"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -14,7 +15,6 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
-	"github.com/docker/docker/api"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/stdcopy"
@@ -120,6 +120,9 @@ func tlsDialWithDialer(dialer *net.Dialer, network, addr string, config *tls.Con
 }
 
 func (cli *DockerCli) dial() (net.Conn, error) {
+	if cli.proto == "ssh" {
+		return sshDialer(cli.addr)("", "")
+	}
 	if cli.tlsConfig != nil && cli.proto != "unix" {
 		// Notice this isn't Go standard's tls.Dial function
 		return tlsDial(cli.proto, cli.addr, cli.tlsConfig)
@@ -127,20 +130,17 @@ func (cli *DockerCli) dial() (net.Conn, error) {
 	return net.Dial(cli.proto, cli.addr)
 }
 
-func (cli *DockerCli) hijack(method, path string, setRawTerminal bool, in io.ReadCloser, stdout, stderr io.Writer, started chan io.Closer, data interface{}) error {
-	defer func() {
-		if started != nil {
-			close(started)
-		}
-	}()
-
+// hijackConn makes the request and hijacks its connection. It returns the
+// connection, a reader with the data the server sent after its response and
+// the response itself, which may be nil.
+func (cli *DockerCli) hijackConn(method, path string, data interface{}) (net.Conn, *bufio.Reader, *http.Response, error) {
 	params, err := cli.encodeData(data)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
-	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", api.APIVERSION, path), params)
+	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", cli.apiVersion(), path), params)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// Add CLI Config's HTTP Headers BEFORE we set the Docker headers
@@ -167,17 +167,31 @@ func (cli *DockerCli) hijack(method, path string, setRawTerminal bool, in io.Rea
 	}
 	if err != nil {
 		if strings.Contains(err.Error(), "connection refused") {
-			return fmt.Errorf("Cannot connect to the Docker daemon. Is 'docker -d' running on this host?")
+			return nil, nil, nil, fmt.Errorf("Cannot connect to the Docker daemon. Is 'docker -d' running on this host?")
 		}
-		return err
+		return nil, nil, nil, err
 	}
 	clientconn := httputil.NewClientConn(dial, nil)
 	defer clientconn.Close()
 
 	// Server hijacks the connection, error 'connection closed' expected
-	clientconn.Do(req)
+	resp, _ := clientconn.Do(req)
 
 	rwc, br := clientconn.Hijack()
+	return rwc, br, resp, nil
+}
+
+func (cli *DockerCli) hijack(method, path string, setRawTerminal bool, in io.ReadCloser, stdout, stderr io.Writer, started chan io.Closer, data interface{}) error {
+	defer func() {
+		if started != nil {
+			close(started)
+		}
+	}()
+
+	rwc, br, _, err := cli.hijackConn(method, path, data)
+	if err != nil {
+		return err
+	}
 	defer rwc.Close()
 
 	if started != nil {
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/units"
+)
+
+// CmdImage is the parent subcommand for all image commands.
+//
+// Usage: docker image <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdImage(args ...string) error {
+	description := "Manage Docker images\n\nCommands:\n"
+	commands := [][]string{
+		{"prune", "Remove unused images"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker image COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("image", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+const (
+	imagePruneWarning    = "WARNING! This will remove all dangling images.\nAre you sure you want to continue? [y/N] "
+	imagePruneAllWarning = "WARNING! This will remove all images without at least one container associated to them.\nAre you sure you want to continue? [y/N] "
+)
+
+// CmdImagePrune removes the untagged images used by no container, or all
+// the images used by no container with --all.
+//
+// Usage: docker image prune [OPTIONS]
+func (cli *DockerCli) CmdImagePrune(args ...string) error {
+	cmd := cli.Subcmd("image prune", "", "Remove unused images", true)
+	all := cmd.Bool([]string{"a", "-all"}, false, "Remove all unused images, not just dangling ones")
+	force := cmd.Bool([]string{"f", "-force"}, false, "Do not prompt for confirmation")
+
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"-filter"}, "Provide filter values (e.g. 'until=<timestamp>')")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	v, err := pruneFilterValues(imagePruneFilters(flFilter.GetAll(), *all))
+	if err != nil {
+		return err
+	}
+	warning := imagePruneWarning
+	if *all {
+		warning = imagePruneAllWarning
+	}
+	if !*force && !cli.confirmPrune(warning) {
+		return nil
+	}
+
+	report, err := cli.pruneImages(v)
+	if err != nil {
+		return err
+	}
+	cli.printImagesPruneReport(report)
+	fmt.Fprintf(cli.out, "Total reclaimed space: %s\n", units.HumanSize(float64(report.SpaceReclaimed)))
+	return nil
+}
+
+// imagePruneFilters adds the filter dangling=false to the filters of an
+// image prune removing all the unused images.
+func imagePruneFilters(filters []string, all bool) []string {
+	if all {
+		return append(filters, "dangling=false")
+	}
+	return filters
+}
+
+func (cli *DockerCli) pruneImages(v url.Values) (*types.ImagesPruneReport, error) {
+	body, _, err := readBody(cli.call("POST", "/images/prune?"+v.Encode(), nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	var report types.ImagesPruneReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (cli *DockerCli) printImagesPruneReport(report *types.ImagesPruneReport) {
+	if len(report.ImagesDeleted) > 0 {
+		fmt.Fprintln(cli.out, "Deleted Images:")
+		for _, del := range report.ImagesDeleted {
+			if del.Deleted != "" {
+				fmt.Fprintf(cli.out, "Deleted: %s\n", del.Deleted)
+			} else {
+				fmt.Fprintf(cli.out, "Untagged: %s\n", del.Untagged)
+			}
+		}
+		fmt.Fprintln(cli.out)
+	}
+}
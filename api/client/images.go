@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -17,6 +18,26 @@ import (
 	"github.com/docker/docker/utils"
 )
 
+// imageContext is the image given to the template of the --format flag of
+// images.
+type imageContext struct {
+	ID           string
+	Repository   string
+	Tag          string
+	Digest       string
+	CreatedSince string
+	CreatedAt    string
+	Size         string
+	Labels       string
+
+	labels map[string]string
+}
+
+// Label returns the value of the label of the image, if any.
+func (c *imageContext) Label(name string) string {
+	return c.labels[name]
+}
+
 // CmdImages lists the images in a specified repository, or all top-level images if no repository is specified.
 //
 // Usage: docker images [OPTIONS] [REPOSITORY]
@@ -26,11 +47,23 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 	all := cmd.Bool([]string{"a", "-all"}, false, "Show all images (default hides intermediate images)")
 	noTrunc := cmd.Bool([]string{"#notrunc", "-no-trunc"}, false, "Don't truncate output")
 	showDigests := cmd.Bool([]string{"-digests"}, false, "Show digests")
+	format := cmd.String([]string{"-format"}, "", "Pretty-print images using a Go template")
 
 	flFilter := opts.NewListOpts(nil)
 	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
 	cmd.Require(flag.Max, 1)
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if *format != "" && !*quiet {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
 
 	// Consolidate all filter flags, and sanity check them early.
 	// They'll get process in the daemon/server.
@@ -72,7 +105,7 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 	}
 
 	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
-	if !*quiet {
+	if !*quiet && tmpl == nil {
 		if *showDigests {
 			fmt.Fprintln(w, "REPOSITORY\tTAG\tDIGEST\tIMAGE ID\tCREATED\tVIRTUAL SIZE")
 		} else {
@@ -107,14 +140,29 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 				tag = ref
 			}
 
-			if !*quiet {
-				if *showDigests {
-					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s\n", repo, tag, digest, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
-				} else {
-					fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\n", repo, tag, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
-				}
-			} else {
+			created := time.Unix(int64(image.Created), 0)
+			switch {
+			case *quiet:
 				fmt.Fprintln(w, ID)
+			case tmpl != nil:
+				if err := tmpl.Execute(cli.out, &imageContext{
+					ID:           ID,
+					Repository:   repo,
+					Tag:          tag,
+					Digest:       digest,
+					CreatedSince: units.HumanDuration(time.Now().UTC().Sub(created)),
+					CreatedAt:    created.String(),
+					Size:         units.HumanSize(float64(image.VirtualSize)),
+					Labels:       joinLabels(image.Labels),
+					labels:       image.Labels,
+				}); err != nil {
+					return err
+				}
+				cli.out.Write([]byte{'\n'})
+			case *showDigests:
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s\n", repo, tag, digest, ID, units.HumanDuration(time.Now().UTC().Sub(created)), units.HumanSize(float64(image.VirtualSize)))
+			default:
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\n", repo, tag, ID, units.HumanDuration(time.Now().UTC().Sub(created)), units.HumanSize(float64(image.VirtualSize)))
 			}
 		}
 	}
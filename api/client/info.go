@@ -3,6 +3,8 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -15,7 +17,9 @@ import (
 func (cli *DockerCli) CmdInfo(args ...string) error {
 	cmd := cli.Subcmd("info", "", "Display system-wide information", true)
 	cmd.Require(flag.Exact, 0)
-	cmd.ParseFlags(args, false)
+	if err := cmd.ParseFlags(args, false); err != nil {
+		return err
+	}
 
 	rdr, _, err := cli.call("GET", "/info", nil, nil)
 	if err != nil {
@@ -37,6 +41,43 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 	}
 	fmt.Fprintf(cli.out, "Execution Driver: %s\n", info.ExecutionDriver)
 	fmt.Fprintf(cli.out, "Logging Driver: %s\n", info.LoggingDriver)
+	if info.SecurityOptions != nil {
+		fmt.Fprintf(cli.out, "Security Options: %s\n", strings.Join(info.SecurityOptions, " "))
+	}
+	if len(info.DefaultCapAdd) > 0 {
+		fmt.Fprintf(cli.out, "Default Capabilities Added: %s\n", strings.Join(info.DefaultCapAdd, " "))
+	}
+	if len(info.DefaultCapDrop) > 0 {
+		fmt.Fprintf(cli.out, "Default Capabilities Dropped: %s\n", strings.Join(info.DefaultCapDrop, " "))
+	}
+	if len(info.Runtimes) > 0 {
+		names := make([]string, 0, len(info.Runtimes))
+		for name := range info.Runtimes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(cli.out, "Runtimes:")
+		for _, name := range names {
+			r := info.Runtimes[name]
+			fmt.Fprintf(cli.out, " %s: %s\n", name, strings.Join(append([]string{r.Path}, r.Args...), " "))
+			if len(r.Env) > 0 {
+				fmt.Fprintf(cli.out, "  Environment: %s\n", strings.Join(r.Env, " "))
+			}
+		}
+	}
+	if info.Experimental {
+		fmt.Fprintln(cli.out, "Experimental: true")
+	}
+	if len(info.ExperimentalFeatures) > 0 {
+		fmt.Fprintln(cli.out, "Experimental Features:")
+		for _, f := range info.ExperimentalFeatures {
+			state := "disabled"
+			if f.Enabled {
+				state = "enabled"
+			}
+			fmt.Fprintf(cli.out, " %s (%s): %s\n", f.Name, state, f.Description)
+		}
+	}
 	fmt.Fprintf(cli.out, "Kernel Version: %s\n", info.KernelVersion)
 	fmt.Fprintf(cli.out, "Operating System: %s\n", info.OperatingSystem)
 	fmt.Fprintf(cli.out, "CPUs: %d\n", info.NCPU)
@@ -64,10 +105,21 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 	if info.NoProxy != "" {
 		fmt.Fprintf(cli.out, "No Proxy: %s\n", info.NoProxy)
 	}
+	if len(info.RegistryProxies) > 0 {
+		names := make([]string, 0, len(info.RegistryProxies))
+		for name := range info.RegistryProxies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(cli.out, "Registry Proxies:")
+		for _, name := range names {
+			fmt.Fprintf(cli.out, " %s: %s\n", name, info.RegistryProxies[name])
+		}
+	}
 
 	if info.IndexServerAddress != "" {
-		u := cli.configFile.AuthConfigs[info.IndexServerAddress].Username
-		if len(u) > 0 {
+		authConfig, _ := cli.configFile.GetAuthConfig(info.IndexServerAddress)
+		if u := authConfig.Username; len(u) > 0 {
 			fmt.Fprintf(cli.out, "Username: %v\n", u)
 			fmt.Fprintf(cli.out, "Registry: %v\n", info.IndexServerAddress)
 		}
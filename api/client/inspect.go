@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"text/template"
 
@@ -12,15 +13,38 @@ import (
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
-// CmdInspect displays low-level information on one or more containers or images.
+// inspectTypes are the types of the objects which can be inspected, in the
+// order they are looked up without --type.
+var inspectTypes = []string{"container", "image", "volume"}
+
+// CmdInspect displays low-level information on one or more containers, images or volumes.
+//
+// The objects are output in the order of the arguments. The objects which
+// can't be found or formatted don't stop the others from being output, their
+// errors are reported together at the end.
 //
-// Usage: docker inspect [OPTIONS] CONTAINER|IMAGE [CONTAINER|IMAGE...]
+// Usage: docker inspect [OPTIONS] CONTAINER|IMAGE|VOLUME [CONTAINER|IMAGE|VOLUME...]
 func (cli *DockerCli) CmdInspect(args ...string) error {
-	cmd := cli.Subcmd("inspect", "CONTAINER|IMAGE [CONTAINER|IMAGE...]", "Return low-level information on a container or image", true)
+	cmd := cli.Subcmd("inspect", "CONTAINER|IMAGE|VOLUME [CONTAINER|IMAGE|VOLUME...]", "Return low-level information on a container, image or volume", true)
 	tmplStr := cmd.String([]string{"f", "#format", "-format"}, "", "Format the output using the given go template")
+	inspectType := cmd.String([]string{"-type"}, "", "Return the information of an object of the given type: container, image or volume")
 	cmd.Require(flag.Min, 1)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	objTypes := inspectTypes
+	if *inspectType != "" {
+		valid := false
+		for _, t := range inspectTypes {
+			valid = valid || t == *inspectType
+		}
+		if !valid {
+			return fmt.Errorf("%q is not a valid value for --type", *inspectType)
+		}
+		objTypes = []string{*inspectType}
+	}
 
 	var tmpl *template.Template
 	if *tmplStr != "" {
@@ -33,89 +57,96 @@ func (cli *DockerCli) CmdInspect(args ...string) error {
 
 	indented := new(bytes.Buffer)
 	indented.WriteString("[\n")
-	status := 0
-	isImage := false
+	var errs []string
 
 	for _, name := range cmd.Args() {
-		obj, _, err := readBody(cli.call("GET", "/containers/"+name+"/json", nil, nil))
+		obj, value, err := cli.inspectObject(name, objTypes)
 		if err != nil {
-			obj, _, err = readBody(cli.call("GET", "/images/"+name+"/json", nil, nil))
-			isImage = true
-			if err != nil {
-				if strings.Contains(err.Error(), "No such") {
-					fmt.Fprintf(cli.err, "Error: No such image or container: %s\n", name)
-				} else {
-					fmt.Fprintf(cli.err, "%s", err)
-				}
-				status = 1
-				continue
-			}
+			errs = append(errs, err.Error())
+			continue
 		}
 
 		if tmpl == nil {
 			if err = json.Indent(indented, obj, "", "    "); err != nil {
-				fmt.Fprintf(cli.err, "%s\n", err)
-				status = 1
+				errs = append(errs, err.Error())
 				continue
 			}
-		} else {
-			rdr := bytes.NewReader(obj)
-			dec := json.NewDecoder(rdr)
+			indented.WriteString(",")
+			continue
+		}
 
-			if isImage {
-				inspPtr := types.ImageInspect{}
-				if err := dec.Decode(&inspPtr); err != nil {
-					fmt.Fprintf(cli.err, "%s\n", err)
-					status = 1
-					continue
-				}
-				if err := tmpl.Execute(cli.out, inspPtr); err != nil {
-					rdr.Seek(0, 0)
-					var raw interface{}
-					if err := dec.Decode(&raw); err != nil {
-						return err
-					}
-					if err = tmpl.Execute(cli.out, raw); err != nil {
-						return err
-					}
-				}
-			} else {
-				inspPtr := types.ContainerJSON{}
-				if err := dec.Decode(&inspPtr); err != nil {
-					fmt.Fprintf(cli.err, "%s\n", err)
-					status = 1
-					continue
-				}
-				if err := tmpl.Execute(cli.out, inspPtr); err != nil {
-					rdr.Seek(0, 0)
-					var raw interface{}
-					if err := dec.Decode(&raw); err != nil {
-						return err
-					}
-					if err = tmpl.Execute(cli.out, raw); err != nil {
-						return err
-					}
-				}
+		// the output of an object is written once it's fully formatted
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, value); err != nil {
+			// the template may use fields unknown to the types of the
+			// client, which are in the raw object
+			var raw interface{}
+			if err := json.Unmarshal(obj, &raw); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			out.Reset()
+			if err := tmpl.Execute(&out, raw); err != nil {
+				errs = append(errs, fmt.Sprintf("Template execution error for %s: %v", name, err))
+				continue
 			}
-			cli.out.Write([]byte{'\n'})
 		}
-		indented.WriteString(",")
-	}
-
-	if indented.Len() > 1 {
-		// Remove trailing ','
-		indented.Truncate(indented.Len() - 1)
+		out.WriteByte('\n')
+		if _, err := out.WriteTo(cli.out); err != nil {
+			return err
+		}
 	}
-	indented.WriteString("]\n")
 
 	if tmpl == nil {
+		if indented.Len() > 2 {
+			// Remove trailing ','
+			indented.Truncate(indented.Len() - 1)
+		}
+		indented.WriteString("]\n")
 		if _, err := io.Copy(cli.out, indented); err != nil {
 			return err
 		}
 	}
 
-	if status != 0 {
-		return StatusError{StatusCode: status}
+	if len(errs) > 0 {
+		return StatusError{StatusCode: 1, Status: strings.Join(errs, "\n")}
 	}
 	return nil
 }
+
+// inspectObject returns the raw object named name, and the object decoded
+// in its type for the templates. The object is looked up with each of the
+// types in order, until one is found.
+func (cli *DockerCli) inspectObject(name string, objTypes []string) ([]byte, interface{}, error) {
+	for _, objType := range objTypes {
+		var (
+			path  string
+			value interface{}
+		)
+		switch objType {
+		case "container":
+			path, value = "/containers/"+name+"/json", &types.ContainerJSON{}
+		case "image":
+			path, value = "/images/"+name+"/json", &types.ImageInspect{}
+		case "volume":
+			path, value = "/volumes/"+name, &types.Volume{}
+		}
+		obj, statusCode, err := readBody(cli.call("GET", path, nil, nil))
+		if statusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(obj, value); err != nil {
+			return nil, nil, err
+		}
+		return obj, value, nil
+	}
+
+	kinds := strings.Join(objTypes, ", ")
+	if len(objTypes) > 1 {
+		kinds = strings.Join(objTypes[:len(objTypes)-1], ", ") + " or " + objTypes[len(objTypes)-1]
+	}
+	return nil, nil, fmt.Errorf("Error: No such %s: %s", kinds, name)
+}
@@ -1,23 +1,45 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"text/template"
 
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
 // CmdLoad loads an image from a tar archive.
 //
 // The tar archive is read from STDIN by default, or from a tar archive file.
+// The progress of the layers is printed while they load, then the loaded
+// images.
 //
 // Usage: docker load [OPTIONS]
 func (cli *DockerCli) CmdLoad(args ...string) error {
 	cmd := cli.Subcmd("load", "", "Load an image from a tar archive on STDIN", true)
 	infile := cmd.String([]string{"i", "-input"}, "", "Read from a tar archive file, instead of STDIN")
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Suppress the load progress, only print the loaded images")
+	format := cmd.String([]string{"-format"}, "", "Print the loaded images using a Go template")
 	cmd.Require(flag.Exact, 0)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if *format != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
 
 	var (
 		input io.Reader = cli.in
@@ -29,13 +51,45 @@ func (cli *DockerCli) CmdLoad(args ...string) error {
 			return err
 		}
 	}
-	sopts := &streamOpts{
-		rawTerminal: true,
-		in:          input,
-		out:         cli.out,
+
+	v := url.Values{}
+	if *quiet {
+		v.Set("quiet", "1")
+	}
+	body, contentType, _, err := cli.clientRequest("POST", "/images/load?"+v.Encode(), input, nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// the daemons older than the API 1.19 stream the raw output of the load
+	if !api.MatchesContentType(contentType, "application/json") {
+		_, err := io.Copy(cli.out, body)
+		return err
 	}
-	if err := cli.stream("POST", "/images/load", sopts); err != nil {
+
+	var loaded []types.ImageLoad
+	if err := jsonmessage.DisplayJSONMessagesStream(body, cli.out, cli.outFd, cli.isTerminalOut, func(aux *json.RawMessage) {
+		var l types.ImageLoad
+		if err := json.Unmarshal(*aux, &l); err == nil {
+			loaded = append(loaded, l)
+		}
+	}); err != nil {
 		return err
 	}
+
+	for _, l := range loaded {
+		switch {
+		case tmpl != nil:
+			if err := tmpl.Execute(cli.out, l); err != nil {
+				return err
+			}
+			fmt.Fprintln(cli.out)
+		case l.Name != "":
+			fmt.Fprintf(cli.out, "Loaded image: %s\n", l.Name)
+		default:
+			fmt.Fprintf(cli.out, "Loaded image ID: %s\n", l.ID)
+		}
+	}
 	return nil
 }
@@ -9,7 +9,6 @@ import (
 	"strings"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/cliconfig"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/registry"
@@ -30,7 +29,9 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 	cmd.StringVar(&password, []string{"p", "-password"}, "", "Password")
 	cmd.StringVar(&email, []string{"e", "-email"}, "", "Email")
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	serverAddress := registry.IndexServerAddress()
 	if len(cmd.Args()) > 0 {
@@ -55,9 +56,9 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 		return string(line)
 	}
 
-	authconfig, ok := cli.configFile.AuthConfigs[serverAddress]
-	if !ok {
-		authconfig = cliconfig.AuthConfig{}
+	authconfig, err := cli.configFile.GetAuthConfig(serverAddress)
+	if err != nil {
+		return err
 	}
 
 	if username == "" {
@@ -111,11 +112,12 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 	authconfig.Password = password
 	authconfig.Email = email
 	authconfig.ServerAddress = serverAddress
-	cli.configFile.AuthConfigs[serverAddress] = authconfig
 
-	stream, statusCode, err := cli.call("POST", "/auth", cli.configFile.AuthConfigs[serverAddress], nil)
+	stream, statusCode, err := cli.call("POST", "/auth", authconfig, nil)
 	if statusCode == 401 {
-		delete(cli.configFile.AuthConfigs, serverAddress)
+		if err2 := cli.configFile.RemoveAuthConfig(serverAddress); err2 != nil {
+			fmt.Fprintf(cli.out, "WARNING: could not remove the credentials: %v\n", err2)
+		}
 		if err2 := cli.configFile.Save(); err2 != nil {
 			fmt.Fprintf(cli.out, "WARNING: could not save config file: %v\n", err2)
 		}
@@ -127,15 +129,20 @@ func (cli *DockerCli) CmdLogin(args ...string) error {
 
 	var response types.AuthResponse
 	if err := json.NewDecoder(stream).Decode(&response); err != nil {
-		// Upon error, remove entry
-		delete(cli.configFile.AuthConfigs, serverAddress)
 		return err
 	}
 
+	if err := cli.configFile.StoreAuthConfig(authconfig); err != nil {
+		return fmt.Errorf("Error saving credentials: %v", err)
+	}
 	if err := cli.configFile.Save(); err != nil {
 		return fmt.Errorf("Error saving config file: %v", err)
 	}
-	fmt.Fprintf(cli.out, "WARNING: login credentials saved in %s\n", cli.configFile.Filename())
+	if helper := cli.configFile.CredentialHelper(serverAddress); helper != "" {
+		fmt.Fprintf(cli.out, "Login credentials saved with the credential helper %s\n", helper)
+	} else {
+		fmt.Fprintf(cli.out, "WARNING: login credentials saved in %s\n", cli.configFile.Filename())
+	}
 
 	if response.Status != "" {
 		fmt.Fprintf(cli.out, "%s\n", response.Status)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strconv"
 
 	"github.com/docker/docker/api/types"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -15,15 +16,19 @@ import (
 // docker logs [OPTIONS] CONTAINER
 func (cli *DockerCli) CmdLogs(args ...string) error {
 	var (
-		cmd    = cli.Subcmd("logs", "CONTAINER", "Fetch the logs of a container", true)
-		follow = cmd.Bool([]string{"f", "-follow"}, false, "Follow log output")
-		since  = cmd.String([]string{"-since"}, "", "Show logs since timestamp")
-		times  = cmd.Bool([]string{"t", "-timestamps"}, false, "Show timestamps")
-		tail   = cmd.String([]string{"-tail"}, "all", "Number of lines to show from the end of the logs")
+		cmd     = cli.Subcmd("logs", "CONTAINER", "Fetch the logs of a container", true)
+		follow  = cmd.Bool([]string{"f", "-follow"}, false, "Follow log output")
+		since   = cmd.String([]string{"-since"}, "", "Show logs since timestamp or relative time (e.g. 10m)")
+		until   = cmd.String([]string{"-until"}, "", "Show logs until timestamp or relative time (e.g. 10m)")
+		times   = cmd.Bool([]string{"t", "-timestamps"}, false, "Show timestamps")
+		details = cmd.Bool([]string{"-details"}, false, "Show the extra details of the logs")
+		tail    = cmd.String([]string{"-tail"}, "all", "Number of lines to show from the end of the logs")
 	)
 	cmd.Require(flag.Exact, 1)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	name := cmd.Arg(0)
 
@@ -37,8 +42,14 @@ func (cli *DockerCli) CmdLogs(args ...string) error {
 		return err
 	}
 
-	if logType := c.HostConfig.LogConfig.Type; logType != "json-file" {
-		return fmt.Errorf("\"logs\" command is supported only for \"json-file\" logging driver (got: %s)", logType)
+	// the logs of the other logging drivers are read from the local cache
+	// of the daemon, unless it is disabled
+	logConfig := c.HostConfig.LogConfig
+	if logConfig.Type == "none" {
+		return fmt.Errorf("\"logs\" command is not available for the \"none\" logging driver")
+	}
+	if cacheDisabled, _ := strconv.ParseBool(logConfig.Config["cache-disabled"]); logConfig.Type != "json-file" && cacheDisabled {
+		return fmt.Errorf("\"logs\" command is not available for the %q logging driver with its local cache disabled", logConfig.Type)
 	}
 
 	v := url.Values{}
@@ -49,10 +60,18 @@ func (cli *DockerCli) CmdLogs(args ...string) error {
 		v.Set("since", timeutils.GetTimestamp(*since))
 	}
 
+	if *until != "" {
+		v.Set("until", timeutils.GetTimestamp(*until))
+	}
+
 	if *times {
 		v.Set("timestamps", "1")
 	}
 
+	if *details {
+		v.Set("details", "1")
+	}
+
 	if *follow {
 		v.Set("follow", "1")
 	}
@@ -0,0 +1,380 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/graph/tags"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/platform"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/utils"
+)
+
+// localManifestList is a manifest list created with docker manifest create,
+// kept by the client until it's pushed.
+type localManifestList struct {
+	Ref       string
+	Manifests []localManifest
+}
+
+// localManifest is the manifest of an image of a local manifest list.
+type localManifest struct {
+	Image      string
+	Descriptor registry.ManifestDescriptor
+}
+
+// CmdManifest is the parent subcommand for all manifest commands.
+//
+// Usage: docker manifest <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdManifest(args ...string) error {
+	description := "Manage the manifest lists of the images of several platforms\n\nCommands:\n"
+	commands := [][]string{
+		{"annotate", "Set the platform of an image of a manifest list"},
+		{"create", "Create a local manifest list"},
+		{"inspect", "Display a manifest list or the manifest of an image"},
+		{"push", "Push a manifest list to its registry"},
+		{"rm", "Remove one or more local manifest lists"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker manifest COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("manifest", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdManifestCreate creates a local manifest list of the images, which must
+// have been pushed to the repository of the list.
+//
+// Usage: docker manifest create [OPTIONS] MANIFEST_LIST IMAGE [IMAGE...]
+func (cli *DockerCli) CmdManifestCreate(args ...string) error {
+	cmd := cli.Subcmd("manifest create", "MANIFEST_LIST IMAGE [IMAGE...]", "Create a local manifest list of images pushed to its repository", true)
+	amend := cmd.Bool([]string{"a", "-amend"}, false, "Add the images to an existing local manifest list")
+	cmd.Require(flag.Min, 2)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	listRef, listRepo, err := parseManifestRef(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
+	list, err := cli.loadManifestList(listRef)
+	if err != nil {
+		return err
+	}
+	if list != nil && !*amend {
+		return fmt.Errorf("The manifest list %s already exists, use --amend to add images to it", listRef)
+	}
+	if list == nil {
+		list = &localManifestList{Ref: listRef}
+	}
+
+	for _, name := range cmd.Args()[1:] {
+		ref, repo, err := parseManifestRef(name)
+		if err != nil {
+			return err
+		}
+		if repo.CanonicalName != listRepo.CanonicalName {
+			return fmt.Errorf("The image %s is not in the repository %s of the manifest list", ref, listRepo.CanonicalName)
+		}
+		inspect, err := cli.inspectDistribution(ref, repo)
+		if err != nil {
+			return err
+		}
+		if len(inspect.Manifests) > 0 || inspect.Descriptor.Platform == nil {
+			return fmt.Errorf("The image %s is a manifest list", ref)
+		}
+		m := localManifest{Image: ref, Descriptor: inspect.Descriptor}
+		replaced := false
+		for i := range list.Manifests {
+			if list.Manifests[i].Image == ref {
+				list.Manifests[i] = m
+				replaced = true
+			}
+		}
+		if !replaced {
+			list.Manifests = append(list.Manifests, m)
+		}
+	}
+
+	if err := cli.saveManifestList(list); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "Created manifest list %s\n", listRef)
+	return nil
+}
+
+// CmdManifestAnnotate sets the platform of an image of a local manifest list.
+//
+// Usage: docker manifest annotate [OPTIONS] MANIFEST_LIST IMAGE
+func (cli *DockerCli) CmdManifestAnnotate(args ...string) error {
+	cmd := cli.Subcmd("manifest annotate", "MANIFEST_LIST IMAGE", "Set the platform of an image of a local manifest list", true)
+	flOS := cmd.String([]string{"-os"}, "", "Set the operating system of the image")
+	flArch := cmd.String([]string{"-arch"}, "", "Set the architecture of the image")
+	flVariant := cmd.String([]string{"-variant"}, "", "Set the variant of the architecture, such as v7 for arm")
+	cmd.Require(flag.Exact, 2)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	listRef, _, err := parseManifestRef(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
+	ref, _, err := parseManifestRef(cmd.Arg(1))
+	if err != nil {
+		return err
+	}
+	list, err := cli.loadManifestList(listRef)
+	if err != nil {
+		return err
+	}
+	if list == nil {
+		return fmt.Errorf("No such manifest list: %s", listRef)
+	}
+
+	for i := range list.Manifests {
+		m := &list.Manifests[i]
+		if m.Image != ref {
+			continue
+		}
+		p := *m.Descriptor.Platform
+		if *flOS != "" {
+			p.OS = *flOS
+		}
+		if *flArch != "" {
+			p.Architecture = *flArch
+		}
+		if *flVariant != "" {
+			p.Variant = *flVariant
+		}
+		// the platform is normalized as the one of the --platform flags
+		normalized, err := platform.Parse(p.OS + "/" + p.Architecture)
+		if err != nil {
+			return err
+		}
+		p.OS, p.Architecture = normalized.OS, normalized.Architecture
+		m.Descriptor.Platform = &p
+		return cli.saveManifestList(list)
+	}
+	return fmt.Errorf("The image %s is not in the manifest list %s", ref, listRef)
+}
+
+// CmdManifestInspect displays the manifest list that would be pushed for a
+// local manifest list, or the manifest of an image in its registry.
+//
+// Usage: docker manifest inspect MANIFEST_LIST|IMAGE
+func (cli *DockerCli) CmdManifestInspect(args ...string) error {
+	cmd := cli.Subcmd("manifest inspect", "MANIFEST_LIST|IMAGE", "Display a local manifest list, or the manifest of an image in its registry", true)
+	cmd.Require(flag.Exact, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	ref, repo, err := parseManifestRef(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
+	list, err := cli.loadManifestList(ref)
+	if err != nil {
+		return err
+	}
+
+	var v interface{}
+	if list != nil {
+		v = list.manifestList()
+	} else if v, err = cli.inspectDistribution(ref, repo); err != nil {
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, b, "", "    "); err != nil {
+		return err
+	}
+	indented.WriteString("\n")
+	_, err = indented.WriteTo(cli.out)
+	return err
+}
+
+// CmdManifestPush pushes a local manifest list to its registry.
+//
+// Usage: docker manifest push [OPTIONS] MANIFEST_LIST
+func (cli *DockerCli) CmdManifestPush(args ...string) error {
+	cmd := cli.Subcmd("manifest push", "MANIFEST_LIST", "Push a local manifest list to its registry", true)
+	purge := cmd.Bool([]string{"p", "-purge"}, false, "Remove the local manifest list once pushed")
+	cmd.Require(flag.Exact, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	ref, repo, err := parseManifestRef(cmd.Arg(0))
+	if err != nil {
+		return err
+	}
+	list, err := cli.loadManifestList(ref)
+	if err != nil {
+		return err
+	}
+	if list == nil {
+		return fmt.Errorf("No such manifest list: %s", ref)
+	}
+
+	headers, err := cli.registryAuthHeaders(repo)
+	if err != nil {
+		return err
+	}
+	body, _, err := readBody(cli.call("POST", "/distribution/"+ref+"/push", list.manifestList(), headers))
+	if err != nil {
+		return err
+	}
+	var response types.ManifestPushResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", response.Digest)
+
+	if *purge {
+		return os.Remove(cli.manifestListPath(ref))
+	}
+	return nil
+}
+
+// CmdManifestRm removes local manifest lists.
+//
+// Usage: docker manifest rm MANIFEST_LIST [MANIFEST_LIST...]
+func (cli *DockerCli) CmdManifestRm(args ...string) error {
+	cmd := cli.Subcmd("manifest rm", "MANIFEST_LIST [MANIFEST_LIST...]", "Remove one or more local manifest lists", true)
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var status = 0
+	for _, name := range cmd.Args() {
+		ref, _, err := parseManifestRef(name)
+		if err == nil {
+			if err = os.Remove(cli.manifestListPath(ref)); os.IsNotExist(err) {
+				err = fmt.Errorf("No such manifest list: %s", ref)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(cli.out, "%s\n", ref)
+	}
+
+	if status != 0 {
+		return StatusError{StatusCode: status}
+	}
+	return nil
+}
+
+// parseManifestRef returns the reference name with its tag, latest if it has
+// none, and its repository.
+func parseManifestRef(name string) (string, *registry.RepositoryInfo, error) {
+	repo, tag := parsers.ParseRepositoryTag(name)
+	if tag == "" {
+		tag = tags.DEFAULTTAG
+	}
+	repoInfo, err := registry.ParseRepositoryInfo(repo)
+	if err != nil {
+		return "", nil, err
+	}
+	return utils.ImageReference(repo, tag), repoInfo, nil
+}
+
+// registryAuthHeaders returns the X-Registry-Auth header with the
+// credentials of the registry of the repository.
+func (cli *DockerCli) registryAuthHeaders(repo *registry.RepositoryInfo) (map[string][]string, error) {
+	authConfig := registry.ResolveAuthConfig(cli.configFile, repo.Index)
+	buf, err := json.Marshal(authConfig)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]string{"X-Registry-Auth": {base64.URLEncoding.EncodeToString(buf)}}, nil
+}
+
+func (cli *DockerCli) inspectDistribution(ref string, repo *registry.RepositoryInfo) (*types.DistributionInspect, error) {
+	headers, err := cli.registryAuthHeaders(repo)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := readBody(cli.call("GET", "/distribution/"+ref+"/json", nil, headers))
+	if err != nil {
+		return nil, err
+	}
+	var inspect types.DistributionInspect
+	if err := json.Unmarshal(body, &inspect); err != nil {
+		return nil, err
+	}
+	return &inspect, nil
+}
+
+// manifestListPath returns the path of the file of the local manifest list
+// ref, in the manifests directory next to the configuration file.
+func (cli *DockerCli) manifestListPath(ref string) string {
+	name := strings.NewReplacer("/", "_", ":", "-").Replace(ref)
+	return filepath.Join(filepath.Dir(cli.configFile.Filename()), "manifests", name+".json")
+}
+
+// loadManifestList returns the local manifest list ref, nil if there's none.
+func (cli *DockerCli) loadManifestList(ref string) (*localManifestList, error) {
+	data, err := ioutil.ReadFile(cli.manifestListPath(ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var list localManifestList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("Error decoding the manifest list %s: %v", ref, err)
+	}
+	return &list, nil
+}
+
+func (cli *DockerCli) saveManifestList(list *localManifestList) error {
+	path := cli.manifestListPath(list.Ref)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// manifestList returns the manifest list pushed for the local one.
+func (l *localManifestList) manifestList() *registry.ManifestList {
+	list := &registry.ManifestList{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeManifestList,
+	}
+	for _, m := range l.Manifests {
+		list.Manifests = append(list.Manifests, m.Descriptor)
+	}
+	return list
+}
@@ -12,7 +12,9 @@ import (
 func (cli *DockerCli) CmdPause(args ...string) error {
 	cmd := cli.Subcmd("pause", "CONTAINER [CONTAINER...]", "Pause all processes within a container", true)
 	cmd.Require(flag.Min, 1)
-	cmd.ParseFlags(args, false)
+	if err := cmd.ParseFlags(args, false); err != nil {
+		return err
+	}
 
 	var errNames []string
 	for _, name := range cmd.Args() {
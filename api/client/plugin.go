@@ -0,0 +1,244 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/docker/docker/api/types"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// CmdPlugin is the parent subcommand for all plugin commands.
+//
+// Usage: docker plugin <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdPlugin(args ...string) error {
+	description := "Manage the plugins of the daemon\n\nCommands:\n"
+	commands := [][]string{
+		{"install", "Install a signed plugin"},
+		{"inspect", "Return low-level information on a plugin"},
+		{"ls", "List plugins"},
+		{"rm", "Remove one or more plugins"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker plugin COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("plugin", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdPluginInstall installs the plugin of a signed manifest, once the user
+// granted the privileges it requires on the host.
+//
+// Usage: docker plugin install [OPTIONS] MANIFEST|-
+func (cli *DockerCli) CmdPluginInstall(args ...string) error {
+	cmd := cli.Subcmd("plugin install", "MANIFEST|-", "Install the plugin of a signed manifest, from a file or STDIN", true)
+	grantAll := cmd.Bool([]string{"-grant-all-permissions"}, false, "Grant all the permissions the plugin requires without prompting")
+	cmd.Require(flag.Exact, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var (
+		manifest []byte
+		err      error
+		file     = cmd.Arg(0)
+	)
+	if file == "-" {
+		manifest, err = ioutil.ReadAll(cli.in)
+	} else {
+		manifest, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	body, _, err := readBody(cli.call("POST", "/plugins/privileges", &types.PluginPrivilegesRequest{Manifest: manifest}, nil))
+	if err != nil {
+		return err
+	}
+	var privileges []types.PluginPrivilege
+	if err := json.Unmarshal(body, &privileges); err != nil {
+		return err
+	}
+
+	if len(privileges) > 0 && !*grantAll {
+		if file == "-" {
+			return fmt.Errorf("Error: the plugin requires privileges on the host, --grant-all-permissions is needed to install it from STDIN")
+		}
+		fmt.Fprintln(cli.out, "The plugin requires the following privileges on the host:")
+		for _, p := range privileges {
+			fmt.Fprintf(cli.out, " - %s (%s): %s\n", p.Name, p.Description, strings.Join(p.Value, ", "))
+		}
+		fmt.Fprint(cli.out, "Do you grant the above permissions? [y/N] ")
+		answer, _ := bufio.NewReader(cli.in).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return fmt.Errorf("Error: the permissions were not granted, the plugin is not installed")
+		}
+	}
+
+	req := &types.PluginInstallRequest{
+		Manifest:   manifest,
+		Privileges: privileges,
+	}
+	body, _, err = readBody(cli.call("POST", "/plugins/install", req, nil))
+	if err != nil {
+		return err
+	}
+
+	var p types.Plugin
+	if err := json.Unmarshal(body, &p); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", p.Name)
+	return nil
+}
+
+// CmdPluginInspect displays low-level information on one or more plugins,
+// such as the privileges granted to them.
+//
+// Usage: docker plugin inspect [OPTIONS] PLUGIN [PLUGIN...]
+func (cli *DockerCli) CmdPluginInspect(args ...string) error {
+	cmd := cli.Subcmd("plugin inspect", "PLUGIN [PLUGIN...]", "Return low-level information on a plugin", true)
+	tmplStr := cmd.String([]string{"f", "-format"}, "", "Format the output using the given go template")
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if *tmplStr != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*tmplStr); err != nil {
+			return StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
+
+	var (
+		plugins []*types.Plugin
+		status  = 0
+	)
+	for _, name := range cmd.Args() {
+		body, _, err := readBody(cli.call("GET", "/plugins/"+name, nil, nil))
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+
+		var p types.Plugin
+		if err := json.Unmarshal(body, &p); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+
+		if tmpl != nil {
+			if err := tmpl.Execute(cli.out, &p); err != nil {
+				return err
+			}
+			cli.out.Write([]byte{'\n'})
+			continue
+		}
+		plugins = append(plugins, &p)
+	}
+
+	if tmpl == nil {
+		b, err := json.Marshal(plugins)
+		if err != nil {
+			return err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, b, "", "    "); err != nil {
+			return err
+		}
+		indented.WriteString("\n")
+		if _, err := indented.WriteTo(cli.out); err != nil {
+			return err
+		}
+	}
+
+	if status != 0 {
+		return StatusError{StatusCode: status}
+	}
+	return nil
+}
+
+// CmdPluginLs outputs a list of the installed plugins.
+//
+// Usage: docker plugin ls [OPTIONS]
+func (cli *DockerCli) CmdPluginLs(args ...string) error {
+	cmd := cli.Subcmd("plugin ls", "", "List plugins", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display plugin names")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	body, _, err := readBody(cli.call("GET", "/plugins", nil, nil))
+	if err != nil {
+		return err
+	}
+
+	var plugins []*types.Plugin
+	if err := json.Unmarshal(body, &plugins); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintln(w, "NAME\tIMPLEMENTS\tADDRESS\tPRIVILEGES")
+	}
+	for _, p := range plugins {
+		if *quiet {
+			fmt.Fprintln(w, p.Name)
+			continue
+		}
+		var privileges []string
+		for _, privilege := range p.Privileges {
+			privileges = append(privileges, privilege.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, strings.Join(p.Implements, ","), p.Address, strings.Join(privileges, ","))
+	}
+	w.Flush()
+	return nil
+}
+
+// CmdPluginRm removes one or more plugins.
+//
+// Usage: docker plugin rm PLUGIN [PLUGIN...]
+func (cli *DockerCli) CmdPluginRm(args ...string) error {
+	cmd := cli.Subcmd("plugin rm", "PLUGIN [PLUGIN...]", "Remove one or more plugins", true)
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var errNames []string
+	for _, name := range cmd.Args() {
+		if _, _, err := readBody(cli.call("DELETE", "/plugins/"+name, nil, nil)); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			errNames = append(errNames, name)
+		} else {
+			fmt.Fprintf(cli.out, "%s\n", name)
+		}
+	}
+	if len(errNames) > 0 {
+		return fmt.Errorf("Error: failed to remove plugins: %v", errNames)
+	}
+	return nil
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/docker/docker/api"
@@ -19,6 +21,39 @@ import (
 	"github.com/docker/docker/pkg/units"
 )
 
+// containerContext is the container given to the template of the --format
+// flag of ps.
+type containerContext struct {
+	ID         string
+	Image      string
+	Command    string
+	CreatedAt  string
+	RunningFor string
+	Ports      string
+	Status     string
+	Size       string
+	Names      string
+	Labels     string
+
+	labels map[string]string
+}
+
+// Label returns the value of the label of the container, if any.
+func (c *containerContext) Label(name string) string {
+	return c.labels[name]
+}
+
+// joinLabels returns the labels as comma separated key=value pairs, sorted
+// by key.
+func joinLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
 // CmdPs outputs a list of Docker containers.
 //
 // Usage: docker ps [OPTIONS]
@@ -38,13 +73,25 @@ func (cli *DockerCli) CmdPs(args ...string) error {
 		since    = cmd.String([]string{"#sinceId", "#-since-id", "-since"}, "", "Show created since Id or Name, include non-running")
 		before   = cmd.String([]string{"#beforeId", "#-before-id", "-before"}, "", "Show only container created before Id or Name")
 		last     = cmd.Int([]string{"n"}, -1, "Show n last created containers, include non-running")
+		format   = cmd.String([]string{"-format"}, "", "Pretty-print containers using a Go template")
 		flFilter = opts.NewListOpts(nil)
 	)
 	cmd.Require(flag.Exact, 0)
 
 	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if *format != "" && !*quiet {
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
+
 	if *last == -1 && *nLatest {
 		*last = 1
 	}
@@ -97,7 +144,7 @@ func (cli *DockerCli) CmdPs(args ...string) error {
 	}
 
 	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
-	if !*quiet {
+	if !*quiet && tmpl == nil {
 		fmt.Fprint(w, "CONTAINER ID\tIMAGE\tCOMMAND\tCREATED\tSTATUS\tPORTS\tNAMES")
 
 		if *size {
@@ -150,16 +197,47 @@ func (cli *DockerCli) CmdPs(args ...string) error {
 			image = "<no image>"
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\t%s\t%s\t", ID, image, command,
-			units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(container.Created), 0))),
-			container.Status, api.DisplayablePorts(container.Ports), strings.Join(names, ","))
+		var (
+			created    = time.Unix(int64(container.Created), 0)
+			runningFor = units.HumanDuration(time.Now().UTC().Sub(created))
+			ports      = api.DisplayablePorts(container.Ports)
+			sizeStr    string
+		)
 
 		if *size {
 			if container.SizeRootFs > 0 {
-				fmt.Fprintf(w, "%s (virtual %s)\n", units.HumanSize(float64(container.SizeRw)), units.HumanSize(float64(container.SizeRootFs)))
+				sizeStr = fmt.Sprintf("%s (virtual %s)", units.HumanSize(float64(container.SizeRw)), units.HumanSize(float64(container.SizeRootFs)))
 			} else {
-				fmt.Fprintf(w, "%s\n", units.HumanSize(float64(container.SizeRw)))
+				sizeStr = units.HumanSize(float64(container.SizeRw))
 			}
+		}
+
+		if tmpl != nil {
+			if err := tmpl.Execute(cli.out, &containerContext{
+				ID:         ID,
+				Image:      image,
+				Command:    command,
+				CreatedAt:  created.String(),
+				RunningFor: runningFor,
+				Ports:      ports,
+				Status:     container.Status,
+				Size:       sizeStr,
+				Names:      strings.Join(names, ","),
+				Labels:     joinLabels(container.Labels),
+				labels:     container.Labels,
+			}); err != nil {
+				return err
+			}
+			cli.out.Write([]byte{'\n'})
+
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\t%s\t%s\t", ID, image, command,
+			runningFor, container.Status, ports, strings.Join(names, ","))
+
+		if *size {
+			fmt.Fprintf(w, "%s\n", sizeStr)
 
 			continue
 		}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"net/url"
 
 	flag "github.com/docker/docker/pkg/mflag"
 )
@@ -12,12 +13,16 @@ import (
 func (cli *DockerCli) CmdRename(args ...string) error {
 	cmd := cli.Subcmd("rename", "OLD_NAME NEW_NAME", "Rename a container", true)
 	cmd.Require(flag.Exact, 2)
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	oldName := cmd.Arg(0)
 	newName := cmd.Arg(1)
 
-	if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/rename?name=%s", oldName, newName), nil, nil)); err != nil {
+	v := url.Values{}
+	v.Set("name", newName)
+	if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/rename?%s", oldName, v.Encode()), nil, nil)); err != nil {
 		fmt.Fprintf(cli.err, "%s\n", err)
 		return fmt.Errorf("Error: failed to rename container named %s", oldName)
 	}
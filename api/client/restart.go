@@ -16,7 +16,9 @@ func (cli *DockerCli) CmdRestart(args ...string) error {
 	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Seconds to wait for stop before killing the container")
 	cmd.Require(flag.Min, 1)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	v := url.Values{}
 	v.Set("t", strconv.Itoa(*nSeconds))
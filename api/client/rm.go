@@ -18,7 +18,9 @@ func (cli *DockerCli) CmdRm(args ...string) error {
 	force := cmd.Bool([]string{"f", "-force"}, false, "Force the removal of a running container (uses SIGKILL)")
 	cmd.Require(flag.Min, 1)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	val := url.Values{}
 	if *v {
@@ -19,7 +19,9 @@ func (cli *DockerCli) CmdRmi(args ...string) error {
 		noprune = cmd.Bool([]string{"-no-prune"}, false, "Do not delete untagged parents")
 	)
 	cmd.Require(flag.Min, 1)
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	v := url.Values{}
 	if *force {
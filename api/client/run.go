@@ -8,6 +8,7 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/resolvconf/dns"
 	"github.com/docker/docker/pkg/signal"
@@ -46,6 +47,7 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		flDetach     = cmd.Bool([]string{"d", "-detach"}, false, "Run container in background and print container ID")
 		flSigProxy   = cmd.Bool([]string{"-sig-proxy"}, true, "Proxy received signals to the process")
 		flName       = cmd.String([]string{"-name"}, "", "Assign a name to the container")
+		flPlatform   = cmd.String([]string{"-platform"}, "", "Set the platform of the image, such as linux/arm64")
 		flAttach     *opts.ListOpts
 
 		ErrConflictAttachDetach               = fmt.Errorf("Conflicting options: -a and -d")
@@ -54,6 +56,9 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 	)
 
 	config, hostConfig, cmd, err := runconfig.Parse(cmd, args)
+	if err == flag.ErrHelp {
+		return err
+	}
 	// just in case the Parse does not exit
 	if err != nil {
 		cmd.ReportError(err.Error(), true)
@@ -75,6 +80,11 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		return nil
 	}
 
+	// the daemon removes the container on exit since the API 1.19, even if
+	// the client is gone by then
+	serverAutoRemove := *flAutoRemove && !cli.apiVersion().LessThan("1.19")
+	hostConfig.AutoRemove = serverAutoRemove
+
 	if !*flDetach {
 		if err := cli.CheckTtyInput(config.AttachStdin, config.Tty); err != nil {
 			return err
@@ -86,7 +96,7 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 				return ErrConflictAttachDetach
 			}
 		}
-		if *flAutoRemove {
+		if *flAutoRemove && !serverAutoRemove {
 			return ErrConflictDetachAutoRemove
 		}
 
@@ -102,7 +112,7 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		sigProxy = false
 	}
 
-	createResponse, err := cli.createContainer(config, hostConfig, hostConfig.ContainerIDFile, *flName)
+	createResponse, err := cli.createContainer(config, hostConfig, hostConfig.ContainerIDFile, *flName, *flPlatform)
 	if err != nil {
 		return err
 	}
@@ -180,13 +190,26 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 	}
 
 	defer func() {
-		if *flAutoRemove {
+		if *flAutoRemove && !serverAutoRemove {
 			if _, _, err = readBody(cli.call("DELETE", "/containers/"+createResponse.ID+"?v=1", nil, nil)); err != nil {
 				fmt.Fprintf(cli.err, "Error deleting container: %s\n", err)
 			}
 		}
 	}()
 
+	// wait for the removal before starting the container, which could be
+	// removed before a later wait reaches the daemon
+	var (
+		removedCh     chan error
+		removedStatus int
+	)
+	if serverAutoRemove && (config.AttachStdout || config.AttachStderr) {
+		removedCh = promise.Go(func() (err error) {
+			removedStatus, err = waitForCondition(cli, createResponse.ID, "removed")
+			return err
+		})
+	}
+
 	//start the container
 	if _, _, err = readBody(cli.call("POST", "/containers/"+createResponse.ID+"/start", nil, nil)); err != nil {
 		return err
@@ -215,7 +238,14 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 	var status int
 
 	// Attached mode
-	if *flAutoRemove {
+	if serverAutoRemove {
+		// Autoremove by the daemon: wait for the container to be removed,
+		// with its exit code
+		if err := <-removedCh; err != nil {
+			return err
+		}
+		status = removedStatus
+	} else if *flAutoRemove {
 		// Autoremove: wait for the container to finish, retrieve
 		// the exit code and remove the container
 		if _, _, err := readBody(cli.call("POST", "/containers/"+createResponse.ID+"/wait", nil, nil)); err != nil {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/url"
 	"os"
+	"strconv"
 
 	flag "github.com/docker/docker/pkg/mflag"
 )
@@ -17,9 +18,14 @@ import (
 func (cli *DockerCli) CmdSave(args ...string) error {
 	cmd := cli.Subcmd("save", "IMAGE [IMAGE...]", "Save an image(s) to a tar archive (streamed to STDOUT by default)", true)
 	outfile := cmd.String([]string{"o", "-output"}, "", "Write to an file, instead of STDOUT")
+	buildCache := cmd.Bool([]string{"-build-cache"}, false, "Include the build cache of the images")
+	compress := cmd.String([]string{"-compress"}, "", "Compress the layers of the images, with gzip or zstd")
+	compressLevel := cmd.Int([]string{"-compress-level"}, 0, "Set the level of the compression of the layers")
 	cmd.Require(flag.Min, 1)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	var (
 		output io.Writer = cli.out
@@ -39,13 +45,23 @@ func (cli *DockerCli) CmdSave(args ...string) error {
 		out:         output,
 	}
 
+	v := url.Values{}
+	if *buildCache {
+		v.Set("buildcache", "1")
+	}
+	if *compress != "" {
+		v.Set("compress", *compress)
+	}
+	if *compressLevel != 0 {
+		v.Set("compresslevel", strconv.Itoa(*compressLevel))
+	}
+
 	if len(cmd.Args()) == 1 {
 		image := cmd.Arg(0)
-		if err := cli.stream("GET", "/images/"+image+"/get", sopts); err != nil {
+		if err := cli.stream("GET", "/images/"+image+"/get?"+v.Encode(), sopts); err != nil {
 			return err
 		}
 	} else {
-		v := url.Values{}
 		for _, arg := range cmd.Args() {
 			v.Add("names", arg)
 		}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 
+	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/parsers/filters"
 	"github.com/docker/docker/pkg/stringutils"
 	"github.com/docker/docker/registry"
 )
@@ -21,6 +25,16 @@ func (r ByStars) Len() int           { return len(r) }
 func (r ByStars) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
 func (r ByStars) Less(i, j int) bool { return r[i].StarCount < r[j].StarCount }
 
+// searchContext is the search result given to the template of the --format
+// flag of search.
+type searchContext struct {
+	Name        string
+	Description string
+	StarCount   int
+	IsOfficial  bool
+	IsAutomated bool
+}
+
 // CmdSearch searches the Docker Hub for images.
 //
 // Usage: docker search [OPTIONS] TERM
@@ -30,13 +44,47 @@ func (cli *DockerCli) CmdSearch(args ...string) error {
 	trusted := cmd.Bool([]string{"#t", "#trusted", "#-trusted"}, false, "Only show trusted builds")
 	automated := cmd.Bool([]string{"-automated"}, false, "Only show automated builds")
 	stars := cmd.Uint([]string{"s", "#stars", "-stars"}, 0, "Only displays with at least x stars")
+	limit := cmd.Int([]string{"-limit"}, registry.DefaultSearchLimit, "Max number of search results")
+	format := cmd.String([]string{"-format"}, "", "Pretty-print search results using a Go template")
+
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
 	cmd.Require(flag.Exact, 1)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if *format != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
 
 	name := cmd.Arg(0)
 	v := url.Values{}
 	v.Set("term", name)
+	v.Set("limit", strconv.Itoa(*limit))
+
+	// Consolidate all filter flags, and sanity check them early.
+	// They'll get processed in the daemon.
+	searchFilterArgs := filters.Args{}
+	for _, f := range flFilter.GetAll() {
+		var err error
+		if searchFilterArgs, err = filters.ParseFlag(f, searchFilterArgs); err != nil {
+			return err
+		}
+	}
+	if len(searchFilterArgs) > 0 {
+		filterJSON, err := filters.ToParam(searchFilterArgs)
+		if err != nil {
+			return err
+		}
+		v.Set("filters", filterJSON)
+	}
 
 	// Resolve the Repository name from fqn to hostname + name
 	taglessRemote, _ := parsers.ParseRepositoryTag(name)
@@ -58,11 +106,26 @@ func (cli *DockerCli) CmdSearch(args ...string) error {
 	sort.Sort(sort.Reverse(results))
 
 	w := tabwriter.NewWriter(cli.out, 10, 1, 3, ' ', 0)
-	fmt.Fprintf(w, "NAME\tDESCRIPTION\tSTARS\tOFFICIAL\tAUTOMATED\n")
+	if tmpl == nil {
+		fmt.Fprintf(w, "NAME\tDESCRIPTION\tSTARS\tOFFICIAL\tAUTOMATED\n")
+	}
 	for _, res := range results {
 		if ((*automated || *trusted) && (!res.IsTrusted && !res.IsAutomated)) || (int(*stars) > res.StarCount) {
 			continue
 		}
+		if tmpl != nil {
+			if err := tmpl.Execute(cli.out, &searchContext{
+				Name:        res.Name,
+				Description: res.Description,
+				StarCount:   res.StarCount,
+				IsOfficial:  res.IsOfficial,
+				IsAutomated: res.IsAutomated || res.IsTrusted,
+			}); err != nil {
+				return err
+			}
+			cli.out.Write([]byte{'\n'})
+			continue
+		}
 		desc := strings.Replace(res.Description, "\n", " ", -1)
 		desc = strings.Replace(desc, "\r", " ", -1)
 		if !*noTrunc && len(desc) > 45 {
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/units"
+)
+
+// CmdSecret is the parent subcommand for all secret commands.
+//
+// Usage: docker secret <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdSecret(args ...string) error {
+	description := "Manage the secrets of the daemon\n\nCommands:\n"
+	commands := [][]string{
+		{"create", "Create a secret"},
+		{"ls", "List secrets"},
+		{"rm", "Remove one or more secrets"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker secret COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("secret", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdSecretCreate creates a secret with the content of a file, or of the
+// standard input.
+//
+// Usage: docker secret create NAME [FILE|-]
+func (cli *DockerCli) CmdSecretCreate(args ...string) error {
+	cmd := cli.Subcmd("secret create", "NAME [FILE|-]", "Create a secret from a file or STDIN", true)
+	cmd.Require(flag.Min, 1)
+	cmd.Require(flag.Max, 2)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if file := cmd.Arg(1); file == "" || file == "-" {
+		data, err = ioutil.ReadAll(cli.in)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	req := &types.SecretCreateRequest{
+		Name: cmd.Arg(0),
+		Data: data,
+	}
+	body, _, err := readBody(cli.call("POST", "/secrets/create", req, nil))
+	if err != nil {
+		return err
+	}
+
+	var secret types.Secret
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", secret.Name)
+	return nil
+}
+
+// CmdSecretLs outputs a list of the secrets, without their content.
+//
+// Usage: docker secret ls [OPTIONS]
+func (cli *DockerCli) CmdSecretLs(args ...string) error {
+	cmd := cli.Subcmd("secret ls", "", "List secrets", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display secret names")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	body, _, err := readBody(cli.call("GET", "/secrets", nil, nil))
+	if err != nil {
+		return err
+	}
+
+	var secrets []*types.Secret
+	if err := json.Unmarshal(body, &secrets); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintln(w, "NAME\tSIZE\tCREATED")
+	}
+	for _, secret := range secrets {
+		if *quiet {
+			fmt.Fprintln(w, secret.Name)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s ago\n", secret.Name, units.HumanSize(float64(secret.Size)), units.HumanDuration(time.Now().UTC().Sub(secret.CreatedAt)))
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+// CmdSecretRm removes one or more secrets.
+//
+// Usage: docker secret rm NAME [NAME...]
+func (cli *DockerCli) CmdSecretRm(args ...string) error {
+	cmd := cli.Subcmd("secret rm", "NAME [NAME...]", "Remove one or more secrets", true)
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var errNames []string
+	for _, name := range cmd.Args() {
+		if _, _, err := readBody(cli.call("DELETE", "/secrets/"+name, nil, nil)); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			errNames = append(errNames, name)
+		} else {
+			fmt.Fprintf(cli.out, "%s\n", name)
+		}
+	}
+	if len(errNames) > 0 {
+		return fmt.Errorf("Error: failed to remove secrets: %v", errNames)
+	}
+	return nil
+}
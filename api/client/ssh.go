@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sshDialer returns the function connecting to the daemon of the host at
+// addr, [user@]host[:port], through ssh: the connection is the standard
+// input and output of ssh running docker system dial-stdio on the host,
+// which forwards them to the socket of the daemon. The ssh command may be
+// replaced by DOCKER_SSH_COMMAND, such as "ssh -i ~/.ssh/docker".
+func sshDialer(addr string) func(network, address string) (net.Conn, error) {
+	return func(_, _ string) (net.Conn, error) {
+		args, err := sshArgs(addr, os.Getenv("DOCKER_SSH_COMMAND"))
+		if err != nil {
+			return nil, err
+		}
+		return newCommandConn(exec.Command(args[0], args[1:]...), addr)
+	}
+}
+
+// sshArgs returns the command line running docker system dial-stdio on the
+// host at addr, with the ssh command sshCommand, ssh by default.
+func sshArgs(addr, sshCommand string) ([]string, error) {
+	args := strings.Fields(sshCommand)
+	if len(args) == 0 {
+		args = []string{"ssh"}
+	}
+	host := addr
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		args = append(args, "-l", host[:i])
+		host = host[i+1:]
+	}
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		args = append(args, "-p", host[i+1:])
+		host = host[:i]
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if host == "" || strings.HasPrefix(host, "-") {
+		return nil, fmt.Errorf("Invalid ssh address: %s", addr)
+	}
+	return append(args, "--", host, "docker", "system", "dial-stdio"), nil
+}
+
+// commandConn is a connection to the standard input and output of a
+// command. The errors of the command are reported when the connection
+// breaks.
+type commandConn struct {
+	cmd    *exec.Cmd
+	addr   string
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+
+	closeOnce sync.Once
+}
+
+func newCommandConn(cmd *exec.Cmd, addr string) (*commandConn, error) {
+	c := &commandConn{cmd: cmd, addr: addr}
+	var err error
+	if c.stdin, err = cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	if c.stdout, err = cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	cmd.Stderr = &c.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("Cannot connect to %s: %v", addr, err)
+	}
+	return c, nil
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err == io.EOF && n == 0 {
+		return 0, c.exitError(err)
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	n, err := c.stdin.Write(p)
+	if err != nil {
+		return n, c.exitError(err)
+	}
+	return n, nil
+}
+
+// exitError returns the error of the command if it failed, or err.
+func (c *commandConn) exitError(err error) error {
+	if waitErr := c.wait(); waitErr != nil {
+		if stderr := strings.TrimSpace(c.stderr.String()); stderr != "" {
+			return fmt.Errorf("Cannot connect to %s: %s", c.addr, stderr)
+		}
+		return fmt.Errorf("Cannot connect to %s: %v", c.addr, waitErr)
+	}
+	return err
+}
+
+func (c *commandConn) wait() error {
+	var err error
+	c.closeOnce.Do(func() {
+		err = c.cmd.Wait()
+	})
+	return err
+}
+
+// CloseWrite closes the standard input of the command, for the half-closes
+// of the hijacked connections.
+func (c *commandConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+func (c *commandConn) Close() error {
+	c.stdin.Close()
+	c.closeOnce.Do(func() {
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return commandAddr("local")
+}
+
+func (c *commandConn) RemoteAddr() net.Addr {
+	return commandAddr(c.addr)
+}
+
+// the deadlines aren't supported by the pipes of the commands
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type commandAddr string
+
+func (a commandAddr) Network() string { return "ssh" }
+func (a commandAddr) String() string  { return string(a) }
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSSHArgs(t *testing.T) {
+	for addr, expected := range map[string][]string{
+		"host":              {"ssh", "--", "host", "docker", "system", "dial-stdio"},
+		"user@host:2222":    {"ssh", "-l", "user", "-p", "2222", "--", "host", "docker", "system", "dial-stdio"},
+		"user@[::1]":        {"ssh", "-l", "user", "--", "::1", "docker", "system", "dial-stdio"},
+		"user@[fe80::1]:22": {"ssh", "-l", "user", "-p", "22", "--", "fe80::1", "docker", "system", "dial-stdio"},
+	} {
+		args, err := sshArgs(addr, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Fatalf("Expected %q for %s, got %q", expected, addr, args)
+		}
+	}
+
+	args, err := sshArgs("host", "ssh -i /keys/docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"ssh", "-i", "/keys/docker", "--", "host", "docker", "system", "dial-stdio"}; !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected %q with the ssh command, got %q", expected, args)
+	}
+	if _, err := sshArgs("user@-oProxyCommand=x", ""); err == nil {
+		t.Fatal("Expected a host starting with a dash to be refused")
+	}
+}
+
+func TestCommandConn(t *testing.T) {
+	conn, err := newCommandConn(exec.Command("cat"), "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(conn)
+	if err != nil || string(out) != "ping" {
+		t.Fatalf("Expected the output of the command, got %q, %v", out, err)
+	}
+
+	conn, err = newCommandConn(exec.Command("sh", "-c", "echo 'Permission denied' >&2; exit 255"), "user@host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := ioutil.ReadAll(conn); err == nil || !strings.Contains(err.Error(), "Cannot connect to user@host: Permission denied") {
+		t.Fatalf("Expected the error of the command, got %v", err)
+	}
+}
@@ -0,0 +1,366 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/compose"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/parsers/filters"
+	"github.com/docker/docker/runconfig"
+)
+
+const (
+	// the labels of the containers and the volumes of the stacks
+	stackNamespaceLabel = "com.docker.stack.namespace"
+	stackServiceLabel   = "com.docker.stack.service"
+)
+
+var validStackName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// CmdStack is the parent subcommand for all stack commands.
+//
+// Usage: docker stack <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdStack(args ...string) error {
+	description := "Manage the stacks of containers described by compose files\n\nCommands:\n"
+	commands := [][]string{
+		{"deploy", "Deploy a stack from a compose file"},
+		{"rm", "Remove the containers of a stack"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker stack COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("stack", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdStackDeploy creates the volumes and the containers of the services of a
+// compose file, named after the stack. The containers of a previous deploy
+// of the stack are replaced. The services are created and started after the
+// services they depend on, and linked to them.
+//
+// Usage: docker stack deploy [OPTIONS] STACK
+func (cli *DockerCli) CmdStackDeploy(args ...string) error {
+	cmd := cli.Subcmd("stack deploy", "STACK", "Deploy a stack from a compose file", true)
+	file := cmd.String([]string{"f", "-file"}, "docker-compose.yml", "Compose file describing the stack, or - to read it from STDIN")
+	cmd.Require(flag.Exact, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	stack := cmd.Arg(0)
+	if !validStackName.MatchString(stack) {
+		return fmt.Errorf("Invalid stack name %s, only [a-zA-Z0-9][a-zA-Z0-9_.-] are allowed", stack)
+	}
+
+	var (
+		data []byte
+		dir  string
+		err  error
+	)
+	if *file == "-" {
+		data, err = ioutil.ReadAll(cli.in)
+		dir, _ = os.Getwd()
+	} else {
+		data, err = ioutil.ReadFile(*file)
+		dir, _ = filepath.Abs(filepath.Dir(*file))
+	}
+	if err != nil {
+		return err
+	}
+	config, err := compose.Load(data, dir, os.Getenv)
+	if err != nil {
+		return fmt.Errorf("Invalid compose file %s: %v", *file, err)
+	}
+	services, err := config.Order()
+	if err != nil {
+		return err
+	}
+
+	// check all the services before changing the stack
+	type container struct {
+		name       string
+		config     *runconfig.Config
+		hostConfig *runconfig.HostConfig
+	}
+	var containers []container
+	for _, s := range services {
+		cmd := flag.NewFlagSet("stack deploy", flag.ContinueOnError)
+		cmd.SetOutput(ioutil.Discard)
+		c, hostConfig, _, err := runconfig.Parse(cmd, serviceArgs(stack, config, s))
+		if err != nil {
+			return fmt.Errorf("Service %s: %v", s.Name, err)
+		}
+		if s.Entrypoint != nil {
+			c.Entrypoint = runconfig.NewEntrypoint(s.Entrypoint...)
+		}
+		containers = append(containers, container{serviceContainerName(stack, s), c, hostConfig})
+	}
+
+	names := make([]string, 0, len(config.Volumes))
+	for name := range config.Volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := cli.deployStackVolume(stack, config.Volumes[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := cli.removeStackContainers(stack); err != nil {
+		return err
+	}
+	for _, c := range containers {
+		fmt.Fprintf(cli.out, "Creating %s\n", c.name)
+		response, err := cli.createContainer(c.config, c.hostConfig, "", c.name, "")
+		if err != nil {
+			return err
+		}
+		if _, _, err := readBody(cli.call("POST", "/containers/"+response.ID+"/start", nil, nil)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CmdStackRm removes the containers of a stack, and its volumes on demand.
+//
+// Usage: docker stack rm [OPTIONS] STACK
+func (cli *DockerCli) CmdStackRm(args ...string) error {
+	cmd := cli.Subcmd("stack rm", "STACK", "Remove the containers of a stack", true)
+	volumes := cmd.Bool([]string{"v", "-volumes"}, false, "Remove the named volumes of the stack")
+	cmd.Require(flag.Exact, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	stack := cmd.Arg(0)
+	if err := cli.removeStackContainers(stack); err != nil {
+		return err
+	}
+	if !*volumes {
+		return nil
+	}
+
+	v := url.Values{}
+	filterJSON, err := filters.ToParam(filters.Args{"label": {stackNamespaceLabel + "=" + stack}})
+	if err != nil {
+		return err
+	}
+	v.Set("filters", filterJSON)
+	body, _, err := readBody(cli.call("GET", "/volumes?"+v.Encode(), nil, nil))
+	if err != nil {
+		return err
+	}
+	var list types.VolumesListResponse
+	if err := json.Unmarshal(body, &list); err != nil {
+		return err
+	}
+	for _, vol := range list.Volumes {
+		fmt.Fprintf(cli.out, "Removing volume %s\n", vol.Name)
+		if _, _, err := readBody(cli.call("DELETE", "/volumes/"+vol.Name, nil, nil)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeStackContainers removes the containers of the stack, running or not.
+func (cli *DockerCli) removeStackContainers(stack string) error {
+	v := url.Values{}
+	v.Set("all", "1")
+	filterJSON, err := filters.ToParam(filters.Args{"label": {stackNamespaceLabel + "=" + stack}})
+	if err != nil {
+		return err
+	}
+	v.Set("filters", filterJSON)
+	body, _, err := readBody(cli.call("GET", "/containers/json?"+v.Encode(), nil, nil))
+	if err != nil {
+		return err
+	}
+	var containers []types.Container
+	if err := json.Unmarshal(body, &containers); err != nil {
+		return err
+	}
+
+	for _, c := range containers {
+		name := c.ID
+		for _, n := range c.Names {
+			// the other names are the aliases of the links to the container
+			if strings.Count(n, "/") == 1 {
+				name = strings.TrimPrefix(n, "/")
+			}
+		}
+		fmt.Fprintf(cli.out, "Removing %s\n", name)
+		if _, _, err := readBody(cli.call("DELETE", "/containers/"+c.ID+"?force=1", nil, nil)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deployStackVolume creates the volume of the stack, unless it exists.
+func (cli *DockerCli) deployStackVolume(stack string, vol *compose.Volume) error {
+	name := vol.Name
+	if !vol.External {
+		name = stack + "_" + vol.Name
+	}
+	_, statusCode, err := readBody(cli.call("GET", "/volumes/"+name, nil, nil))
+	if err == nil {
+		return nil
+	}
+	if statusCode != 404 {
+		return err
+	}
+	if vol.External {
+		return fmt.Errorf("The external volume %s doesn't exist", name)
+	}
+
+	labels := map[string]string{stackNamespaceLabel: stack}
+	for k, v := range vol.Labels {
+		labels[k] = v
+	}
+	driver := vol.Driver
+	if driver == "" {
+		driver = "local"
+	}
+	fmt.Fprintf(cli.out, "Creating volume %s\n", name)
+	req := &types.VolumeCreateRequest{
+		Name:       name,
+		Driver:     driver,
+		DriverOpts: vol.DriverOpts,
+		Labels:     labels,
+	}
+	_, _, err = readBody(cli.call("POST", "/volumes/create", req, nil))
+	return err
+}
+
+// serviceContainerName returns the name of the container of the service of
+// the stack.
+func serviceContainerName(stack string, s *compose.Service) string {
+	if s.ContainerName != "" {
+		return s.ContainerName
+	}
+	return stack + "_" + s.Name
+}
+
+// serviceArgs returns the arguments of docker create creating the container
+// of the service of the stack. The service is linked to the services it
+// depends on, and the names of the volumes and the containers of the stack
+// are prefixed by the name of the stack.
+func serviceArgs(stack string, config *compose.Config, s *compose.Service) []string {
+	args := []string{
+		"--label", stackNamespaceLabel + "=" + stack,
+		"--label", stackServiceLabel + "=" + s.Name,
+	}
+	add := func(name string, values ...string) {
+		for _, v := range values {
+			args = append(args, name, v)
+		}
+	}
+	labels := make([]string, 0, len(s.Labels))
+	for k, v := range s.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	add("--label", labels...)
+	add("--env", s.Environment...)
+	add("--publish", s.Ports...)
+	add("--expose", s.Expose...)
+	add("--cap-add", s.CapAdd...)
+	add("--cap-drop", s.CapDrop...)
+	add("--dns", s.DNS...)
+	add("--add-host", s.ExtraHosts...)
+
+	for _, spec := range s.Volumes {
+		parts := strings.SplitN(spec, ":", 2)
+		if vol, exists := config.Volumes[parts[0]]; exists && len(parts) == 2 && !vol.External {
+			spec = stack + "_" + spec
+		}
+		add("--volume", spec)
+	}
+	for _, from := range s.VolumesFrom {
+		if strings.HasPrefix(from, "container:") {
+			add("--volumes-from", strings.TrimPrefix(from, "container:"))
+			continue
+		}
+		parts := strings.SplitN(from, ":", 2)
+		parts[0] = serviceContainerName(stack, config.Services[parts[0]])
+		add("--volumes-from", strings.Join(parts, ":"))
+	}
+
+	netMode := s.NetworkMode
+	if strings.HasPrefix(netMode, "service:") {
+		netMode = "container:" + serviceContainerName(stack, config.Services[strings.TrimPrefix(netMode, "service:")])
+	}
+	if netMode != "" {
+		add("--net", netMode)
+	}
+	if netMode == "" || netMode == "bridge" {
+		// the services reach the services they depend on by their name
+		aliases := make(map[string]string)
+		for _, dep := range s.DependsOn {
+			aliases[dep] = dep
+		}
+		for _, link := range s.Links {
+			parts := strings.SplitN(link, ":", 2)
+			if len(parts) == 1 {
+				parts = append(parts, parts[0])
+			}
+			aliases[parts[0]] = parts[1]
+		}
+		deps := make([]string, 0, len(aliases))
+		for dep := range aliases {
+			deps = append(deps, dep)
+		}
+		sort.Strings(deps)
+		for _, dep := range deps {
+			add("--link", serviceContainerName(stack, config.Services[dep])+":"+aliases[dep])
+		}
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"--restart", s.Restart},
+		{"--user", s.User},
+		{"--workdir", s.WorkingDir},
+		{"--hostname", s.Hostname},
+		{"--memory", s.MemLimit},
+		{"--cpu-shares", s.CPUShares},
+	} {
+		if f.value != "" {
+			add(f.name, f.value)
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		value bool
+	}{
+		{"--privileged", s.Privileged},
+		{"--read-only", s.ReadOnly},
+		{"--tty", s.Tty},
+		{"--interactive", s.StdinOpen},
+	} {
+		if f.value {
+			args = append(args, f.name)
+		}
+	}
+	args = append(args, s.Image)
+	return append(args, s.Command...)
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/pkg/compose"
+)
+
+func TestServiceArgs(t *testing.T) {
+	config := &compose.Config{
+		Services: map[string]*compose.Service{
+			"web": {
+				Name:        "web",
+				Image:       "nginx",
+				Command:     []string{"nginx", "-g", "daemon off;"},
+				Links:       []string{"app:backend"},
+				DependsOn:   []string{"db"},
+				Volumes:     []string{"data:/data", "shared:/shared", "/srv:/srv:ro"},
+				VolumesFrom: []string{"app:ro", "container:other"},
+				Restart:     "always",
+				ReadOnly:    true,
+			},
+			"app": {Name: "app", Image: "app", ContainerName: "backend"},
+			"db":  {Name: "db", Image: "postgres"},
+		},
+		Volumes: map[string]*compose.Volume{
+			"data":   {Name: "data"},
+			"shared": {Name: "shared", External: true},
+		},
+	}
+
+	expected := []string{
+		"--label", "com.docker.stack.namespace=prod",
+		"--label", "com.docker.stack.service=web",
+		"--volume", "prod_data:/data",
+		"--volume", "shared:/shared",
+		"--volume", "/srv:/srv:ro",
+		"--volumes-from", "backend:ro",
+		"--volumes-from", "other",
+		"--link", "backend:backend",
+		"--link", "prod_db:db",
+		"--restart", "always",
+		"--read-only",
+		"nginx", "nginx", "-g", "daemon off;",
+	}
+	if args := serviceArgs("prod", config, config.Services["web"]); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected %q, got %q", expected, args)
+	}
+
+	config.Services["web"].NetworkMode = "service:db"
+	expected = []string{
+		"--label", "com.docker.stack.namespace=prod",
+		"--label", "com.docker.stack.service=web",
+		"--volume", "prod_data:/data",
+		"--volume", "shared:/shared",
+		"--volume", "/srv:/srv:ro",
+		"--volumes-from", "backend:ro",
+		"--volumes-from", "other",
+		"--net", "container:prod_db",
+		"--restart", "always",
+		"--read-only",
+		"nginx", "nginx", "-g", "daemon off;",
+	}
+	if args := serviceArgs("prod", config, config.Services["web"]); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected %q without links in the network of db, got %q", expected, args)
+	}
+}
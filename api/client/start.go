@@ -22,15 +22,10 @@ func (cli *DockerCli) forwardAllSignals(cid string) chan os.Signal {
 			if s == signal.SIGCHLD {
 				continue
 			}
-			var sig string
-			for sigStr, sigN := range signal.SignalMap {
-				if sigN == s {
-					sig = sigStr
-					break
-				}
-			}
+			sig := signal.Name(s)
 			if sig == "" {
 				fmt.Fprintf(cli.err, "Unsupported signal: %v. Discarding.\n", s)
+				continue
 			}
 			if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/kill?signal=%s", cid, sig), nil, nil)); err != nil {
 				logrus.Debugf("Error sending signal: %s", err)
@@ -54,7 +49,9 @@ func (cli *DockerCli) CmdStart(args ...string) error {
 	)
 
 	cmd.Require(flag.Min, 1)
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	if *attach || *openStdin {
 		if cmd.NArg() > 1 {
@@ -9,10 +9,13 @@ import (
 	"strings"
 	"sync"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/docker/docker/api/types"
-	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/parsers/filters"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/units"
 )
 
@@ -24,10 +27,50 @@ type containerStats struct {
 	MemoryPercentage float64
 	NetworkRx        float64
 	NetworkTx        float64
+	BlockRead        float64
+	BlockWrite       float64
+	PidsCurrent      uint64
 	mu               sync.RWMutex
 	err              error
 }
 
+// statsContext is the stats of a container given to the template of the
+// --format flag of stats.
+type statsContext struct {
+	Container string
+	CPUPerc   string
+	MemUsage  string
+	MemPerc   string
+	NetIO     string
+	BlockIO   string
+	PIDs      string
+}
+
+// stats is the set of the containers whose stats are displayed.
+type stats struct {
+	mu sync.Mutex
+	cs []*containerStats
+}
+
+func (s *stats) add(cs *containerStats) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.cs {
+		if c.Name == cs.Name {
+			return false
+		}
+	}
+	s.cs = append(s.cs, cs)
+	sort.Sort(byName(s.cs))
+	return true
+}
+
+type byName []*containerStats
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
 func (s *containerStats) Collect(cli *DockerCli, streamStats bool) {
 	v := url.Values{}
 	if streamStats {
@@ -59,6 +102,12 @@ func (s *containerStats) Collect(cli *DockerCli, streamStats bool) {
 				memPercent = float64(v.MemoryStats.Usage) / float64(v.MemoryStats.Limit) * 100.0
 				cpuPercent = 0.0
 			)
+			// the stats of the daemons supporting it have the previous CPU stats
+			if v.PreCpuStats.SystemUsage != 0 {
+				previousCPU = v.PreCpuStats.CpuUsage.TotalUsage
+				previousSystem = v.PreCpuStats.SystemUsage
+				start = false
+			}
 			if !start {
 				cpuPercent = calculateCPUPercent(previousCPU, previousSystem, v)
 			}
@@ -70,6 +119,8 @@ func (s *containerStats) Collect(cli *DockerCli, streamStats bool) {
 			s.MemoryPercentage = memPercent
 			s.NetworkRx = float64(v.Network.RxBytes)
 			s.NetworkTx = float64(v.Network.TxBytes)
+			s.BlockRead, s.BlockWrite = calculateBlockIO(v.BlkioStats)
+			s.PidsCurrent = v.PidsStats.Current
 			s.mu.Unlock()
 			previousCPU = v.CpuStats.CpuUsage.TotalUsage
 			previousSystem = v.CpuStats.SystemUsage
@@ -88,6 +139,9 @@ func (s *containerStats) Collect(cli *DockerCli, streamStats bool) {
 			s.CPUPercentage = 0
 			s.Memory = 0
 			s.MemoryPercentage = 0
+			s.BlockRead = 0
+			s.BlockWrite = 0
+			s.PidsCurrent = 0
 			s.mu.Unlock()
 		case err := <-u:
 			if err != nil {
@@ -103,77 +157,130 @@ func (s *containerStats) Collect(cli *DockerCli, streamStats bool) {
 	}
 }
 
-func (s *containerStats) Display(w io.Writer) error {
+func (s *containerStats) Display(w io.Writer, tmpl *template.Template) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if s.err != nil {
 		return s.err
 	}
-	fmt.Fprintf(w, "%s\t%.2f%%\t%s/%s\t%.2f%%\t%s/%s\n",
-		s.Name,
-		s.CPUPercentage,
-		units.HumanSize(s.Memory), units.HumanSize(s.MemoryLimit),
-		s.MemoryPercentage,
-		units.HumanSize(s.NetworkRx), units.HumanSize(s.NetworkTx))
+	c := &statsContext{
+		Container: s.Name,
+		CPUPerc:   fmt.Sprintf("%.2f%%", s.CPUPercentage),
+		MemUsage:  units.HumanSize(s.Memory) + "/" + units.HumanSize(s.MemoryLimit),
+		MemPerc:   fmt.Sprintf("%.2f%%", s.MemoryPercentage),
+		NetIO:     units.HumanSize(s.NetworkRx) + "/" + units.HumanSize(s.NetworkTx),
+		BlockIO:   units.HumanSize(s.BlockRead) + "/" + units.HumanSize(s.BlockWrite),
+		PIDs:      fmt.Sprintf("%d", s.PidsCurrent),
+	}
+	if tmpl != nil {
+		if err := tmpl.Execute(w, c); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		c.Container, c.CPUPerc, c.MemUsage, c.MemPerc, c.NetIO, c.BlockIO, c.PIDs)
 	return nil
 }
 
 // CmdStats displays a live stream of resource usage statistics for one or more containers.
 //
-// This shows real-time information on CPU usage, memory usage, and network I/O.
+// This shows real-time information on CPU usage, memory usage, network I/O,
+// block I/O and the number of processes. Without containers, the stats of all
+// the running containers are displayed, including the ones started later.
 //
-// Usage: docker stats CONTAINER [CONTAINER...]
+// Usage: docker stats [OPTIONS] [CONTAINER...]
 func (cli *DockerCli) CmdStats(args ...string) error {
-	cmd := cli.Subcmd("stats", "CONTAINER [CONTAINER...]", "Display a live stream of one or more containers' resource usage statistics", true)
+	cmd := cli.Subcmd("stats", "[CONTAINER...]", "Display a live stream of one or more containers' resource usage statistics", true)
 	noStream := cmd.Bool([]string{"-no-stream"}, false, "Disable streaming stats and only pull the first result")
-	cmd.Require(flag.Min, 1)
-	cmd.ParseFlags(args, true)
+	format := cmd.String([]string{"-format"}, "", "Pretty-print stats using a Go template")
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if *format != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
 
-	names := cmd.Args()
-	sort.Strings(names)
 	var (
-		cStats []*containerStats
-		w      = tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+		cStats  = &stats{}
+		w       = tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+		names   = cmd.Args()
+		showAll = len(names) == 0
 	)
 	printHeader := func() {
 		if !*noStream {
 			fmt.Fprint(cli.out, "\033[2J")
 			fmt.Fprint(cli.out, "\033[H")
 		}
-		io.WriteString(w, "CONTAINER\tCPU %\tMEM USAGE/LIMIT\tMEM %\tNET I/O\n")
+		if tmpl == nil {
+			io.WriteString(w, "CONTAINER\tCPU %\tMEM USAGE/LIMIT\tMEM %\tNET I/O\tBLOCK I/O\tPIDS\n")
+		}
+	}
+	collect := func(name string) {
+		s := &containerStats{Name: name}
+		if cStats.add(s) {
+			go s.Collect(cli, !*noStream)
+		}
+	}
+
+	if showAll {
+		if !*noStream {
+			// follow the containers started from now on, the stats of the
+			// containers which stop are dropped once their stream ends
+			if err := cli.watchContainerStarts(collect); err != nil {
+				return err
+			}
+		}
+		running, err := cli.runningContainerNames()
+		if err != nil {
+			return err
+		}
+		names = running
 	}
 	for _, n := range names {
-		s := &containerStats{Name: n}
-		cStats = append(cStats, s)
-		go s.Collect(cli, !*noStream)
+		collect(n)
 	}
 	// do a quick pause so that any failed connections for containers that do not exist are able to be
 	// evicted before we display the initial or default values.
 	time.Sleep(500 * time.Millisecond)
-	var errs []string
-	for _, c := range cStats {
-		c.mu.Lock()
-		if c.err != nil {
-			errs = append(errs, fmt.Sprintf("%s: %v", c.Name, c.err))
+	if !showAll {
+		var errs []string
+		cStats.mu.Lock()
+		for _, c := range cStats.cs {
+			c.mu.Lock()
+			if c.err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", c.Name, c.err))
+			}
+			c.mu.Unlock()
+		}
+		cStats.mu.Unlock()
+		if len(errs) > 0 {
+			return fmt.Errorf("%s", strings.Join(errs, ", "))
 		}
-		c.mu.Unlock()
-	}
-	if len(errs) > 0 {
-		return fmt.Errorf("%s", strings.Join(errs, ", "))
 	}
 	for range time.Tick(500 * time.Millisecond) {
 		printHeader()
+		cStats.mu.Lock()
 		toRemove := []int{}
-		for i, s := range cStats {
-			if err := s.Display(w); err != nil && !*noStream {
+		for i, s := range cStats.cs {
+			if err := s.Display(w, tmpl); err != nil && !*noStream {
 				toRemove = append(toRemove, i)
 			}
 		}
 		for j := len(toRemove) - 1; j >= 0; j-- {
 			i := toRemove[j]
-			cStats = append(cStats[:i], cStats[i+1:]...)
+			cStats.cs = append(cStats.cs[:i], cStats.cs[i+1:]...)
 		}
-		if len(cStats) == 0 {
+		n := len(cStats.cs)
+		cStats.mu.Unlock()
+		if n == 0 && !showAll {
 			return nil
 		}
 		w.Flush()
@@ -184,6 +291,86 @@ func (cli *DockerCli) CmdStats(args ...string) error {
 	return nil
 }
 
+// runningContainerNames returns the names of the running containers.
+func (cli *DockerCli) runningContainerNames() ([]string, error) {
+	rdr, _, err := cli.call("GET", "/containers/json", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rdr.Close()
+
+	var containers []types.Container
+	if err := json.NewDecoder(rdr).Decode(&containers); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, c := range containers {
+		names = append(names, containerName(c.Names, c.ID))
+	}
+	return names, nil
+}
+
+// watchContainerStarts calls fn in the background with the name of each
+// container started, until the events of the daemon end.
+func (cli *DockerCli) watchContainerStarts(fn func(name string)) error {
+	filterJSON, err := filters.ToParam(filters.Args{"event": {"start"}})
+	if err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Set("filters", filterJSON)
+	rdr, _, err := cli.call("GET", "/events?"+v.Encode(), nil, nil)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer rdr.Close()
+		dec := json.NewDecoder(rdr)
+		for {
+			var ev jsonmessage.JSONMessage
+			if err := dec.Decode(&ev); err != nil {
+				return
+			}
+			body, _, err := readBody(cli.call("GET", "/containers/"+ev.ID+"/json", nil, nil))
+			if err != nil {
+				continue
+			}
+			var c types.ContainerJSON
+			if err := json.Unmarshal(body, &c); err != nil {
+				continue
+			}
+			fn(strings.TrimPrefix(c.Name, "/"))
+		}
+	}()
+	return nil
+}
+
+// containerName returns the default name of a container, the one without a
+// link alias, or its short ID.
+func containerName(names []string, id string) string {
+	for _, name := range names {
+		name = strings.TrimPrefix(name, "/")
+		if !strings.Contains(name, "/") {
+			return name
+		}
+	}
+	return stringid.TruncateID(id)
+}
+
+// calculateBlockIO returns the bytes read from and written to the block
+// devices.
+func calculateBlockIO(blkio types.BlkioStats) (read float64, write float64) {
+	for _, entry := range blkio.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += float64(entry.Value)
+		case "write":
+			write += float64(entry.Value)
+		}
+	}
+	return
+}
+
 func calculateCPUPercent(previousCPU, previousSystem uint64, v *types.Stats) float64 {
 	var (
 		cpuPercent = 0.0
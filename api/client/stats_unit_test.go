@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"sync"
 	"testing"
+	"text/template"
+
+	"github.com/docker/docker/api/types"
 )
 
 func TestDisplay(t *testing.T) {
@@ -15,15 +18,48 @@ func TestDisplay(t *testing.T) {
 		MemoryPercentage: 100.0 / 2048.0 * 100.0,
 		NetworkRx:        100 * 1024 * 1024,
 		NetworkTx:        800 * 1024 * 1024,
+		BlockRead:        100 * 1024 * 1024,
+		BlockWrite:       800 * 1024 * 1024,
+		PidsCurrent:      1,
 		mu:               sync.RWMutex{},
 	}
 	var b bytes.Buffer
-	if err := c.Display(&b); err != nil {
+	if err := c.Display(&b, nil); err != nil {
 		t.Fatalf("c.Display() gave error: %s", err)
 	}
 	got := b.String()
-	want := "app\t30.00%\t104.9 MB/2.147 GB\t4.88%\t104.9 MB/838.9 MB\n"
+	want := "app\t30.00%\t104.9 MB/2.147 GB\t4.88%\t104.9 MB/838.9 MB\t104.9 MB/838.9 MB\t1\n"
+	if got != want {
+		t.Fatalf("c.Display() = %q, want %q", got, want)
+	}
+
+	b.Reset()
+	tmpl := template.Must(template.New("").Parse("{{.Container}}: {{.CPUPerc}} {{.PIDs}}"))
+	if err := c.Display(&b, tmpl); err != nil {
+		t.Fatalf("c.Display() gave error: %s", err)
+	}
+	got = b.String()
+	want = "app: 30.00% 1\n"
 	if got != want {
 		t.Fatalf("c.Display() = %q, want %q", got, want)
 	}
 }
+
+func TestCalculateBlockIO(t *testing.T) {
+	blkio := types.BlkioStats{
+		IoServiceBytesRecursive: []types.BlkioStatEntry{
+			{Major: 8, Minor: 0, Op: "Read", Value: 1234},
+			{Major: 8, Minor: 1, Op: "read", Value: 4567},
+			{Major: 8, Minor: 0, Op: "Write", Value: 123},
+			{Major: 8, Minor: 1, Op: "write", Value: 456},
+			{Major: 8, Minor: 0, Op: "Total", Value: 1357},
+		},
+	}
+	read, write := calculateBlockIO(blkio)
+	if read != 5801 {
+		t.Fatalf("read = %v, want 5801", read)
+	}
+	if write != 579 {
+		t.Fatalf("write = %v, want 579", write)
+	}
+}
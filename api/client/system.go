@@ -0,0 +1,186 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/parsers/filters"
+	"github.com/docker/docker/pkg/units"
+)
+
+// CmdSystem is the parent subcommand for all system commands.
+//
+// Usage: docker system <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdSystem(args ...string) error {
+	description := "Manage Docker\n\nCommands:\n"
+	commands := [][]string{
+		{"dial-stdio", "Forward the standard input and output to the daemon"},
+		{"prune", "Remove unused data"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker system COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("system", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdSystemDialStdio forwards the standard input and output to the socket of
+// the daemon, for the clients connecting to it through ssh.
+//
+// Usage: docker system dial-stdio
+func (cli *DockerCli) CmdSystemDialStdio(args ...string) error {
+	cmd := cli.Subcmd("system dial-stdio", "", "Forward the standard input and output to the daemon", true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	conn, err := cli.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	inDone := make(chan error, 1)
+	outDone := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(conn, cli.in)
+		// the daemon answers the requests sent before the end of the input
+		if cw, ok := conn.(interface {
+			CloseWrite() error
+		}); ok {
+			cw.CloseWrite()
+		}
+		inDone <- err
+	}()
+	go func() {
+		_, err := io.Copy(cli.out, conn)
+		outDone <- err
+	}()
+
+	select {
+	case err := <-outDone:
+		return err
+	case err := <-inDone:
+		if err != nil {
+			return err
+		}
+		return <-outDone
+	}
+}
+
+// CmdSystemPrune removes the stopped containers and the dangling images,
+// all the unused images with --all, and the unused volumes with --volumes.
+//
+// Usage: docker system prune [OPTIONS]
+func (cli *DockerCli) CmdSystemPrune(args ...string) error {
+	cmd := cli.Subcmd("system prune", "", "Remove unused data", true)
+	all := cmd.Bool([]string{"a", "-all"}, false, "Remove all unused images, not just dangling ones")
+	force := cmd.Bool([]string{"f", "-force"}, false, "Do not prompt for confirmation")
+	pruneVolumes := cmd.Bool([]string{"-volumes"}, false, "Prune volumes")
+
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"-filter"}, "Provide filter values (e.g. 'label=<key>=<value>')")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	v, err := pruneFilterValues(flFilter.GetAll())
+	if err != nil {
+		return err
+	}
+	imageValues, err := pruneFilterValues(imagePruneFilters(flFilter.GetAll(), *all))
+	if err != nil {
+		return err
+	}
+
+	if !*force {
+		warning := "WARNING! This will remove:\n\t- all stopped containers\n"
+		if *all {
+			warning += "\t- all images without at least one container associated to them\n"
+		} else {
+			warning += "\t- all dangling images\n"
+		}
+		if *pruneVolumes {
+			warning += "\t- all volumes not used by at least one container\n"
+		}
+		if !cli.confirmPrune(warning + "Are you sure you want to continue? [y/N] ") {
+			return nil
+		}
+	}
+
+	// the containers are removed first, for their images and their volumes
+	// to be unused
+	var spaceReclaimed uint64
+	containers, err := cli.pruneContainers(v)
+	if err != nil {
+		return err
+	}
+	cli.printContainersPruneReport(containers)
+	spaceReclaimed += containers.SpaceReclaimed
+
+	if *pruneVolumes {
+		volumes, err := cli.pruneVolumes(v)
+		if err != nil {
+			return err
+		}
+		cli.printVolumesPruneReport(volumes)
+		spaceReclaimed += volumes.SpaceReclaimed
+	}
+
+	images, err := cli.pruneImages(imageValues)
+	if err != nil {
+		return err
+	}
+	cli.printImagesPruneReport(images)
+	spaceReclaimed += images.SpaceReclaimed
+
+	fmt.Fprintf(cli.out, "Total reclaimed space: %s\n", units.HumanSize(float64(spaceReclaimed)))
+	return nil
+}
+
+// pruneFilterValues returns the query of a prune with the filters given as
+// key=value.
+func pruneFilterValues(flFilters []string) (url.Values, error) {
+	pruneFilterArgs := filters.Args{}
+	for _, f := range flFilters {
+		var err error
+		pruneFilterArgs, err = filters.ParseFlag(f, pruneFilterArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	v := url.Values{}
+	if len(pruneFilterArgs) > 0 {
+		filterJSON, err := filters.ToParam(pruneFilterArgs)
+		if err != nil {
+			return nil, err
+		}
+		v.Set("filters", filterJSON)
+	}
+	return v, nil
+}
+
+// confirmPrune prints the warning of a prune, and returns whether the user
+// confirmed it.
+func (cli *DockerCli) confirmPrune(warning string) bool {
+	fmt.Fprint(cli.out, warning)
+	answer, _ := bufio.NewReader(cli.in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
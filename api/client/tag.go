@@ -16,7 +16,9 @@ func (cli *DockerCli) CmdTag(args ...string) error {
 	force := cmd.Bool([]string{"f", "#force", "-force"}, false, "Force")
 	cmd.Require(flag.Exact, 2)
 
-	cmd.ParseFlags(args, true)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
 
 	var (
 		repository, tag = parsers.ParseRepositoryTag(cmd.Arg(1))
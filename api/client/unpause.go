@@ -12,7 +12,9 @@ import (
 func (cli *DockerCli) CmdUnpause(args ...string) error {
 	cmd := cli.Subcmd("unpause", "CONTAINER [CONTAINER...]", "Unpause all processes within a container", true)
 	cmd.Require(flag.Min, 1)
-	cmd.ParseFlags(args, false)
+	if err := cmd.ParseFlags(args, false); err != nil {
+		return err
+	}
 
 	var errNames []string
 	for _, name := range cmd.Args() {
@@ -26,6 +26,7 @@ import (
 	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/term"
+	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/registry"
 )
 
@@ -48,14 +49,55 @@ func (cli *DockerCli) encodeData(data interface{}) (*bytes.Buffer, error) {
 	return params, nil
 }
 
+// apiVersion returns the API version of the requests: the highest version
+// supported by both the client and the daemon. The daemon is pinged for its
+// version before the first request. The requests to a daemon which doesn't
+// report its version use the version of the client.
+func (cli *DockerCli) apiVersion() version.Version {
+	cli.negotiateOnce.Do(func() {
+		cli.version = api.APIVERSION
+
+		req, err := http.NewRequest("GET", "/_ping", nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("User-Agent", "Docker-Client/"+dockerversion.VERSION)
+		req.URL.Host = cli.addr
+		req.URL.Scheme = cli.scheme
+		resp, err := cli.HTTPClient().Do(req)
+		if err != nil {
+			// the error is reported by the request itself
+			return
+		}
+		resp.Body.Close()
+
+		serverVersion := version.Version(resp.Header.Get("Api-Version"))
+		if serverVersion != "" && serverVersion.LessThan(api.APIVERSION) {
+			logrus.Debugf("Using the API version %s of the daemon instead of %s", serverVersion, api.APIVERSION)
+			cli.version = serverVersion
+		}
+	})
+	return cli.version
+}
+
 func (cli *DockerCli) clientRequest(method, path string, in io.Reader, headers map[string][]string) (io.ReadCloser, string, int, error) {
+	resp, statusCode, err := cli.clientResponse(method, path, in, headers)
+	if err != nil {
+		return nil, "", statusCode, err
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), statusCode, nil
+}
+
+// clientResponse sends the request to the daemon like clientRequest, and
+// returns its response, with its headers.
+func (cli *DockerCli) clientResponse(method, path string, in io.Reader, headers map[string][]string) (*http.Response, int, error) {
 	expectedPayload := (method == "POST" || method == "PUT")
 	if expectedPayload && in == nil {
 		in = bytes.NewReader([]byte{})
 	}
-	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", api.APIVERSION, path), in)
+	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", cli.apiVersion(), path), in)
 	if err != nil {
-		return nil, "", -1, err
+		return nil, -1, err
 	}
 
 	// Add CLI Config's HTTP Headers BEFORE we set the Docker headers
@@ -85,27 +127,27 @@ func (cli *DockerCli) clientRequest(method, path string, in io.Reader, headers m
 	}
 	if err != nil {
 		if strings.Contains(err.Error(), "connection refused") {
-			return nil, "", statusCode, errConnectionRefused
+			return nil, statusCode, errConnectionRefused
 		}
 
-		if cli.tlsConfig == nil {
-			return nil, "", statusCode, fmt.Errorf("%v. Are you trying to connect to a TLS-enabled daemon without TLS?", err)
+		if cli.tlsConfig == nil && cli.proto != "ssh" {
+			return nil, statusCode, fmt.Errorf("%v. Are you trying to connect to a TLS-enabled daemon without TLS?", err)
 		}
-		return nil, "", statusCode, fmt.Errorf("An error occurred trying to connect: %v", err)
+		return nil, statusCode, fmt.Errorf("An error occurred trying to connect: %v", err)
 	}
 
 	if statusCode < 200 || statusCode >= 400 {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return nil, "", statusCode, err
+			return nil, statusCode, err
 		}
 		if len(body) == 0 {
-			return nil, "", statusCode, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(statusCode), req.URL)
+			return nil, statusCode, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(statusCode), req.URL)
 		}
-		return nil, "", statusCode, fmt.Errorf("Error response from daemon: %s", bytes.TrimSpace(body))
+		return nil, statusCode, fmt.Errorf("Error response from daemon: %s", bytes.TrimSpace(body))
 	}
 
-	return resp.Body, resp.Header.Get("Content-Type"), statusCode, nil
+	return resp, statusCode, nil
 }
 
 func (cli *DockerCli) clientRequestAttemptLogin(method, path string, in io.Reader, out io.Writer, index *registry.IndexInfo, cmdName string) (io.ReadCloser, int, error) {
@@ -190,7 +232,7 @@ func (cli *DockerCli) streamBody(body io.ReadCloser, contentType string, rawTerm
 	defer body.Close()
 
 	if api.MatchesContentType(contentType, "application/json") {
-		return jsonmessage.DisplayJSONMessagesStream(body, stdout, cli.outFd, cli.isTerminalOut)
+		return jsonmessage.DisplayJSONMessagesStream(body, stdout, cli.outFd, cli.isTerminalOut, nil)
 	}
 	if stdout != nil || stderr != nil {
 		// When TTY is ON, use regular copy
@@ -228,7 +270,20 @@ func (cli *DockerCli) resizeTty(id string, isExec bool) {
 }
 
 func waitForExit(cli *DockerCli, containerID string) (int, error) {
-	stream, _, err := cli.call("POST", "/containers/"+containerID+"/wait", nil, nil)
+	return waitForCondition(cli, containerID, "")
+}
+
+// waitForCondition waits until the condition of the container is met, and
+// returns its exit code. The daemon waits until the container isn't running
+// with an empty condition.
+func waitForCondition(cli *DockerCli, containerID, condition string) (int, error) {
+	path := "/containers/" + containerID + "/wait"
+	if condition != "" && condition != "not-running" {
+		v := url.Values{}
+		v.Set("condition", condition)
+		path += "?" + v.Encode()
+	}
+	stream, _, err := cli.call("POST", path, nil, nil)
 	if err != nil {
 		return -1, err
 	}
@@ -0,0 +1,328 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/parsers/filters"
+	"github.com/docker/docker/pkg/units"
+	"github.com/docker/docker/runconfig"
+)
+
+// CmdVolume is the parent subcommand for all volume commands.
+//
+// Usage: docker volume <COMMAND> [OPTIONS]
+func (cli *DockerCli) CmdVolume(args ...string) error {
+	description := "Manage Docker volumes\n\nCommands:\n"
+	commands := [][]string{
+		{"create", "Create a volume"},
+		{"inspect", "Return low-level information on a volume"},
+		{"ls", "List volumes"},
+		{"prune", "Remove all unused volumes"},
+		{"rm", "Remove one or more volumes"},
+		{"snapshot", "Create a copy of a volume"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker volume COMMAND --help' for more information on a command"
+	cmd := cli.Subcmd("volume", "COMMAND [OPTIONS]", description, true)
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdVolumeLs outputs a list of the named and anonymous volumes.
+//
+// Usage: docker volume ls [OPTIONS]
+func (cli *DockerCli) CmdVolumeLs(args ...string) error {
+	cmd := cli.Subcmd("volume ls", "", "List volumes", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display volume names")
+
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	volFilterArgs := filters.Args{}
+	for _, f := range flFilter.GetAll() {
+		var err error
+		volFilterArgs, err = filters.ParseFlag(f, volFilterArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	v := url.Values{}
+	if len(volFilterArgs) > 0 {
+		filterJSON, err := filters.ToParam(volFilterArgs)
+		if err != nil {
+			return err
+		}
+		v.Set("filters", filterJSON)
+	}
+
+	body, _, err := readBody(cli.call("GET", "/volumes?"+v.Encode(), nil, nil))
+	if err != nil {
+		return err
+	}
+
+	var volumes types.VolumesListResponse
+	if err := json.Unmarshal(body, &volumes); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintln(w, "DRIVER\tVOLUME NAME")
+	}
+	for _, v := range volumes.Volumes {
+		if *quiet {
+			fmt.Fprintln(w, v.Name)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", v.Driver, v.Name)
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+// CmdVolumeInspect displays low-level information on one or more volumes.
+//
+// Usage: docker volume inspect [OPTIONS] VOLUME [VOLUME...]
+func (cli *DockerCli) CmdVolumeInspect(args ...string) error {
+	cmd := cli.Subcmd("volume inspect", "VOLUME [VOLUME...]", "Return low-level information on a volume", true)
+	tmplStr := cmd.String([]string{"f", "-format"}, "", "Format the output using the given go template")
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if *tmplStr != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*tmplStr); err != nil {
+			return StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
+
+	var (
+		volumes []*types.Volume
+		status  = 0
+	)
+	for _, name := range cmd.Args() {
+		body, _, err := readBody(cli.call("GET", "/volumes/"+name, nil, nil))
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+
+		var v types.Volume
+		if err := json.Unmarshal(body, &v); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+
+		if tmpl != nil {
+			if err := tmpl.Execute(cli.out, &v); err != nil {
+				return err
+			}
+			cli.out.Write([]byte{'\n'})
+			continue
+		}
+		volumes = append(volumes, &v)
+	}
+
+	if tmpl == nil {
+		b, err := json.Marshal(volumes)
+		if err != nil {
+			return err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, b, "", "    "); err != nil {
+			return err
+		}
+		indented.WriteString("\n")
+		if _, err := indented.WriteTo(cli.out); err != nil {
+			return err
+		}
+	}
+
+	if status != 0 {
+		return StatusError{StatusCode: status}
+	}
+	return nil
+}
+
+// CmdVolumeCreate creates a new volume, named or anonymous.
+//
+// Usage: docker volume create [OPTIONS]
+func (cli *DockerCli) CmdVolumeCreate(args ...string) error {
+	cmd := cli.Subcmd("volume create", "", "Create a volume", true)
+	name := cmd.String([]string{"-name"}, "", "Specify the volume name, instead of creating an anonymous volume")
+	driver := cmd.String([]string{"d", "-driver"}, "local", "Specify the volume driver of a named volume")
+	from := cmd.String([]string{"-from"}, "", "Create the volume as a copy of another volume")
+
+	flOpts := opts.NewListOpts(nil)
+	cmd.Var(&flOpts, []string{"o", "-opt"}, "Set driver specific options")
+	flLabels := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flLabels, []string{"-label"}, "Set metadata for a volume")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	// a copy has the driver of the volume copied, unless one is given
+	if *from != "" && !cmd.IsSet("d") && !cmd.IsSet("-driver") {
+		*driver = ""
+	}
+
+	req := &types.VolumeCreateRequest{
+		Name:       *name,
+		Driver:     *driver,
+		DriverOpts: runconfig.ConvertKVStringsToMap(flOpts.GetAll()),
+		From:       *from,
+		Labels:     runconfig.ConvertKVStringsToMap(flLabels.GetAll()),
+	}
+	return cli.createVolume(req)
+}
+
+// CmdVolumeSnapshot creates a copy of a volume, named after the volume and
+// the current time if no name is given.
+//
+// Usage: docker volume snapshot [OPTIONS] VOLUME [SNAPSHOT]
+func (cli *DockerCli) CmdVolumeSnapshot(args ...string) error {
+	cmd := cli.Subcmd("volume snapshot", "VOLUME [SNAPSHOT]", "Create a copy of a volume", true)
+	flLabels := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flLabels, []string{"-label"}, "Set metadata for the snapshot")
+	cmd.Require(flag.Min, 1)
+	cmd.Require(flag.Max, 2)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	from := cmd.Arg(0)
+	name := cmd.Arg(1)
+	if name == "" {
+		name = from + "-" + time.Now().UTC().Format("20060102150405")
+	}
+
+	req := &types.VolumeCreateRequest{
+		Name:   name,
+		From:   from,
+		Labels: runconfig.ConvertKVStringsToMap(flLabels.GetAll()),
+	}
+	return cli.createVolume(req)
+}
+
+func (cli *DockerCli) createVolume(req *types.VolumeCreateRequest) error {
+	body, _, err := readBody(cli.call("POST", "/volumes/create", req, nil))
+	if err != nil {
+		return err
+	}
+
+	var v types.Volume
+	if err := json.Unmarshal(body, &v); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", v.Name)
+	return nil
+}
+
+// CmdVolumeRm removes one or more volumes.
+//
+// Usage: docker volume rm VOLUME [VOLUME...]
+func (cli *DockerCli) CmdVolumeRm(args ...string) error {
+	cmd := cli.Subcmd("volume rm", "VOLUME [VOLUME...]", "Remove one or more volumes", true)
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	var errNames []string
+	for _, name := range cmd.Args() {
+		if _, _, err := readBody(cli.call("DELETE", "/volumes/"+name, nil, nil)); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			errNames = append(errNames, name)
+		} else {
+			fmt.Fprintf(cli.out, "%s\n", name)
+		}
+	}
+	if len(errNames) > 0 {
+		return fmt.Errorf("Error: failed to remove volumes: %v", errNames)
+	}
+	return nil
+}
+
+const volumePruneWarning = "WARNING! This will remove all volumes not used by at least one container.\nAre you sure you want to continue? [y/N] "
+
+// CmdVolumePrune removes all the volumes used by no container.
+//
+// Usage: docker volume prune [OPTIONS]
+func (cli *DockerCli) CmdVolumePrune(args ...string) error {
+	cmd := cli.Subcmd("volume prune", "", "Remove all unused volumes", true)
+	force := cmd.Bool([]string{"f", "-force"}, false, "Do not prompt for confirmation")
+
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"-filter"}, "Provide filter values (e.g. 'label=<label>')")
+	cmd.Require(flag.Exact, 0)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return err
+	}
+
+	v, err := pruneFilterValues(flFilter.GetAll())
+	if err != nil {
+		return err
+	}
+	if !*force && !cli.confirmPrune(volumePruneWarning) {
+		return nil
+	}
+
+	report, err := cli.pruneVolumes(v)
+	if err != nil {
+		return err
+	}
+	cli.printVolumesPruneReport(report)
+	fmt.Fprintf(cli.out, "Total reclaimed space: %s\n", units.HumanSize(float64(report.SpaceReclaimed)))
+	return nil
+}
+
+func (cli *DockerCli) pruneVolumes(v url.Values) (*types.VolumesPruneReport, error) {
+	body, _, err := readBody(cli.call("POST", "/volumes/prune?"+v.Encode(), nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	var report types.VolumesPruneReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (cli *DockerCli) printVolumesPruneReport(report *types.VolumesPruneReport) {
+	if len(report.VolumesDeleted) > 0 {
+		fmt.Fprintln(cli.out, "Deleted Volumes:")
+		for _, name := range report.VolumesDeleted {
+			fmt.Fprintln(cli.out, name)
+		}
+		fmt.Fprintln(cli.out)
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/audit"
+)
+
+// maxAuditErrorSize is the maximum size of the errors of the requests
+// recorded in the audit log.
+const maxAuditErrorSize = 1024
+
+// requestUser returns the user of the request authenticated by its TLS
+// certificate, and the method authenticating it.
+func requestUser(r *http.Request) (string, string) {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName, "TLS"
+	}
+	return "", ""
+}
+
+// auditResponseWriter records the status code of the response, and its
+// body when it is an error.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	start    time.Time
+	body     *audit.BodyRecorder
+	status   int
+	errorMsg []byte
+}
+
+func newAuditResponseWriter(w http.ResponseWriter) *auditResponseWriter {
+	return &auditResponseWriter{ResponseWriter: w, start: time.Now().UTC()}
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status >= http.StatusBadRequest && len(w.errorMsg) < maxAuditErrorSize {
+		left := maxAuditErrorSize - len(w.errorMsg)
+		if len(b) < left {
+			left = len(b)
+		}
+		w.errorMsg = append(w.errorMsg, b[:left]...)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hijacks the connection of the response, whose handler writes its
+// own status line.
+func (w *auditResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Internal response writer doesn't support the Hijacker interface")
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return hijacker.Hijack()
+}
+
+func (w *auditResponseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return nil
+}
+
+// recordBody records the body of the request r as its handler reads it. The
+// credentials sent to /auth are never recorded.
+func (w *auditResponseWriter) recordBody(r *http.Request, route string) {
+	if r.Body == nil || route == "/auth" {
+		return
+	}
+	w.body = audit.NewBodyRecorder(r.Body)
+	r.Body = w.body
+}
+
+// log records the request r, with its result, in the audit log.
+func (w *auditResponseWriter) log(l audit.Logger, r *http.Request) {
+	e := &audit.Entry{
+		Time:       w.start,
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		URI:        r.RequestURI,
+		Status:     w.status,
+	}
+	e.User, e.UserAuthNMethod = requestUser(r)
+	if w.body != nil {
+		w.body.Record(e)
+	}
+	if len(w.errorMsg) > 0 {
+		e.Error = strings.TrimSpace(string(w.errorMsg))
+	}
+	if err := l.Log(e); err != nil {
+		logrus.Errorf("Error recording %s %s in the audit log: %v", r.Method, r.RequestURI, err)
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/audit"
+	"github.com/docker/docker/pkg/version"
+)
+
+type testAuditLogger struct {
+	entries []*audit.Entry
+}
+
+func (l *testAuditLogger) Log(e *audit.Entry) error {
+	l.entries = append(l.entries, e)
+	return nil
+}
+
+func (l *testAuditLogger) Close() error {
+	return nil
+}
+
+func TestAuditLog(t *testing.T) {
+	l := &testAuditLogger{}
+	handler := func(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		if r.Body != nil {
+			ioutil.ReadAll(r.Body)
+		}
+		if r.Method == "DELETE" {
+			return fmt.Errorf("No such container: test")
+		}
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	}
+
+	for _, c := range []struct {
+		method, route, uri, body string
+	}{
+		{"POST", "/containers/create", "/containers/create?name=test", `{"Image": "busybox"}`},
+		{"DELETE", "/containers/{name:.*}", "/containers/test", ""},
+		{"GET", "/containers/json", "/containers/json", ""},
+		{"POST", "/auth", "/auth", `{"username": "test", "password": "secret"}`},
+	} {
+		f := makeHttpHandler(false, c.method, c.route, handler, "", version.Version("1.7.0"), nil, l)
+		r, err := http.NewRequest(c.method, c.uri, strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.RequestURI = c.uri
+		r.Header.Set("Content-Type", "application/json")
+		f(httptest.NewRecorder(), r)
+	}
+
+	// the GET requests are not recorded
+	if len(l.entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(l.entries))
+	}
+	if e := l.entries[0]; e.Method != "POST" || e.URI != "/containers/create?name=test" || e.Status != http.StatusCreated || string(e.Body) != `{"Image": "busybox"}` {
+		t.Fatalf("Unexpected entry of the creation: %+v", e)
+	}
+	if e := l.entries[1]; e.Status != http.StatusNotFound || e.Error != "No such container: test" {
+		t.Fatalf("Unexpected entry of the removal: %+v", e)
+	}
+	if e := l.entries[2]; e.Body != nil {
+		t.Fatalf("Expected the credentials not to be recorded, got %s", e.Body)
+	}
+}
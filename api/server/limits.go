@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/docker/docker/pkg/version"
+)
+
+// statusTooManyRequests is the status of the requests over the limit, RFC
+// 6585, which net/http doesn't define before Go 1.6.
+const statusTooManyRequests = 429
+
+// requestLimiter limits the number of concurrent requests to an endpoint. The
+// requests over the limit are rejected at once with a 429, so that a client
+// can't wedge the daemon, and is told to retry later instead.
+type requestLimiter struct {
+	name  string
+	slots chan struct{}
+}
+
+// newRequestLimiter returns a limiter of limit concurrent requests, or nil
+// when there is no limit.
+func newRequestLimiter(name string, limit int) *requestLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &requestLimiter{name: name, slots: make(chan struct{}, limit)}
+}
+
+// wrap returns the handler fn, limited by the limiter.
+func (l *requestLimiter) wrap(fn HttpApiFunc) HttpApiFunc {
+	if l == nil {
+		return fn
+	}
+	return func(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		select {
+		case l.slots <- struct{}{}:
+			defer func() { <-l.slots }()
+			return fn(version, w, r, vars)
+		default:
+			apiRequestsRejected.Inc(l.name)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, fmt.Sprintf("Too many concurrent %s, the daemon allows %d, retry later", l.name, cap(l.slots)), statusTooManyRequests)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/pkg/version"
+)
+
+func TestRequestLimiter(t *testing.T) {
+	l := newRequestLimiter("builds", 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fn := l.wrap(func(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		fn("", httptest.NewRecorder(), nil, nil)
+		close(done)
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	if err := fn("", w, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != statusTooManyRequests {
+		t.Fatalf("Expected %d over the limit, got %d", statusTooManyRequests, w.Code)
+	}
+	if w.HeaderMap.Get("Retry-After") == "" {
+		t.Fatal("Expected a Retry-After header over the limit")
+	}
+
+	close(release)
+	<-done
+
+	go func() { <-started }()
+	w = httptest.NewRecorder()
+	if err := fn("", w, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected %d once the request is done, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRequestLimiterNoLimit(t *testing.T) {
+	if l := newRequestLimiter("builds", 0); l != nil {
+		t.Fatalf("Expected no limiter without a limit, got %v", l)
+	}
+	var l *requestLimiter
+	if l.wrap(nil) != nil {
+		t.Fatal("Expected the handler to be kept without a limit")
+	}
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"github.com/docker/docker/pkg/metrics"
+)
+
+var apiRequestDuration = metrics.NewHistogram("engine_daemon_api_request_duration_seconds",
+	"The duration of the requests of the remote API in seconds, by method and route.", metrics.DefaultBuckets, "method", "route")
+
+var apiRequestsRejected = metrics.NewCounter("engine_daemon_api_requests_rejected_total",
+	"The requests of the remote API rejected over the concurrency limits, by limit.", "limit")
+
+func init() {
+	metrics.MustRegister(apiRequestDuration)
+	metrics.MustRegister(apiRequestsRejected)
+}
@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"net/http/pprof"
 
+	"github.com/docker/docker/pkg/signal"
 	"github.com/gorilla/mux"
 )
 
 func ProfilerSetup(mainRouter *mux.Router, path string) {
 	var r = mainRouter.PathPrefix(path).Subrouter()
 	r.HandleFunc("/vars", expVars)
+	r.HandleFunc("/stacks", stacks)
 	r.HandleFunc("/pprof/", pprof.Index)
 	r.HandleFunc("/pprof/cmdline", pprof.Cmdline)
 	r.HandleFunc("/pprof/profile", pprof.Profile)
@@ -22,6 +24,12 @@ func ProfilerSetup(mainRouter *mux.Router, path string) {
 	r.HandleFunc("/pprof/threadcreate", pprof.Handler("threadcreate").ServeHTTP)
 }
 
+// stacks writes the stack traces of all the goroutines of the daemon.
+func stacks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(signal.Stacks())
+}
+
 // Replicated from expvar.go as not public.
 func expVars(w http.ResponseWriter, r *http.Request) {
 	first := true
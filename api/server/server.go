@@ -23,17 +23,22 @@ import (
 	"github.com/docker/docker/builder"
 	"github.com/docker/docker/cliconfig"
 	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/daemon/networkdriver/bridge"
 	"github.com/docker/docker/graph"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/audit"
+	"github.com/docker/docker/pkg/authorization"
 	"github.com/docker/docker/pkg/ioutils"
-	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/filters"
 	"github.com/docker/docker/pkg/parsers/kernel"
+	"github.com/docker/docker/pkg/platform"
 	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/version"
+	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
@@ -44,25 +49,44 @@ type ServerConfig struct {
 	CorsHeaders string
 	Version     string
 	SocketGroup string
+	SocketMode  string
 	Tls         bool
 	TlsVerify   bool
 	TlsCa       string
 	TlsCert     string
 	TlsKey      string
+	TlsCrl      string
+	// AuthZPlugins are the names of the authorization plugins allowing the
+	// requests, in order.
+	AuthZPlugins []string
+	// MaxConcurrentBuilds, MaxConcurrentPulls and MaxConcurrentCreates
+	// limit the concurrent requests of the builds, of the pulls and imports
+	// of images, and of the creations of containers, when they are set.
+	MaxConcurrentBuilds  int
+	MaxConcurrentPulls   int
+	MaxConcurrentCreates int
+	// AuditLog records the requests changing the state of the daemon, if
+	// it is set.
+	AuditLog audit.Logger
 }
 
 type Server struct {
-	daemon  *daemon.Daemon
-	cfg     *ServerConfig
-	router  *mux.Router
-	start   chan struct{}
-	servers []serverCloser
+	daemon       *daemon.Daemon
+	cfg          *ServerConfig
+	router       *mux.Router
+	start        chan struct{}
+	listening    chan struct{}
+	servers      []serverCloser
+	authZPlugins []authorization.Plugin
+	tls          *tlsReloader
 }
 
 func New(cfg *ServerConfig) *Server {
 	srv := &Server{
-		cfg:   cfg,
-		start: make(chan struct{}),
+		cfg:          cfg,
+		start:        make(chan struct{}),
+		listening:    make(chan struct{}),
+		authZPlugins: authorization.NewPlugins(cfg.AuthZPlugins),
 	}
 	r := createRouter(srv)
 	srv.router = r
@@ -77,37 +101,70 @@ func (s *Server) Close() {
 	}
 }
 
+// tlsReloader returns the TLS configuration of the TCP listeners, or nil
+// without TLS.
+func (s *Server) tlsReloader() (*tlsReloader, error) {
+	config := tlsConfigFromServerConfig(s.cfg)
+	if config == nil {
+		return nil, nil
+	}
+	if s.tls == nil {
+		r, err := newTlsReloader(config)
+		if err != nil {
+			return nil, err
+		}
+		s.tls = r
+	}
+	return s.tls, nil
+}
+
+// ReloadTLS loads the certificate, the key, the CA certificate and the CRL
+// of the TCP listeners again, for the new connections.
+func (s *Server) ReloadTLS() error {
+	if s.tls == nil {
+		return nil
+	}
+	return s.tls.reload()
+}
+
 type serverCloser interface {
 	Serve() error
 	Close() error
+	Addr() string
 }
 
 // ServeApi loops through all of the protocols sent in to docker and spawns
 // off a go routine to setup a serving http.Server for each.
 func (s *Server) ServeApi(protoAddrs []string) error {
-	var chErrors = make(chan error, len(protoAddrs))
-
+	// all the listeners are set up before serving any of them
 	for _, protoAddr := range protoAddrs {
 		protoAddrParts := strings.SplitN(protoAddr, "://", 2)
 		if len(protoAddrParts) != 2 {
 			return fmt.Errorf("bad format, expected PROTO://ADDR")
 		}
-		srv, err := s.newServer(protoAddrParts[0], protoAddrParts[1])
+		srvs, err := s.newServer(protoAddrParts[0], protoAddrParts[1])
 		if err != nil {
 			return err
 		}
-		s.servers = append(s.servers, srv)
+		for _, srv := range srvs {
+			logrus.Infof("Listening for HTTP on %s (%s)", protoAddrParts[0], srv.Addr())
+		}
+		s.servers = append(s.servers, srvs...)
+	}
+	close(s.listening)
 
-		go func(proto, addr string) {
-			logrus.Infof("Listening for HTTP on %s (%s)", proto, addr)
-			if err := srv.Serve(); err != nil && strings.Contains(err.Error(), "use of closed network connection") {
+	var chErrors = make(chan error, len(s.servers))
+	for _, srv := range s.servers {
+		go func(srv serverCloser) {
+			err := srv.Serve()
+			if err != nil && strings.Contains(err.Error(), "use of closed network connection") {
 				err = nil
 			}
 			chErrors <- err
-		}(protoAddrParts[0], protoAddrParts[1])
+		}(srv)
 	}
 
-	for i := 0; i < len(protoAddrs); i++ {
+	for range s.servers {
 		err := <-chErrors
 		if err != nil {
 			return err
@@ -128,6 +185,9 @@ func (s *HttpServer) Serve() error {
 func (s *HttpServer) Close() error {
 	return s.l.Close()
 }
+func (s *HttpServer) Addr() string {
+	return s.l.Addr().String()
+}
 
 type HttpApiFunc func(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error
 
@@ -200,13 +260,16 @@ func httpError(w http.ResponseWriter, err error) {
 	// create appropriate error types with clearly defined meaning.
 	errStr := strings.ToLower(err.Error())
 	for keyword, status := range map[string]int{
-		"not found":             http.StatusNotFound,
-		"no such":               http.StatusNotFound,
-		"bad parameter":         http.StatusBadRequest,
-		"conflict":              http.StatusConflict,
-		"impossible":            http.StatusNotAcceptable,
-		"wrong login/password":  http.StatusUnauthorized,
-		"hasn't been activated": http.StatusForbidden,
+		"not found":              http.StatusNotFound,
+		"no such":                http.StatusNotFound,
+		"bad parameter":          http.StatusBadRequest,
+		"conflict":               http.StatusConflict,
+		"impossible":             http.StatusNotAcceptable,
+		"wrong login/password":   http.StatusUnauthorized,
+		"hasn't been activated":  http.StatusForbidden,
+		"authorization denied":   http.StatusForbidden,
+		"denied by image policy": http.StatusForbidden,
+		"experimental feature":   http.StatusNotImplemented,
 	} {
 		if strings.Contains(errStr, keyword) {
 			statusCode = status
@@ -273,18 +336,12 @@ func (s *Server) postContainersKill(version version.Version, w http.ResponseWrit
 
 	// If we have a signal, look at it. Otherwise, do nothing
 	if sigStr := vars["signal"]; sigStr != "" {
-		// Check if we passed the signal as a number:
-		// The largest legal signal is 31, so let's parse on 5 bits
-		sig, err := strconv.ParseUint(sigStr, 10, 5)
+		// the signal is a number, or a name like "KILL" or "SIGKILL"
+		s, err := signal.ParseSignal(sigStr)
 		if err != nil {
-			// The signal is not a number, treat it as a string (either like
-			// "KILL" or like "SIGKILL")
-			sig = uint64(signal.SignalMap[strings.TrimPrefix(sigStr, "SIG")])
-		}
-
-		if sig == 0 {
-			return fmt.Errorf("Invalid signal: %s", sigStr)
+			return fmt.Errorf("Bad parameter: %v", err)
 		}
+		sig = uint64(s)
 	}
 
 	if err := s.daemon.ContainerKill(name, sig); err != nil {
@@ -432,24 +489,44 @@ func (s *Server) getEvents(version version.Version, w http.ResponseWriter, r *ht
 		return c.ID
 	}
 
-	sendEvent := func(ev *jsonmessage.JSONMessage) error {
+	sendEvent := func(ev *events.Message) error {
 		//incoming container filter can be name,id or partial id, convert and replace as a full container id
 		for i, cn := range ef["container"] {
 			ef["container"][i] = getContainerId(cn)
 		}
 
+		// the container of the network and volume events is an attribute
+		containerID := ev.ID
+		if ev.Type == events.NetworkEventType || ev.Type == events.VolumeEventType {
+			containerID = ev.Actor.Attributes["container"]
+		}
 		if isFiltered(ev.Status, ef["event"]) || isFiltered(ev.From, ef["image"]) ||
-			isFiltered(ev.ID, ef["container"]) {
+			isFiltered(containerID, ef["container"]) || isFiltered(ev.Type, ef["type"]) ||
+			!ef.MatchKVList("label", ev.Actor.Attributes) {
+			return nil
+		}
+		if len(ef["network"]) > 0 && (ev.Type != events.NetworkEventType || isFiltered(ev.Actor.ID, ef["network"])) {
+			return nil
+		}
+		if len(ef["volume"]) > 0 && (ev.Type != events.VolumeEventType || isFiltered(ev.Actor.ID, ef["volume"])) {
 			return nil
 		}
 
+		if version.LessThan("1.19") {
+			// the older clients only know the events of the containers
+			// and the images
+			if ev.Type == events.NetworkEventType || ev.Type == events.VolumeEventType {
+				return nil
+			}
+			return enc.Encode(&ev.JSONMessage)
+		}
 		return enc.Encode(ev)
 	}
 
 	current, l := es.Subscribe()
 	defer es.Evict(l)
 	for _, ev := range current {
-		if ev.Time < since {
+		if ev.Time < since || (until > 0 && ev.Time > until) {
 			continue
 		}
 		if err := sendEvent(ev); err != nil {
@@ -459,7 +536,7 @@ func (s *Server) getEvents(version version.Version, w http.ResponseWriter, r *ht
 	for {
 		select {
 		case ev := <-l:
-			jev, ok := ev.(*jsonmessage.JSONMessage)
+			jev, ok := ev.(*events.Message)
 			if !ok {
 				continue
 			}
@@ -553,7 +630,11 @@ func (s *Server) getContainersStats(version version.Version, w http.ResponseWrit
 		return fmt.Errorf("Missing parameter")
 	}
 
-	return s.daemon.ContainerStats(vars["name"], boolValue(r, "stream"), ioutils.NewWriteFlusher(w))
+	stream := boolValue(r, "stream")
+	if r.Form.Get("stream") == "" && version.GreaterThanOrEqualTo("1.19") {
+		stream = true
+	}
+	return s.daemon.ContainerStats(vars["name"], stream, ioutils.NewWriteFlusher(w))
 }
 
 func (s *Server) getContainersLogs(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -570,7 +651,7 @@ func (s *Server) getContainersLogs(version version.Version, w http.ResponseWrite
 		return fmt.Errorf("Bad parameters: you must choose at least one stream")
 	}
 
-	var since time.Time
+	var since, until time.Time
 	if r.Form.Get("since") != "" {
 		s, err := strconv.ParseInt(r.Form.Get("since"), 10, 64)
 		if err != nil {
@@ -578,11 +659,23 @@ func (s *Server) getContainersLogs(version version.Version, w http.ResponseWrite
 		}
 		since = time.Unix(s, 0)
 	}
+	if r.Form.Get("until") != "" {
+		u, err := strconv.ParseInt(r.Form.Get("until"), 10, 64)
+		if err != nil {
+			return err
+		}
+		until = time.Unix(u, 0)
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return fmt.Errorf("Bad parameters: until is before since")
+	}
 
 	logsConfig := &daemon.ContainerLogsConfig{
 		Follow:     boolValue(r, "follow"),
 		Timestamps: boolValue(r, "timestamps"),
+		Details:    boolValue(r, "details"),
 		Since:      since,
+		Until:      until,
 		Tail:       r.Form.Get("tail"),
 		UseStdout:  stdout,
 		UseStderr:  stderr,
@@ -706,6 +799,11 @@ func (s *Server) postImagesCreate(version version.Version, w http.ResponseWriter
 			AuthConfig:  authConfig,
 			OutStream:   output,
 		}
+		if p := r.Form.Get("platform"); p != "" {
+			if imagePullConfig.Platform, err = platform.Parse(p); err != nil {
+				return fmt.Errorf("Bad parameter: %v", err)
+			}
+		}
 
 		err = s.daemon.Repositories().Pull(image, tag, imagePullConfig)
 	} else { //import
@@ -716,6 +814,7 @@ func (s *Server) postImagesCreate(version version.Version, w http.ResponseWriter
 		src := r.Form.Get("fromSrc")
 		imageImportConfig := &graph.ImageImportConfig{
 			Changes:   r.Form["changes"],
+			Message:   r.Form.Get("message"),
 			InConfig:  r.Body,
 			OutStream: output,
 		}
@@ -767,7 +866,15 @@ func (s *Server) getImagesSearch(version version.Version, w http.ResponseWriter,
 			headers[k] = v
 		}
 	}
-	query, err := s.daemon.RegistryService.Search(r.Form.Get("term"), config, headers)
+	limit := registry.DefaultSearchLimit
+	if r.Form.Get("limit") != "" {
+		n, err := strconv.Atoi(r.Form.Get("limit"))
+		if err != nil {
+			return fmt.Errorf("Bad parameter: invalid limit %q", r.Form.Get("limit"))
+		}
+		limit = n
+	}
+	query, err := s.daemon.RegistryService.Search(r.Form.Get("term"), limit, r.Form.Get("filters"), config, headers)
 	if err != nil {
 		return err
 	}
@@ -835,10 +942,24 @@ func (s *Server) getImagesGet(version version.Version, w http.ResponseWriter, r
 		return err
 	}
 
+	var compressionLevel int
+	if s := r.Form.Get("compresslevel"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("Bad parameter: invalid compression level %q", s)
+		}
+		compressionLevel = n
+	}
+
 	w.Header().Set("Content-Type", "application/x-tar")
 
 	output := ioutils.NewWriteFlusher(w)
-	imageExportConfig := &graph.ImageExportConfig{Outstream: output}
+	imageExportConfig := &graph.ImageExportConfig{
+		BuildCache:       boolValue(r, "buildcache"),
+		Compression:      r.Form.Get("compress"),
+		CompressionLevel: compressionLevel,
+		Outstream:        output,
+	}
 	if name, ok := vars["name"]; ok {
 		imageExportConfig.Names = []string{name}
 	} else {
@@ -857,7 +978,29 @@ func (s *Server) getImagesGet(version version.Version, w http.ResponseWriter, r
 }
 
 func (s *Server) postImagesLoad(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
-	return s.daemon.Repositories().Load(r.Body, w)
+	if version.LessThan("1.19") {
+		return s.daemon.Repositories().Load(r.Body, &graph.ImageLoadConfig{OutStream: w, Quiet: true})
+	}
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	output := ioutils.NewWriteFlusher(w)
+	imageLoadConfig := &graph.ImageLoadConfig{
+		OutStream: output,
+		JSON:      true,
+		Quiet:     boolValue(r, "quiet"),
+	}
+	if err := s.daemon.Repositories().Load(r.Body, imageLoadConfig); err != nil {
+		if !output.Flushed() {
+			return err
+		}
+		sf := streamformatter.NewJSONStreamFormatter()
+		output.Write(sf.FormatError(err))
+	}
+	return nil
 }
 
 func (s *Server) postContainersCreate(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -876,8 +1019,14 @@ func (s *Server) postContainersCreate(version version.Version, w http.ResponseWr
 	if err != nil {
 		return err
 	}
+	var imagePlatform *platform.Platform
+	if p := r.Form.Get("platform"); p != "" {
+		if imagePlatform, err = platform.Parse(p); err != nil {
+			return fmt.Errorf("Bad parameter: %v", err)
+		}
+	}
 
-	containerId, warnings, err := s.daemon.ContainerCreate(name, config, hostConfig)
+	containerId, warnings, err := s.daemon.ContainerCreate(name, config, hostConfig, imagePlatform)
 	if err != nil {
 		return err
 	}
@@ -1031,11 +1180,14 @@ func (s *Server) postContainersStop(version version.Version, w http.ResponseWrit
 }
 
 func (s *Server) postContainersWait(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
 	}
 
-	status, err := s.daemon.ContainerWait(vars["name"], -1*time.Second)
+	status, err := s.daemon.ContainerWaitCondition(vars["name"], r.Form.Get("condition"), -1*time.Second)
 	if err != nil {
 		return err
 	}
@@ -1115,11 +1267,29 @@ func (s *Server) wsContainersAttach(version version.Version, w http.ResponseWrit
 		defer ws.Close()
 
 		wsAttachWithLogsConfig := &daemon.ContainerWsAttachWithLogsConfig{
-			InStream:  ws,
-			OutStream: ws,
-			ErrStream: ws,
-			Logs:      boolValue(r, "logs"),
-			Stream:    boolValue(r, "stream"),
+			Logs:   boolValue(r, "logs"),
+			Stream: boolValue(r, "stream"),
+		}
+		if version.LessThan("1.19") {
+			// the streams are written as is to the text frames
+			wsAttachWithLogsConfig.InStream = ws
+			wsAttachWithLogsConfig.OutStream = ws
+			wsAttachWithLogsConfig.ErrStream = ws
+		} else {
+			input := &wsInput{ws: ws, resize: func(height, width int) error {
+				return s.daemon.ContainerResize(vars["name"], height, width)
+			}}
+			if boolValue(r, "stdin") {
+				wsAttachWithLogsConfig.InStream = input
+			} else {
+				go input.drain()
+			}
+			if boolValue(r, "stdout") {
+				wsAttachWithLogsConfig.OutStream = &wsStreamWriter{ws: ws, stream: wsStdout}
+			}
+			if boolValue(r, "stderr") {
+				wsAttachWithLogsConfig.ErrStream = &wsStreamWriter{ws: ws, stream: wsStderr}
+			}
 		}
 
 		if err := s.daemon.ContainerWsAttachWithLogs(vars["name"], wsAttachWithLogsConfig); err != nil {
@@ -1169,11 +1339,358 @@ func (s *Server) getImagesByName(version version.Version, w http.ResponseWriter,
 	return writeJSON(w, http.StatusOK, imageInspect)
 }
 
+func (s *Server) getVolumesList(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	volumes, err := s.daemon.Volumes(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, &types.VolumesListResponse{Volumes: volumes})
+}
+
+func (s *Server) getVolumeByName(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	v, err := s.daemon.VolumeInspect(vars["name"])
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, v)
+}
+
+func (s *Server) postVolumesCreate(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var req types.VolumeCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	var (
+		v   *types.Volume
+		err error
+	)
+	if req.From != "" {
+		if len(req.DriverOpts) > 0 {
+			return fmt.Errorf("Bad parameter: the driver options of a clone are those of the volume %s", req.From)
+		}
+		v, err = s.daemon.VolumeClone(req.Name, req.From, req.Driver, req.Labels)
+	} else {
+		v, err = s.daemon.VolumeCreate(req.Name, req.Driver, req.DriverOpts, req.Labels)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusCreated, v)
+}
+
+func (s *Server) postContainersPrune(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	report, err := s.daemon.ContainersPrune(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) postImagesPrune(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	report, err := s.daemon.ImagesPrune(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) postVolumesPrune(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	report, err := s.daemon.VolumesPrune(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) deleteVolumes(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	if err := s.daemon.VolumeRm(vars["name"]); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) getSecretsList(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	secrets, err := s.daemon.Secrets()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, secrets)
+}
+
+func (s *Server) postSecretsCreate(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var req types.SecretCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	secret, err := s.daemon.SecretCreate(req.Name, req.Data)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusCreated, secret)
+}
+
+func (s *Server) deleteSecrets(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	if err := s.daemon.SecretRm(vars["name"]); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) getPluginsList(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	plugins, err := s.daemon.Plugins()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, plugins)
+}
+
+func (s *Server) getPluginByName(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	p, err := s.daemon.Plugin(vars["name"])
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) postPluginsPrivileges(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var req types.PluginPrivilegesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	privileges, err := s.daemon.PluginPrivileges(req.Manifest)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, privileges)
+}
+
+// manifestConfig returns the configuration of the requests of the manifests
+// of a registry, with the credentials of the X-Registry-Auth header.
+func manifestConfig(r *http.Request) *graph.ManifestConfig {
+	metaHeaders := map[string][]string{}
+	for k, v := range r.Header {
+		if strings.HasPrefix(k, "X-Meta-") {
+			metaHeaders[k] = v
+		}
+	}
+	authConfig := &cliconfig.AuthConfig{}
+	if authEncoded := r.Header.Get("X-Registry-Auth"); authEncoded != "" {
+		authJson := base64.NewDecoder(base64.URLEncoding, strings.NewReader(authEncoded))
+		if err := json.NewDecoder(authJson).Decode(authConfig); err != nil {
+			authConfig = &cliconfig.AuthConfig{}
+		}
+	}
+	return &graph.ManifestConfig{MetaHeaders: metaHeaders, AuthConfig: authConfig}
+}
+
+func (s *Server) getDistributionByName(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	inspect, err := s.daemon.Repositories().ManifestInspect(vars["name"], manifestConfig(r))
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, inspect)
+}
+
+func (s *Server) postDistributionPush(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var list registry.ManifestList
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		return fmt.Errorf("Bad parameter: error decoding the manifest list: %v", err)
+	}
+
+	dgst, err := s.daemon.Repositories().ManifestPush(vars["name"], &list, manifestConfig(r))
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusCreated, &types.ManifestPushResponse{Digest: dgst})
+}
+
+func (s *Server) postPluginsInstall(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var req types.PluginInstallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	p, err := s.daemon.PluginInstall(req.Manifest, req.Privileges)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusCreated, p)
+}
+
+func (s *Server) deletePlugins(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	if err := s.daemon.PluginRm(vars["name"]); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// postBuildSSH hijacks the connection to forward the client's SSH agent
+// to the build which refers to the session.
+func (s *Server) postBuildSSH(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	session := r.Form.Get("session")
+	if session == "" {
+		return fmt.Errorf("Missing parameter: session")
+	}
+
+	inStream, outStream, err := hijackServer(w)
+	if err != nil {
+		return err
+	}
+	defer closeStreams(inStream, outStream)
+
+	// register the session before answering, the client starts the build
+	// as soon as it gets the answer.
+	conn := struct {
+		io.Reader
+		io.Writer
+	}{inStream, outStream}
+	done, err := builder.AttachSSHAgent(session, conn)
+	if err != nil {
+		fmt.Fprintf(outStream, "HTTP/1.1 409 Conflict\r\nContent-Type: text/plain\r\n\r\n%s\n", err)
+		return nil
+	}
+
+	if _, ok := r.Header["Upgrade"]; ok {
+		fmt.Fprintf(outStream, "HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	} else {
+		fmt.Fprintf(outStream, "HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n")
+	}
+	<-done
+	return nil
+}
+
+// postBuildOutput hijacks the connection to export the result of the build
+// which refers to the session, as a tar archive.
+func (s *Server) postBuildOutput(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	session := r.Form.Get("session")
+	if session == "" {
+		return fmt.Errorf("Missing parameter: session")
+	}
+
+	inStream, outStream, err := hijackServer(w)
+	if err != nil {
+		return err
+	}
+	defer closeStreams(inStream, outStream)
+
+	// register the output before answering, the client starts the build
+	// as soon as it gets the answer.
+	done, err := builder.AttachBuildOutput(session, outStream)
+	if err != nil {
+		fmt.Fprintf(outStream, "HTTP/1.1 409 Conflict\r\nContent-Type: text/plain\r\n\r\n%s\n", err)
+		return nil
+	}
+
+	if _, ok := r.Header["Upgrade"]; ok {
+		fmt.Fprintf(outStream, "HTTP/1.1 101 UPGRADED\r\nContent-Type: application/x-tar\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	} else {
+		fmt.Fprintf(outStream, "HTTP/1.1 200 OK\r\nContent-Type: application/x-tar\r\n\r\n")
+	}
+	<-done
+	return nil
+}
+
 func (s *Server) postBuild(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	var (
 		authConfig        = &cliconfig.AuthConfig{}
 		configFileEncoded = r.Header.Get("X-Registry-Config")
 		configFile        = &cliconfig.ConfigFile{}
+		secretsEncoded    = r.Header.Get("X-Build-Secrets")
 		buildConfig       = builder.NewBuildConfig()
 	)
 
@@ -1186,6 +1703,13 @@ func (s *Server) postBuild(version version.Version, w http.ResponseWriter, r *ht
 		}
 	}
 
+	if secretsEncoded != "" {
+		secretsJSON := base64.NewDecoder(base64.URLEncoding, strings.NewReader(secretsEncoded))
+		if err := json.NewDecoder(secretsJSON).Decode(&buildConfig.Secrets); err != nil {
+			return fmt.Errorf("Invalid X-Build-Secrets header: %v", err)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 
 	if boolValue(r, "forcerm") && version.GreaterThanOrEqualTo("1.12") {
@@ -1219,6 +1743,50 @@ func (s *Server) postBuild(version version.Version, w http.ResponseWriter, r *ht
 	buildConfig.CpuSetCpus = r.FormValue("cpusetcpus")
 	buildConfig.CpuSetMems = r.FormValue("cpusetmems")
 	buildConfig.CgroupParent = r.FormValue("cgroupparent")
+	buildConfig.NetworkMode = r.FormValue("networkmode")
+	buildConfig.Target = r.FormValue("target")
+	buildConfig.Squash = boolValue(r, "squash")
+	buildConfig.SSHSession = r.FormValue("ssh")
+	buildConfig.Output = r.FormValue("output")
+	if p := r.FormValue("platform"); p != "" {
+		buildPlatform, err := platform.Parse(p)
+		if err != nil {
+			return fmt.Errorf("Bad parameter: %v", err)
+		}
+		buildConfig.Platform = buildPlatform
+	}
+
+	var buildArgs = map[string]string{}
+	if buildArgsJSON := r.FormValue("buildargs"); buildArgsJSON != "" {
+		if err := json.NewDecoder(strings.NewReader(buildArgsJSON)).Decode(&buildArgs); err != nil {
+			return err
+		}
+	}
+	buildConfig.BuildArgs = buildArgs
+
+	var labels = map[string]string{}
+	if labelsJSON := r.FormValue("labels"); labelsJSON != "" {
+		if err := json.NewDecoder(strings.NewReader(labelsJSON)).Decode(&labels); err != nil {
+			return err
+		}
+	}
+	buildConfig.Labels = labels
+
+	var cacheFrom = []string{}
+	if cacheFromJSON := r.FormValue("cachefrom"); cacheFromJSON != "" {
+		if err := json.NewDecoder(strings.NewReader(cacheFromJSON)).Decode(&cacheFrom); err != nil {
+			return err
+		}
+	}
+	buildConfig.CacheFrom = cacheFrom
+
+	var extraHosts = []string{}
+	if extraHostsJSON := r.FormValue("extrahosts"); extraHostsJSON != "" {
+		if err := json.NewDecoder(strings.NewReader(extraHostsJSON)).Decode(&extraHosts); err != nil {
+			return err
+		}
+	}
+	buildConfig.ExtraHosts = extraHosts
 
 	// Job cancellation. Note: not all job types support this.
 	if closeNotifier, ok := w.(http.CloseNotifier); ok {
@@ -1285,6 +1853,88 @@ func (s *Server) postContainersCopy(version version.Version, w http.ResponseWrit
 	return nil
 }
 
+// containerPathError returns the error of a path of a container, without the
+// path of the container on the host.
+func containerPathError(err error, path, name string) error {
+	switch {
+	case os.IsNotExist(err):
+		return fmt.Errorf("No such file or directory %s in container %s", path, name)
+	case err == archive.ErrNotDirectory || err == daemon.ErrExtractPointNotDirectory:
+		return fmt.Errorf("Bad parameter: %s in container %s: %v", path, name, err)
+	}
+	return err
+}
+
+func setContainerPathStatHeader(stat *types.ContainerPathStat, header http.Header) error {
+	statJSON, err := json.Marshal(stat)
+	if err != nil {
+		return err
+	}
+	header.Set("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString(statJSON))
+	return nil
+}
+
+func archivePathParam(r *http.Request, vars map[string]string) (string, error) {
+	if vars == nil {
+		return "", fmt.Errorf("Missing parameter")
+	}
+	if err := parseForm(r); err != nil {
+		return "", err
+	}
+	path := r.Form.Get("path")
+	if path == "" {
+		return "", fmt.Errorf("Bad parameter: path cannot be empty")
+	}
+	return path, nil
+}
+
+func (s *Server) headContainersArchive(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	path, err := archivePathParam(r, vars)
+	if err != nil {
+		return err
+	}
+
+	stat, err := s.daemon.ContainerStatPath(vars["name"], path)
+	if err != nil {
+		return containerPathError(err, path, vars["name"])
+	}
+	return setContainerPathStatHeader(stat, w.Header())
+}
+
+func (s *Server) getContainersArchive(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	path, err := archivePathParam(r, vars)
+	if err != nil {
+		return err
+	}
+
+	content, stat, err := s.daemon.ContainerArchivePath(vars["name"], path)
+	if err != nil {
+		return containerPathError(err, path, vars["name"])
+	}
+	defer content.Close()
+
+	if err := setContainerPathStatHeader(stat, w.Header()); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-tar")
+	_, err = io.Copy(w, content)
+	return err
+}
+
+func (s *Server) putContainersArchive(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	path, err := archivePathParam(r, vars)
+	if err != nil {
+		return err
+	}
+
+	noOverwriteDirNonDir := boolValue(r, "noOverwriteDirNonDir")
+	copyUIDGID := boolValue(r, "copyUIDGID")
+	if err := s.daemon.ContainerExtractToDir(vars["name"], path, noOverwriteDirNonDir, copyUIDGID, r.Body); err != nil {
+		return containerPathError(err, path, vars["name"])
+	}
+	return nil
+}
+
 func (s *Server) postContainerExecCreate(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return nil
@@ -1402,8 +2052,10 @@ func (s *Server) ping(version version.Version, w http.ResponseWriter, r *http.Re
 	return err
 }
 
-func makeHttpHandler(logging bool, localMethod string, localRoute string, handlerFunc HttpApiFunc, corsHeaders string, dockerVersion version.Version) http.HandlerFunc {
+func makeHttpHandler(logging bool, localMethod string, localRoute string, handlerFunc HttpApiFunc, corsHeaders string, dockerVersion version.Version, authZPlugins []authorization.Plugin, auditLog audit.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		defer apiRequestDuration.ObserveSince(time.Now(), localMethod, localRoute)
+
 		// log the request
 		logrus.Debugf("Calling %s %s", localMethod, localRoute)
 
@@ -1411,6 +2063,15 @@ func makeHttpHandler(logging bool, localMethod string, localRoute string, handle
 			logrus.Infof("%s %s", r.Method, r.RequestURI)
 		}
 
+		// the requests changing the state of the daemon are audited, with
+		// their result
+		if auditLog != nil && localMethod != "GET" && localMethod != "HEAD" {
+			aw := newAuditResponseWriter(w)
+			aw.recordBody(r, localRoute)
+			w = aw
+			defer aw.log(auditLog, r)
+		}
+
 		if strings.Contains(r.Header.Get("User-Agent"), "Docker-Client/") {
 			userAgent := strings.Split(r.Header.Get("User-Agent"), "/")
 			if len(userAgent) == 2 && !dockerVersion.Equal(version.Version(userAgent[1])) {
@@ -1424,12 +2085,32 @@ func makeHttpHandler(logging bool, localMethod string, localRoute string, handle
 		if corsHeaders != "" {
 			writeCorsHeaders(w, r, corsHeaders)
 		}
+		// the clients negotiate the version of their requests from it
+		w.Header().Set("Api-Version", string(api.APIVERSION))
 
 		if version.GreaterThan(api.APIVERSION) {
 			http.Error(w, fmt.Errorf("client and server don't have same version (client API version: %s, server API version: %s)", version, api.APIVERSION).Error(), http.StatusNotFound)
 			return
 		}
 
+		// the authorization plugins allow the request, and then its response
+		if len(authZPlugins) > 0 {
+			user, userAuthNMethod := requestUser(r)
+			authCtx := authorization.NewCtx(authZPlugins, user, userAuthNMethod, r.Method, r.RequestURI)
+			if err := authCtx.AuthZRequest(r); err != nil {
+				logrus.Errorf("AuthZRequest for %s %s returned error: %s", localMethod, localRoute, err)
+				httpError(w, err)
+				return
+			}
+			rm := authorization.NewResponseModifier(w, authCtx.AuthZResponse)
+			defer func() {
+				if err := rm.FlushAll(); err != nil {
+					logrus.Errorf("AuthZResponse for %s %s returned error: %s", localMethod, localRoute, err)
+				}
+			}()
+			w = rm
+		}
+
 		if err := handlerFunc(version, w, r, mux.Vars(r)); err != nil {
 			logrus.Errorf("Handler for %s %s returned error: %s", localMethod, localRoute, err)
 			httpError(w, err)
@@ -1438,12 +2119,26 @@ func makeHttpHandler(logging bool, localMethod string, localRoute string, handle
 }
 
 // we keep enableCors just for legacy usage, need to be removed in the future
+// experimental returns the handler fn of the experimental feature name,
+// refusing the requests while the feature is disabled on the daemon.
+func (s *Server) experimental(name string, fn HttpApiFunc) HttpApiFunc {
+	return func(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		if err := s.daemon.CheckExperimental(name); err != nil {
+			return err
+		}
+		return fn(version, w, r, vars)
+	}
+}
+
 func createRouter(s *Server) *mux.Router {
 	r := mux.NewRouter()
 	if os.Getenv("DEBUG") != "" {
 		ProfilerSetup(r, "/debug/")
 	}
 	m := map[string]map[string]HttpApiFunc{
+		"HEAD": {
+			"/containers/{name:.*}/archive": s.headContainersArchive,
+		},
 		"GET": {
 			"/_ping":                          s.ping,
 			"/events":                         s.getEvents,
@@ -1455,6 +2150,7 @@ func createRouter(s *Server) *mux.Router {
 			"/images/{name:.*}/get":           s.getImagesGet,
 			"/images/{name:.*}/history":       s.getImagesHistory,
 			"/images/{name:.*}/json":          s.getImagesByName,
+			"/distribution/{name:.*}/json":    s.getDistributionByName,
 			"/containers/ps":                  s.getContainersJSON,
 			"/containers/json":                s.getContainersJSON,
 			"/containers/{name:.*}/export":    s.getContainersExport,
@@ -1464,17 +2160,28 @@ func createRouter(s *Server) *mux.Router {
 			"/containers/{name:.*}/logs":      s.getContainersLogs,
 			"/containers/{name:.*}/stats":     s.getContainersStats,
 			"/containers/{name:.*}/attach/ws": s.wsContainersAttach,
+			"/containers/{name:.*}/archive":   s.getContainersArchive,
 			"/exec/{id:.*}/json":              s.getExecByID,
+			"/volumes":                        s.getVolumesList,
+			"/volumes/{name:.*}":              s.getVolumeByName,
+			"/secrets":                        s.getSecretsList,
+			"/plugins":                        s.getPluginsList,
+			"/plugins/{name:.*}":              s.getPluginByName,
 		},
 		"POST": {
 			"/auth":                         s.postAuth,
 			"/commit":                       s.postCommit,
 			"/build":                        s.postBuild,
+			"/build/ssh":                    s.postBuildSSH,
+			"/build/output":                 s.postBuildOutput,
 			"/images/create":                s.postImagesCreate,
 			"/images/load":                  s.postImagesLoad,
+			"/images/prune":                 s.postImagesPrune,
 			"/images/{name:.*}/push":        s.postImagesPush,
 			"/images/{name:.*}/tag":         s.postImagesTag,
+			"/distribution/{name:.*}/push":  s.postDistributionPush,
 			"/containers/create":            s.postContainersCreate,
+			"/containers/prune":             s.postContainersPrune,
 			"/containers/{name:.*}/kill":    s.postContainersKill,
 			"/containers/{name:.*}/pause":   s.postContainersPause,
 			"/containers/{name:.*}/unpause": s.postContainersUnpause,
@@ -1489,10 +2196,21 @@ func createRouter(s *Server) *mux.Router {
 			"/exec/{name:.*}/start":         s.postContainerExecStart,
 			"/exec/{name:.*}/resize":        s.postContainerExecResize,
 			"/containers/{name:.*}/rename":  s.postContainerRename,
+			"/volumes/create":               s.postVolumesCreate,
+			"/volumes/prune":                s.postVolumesPrune,
+			"/secrets/create":               s.postSecretsCreate,
+			"/plugins/privileges":           s.postPluginsPrivileges,
+			"/plugins/install":              s.postPluginsInstall,
+		},
+		"PUT": {
+			"/containers/{name:.*}/archive": s.putContainersArchive,
 		},
 		"DELETE": {
 			"/containers/{name:.*}": s.deleteContainers,
 			"/images/{name:.*}":     s.deleteImages,
+			"/volumes/{name:.*}":    s.deleteVolumes,
+			"/secrets/{name:.*}":    s.deleteSecrets,
+			"/plugins/{name:.*}":    s.deletePlugins,
 		},
 		"OPTIONS": {
 			"": s.optionsHandler,
@@ -1506,6 +2224,12 @@ func createRouter(s *Server) *mux.Router {
 		corsHeaders = "*"
 	}
 
+	limiters := map[string]*requestLimiter{
+		"/build":             newRequestLimiter("builds", s.cfg.MaxConcurrentBuilds),
+		"/images/create":     newRequestLimiter("pulls", s.cfg.MaxConcurrentPulls),
+		"/containers/create": newRequestLimiter("creates", s.cfg.MaxConcurrentCreates),
+	}
+
 	for method, routes := range m {
 		for route, fct := range routes {
 			logrus.Debugf("Registering %s, %s", method, route)
@@ -1513,9 +2237,12 @@ func createRouter(s *Server) *mux.Router {
 			localRoute := route
 			localFct := fct
 			localMethod := method
+			if method == "POST" {
+				localFct = limiters[localRoute].wrap(localFct)
+			}
 
 			// build the handler function
-			f := makeHttpHandler(s.cfg.Logging, localMethod, localRoute, localFct, corsHeaders, version.Version(s.cfg.Version))
+			f := makeHttpHandler(s.cfg.Logging, localMethod, localRoute, localFct, corsHeaders, version.Version(s.cfg.Version), s.authZPlugins, s.cfg.AuditLog)
 
 			// add the new route
 			if localRoute == "" {
@@ -9,71 +9,79 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/pkg/listenbuffer"
 	"github.com/docker/docker/pkg/systemd"
 )
 
-// newServer sets up the required serverCloser and does protocol specific checking.
-func (s *Server) newServer(proto, addr string) (serverCloser, error) {
-	var (
-		err error
-		l   net.Listener
-	)
+// newServer sets up the required serverCloser and does protocol specific
+// checking. A fd address can give several sockets activated by systemd.
+func (s *Server) newServer(proto, addr string) ([]serverCloser, error) {
+	var ls []net.Listener
 	switch proto {
 	case "fd":
-		ls, err := systemd.ListenFD(addr)
+		activated, err := systemd.ListenFD(addr)
 		if err != nil {
 			return nil, err
 		}
-		chErrors := make(chan error, len(ls))
-		// We don't want to start serving on these sockets until the
-		// daemon is initialized and installed. Otherwise required handlers
-		// won't be ready.
-		<-s.start
-		// Since ListenFD will return one or more sockets we have
-		// to create a go func to spawn off multiple serves
-		for i := range ls {
-			listener := ls[i]
-			go func() {
-				httpSrv := http.Server{Handler: s.router}
-				chErrors <- httpSrv.Serve(listener)
-			}()
+		reloader, err := s.tlsReloader()
+		if err != nil {
+			return nil, err
 		}
-		for i := 0; i < len(ls); i++ {
-			if err := <-chErrors; err != nil {
-				return nil, err
+		for _, l := range activated {
+			if _, ok := l.Addr().(*net.TCPAddr); ok && reloader != nil {
+				l = reloader.newListener(l)
 			}
+			// We don't want to start serving on these sockets until the
+			// daemon is initialized and installed. Otherwise required handlers
+			// won't be ready.
+			ls = append(ls, listenbuffer.Wrap(l, s.start))
 		}
-		return nil, nil
 	case "tcp":
 		if !s.cfg.TlsVerify {
 			logrus.Warn("/!\\ DON'T BIND ON ANY IP ADDRESS WITHOUT setting -tlsverify IF YOU DON'T KNOW WHAT YOU'RE DOING /!\\")
 		}
-		if l, err = NewTcpSocket(addr, tlsConfigFromServerConfig(s.cfg), s.start); err != nil {
+		reloader, err := s.tlsReloader()
+		if err != nil {
+			return nil, err
+		}
+		l, err := NewTcpSocket(addr, reloader, s.start)
+		if err != nil {
 			return nil, err
 		}
 		if err := allocateDaemonPort(addr); err != nil {
 			return nil, err
 		}
+		ls = append(ls, l)
 	case "unix":
-		if l, err = NewUnixSocket(addr, s.cfg.SocketGroup, s.start); err != nil {
+		l, err := NewUnixSocket(addr, s.cfg.SocketGroup, s.cfg.SocketMode, s.start)
+		if err != nil {
 			return nil, err
 		}
+		ls = append(ls, l)
 	default:
 		return nil, fmt.Errorf("Invalid protocol format: %q", proto)
 	}
-	return &HttpServer{
-		&http.Server{
-			Addr:    addr,
-			Handler: s.router,
-		},
-		l,
-	}, nil
+	var srvs []serverCloser
+	for _, l := range ls {
+		srvs = append(srvs, &HttpServer{
+			&http.Server{
+				Addr:    addr,
+				Handler: s.router,
+			},
+			l,
+		})
+	}
+	return srvs, nil
 }
 
 func (s *Server) AcceptConnections(d *daemon.Daemon) {
-	// Tell the init daemon we are accepting requests
+	// Tell the init daemon we are accepting requests, once the listeners
+	// are set up
 	s.daemon = d
-	go systemd.SdNotify("READY=1")
+	go func() {
+		<-s.listening
+		systemd.SdNotify("READY=1")
+	}()
 	// close the lock so the listeners start accepting connections
 	select {
 	case <-s.start:
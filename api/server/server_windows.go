@@ -12,17 +12,18 @@ import (
 )
 
 // NewServer sets up the required Server and does protocol specific checking.
-func (s *Server) newServer(proto, addr string) (Server, error) {
-	var (
-		err error
-		l   net.Listener
-	)
+func (s *Server) newServer(proto, addr string) ([]serverCloser, error) {
+	var l net.Listener
 	switch proto {
 	case "tcp":
 		if !s.cfg.TlsVerify {
 			logrus.Warn("/!\\ DON'T BIND ON ANY IP ADDRESS WITHOUT setting -tlsverify IF YOU DON'T KNOW WHAT YOU'RE DOING /!\\")
 		}
-		if l, err = NewTcpSocket(addr, tlsConfigFromServerConfig(s.cfg)); err != nil {
+		reloader, err := s.tlsReloader()
+		if err != nil {
+			return nil, err
+		}
+		if l, err = NewTcpSocket(addr, reloader, s.start); err != nil {
 			return nil, err
 		}
 		if err := allocateDaemonPort(addr); err != nil {
@@ -31,13 +32,13 @@ func (s *Server) newServer(proto, addr string) (Server, error) {
 	default:
 		return nil, errors.New("Invalid protocol format. Windows only supports tcp.")
 	}
-	return &HttpServer{
+	return []serverCloser{&HttpServer{
 		&http.Server{
 			Addr:    addr,
 			Handler: s.router,
 		},
 		l,
-	}, nil
+	}}, nil
 }
 
 func (s *Server) AcceptConnections(d *daemon.Daemon) {
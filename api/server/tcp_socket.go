@@ -3,11 +3,17 @@ package server
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/listenbuffer"
 )
 
@@ -15,6 +21,7 @@ type tlsConfig struct {
 	CA          string
 	Certificate string
 	Key         string
+	CRL         string
 	Verify      bool
 }
 
@@ -28,29 +35,159 @@ func tlsConfigFromServerConfig(conf *ServerConfig) *tlsConfig {
 		Certificate: conf.TlsCert,
 		Key:         conf.TlsKey,
 		CA:          conf.TlsCa,
+		CRL:         conf.TlsCrl,
 	}
 }
 
-func NewTcpSocket(addr string, config *tlsConfig, activate <-chan struct{}) (net.Listener, error) {
+func NewTcpSocket(addr string, reloader *tlsReloader, activate <-chan struct{}) (net.Listener, error) {
 	l, err := listenbuffer.NewListenBuffer("tcp", addr, activate)
 	if err != nil {
 		return nil, err
 	}
-	if config != nil {
-		if l, err = setupTls(l, config); err != nil {
-			return nil, err
-		}
+	if reloader != nil {
+		l = reloader.newListener(l)
 	}
 	return l, nil
 }
 
-func setupTls(l net.Listener, config *tlsConfig) (net.Listener, error) {
+// tlsReloader holds the TLS configuration of the server, which is loaded
+// again from its files on reload, so that the certificate, the key, the CA
+// and the CRL are rotated without restarting the daemon. The connections
+// already established keep the configuration they were accepted with.
+type tlsReloader struct {
+	config *tlsConfig
+
+	mu      sync.RWMutex
+	current *tls.Config
+	revoked revokedCerts
+}
+
+func newTlsReloader(config *tlsConfig) (*tlsReloader, error) {
+	r := &tlsReloader{config: config}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// get returns the current configuration and the certificates revoked by its
+// CRL.
+func (r *tlsReloader) get() (*tls.Config, revokedCerts) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current, r.revoked
+}
+
+// reload loads the configuration from its files again. The current
+// configuration is kept if they are invalid.
+func (r *tlsReloader) reload() error {
+	config, revoked, err := loadTlsConfig(r.config)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.current = config
+	r.revoked = revoked
+	r.mu.Unlock()
+	return nil
+}
+
+// handshakeTimeout bounds the TLS handshake of the connections, so that the
+// clients which never complete it don't hold a goroutine.
+const handshakeTimeout = 30 * time.Second
+
+// tlsListener accepts the TLS connections of a listener with the current
+// configuration of its reloader. The handshake of each connection is done in
+// its own goroutine before the connection is handed to the server, so that
+// the client certificates revoked by the CRL are rejected.
+type tlsListener struct {
+	net.Listener
+	r *tlsReloader
+
+	conns  chan net.Conn
+	errs   chan error
+	done   chan struct{} // closed when the listener fails
+	err    error
+	closed chan struct{}
+	once   sync.Once
+}
+
+// newListener returns a listener of the TLS connections of l.
+func (r *tlsReloader) newListener(l net.Listener) net.Listener {
+	tl := &tlsListener{
+		Listener: l,
+		r:        r,
+		conns:    make(chan net.Conn),
+		errs:     make(chan error),
+		done:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	go tl.serve()
+	return tl
+}
+
+func (l *tlsListener) serve() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				select {
+				case l.errs <- err:
+					continue
+				case <-l.closed:
+				}
+			}
+			l.err = err
+			close(l.done)
+			return
+		}
+		config, revoked := l.r.get()
+		go l.handshake(tls.Server(conn, config), revoked)
+	}
+}
+
+func (l *tlsListener) handshake(conn *tls.Conn, revoked revokedCerts) {
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	err := conn.Handshake()
+	if err == nil {
+		err = revoked.verify(conn.ConnectionState().PeerCertificates)
+	}
+	if err != nil {
+		logrus.Debugf("TLS handshake with %s failed: %v", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	select {
+	case l.conns <- conn:
+	case <-l.closed:
+		conn.Close()
+	}
+}
+
+func (l *tlsListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case err := <-l.errs:
+		return nil, err
+	case <-l.done:
+		return nil, l.err
+	}
+}
+
+func (l *tlsListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
+
+func loadTlsConfig(config *tlsConfig) (*tls.Config, revokedCerts, error) {
 	tlsCert, err := tls.LoadX509KeyPair(config.Certificate, config.Key)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("Could not load X509 key pair (%s, %s): %v", config.Certificate, config.Key, err)
+			return nil, nil, fmt.Errorf("Could not load X509 key pair (%s, %s): %v", config.Certificate, config.Key, err)
 		}
-		return nil, fmt.Errorf("Error reading X509 key pair (%s, %s): %q. Make sure the key is encrypted.",
+		return nil, nil, fmt.Errorf("Error reading X509 key pair (%s, %s): %q. Make sure the key is encrypted.",
 			config.Certificate, config.Key, err)
 	}
 	tlsConfig := &tls.Config{
@@ -63,11 +200,109 @@ func setupTls(l net.Listener, config *tlsConfig) (net.Listener, error) {
 		certPool := x509.NewCertPool()
 		file, err := ioutil.ReadFile(config.CA)
 		if err != nil {
-			return nil, fmt.Errorf("Could not read CA certificate: %v", err)
+			return nil, nil, fmt.Errorf("Could not read CA certificate: %v", err)
 		}
 		certPool.AppendCertsFromPEM(file)
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 		tlsConfig.ClientCAs = certPool
+
+		if config.CRL != "" {
+			revoked, err := loadCRL(config.CRL, file)
+			if err != nil {
+				return nil, nil, err
+			}
+			return tlsConfig, revoked, nil
+		}
+	} else if config.CRL != "" {
+		return nil, nil, errors.New("A CRL requires a CA certificate to verify the client certificates")
+	}
+	return tlsConfig, nil, nil
+}
+
+// revokedCerts are the serial numbers of the revoked certificates, by the
+// subject of their issuer.
+type revokedCerts map[string]map[string]bool
+
+// loadCRL loads the certificate revocation lists of the file at path, in PEM
+// or in DER, which must be signed by one of the CA certificates.
+func loadCRL(path string, caPEM []byte) (revokedCerts, error) {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read CRL: %v", err)
+	}
+
+	var cas []*x509.Certificate
+	for rest := caPEM; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse CA certificate: %v", err)
+		}
+		cas = append(cas, ca)
+	}
+
+	var crls []*pkix.CertificateList
+	if block, _ := pem.Decode(file); block == nil {
+		crl, err := x509.ParseDERCRL(file)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse CRL %s: %v", path, err)
+		}
+		crls = append(crls, crl)
+	} else {
+		for rest := file; ; {
+			if block, rest = pem.Decode(rest); block == nil {
+				break
+			}
+			if block.Type != "X509 CRL" {
+				continue
+			}
+			crl, err := x509.ParseDERCRL(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("Could not parse CRL %s: %v", path, err)
+			}
+			crls = append(crls, crl)
+		}
+	}
+
+	revoked := make(revokedCerts)
+	for _, crl := range crls {
+		var issuer *x509.Certificate
+		for _, ca := range cas {
+			if ca.CheckCRLSignature(crl) == nil {
+				issuer = ca
+				break
+			}
+		}
+		if issuer == nil {
+			return nil, fmt.Errorf("The CRL %s isn't signed by a CA certificate", path)
+		}
+		if crl.HasExpired(time.Now()) {
+			logrus.Warnf("The CRL %s of %s has expired, it should be updated", path, issuer.Subject.CommonName)
+		}
+		serials := revoked[string(issuer.RawSubject)]
+		if serials == nil {
+			serials = make(map[string]bool)
+			revoked[string(issuer.RawSubject)] = serials
+		}
+		for _, cert := range crl.TBSCertList.RevokedCertificates {
+			serials[cert.SerialNumber.String()] = true
+		}
+	}
+	return revoked, nil
+}
+
+// verify rejects the client certificates revoked by their issuer.
+func (revoked revokedCerts) verify(certs []*x509.Certificate) error {
+	for _, cert := range certs {
+		if revoked[string(cert.RawIssuer)][cert.SerialNumber.String()] {
+			return fmt.Errorf("The certificate %s (serial %s) is revoked", cert.Subject.CommonName, cert.SerialNumber)
+		}
 	}
-	return tls.NewListener(l, tlsConfig), nil
+	return nil
 }
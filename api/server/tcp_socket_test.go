@@ -0,0 +1,247 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate of the given serial signed by the CA, and its key.
+func (ca *testCA) issue(t *testing.T, serial int64, name string) (*x509.Certificate, []byte, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func (ca *testCA) crl(t *testing.T, serials ...int64) []byte {
+	var revoked []pkix.RevokedCertificate
+	for _, serial := range serials {
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	der, err := ca.cert.CreateCRL(rand.Reader, ca.key, revoked, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCRL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-tls-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+	good, _, _ := ca.issue(t, 2, "good")
+	bad, _, _ := ca.issue(t, 3, "bad")
+	revoked, err := loadCRL(writeTestFile(t, dir, "crl.pem", ca.crl(t, 3)), ca.pem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := revoked.verify([]*x509.Certificate{good}); err != nil {
+		t.Fatalf("Expected the certificate not to be revoked, got %v", err)
+	}
+	if err := revoked.verify([]*x509.Certificate{bad}); err == nil {
+		t.Fatal("Expected the certificate to be revoked")
+	}
+
+	other := newTestCA(t)
+	if _, err := loadCRL(writeTestFile(t, dir, "other.pem", other.crl(t, 3)), ca.pem); err == nil {
+		t.Fatal("Expected a CRL of another CA to be rejected")
+	}
+}
+
+func TestTlsReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-tls-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+	_, certPEM, keyPEM := ca.issue(t, 2, "server")
+	config := &tlsConfig{
+		CA:          writeTestFile(t, dir, "ca.pem", ca.pem),
+		Certificate: writeTestFile(t, dir, "cert.pem", certPEM),
+		Key:         writeTestFile(t, dir, "key.pem", keyPEM),
+		Verify:      true,
+	}
+	r, err := newTlsReloader(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, revoked := r.get(); revoked != nil {
+		t.Fatal("Expected no revocation check without a CRL")
+	}
+
+	_, certPEM, keyPEM = ca.issue(t, 3, "rotated")
+	writeTestFile(t, dir, "cert.pem", certPEM)
+	writeTestFile(t, dir, "key.pem", keyPEM)
+	config.CRL = writeTestFile(t, dir, "crl.pem", ca.crl(t, 2))
+	if err := r.reload(); err != nil {
+		t.Fatal(err)
+	}
+	current, revoked := r.get()
+	cert, err := x509.ParseCertificate(current.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "rotated" {
+		t.Fatalf("Expected the rotated certificate, got %s", cert.Subject.CommonName)
+	}
+	if revoked == nil {
+		t.Fatal("Expected the revocation check of the reloaded CRL")
+	}
+
+	// an invalid configuration keeps the current one
+	writeTestFile(t, dir, "key.pem", []byte("invalid"))
+	if err := r.reload(); err == nil {
+		t.Fatal("Expected an invalid key to fail the reload")
+	}
+	if kept, _ := r.get(); kept != current {
+		t.Fatal("Expected the current configuration to be kept")
+	}
+}
+
+func TestTlsListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-tls-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+	_, certPEM, keyPEM := ca.issue(t, 2, "server")
+	r, err := newTlsReloader(&tlsConfig{
+		CA:          writeTestFile(t, dir, "ca.pem", ca.pem),
+		Certificate: writeTestFile(t, dir, "cert.pem", certPEM),
+		Key:         writeTestFile(t, dir, "key.pem", keyPEM),
+		CRL:         writeTestFile(t, dir, "crl.pem", ca.crl(t, 3)),
+		Verify:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := r.newListener(inner)
+	defer l.Close()
+
+	dial := func(serial int64, name string) error {
+		_, certPEM, keyPEM := ca.issue(t, serial, name)
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := tls.Dial("tcp", inner.Addr().String(), &tls.Config{
+			Certificates:       []tls.Certificate{cert},
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			return err
+		}
+		_, err = conn.Read(make([]byte, 4))
+		return err
+	}
+
+	// the revoked client is closed without being handed to the server
+	if err := dial(3, "revoked"); err == nil {
+		t.Fatal("Expected the connection of the revoked client to be closed")
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- dial(4, "good") }()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		t.Fatalf("Expected a *tls.Conn for the client certificate of the requests, got %T", conn)
+	}
+	if peers := tlsConn.ConnectionState().PeerCertificates; len(peers) == 0 || peers[0].Subject.CommonName != "good" {
+		t.Fatal("Expected the certificate of the good client")
+	}
+	if _, err := conn.Write([]byte("pong")); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,8 +5,10 @@ package server
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/Sirupsen/logrus"
@@ -14,7 +16,13 @@ import (
 	"github.com/docker/libcontainer/user"
 )
 
-func NewUnixSocket(path, group string, activate <-chan struct{}) (net.Listener, error) {
+// NewUnixSocket returns a listener of the unix socket at addr, with the given
+// group and mode unless they are set by the query of addr.
+func NewUnixSocket(addr, group, mode string, activate <-chan struct{}) (net.Listener, error) {
+	path, group, perm, err := parseUnixSocketAddr(addr, group, mode)
+	if err != nil {
+		return nil, err
+	}
 	if err := syscall.Unlink(path); err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
@@ -28,13 +36,51 @@ func NewUnixSocket(path, group string, activate <-chan struct{}) (net.Listener,
 		l.Close()
 		return nil, err
 	}
-	if err := os.Chmod(path, 0660); err != nil {
+	if err := os.Chmod(path, perm); err != nil {
 		l.Close()
 		return nil, err
 	}
 	return l, nil
 }
 
+// parseUnixSocketAddr returns the path of the unix socket at addr, and its
+// group and its mode, which are the given defaults unless they are set by the
+// query of addr, such as /var/run/docker-ops.sock?group=ops&mode=0640.
+func parseUnixSocketAddr(addr, group, mode string) (string, string, os.FileMode, error) {
+	path := addr
+	if i := strings.Index(addr, "?"); i >= 0 {
+		path = addr[:i]
+		query, err := url.ParseQuery(addr[i+1:])
+		if err != nil {
+			return "", "", 0, fmt.Errorf("Invalid unix socket options %s: %v", addr, err)
+		}
+		for key, values := range query {
+			switch key {
+			case "group":
+				group = values[0]
+			case "mode":
+				mode = values[0]
+			default:
+				return "", "", 0, fmt.Errorf("Invalid unix socket option %s for %s", key, path)
+			}
+		}
+	}
+	m, err := parseSocketMode(mode)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return path, group, m, nil
+}
+
+// parseSocketMode parses the permissions of a unix socket, in octal.
+func parseSocketMode(mode string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m&^0777 != 0 {
+		return 0, fmt.Errorf("Invalid unix socket mode %s, expected permissions in octal such as 0660", mode)
+	}
+	return os.FileMode(m), nil
+}
+
 func setSocketGroup(path, group string) error {
 	if group == "" {
 		return nil
@@ -0,0 +1,43 @@
+// +build linux
+
+package server
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseUnixSocketAddr(t *testing.T) {
+	for _, c := range []struct {
+		addr  string
+		path  string
+		group string
+		mode  os.FileMode
+	}{
+		{"/var/run/docker.sock", "/var/run/docker.sock", "docker", 0660},
+		{"/var/run/docker-ops.sock?group=ops", "/var/run/docker-ops.sock", "ops", 0660},
+		{"/var/run/docker-ops.sock?group=ops&mode=0640", "/var/run/docker-ops.sock", "ops", 0640},
+		{"/var/run/docker-root.sock?group=&mode=600", "/var/run/docker-root.sock", "", 0600},
+	} {
+		path, group, mode, err := parseUnixSocketAddr(c.addr, "docker", "0660")
+		if err != nil {
+			t.Fatalf("%s: %v", c.addr, err)
+		}
+		if path != c.path || group != c.group || mode != c.mode {
+			t.Fatalf("%s: expected %s, %q, %o, got %s, %q, %o", c.addr, c.path, c.group, c.mode, path, group, mode)
+		}
+	}
+
+	for _, addr := range []string{
+		"/var/run/docker.sock?mode=0999",
+		"/var/run/docker.sock?mode=01777",
+		"/var/run/docker.sock?owner=root",
+	} {
+		if _, _, _, err := parseUnixSocketAddr(addr, "docker", "0660"); err == nil {
+			t.Fatalf("Expected %s to be invalid", addr)
+		}
+	}
+	if _, _, _, err := parseUnixSocketAddr("/var/run/docker.sock", "docker", "rw"); err == nil {
+		t.Fatal("Expected an invalid default mode to fail")
+	}
+}
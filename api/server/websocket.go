@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"code.google.com/p/go.net/websocket"
+	"github.com/Sirupsen/logrus"
+)
+
+// Since the API version 1.19, a websocket attached to a container carries
+// its output in binary frames, the first byte of which is the stream of the
+// output: 1 for stdout, 2 for stderr. The client sends the input of the
+// container in binary frames, and control messages in text frames, such as
+// {"Type": "resize", "Height": 24, "Width": 80} to resize its tty. The
+// websocket is closed once the streams of the container are closed.
+const (
+	wsStdout byte = 1
+	wsStderr byte = 2
+
+	wsResize = "resize"
+)
+
+// wsControl is a control message of a websocket client.
+type wsControl struct {
+	Type   string
+	Height int
+	Width  int
+}
+
+// wsFrame is a frame received from a websocket, with its payload type.
+type wsFrame struct {
+	payloadType byte
+	data        []byte
+}
+
+var wsFrameCodec = websocket.Codec{
+	Marshal: func(v interface{}) ([]byte, byte, error) {
+		f := v.(*wsFrame)
+		return f.data, f.payloadType, nil
+	},
+	Unmarshal: func(data []byte, payloadType byte, v interface{}) error {
+		f := v.(*wsFrame)
+		f.payloadType = payloadType
+		f.data = data
+		return nil
+	},
+}
+
+// wsStreamWriter writes the output of a stream of a container to a
+// websocket, each write in a binary frame prefixed by the stream.
+type wsStreamWriter struct {
+	ws     *websocket.Conn
+	stream byte
+}
+
+func (w *wsStreamWriter) Write(p []byte) (int, error) {
+	data := make([]byte, len(p)+1)
+	data[0] = w.stream
+	copy(data[1:], p)
+	if err := wsFrameCodec.Send(w.ws, &wsFrame{payloadType: websocket.BinaryFrame, data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// wsInput reads the input of a container from the binary frames of a
+// websocket, handling the control messages of its text frames.
+type wsInput struct {
+	ws     *websocket.Conn
+	buf    []byte
+	resize func(height, width int) error
+}
+
+func (r *wsInput) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		var f wsFrame
+		if err := wsFrameCodec.Receive(r.ws, &f); err != nil {
+			return 0, err
+		}
+		if f.payloadType == websocket.TextFrame {
+			if err := r.control(f.data); err != nil {
+				logrus.Errorf("Error handling a websocket control message: %s", err)
+			}
+			continue
+		}
+		r.buf = f.data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *wsInput) Close() error {
+	return nil
+}
+
+func (r *wsInput) control(data []byte) error {
+	var msg wsControl
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	switch msg.Type {
+	case wsResize:
+		return r.resize(msg.Height, msg.Width)
+	}
+	return fmt.Errorf("unknown control message %q", msg.Type)
+}
+
+// drain handles the control messages of a websocket which doesn't send the
+// input of the container.
+func (r *wsInput) drain() {
+	buf := make([]byte, 4096)
+	for {
+		if _, err := r.Read(buf); err != nil {
+			if err != io.EOF {
+				logrus.Debugf("Error reading websocket: %s", err)
+			}
+			return
+		}
+	}
+}
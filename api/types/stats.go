@@ -78,10 +78,30 @@ type Network struct {
 	TxDropped uint64 `json:"tx_dropped"`
 }
 
+// LogStats are the stats of the delivery of the messages of the container
+// to its logging driver, in non-blocking mode.
+type LogStats struct {
+	// number of messages dropped while the buffer of the messages was full.
+	Dropped uint64 `json:"dropped"`
+	// size in bytes of the messages dropped.
+	DroppedBytes uint64 `json:"dropped_bytes"`
+}
+
+// PidsStats are the stats of the processes of the container.
+type PidsStats struct {
+	// number of processes in the container.
+	Current uint64 `json:"current,omitempty"`
+}
+
 type Stats struct {
-	Read        time.Time   `json:"read"`
-	Network     Network     `json:"network,omitempty"`
-	CpuStats    CpuStats    `json:"cpu_stats,omitempty"`
-	MemoryStats MemoryStats `json:"memory_stats,omitempty"`
-	BlkioStats  BlkioStats  `json:"blkio_stats,omitempty"`
+	Read    time.Time `json:"read"`
+	Network Network   `json:"network,omitempty"` // the total of the interfaces
+	// stats of each network interface, by its name in the container.
+	Networks    map[string]Network `json:"networks,omitempty"`
+	CpuStats    CpuStats           `json:"cpu_stats,omitempty"`
+	PreCpuStats CpuStats           `json:"precpu_stats,omitempty"` // the CPU stats of the previous read
+	MemoryStats MemoryStats        `json:"memory_stats,omitempty"`
+	BlkioStats  BlkioStats         `json:"blkio_stats,omitempty"`
+	LogStats    LogStats           `json:"log_stats,omitempty"`
+	PidsStats   PidsStats          `json:"pids_stats,omitempty"`
 }
@@ -1,10 +1,12 @@
 package types
 
 import (
+	"os"
 	"time"
 
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/pkg/version"
+	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -63,6 +65,12 @@ type ImageDelete struct {
 	Deleted  string `json:",omitempty"`
 }
 
+// POST "/images/load", in the aux field of the messages of the response
+type ImageLoad struct {
+	Name string `json:",omitempty"` // the repository and tag, none if untagged
+	ID   string
+}
+
 // GET "/images/json"
 type Image struct {
 	ID          string `json:"Id"`
@@ -118,6 +126,17 @@ type CopyConfig struct {
 	Resource string
 }
 
+// ContainerPathStat is the stat of a path in a container, returned by
+// HEAD "/containers/"+containerID+"/archive" in the header
+// X-Docker-Container-Path-Stat, as base64 encoded JSON.
+type ContainerPathStat struct {
+	Name       string      `json:"name"`
+	Size       int64       `json:"size"`
+	Mode       os.FileMode `json:"mode"`
+	Mtime      time.Time   `json:"mtime"`
+	LinkTarget string      `json:"linkTarget"`
+}
+
 // GET "/containers/{name:.*}/top"
 type ContainerProcessList struct {
 	Processes [][]string
@@ -136,38 +155,60 @@ type Version struct {
 
 // GET "/info"
 type Info struct {
-	ID                 string
-	Containers         int
-	Images             int
-	Driver             string
-	DriverStatus       [][2]string
-	MemoryLimit        bool
-	SwapLimit          bool
-	CpuCfsPeriod       bool
-	CpuCfsQuota        bool
-	IPv4Forwarding     bool
-	Debug              bool
-	NFd                int
-	OomKillDisable     bool
-	NGoroutines        int
-	SystemTime         string
-	ExecutionDriver    string
-	LoggingDriver      string
-	NEventsListener    int
-	KernelVersion      string
-	OperatingSystem    string
-	IndexServerAddress string
-	RegistryConfig     interface{}
-	InitSha1           string
-	InitPath           string
-	NCPU               int
-	MemTotal           int64
-	DockerRootDir      string
-	HttpProxy          string
-	HttpsProxy         string
-	NoProxy            string
-	Name               string
-	Labels             []string
+	ID                   string
+	Containers           int
+	Images               int
+	Driver               string
+	DriverStatus         [][2]string
+	MemoryLimit          bool
+	SwapLimit            bool
+	CpuCfsPeriod         bool
+	CpuCfsQuota          bool
+	IPv4Forwarding       bool
+	Debug                bool
+	NFd                  int
+	OomKillDisable       bool
+	NGoroutines          int
+	SystemTime           string
+	ExecutionDriver      string
+	LoggingDriver        string
+	NEventsListener      int
+	KernelVersion        string
+	OperatingSystem      string
+	IndexServerAddress   string
+	RegistryConfig       interface{}
+	InitSha1             string
+	InitPath             string
+	NCPU                 int
+	MemTotal             int64
+	DockerRootDir        string
+	HttpProxy            string
+	HttpsProxy           string
+	NoProxy              string
+	RegistryProxies      map[string]string // the proxies of all the requests to the registries, by registry
+	Name                 string
+	Labels               []string
+	SecurityOptions      []string // the security features applied to the containers: apparmor, seccomp, selinux
+	DefaultCapAdd        []string // the capabilities added to and dropped from the default set of the containers
+	DefaultCapDrop       []string
+	Runtimes             map[string]Runtime // the OCI runtimes registered in the daemon, by name
+	Experimental         bool               // all the experimental features are enabled
+	ExperimentalFeatures []ExperimentalFeature
+}
+
+// ExperimentalFeature is a feature of the daemon shipped behind a gate.
+type ExperimentalFeature struct {
+	Name        string
+	Description string
+	Enabled     bool
+}
+
+// Runtime is an OCI runtime registered in the daemon. Only the names of the
+// environment variables set for it are reported.
+type Runtime struct {
+	Path string
+	Args []string `json:",omitempty"`
+	Env  []string `json:",omitempty"`
 }
 
 // This struct is a temp struct used by execStart
@@ -180,16 +221,41 @@ type ExecStartCheck struct {
 }
 
 type ContainerState struct {
-	Running    bool
-	Paused     bool
-	Restarting bool
-	OOMKilled  bool
-	Dead       bool
-	Pid        int
-	ExitCode   int
-	Error      string
-	StartedAt  time.Time
-	FinishedAt time.Time
+	Running     bool
+	Paused      bool
+	Restarting  bool
+	OOMKilled   bool
+	Dead        bool
+	Pid         int
+	ExitCode    int
+	Error       string
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	PausedAt    time.Time // when the container was paused, while it is
+	NextRestart time.Time
+	Health      *Health `json:",omitempty"`
+}
+
+// Health states
+const (
+	Starting  = "starting"  // no result of the health check yet
+	Healthy   = "healthy"   // the health check passes
+	Unhealthy = "unhealthy" // the health check failed Retries times in a row
+)
+
+// Health is the state of the health check of a container
+type Health struct {
+	Status        string
+	FailingStreak int                  // number of failures in a row
+	Log           []*HealthcheckResult // the latest results of the check
+}
+
+// HealthcheckResult is the result of one run of a health check
+type HealthcheckResult struct {
+	Start    time.Time
+	End      time.Time
+	ExitCode int    // 0 for healthy, anything else for unhealthy
+	Output   string // the start of the output of the check
 }
 
 // GET "/containers/{name:.*}/json"
@@ -217,4 +283,135 @@ type ContainerJSON struct {
 	AppArmorProfile string
 	ExecIDs         []string
 	HostConfig      *runconfig.HostConfig
+	RuntimeState    *ContainerRuntimeState `json:",omitempty"` // only set while the container is running
+}
+
+// ContainerRuntimeState is the state of a running container in the exec
+// driver, to debug it without reading its cgroups on the host.
+type ContainerRuntimeState struct {
+	CgroupPaths     map[string]string // the paths of the cgroups of the container by subsystem
+	Resources       ContainerResources
+	AppArmorProfile string
+	SeccompProfile  string // "default", "unconfined" or "custom"
+	Stats           *Stats // the resource usage of the container when it was inspected
+}
+
+// ContainerResources are the limits in effect in the cgroups of a container,
+// 0 when there is no limit, and -1 for the unlimited swap.
+type ContainerResources struct {
+	Memory         int64
+	MemorySwap     int64
+	OomKillDisable bool
+	CpuShares      int64
+	CpuPeriod      int64
+	CpuQuota       int64
+	CpusetCpus     string
+	CpusetMems     string
+	BlkioWeight    int64
+}
+
+// Volume is a volume managed by the daemon, a named volume or an anonymous
+// one named by its ID
+type Volume struct {
+	Name       string
+	Driver     string
+	Mountpoint string
+	From       string // the volume this volume was cloned from
+	Options    map[string]string
+	Labels     map[string]string
+	UsageData  *VolumeUsageData `json:",omitempty"` // only returned by GET "/volumes/{name:.*}"
+}
+
+type VolumeUsageData struct {
+	Size       int64    // the size of the data of a local volume in bytes, -1 if it isn't available
+	Containers []string // the IDs of the containers referencing the volume
+}
+
+// GET "/volumes"
+type VolumesListResponse struct {
+	Volumes []*Volume
+}
+
+// POST "/containers/prune"
+type ContainersPruneReport struct {
+	ContainersDeleted []string
+	SpaceReclaimed    uint64 // the size of the read-write layers of the containers deleted, in bytes
+}
+
+// POST "/images/prune"
+type ImagesPruneReport struct {
+	ImagesDeleted  []ImageDelete
+	SpaceReclaimed uint64 // the size of the layers of the images deleted, in bytes
+}
+
+// POST "/volumes/prune"
+type VolumesPruneReport struct {
+	VolumesDeleted []string
+	SpaceReclaimed uint64 // the size of the local volumes deleted, in bytes
+}
+
+// POST "/volumes/create"
+type VolumeCreateRequest struct {
+	Name       string // an anonymous volume is created without a name
+	Driver     string // the local driver is used without a driver
+	DriverOpts map[string]string
+	From       string // the volume to clone, instead of creating an empty volume
+	Labels     map[string]string
+}
+
+// GET "/secrets"
+// The data of the secrets is never returned.
+type Secret struct {
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+}
+
+// POST "/secrets/create"
+type SecretCreateRequest struct {
+	Name string
+	Data []byte
+}
+
+// PluginPrivilege is a privilege on the host a plugin requires, such as
+// "devices" with the paths of the devices it accesses.
+type PluginPrivilege struct {
+	Name        string
+	Description string
+	Value       []string
+}
+
+// GET "/plugins"
+type Plugin struct {
+	Name        string
+	Implements  []string
+	Address     string
+	Publisher   string // the ID of the key of the publisher who signed the plugin
+	Privileges  []PluginPrivilege
+	InstalledAt time.Time
+}
+
+// POST "/plugins/privileges"
+type PluginPrivilegesRequest struct {
+	Manifest []byte // the signed manifest of the plugin
+}
+
+// POST "/plugins/install"
+// The privileges granted must be those required by the plugin.
+type PluginInstallRequest struct {
+	Manifest   []byte
+	Privileges []PluginPrivilege
+}
+
+// GET "/distribution/{name:.*}/json"
+// The descriptor of the manifest of an image in its registry, with the
+// manifests it points at if it's a manifest list.
+type DistributionInspect struct {
+	Descriptor registry.ManifestDescriptor
+	Manifests  []registry.ManifestDescriptor `json:",omitempty"`
+}
+
+// POST "/distribution/{name:.*}/push"
+type ManifestPushResponse struct {
+	Digest string
 }
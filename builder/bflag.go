@@ -10,6 +10,7 @@ type FlagType int
 const (
 	boolType FlagType = iota
 	stringType
+	stringsType
 )
 
 type BuilderFlags struct {
@@ -24,6 +25,7 @@ type Flag struct {
 	name     string
 	flagType FlagType
 	Value    string
+	Values   []string // values of a flag added with AddStrings
 }
 
 func NewBuilderFlags() *BuilderFlags {
@@ -55,6 +57,12 @@ func (bf *BuilderFlags) AddString(name string, def string) *Flag {
 	return flag
 }
 
+// AddStrings adds a string flag which may be specified more than once,
+// its values are collected in Values.
+func (bf *BuilderFlags) AddStrings(name string) *Flag {
+	return bf.addFlag(name, stringsType)
+}
+
 func (bf *BuilderFlags) addFlag(name string, flagType FlagType) *Flag {
 	if _, ok := bf.flags[name]; ok {
 		bf.Err = fmt.Errorf("Duplicate flag defined: %s", name)
@@ -117,7 +125,7 @@ func (bf *BuilderFlags) Parse() error {
 			return fmt.Errorf("Unknown flag: %s", arg)
 		}
 
-		if _, ok = bf.used[arg]; ok {
+		if _, ok = bf.used[arg]; ok && flag.flagType != stringsType {
 			return fmt.Errorf("Duplicate flag specified: %s", arg)
 		}
 
@@ -145,6 +153,12 @@ func (bf *BuilderFlags) Parse() error {
 			}
 			flag.Value = value
 
+		case stringsType:
+			if index < 0 {
+				return fmt.Errorf("Missing a value on flag: %s", arg)
+			}
+			flag.Values = append(flag.Values, value)
+
 		default:
 			panic(fmt.Errorf("No idea what kind of flag we have! Should never get here!"))
 		}
@@ -184,4 +184,28 @@ func TestBuilderFlags(t *testing.T) {
 	if !flBool1.IsTrue() {
 		t.Fatalf("Teset %s, bool1 should be true", bf.Args)
 	}
+
+	// ---
+
+	bf = NewBuilderFlags()
+	flStrs1 := bf.AddStrings("strs1")
+	bf.Args = []string{"--strs1=a", "--strs1=b"}
+
+	if err = bf.Parse(); err != nil {
+		t.Fatalf("Test %q was supposed to work: %s", bf.Args, err)
+	}
+
+	if !flStrs1.IsUsed() || len(flStrs1.Values) != 2 || flStrs1.Values[0] != "a" || flStrs1.Values[1] != "b" {
+		t.Fatalf("Test %s, strs1 should be [a b], got %v", bf.Args, flStrs1.Values)
+	}
+
+	// ---
+
+	bf = NewBuilderFlags()
+	flStrs1 = bf.AddStrings("strs1")
+	bf.Args = []string{"--strs1"}
+
+	if err = bf.Parse(); err == nil {
+		t.Fatalf("Test %q was supposed to fail", bf.Args)
+	}
 }
@@ -14,7 +14,9 @@ import (
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/nat"
@@ -152,48 +154,76 @@ func label(b *Builder, args []string, attributes map[string]bool, original strin
 //
 // Add the file 'foo' to '/path'. Tarball and Remote URL (git, http) handling
 // exist here. If you do not wish to have this automatic handling, use COPY.
+// With --chown=<user>[:<group>] the added files are owned by that user and
+// group of the image instead of root.
 //
 func add(b *Builder, args []string, attributes map[string]bool, original string) error {
 	if len(args) < 2 {
 		return fmt.Errorf("ADD requires at least two arguments")
 	}
 
+	flChown := b.BuilderFlags.AddString("chown", "")
+
 	if err := b.BuilderFlags.Parse(); err != nil {
 		return err
 	}
 
-	return b.runContextCommand(args, true, true, "ADD")
+	return b.runContextCommand(args, true, true, "ADD", flChown.Value)
 }
 
 // COPY foo /path
 //
-// Same as 'ADD' but without the tar and remote url handling.
+// Same as 'ADD' but without the tar and remote url handling. With
+// --from=<stage> the files are copied from a previous build stage instead of
+// the context. --chown works the same as for ADD.
 //
 func dispatchCopy(b *Builder, args []string, attributes map[string]bool, original string) error {
 	if len(args) < 2 {
 		return fmt.Errorf("COPY requires at least two arguments")
 	}
 
+	flFrom := b.BuilderFlags.AddString("from", "")
+	flChown := b.BuilderFlags.AddString("chown", "")
+
 	if err := b.BuilderFlags.Parse(); err != nil {
 		return err
 	}
 
-	return b.runContextCommand(args, false, false, "COPY")
+	if flFrom.IsUsed() {
+		if flFrom.Value == "" {
+			return fmt.Errorf("COPY --from requires a build stage or image name")
+		}
+		return b.runCopyFromStage(args, flFrom.Value, "COPY", flChown.Value)
+	}
+
+	return b.runContextCommand(args, false, false, "COPY", flChown.Value)
 }
 
-// FROM imagename
+// FROM imagename [AS name]
 //
-// This sets the image the dockerfile will build on top of.
+// This sets the image the dockerfile will build on top of. Each FROM starts
+// a new build stage, which can be named to refer to it from COPY --from.
 //
 func from(b *Builder, args []string, attributes map[string]bool, original string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("FROM requires one argument")
+	if len(args) != 1 && len(args) != 3 {
+		return fmt.Errorf("FROM requires either one or three arguments")
+	}
+	if len(args) == 3 && !strings.EqualFold(args[1], "as") {
+		return fmt.Errorf("FROM requires the form `FROM <image> AS <name>` when given three arguments")
 	}
 
 	if err := b.BuilderFlags.Parse(); err != nil {
 		return err
 	}
 
+	stageName := ""
+	if len(args) == 3 {
+		stageName = args[2]
+	}
+	if err := b.startStage(stageName); err != nil {
+		return err
+	}
+
 	name := args[0]
 
 	if name == NoBaseImageSpecifier {
@@ -203,7 +233,7 @@ func from(b *Builder, args []string, attributes map[string]bool, original string
 	}
 
 	image, err := b.Daemon.Repositories().LookupImage(name)
-	if b.Pull {
+	if b.Pull || (err == nil && b.platform != nil && !b.platform.Match(image.OS, image.Architecture)) {
 		image, err = b.pullImage(name)
 		if err != nil {
 			return err
@@ -290,15 +320,37 @@ func workdir(b *Builder, args []string, attributes map[string]bool, original str
 // RUN echo hi          # cmd /S /C echo hi   (Windows)
 // RUN [ "echo", "hi" ] # echo hi
 //
+// With --mount=type=secret,id=<id> the secret passed to the build with
+// --secret id=<id> is available to the command at /run/secrets/<id>, and with
+// --mount=type=ssh the SSH agent forwarded with --ssh is, without either
+// being committed to the image.
+//
 func run(b *Builder, args []string, attributes map[string]bool, original string) error {
 	if b.image == "" && !b.noBaseImage {
 		return fmt.Errorf("Please provide a source image with `from` prior to run")
 	}
 
+	flMount := b.BuilderFlags.AddStrings("mount")
+
 	if err := b.BuilderFlags.Parse(); err != nil {
 		return err
 	}
 
+	var (
+		mounts   []*runMount
+		mountEnv []string
+	)
+	for _, spec := range flMount.Values {
+		m, err := parseRunMount(spec)
+		if err != nil {
+			return err
+		}
+		if m.mountType == "ssh" {
+			mountEnv = append(mountEnv, "SSH_AUTH_SOCK="+m.target)
+		}
+		mounts = append(mounts, m)
+	}
+
 	args = handleJsonArgs(args, attributes)
 
 	if !attributes["json"] {
@@ -319,14 +371,46 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 	}
 
 	cmd := b.Config.Cmd
-	// set Cmd manually, this is special case only for Dockerfiles
-	b.Config.Cmd = config.Cmd
 	runconfig.Merge(b.Config, config)
-
 	defer func(cmd *runconfig.Command) { b.Config.Cmd = cmd }(cmd)
 
-	logrus.Debugf("[BUILDER] Command to be executed: %v", b.Config.Cmd)
+	// derive the net build-time environment for this run. We let config
+	// environment override the build time environment.
+	// This means that the following cases are handled differently:
+	// ARG foo=bar / ENV foo=baz -> RUN sees foo=baz
+	// ENV foo=baz / ARG foo=bar -> RUN still sees foo=baz
+	cmdBuildEnv := []string{}
+	configEnv := runconfig.ConvertKVStringsToMap(b.Config.Env)
+	for key, val := range b.buildArgs {
+		if !b.isBuildArgAllowed(key) {
+			// skip build-args that are not in allowed list, meaning they have
+			// not been defined by an "ARG" Dockerfile command yet.
+			// This is an error condition but only if there is no "ARG" in the entire
+			// Dockerfile, so we'll generate any necessary errors after we parsed
+			// the entire file (see 'leftoverArgs' processing in evaluator.go )
+			continue
+		}
+		if _, ok := configEnv[key]; !ok {
+			cmdBuildEnv = append(cmdBuildEnv, fmt.Sprintf("%s=%s", key, val))
+		}
+	}
+	// keep the cache key independent of map ordering
+	sort.Strings(cmdBuildEnv)
+
+	// derive the command to use for probeCache() and to commit in this container.
+	// Note that we only do this if there are any build-time env vars.  Also, we
+	// use the special argument "|#" at the start of the args array. This will
+	// avoid conflicts with any RUN command since commands can not
+	// start with | (vertical bar). The "#" (number of build envs) is there to
+	// help ensure proper cache matches. We don't want a RUN command
+	// that starts with "foo=abc" to be considered part of a build-time env var.
+	saveCmd := config.Cmd
+	if len(cmdBuildEnv) > 0 {
+		tmpEnv := append([]string{fmt.Sprintf("|%d", len(cmdBuildEnv))}, cmdBuildEnv...)
+		saveCmd = runconfig.NewCommand(append(tmpEnv, saveCmd.Slice()...)...)
+	}
 
+	b.Config.Cmd = saveCmd
 	hit, err := b.probeCache()
 	if err != nil {
 		return err
@@ -335,8 +419,18 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 		return nil
 	}
 
+	// set Cmd manually, this is special case only for Dockerfiles
+	b.Config.Cmd = config.Cmd
+	// set build-time environment for 'run'.
+	env := b.Config.Env
+	b.Config.Env = append(b.Config.Env, cmdBuildEnv...)
+	b.Config.Env = append(b.Config.Env, mountEnv...)
+
+	logrus.Debugf("[BUILDER] Command to be executed: %v", b.Config.Cmd)
+
 	c, err := b.create()
 	if err != nil {
+		b.Config.Env = env
 		return err
 	}
 
@@ -345,7 +439,23 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 	c.Mount()
 	defer c.Unmount()
 
+	removeMounts, err := b.mountRunMounts(c, mounts)
+	if err != nil {
+		b.Config.Env = env
+		return err
+	}
+	defer removeMounts()
+
 	err = b.run(c)
+	// the mounts must be gone from the container before it is committed
+	removeMounts()
+	// revert to original config environment and set the command string to
+	// have the build-time env vars in it (if any) so that future cache look-ups
+	// properly match it. The container shares b.Config, so this is also what
+	// gets recorded as the image's container config, the build-time values are
+	// never persisted into the image's runtime config.
+	b.Config.Env = env
+	b.Config.Cmd = saveCmd
 	if err != nil {
 		return err
 	}
@@ -356,6 +466,57 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 	return nil
 }
 
+// ARG name[=value]
+//
+// Adds the variable foo to the trusted list of variables that can be passed
+// to builder using the --build-arg flag for expansion/subsitution or passing to 'run'.
+// Dockerfile author may optionally set a default value of this variable.
+func arg(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("ARG requires exactly one argument definition")
+	}
+
+	if err := b.BuilderFlags.Parse(); err != nil {
+		return err
+	}
+
+	var (
+		name       string
+		value      string
+		hasDefault bool
+	)
+
+	arg := args[0]
+	// 'arg' can just be a name or name-value pair. Note that this is different
+	// from 'env' that handles the split of name and value at the parser level.
+	// The reason for doing it differently for 'arg' is that we support just
+	// defining an arg and not assign it a value (while 'env' always expects a
+	// name-value pair). If possible, it will be good to harmonize the two.
+	if strings.Contains(arg, "=") {
+		parts := strings.SplitN(arg, "=", 2)
+		name = parts[0]
+		value = parts[1]
+		hasDefault = true
+	} else {
+		name = arg
+		hasDefault = false
+	}
+	if name == "" {
+		return fmt.Errorf("ARG requires a variable name")
+	}
+	// add the arg to allowed list of build-time args from this step on.
+	b.allowedBuildArgs[name] = true
+
+	// If there is a default value associated with this arg then add it to the
+	// b.buildArgs if one is not already passed to the builder. The args passed
+	// to builder override the default value of 'arg'.
+	if _, ok := b.buildArgs[name]; !ok && hasDefault {
+		b.buildArgs[name] = value
+	}
+
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("ARG %s", arg))
+}
+
 // CMD foo
 //
 // Set the default command to run in the container (which may be empty).
@@ -389,6 +550,82 @@ func cmd(b *Builder, args []string, attributes map[string]bool, original string)
 	return nil
 }
 
+// HEALTHCHECK [--interval=30s] [--timeout=30s] [--retries=3] CMD command
+// HEALTHCHECK NONE
+//
+// Set the command the daemon runs periodically to check that a container of
+// the image is healthy, in the same form as for CMD, or disable the check
+// inherited from the base image.
+//
+func healthcheck(b *Builder, args []string, attributes map[string]bool, original string) error {
+	flInterval := b.BuilderFlags.AddString("interval", "")
+	flTimeout := b.BuilderFlags.AddString("timeout", "")
+	flRetries := b.BuilderFlags.AddString("retries", "")
+
+	if err := b.BuilderFlags.Parse(); err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("HEALTHCHECK requires an argument")
+	}
+
+	switch typ := strings.ToUpper(args[0]); typ {
+	case "NONE":
+		if len(args) != 1 || flInterval.IsUsed() || flTimeout.IsUsed() || flRetries.IsUsed() {
+			return fmt.Errorf("HEALTHCHECK NONE takes no arguments")
+		}
+		b.Config.Healthcheck = &runconfig.HealthConfig{Test: []string{typ}}
+	case "CMD":
+		cmdSlice := handleJsonArgs(args[1:], attributes)
+		if len(cmdSlice) == 0 {
+			return fmt.Errorf("Missing command after HEALTHCHECK CMD")
+		}
+		if !attributes["json"] {
+			typ = "CMD-SHELL"
+		}
+
+		health := &runconfig.HealthConfig{
+			Test: append([]string{typ}, cmdSlice...),
+		}
+		var err error
+		if health.Interval, err = parseHealthDuration(flInterval); err != nil {
+			return err
+		}
+		if health.Timeout, err = parseHealthDuration(flTimeout); err != nil {
+			return err
+		}
+		if flRetries.Value != "" {
+			retries, err := strconv.Atoi(flRetries.Value)
+			if err != nil || retries < 1 {
+				return fmt.Errorf("--retries must be a positive integer: %s", flRetries.Value)
+			}
+			health.Retries = retries
+		}
+		b.Config.Healthcheck = health
+	default:
+		return fmt.Errorf("Unknown type %s in HEALTHCHECK (try CMD)", args[0])
+	}
+
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("HEALTHCHECK %q", b.Config.Healthcheck.Test))
+}
+
+// parseHealthDuration parses the value of a duration flag of HEALTHCHECK, the
+// zero value meaning the default of the daemon.
+func parseHealthDuration(fl *Flag) (time.Duration, error) {
+	if fl.Value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(fl.Value)
+	if err != nil {
+		return 0, fmt.Errorf("--%s: %v", fl.name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("--%s must be a positive duration: %s", fl.name, fl.Value)
+	}
+	return d, nil
+}
+
 // ENTRYPOINT /usr/sbin/nginx
 //
 // Set the entrypoint (which defaults to sh -c on linux, or cmd /S /C on Windows) to
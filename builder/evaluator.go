@@ -24,6 +24,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -33,6 +34,7 @@ import (
 	"github.com/docker/docker/cliconfig"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/platform"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/symlink"
@@ -51,26 +53,42 @@ var replaceEnvAllowed = map[string]struct{}{
 	command.Expose:  {},
 	command.Volume:  {},
 	command.User:    {},
+	command.Arg:     {},
+}
+
+// builtinAllowedBuildArgs are build-time variables which can be passed with
+// --build-arg without being declared by an ARG instruction.
+var builtinAllowedBuildArgs = map[string]bool{
+	"HTTP_PROXY":  true,
+	"http_proxy":  true,
+	"HTTPS_PROXY": true,
+	"https_proxy": true,
+	"FTP_PROXY":   true,
+	"ftp_proxy":   true,
+	"NO_PROXY":    true,
+	"no_proxy":    true,
 }
 
 var evaluateTable map[string]func(*Builder, []string, map[string]bool, string) error
 
 func init() {
 	evaluateTable = map[string]func(*Builder, []string, map[string]bool, string) error{
-		command.Env:        env,
-		command.Label:      label,
-		command.Maintainer: maintainer,
-		command.Add:        add,
-		command.Copy:       dispatchCopy, // copy() is a go builtin
-		command.From:       from,
-		command.Onbuild:    onbuild,
-		command.Workdir:    workdir,
-		command.Run:        run,
-		command.Cmd:        cmd,
-		command.Entrypoint: entrypoint,
-		command.Expose:     expose,
-		command.Volume:     volume,
-		command.User:       user,
+		command.Env:         env,
+		command.Label:       label,
+		command.Maintainer:  maintainer,
+		command.Add:         add,
+		command.Copy:        dispatchCopy, // copy() is a go builtin
+		command.From:        from,
+		command.Onbuild:     onbuild,
+		command.Workdir:     workdir,
+		command.Run:         run,
+		command.Cmd:         cmd,
+		command.Entrypoint:  entrypoint,
+		command.Expose:      expose,
+		command.Volume:      volume,
+		command.User:        user,
+		command.Arg:         arg,
+		command.Healthcheck: healthcheck,
 	}
 }
 
@@ -87,12 +105,45 @@ type Builder struct {
 	Verbose      bool
 	UtilizeCache bool
 	cacheBusted  bool
+	cacheFrom    []string // images whose history is used as the build cache
 
 	// controls how images and containers are handled between steps.
 	Remove      bool
 	ForceRemove bool
 	Pull        bool
 
+	// build-time variables passed with --build-arg, only those declared
+	// with ARG in the Dockerfile (or builtin) are made available to RUN.
+	buildArgs        map[string]string
+	allowedBuildArgs map[string]bool
+
+	// labels passed with --label, set on the image built by a last step.
+	labels map[string]string
+
+	// the platform passed with --platform the base images are pulled for.
+	platform *platform.Platform
+
+	// secrets passed with --secret, only exposed to RUN --mount=type=secret.
+	secrets map[string][]byte
+	// the client's SSH agent, only exposed to RUN --mount=type=ssh.
+	sshAgent *sshAgentSession
+
+	// multi-stage builds: every FROM starts a new stage, and the images of
+	// the stages completed so far can be copied from with COPY --from.
+	target      string         // name of the stage to stop the build at
+	inStage     bool           // indicates if a FROM has been processed
+	stageName   string         // name of the current stage, if it has one
+	stageImages []string       // image IDs of the completed stages
+	stageNames  map[string]int // stage names to their index in stageImages
+	baseImage   string         // image ID the current stage was started from
+
+	// squash the layers of the final stage into one on top of its base image.
+	squash bool
+
+	// the last image committed by the build, unlike those taken from the
+	// cache or the base images, which the build alone refers to.
+	committed string
+
 	// set this to true if we want the builder to not commit between steps.
 	// This is useful when we only want to use the evaluator table to generate
 	// the final configs of the Dockerfile but dont want the layers
@@ -130,6 +181,10 @@ type Builder struct {
 	memory       int64
 	memorySwap   int64
 
+	// Set the network of build containers
+	networkMode runconfig.NetworkMode
+	extraHosts  []string
+
 	cancelled <-chan struct{} // When closed, job was cancelled.
 }
 
@@ -164,7 +219,19 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 	b.Config = &runconfig.Config{}
 
 	b.TmpContainers = map[string]struct{}{}
+	if b.buildArgs == nil {
+		b.buildArgs = map[string]string{}
+	}
+	b.allowedBuildArgs = map[string]bool{}
+	b.stageNames = map[string]int{}
+	b.resolveCacheFrom()
 
+	b.target = strings.ToLower(b.target)
+	if b.target != "" && !b.hasStage(b.target) {
+		return "", fmt.Errorf("Failed to reach build target %s in Dockerfile", b.target)
+	}
+
+	var steps int
 	for i, n := range b.dockerfile.Children {
 		select {
 		case <-b.cancelled:
@@ -174,6 +241,10 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 		default:
 			// Not cancelled yet, keep going...
 		}
+		// the target stage is complete once the next one begins
+		if n.Value == command.From && b.target != "" && b.stageName == b.target {
+			break
+		}
 		if err := b.dispatch(i, n); err != nil {
 			if b.ForceRemove {
 				b.clearTmp()
@@ -184,16 +255,132 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 		if b.Remove {
 			b.clearTmp()
 		}
+		steps = i + 1
+	}
+
+	if len(b.labels) > 0 && b.image != "" {
+		if err := b.commitLabels(steps); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(b.OutStream, " ---> %s\n", stringid.TruncateID(b.image))
+	}
+
+	// check if there are any leftover build-args that were passed but not
+	// consumed during build. Return an error, if there are any.
+	leftoverArgs := []string{}
+	for arg := range b.buildArgs {
+		if !b.isBuildArgAllowed(arg) {
+			leftoverArgs = append(leftoverArgs, arg)
+		}
+	}
+	if len(leftoverArgs) > 0 {
+		sort.Strings(leftoverArgs)
+		return "", fmt.Errorf("One or more build-args %v were not consumed, failing build.", leftoverArgs)
 	}
 
 	if b.image == "" {
 		return "", fmt.Errorf("No image was generated. Is your Dockerfile empty?")
 	}
 
+	if b.squash {
+		if err := b.squashImage(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := b.saveBuildCache(); err != nil {
+		return "", err
+	}
+
 	fmt.Fprintf(b.OutStream, "Successfully built %s\n", stringid.TruncateID(b.image))
 	return b.image, nil
 }
 
+// commitLabels sets the labels given with --label on the image built, as a
+// last LABEL step of the Dockerfile would. They override the labels of the
+// Dockerfile, and unlike those their values are not expanded.
+func (b *Builder) commitLabels(stepN int) error {
+	keys := make([]string, 0, len(b.labels))
+	for k := range b.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if b.Config.Labels == nil {
+		b.Config.Labels = map[string]string{}
+	}
+	commitStr := "LABEL"
+	for _, k := range keys {
+		b.Config.Labels[k] = b.labels[k]
+		commitStr += " " + k + "=" + b.labels[k]
+	}
+	fmt.Fprintf(b.OutStream, "Step %d : %s\n", stepN, commitStr)
+	return b.commit("", b.Config.Cmd, commitStr)
+}
+
+// saveBuildCache records the images of the earlier stages of a multi-stage
+// build as the build cache of the resulting image. They are not its parents,
+// so without this they would be left out when the image is exported with its
+// build cache.
+func (b *Builder) saveBuildCache() error {
+	var ids []string
+	seen := map[string]struct{}{b.image: {}}
+	for _, id := range b.stageImages {
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return b.Daemon.Graph().SetBuildCache(b.image, ids)
+}
+
+// hasStage checks if the Dockerfile has a build stage named name, as in
+// `FROM image AS name`.
+func (b *Builder) hasStage(name string) bool {
+	for _, n := range b.dockerfile.Children {
+		if n.Value != command.From || n.Next == nil || n.Next.Next == nil || n.Next.Next.Next == nil {
+			continue
+		}
+		if strings.EqualFold(n.Next.Next.Next.Value, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBuildArgAllowed checks if the given build-time variable is declared by
+// an ARG instruction so far, or is one of the builtin ones.
+func (b *Builder) isBuildArgAllowed(arg string) bool {
+	if _, ok := builtinAllowedBuildArgs[arg]; ok {
+		return true
+	}
+	if _, ok := b.allowedBuildArgs[arg]; ok {
+		return true
+	}
+	return false
+}
+
+// buildEnv returns the environment used for variable substitution: the
+// config's environment plus the allowed build-time variables, where the
+// former takes precedence.
+func (b *Builder) buildEnv() []string {
+	envs := b.Config.Env
+	configEnv := runconfig.ConvertKVStringsToMap(envs)
+	for key, val := range b.buildArgs {
+		if !b.isBuildArgAllowed(key) {
+			continue
+		}
+		if _, ok := configEnv[key]; !ok {
+			envs = append(envs, fmt.Sprintf("%s=%s", key, val))
+		}
+	}
+	return envs
+}
+
 // Reads a Dockerfile from the current context. It assumes that the
 // 'filename' is a relative path from the root of the context
 func (b *Builder) readDockerfile() error {
@@ -245,12 +432,14 @@ func (b *Builder) readDockerfile() error {
 	// we had the Dockerfile to actually parse, and then we also need the
 	// .dockerignore file to know whether either file should be removed.
 	// Note that this assumes the Dockerfile has been read into memory and
-	// is now safe to be removed.
-
-	excludes, _ := utils.ReadDockerIgnore(filepath.Join(b.contextPath, ".dockerignore"))
-	if rm, _ := fileutils.Matches(".dockerignore", excludes); rm == true {
-		os.Remove(filepath.Join(b.contextPath, ".dockerignore"))
-		b.context.(tarsum.BuilderContext).Remove(".dockerignore")
+	// is now safe to be removed. The Dockerfile may have an ignore file of
+	// its own, named after it, which takes the place of .dockerignore.
+
+	dockerIgnore := utils.DockerIgnoreFile(b.contextPath, b.dockerfileName)
+	excludes, _ := utils.ReadDockerIgnore(filepath.Join(b.contextPath, dockerIgnore))
+	if rm, _ := fileutils.Matches(dockerIgnore, excludes); rm == true {
+		os.Remove(filepath.Join(b.contextPath, dockerIgnore))
+		b.context.(tarsum.BuilderContext).Remove(dockerIgnore)
 	}
 	if rm, _ := fileutils.Matches(b.dockerfileName, excludes); rm == true {
 		os.Remove(filepath.Join(b.contextPath, b.dockerfileName))
@@ -321,7 +510,7 @@ func (b *Builder) dispatch(stepN int, ast *parser.Node) error {
 		str = ast.Value
 		if _, ok := replaceEnvAllowed[cmd]; ok {
 			var err error
-			str, err = ProcessWord(ast.Value, b.Config.Env)
+			str, err = ProcessWord(ast.Value, b.buildEnv())
 			if err != nil {
 				return err
 			}
@@ -15,11 +15,13 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/builder/parser"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/graph"
@@ -29,14 +31,17 @@ import (
 	"github.com/docker/docker/pkg/httputils"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/progressreader"
 	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/pkg/tarsum"
 	"github.com/docker/docker/pkg/urlutil"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
+	userpkg "github.com/docker/libcontainer/user"
 )
 
 func (b *Builder) readContext(context io.Reader) error {
@@ -109,10 +114,78 @@ func (b *Builder) commit(id string, autoCmd *runconfig.Command, comment string)
 		return err
 	}
 	b.image = image.ID
+	b.committed = image.ID
+	return nil
+}
+
+// squashImage replaces the image of the final stage with one holding all
+// the changes made by the stage in a single layer on top of its base image.
+// The unsquashed image is kept, so that the build cache remains intact.
+func (b *Builder) squashImage() error {
+	if b.image == b.baseImage {
+		return nil
+	}
+
+	graph := b.Daemon.Graph()
+	img, err := graph.Get(b.image)
+	if err != nil {
+		return err
+	}
+
+	driver := graph.Driver()
+	fs, err := driver.Get(img.ID, "")
+	if err != nil {
+		return err
+	}
+	defer driver.Put(img.ID)
+
+	var layer archive.Archive
+	if b.baseImage == "" {
+		if layer, err = archive.Tar(fs, archive.Uncompressed); err != nil {
+			return err
+		}
+	} else {
+		baseFs, err := driver.Get(b.baseImage, "")
+		if err != nil {
+			return err
+		}
+		defer driver.Put(b.baseImage)
+
+		changes, err := archive.ChangesDirs(fs, baseFs)
+		if err != nil {
+			return err
+		}
+		if layer, err = archive.ExportChanges(fs, changes); err != nil {
+			return err
+		}
+	}
+	defer layer.Close()
+
+	squashed := &imagepkg.Image{
+		ID:            stringid.GenerateRandomID(),
+		Parent:        b.baseImage,
+		Comment:       fmt.Sprintf("merge %s to %s", img.ID, b.baseImage),
+		Created:       time.Now().UTC(),
+		DockerVersion: dockerversion.VERSION,
+		Author:        img.Author,
+		Config:        img.Config,
+		Architecture:  img.Architecture,
+		OS:            img.OS,
+	}
+	if err := graph.Register(squashed, layer); err != nil {
+		return err
+	}
+
+	// the unsquashed image holds the build cache of the final stage
+	b.stageImages = append(b.stageImages, b.image)
+	b.image = squashed.ID
+	b.committed = squashed.ID
+	fmt.Fprintf(b.OutStream, "Squashed %s into %s\n", stringid.TruncateID(img.ID), stringid.TruncateID(squashed.ID))
 	return nil
 }
 
 type copyInfo struct {
+	root       string // directory origPath is relative to
 	origPath   string
 	destPath   string
 	hash       string
@@ -120,7 +193,7 @@ type copyInfo struct {
 	tmpDir     string
 }
 
-func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecompression bool, cmdName string) error {
+func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecompression bool, cmdName string, chown string) error {
 	if b.context == nil {
 		return fmt.Errorf("No context given. Impossible to use %s", cmdName)
 	}
@@ -161,6 +234,49 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 		}
 	}
 
+	return b.copyToContainer(copyInfos, dest, cmdName, chown)
+}
+
+// runCopyFromStage is the COPY --from counterpart of runContextCommand: the
+// sources are looked up in the filesystem of a previous build stage (or of
+// an image) instead of in the build context.
+func (b *Builder) runCopyFromStage(args []string, from string, cmdName string, chown string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("Invalid %s format - at least two arguments required", cmdName)
+	}
+
+	imageID, err := b.stageImage(from)
+	if err != nil {
+		return err
+	}
+
+	driver := b.Daemon.GraphDriver()
+	root, err := driver.Get(imageID, "")
+	if err != nil {
+		return err
+	}
+	defer driver.Put(imageID)
+
+	dest := args[len(args)-1] // last one is always the dest
+
+	copyInfos := []*copyInfo{}
+
+	b.Config.Image = b.image
+
+	for _, orig := range args[0 : len(args)-1] {
+		if err := calcStageCopyInfo(b, cmdName, &copyInfos, root, orig, dest); err != nil {
+			return err
+		}
+	}
+
+	return b.copyToContainer(copyInfos, dest, cmdName, chown)
+}
+
+// copyToContainer copies the sources described by copyInfos into a new
+// container created from the current image and commits the result. The
+// copied files are owned by chown, a user[:group] of the image, or by root
+// when it is empty.
+func (b *Builder) copyToContainer(copyInfos []*copyInfo, dest string, cmdName string, chown string) error {
 	if len(copyInfos) == 0 {
 		return fmt.Errorf("No source files were specified")
 	}
@@ -189,6 +305,10 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 		srcHash = "multi:" + hex.EncodeToString(hasher.Sum(nil))
 		origPaths = strings.Join(origs, " ")
 	}
+	if chown != "" {
+		// a different owner gives a different layer
+		cmdName = fmt.Sprintf("%s --chown=%s", cmdName, chown)
+	}
 
 	cmd := b.Config.Cmd
 	b.Config.Cmd = runconfig.NewCommand("/bin/sh", "-c", fmt.Sprintf("#(nop) %s %s in %s", cmdName, srcHash, dest))
@@ -214,8 +334,13 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 	}
 	defer container.Unmount()
 
+	uid, gid, err := lookupChown(container, chown)
+	if err != nil {
+		return err
+	}
+
 	for _, ci := range copyInfos {
-		if err := b.addContext(container, ci.origPath, ci.destPath, ci.decompress); err != nil {
+		if err := b.addContext(container, ci.root, ci.origPath, ci.destPath, ci.decompress, uid, gid); err != nil {
 			return err
 		}
 	}
@@ -233,17 +358,7 @@ func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath stri
 	}
 	origPath = strings.TrimPrefix(origPath, "./")
 
-	// Twiddle the destPath when its a relative path - meaning, make it
-	// relative to the WORKINGDIR
-	if !filepath.IsAbs(destPath) {
-		hasSlash := strings.HasSuffix(destPath, "/")
-		destPath = filepath.Join("/", b.Config.WorkingDir, destPath)
-
-		// Make sure we preserve any trailing slash
-		if hasSlash {
-			destPath += "/"
-		}
-	}
+	destPath = b.absDestPath(destPath)
 
 	// In the remote/URL case, download it and gen its hashcode
 	if urlutil.IsURL(origPath) {
@@ -252,6 +367,7 @@ func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath stri
 		}
 
 		ci := copyInfo{}
+		ci.root = b.contextPath
 		ci.origPath = origPath
 		ci.hash = origPath // default to this but can change
 		ci.destPath = destPath
@@ -376,6 +492,7 @@ func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath stri
 	fi, _ := os.Stat(path.Join(b.contextPath, origPath))
 
 	ci := copyInfo{}
+	ci.root = b.contextPath
 	ci.origPath = origPath
 	ci.hash = origPath
 	ci.destPath = destPath
@@ -428,6 +545,354 @@ func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath stri
 	return nil
 }
 
+// absDestPath twiddles destPath when it is a relative path - meaning, makes
+// it relative to the WORKINGDIR.
+func (b *Builder) absDestPath(destPath string) string {
+	if filepath.IsAbs(destPath) {
+		return destPath
+	}
+	hasSlash := strings.HasSuffix(destPath, "/")
+	destPath = filepath.Join("/", b.Config.WorkingDir, destPath)
+
+	// Make sure we preserve any trailing slash
+	if hasSlash {
+		destPath += "/"
+	}
+	return destPath
+}
+
+// calcStageCopyInfo resolves origPath inside root, the filesystem of the
+// image a COPY --from reads from. Symlinks are resolved within root and
+// wildcards are only supported in the last path component.
+func calcStageCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, root string, origPath string, destPath string) error {
+	destPath = b.absDestPath(destPath)
+
+	dir, base := path.Split(path.Clean("/" + origPath))
+	if ContainsWildcards(dir) {
+		return fmt.Errorf("%s: wildcards are only supported in the last path component with %s --from", origPath, cmdName)
+	}
+
+	var sources []string
+	if ContainsWildcards(base) {
+		scopedDir, err := symlink.FollowSymlinkInScope(filepath.Join(root, dir), root)
+		if err != nil {
+			return err
+		}
+		names, err := readDirNames(scopedDir)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if match, _ := path.Match(base, name); match {
+				sources = append(sources, filepath.Join(scopedDir, name))
+			}
+		}
+	} else {
+		source, err := symlink.FollowSymlinkInScope(filepath.Join(root, dir, base), root)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, source := range sources {
+		if _, err := os.Stat(source); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%s: no such file or directory", origPath)
+			}
+			return err
+		}
+		rel, err := filepath.Rel(root, source)
+		if err != nil {
+			return err
+		}
+
+		ci := copyInfo{}
+		ci.root = root
+		ci.origPath = rel
+		ci.destPath = destPath
+
+		// The image's layers are not part of the context's tarsum, so
+		// calculate the checksum of the source to use for the cache.
+		r, err := archive.Tar(source, archive.Uncompressed)
+		if err != nil {
+			return err
+		}
+		tarSum, err := tarsum.NewTarSum(r, true, tarsum.Version0)
+		if err != nil {
+			r.Close()
+			return err
+		}
+		if _, err := io.Copy(ioutil.Discard, tarSum); err != nil {
+			r.Close()
+			return err
+		}
+		ci.hash = tarSum.Sum(nil)
+		r.Close()
+
+		*cInfos = append(*cInfos, &ci)
+	}
+	return nil
+}
+
+// readDirNames returns the sorted names of the entries in dir.
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// resolveCacheFrom replaces the names of the images given with --cache-from
+// by their IDs. Images which don't exist are skipped with a warning, so that
+// the first build on a machine doesn't fail.
+func (b *Builder) resolveCacheFrom() {
+	var ids []string
+	for _, name := range b.cacheFrom {
+		img, err := b.Daemon.Repositories().LookupImage(name)
+		if err != nil {
+			fmt.Fprintf(b.OutStream, "[Warning] Unable to use %s as build cache: %v\n", name, err)
+			continue
+		}
+		ids = append(ids, img.ID)
+	}
+	b.cacheFrom = ids
+}
+
+// runMount is a `RUN --mount=type=<secret|ssh>,id=<id>[,target=<path>]`
+// flag.
+type runMount struct {
+	mountType string
+	id        string
+	target    string
+}
+
+// parseRunMount parses a value of the --mount flag of RUN. The target of a
+// secret defaults to /run/secrets/<id>, the one of the SSH agent to
+// sshAgentTarget.
+func parseRunMount(spec string) (*runMount, error) {
+	m := &runMount{}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid field %q in --mount, must be key=value", field)
+		}
+		switch strings.ToLower(parts[0]) {
+		case "type":
+			m.mountType = parts[1]
+		case "id":
+			m.id = parts[1]
+		case "target", "dst", "destination":
+			m.target = parts[1]
+		default:
+			return nil, fmt.Errorf("Unknown key %q in --mount", parts[0])
+		}
+	}
+
+	switch m.mountType {
+	case "secret":
+		if m.id == "" {
+			if m.target == "" {
+				return nil, fmt.Errorf("--mount=type=secret requires an id or a target")
+			}
+			m.id = filepath.Base(m.target)
+		}
+		if m.target == "" {
+			m.target = path.Join("/run/secrets", m.id)
+		}
+	case "ssh":
+		if m.id == "" {
+			m.id = "default"
+		}
+		if m.id != "default" {
+			return nil, fmt.Errorf("Unknown SSH agent %q, only the default one can be forwarded", m.id)
+		}
+		if m.target == "" {
+			m.target = sshAgentTarget
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported --mount type %q, must be secret or ssh", m.mountType)
+	}
+
+	if !path.IsAbs(m.target) {
+		return nil, fmt.Errorf("The target of a --mount must be an absolute path: %s", m.target)
+	}
+	return m, nil
+}
+
+// mountRunMounts makes the mounts of a RUN available in the container c
+// while it runs. The returned function removes them and must be called
+// before commit.
+func (b *Builder) mountRunMounts(c *daemon.Container, mounts []*runMount) (func(), error) {
+	var cleanups []func()
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+		cleanups = nil
+	}
+
+	for _, m := range mounts {
+		var (
+			release func()
+			err     error
+		)
+		switch m.mountType {
+		case "secret":
+			release, err = b.mountSecret(c, m)
+		case "ssh":
+			release, err = b.mountSSHAgent(c, m)
+		}
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		cleanups = append(cleanups, release)
+	}
+	return cleanup, nil
+}
+
+// addTmpMount bind mounts source at target in the container c while it runs.
+// The returned function removes the mount point libcontainer creates for it
+// in the container, so it doesn't end up in the committed layer.
+func addTmpMount(c *daemon.Container, source, target string) (func(), error) {
+	// find the topmost directory of the target which doesn't exist in the
+	// image, everything from there on is created only for the mount.
+	createdPath := ""
+	for p := path.Clean(target); p != "/"; p = path.Dir(p) {
+		resPath, err := c.GetResourcePath(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(resPath); !os.IsNotExist(err) {
+			break
+		}
+		createdPath = resPath
+	}
+
+	c.AddTmpMount(source, target)
+
+	return func() {
+		if createdPath == "" {
+			return
+		}
+		if err := os.RemoveAll(createdPath); err != nil {
+			logrus.Debugf("[BUILDER] failed to remove mount point %s: %v", target, err)
+		}
+	}, nil
+}
+
+// mountSecret makes the secret m.id available read-only at m.target in the
+// container c while it runs. The secret is written to a tmpfs which is
+// bind mounted into the container, so it never touches the disk nor the
+// container's filesystem.
+func (b *Builder) mountSecret(c *daemon.Container, m *runMount) (func(), error) {
+	data, ok := b.secrets[m.id]
+	if !ok {
+		return nil, fmt.Errorf("Secret %s was not provided, use --secret id=%s,src=<file>", m.id, m.id)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "docker-build-secret")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmpDir, 0700); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+	if err := mount.Mount("tmpfs", tmpDir, "tmpfs", "mode=0700"); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("Error mounting tmpfs for secret %s: %v", m.id, err)
+	}
+
+	release := func() {
+		if err := mount.Unmount(tmpDir); err != nil {
+			logrus.Debugf("[BUILDER] failed to unmount tmpfs of secret %s: %v", m.id, err)
+		}
+		os.RemoveAll(tmpDir)
+	}
+
+	secretPath := filepath.Join(tmpDir, "secret")
+	if err := ioutil.WriteFile(secretPath, data, 0444); err != nil {
+		release()
+		return nil, err
+	}
+	removeMountPoint, err := addTmpMount(c, secretPath, m.target)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	return func() {
+		removeMountPoint()
+		release()
+	}, nil
+}
+
+// startStage finishes the current build stage, if there is one, and resets
+// the builder so that a new stage, optionally named name, can begin.
+func (b *Builder) startStage(name string) error {
+	name = strings.ToLower(name)
+	if name != "" {
+		if _, err := strconv.Atoi(name); err == nil {
+			return fmt.Errorf("Build stage name can't be a number: %s", name)
+		}
+		if _, ok := b.stageNames[name]; ok {
+			return fmt.Errorf("Duplicate name for build stage: %s", name)
+		}
+	}
+
+	if b.inStage {
+		b.stageImages = append(b.stageImages, b.image)
+		b.image = ""
+		b.baseImage = ""
+		b.noBaseImage = false
+		b.maintainer = ""
+		b.cmdSet = false
+		b.Config = &runconfig.Config{}
+	}
+	b.inStage = true
+	b.stageName = name
+	if name != "" {
+		b.stageNames[name] = len(b.stageImages)
+	}
+	return nil
+}
+
+// stageImage returns the ID of the image that COPY --from=from reads from.
+// from is the name or the index of a previous build stage, or an image name.
+func (b *Builder) stageImage(from string) (string, error) {
+	idx, ok := b.stageNames[strings.ToLower(from)]
+	if !ok {
+		n, err := strconv.Atoi(from)
+		if err != nil {
+			img, err := b.Daemon.Repositories().LookupImage(from)
+			if err != nil {
+				return "", err
+			}
+			return img.ID, nil
+		}
+		if n < 0 || n >= len(b.stageImages) {
+			return "", fmt.Errorf("Invalid build stage index: %d", n)
+		}
+		idx = n
+	}
+	if idx >= len(b.stageImages) {
+		return "", fmt.Errorf("Cannot copy from the current build stage: %s", from)
+	}
+	if b.stageImages[idx] == "" {
+		return "", fmt.Errorf("Build stage %s did not produce an image", from)
+	}
+	return b.stageImages[idx], nil
+}
+
 func ContainsWildcards(name string) bool {
 	for i := 0; i < len(name); i++ {
 		ch := name[i]
@@ -460,6 +925,7 @@ func (b *Builder) pullImage(name string) (*imagepkg.Image, error) {
 	imagePullConfig := &graph.ImagePullConfig{
 		AuthConfig: pullRegistryAuth,
 		OutStream:  ioutils.NopWriteCloser(b.OutOld),
+		Platform:   b.platform,
 	}
 
 	if err := b.Daemon.Repositories().Pull(remote, tag, imagePullConfig); err != nil {
@@ -476,6 +942,7 @@ func (b *Builder) pullImage(name string) (*imagepkg.Image, error) {
 
 func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 	b.image = img.ID
+	b.baseImage = img.ID
 
 	if img.Config != nil {
 		b.Config = img.Config
@@ -522,7 +989,8 @@ func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 
 // probeCache checks to see if image-caching is enabled (`b.UtilizeCache`)
 // and if so attempts to look up the current `b.image` and `b.Config` pair
-// in the current server `b.Daemon`. If an image is found, probeCache returns
+// in the current server `b.Daemon`, or only in the history of the images
+// given with --cache-from if there are any. If an image is found, probeCache returns
 // `(true, nil)`. If no image is found, it returns `(false, nil)`. If there
 // is any error, it returns `(false, err)`.
 func (b *Builder) probeCache() (bool, error) {
@@ -530,7 +998,15 @@ func (b *Builder) probeCache() (bool, error) {
 		return false, nil
 	}
 
-	cache, err := b.Daemon.ImageGetCached(b.image, b.Config)
+	var (
+		cache *imagepkg.Image
+		err   error
+	)
+	if len(b.cacheFrom) > 0 {
+		cache, err = b.Daemon.ImageGetCachedFrom(b.image, b.Config, b.cacheFrom)
+	} else {
+		cache, err = b.Daemon.ImageGetCached(b.image, b.Config)
+	}
 	if err != nil {
 		return false, err
 	}
@@ -561,6 +1037,8 @@ func (b *Builder) create() (*daemon.Container, error) {
 		CgroupParent: b.cgroupParent,
 		Memory:       b.memory,
 		MemorySwap:   b.memorySwap,
+		NetworkMode:  b.networkMode,
+		ExtraHosts:   b.extraHosts,
 	}
 
 	config := *b.Config
@@ -650,11 +1128,11 @@ func (b *Builder) checkPathForAddition(orig string) error {
 	return nil
 }
 
-func (b *Builder) addContext(container *daemon.Container, orig, dest string, decompress bool) error {
+func (b *Builder) addContext(container *daemon.Container, root, orig, dest string, decompress bool, uid, gid int) error {
 	var (
 		err        error
 		destExists = true
-		origPath   = path.Join(b.contextPath, orig)
+		origPath   = path.Join(root, orig)
 		destPath   string
 	)
 
@@ -685,7 +1163,7 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 	}
 
 	if fi.IsDir() {
-		return copyAsDirectory(origPath, destPath, destExists)
+		return copyAsDirectory(origPath, destPath, destExists, uid, gid)
 	}
 
 	// If we are adding a remote file (or we've been told not to decompress), do not try to untar it
@@ -700,7 +1178,7 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		}
 
 		// try to successfully untar the orig
-		if err := chrootarchive.UntarPath(origPath, tarDest); err == nil {
+		if err := untarPath(origPath, tarDest, uid, gid); err == nil {
 			return nil
 		} else if err != io.EOF {
 			logrus.Debugf("Couldn't untar %s to %s: %s", origPath, tarDest, err)
@@ -719,14 +1197,60 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		resPath = path.Join(destPath, path.Base(origPath))
 	}
 
-	return fixPermissions(origPath, resPath, 0, 0, destExists)
+	return fixPermissions(origPath, resPath, uid, gid, destExists)
 }
 
-func copyAsDirectory(source, destination string, destExisted bool) error {
+func copyAsDirectory(source, destination string, destExisted bool, uid, gid int) error {
 	if err := chrootarchive.CopyWithTar(source, destination); err != nil {
 		return err
 	}
-	return fixPermissions(source, destination, 0, 0, destExisted)
+	return fixPermissions(source, destination, uid, gid, destExisted)
+}
+
+// untarPath unpacks the archive src at dst. The files keep the owners
+// recorded in the archive, unless uid and gid aren't root.
+func untarPath(src, dst string, uid, gid int) error {
+	if uid == 0 && gid == 0 {
+		return chrootarchive.UntarPath(src, dst)
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return chrootarchive.Untar(f, dst, &archive.TarOptions{
+		ChownOpts: &archive.TarChownOptions{UID: uid, GID: gid},
+	})
+}
+
+// lookupChown resolves the user[:group] of a --chown flag to a uid and gid
+// with the /etc/passwd and /etc/group of the container. A group defaults to
+// the primary group of a user found in /etc/passwd, and to the same id for a
+// numeric user which isn't.
+func lookupChown(container *daemon.Container, chown string) (int, int, error) {
+	if chown == "" {
+		return 0, 0, nil
+	}
+	userSpec := chown
+	if !strings.Contains(chown, ":") {
+		if _, err := strconv.Atoi(chown); err == nil {
+			userSpec = chown + ":" + chown
+		}
+	}
+
+	passwdPath, err := container.GetResourcePath("/etc/passwd")
+	if err != nil {
+		return 0, 0, err
+	}
+	groupPath, err := container.GetResourcePath("/etc/group")
+	if err != nil {
+		return 0, 0, err
+	}
+	execUser, err := userpkg.GetExecUserPath(userSpec, nil, passwdPath, groupPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid --chown %s: %v", chown, err)
+	}
+	return execUser.Uid, execUser.Gid, nil
 }
 
 func fixPermissions(source, destination string, uid, gid int, destExisted bool) error {
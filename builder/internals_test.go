@@ -0,0 +1,44 @@
+package builder
+
+import (
+	"testing"
+)
+
+func TestParseRunMount(t *testing.T) {
+	valid := map[string]runMount{
+		"type=secret,id=npm":                      {mountType: "secret", id: "npm", target: "/run/secrets/npm"},
+		"type=secret,id=npm,target=/root/.npmrc":  {mountType: "secret", id: "npm", target: "/root/.npmrc"},
+		"type=secret,target=/root/.npmrc":         {mountType: "secret", id: ".npmrc", target: "/root/.npmrc"},
+		"type=secret,id=npm,dst=/etc/npmrc":       {mountType: "secret", id: "npm", target: "/etc/npmrc"},
+		"type=secret,id=npm,destination=/npmrc":   {mountType: "secret", id: "npm", target: "/npmrc"},
+		"TYPE=secret,ID=npm,TARGET=/run/npm/conf": {mountType: "secret", id: "npm", target: "/run/npm/conf"},
+		"type=ssh":                    {mountType: "ssh", id: "default", target: sshAgentTarget},
+		"type=ssh,target=/agent.sock": {mountType: "ssh", id: "default", target: "/agent.sock"},
+	}
+	for spec, expected := range valid {
+		m, err := parseRunMount(spec)
+		if err != nil {
+			t.Fatalf("%q was supposed to work: %s", spec, err)
+		}
+		if *m != expected {
+			t.Fatalf("%q: expected %+v, got %+v", spec, expected, *m)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"id=npm",
+		"type=bind,id=npm",
+		"type=secret",
+		"type=secret,id",
+		"type=secret,id=npm,target=relative/path",
+		"type=secret,id=npm,mode=0400",
+		"type=ssh,id=other",
+		"type=ssh,target=agent.sock",
+	}
+	for _, spec := range invalid {
+		if _, err := parseRunMount(spec); err == nil {
+			t.Fatalf("%q was supposed to fail", spec)
+		}
+	}
+}
@@ -6,17 +6,22 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/builder/parser"
 	"github.com/docker/docker/cliconfig"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/graph/tags"
+	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/httputils"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/platform"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/urlutil"
 	"github.com/docker/docker/registry"
@@ -53,6 +58,17 @@ type Config struct {
 	CpuSetCpus     string
 	CpuSetMems     string
 	CgroupParent   string
+	NetworkMode    string
+	ExtraHosts     []string
+	BuildArgs      map[string]string
+	Labels         map[string]string
+	Platform       *platform.Platform
+	Target         string
+	CacheFrom      []string
+	Squash         bool
+	Secrets        map[string][]byte
+	SSHSession     string
+	Output         string
 	AuthConfig     *cliconfig.AuthConfig
 	ConfigFile     *cliconfig.ConfigFile
 
@@ -85,7 +101,11 @@ func NewBuildConfig() *Config {
 	}
 }
 
-func Build(d *daemon.Daemon, buildConfig *Config) error {
+func Build(d *daemon.Daemon, buildConfig *Config) (err error) {
+	defer func(start time.Time) {
+		observeBuild(start, err)
+	}(time.Now())
+
 	var (
 		repoName string
 		tag      string
@@ -104,16 +124,21 @@ func Build(d *daemon.Daemon, buildConfig *Config) error {
 		}
 	}
 
+	networkMode, err := parseBuildNetworkMode(buildConfig.NetworkMode, buildConfig.ExtraHosts)
+	if err != nil {
+		return err
+	}
+
 	if buildConfig.RemoteURL == "" {
 		context = ioutil.NopCloser(buildConfig.Context)
 	} else if urlutil.IsGitURL(buildConfig.RemoteURL) {
-		root, err := utils.GitClone(buildConfig.RemoteURL)
+		root, contextDir, err := utils.GitClone(buildConfig.RemoteURL)
 		if err != nil {
 			return err
 		}
 		defer os.RemoveAll(root)
 
-		c, err := archive.Tar(root, archive.Uncompressed)
+		c, err := tarGitContext(contextDir, buildConfig.DockerfileName)
 		if err != nil {
 			return err
 		}
@@ -171,20 +196,115 @@ func Build(d *daemon.Daemon, buildConfig *Config) error {
 		cgroupParent:    buildConfig.CgroupParent,
 		memory:          buildConfig.Memory,
 		memorySwap:      buildConfig.MemorySwap,
+		networkMode:     networkMode,
+		extraHosts:      buildConfig.ExtraHosts,
+		buildArgs:       buildConfig.BuildArgs,
+		labels:          buildConfig.Labels,
+		platform:        buildConfig.Platform,
+		target:          buildConfig.Target,
+		cacheFrom:       buildConfig.CacheFrom,
+		squash:          buildConfig.Squash,
+		secrets:         buildConfig.Secrets,
 		cancelled:       buildConfig.WaitCancelled(),
 	}
 
+	if buildConfig.SSHSession != "" {
+		sshAgent, err := claimSSHAgent(buildConfig.SSHSession)
+		if err != nil {
+			return err
+		}
+		defer sshAgent.close()
+		builder.sshAgent = sshAgent
+	}
+
+	var output *buildOutput
+	if buildConfig.Output != "" {
+		if repoName != "" {
+			return fmt.Errorf("Conflicting options: --output and --tag")
+		}
+		if output, err = claimBuildOutput(buildConfig.Output); err != nil {
+			return err
+		}
+		defer output.close()
+	}
+
 	id, err := builder.Run(context)
 	if err != nil {
 		return err
 	}
 
+	if output != nil {
+		if err := output.export(d, id); err != nil {
+			return err
+		}
+		// the result is exported instead of kept as an image, the images of
+		// the previous steps remain as the build cache
+		if builder.committed == id {
+			if _, err := d.ImageDelete(id, false, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	if repoName != "" {
 		return d.Repositories().Tag(repoName, tag, id, true)
 	}
 	return nil
 }
 
+// parseBuildNetworkMode validates the network mode and the extra hosts of the
+// containers of a build. The default network mode is left to the daemon.
+func parseBuildNetworkMode(mode string, extraHosts []string) (runconfig.NetworkMode, error) {
+	for _, host := range extraHosts {
+		if _, err := opts.ValidateExtraHost(host); err != nil {
+			return "", err
+		}
+	}
+	if mode == "" || mode == "default" {
+		return "", nil
+	}
+
+	netMode, err := runconfig.ParseNetMode(mode)
+	if err != nil {
+		return "", fmt.Errorf("--network: invalid network mode: %v", err)
+	}
+	if (netMode.IsContainer() || netMode.IsHost()) && len(extraHosts) > 0 {
+		return "", runconfig.ErrConflictNetworkHosts
+	}
+	return netMode, nil
+}
+
+// tarGitContext archives the build context cloned from a git repository,
+// leaving out the files excluded by its .dockerignore like the client does
+// for a local context.
+func tarGitContext(root, dockerfileName string) (archive.Archive, error) {
+	if dockerfileName == "" {
+		dockerfileName = api.DefaultDockerfileName
+	}
+
+	dockerIgnore := utils.DockerIgnoreFile(root, dockerfileName)
+	excludes, err := utils.ReadDockerIgnore(filepath.Join(root, dockerIgnore))
+	if err != nil {
+		return nil, err
+	}
+
+	// The Dockerfile and .dockerignore are sent even when excluded, they
+	// are removed after the Dockerfile has been parsed.
+	includes := []string{"."}
+	keepThem1, _ := fileutils.Matches(dockerIgnore, excludes)
+	keepThem2, _ := fileutils.Matches(dockerfileName, excludes)
+	if keepThem1 || keepThem2 {
+		includes = append(includes, dockerIgnore, dockerfileName)
+	}
+
+	return archive.TarWithOptions(root, &archive.TarOptions{
+		Compression:     archive.Uncompressed,
+		ExcludePatterns: excludes,
+		IncludeFiles:    includes,
+	})
+}
+
 func BuildFromConfig(d *daemon.Daemon, c *runconfig.Config, changes []string) (*runconfig.Config, error) {
 	ast, err := parser.Parse(bytes.NewBufferString(strings.Join(changes, "\n")))
 	if err != nil {
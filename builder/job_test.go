@@ -0,0 +1,44 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/docker/docker/runconfig"
+)
+
+func TestParseBuildNetworkMode(t *testing.T) {
+	valid := map[string]runconfig.NetworkMode{
+		"":              "",
+		"default":       "",
+		"bridge":        "bridge",
+		"none":          "none",
+		"host":          "host",
+		"container:foo": "container:foo",
+	}
+	for mode, expected := range valid {
+		netMode, err := parseBuildNetworkMode(mode, nil)
+		if err != nil {
+			t.Fatalf("%q was supposed to work: %s", mode, err)
+		}
+		if netMode != expected {
+			t.Fatalf("%q: expected %q, got %q", mode, expected, netMode)
+		}
+	}
+
+	if _, err := parseBuildNetworkMode("bridge", []string{"mirror:10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string][]string{
+		"foo":        nil,
+		"container:": nil,
+		"host":       {"mirror:10.0.0.1"},
+		"none":       {"mirror"},
+		"default":    {"mirror:not-an-ip"},
+	}
+	for mode, extraHosts := range invalid {
+		if _, err := parseBuildNetworkMode(mode, extraHosts); err == nil {
+			t.Fatalf("%q with %q was not supposed to work", mode, extraHosts)
+		}
+	}
+}
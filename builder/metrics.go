@@ -0,0 +1,33 @@
+package builder
+
+import (
+	"time"
+
+	"github.com/docker/docker/pkg/metrics"
+)
+
+// buildBuckets are the buckets of the durations of the builds in seconds,
+// from 1s to 30m.
+var buildBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+var (
+	builds = metrics.NewCounter("engine_daemon_builds_total",
+		"The number of image builds, by result.", "result")
+	buildDuration = metrics.NewHistogram("engine_daemon_build_duration_seconds",
+		"The duration of the image builds in seconds.", buildBuckets)
+)
+
+func init() {
+	metrics.MustRegister(builds, buildDuration)
+}
+
+// observeBuild records a build started at start, which failed if err isn't
+// nil.
+func observeBuild(start time.Time, err error) {
+	buildDuration.ObserveSince(start)
+	if err != nil {
+		builds.Inc("failure")
+		return
+	}
+	builds.Inc("success")
+}
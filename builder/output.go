@@ -0,0 +1,102 @@
+package builder
+
+// Export of the result of a build with `docker build --output`.
+//
+// The client opens a hijacked connection to the daemon for the build, which
+// is registered here with AttachBuildOutput. Once the build has succeeded, the
+// filesystem of its final image is written on that connection as a tar
+// archive, which the client unpacks to a directory or saves to a file. The
+// final image is deleted afterwards when the build committed it.
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/pkg/archive"
+)
+
+// buildOutputTimeout is how long an output waits for the build referring to
+// it before it is dropped.
+const buildOutputTimeout = time.Minute
+
+type buildOutput struct {
+	w         io.Writer
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var buildOutputs = struct {
+	sync.Mutex
+	m map[string]*buildOutput
+}{m: make(map[string]*buildOutput)}
+
+// AttachBuildOutput registers w, a connection to a client, as the output id
+// which a build can export its result to. The returned channel is closed once
+// the output is over, when the build referring to it has finished or none did
+// within a minute.
+func AttachBuildOutput(id string, w io.Writer) (<-chan struct{}, error) {
+	buildOutputs.Lock()
+	defer buildOutputs.Unlock()
+
+	if _, exists := buildOutputs.m[id]; exists {
+		return nil, fmt.Errorf("Build output %s already exists", id)
+	}
+	o := &buildOutput{
+		w:    w,
+		done: make(chan struct{}),
+	}
+	buildOutputs.m[id] = o
+
+	time.AfterFunc(buildOutputTimeout, func() {
+		buildOutputs.Lock()
+		defer buildOutputs.Unlock()
+		if buildOutputs.m[id] == o {
+			delete(buildOutputs.m, id)
+			o.close()
+		}
+	})
+	return o.done, nil
+}
+
+// claimBuildOutput returns the output id for use by a build. An output can
+// only be used by one build.
+func claimBuildOutput(id string) (*buildOutput, error) {
+	buildOutputs.Lock()
+	defer buildOutputs.Unlock()
+
+	o, exists := buildOutputs.m[id]
+	if !exists {
+		return nil, fmt.Errorf("Build output %s not found", id)
+	}
+	delete(buildOutputs.m, id)
+	return o, nil
+}
+
+func (o *buildOutput) close() {
+	o.closeOnce.Do(func() { close(o.done) })
+}
+
+// export writes the filesystem of the image id to the output as a tar
+// archive.
+func (o *buildOutput) export(d *daemon.Daemon, id string) error {
+	driver := d.GraphDriver()
+	root, err := driver.Get(id, "")
+	if err != nil {
+		return err
+	}
+	defer driver.Put(id)
+
+	arch, err := archive.Tar(root, archive.Uncompressed)
+	if err != nil {
+		return err
+	}
+	defer arch.Close()
+
+	if _, err := io.Copy(o.w, arch); err != nil {
+		return fmt.Errorf("Error exporting the build result: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package builder
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBuildOutputSession(t *testing.T) {
+	var buf bytes.Buffer
+	done, err := AttachBuildOutput("test-output", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AttachBuildOutput("test-output", &buf); err == nil {
+		t.Fatal("Attaching an output twice was supposed to fail")
+	}
+	o, err := claimBuildOutput("test-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := claimBuildOutput("test-output"); err == nil {
+		t.Fatal("Claiming an output twice was supposed to fail")
+	}
+
+	select {
+	case <-done:
+		t.Fatal("The output was over before being closed")
+	default:
+	}
+	o.close()
+	o.close()
+	<-done
+}
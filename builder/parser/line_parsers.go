@@ -195,6 +195,38 @@ func parseLabel(rest string) (*Node, map[string]bool, error) {
 	return parseNameVal(rest, "LABEL")
 }
 
+// parses a statement containing one or more keyword definition(s) and/or
+// value assignments, like `name1 name2= name3="" name4=value`.
+// Note that this is a stricter format than the old format of assignment,
+// allowed by parseNameVal(), in a way that this only allows assignment of the
+// form `keyword=[<value>]` like  `name2=`, `name3=""`, and `name4=value` above.
+// In addition, a keyword definition alone is of the form `keyword` like `name1`
+// above. And the assignments `name2=` and `name3=""` are equivalent and
+// assign an empty value to the respective keywords.
+func parseNameOrNameVal(rest string) (*Node, map[string]bool, error) {
+	words := TOKEN_WHITESPACE.Split(strings.TrimSpace(rest), -1)
+	if len(words) == 0 || words[0] == "" {
+		return nil, nil, nil
+	}
+
+	var (
+		rootnode *Node
+		prevNode *Node
+	)
+	for i, word := range words {
+		node := &Node{}
+		node.Value = word
+		if i == 0 {
+			rootnode = node
+		} else {
+			prevNode.Next = node
+		}
+		prevNode = node
+	}
+
+	return rootnode, nil, nil
+}
+
 // parses a whitespace-delimited set of arguments. The result is effectively a
 // linked list of string arguments.
 func parseStringsWhitespaceDelimited(rest string) (*Node, map[string]bool, error) {
@@ -293,3 +325,27 @@ func parseMaybeJSONToList(rest string) (*Node, map[string]bool, error) {
 
 	return parseStringsWhitespaceDelimited(rest)
 }
+
+// parseHealthConfig parses the arguments of HEALTHCHECK, which are either NONE
+// or CMD followed by a command in the same form as for the CMD instruction.
+//
+// HEALTHCHECK CMD ["curl", "-f", "http://localhost/"] -> (healthcheck "CMD" "curl" "-f" "http://localhost/")
+//
+func parseHealthConfig(rest string) (*Node, map[string]bool, error) {
+	words := TOKEN_WHITESPACE.Split(rest, 2)
+	if len(words) == 0 || words[0] == "" {
+		return nil, nil, nil
+	}
+
+	node := &Node{Value: words[0]}
+	if len(words) == 1 {
+		return node, nil, nil
+	}
+
+	cmd, attrs, err := parseMaybeJSON(words[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	node.Next = cmd
+	return node, attrs, nil
+}
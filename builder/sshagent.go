@@ -0,0 +1,211 @@
+package builder
+
+// Forwarding of the client's SSH agent to `RUN --mount=type=ssh`.
+//
+// The client opens a hijacked connection to the daemon for the build, which
+// it pipes to its SSH agent, and registers it here with AttachSSHAgent. Each
+// RUN that mounts the agent gets a unix socket of its own, and the requests
+// made on it are forwarded one at a time over that single connection. This
+// works because the agent protocol is a strict sequence of length-prefixed
+// requests and responses.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/daemon"
+)
+
+const (
+	// sshAgentTarget is where the SSH agent is mounted in the container
+	// unless the --mount flag says otherwise.
+	sshAgentTarget = "/run/ssh_agent.sock"
+
+	// maxAgentMessageLen bounds the messages forwarded to the agent.
+	maxAgentMessageLen = 256 * 1024
+
+	// sshAgentSessionTimeout is how long a session waits for the build
+	// referring to it before it is dropped.
+	sshAgentSessionTimeout = time.Minute
+)
+
+type sshAgentSession struct {
+	mu        sync.Mutex // serializes the requests made to the agent
+	conn      io.ReadWriter
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var sshAgentSessions = struct {
+	sync.Mutex
+	m map[string]*sshAgentSession
+}{m: make(map[string]*sshAgentSession)}
+
+// AttachSSHAgent registers conn, a connection to the SSH agent of a client,
+// as the session id which a build can forward to its RUN instructions. The
+// returned channel is closed once the session is over, when the build
+// referring to it has finished or none did within a minute.
+func AttachSSHAgent(id string, conn io.ReadWriter) (<-chan struct{}, error) {
+	sshAgentSessions.Lock()
+	defer sshAgentSessions.Unlock()
+
+	if _, exists := sshAgentSessions.m[id]; exists {
+		return nil, fmt.Errorf("SSH agent session %s already exists", id)
+	}
+	s := &sshAgentSession{
+		conn: conn,
+		done: make(chan struct{}),
+	}
+	sshAgentSessions.m[id] = s
+
+	time.AfterFunc(sshAgentSessionTimeout, func() {
+		sshAgentSessions.Lock()
+		defer sshAgentSessions.Unlock()
+		if sshAgentSessions.m[id] == s {
+			delete(sshAgentSessions.m, id)
+			s.close()
+		}
+	})
+	return s.done, nil
+}
+
+// claimSSHAgent returns the session id for use by a build. A session can
+// only be used by one build.
+func claimSSHAgent(id string) (*sshAgentSession, error) {
+	sshAgentSessions.Lock()
+	defer sshAgentSessions.Unlock()
+
+	s, exists := sshAgentSessions.m[id]
+	if !exists {
+		return nil, fmt.Errorf("SSH agent session %s not found", id)
+	}
+	delete(sshAgentSessions.m, id)
+	return s, nil
+}
+
+func (s *sshAgentSession) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// roundTrip forwards a request to the agent and returns its response.
+func (s *sshAgentSession) roundTrip(req []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := writeAgentMessage(s.conn, req); err != nil {
+		s.close()
+		return nil, err
+	}
+	resp, err := readAgentMessage(s.conn)
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// serve forwards the requests made on conn, a connection from a container,
+// until it is closed.
+func (s *sshAgentSession) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		req, err := readAgentMessage(conn)
+		if err != nil {
+			return
+		}
+		resp, err := s.roundTrip(req)
+		if err != nil {
+			return
+		}
+		if err := writeAgentMessage(conn, resp); err != nil {
+			return
+		}
+	}
+}
+
+// readAgentMessage reads a message of the agent protocol, which is prefixed
+// with its length as a 4 bytes big endian integer.
+func readAgentMessage(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxAgentMessageLen {
+		return nil, fmt.Errorf("SSH agent message too long: %d bytes", n)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeAgentMessage(w io.Writer, msg []byte) error {
+	buf := make([]byte, 4+len(msg))
+	binary.BigEndian.PutUint32(buf, uint32(len(msg)))
+	copy(buf[4:], msg)
+	_, err := w.Write(buf)
+	return err
+}
+
+// mountSSHAgent makes the SSH agent forwarded to the build available on a
+// unix socket at m.target in the container c while it runs.
+func (b *Builder) mountSSHAgent(c *daemon.Container, m *runMount) (func(), error) {
+	if b.sshAgent == nil {
+		return nil, fmt.Errorf("RUN --mount=type=ssh requires the SSH agent to be forwarded with docker build --ssh")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "docker-build-ssh")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmpDir, 0700); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+
+	socketPath := filepath.Join(tmpDir, "agent.sock")
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+	// the directory keeps other users of the host out, the socket has to
+	// be usable by whatever user the RUN is executed as.
+	if err := os.Chmod(socketPath, 0666); err != nil {
+		l.Close()
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.sshAgent.serve(conn)
+		}
+	}()
+
+	removeMountPoint, err := addTmpMount(c, socketPath, m.target)
+	if err != nil {
+		l.Close()
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+
+	return func() {
+		l.Close()
+		removeMountPoint()
+		os.RemoveAll(tmpDir)
+	}, nil
+}
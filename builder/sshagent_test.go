@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestSSHAgentSession(t *testing.T) {
+	// a fake agent answering every request with its reverse
+	agentConn, clientConn := net.Pipe()
+	defer agentConn.Close()
+	defer clientConn.Close()
+	go func() {
+		for {
+			req, err := readAgentMessage(agentConn)
+			if err != nil {
+				return
+			}
+			resp := make([]byte, len(req))
+			for i := range req {
+				resp[len(req)-1-i] = req[i]
+			}
+			if err := writeAgentMessage(agentConn, resp); err != nil {
+				return
+			}
+		}
+	}()
+
+	if _, err := AttachSSHAgent("test-session", clientConn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AttachSSHAgent("test-session", clientConn); err == nil {
+		t.Fatal("Attaching a session twice was supposed to fail")
+	}
+	s, err := claimSSHAgent("test-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+	if _, err := claimSSHAgent("test-session"); err == nil {
+		t.Fatal("Claiming a session twice was supposed to fail")
+	}
+
+	containerConn, serverConn := net.Pipe()
+	defer containerConn.Close()
+	go s.serve(serverConn)
+
+	for _, req := range [][]byte{[]byte("abc"), []byte("hello")} {
+		if err := writeAgentMessage(containerConn, req); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := readAgentMessage(containerConn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := make([]byte, len(req))
+		for i := range req {
+			expected[len(req)-1-i] = req[i]
+		}
+		if !bytes.Equal(resp, expected) {
+			t.Fatalf("Expected %q, got %q", expected, resp)
+		}
+	}
+}
+
+func TestReadAgentMessageTooLong(t *testing.T) {
+	buf := []byte{0xff, 0xff, 0xff, 0xff}
+	if _, err := readAgentMessage(bytes.NewReader(buf)); err == nil {
+		t.Fatal("Reading a message longer than maxAgentMessageLen was supposed to fail")
+	}
+}
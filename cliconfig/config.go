@@ -41,7 +41,30 @@ type AuthConfig struct {
 type ConfigFile struct {
 	AuthConfigs map[string]AuthConfig `json:"auths"`
 	HttpHeaders map[string]string     `json:"HttpHeaders,omitempty"`
-	filename    string                // Note: not serialized - for internal use only
+	// CredentialsStore is the credential helper keeping the credentials of
+	// all the registries, and CredentialHelpers those of given registries
+	CredentialsStore  string            `json:"credsStore,omitempty"`
+	CredentialHelpers map[string]string `json:"credHelpers,omitempty"`
+	// Contexts are the daemons the client connects to by name, and
+	// CurrentContext the one used when no other daemon is given
+	Contexts       map[string]Context `json:"contexts,omitempty"`
+	CurrentContext string             `json:"currentContext,omitempty"`
+	filename       string             // Note: not serialized - for internal use only
+}
+
+// DefaultContext is the name of the context of the default daemon, given by
+// DOCKER_HOST or the local socket.
+const DefaultContext = "default"
+
+// Context is a daemon the client connects to, and its TLS configuration.
+type Context struct {
+	Description string `json:"description,omitempty"`
+	Host        string `json:"host"`
+	TLS         bool   `json:"tls,omitempty"`
+	TLSVerify   bool   `json:"tlsverify,omitempty"`
+	TLSCACert   string `json:"tlscacert,omitempty"`
+	TLSCert     string `json:"tlscert,omitempty"`
+	TLSKey      string `json:"tlskey,omitempty"`
 }
 
 func NewConfigFile(fn string) *ConfigFile {
@@ -77,9 +100,12 @@ func Load(configDir string) (*ConfigFile, error) {
 		}
 
 		for addr, ac := range configFile.AuthConfigs {
-			ac.Username, ac.Password, err = DecodeAuth(ac.Auth)
-			if err != nil {
-				return &configFile, err
+			// the credentials kept by a credential helper aren't in the file
+			if ac.Auth != "" {
+				ac.Username, ac.Password, err = DecodeAuth(ac.Auth)
+				if err != nil {
+					return &configFile, err
+				}
 			}
 			ac.Auth = ""
 			ac.ServerAddress = addr
@@ -146,7 +172,12 @@ func (configFile *ConfigFile) Save() error {
 	for k, authConfig := range configFile.AuthConfigs {
 		authCopy := authConfig
 
-		authCopy.Auth = EncodeAuth(&authCopy)
+		// the credentials kept by a credential helper aren't saved
+		if configFile.CredentialHelper(k) == "" {
+			authCopy.Auth = EncodeAuth(&authCopy)
+		} else {
+			authCopy.Auth = ""
+		}
 		authCopy.Username = ""
 		authCopy.Password = ""
 		authCopy.ServerAddress = ""
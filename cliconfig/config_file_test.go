@@ -111,7 +111,7 @@ func TestOldJson(t *testing.T) {
 
 	ac := config.AuthConfigs["https://index.docker.io/v1/"]
 	if ac.Email != "user@example.com" || ac.Username != "joejoe" || ac.Password != "hello" {
-		t.Fatalf("Missing data from parsing:\n%q", config)
+		t.Fatalf("Missing data from parsing:\n%+v", ac)
 	}
 
 	// Now save it and make sure it shows up in new form
@@ -140,7 +140,7 @@ func TestNewJson(t *testing.T) {
 
 	ac := config.AuthConfigs["https://index.docker.io/v1/"]
 	if ac.Email != "user@example.com" || ac.Username != "joejoe" || ac.Password != "hello" {
-		t.Fatalf("Missing data from parsing:\n%q", config)
+		t.Fatalf("Missing data from parsing:\n%+v", ac)
 	}
 
 	// Now save it and make sure it shows up in new form
@@ -155,3 +155,31 @@ func TestNewJson(t *testing.T) {
 		t.Fatalf("Should have save in new form: %s", string(buf))
 	}
 }
+
+func TestContexts(t *testing.T) {
+	tmpHome, err := ioutil.TempDir("", "config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpHome)
+
+	config, err := Load(tmpHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Contexts = map[string]Context{
+		"prod": {Host: "tcp://10.0.0.1:2376", TLSVerify: true, TLSCACert: "/certs/ca.pem"},
+	}
+	config.CurrentContext = "prod"
+	if err := config.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = Load(tmpHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx := config.Contexts["prod"]; config.CurrentContext != "prod" || ctx.Host != "tcp://10.0.0.1:2376" || !ctx.TLSVerify || ctx.TLSCACert != "/certs/ca.pem" {
+		t.Fatalf("Expected the context to be saved, got %+v, current %s", config.Contexts, config.CurrentContext)
+	}
+}
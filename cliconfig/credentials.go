@@ -0,0 +1,157 @@
+package cliconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The credentials of a registry can be kept by a credential helper instead
+// of the config file: the helper named "osxkeychain" is the binary
+// docker-credential-osxkeychain in the PATH. It's given an action as its
+// argument, and its input on stdin:
+//
+//   - get: the server address, it outputs the credentials as
+//     {"Username": "...", "Secret": "..."};
+//   - store: the credentials as {"ServerURL": "...", "Username": "...",
+//     "Secret": "..."};
+//   - erase: the server address.
+//
+// A helper outputs "credentials not found in native keychain" when it has no
+// credentials for a server.
+const (
+	credentialHelperPrefix = "docker-credential-"
+	credentialsNotFound    = "credentials not found in native keychain"
+)
+
+var errCredentialsNotFound = errors.New(credentialsNotFound)
+
+// helperCredentials are the credentials exchanged with a credential helper.
+type helperCredentials struct {
+	ServerURL string `json:",omitempty"`
+	Username  string
+	Secret    string
+}
+
+// CredentialHelper returns the name of the credential helper keeping the
+// credentials of the registry at serverAddress, or "" when they are in the
+// config file. A helper set for the registry in credHelpers takes
+// precedence over the credsStore of all the registries.
+func (configFile *ConfigFile) CredentialHelper(serverAddress string) string {
+	if helper, ok := configFile.CredentialHelpers[serverAddress]; ok {
+		return helper
+	}
+	if helper, ok := configFile.CredentialHelpers[registryHostname(serverAddress)]; ok {
+		return helper
+	}
+	return configFile.CredentialsStore
+}
+
+// GetAuthConfig returns the credentials of the registry at serverAddress,
+// from its credential helper or from the config file. The credentials are
+// empty when there are none.
+func (configFile *ConfigFile) GetAuthConfig(serverAddress string) (AuthConfig, error) {
+	authConfig := configFile.AuthConfigs[serverAddress]
+	helper := configFile.CredentialHelper(serverAddress)
+	if helper == "" {
+		return authConfig, nil
+	}
+
+	out, err := runCredentialHelper(helper, "get", []byte(serverAddress))
+	if err == errCredentialsNotFound {
+		return AuthConfig{}, nil
+	}
+	if err != nil {
+		return AuthConfig{}, err
+	}
+	var creds helperCredentials
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return AuthConfig{}, fmt.Errorf("invalid credentials from %s%s: %v", credentialHelperPrefix, helper, err)
+	}
+	authConfig.Username = creds.Username
+	authConfig.Password = creds.Secret
+	authConfig.ServerAddress = serverAddress
+	return authConfig, nil
+}
+
+// GetAllAuthConfigs returns the credentials of all the registries the user
+// is logged in to, from their credential helpers or from the config file.
+func (configFile *ConfigFile) GetAllAuthConfigs() (map[string]AuthConfig, error) {
+	authConfigs := make(map[string]AuthConfig, len(configFile.AuthConfigs))
+	for serverAddress := range configFile.AuthConfigs {
+		authConfig, err := configFile.GetAuthConfig(serverAddress)
+		if err != nil {
+			return nil, err
+		}
+		authConfigs[serverAddress] = authConfig
+	}
+	return authConfigs, nil
+}
+
+// StoreAuthConfig keeps the credentials of the registry of authConfig, in
+// its credential helper when it has one, the config file recording only
+// that the user is logged in. The config file has to be saved afterwards.
+func (configFile *ConfigFile) StoreAuthConfig(authConfig AuthConfig) error {
+	if helper := configFile.CredentialHelper(authConfig.ServerAddress); helper != "" {
+		creds, err := json.Marshal(helperCredentials{
+			ServerURL: authConfig.ServerAddress,
+			Username:  authConfig.Username,
+			Secret:    authConfig.Password,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := runCredentialHelper(helper, "store", creds); err != nil {
+			return err
+		}
+	}
+	configFile.AuthConfigs[authConfig.ServerAddress] = authConfig
+	return nil
+}
+
+// RemoveAuthConfig removes the credentials of the registry at
+// serverAddress, from its credential helper too. The config file has to be
+// saved afterwards.
+func (configFile *ConfigFile) RemoveAuthConfig(serverAddress string) error {
+	delete(configFile.AuthConfigs, serverAddress)
+	if helper := configFile.CredentialHelper(serverAddress); helper != "" {
+		if _, err := runCredentialHelper(helper, "erase", []byte(serverAddress)); err != nil && err != errCredentialsNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCredentialHelper runs the action of the credential helper with the
+// input, and returns its output.
+func runCredentialHelper(helper, action string, input []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(credentialHelperPrefix+helper, action)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stdout.String())
+		if msg == credentialsNotFound {
+			return nil, errCredentialsNotFound
+		}
+		if msg == "" {
+			msg = strings.TrimSpace(stderr.String())
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("error running %s%s %s: %s", credentialHelperPrefix, helper, action, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// registryHostname returns the hostname of the address of a registry,
+// which may be a URL.
+func registryHostname(serverAddress string) string {
+	hostname := strings.TrimPrefix(strings.TrimPrefix(serverAddress, "http://"), "https://")
+	return strings.SplitN(hostname, "/", 2)[0]
+}
@@ -0,0 +1,164 @@
+package cliconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// testCredentialHelper keeps the credentials in files next to it.
+const testCredentialHelper = `#!/bin/sh
+dir="$(dirname "$0")/store"
+mkdir -p "$dir"
+key() {
+	echo "$1" | tr -c 'a-zA-Z0-9\n' _
+}
+case "$1" in
+store)
+	input=$(cat)
+	url=$(echo "$input" | sed 's/.*"ServerURL":"\([^"]*\)".*/\1/')
+	echo "$input" > "$dir/$(key "$url")"
+	;;
+get)
+	f="$dir/$(key "$(cat)")"
+	if [ ! -f "$f" ]; then
+		echo "credentials not found in native keychain"
+		exit 1
+	fi
+	cat "$f"
+	;;
+erase)
+	rm -f "$dir/$(key "$(cat)")"
+	;;
+*)
+	echo "unknown action $1" >&2
+	exit 1
+	;;
+esac
+`
+
+func setupCredentialHelper(t *testing.T) (string, func()) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test credential helper is a shell script")
+	}
+	tmpDir, err := ioutil.TempDir("", "config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, credentialHelperPrefix+"test"), []byte(testCredentialHelper), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", tmpDir+string(os.PathListSeparator)+path)
+	return tmpDir, func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(tmpDir)
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	config := NewConfigFile("")
+	if helper := config.CredentialHelper("registry.example.com"); helper != "" {
+		t.Fatalf("Expected no credential helper, got %q", helper)
+	}
+
+	config.CredentialsStore = "store"
+	config.CredentialHelpers = map[string]string{"registry.example.com": "helper"}
+	for serverAddress, expected := range map[string]string{
+		"registry.example.com":                "helper",
+		"https://registry.example.com/v1/":    "helper",
+		"registry.example.com:5000":           "store",
+		"https://index.docker.io/v1/":         "store",
+		"http://registry.example.com/v2/repo": "helper",
+	} {
+		if helper := config.CredentialHelper(serverAddress); helper != expected {
+			t.Fatalf("Expected the credential helper %q for %s, got %q", expected, serverAddress, helper)
+		}
+	}
+}
+
+func TestStoreAuthConfigWithCredentialHelper(t *testing.T) {
+	tmpHome, cleanup := setupCredentialHelper(t)
+	defer cleanup()
+
+	config, err := Load(tmpHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.CredentialHelpers = map[string]string{"registry.example.com": "test"}
+
+	if err := config.StoreAuthConfig(AuthConfig{
+		Username:      "joejoe",
+		Password:      "hello",
+		Email:         "user@example.com",
+		ServerAddress: "registry.example.com",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.StoreAuthConfig(AuthConfig{
+		Username:      "joe",
+		Password:      "world",
+		Email:         "other@example.com",
+		ServerAddress: "other.example.com",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the credentials without a helper are in the file
+	buf, err := ioutil.ReadFile(filepath.Join(tmpHome, CONFIGFILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), EncodeAuth(&AuthConfig{Username: "joejoe", Password: "hello"})) {
+		t.Fatalf("Expected no credentials of the helper in the file: %s", buf)
+	}
+	if !strings.Contains(string(buf), EncodeAuth(&AuthConfig{Username: "joe", Password: "world"})) || !strings.Contains(string(buf), `"credHelpers"`) {
+		t.Fatalf("Expected the credentials without a helper and the helpers in the file: %s", buf)
+	}
+
+	config, err = Load(tmpHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac, err := config.GetAuthConfig("registry.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ac.Username != "joejoe" || ac.Password != "hello" || ac.Email != "user@example.com" || ac.ServerAddress != "registry.example.com" {
+		t.Fatalf("Unexpected credentials from the helper: %+v", ac)
+	}
+	all, err := config.GetAllAuthConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all["registry.example.com"].Password != "hello" || all["other.example.com"].Password != "world" {
+		t.Fatalf("Unexpected credentials of all the registries: %+v", all)
+	}
+
+	if err := config.RemoveAuthConfig("registry.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if ac, err := config.GetAuthConfig("registry.example.com"); err != nil || ac.Username != "" {
+		t.Fatalf("Expected no credentials once removed, got %+v, %v", ac, err)
+	}
+}
+
+func TestCredentialHelperErrors(t *testing.T) {
+	config := NewConfigFile("")
+	config.CredentialsStore = "doesnotexist"
+	if _, err := config.GetAuthConfig("registry.example.com"); err == nil || !strings.Contains(err.Error(), "docker-credential-doesnotexist get") {
+		t.Fatalf("Expected an error running a missing helper, got %v", err)
+	}
+
+	_, cleanup := setupCredentialHelper(t)
+	defer cleanup()
+	if _, err := runCredentialHelper("test", "list", nil); err == nil || !strings.Contains(err.Error(), "unknown action list") {
+		t.Fatalf("Expected the error output of the helper, got %v", err)
+	}
+}
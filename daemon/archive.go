@@ -0,0 +1,218 @@
+package daemon
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/ioutils"
+)
+
+// ErrExtractPointNotDirectory is returned when the path where an archive is
+// extracted in a container is not a directory.
+var ErrExtractPointNotDirectory = errors.New("extraction point is not a directory")
+
+// ContainerStatPath returns the stat of the path in the container.
+func (daemon *Daemon) ContainerStatPath(name, path string) (*types.ContainerPathStat, error) {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return container.StatPath(path)
+}
+
+// ContainerArchivePath returns an archive of the resource at the path in the
+// container, with its stat.
+func (daemon *Daemon) ContainerArchivePath(name, path string) (io.ReadCloser, *types.ContainerPathStat, error) {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return container.ArchivePath(path)
+}
+
+// ContainerExtractToDir extracts the archive in the directory at the path in
+// the container.
+func (daemon *Daemon) ContainerExtractToDir(name, path string, noOverwriteDirNonDir, copyUIDGID bool, content io.Reader) error {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return err
+	}
+	return container.ExtractToDir(path, noOverwriteDirNonDir, copyUIDGID, content)
+}
+
+// mountForArchive mounts the root filesystem and the volumes of the
+// container to access the paths in it.
+func (container *Container) mountForArchive() error {
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	if err := container.mountVolumes(); err != nil {
+		container.unmountVolumes()
+		container.Unmount()
+		return err
+	}
+	return nil
+}
+
+func (container *Container) unmountForArchive() {
+	container.unmountVolumes()
+	container.Unmount()
+}
+
+// resolvePath returns the path on the host of the path in the container,
+// following its symlinks in the scope of the container, with the trailing
+// "/." or path separator of the path. The absolute path in the container is
+// returned too.
+func (container *Container) resolvePath(path string) (resolvedPath, absPath string, err error) {
+	absPath = archive.PreserveTrailingDotOrSeparator(filepath.Join("/", path), path)
+	resolvedPath, err = container.GetResourcePath(absPath)
+	if err != nil {
+		return "", "", err
+	}
+	return archive.PreserveTrailingDotOrSeparator(resolvedPath, absPath), absPath, nil
+}
+
+// statPath returns the stat of the resolved path, named after the path in
+// the container, with the target of its symlinks in the container.
+func (container *Container) statPath(resolvedPath, absPath string) (*types.ContainerPathStat, error) {
+	fi, err := os.Lstat(filepath.Clean(resolvedPath))
+	if err != nil {
+		return nil, err
+	}
+	if archive.HasTrailingPathSeparator(absPath) && !fi.IsDir() {
+		return nil, archive.ErrNotDirectory
+	}
+
+	var linkTarget string
+	if cleanResolved := filepath.Clean(resolvedPath); cleanResolved != filepath.Join(container.basefs, absPath) {
+		linkTarget = "/" + strings.TrimPrefix(strings.TrimPrefix(cleanResolved, container.basefs), "/")
+	}
+
+	return &types.ContainerPathStat{
+		Name:       filepath.Base(absPath),
+		Size:       fi.Size(),
+		Mode:       fi.Mode(),
+		Mtime:      fi.ModTime(),
+		LinkTarget: linkTarget,
+	}, nil
+}
+
+// StatPath returns the stat of the path in the container.
+func (container *Container) StatPath(path string) (*types.ContainerPathStat, error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if err := container.mountForArchive(); err != nil {
+		return nil, err
+	}
+	defer container.unmountForArchive()
+
+	resolvedPath, absPath, err := container.resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return container.statPath(resolvedPath, absPath)
+}
+
+// ArchivePath returns an archive of the resource at the path in the
+// container, its entries named after the path, with its stat. The symlinks of
+// the path are followed in the scope of the container.
+func (container *Container) ArchivePath(path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if err := container.mountForArchive(); err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			container.unmountForArchive()
+		}
+	}()
+
+	resolvedPath, absPath, err := container.resolvePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stat, err = container.statPath(resolvedPath, absPath); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := archive.TarResource(resolvedPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	tarball := data
+	if _, base := archive.SplitPathDirEntry(resolvedPath); base != "." && base != stat.Name {
+		// the path is a symlink, its target is named after it
+		tarball = archive.RebaseArchiveEntries(data, base, stat.Name)
+	}
+
+	content = ioutils.NewReadCloserWrapper(tarball, func() error {
+		err := tarball.Close()
+		data.Close()
+		container.unmountForArchive()
+		return err
+	})
+	return content, stat, nil
+}
+
+// ExtractToDir extracts the archive in the directory at the path in the
+// container, whose symlinks are followed in the scope of the container. The
+// files are owned by root, unless copyUIDGID keeps the owners of the
+// archive.
+func (container *Container) ExtractToDir(path string, noOverwriteDirNonDir, copyUIDGID bool, content io.Reader) error {
+	container.Lock()
+	defer container.Unlock()
+
+	if err := container.mountForArchive(); err != nil {
+		return err
+	}
+	defer container.unmountForArchive()
+
+	resolvedPath, absPath, err := container.resolvePath(path)
+	if err != nil {
+		return err
+	}
+	stat, err := container.statPath(resolvedPath, absPath)
+	if err != nil {
+		return err
+	}
+	if !stat.Mode.IsDir() {
+		return ErrExtractPointNotDirectory
+	}
+
+	containerPath := "/" + strings.TrimPrefix(strings.TrimPrefix(filepath.Clean(resolvedPath), container.basefs), "/")
+	if container.isPathReadOnly(containerPath) {
+		return errors.New("the path is on a read-only file system")
+	}
+
+	options := &archive.TarOptions{
+		NoOverwriteDirNonDir: noOverwriteDirNonDir,
+	}
+	if !copyUIDGID {
+		options.ChownOpts = &archive.TarChownOptions{UID: 0, GID: 0}
+	}
+	return chrootarchive.Untar(content, filepath.Clean(resolvedPath), options)
+}
+
+// isPathReadOnly returns whether the path in the container is on a read-only
+// volume, or on the root filesystem when it's read-only.
+func (container *Container) isPathReadOnly(path string) bool {
+	var volume string
+	for dest := range container.Volumes {
+		if (path == dest || strings.HasPrefix(path, strings.TrimSuffix(dest, "/")+"/")) && len(dest) > len(volume) {
+			volume = dest
+		}
+	}
+	if volume != "" {
+		return !container.VolumesRW[volume]
+	}
+	return container.hostConfig.ReadonlyRootfs
+}
@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"fmt"
+
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/runconfig"
 )
@@ -18,9 +20,14 @@ type ContainerCommitConfig struct {
 // Commit creates a new filesystem image from the current state of a container.
 // The image can optionally be tagged into a repository
 func (daemon *Daemon) Commit(container *Container, repository, tag, comment, author string, pause bool, config *runconfig.Config) (*image.Image, error) {
-	if pause && !container.IsPaused() {
-		container.Pause()
-		defer container.Unpause()
+	if pause {
+		paused, err := container.pauseForCommit()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot pause container %s to commit it: %v", container.ID, err)
+		}
+		if paused {
+			defer container.unpauseForCommit()
+		}
 	}
 
 	if err := container.Mount(); err != nil {
@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"time"
+
 	"github.com/docker/docker/daemon/networkdriver"
 	"github.com/docker/docker/daemon/networkdriver/bridge"
 	"github.com/docker/docker/opts"
@@ -13,26 +15,55 @@ const (
 	disableNetworkBridge = "none"
 )
 
+// DefaultRuntime is the runtime of the containers run by the native driver,
+// rather than by an OCI runtime.
+const DefaultRuntime = "native"
+
 // CommonConfig defines the configuration of a docker daemon which are
 // common across platforms.
 type CommonConfig struct {
-	AutoRestart    bool
-	Bridge         bridge.Config
-	Context        map[string][]string
-	CorsHeaders    string
-	DisableNetwork bool
-	Dns            []string
-	DnsSearch      []string
-	EnableCors     bool
-	ExecDriver     string
-	ExecRoot       string
-	GraphDriver    string
-	Labels         []string
-	LogConfig      runconfig.LogConfig
-	Mtu            int
-	Pidfile        string
-	Root           string
-	TrustKeyPath   string
+	AuditLog             string   // syslog or the file recording the requests changing the state of the daemon
+	AuthZPlugins         []string // the authorization plugins of the remote API
+	AutoRestart          bool
+	Bridge               bridge.Config
+	Context              map[string][]string
+	CorsHeaders          string
+	DebugAddress         string   // the local address exposing the profiling of the daemon
+	DefaultCapAdd        []string // the capabilities added to and dropped from the default set of the containers
+	DefaultCapDrop       []string
+	DisableNetwork       bool
+	Dns                  []string
+	DnsSearch            []string
+	EnableCors           bool
+	ExecDriver           string
+	ExecRoot             string
+	Experimental         bool     // all the experimental features are enabled
+	ExperimentalFeatures []string // the experimental features enabled one by one
+	GpuHook              string   // the command listing the driver files of the GPUs mounted in the containers
+	GraphDriver          string
+	ImagePolicyCacheTTL  time.Duration // how long the decisions of the image policy plugins are cached
+	ImagePolicyPlugins   []string      // the plugins checking the images of the containers created
+	Labels               []string
+	LiveRestore          bool // the containers are kept running while the daemon is down
+	LogConfig            runconfig.LogConfig
+	MaxConcurrentBuilds  int // the limits of the concurrent requests of the remote API, if set
+	MaxConcurrentCreates int
+	MaxConcurrentPulls   int
+	MaxDownloadBandwidth string // the bandwidths of the pulls and the pushes of all the layers, in bytes per second
+	MaxUploadBandwidth   string
+	MetricsAddress       string // the address exposing the metrics of the daemon
+	Mtu                  int
+	Pidfile              string
+	PluginTrustDir       string        // the public keys of the trusted publishers of plugins
+	RestartMaxDelay      time.Duration // the maximum delay between the restarts of a container
+	Root                 string
+	RuntimeArgs          []string          // the arguments of the OCI runtimes, as name=arg
+	RuntimeEnv           []string          // the environment of the OCI runtimes, as name=KEY=value
+	Runtimes             map[string]string // the paths of the OCI runtimes of the containers by name
+	ShutdownTimeout      int               // the seconds the containers are given to stop on shutdown
+	StorageBudget        string            // the size of the layers above which the unreferenced ones are collected
+	TlsCrl               string            // the revoked client certificates of the remote API
+	TrustKeyPath         string
 }
 
 // InstallCommonFlags adds command-line options to the top-level flag parser for
@@ -69,6 +100,31 @@ func (config *Config) InstallCommonFlags() {
 	flag.StringVar(&config.LogConfig.Type, []string{"-log-driver"}, "json-file", "Default driver for container logs")
 	opts.LogOptsVar(config.LogConfig.Config, []string{"-log-opt"}, "Set log driver options")
 	flag.BoolVar(&config.Bridge.EnableUserlandProxy, []string{"-userland-proxy"}, true, "Use userland proxy for loopback traffic")
+	opts.ListVar(&config.AuthZPlugins, []string{"-authorization-plugin"}, "List authorization plugins in order from first evaluator")
+	flag.StringVar(&config.AuditLog, []string{"-audit-log"}, "", "Record the requests changing the state of the daemon to syslog or to a file")
+	flag.IntVar(&config.ShutdownTimeout, []string{"-shutdown-timeout"}, 10, "Set the seconds the containers are given to stop when the daemon shuts down")
+	flag.DurationVar(&config.RestartMaxDelay, []string{"-restart-max-delay"}, time.Minute, "Set the maximum delay between the restarts of a container")
+	flag.StringVar(&config.MetricsAddress, []string{"-metrics-addr"}, "", "Set the address exposing the metrics of the daemon")
+	flag.StringVar(&config.DebugAddress, []string{"-debug-addr"}, "", "Set the local address exposing the profiling and the goroutine stacks of the daemon")
+	flag.StringVar(&config.TlsCrl, []string{"-tlscrl"}, "", "Path to the revocation list of the client certificates")
+	flag.BoolVar(&config.LiveRestore, []string{"-live-restore"}, false, "Keep the containers running while the daemon is down")
+	flag.IntVar(&config.MaxConcurrentBuilds, []string{"-max-concurrent-builds"}, 0, "Limit the concurrent builds of the remote API")
+	flag.IntVar(&config.MaxConcurrentPulls, []string{"-max-concurrent-pulls"}, 0, "Limit the concurrent pulls and imports of images of the remote API")
+	flag.IntVar(&config.MaxConcurrentCreates, []string{"-max-concurrent-creates"}, 0, "Limit the concurrent creations of containers of the remote API")
+	flag.StringVar(&config.MaxDownloadBandwidth, []string{"-max-download-bandwidth"}, "", "Limit the bandwidth of all the layers pulled, in bytes per second")
+	flag.StringVar(&config.MaxUploadBandwidth, []string{"-max-upload-bandwidth"}, "", "Limit the bandwidth of all the layers pushed, in bytes per second")
+	opts.ListVar(&config.DefaultCapAdd, []string{"-default-cap-add"}, "Add Linux capabilities to the default set of the containers")
+	opts.ListVar(&config.ImagePolicyPlugins, []string{"-image-policy-plugin"}, "List image policy plugins checking the images of the containers created")
+	flag.DurationVar(&config.ImagePolicyCacheTTL, []string{"-image-policy-cache-ttl"}, 10*time.Minute, "Set how long the decisions of the image policy plugins are cached")
+	flag.BoolVar(&config.Experimental, []string{"-experimental"}, false, "Enable all the experimental features of the daemon")
+	opts.ListVar(&config.ExperimentalFeatures, []string{"-experimental-feature"}, "Enable an experimental feature of the daemon")
+	flag.StringVar(&config.StorageBudget, []string{"-storage-budget"}, "", "Collect the unreferenced layers while the layers take more than this size")
+	flag.StringVar(&config.PluginTrustDir, []string{"-plugin-trust-dir"}, "/etc/docker/plugin-trust", "Directory of the public keys of the trusted plugin publishers")
+	opts.ListVar(&config.DefaultCapDrop, []string{"-default-cap-drop"}, "Drop Linux capabilities from the default set of the containers")
+	opts.RuntimesVar(config.Runtimes, []string{"-add-runtime"}, "Register an OCI runtime for the containers, as name=path")
+	opts.RuntimeArgsVar(&config.RuntimeArgs, []string{"-runtime-arg"}, "Add an argument to the command line of an OCI runtime, as name=arg")
+	opts.RuntimeEnvVar(&config.RuntimeEnv, []string{"-runtime-env"}, "Set an environment variable of an OCI runtime, as name=KEY=value")
+	flag.StringVar(&config.GpuHook, []string{"-gpu-hook"}, "", "Path of the hook listing the driver files mounted in the containers with GPUs")
 
 }
 
@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// DefaultConfigFile is the configuration file of the daemon, read if it
+// exists.
+const DefaultConfigFile = "/etc/docker/daemon.json"
+
+// MergeConfigFile sets the options of the daemon from the JSON configuration
+// file at path, whose keys are the long names of the flags:
+//
+//	{
+//		"label": ["rack=r12", "storage=ssd"],
+//		"log-driver": "syslog",
+//		"log-opt": {"syslog-tag": "docker"}
+//	}
+//
+// The lists set the options which can be repeated, and the objects set the
+// key=value options. An option can't be both in the file and on the command
+// line.
+func MergeConfigFile(path string, flags *flag.FlagSet) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(b, &config); err != nil {
+		return fmt.Errorf("Error parsing the configuration file %s: %v", path, err)
+	}
+
+	set := make(map[*flag.Flag]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f] = true
+	})
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var conflicts []string
+	for _, key := range keys {
+		f := flags.Lookup("-" + key)
+		if f == nil {
+			return fmt.Errorf("Unknown option %s in the configuration file %s", key, path)
+		}
+		if set[f] {
+			conflicts = append(conflicts, key)
+			continue
+		}
+		values, err := configValues(config[key])
+		if err != nil {
+			return fmt.Errorf("Invalid value of %s in the configuration file %s: %v", key, path, err)
+		}
+		for _, value := range values {
+			if err := flags.Set("-"+key, value); err != nil {
+				return fmt.Errorf("Invalid value of %s in the configuration file %s: %v", key, path, err)
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("The options %s are set both in the configuration file %s and as flags", strings.Join(conflicts, ", "), path)
+	}
+	return nil
+}
+
+// configValues returns the values of the flag of a key of the configuration
+// file.
+func configValues(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		var values []string
+		for _, e := range v {
+			value, err := configValue(e)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var values []string
+		for _, key := range keys {
+			value, err := configValue(v[key])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, key+"="+value)
+		}
+		return values, nil
+	}
+	value, err := configValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{value}, nil
+}
+
+func configValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("expected a string, a boolean or a number, got %v", v)
+}
@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "docker-config-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestMergeConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"label": ["rack=r12", "gpu=true"],
+		"log-opt": {"syslog-tag": "docker", "syslog-facility": "daemon"},
+		"mtu": 9000,
+		"live-restore": true
+	}`)
+	defer os.Remove(path)
+
+	var (
+		labels      = opts.NewListOpts(opts.ValidateLabel)
+		logOpts     = opts.NewListOpts(nil)
+		mtu         int
+		liveRestore bool
+	)
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	flags.Var(&labels, []string{"-label"}, "")
+	flags.Var(&logOpts, []string{"-log-opt"}, "")
+	flags.IntVar(&mtu, []string{"-mtu"}, 0, "")
+	flags.BoolVar(&liveRestore, []string{"-live-restore"}, false, "")
+
+	if err := MergeConfigFile(path, flags); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"rack=r12", "gpu=true"}; !reflect.DeepEqual(labels.GetAll(), expected) {
+		t.Fatalf("Expected the labels %v, got %v", expected, labels.GetAll())
+	}
+	if expected := []string{"syslog-facility=daemon", "syslog-tag=docker"}; !reflect.DeepEqual(logOpts.GetAll(), expected) {
+		t.Fatalf("Expected the log options %v, got %v", expected, logOpts.GetAll())
+	}
+	if mtu != 9000 || !liveRestore {
+		t.Fatalf("Expected the mtu 9000 and live restore, got %d and %t", mtu, liveRestore)
+	}
+}
+
+func TestMergeConfigFileErrors(t *testing.T) {
+	for _, content := range []string{
+		`{"unknown": true}`,
+		`{"label": ["invalid"]}`,
+		`{"mtu": "large"}`,
+		`{"mtu": [[1]]}`,
+		`{"label": `,
+	} {
+		path := writeConfigFile(t, content)
+		defer os.Remove(path)
+
+		var (
+			labels = opts.NewListOpts(opts.ValidateLabel)
+			mtu    int
+		)
+		flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+		flags.Var(&labels, []string{"-label"}, "")
+		flags.IntVar(&mtu, []string{"-mtu"}, 0, "")
+		if err := MergeConfigFile(path, flags); err == nil {
+			t.Fatalf("Expected %s to be invalid", content)
+		}
+	}
+}
+
+func TestMergeConfigFileConflicts(t *testing.T) {
+	path := writeConfigFile(t, `{"mtu": 9000}`)
+	defer os.Remove(path)
+
+	var mtu int
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	flags.IntVar(&mtu, []string{"#mtu", "-mtu"}, 0, "")
+	if err := flags.Parse([]string{"--mtu", "1500"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := MergeConfigFile(path, flags); err == nil {
+		t.Fatal("Expected an option set both in the file and as a flag to conflict")
+	}
+}
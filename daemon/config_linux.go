@@ -19,9 +19,11 @@ type Config struct {
 
 	// Fields below here are platform specific.
 	EnableSelinuxSupport bool
+	EncryptRwLayers      bool // the read-write layers of the containers are encrypted with fscrypt
 	ExecOptions          []string
 	GraphOptions         []string
 	SocketGroup          string
+	SocketMode           string
 	Ulimits              map[string]*ulimit.Ulimit
 }
 
@@ -37,7 +39,9 @@ func (config *Config) InstallFlags() {
 	opts.ListVar(&config.GraphOptions, []string{"-storage-opt"}, "Set storage driver options")
 	opts.ListVar(&config.ExecOptions, []string{"-exec-opt"}, "Set exec driver options")
 	flag.BoolVar(&config.EnableSelinuxSupport, []string{"-selinux-enabled"}, false, "Enable selinux support")
+	flag.BoolVar(&config.EncryptRwLayers, []string{"-encrypt-rw-layers"}, false, "Encrypt the read-write layers of the containers with keys held by the daemon")
 	flag.StringVar(&config.SocketGroup, []string{"G", "-group"}, "docker", "Group for the unix socket")
+	flag.StringVar(&config.SocketMode, []string{"-socket-mode"}, "0660", "Permissions of the unix socket, in octal")
 	config.Ulimits = make(map[string]*ulimit.Ulimit)
 	opts.UlimitMapVar(config.Ulimits, []string{"-default-ulimit"}, "Set default ulimits for containers")
 }
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -20,8 +21,11 @@ import (
 	"github.com/docker/libcontainer/label"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/cache"
 	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/daemon/networkdriver/bridge"
@@ -41,6 +45,7 @@ import (
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
+	"github.com/docker/docker/volumes"
 )
 
 const DefaultPathEnv = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
@@ -90,6 +95,8 @@ type Container struct {
 	daemon                   *Daemon
 	MountLabel, ProcessLabel string
 	AppArmorProfile          string
+	SeccompProfile           string
+	RwLayerEncrypted         bool // the read-write layer is encrypted with a key of the daemon
 	RestartCount             int
 	UpdateDns                bool
 
@@ -101,6 +108,23 @@ type Container struct {
 	// Easier than migrating older container configs :)
 	VolumesRW  map[string]bool
 	hostConfig *runconfig.HostConfig
+	// Read-only bind mounts only needed while the container runs, such as
+	// the secrets of a build step. They are never stored with the container.
+	tmpMounts []execdriver.Mount
+	// Volumes of the volume driver plugins, and local volumes with mount
+	// options, mounted while the container runs.
+	mountedVolumes []*volumes.Volume
+	// Whether the mount events of the volumes were logged, for their
+	// unmount events to be logged once the container stops.
+	volumesMounted bool
+	// The tmpfs of the secrets in /run/secrets while the container runs,
+	// and the mount point created for it in the container's filesystem.
+	secretMounts      []execdriver.Mount
+	secretsMountPoint string
+	// The device nodes of the GPUs of the container and the driver files
+	// listed by the GPU hook, while the container runs.
+	gpuDevices []*configs.Device
+	gpuMounts  []execdriver.Mount
 
 	activeLinks  map[string]*links.Link
 	monitor      *containerMonitor
@@ -201,10 +225,32 @@ func (container *Container) WriteHostConfig() error {
 }
 
 func (container *Container) LogEvent(action string) {
+	container.logEventWithAttributes(action, nil)
+}
+
+// logDieEvent logs the die event of the container, with its exit code.
+func (container *Container) logDieEvent(exitCode int) {
+	container.logEventWithAttributes("die", map[string]string{"exitCode": strconv.Itoa(exitCode)})
+}
+
+// logEventWithAttributes logs an event of the container like LogEvent, with
+// the extra attributes of the action.
+func (container *Container) logEventWithAttributes(action string, extra map[string]string) {
 	d := container.daemon
-	d.EventsService.Log(
+	attributes := map[string]string{
+		"image": container.Config.Image,
+		"name":  strings.TrimPrefix(container.Name, "/"),
+	}
+	for k, v := range container.Config.Labels {
+		attributes[k] = v
+	}
+	for k, v := range extra {
+		attributes[k] = v
+	}
+	d.EventsService.LogActor(
+		events.ContainerEventType,
 		action,
-		container.ID,
+		events.Actor{ID: container.ID, Attributes: attributes},
 		container.Config.Image,
 	)
 }
@@ -349,6 +395,7 @@ func populateCommand(c *Container, env []string) error {
 
 		userSpecifiedDevices = append(userSpecifiedDevices, devs...)
 	}
+	userSpecifiedDevices = append(userSpecifiedDevices, c.gpuDevices...)
 	allowedDevices := append(configs.DefaultAllowedDevices, userSpecifiedDevices...)
 
 	autoCreatedDevices := append(configs.DefaultAutoCreatedDevices, userSpecifiedDevices...)
@@ -420,14 +467,25 @@ func populateCommand(c *Container, env []string) error {
 		AutoCreatedDevices: autoCreatedDevices,
 		CapAdd:             c.hostConfig.CapAdd,
 		CapDrop:            c.hostConfig.CapDrop,
+		DefaultCapAdd:      c.daemon.config.DefaultCapAdd,
+		DefaultCapDrop:     c.daemon.config.DefaultCapDrop,
 		ProcessConfig:      processConfig,
 		ProcessLabel:       c.GetProcessLabel(),
 		MountLabel:         c.GetMountLabel(),
 		LxcConfig:          lxcConfig,
 		AppArmorProfile:    c.AppArmorProfile,
+		SeccompProfile:     c.SeccompProfile,
 		CgroupParent:       c.hostConfig.CgroupParent,
 	}
 
+	if r := c.hostConfig.Runtime; r != "" && r != DefaultRuntime {
+		runtime, ok := c.daemon.runtimes[r]
+		if !ok {
+			return fmt.Errorf("Unknown runtime %s of container %s", r, c.ID)
+		}
+		c.command.Runtime = runtime
+	}
+
 	return nil
 }
 
@@ -457,6 +515,9 @@ func (container *Container) Start() (err error) {
 		}
 	}()
 
+	if err := container.checkPlatform(); err != nil {
+		return err
+	}
 	if err := container.setupContainerDns(); err != nil {
 		return err
 	}
@@ -473,6 +534,15 @@ func (container *Container) Start() (err error) {
 	if err := container.prepareVolumes(); err != nil {
 		return err
 	}
+	if err := container.mountDriverVolumes(); err != nil {
+		return err
+	}
+	if err := container.setupSecrets(); err != nil {
+		return err
+	}
+	if err := container.setupGpus(); err != nil {
+		return err
+	}
 	linkedEnv, err := container.setupLinkedContainers()
 	if err != nil {
 		return err
@@ -491,6 +561,41 @@ func (container *Container) Start() (err error) {
 	return container.waitForStart()
 }
 
+// restore re-attaches to the process of the container left running by a
+// previous daemon, and monitors it as if it was started by this daemon.
+func (container *Container) restore() (err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	defer func() {
+		if err != nil {
+			container.cleanup()
+		}
+	}()
+
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	if err := container.RestoreNetwork(); err != nil {
+		return err
+	}
+	if err := container.restoreDriverVolumes(); err != nil {
+		return err
+	}
+	linkedEnv, err := container.setupLinkedContainers()
+	if err != nil {
+		return err
+	}
+	env := container.createDaemonEnvironment(linkedEnv)
+	if err := populateCommand(container, env); err != nil {
+		return err
+	}
+
+	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	container.monitor.restore = true
+	return container.waitForMonitor()
+}
+
 func (container *Container) Run() error {
 	if err := container.Start(); err != nil {
 		return err
@@ -671,6 +776,7 @@ func (container *Container) AllocateNetwork() error {
 
 	networkSettings.Ports = bindings
 	container.NetworkSettings = networkSettings
+	container.logNetworkEvent("connect")
 
 	return nil
 }
@@ -680,11 +786,28 @@ func (container *Container) ReleaseNetwork() {
 		return
 	}
 
+	allocated := container.isNetworkAllocated()
 	bridge.Release(container.ID)
+	if allocated {
+		container.logNetworkEvent("disconnect")
+	}
 
 	container.NetworkSettings = &network.Settings{}
 }
 
+// logNetworkEvent logs an event of the bridge network the container is
+// connected to.
+func (container *Container) logNetworkEvent(action string) {
+	container.daemon.EventsService.LogActor(events.NetworkEventType, action, events.Actor{
+		ID: "bridge",
+		Attributes: map[string]string{
+			"container": container.ID,
+			"name":      "bridge",
+			"type":      "bridge",
+		},
+	}, "")
+}
+
 func (container *Container) isNetworkAllocated() bool {
 	return container.NetworkSettings.IPAddress != ""
 }
@@ -724,10 +847,14 @@ func (container *Container) cleanup() {
 		}
 	}
 
+	container.unmountSecrets()
+
 	if err := container.Unmount(); err != nil {
 		logrus.Errorf("%v: Failed to umount filesystem: %v", container.ID, err)
 	}
 
+	container.unmountDriverVolumes()
+
 	for _, eConfig := range container.execCommands.s {
 		container.daemon.unregisterExecCommand(eConfig)
 	}
@@ -738,9 +865,10 @@ func (container *Container) KillSig(sig int) error {
 	container.Lock()
 	defer container.Unlock()
 
-	// We could unpause the container for them rather than returning this error
+	// the signals aren't handled by the processes of a paused container
+	// until it's unpaused
 	if container.Paused {
-		return fmt.Errorf("Container %s is paused. Unpause the container before stopping", container.ID)
+		return errContainerPaused(container, "stopping or killing")
 	}
 
 	if !container.Running {
@@ -776,6 +904,9 @@ func (container *Container) Pause() error {
 	defer container.Unlock()
 
 	// We cannot Pause the container which is already paused
+	if container.pausedForCommit {
+		return fmt.Errorf("Conflict: container %s is paused by a commit in progress", container.ID)
+	}
 	if container.Paused {
 		return fmt.Errorf("Container %s is already paused", container.ID)
 	}
@@ -788,7 +919,7 @@ func (container *Container) Pause() error {
 	if err := container.daemon.execDriver.Pause(container.command); err != nil {
 		return err
 	}
-	container.Paused = true
+	container.setPaused()
 	return nil
 }
 
@@ -801,6 +932,11 @@ func (container *Container) Unpause() error {
 		return fmt.Errorf("Container %s is not paused, so what", container.ID)
 	}
 
+	// the commit unpauses the container once it's done
+	if container.pausedForCommit {
+		return fmt.Errorf("Conflict: container %s is paused by a commit in progress", container.ID)
+	}
+
 	// We cannot unpause the container which is not running
 	if !container.Running {
 		return fmt.Errorf("Container %s is not running", container.ID)
@@ -809,10 +945,72 @@ func (container *Container) Unpause() error {
 	if err := container.daemon.execDriver.Unpause(container.command); err != nil {
 		return err
 	}
-	container.Paused = false
+	container.setUnpaused()
+	return nil
+}
+
+// pauseForCommit pauses the running container for a commit, unless it's
+// already paused. It returns whether the container was paused.
+func (container *Container) pauseForCommit() (bool, error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if !container.Running || container.Paused {
+		return false, nil
+	}
+	if err := container.daemon.execDriver.Pause(container.command); err != nil {
+		return false, err
+	}
+	container.setPaused()
+	container.pausedForCommit = true
+	return true, nil
+}
+
+// unpauseForCommit unpauses the container paused by pauseForCommit once the
+// commit is done, unless it was killed in between.
+func (container *Container) unpauseForCommit() error {
+	container.Lock()
+	defer container.Unlock()
+
+	if !container.pausedForCommit {
+		return nil
+	}
+	if err := container.daemon.execDriver.Unpause(container.command); err != nil {
+		return err
+	}
+	container.setUnpaused()
 	return nil
 }
 
+// forceKill kills the container, even if it's paused. A paused container is
+// sent SIGKILL before it's unpaused, for its processes not to run again.
+func (container *Container) forceKill() error {
+	container.Lock()
+	if !container.Running || !container.Paused {
+		container.Unlock()
+		return container.Kill()
+	}
+	container.monitor.ExitOnNext()
+	err := container.daemon.Kill(container, 9)
+	if err == nil || err == syscall.ESRCH {
+		err = container.daemon.execDriver.Unpause(container.command)
+	}
+	if err == nil {
+		container.setUnpaused()
+	}
+	container.Unlock()
+	if err != nil {
+		return err
+	}
+	return container.Kill()
+}
+
+// errContainerPaused is the error of an operation which can't be done while
+// the container is paused.
+func errContainerPaused(container *Container, operation string) error {
+	return fmt.Errorf("Conflict: container %s is paused, unpause the container before %s", container.ID, operation)
+}
+
 func (container *Container) Kill() error {
 	if !container.IsRunning() {
 		return nil
@@ -1447,24 +1645,120 @@ func (container *Container) getLogConfig() runconfig.LogConfig {
 
 func (container *Container) getLogger() (logger.Logger, error) {
 	cfg := container.getLogConfig()
+	// the drivers don't take the options of the delivery mode of the
+	// messages
+	var modeCfg map[string]string
+	cfg.Config, modeCfg = logger.SplitModeOpts(cfg.Config)
+	l, err := container.getLogDriver(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ml, err := logger.WithMode(l, modeCfg)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return ml, nil
+}
+
+func (container *Container) getLogDriver(cfg runconfig.LogConfig) (logger.Logger, error) {
 	c, err := logger.GetLogDriver(cfg.Type)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get logging factory: %v", err)
 	}
-	ctx := logger.Context{
-		Config:        cfg.Config,
-		ContainerID:   container.ID,
-		ContainerName: container.Name,
-	}
+	ctx := container.getLogContext(cfg)
 
 	// Set logging file for "json-logger"
 	if cfg.Type == jsonfilelog.Name {
-		ctx.LogPath, err = container.GetRootResourcePath(fmt.Sprintf("%s-json.log", container.ID))
+		ctx.LogPath, err = container.getLogPath()
 		if err != nil {
 			return nil, err
 		}
+		return c(ctx)
+	}
+
+	// the other drivers don't take the options of the local cache
+	var cacheCfg map[string]string
+	ctx.Config, cacheCfg = cache.SplitLogOpts(cfg.Config)
+	l, err := c(ctx)
+	if err != nil || !cache.Enabled(cfg.Type, cacheCfg) {
+		return l, err
+	}
+	ctx.Config = cfg.Config
+	if ctx.LogPath, err = container.getLogPath(); err != nil {
+		l.Close()
+		return nil, err
+	}
+	cl, err := cache.WithLocalCache(l, ctx)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return cl, nil
+}
+
+func (container *Container) getLogContext(cfg runconfig.LogConfig) logger.Context {
+	return logger.Context{
+		Config:              cfg.Config,
+		ContainerID:         container.ID,
+		ContainerName:       container.Name,
+		ContainerEntrypoint: container.Path,
+		ContainerArgs:       container.Args,
+		ContainerImageID:    container.ImageID,
+		ContainerImageName:  container.Config.Image,
+		ContainerCreated:    container.Created,
+		ContainerEnv:        container.Config.Env,
+		ContainerLabels:     container.Config.Labels,
+	}
+}
+
+func (container *Container) getLogPath() (string, error) {
+	return container.GetRootResourcePath(fmt.Sprintf("%s-json.log", container.ID))
+}
+
+// canReadLogs returns whether the logs of the container can be read back,
+// from the json-file log of its logging driver or of the local cache, or
+// from its logging driver plugin.
+func (container *Container) canReadLogs() bool {
+	cfg := container.getLogConfig()
+	if cfg.Type == jsonfilelog.Name || logger.GetCapability(cfg.Type).ReadLogs {
+		return true
+	}
+	_, cacheCfg := cache.SplitLogOpts(cfg.Config)
+	return cache.Enabled(cfg.Type, cacheCfg)
+}
+
+// getLogReader returns the reader of the logs of the container: the
+// json-file logger of its json-file logging driver or of the local cache of
+// its logging driver, or its logging driver plugin.
+func (container *Container) getLogReader() (logger.LogReader, error) {
+	if !container.canReadLogs() {
+		return nil, fmt.Errorf("\"logs\" are not available for the %q logging driver with its local cache disabled", container.LogDriverType())
+	}
+	cfg := container.getLogConfig()
+	if logger.GetCapability(cfg.Type).ReadLogs {
+		ctx := container.getLogContext(cfg)
+		ctx.Config, _ = cache.SplitLogOpts(cfg.Config)
+		return logger.GetLogReader(cfg.Type, ctx)
+	}
+	logPath, err := container.getLogPath()
+	if err != nil {
+		return nil, err
+	}
+	ctx := logger.Context{
+		Config:      cfg.Config,
+		ContainerID: container.ID,
+		LogPath:     logPath,
+	}
+	if cfg.Type != jsonfilelog.Name {
+		_, cacheCfg := cache.SplitLogOpts(cfg.Config)
+		ctx.Config = cache.JSONFileConfig(cacheCfg)
 	}
-	return c(ctx)
+	l, err := jsonfilelog.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.(*jsonfilelog.JSONFileLogger), nil
 }
 
 func (container *Container) startLogging() error {
@@ -1486,17 +1780,37 @@ func (container *Container) startLogging() error {
 	copier.Run()
 	container.logDriver = l
 
-	// set LogPath field only for json-file logdriver
-	if jl, ok := l.(*jsonfilelog.JSONFileLogger); ok {
+	// set LogPath field only for json-file logdriver, or the local cache
+	// of the other ones
+	if jl, ok := l.(interface {
+		LogPath() string
+	}); ok {
 		container.LogPath = jl.LogPath()
 	}
 
 	return nil
 }
 
+// logStats returns the stats of the delivery of the messages of the
+// container to its logging driver, which only drops messages in
+// non-blocking mode.
+func (container *Container) logStats() types.LogStats {
+	container.Lock()
+	l := container.logDriver
+	container.Unlock()
+	var stats types.LogStats
+	if rl, ok := l.(*logger.RingLogger); ok {
+		stats.Dropped, stats.DroppedBytes = rl.Dropped()
+	}
+	return stats
+}
+
 func (container *Container) waitForStart() error {
 	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	return container.waitForMonitor()
+}
 
+func (container *Container) waitForMonitor() error {
 	// block until we either receive an error from the initial start of the container's
 	// process or until the process is running in the container
 	select {
@@ -1600,6 +1914,7 @@ func (container *Container) Exec(execConfig *execConfig) error {
 	waitStart := make(chan struct{})
 
 	callback := func(processConfig *execdriver.ProcessConfig, pid int) {
+		execConfig.setPid(pid)
 		if processConfig.Tty {
 			// The callback is called after the process Start()
 			// so we are in the parent process. In TTY mode, stdin/out/err is the PtySlave
@@ -1664,14 +1979,17 @@ func (c *Container) Attach(stdin io.ReadCloser, stdout io.Writer, stderr io.Writ
 
 func (c *Container) AttachWithLogs(stdin io.ReadCloser, stdout, stderr io.Writer, logs, stream bool) error {
 	if logs {
-		logDriver, err := c.getLogger()
-		cLog, err := logDriver.GetReader()
+		logReader, err := c.getLogReader()
+		if err != nil {
+			return err
+		}
+		defer logReader.Close()
+		cLog, err := logReader.GetReader()
 
 		if err != nil {
 			logrus.Errorf("Error reading logs: %s", err)
-		} else if c.LogDriverType() != jsonfilelog.Name {
-			logrus.Errorf("Reading logs not implemented for driver %s", c.LogDriverType())
 		} else {
+			defer cLog.(io.Closer).Close()
 			dec := json.NewDecoder(cLog)
 			for {
 				l := &jsonlog.JSONLog{}
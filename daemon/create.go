@@ -4,14 +4,19 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/imagepolicy"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/platform"
 	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
+	"github.com/docker/docker/volumes"
 	"github.com/docker/libcontainer/label"
 )
 
-func (daemon *Daemon) ContainerCreate(name string, config *runconfig.Config, hostConfig *runconfig.HostConfig) (string, []string, error) {
+func (daemon *Daemon) ContainerCreate(name string, config *runconfig.Config, hostConfig *runconfig.HostConfig, p *platform.Platform) (string, []string, error) {
 	warnings, err := daemon.verifyHostConfig(hostConfig)
 	if err != nil {
 		return "", warnings, err
@@ -24,6 +29,18 @@ func (daemon *Daemon) ContainerCreate(name string, config *runconfig.Config, hos
 		return "", warnings, fmt.Errorf("The working directory '%s' is invalid. It needs to be an absolute path.", config.WorkingDir)
 	}
 
+	if err := daemon.checkImagePolicy(config.Image); err != nil {
+		return "", warnings, err
+	}
+
+	// an image of another platform is reported missing, for the client to
+	// pull the one of the platform
+	if p != nil && config.Image != "" {
+		if img, err := daemon.repositories.LookupImage(config.Image); err == nil && !p.Match(img.OS, img.Architecture) {
+			return "", warnings, fmt.Errorf("No such image: %s (platform: %s)", config.Image, p)
+		}
+	}
+
 	container, buildWarnings, err := daemon.Create(config, hostConfig, name)
 	if err != nil {
 		if daemon.Graph().IsNotExist(err, config.Image) {
@@ -38,10 +55,85 @@ func (daemon *Daemon) ContainerCreate(name string, config *runconfig.Config, hos
 
 	container.LogEvent("create")
 	warnings = append(warnings, buildWarnings...)
+	if img, err := container.GetImage(); err == nil && !hostConfig.SkipPlatformCheck {
+		if err := platform.Check(img.OS, img.Architecture); err != nil {
+			warnings = append(warnings, fmt.Sprintf("The container won't start: %v.", err))
+		}
+	}
 
 	return container.ID, warnings, nil
 }
 
+// checkImagePolicy checks the image of a container with the image policy
+// plugins, before the container is created. A missing image is reported by
+// Create.
+func (daemon *Daemon) checkImagePolicy(name string) error {
+	if name == "" {
+		return nil
+	}
+	img, err := daemon.repositories.LookupImage(name)
+	if err != nil || img == nil {
+		return nil
+	}
+
+	req := &imagepolicy.Request{Image: name, ID: img.ID}
+	for _, ref := range daemon.repositories.ByID()[img.ID] {
+		if _, tag := parsers.ParseRepositoryTag(ref); utils.DigestReference(tag) {
+			req.Digests = append(req.Digests, ref)
+		} else {
+			req.Tags = append(req.Tags, ref)
+		}
+	}
+	if img.Config != nil {
+		req.Labels = img.Config.Labels
+	}
+	return daemon.imagePolicy.Check(req)
+}
+
+// VolumeCreate creates the named volume with the given name, volume driver,
+// driver options and labels, or an anonymous local volume if the name is
+// empty. Creating a named volume which already exists returns it, with its
+// own options and labels.
+func (daemon *Daemon) VolumeCreate(name, driver string, opts, labels map[string]string) (*types.Volume, error) {
+	var (
+		v   *volumes.Volume
+		err error
+	)
+	if name == "" {
+		if driver != "" && driver != volumes.DefaultDriver {
+			return nil, fmt.Errorf("Bad parameter: the volumes of the %s volume driver must be named", driver)
+		}
+		if len(opts) > 0 {
+			return nil, fmt.Errorf("Bad parameter: the volumes with driver options must be named")
+		}
+		v, err = daemon.volumes.CreateVolume(labels, true)
+	} else {
+		v, err = daemon.volumes.FindOrCreateNamedVolume(name, driver, opts, labels, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return volumeToAPIType(v), nil
+}
+
+// VolumeClone creates the named volume with the given name and labels, or an
+// anonymous volume if the name is empty, as a copy of the volume from, with
+// the volume driver of from.
+func (daemon *Daemon) VolumeClone(name, from, driver string, labels map[string]string) (*types.Volume, error) {
+	src := daemon.volumes.GetByName(from)
+	if src == nil {
+		return nil, fmt.Errorf("No such volume: %s", from)
+	}
+	if driver != "" && driver != src.DriverName() {
+		return nil, fmt.Errorf("Bad parameter: volume %s has the %s driver, not %s", from, src.DriverName(), driver)
+	}
+	v, err := daemon.volumes.CloneVolume(src, name, labels, true)
+	if err != nil {
+		return nil, err
+	}
+	return volumeToAPIType(v), nil
+}
+
 // Create creates a new container from the given configuration with a given name.
 func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.HostConfig, name string) (*Container, []string, error) {
 	var (
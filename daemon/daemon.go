@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -25,6 +26,7 @@ import (
 	"github.com/docker/docker/daemon/graphdriver"
 	_ "github.com/docker/docker/daemon/graphdriver/vfs"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/cache"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/daemon/networkdriver/bridge"
 	"github.com/docker/docker/graph"
@@ -33,14 +35,17 @@ import (
 	"github.com/docker/docker/pkg/broadcastwriter"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/graphdb"
+	"github.com/docker/docker/pkg/imagepolicy"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/kernel"
 	"github.com/docker/docker/pkg/resolvconf"
+	"github.com/docker/docker/pkg/seccomp"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/sysinfo"
 	"github.com/docker/docker/pkg/truncindex"
+	"github.com/docker/docker/pkg/units"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/trust"
@@ -101,11 +106,16 @@ type Daemon struct {
 	idIndex          *truncindex.TruncIndex
 	sysInfo          *sysinfo.SysInfo
 	volumes          *volumes.Repository
+	secrets          *secretStore
+	plugins          *pluginStore
+	runtimes         map[string]*execdriver.Runtime // the OCI runtimes by name
+	imagePolicy      *imagepolicy.Policy
 	config           *Config
 	containerGraph   *graphdb.Database
 	driver           graphdriver.Driver
 	execDriver       execdriver.Driver
 	statsCollector   *statsCollector
+	layerGC          *layerGC // nil without storage budget
 	defaultLogConfig runconfig.LogConfig
 	RegistryService  *registry.Service
 	EventsService    *events.Events
@@ -208,26 +218,34 @@ func (daemon *Daemon) register(container *Container, updateSuffixarray bool) err
 
 	container.registerVolumes()
 
-	if container.IsRunning() {
-		logrus.Debugf("killing old running container %s", container.ID)
+	// with live restore, the running containers are restored once they are
+	// all registered, for their links
+	if container.IsRunning() && !daemon.config.LiveRestore {
+		daemon.killOldContainer(container)
+	}
 
-		container.SetStopped(&execdriver.ExitStatus{ExitCode: 0})
+	return nil
+}
 
-		// use the current driver and ensure that the container is dead x.x
-		cmd := &execdriver.Command{
-			ID: container.ID,
-		}
-		daemon.execDriver.Terminate(cmd)
+// killOldContainer ensures that a container left running by a previous daemon
+// is dead, and marks it as stopped.
+func (daemon *Daemon) killOldContainer(container *Container) {
+	logrus.Debugf("killing old running container %s", container.ID)
 
-		if err := container.Unmount(); err != nil {
-			logrus.Debugf("unmount error %s", err)
-		}
-		if err := container.ToDisk(); err != nil {
-			logrus.Debugf("saving stopped state to disk %s", err)
-		}
+	container.SetStopped(&execdriver.ExitStatus{ExitCode: 0})
+
+	// use the current driver and ensure that the container is dead x.x
+	cmd := &execdriver.Command{
+		ID: container.ID,
 	}
+	daemon.execDriver.Terminate(cmd)
 
-	return nil
+	if err := container.Unmount(); err != nil {
+		logrus.Debugf("unmount error %s", err)
+	}
+	if err := container.ToDisk(); err != nil {
+		logrus.Debugf("saving stopped state to disk %s", err)
+	}
 }
 
 func (daemon *Daemon) ensureName(container *Container) error {
@@ -319,6 +337,28 @@ func (daemon *Daemon) restore() error {
 		registeredContainers = append(registeredContainers, container)
 	}
 
+	// re-attach to the containers left running by the previous daemon
+	if daemon.config.LiveRestore {
+		for _, container := range registeredContainers {
+			if !container.IsRunning() {
+				continue
+			}
+			logrus.Debugf("Restoring container %s", container.ID)
+			if err := container.restore(); err != nil {
+				logrus.Errorf("Failed to restore container %s: %s", container.ID, err)
+				daemon.killOldContainer(container)
+			}
+		}
+	}
+
+	// remove the containers to remove on exit which stopped while the daemon
+	// was down
+	for _, container := range registeredContainers {
+		if container.hostConfig.AutoRemove && !container.IsRunning() {
+			daemon.autoRemove(container)
+		}
+	}
+
 	// check the restart policy on the containers and restart any container with
 	// the restart policy of "always"
 	if daemon.config.AutoRestart {
@@ -540,16 +580,27 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 		err       error
 	)
 
+	container.SeccompProfile = ""
 	for _, opt := range config.SecurityOpt {
-		con := strings.SplitN(opt, ":", 2)
-		if len(con) == 1 {
+		// the options are key:value, or key=value
+		i := strings.IndexAny(opt, ":=")
+		if i == -1 {
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
+		con := []string{opt[:i], opt[i+1:]}
 		switch con[0] {
 		case "label":
 			labelOpts = append(labelOpts, con[1])
 		case "apparmor":
 			container.AppArmorProfile = con[1]
+		case "seccomp":
+			// the client sends the content of the profile
+			if con[1] != "unconfined" {
+				if _, err := seccomp.LoadProfile([]byte(con[1])); err != nil {
+					return fmt.Errorf("Invalid seccomp profile: %v", err)
+				}
+			}
+			container.SeccompProfile = con[1]
 		default:
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
@@ -612,6 +663,9 @@ func (daemon *Daemon) createRootfs(container *Container) error {
 		return err
 	}
 
+	if daemon.config.EncryptRwLayers {
+		return daemon.createEncryptedRwLayer(container, initID)
+	}
 	if err := daemon.driver.Create(container.ID, initID); err != nil {
 		return err
 	}
@@ -735,6 +789,49 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	if !config.Bridge.EnableIptables && config.Bridge.EnableIpMasq {
 		config.Bridge.EnableIpMasq = false
 	}
+	if config.LiveRestore && config.ExecDriver != "native" {
+		return nil, fmt.Errorf("You specified --live-restore with the %s exec driver. Live restore is only supported by the native exec driver.", config.ExecDriver)
+	}
+	if len(config.DefaultCapAdd) > 0 || len(config.DefaultCapDrop) > 0 {
+		if config.ExecDriver != "native" {
+			return nil, fmt.Errorf("You specified --default-cap-add or --default-cap-drop with the %s exec driver. The default capabilities are only supported by the native exec driver.", config.ExecDriver)
+		}
+		if _, err := execdriver.TweakCapabilities(nil, config.DefaultCapAdd, config.DefaultCapDrop); err != nil {
+			return nil, err
+		}
+	}
+	if len(config.Runtimes) > 0 && config.ExecDriver != "native" {
+		return nil, fmt.Errorf("You specified --add-runtime with the %s exec driver. The OCI runtimes are only supported by the native exec driver.", config.ExecDriver)
+	}
+	runtimes, err := newRuntimes(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.GpuHook != "" {
+		if _, err := exec.LookPath(config.GpuHook); err != nil {
+			return nil, fmt.Errorf("Error checking the GPU hook: %v", err)
+		}
+	}
+	if err := validateExperimental(config); err != nil {
+		return nil, err
+	}
+	var storageBudget int64
+	if config.StorageBudget != "" {
+		if storageBudget, err = units.RAMInBytes(config.StorageBudget); err != nil || storageBudget < 0 {
+			return nil, fmt.Errorf("Invalid storage budget: %s", config.StorageBudget)
+		}
+	}
+	var downloadBandwidth, uploadBandwidth int64
+	if config.MaxDownloadBandwidth != "" {
+		if downloadBandwidth, err = units.RAMInBytes(config.MaxDownloadBandwidth); err != nil || downloadBandwidth < 0 {
+			return nil, fmt.Errorf("Invalid download bandwidth: %s", config.MaxDownloadBandwidth)
+		}
+	}
+	if config.MaxUploadBandwidth != "" {
+		if uploadBandwidth, err = units.RAMInBytes(config.MaxUploadBandwidth); err != nil || uploadBandwidth < 0 {
+			return nil, fmt.Errorf("Invalid upload bandwidth: %s", config.MaxUploadBandwidth)
+		}
+	}
 	config.DisableNetwork = config.Bridge.Iface == disableNetworkBridge
 
 	// Check that the system is supported and we have sufficient privileges
@@ -804,6 +901,9 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		if _, err := logger.GetLogDriver(config.LogConfig.Type); err != nil {
 			return nil, fmt.Errorf("error finding the logging driver: %v", err)
 		}
+		if err := cache.ValidateLogOpts(config.LogConfig.Type, config.LogConfig.Config); err != nil {
+			return nil, err
+		}
 	}
 	logrus.Debugf("Using default logging driver %s", config.LogConfig.Type)
 
@@ -831,6 +931,12 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	if err := migrateIfAufs(d.driver, config.Root); err != nil {
 		return nil, err
 	}
+	if config.EncryptRwLayers {
+		if err := checkRwLayerEncryption(d.driver, config.Root); err != nil {
+			return nil, err
+		}
+	}
+	d.driver = graphdriver.InstrumentedDriver(d.driver)
 
 	logrus.Debug("Creating images graph")
 	g, err := graph.NewGraph(path.Join(config.Root, "graph"), d.driver)
@@ -848,6 +954,16 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		return nil, err
 	}
 
+	secrets, err := newSecretStore(filepath.Join(config.Root, "secrets"))
+	if err != nil {
+		return nil, err
+	}
+
+	installedPlugins, err := newPluginStore(filepath.Join(config.Root, "plugins"), config.PluginTrustDir)
+	if err != nil {
+		return nil, err
+	}
+
 	trustKey, err := api.LoadOrCreateTrustKey(config.TrustKeyPath)
 	if err != nil {
 		return nil, err
@@ -862,14 +978,19 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		return nil, fmt.Errorf("could not create trust store: %s", err)
 	}
 
-	eventsService := events.New()
+	eventsService, err := events.NewFromFile(path.Join(config.Root, "events.json"))
+	if err != nil {
+		return nil, err
+	}
 	logrus.Debug("Creating repository list")
 	tagCfg := &graph.TagStoreConfig{
-		Graph:    g,
-		Key:      trustKey,
-		Registry: registryService,
-		Events:   eventsService,
-		Trust:    trustService,
+		Graph:             g,
+		Key:               trustKey,
+		Registry:          registryService,
+		Events:            eventsService,
+		Trust:             trustService,
+		DownloadBandwidth: downloadBandwidth,
+		UploadBandwidth:   uploadBandwidth,
 	}
 	repositories, err := graph.NewTagStore(path.Join(config.Root, "repositories-"+d.driver.String()), tagCfg)
 	if err != nil {
@@ -911,7 +1032,7 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	}
 
 	sysInfo := sysinfo.New(false)
-	ed, err := execdrivers.NewDriver(config.ExecDriver, config.ExecOptions, config.ExecRoot, config.Root, sysInitPath, sysInfo)
+	ed, err := execdrivers.NewDriver(config.ExecDriver, config.ExecOptions, config.ExecRoot, config.Root, sysInitPath, sysInfo, config.LiveRestore)
 	if err != nil {
 		return nil, err
 	}
@@ -925,6 +1046,10 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	d.idIndex = truncindex.NewTruncIndex([]string{})
 	d.sysInfo = sysInfo
 	d.volumes = volumes
+	d.secrets = secrets
+	d.plugins = installedPlugins
+	d.runtimes = runtimes
+	d.imagePolicy = imagepolicy.New(config.ImagePolicyPlugins, config.ImagePolicyCacheTTL)
 	d.config = config
 	d.sysInitPath = sysInitPath
 	d.execDriver = ed
@@ -937,6 +1062,14 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		return nil, err
 	}
 
+	if config.StorageBudget != "" {
+		d.layerGC = newLayerGC(d, storageBudget)
+	}
+
+	if err := d.registerMetrics(); err != nil {
+		logrus.Errorf("Failed to register the metrics of the daemon: %v", err)
+	}
+
 	// set up filesystem watch on resolv.conf for network changes
 	if err := d.setupResolvconfWatcher(); err != nil {
 		return nil, err
@@ -946,42 +1079,52 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 }
 
 func (daemon *Daemon) Shutdown() error {
+	if daemon.layerGC != nil {
+		daemon.layerGC.stop()
+	}
+	if daemon.EventsService != nil {
+		// the events of the containers stopped by the shutdown are kept
+		defer func() {
+			if err := daemon.EventsService.Close(); err != nil {
+				logrus.Errorf("Error during events Close(): %v", err)
+			}
+		}()
+	}
+	// with live restore, the running containers are left running, with their
+	// filesystems mounted
+	liveRestore := daemon.config != nil && daemon.config.LiveRestore
+	if daemon.containers != nil && !liveRestore {
+		logrus.Debug("starting clean shutdown of all containers...")
+		daemon.shutdownContainers()
+	}
 	if daemon.containerGraph != nil {
 		if err := daemon.containerGraph.Close(); err != nil {
 			logrus.Errorf("Error during container graph.Close(): %v", err)
 		}
 	}
+	if liveRestore {
+		return nil
+	}
 	if daemon.driver != nil {
 		if err := daemon.driver.Cleanup(); err != nil {
 			logrus.Errorf("Error during graph storage driver.Cleanup(): %v", err)
 		}
 	}
-	if daemon.containers != nil {
-		group := sync.WaitGroup{}
-		logrus.Debug("starting clean shutdown of all containers...")
-		for _, container := range daemon.List() {
-			c := container
-			if c.IsRunning() {
-				logrus.Debugf("stopping %s", c.ID)
-				group.Add(1)
-
-				go func() {
-					defer group.Done()
-					if err := c.KillSig(15); err != nil {
-						logrus.Debugf("kill 15 error for %s - %s", c.ID, err)
-					}
-					c.WaitStop(-1 * time.Second)
-					logrus.Debugf("container stopped %s", c.ID)
-				}()
-			}
-		}
-		group.Wait()
-	}
 
 	return nil
 }
 
+// ShutdownTimeout returns the time the daemon waits for its shutdown.
+func (daemon *Daemon) ShutdownTimeout() time.Duration {
+	// the containers are killed after the shutdown timeout, which takes up
+	// to 10 seconds
+	return time.Duration(daemon.config.ShutdownTimeout+15) * time.Second
+}
+
 func (daemon *Daemon) Mount(container *Container) error {
+	if err := daemon.loadRwLayerKey(container); err != nil {
+		return err
+	}
 	dir, err := daemon.driver.Get(container.ID, container.GetMountLabel())
 	if err != nil {
 		return fmt.Errorf("Error getting container %s from driver %s: %s", container.ID, daemon.driver, err)
@@ -1015,6 +1158,10 @@ func (daemon *Daemon) Run(c *Container, pipes *execdriver.Pipes, startCallback e
 	return daemon.execDriver.Run(c.command, pipes, startCallback)
 }
 
+func (daemon *Daemon) Restore(c *Container, pipes *execdriver.Pipes, restoreCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	return daemon.execDriver.Restore(c.command, pipes, restoreCallback)
+}
+
 func (daemon *Daemon) Kill(c *Container, sig int) error {
 	return daemon.execDriver.Kill(c.command, sig)
 }
@@ -1109,6 +1256,29 @@ func (daemon *Daemon) ImageGetCached(imgID string, config *runconfig.Config) (*i
 	return match, nil
 }
 
+// ImageGetCachedFrom is like ImageGetCached, but only the images in sources
+// and their parents are considered as cache candidates.
+func (daemon *Daemon) ImageGetCachedFrom(imgID string, config *runconfig.Config, sources []string) (*image.Image, error) {
+	var match *image.Image
+	for _, source := range sources {
+		img, err := daemon.Graph().Get(source)
+		if err != nil {
+			return nil, err
+		}
+		if err := img.WalkHistory(func(img *image.Image) error {
+			if img.Parent == imgID && runconfig.Compare(&img.ContainerConfig, config) {
+				if match == nil || match.Created.Before(img.Created) {
+					match = img
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return match, nil
+}
+
 // tempDir returns the default directory to use for temporary files.
 func tempDir(rootDir string) (string, error) {
 	var tmpDir string
@@ -1118,6 +1288,35 @@ func tempDir(rootDir string) (string, error) {
 	return tmpDir, os.MkdirAll(tmpDir, 0700)
 }
 
+// newRuntimes returns the OCI runtimes registered with --add-runtime by name,
+// with the arguments and the environment set for them.
+func newRuntimes(config *Config) (map[string]*execdriver.Runtime, error) {
+	runtimes := make(map[string]*execdriver.Runtime)
+	for name, path := range config.Runtimes {
+		if _, err := exec.LookPath(path); err != nil {
+			return nil, fmt.Errorf("Error checking the runtime %s: %v", name, err)
+		}
+		runtimes[name] = &execdriver.Runtime{Path: path}
+	}
+	for _, arg := range config.RuntimeArgs {
+		parts := strings.SplitN(arg, "=", 2)
+		r, ok := runtimes[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("You specified --runtime-arg %s for the unknown runtime %s, the runtimes are registered with --add-runtime.", arg, parts[0])
+		}
+		r.Args = append(r.Args, parts[1])
+	}
+	for _, env := range config.RuntimeEnv {
+		parts := strings.SplitN(env, "=", 2)
+		r, ok := runtimes[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("You specified --runtime-env %s for the unknown runtime %s, the runtimes are registered with --add-runtime.", env, parts[0])
+		}
+		r.Env = append(r.Env, parts[1])
+	}
+	return runtimes, nil
+}
+
 func checkKernel() error {
 	// Check for unsupported kernel versions
 	// FIXME: it would be cleaner to not test for specific versions, but rather
@@ -1173,6 +1372,9 @@ func (daemon *Daemon) verifyHostConfig(hostConfig *runconfig.HostConfig) ([]stri
 		warnings = append(warnings, "Your kernel does not support CPU cfs quota. Quota discarded.")
 		hostConfig.CpuQuota = 0
 	}
+	if hostConfig.AutoRemove && (hostConfig.RestartPolicy.IsAlways() || hostConfig.RestartPolicy.IsOnFailure()) {
+		return warnings, fmt.Errorf("Conflicting options: AutoRemove and the restart policy %s", hostConfig.RestartPolicy.Name)
+	}
 	if hostConfig.BlkioWeight > 0 && (hostConfig.BlkioWeight < 10 || hostConfig.BlkioWeight > 1000) {
 		return warnings, fmt.Errorf("Range of blkio weight is from 10 to 1000.")
 	}
@@ -1180,6 +1382,25 @@ func (daemon *Daemon) verifyHostConfig(hostConfig *runconfig.HostConfig) ([]stri
 		hostConfig.OomKillDisable = false
 		return warnings, fmt.Errorf("Your kernel does not support oom kill disable.")
 	}
+	if logType := hostConfig.LogConfig.Type; logType != "" && logType != "none" {
+		if _, err := logger.GetLogDriver(logType); err != nil {
+			return warnings, err
+		}
+		if err := cache.ValidateLogOpts(logType, hostConfig.LogConfig.Config); err != nil {
+			return warnings, err
+		}
+	}
+	if err := daemon.verifySecrets(hostConfig.Secrets); err != nil {
+		return warnings, err
+	}
+	if !hostConfig.Gpus.Valid() {
+		return warnings, fmt.Errorf("Bad parameter: invalid GPUs %q, expected all or device=0,1", hostConfig.Gpus)
+	}
+	if r := hostConfig.Runtime; r != "" && r != DefaultRuntime {
+		if _, ok := daemon.runtimes[r]; !ok {
+			return warnings, fmt.Errorf("Unknown runtime %s, the runtimes of the daemon are registered with --add-runtime", r)
+		}
+	}
 
 	return warnings, nil
 }
@@ -1,8 +1,11 @@
 package daemon
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -37,3 +40,60 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatal("Expected parseSecurityOpt error, got nil")
 	}
 }
+
+func TestNewRuntimes(t *testing.T) {
+	tests := []struct {
+		runtimes map[string]string
+		args     []string
+		env      []string
+		expected map[string]*execdriver.Runtime
+		err      string
+	}{
+		{
+			runtimes: map[string]string{},
+			expected: map[string]*execdriver.Runtime{},
+		},
+		{
+			runtimes: map[string]string{"runc": "/bin/sh", "kata": "/bin/true"},
+			args:     []string{"runc=--debug", "kata=--kata-config=/etc/kata.toml", "runc=--systemd-cgroup"},
+			env:      []string{"runc=XDG_RUNTIME_DIR=/run/runc", "kata=KATA_DEBUG=1"},
+			expected: map[string]*execdriver.Runtime{
+				"runc": {Path: "/bin/sh", Args: []string{"--debug", "--systemd-cgroup"}, Env: []string{"XDG_RUNTIME_DIR=/run/runc"}},
+				"kata": {Path: "/bin/true", Args: []string{"--kata-config=/etc/kata.toml"}, Env: []string{"KATA_DEBUG=1"}},
+			},
+		},
+		{
+			runtimes: map[string]string{"runc": "/nonexistent/runc"},
+			err:      "Error checking the runtime runc",
+		},
+		{
+			runtimes: map[string]string{"runc": "/bin/sh"},
+			args:     []string{"kata=--debug"},
+			err:      "--runtime-arg kata=--debug for the unknown runtime kata",
+		},
+		{
+			runtimes: map[string]string{"runc": "/bin/sh"},
+			env:      []string{"kata=KATA_DEBUG=1"},
+			err:      "--runtime-env kata=KATA_DEBUG=1 for the unknown runtime kata",
+		},
+	}
+	for _, test := range tests {
+		config := &Config{}
+		config.Runtimes = test.runtimes
+		config.RuntimeArgs = test.args
+		config.RuntimeEnv = test.env
+		runtimes, err := newRuntimes(config)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Expected newRuntimes(%v) to fail with %q, got %v", test.runtimes, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected newRuntimes(%v) error: %v", test.runtimes, err)
+		}
+		if !reflect.DeepEqual(runtimes, test.expected) {
+			t.Fatalf("Expected the runtimes %v, got %v", test.expected, runtimes)
+		}
+	}
+}
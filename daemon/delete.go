@@ -4,8 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/parsers/filters"
+	"github.com/docker/docker/pkg/timeutils"
 )
 
 type ContainerRmConfig struct {
@@ -51,7 +56,7 @@ func (daemon *Daemon) ContainerRm(name string, config *ContainerRmConfig) error
 		daemon.statsCollector.stopCollection(container)
 		if container.IsRunning() {
 			if config.ForceRemove {
-				if err := container.Kill(); err != nil {
+				if err := container.forceKill(); err != nil {
 					return fmt.Errorf("Could not kill running container, cannot remove - %v", err)
 				}
 			} else {
@@ -76,8 +81,21 @@ func (daemon *Daemon) ContainerRm(name string, config *ContainerRmConfig) error
 	return nil
 }
 
+// autoRemove removes the stopped container with its volumes, when its
+// HostConfig.AutoRemove is set.
+func (daemon *Daemon) autoRemove(container *Container) {
+	if err := daemon.ContainerRm(container.ID, &ContainerRmConfig{RemoveVolume: true}); err != nil {
+		logrus.Errorf("Error removing container %s on exit: %v", container.ID, err)
+	}
+}
+
+// DeleteVolumes removes the anonymous volumes among the given volume paths,
+// keeping the bind mounts and the named volumes.
 func (daemon *Daemon) DeleteVolumes(volumeIDs map[string]struct{}) {
 	for id := range volumeIDs {
+		if v := daemon.volumes.Get(id); v != nil && v.Name != "" {
+			continue
+		}
 		if err := daemon.volumes.Delete(id); err != nil {
 			logrus.Infof("%s", err)
 			continue
@@ -85,6 +103,128 @@ func (daemon *Daemon) DeleteVolumes(volumeIDs map[string]struct{}) {
 	}
 }
 
+// VolumeRm removes the named volume with the given name, or the anonymous
+// volume with the given ID, unless containers use it.
+func (daemon *Daemon) VolumeRm(name string) error {
+	v := daemon.volumes.GetByName(name)
+	if v == nil {
+		return fmt.Errorf("No such volume: %s", name)
+	}
+	if containers := v.Containers(); len(containers) > 0 {
+		return fmt.Errorf("Conflict: volume %s is in use by containers %v", name, containers)
+	}
+	return daemon.volumes.Delete(v.Path)
+}
+
+var acceptedVolumePruneFilterTags = map[string]struct{}{
+	"label": {},
+	"until": {},
+}
+
+var acceptedContainerPruneFilterTags = map[string]struct{}{
+	"label": {},
+	"until": {},
+}
+
+// parsePruneFilters returns the filters of a prune, checking they're
+// accepted, and the time of their until filter, zero without one.
+func parsePruneFilters(filter string, accepted map[string]struct{}) (filters.Args, time.Time, error) {
+	pruneFilters, err := filters.FromParam(filter)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	for name := range pruneFilters {
+		if _, ok := accepted[name]; !ok {
+			return nil, time.Time{}, fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
+
+	var until time.Time
+	if values := pruneFilters["until"]; len(values) > 0 {
+		if len(values) > 1 {
+			return nil, time.Time{}, fmt.Errorf("Invalid filter: more than one until filter")
+		}
+		ts, err := strconv.ParseInt(timeutils.GetTimestamp(values[0]), 10, 64)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("Invalid filter 'until=%s'", values[0])
+		}
+		until = time.Unix(ts, 0)
+	}
+	return pruneFilters, until, nil
+}
+
+// ContainersPrune removes the stopped containers, matching the filters: the
+// containers with a label, or the containers created before the timestamp
+// until. It reports the containers removed, and the size of their
+// read-write layers. Their volumes are kept.
+func (daemon *Daemon) ContainersPrune(filter string) (*types.ContainersPruneReport, error) {
+	pruneFilters, until, err := parsePruneFilters(filter, acceptedContainerPruneFilterTags)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.ContainersPruneReport{}
+	for _, container := range daemon.List() {
+		if container.IsRunning() || container.IsRestarting() {
+			continue
+		}
+		if !until.IsZero() && !container.Created.Before(until) {
+			continue
+		}
+		if !pruneFilters.MatchKVList("label", container.Config.Labels) {
+			continue
+		}
+
+		sizeRw, _ := container.GetSize()
+		// the container may have been started or removed since
+		if err := daemon.ContainerRm(container.ID, &ContainerRmConfig{}); err != nil {
+			logrus.Debugf("Error pruning container %s: %v", container.ID, err)
+			continue
+		}
+		report.ContainersDeleted = append(report.ContainersDeleted, container.ID)
+		if sizeRw > 0 {
+			report.SpaceReclaimed += uint64(sizeRw)
+		}
+	}
+	return report, nil
+}
+
+// VolumesPrune removes the volumes used by no container, matching the
+// filters: the volumes with a label, or the volumes created before the
+// timestamp until. It reports the volumes removed, and the size of the data
+// of the local volumes removed.
+func (daemon *Daemon) VolumesPrune(filter string) (*types.VolumesPruneReport, error) {
+	pruneFilters, until, err := parsePruneFilters(filter, acceptedVolumePruneFilterTags)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.VolumesPruneReport{}
+	for _, v := range daemon.volumes.List() {
+		if len(v.Containers()) > 0 {
+			continue
+		}
+		if !until.IsZero() && !v.CreatedAt.Before(until) {
+			continue
+		}
+		if !pruneFilters.MatchKVList("label", v.Labels) {
+			continue
+		}
+
+		size := volumeSize(v)
+		// a container may have started using the volume since
+		if err := daemon.volumes.Delete(v.Path); err != nil {
+			logrus.Debugf("Error pruning volume %s: %v", v.DisplayName(), err)
+			continue
+		}
+		report.VolumesDeleted = append(report.VolumesDeleted, v.DisplayName())
+		if size > 0 {
+			report.SpaceReclaimed += uint64(size)
+		}
+	}
+	return report, nil
+}
+
 func (daemon *Daemon) Rm(container *Container) (err error) {
 	return daemon.commonRm(container, false)
 }
@@ -130,6 +270,7 @@ func (daemon *Daemon) commonRm(container *Container, forceRemove bool) (err erro
 			daemon.idIndex.Delete(container.ID)
 			daemon.containers.Delete(container.ID)
 			os.RemoveAll(container.root)
+			container.SetRemoved()
 		}
 	}()
 
@@ -141,6 +282,7 @@ func (daemon *Daemon) commonRm(container *Container, forceRemove bool) (err erro
 	if err = daemon.driver.Remove(container.ID); err != nil {
 		return fmt.Errorf("Driver %s failed to remove root filesystem %s: %s", daemon.driver, container.ID, err)
 	}
+	daemon.removeRwLayerKey(container)
 
 	initID := fmt.Sprintf("%s-init", container.ID)
 	if err := daemon.driver.Remove(initID); err != nil {
@@ -158,6 +300,7 @@ func (daemon *Daemon) commonRm(container *Container, forceRemove bool) (err erro
 	selinuxFreeLxcContexts(container.ProcessLabel)
 	daemon.idIndex.Delete(container.ID)
 	daemon.containers.Delete(container.ID)
+	container.SetRemoved()
 
 	return nil
 }
@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/fscrypt"
+)
+
+// With --encrypt-rw-layers, the read-write layers of the containers are
+// encrypted with fscrypt, with a random key per container. The keys are kept
+// in the exec root of the daemon, a tmpfs on most hosts, and are removed with
+// the containers: the files written by a container can't be decrypted once
+// it's removed, nor once the host rebooted.
+
+// checkRwLayerEncryption returns an error if driver can't encrypt the
+// read-write layers of the containers in root.
+func checkRwLayerEncryption(driver graphdriver.Driver, root string) error {
+	if _, ok := driver.(graphdriver.EncryptedDriver); !ok {
+		return fmt.Errorf("The %s storage driver can't encrypt the read-write layers of the containers", driver)
+	}
+	dir, err := ioutil.TempDir(root, "encryption-check")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	key, err := fscrypt.NewKey()
+	if err != nil {
+		return err
+	}
+	if err := fscrypt.SetPolicy(dir, fscrypt.Descriptor(key)); err != nil {
+		return fmt.Errorf("The filesystem of %s can't encrypt the read-write layers of the containers: %v", root, err)
+	}
+	return nil
+}
+
+func (daemon *Daemon) rwLayerKeyPath(container *Container) string {
+	return filepath.Join(daemon.config.ExecRoot, "layer-keys", container.ID)
+}
+
+// createEncryptedRwLayer creates the read-write layer of the container on
+// top of its init layer, encrypted with a new key.
+func (daemon *Daemon) createEncryptedRwLayer(container *Container, initID string) error {
+	key, err := fscrypt.NewKey()
+	if err != nil {
+		return err
+	}
+	keyPath := daemon.rwLayerKeyPath(container)
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(keyPath, key, 0600); err != nil {
+		return err
+	}
+	descriptor, err := fscrypt.AddKey(key)
+	if err == nil {
+		err = daemon.driver.(graphdriver.EncryptedDriver).CreateEncrypted(container.ID, initID, descriptor)
+	}
+	if err != nil {
+		fscrypt.RemoveKey(fscrypt.Descriptor(key))
+		os.Remove(keyPath)
+		return fmt.Errorf("Error creating the encrypted read-write layer of container %s: %v", container.ID, err)
+	}
+	container.RwLayerEncrypted = true
+	return nil
+}
+
+// loadRwLayerKey adds the key of the encrypted read-write layer of the
+// container to the keyrings of the daemon, which its processes inherit,
+// before the layer is mounted. The key is added again after a restart of the
+// daemon.
+func (daemon *Daemon) loadRwLayerKey(container *Container) error {
+	if !container.RwLayerEncrypted {
+		return nil
+	}
+	key, err := ioutil.ReadFile(daemon.rwLayerKeyPath(container))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("The key of the encrypted read-write layer of container %s is lost, the host rebooted since it was created: the container must be removed", container.ID)
+		}
+		return err
+	}
+	_, err = fscrypt.AddKey(key)
+	return err
+}
+
+// removeRwLayerKey removes the key of the encrypted read-write layer of the
+// container, once the layer is removed.
+func (daemon *Daemon) removeRwLayerKey(container *Container) {
+	if !container.RwLayerEncrypted {
+		return
+	}
+	keyPath := daemon.rwLayerKeyPath(container)
+	key, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.Errorf("Error reading the key of the read-write layer of container %s: %v", container.ID, err)
+		}
+		return
+	}
+	if err := fscrypt.RemoveKey(fscrypt.Descriptor(key)); err != nil {
+		logrus.Errorf("Error removing the key of the read-write layer of container %s: %v", container.ID, err)
+	}
+	if err := os.Remove(keyPath); err != nil {
+		logrus.Errorf("Error removing the key of the read-write layer of container %s: %v", container.ID, err)
+	}
+}
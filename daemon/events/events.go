@@ -1,36 +1,109 @@
 package events
 
 import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/pubsub"
 )
 
-const eventsLimit = 64
+const eventsLimit = 256
 
-// Events is pubsub channel for *jsonmessage.JSONMessage
+// The types of the objects of the events.
+const (
+	ContainerEventType = "container"
+	ImageEventType     = "image"
+	NetworkEventType   = "network"
+	VolumeEventType    = "volume"
+)
+
+// Actor is the object of an event, with its attributes such as its name and
+// its labels.
+type Actor struct {
+	ID         string
+	Attributes map[string]string
+}
+
+// Message is an event. The status, the id and the from of the event are kept
+// for the clients of the API versions before 1.19.
+type Message struct {
+	jsonmessage.JSONMessage
+	Type   string
+	Action string
+	Actor  Actor
+}
+
+// Events is pubsub channel for *Message
 type Events struct {
 	mu     sync.Mutex
-	events []*jsonmessage.JSONMessage
+	events []*Message
 	pub    *pubsub.Publisher
+
+	// the last events are kept in the file at path across the restarts of
+	// the daemon, when it's set
+	path    string
+	file    *os.File
+	written int // the events in the file
+
+	// the events are published in order by a single goroutine
+	pending    []*Message
+	publishing bool
 }
 
 // New returns new *Events instance
 func New() *Events {
 	return &Events{
-		events: make([]*jsonmessage.JSONMessage, 0, eventsLimit),
+		events: make([]*Message, 0, eventsLimit),
 		pub:    pubsub.NewPublisher(100*time.Millisecond, 1024),
 	}
 }
 
-// Subscribe adds new listener to events, returns slice of 64 stored last events
-// channel in which you can expect new events in form of interface{}, so you
-// need type assertion.
-func (e *Events) Subscribe() ([]*jsonmessage.JSONMessage, chan interface{}) {
+// NewFromFile returns new *Events instance keeping its last events in the
+// file at path, and replaying the events kept there by a previous daemon.
+func NewFromFile(path string) (*Events, error) {
+	e := New()
+	e.path = path
+
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var m Message
+			if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+				// a partial event written by a daemon which crashed
+				logrus.Warnf("Ignoring an invalid event in %s: %v", path, err)
+				continue
+			}
+			e.append(&m)
+		}
+		err := scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.compact(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Subscribe adds new listener to events, returns slice of the last stored
+// events and channel in which you can expect new events in form of
+// interface{}, so you need type assertion.
+func (e *Events) Subscribe() ([]*Message, chan interface{}) {
 	e.mu.Lock()
-	current := make([]*jsonmessage.JSONMessage, len(e.events))
+	current := make([]*Message, len(e.events))
 	copy(current, e.events)
 	l := e.pub.Subscribe()
 	e.mu.Unlock()
@@ -42,25 +115,126 @@ func (e *Events) Evict(l chan interface{}) {
 	e.pub.Evict(l)
 }
 
-// Log broadcasts event to listeners. Each listener has 100 millisecond for
-// receiving event or it will be skipped.
+// Log broadcasts an event of the image id to listeners. Each listener has 100
+// millisecond for receiving event or it will be skipped.
 func (e *Events) Log(action, id, from string) {
-	go func() {
+	e.LogActor(ImageEventType, action, Actor{ID: id}, from)
+}
+
+// LogActor broadcasts an event of the given type of the actor to listeners
+// like Log. The event is stored at once, and published in the background.
+func (e *Events) LogActor(eventType, action string, actor Actor, from string) {
+	m := &Message{
+		JSONMessage: jsonmessage.JSONMessage{Status: action, ID: actor.ID, From: from, Time: time.Now().UTC().Unix()},
+		Type:        eventType,
+		Action:      action,
+		Actor:       actor,
+	}
+	e.mu.Lock()
+	e.append(m)
+	if e.file != nil {
+		if err := e.persist(m); err != nil {
+			logrus.Errorf("Error saving the event to %s: %v", e.path, err)
+		}
+	}
+	e.pending = append(e.pending, m)
+	if !e.publishing {
+		e.publishing = true
+		go e.publish()
+	}
+	e.mu.Unlock()
+}
+
+// publish publishes the pending events, in order, until there is none left.
+func (e *Events) publish() {
+	for {
 		e.mu.Lock()
-		jm := &jsonmessage.JSONMessage{Status: action, ID: id, From: from, Time: time.Now().UTC().Unix()}
-		if len(e.events) == cap(e.events) {
-			// discard oldest event
-			copy(e.events, e.events[1:])
-			e.events[len(e.events)-1] = jm
-		} else {
-			e.events = append(e.events, jm)
+		if len(e.pending) == 0 {
+			e.publishing = false
+			e.mu.Unlock()
+			return
 		}
+		m := e.pending[0]
+		e.pending = e.pending[1:]
 		e.mu.Unlock()
-		e.pub.Publish(jm)
-	}()
+		e.pub.Publish(m)
+	}
 }
 
 // SubscribersCount returns number of event listeners
 func (e *Events) SubscribersCount() int {
 	return e.pub.Len()
 }
+
+// Close closes the file of the events.
+func (e *Events) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
+
+func (e *Events) append(m *Message) {
+	if len(e.events) == cap(e.events) {
+		// discard oldest event
+		copy(e.events, e.events[1:])
+		e.events[len(e.events)-1] = m
+	} else {
+		e.events = append(e.events, m)
+	}
+}
+
+// persist appends the event to the file, which is rewritten with only the
+// last events once it holds twice as many.
+func (e *Events) persist(m *Message) error {
+	if e.written >= 2*eventsLimit {
+		return e.compact()
+	}
+	if err := json.NewEncoder(e.file).Encode(m); err != nil {
+		return err
+	}
+	e.written++
+	return nil
+}
+
+// compact rewrites the file with the last events, and opens it to append
+// the next ones.
+func (e *Events) compact() error {
+	if e.file != nil {
+		e.file.Close()
+		e.file = nil
+	}
+
+	tmp, err := os.OpenFile(filepath.Join(filepath.Dir(e.path), "."+filepath.Base(e.path)+".tmp"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	for _, m := range e.events {
+		if err := enc.Encode(m); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), e.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	f, err := os.OpenFile(e.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	e.file = f
+	e.written = len(e.events)
+	return nil
+}
@@ -2,10 +2,11 @@ package events
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
-
-	"github.com/docker/docker/pkg/jsonmessage"
 )
 
 func TestEventsLog(t *testing.T) {
@@ -21,7 +22,7 @@ func TestEventsLog(t *testing.T) {
 	e.Log("test", "cont", "image")
 	select {
 	case msg := <-l1:
-		jmsg, ok := msg.(*jsonmessage.JSONMessage)
+		jmsg, ok := msg.(*Message)
 		if !ok {
 			t.Fatalf("Unexpected type %T", msg)
 		}
@@ -42,7 +43,7 @@ func TestEventsLog(t *testing.T) {
 	}
 	select {
 	case msg := <-l2:
-		jmsg, ok := msg.(*jsonmessage.JSONMessage)
+		jmsg, ok := msg.(*Message)
 		if !ok {
 			t.Fatalf("Unexpected type %T", msg)
 		}
@@ -103,10 +104,10 @@ func TestLogEvents(t *testing.T) {
 		t.Fatalf("Must be %d events, got %d", eventsLimit, len(e.events))
 	}
 
-	var msgs []*jsonmessage.JSONMessage
+	var msgs []*Message
 	for len(msgs) < 10 {
 		m := <-l
-		jm, ok := (m).(*jsonmessage.JSONMessage)
+		jm, ok := (m).(*Message)
 		if !ok {
 			t.Fatalf("Unexpected type %T", m)
 		}
@@ -120,16 +121,61 @@ func TestLogEvents(t *testing.T) {
 		t.Fatalf("First action is %s, must be action_16", first.Status)
 	}
 	last := current[len(current)-1]
-	if last.Status != "action_79" {
-		t.Fatalf("Last action is %s, must be action_79", last.Status)
+	if expected := fmt.Sprintf("action_%d", eventsLimit+15); last.Status != expected {
+		t.Fatalf("Last action is %s, must be %s", last.Status, expected)
 	}
 
 	firstC := msgs[0]
-	if firstC.Status != "action_80" {
-		t.Fatalf("First action is %s, must be action_80", firstC.Status)
+	if expected := fmt.Sprintf("action_%d", eventsLimit+16); firstC.Status != expected {
+		t.Fatalf("First action is %s, must be %s", firstC.Status, expected)
 	}
 	lastC := msgs[len(msgs)-1]
-	if lastC.Status != "action_89" {
-		t.Fatalf("Last action is %s, must be action_89", lastC.Status)
+	if expected := fmt.Sprintf("action_%d", eventsLimit+25); lastC.Status != expected {
+		t.Fatalf("Last action is %s, must be %s", lastC.Status, expected)
+	}
+}
+
+func TestEventsFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "events-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.json")
+
+	e, err := NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, l := e.Subscribe()
+	for i := 0; i < 3*eventsLimit; i++ {
+		e.LogActor(ContainerEventType, fmt.Sprintf("action_%d", i), Actor{ID: "cont", Attributes: map[string]string{"com.example": "label"}}, "image")
+		// the events are logged in order
+		<-l
+	}
+	e.Evict(l)
+	e.Close()
+
+	e, err = NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	current, l := e.Subscribe()
+	defer e.Evict(l)
+	if len(current) != eventsLimit {
+		t.Fatalf("Must be %d events, got %d", eventsLimit, len(current))
+	}
+	if expected := fmt.Sprintf("action_%d", 2*eventsLimit); current[0].Status != expected {
+		t.Fatalf("First action is %s, must be %s", current[0].Status, expected)
+	}
+	if expected := fmt.Sprintf("action_%d", 3*eventsLimit-1); current[len(current)-1].Status != expected {
+		t.Fatalf("Last action is %s, must be %s", current[len(current)-1].Status, expected)
+	}
+	if current[0].Type != ContainerEventType || current[0].Action != current[0].Status || current[0].Actor.ID != "cont" {
+		t.Fatalf("Must keep the type and the actor of the events, got %+v", current[0])
+	}
+	if current[0].Actor.Attributes["com.example"] != "label" {
+		t.Fatalf("Must keep the attributes of the events, got %v", current[0].Actor.Attributes)
 	}
 }
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
@@ -13,6 +15,7 @@ import (
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
 )
 
 type execConfig struct {
@@ -26,6 +29,32 @@ type execConfig struct {
 	OpenStderr bool
 	OpenStdout bool
 	Container  *Container
+
+	pid    int  // the pid of the process, once it started
+	killed bool // the process is killed as soon as it starts
+}
+
+// setPid records the pid of the process of the exec once it started, and
+// kills it if the exec was killed meanwhile.
+func (execConfig *execConfig) setPid(pid int) {
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	execConfig.pid = pid
+	if execConfig.killed {
+		syscall.Kill(pid, syscall.SIGKILL)
+	}
+}
+
+// kill kills the process of the exec, at once if it started, or else as soon
+// as it starts.
+func (execConfig *execConfig) kill() error {
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	execConfig.killed = true
+	if execConfig.pid == 0 {
+		return nil
+	}
+	return syscall.Kill(execConfig.pid, syscall.SIGKILL)
 }
 
 type execStore struct {
@@ -103,7 +132,7 @@ func (d *Daemon) getActiveContainer(name string) (*Container, error) {
 		return nil, fmt.Errorf("Container %s is not running", name)
 	}
 	if container.IsPaused() {
-		return nil, fmt.Errorf("Container %s is paused, unpause the container before exec", name)
+		return nil, errContainerPaused(container, "exec")
 	}
 	return container, nil
 }
@@ -130,6 +159,10 @@ func (d *Daemon) ContainerExecCreate(config *runconfig.ExecConfig) (string, erro
 		User:       config.User,
 		Privileged: config.Privileged,
 	}
+	if len(config.Env) > 0 {
+		// the variables of the command override those of the container
+		processConfig.Env = utils.ReplaceOrAppendEnvValues(container.command.ProcessConfig.Env, config.Env)
+	}
 
 	execConfig := &execConfig{
 		ID:            stringid.GenerateRandomID(),
@@ -142,7 +175,9 @@ func (d *Daemon) ContainerExecCreate(config *runconfig.ExecConfig) (string, erro
 		Running:       false,
 	}
 
-	container.LogEvent("exec_create: " + execConfig.ProcessConfig.Entrypoint + " " + strings.Join(execConfig.ProcessConfig.Arguments, " "))
+	container.logEventWithAttributes("exec_create: "+execConfig.ProcessConfig.Entrypoint+" "+strings.Join(execConfig.ProcessConfig.Arguments, " "), map[string]string{
+		"execID": execConfig.ID,
+	})
 
 	d.registerExecCommand(execConfig)
 
@@ -161,6 +196,10 @@ func (d *Daemon) ContainerExecStart(execName string, stdin io.ReadCloser, stdout
 	if err != nil {
 		return err
 	}
+	// the container may have been paused since the exec was created
+	if execConfig.Container.IsPaused() {
+		return errContainerPaused(execConfig.Container, "exec")
+	}
 
 	func() {
 		execConfig.Lock()
@@ -177,7 +216,9 @@ func (d *Daemon) ContainerExecStart(execName string, stdin io.ReadCloser, stdout
 	logrus.Debugf("starting exec command %s in container %s", execConfig.ID, execConfig.Container.ID)
 	container := execConfig.Container
 
-	container.LogEvent("exec_start: " + execConfig.ProcessConfig.Entrypoint + " " + strings.Join(execConfig.ProcessConfig.Arguments, " "))
+	container.logEventWithAttributes("exec_start: "+execConfig.ProcessConfig.Entrypoint+" "+strings.Join(execConfig.ProcessConfig.Arguments, " "), map[string]string{
+		"execID": execConfig.ID,
+	})
 
 	if execConfig.OpenStdin {
 		r, w := io.Pipe()
@@ -241,6 +282,10 @@ func (d *Daemon) Exec(c *Container, execConfig *execConfig, pipes *execdriver.Pi
 
 	execConfig.ExitCode = exitStatus
 	execConfig.Running = false
+	c.logEventWithAttributes("exec_die", map[string]string{
+		"execID":   execConfig.ID,
+		"exitCode": strconv.Itoa(exitStatus),
+	})
 
 	return exitStatus, err
 }
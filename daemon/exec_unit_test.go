@@ -0,0 +1,34 @@
+package daemon
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestExecConfigKill(t *testing.T) {
+	for _, killFirst := range []bool{true, false} {
+		cmd := exec.Command("sleep", "60")
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		e := &execConfig{}
+		if killFirst {
+			// killed before it started, the process is killed when it starts
+			if err := e.kill(); err != nil {
+				t.Fatal(err)
+			}
+			e.setPid(cmd.Process.Pid)
+		} else {
+			e.setPid(cmd.Process.Pid)
+			if err := e.kill(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := cmd.Wait()
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok || exitErr.Sys().(syscall.WaitStatus).Signal() != syscall.SIGKILL {
+			t.Fatalf("Expected the process to be killed (killed first: %v), got %v", killFirst, err)
+		}
+	}
+}
@@ -21,6 +21,7 @@ var (
 	ErrWaitTimeoutReached      = errors.New("Wait timeout reached")
 	ErrDriverAlreadyRegistered = errors.New("A driver already registered this docker init function")
 	ErrDriverNotFound          = errors.New("The requested docker init has not been found")
+	ErrNotRestorable           = errors.New("The container can't be restored by the driver")
 )
 
 type StartCallback func(*ProcessConfig, int)
@@ -54,6 +55,9 @@ type ExitStatus struct {
 
 type Driver interface {
 	Run(c *Command, pipes *Pipes, startCallback StartCallback) (ExitStatus, error) // Run executes the process and blocks until the process exits and returns the exit code
+	// Restore re-attaches to the process of a container left running by a
+	// previous daemon, and blocks like Run until the process exits
+	Restore(c *Command, pipes *Pipes, restoreCallback StartCallback) (ExitStatus, error)
 	// Exec executes the process in an existing container, blocks until the process exits and returns the exit code
 	Exec(c *Command, processConfig *ProcessConfig, pipes *Pipes, startCallback StartCallback) (int, error)
 	Kill(c *Command, sig int) error
@@ -65,6 +69,9 @@ type Driver interface {
 	Terminate(c *Command) error                   // kill it with fire
 	Clean(id string) error                        // clean all traces of container exec
 	Stats(id string) (*ResourceStats, error)      // Get resource stats for a running container
+	// RuntimeState returns the state of the running container in the
+	// driver, such as its cgroups and the security profiles applied to it
+	RuntimeState(c *Command) (*RuntimeState, error)
 }
 
 // Network settings of the container
@@ -118,11 +125,20 @@ type Resources struct {
 	OomKillDisable bool             `json:"oom_kill_disable"`
 }
 
+// RuntimeState is the state of a running container in the driver.
+type RuntimeState struct {
+	CgroupPaths     map[string]string // the paths of the cgroups of the container by subsystem
+	Resources       Resources         // the limits in effect in the cgroups of the container
+	AppArmorProfile string            // the AppArmor profile applied, if any
+	SeccompProfile  string            // "default", "unconfined" or "custom"
+}
+
 type ResourceStats struct {
 	*libcontainer.Stats
 	Read        time.Time `json:"read"`
 	MemoryLimit int64     `json:"memory_limit"`
 	SystemUsage uint64    `json:"system_usage"`
+	Pids        uint64    `json:"pids"`
 }
 
 type Mount struct {
@@ -167,11 +183,22 @@ type Command struct {
 	AutoCreatedDevices []*configs.Device `json:"autocreated_devices"`
 	CapAdd             []string          `json:"cap_add"`
 	CapDrop            []string          `json:"cap_drop"`
-	ContainerPid       int               `json:"container_pid"`  // the pid for the process inside a container
-	ProcessConfig      ProcessConfig     `json:"process_config"` // Describes the init process of the container.
+	DefaultCapAdd      []string          `json:"default_cap_add"`  // the capabilities added to the default set of the daemon, before CapAdd
+	DefaultCapDrop     []string          `json:"default_cap_drop"` // the capabilities dropped from the default set of the daemon, before CapDrop
+	ContainerPid       int               `json:"container_pid"`    // the pid for the process inside a container
+	ProcessConfig      ProcessConfig     `json:"process_config"`   // Describes the init process of the container.
 	ProcessLabel       string            `json:"process_label"`
 	MountLabel         string            `json:"mount_label"`
 	LxcConfig          []string          `json:"lxc_config"`
 	AppArmorProfile    string            `json:"apparmor_profile"`
-	CgroupParent       string            `json:"cgroup_parent"` // The parent cgroup for this command.
+	SeccompProfile     string            `json:"seccomp_profile"` // the JSON profile, "unconfined", or "" for the default profile
+	CgroupParent       string            `json:"cgroup_parent"`   // The parent cgroup for this command.
+	Runtime            *Runtime          `json:"runtime"`         // the OCI runtime running the container, or nil for libcontainer
+}
+
+// Runtime is an OCI runtime running containers instead of the exec driver.
+type Runtime struct {
+	Path string   `json:"path"`
+	Args []string `json:"args"` // the arguments given to the runtime before its commands
+	Env  []string `json:"env"`  // the environment of the runtime, on top of the one of the daemon
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/docker/docker/daemon/execdriver/native/template"
 	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups"
 	"github.com/docker/libcontainer/cgroups/fs"
 	"github.com/docker/libcontainer/configs"
 )
@@ -62,8 +63,8 @@ func SetupCgroups(container *configs.Config, c *Command) error {
 	return nil
 }
 
-// Returns the network statistics for the network interfaces represented by the NetworkRuntimeInfo.
-func getNetworkInterfaceStats(interfaceName string) (*libcontainer.NetworkInterface, error) {
+// NetworkInterfaceStats returns the statistics of the network interface of the host interfaceName.
+func NetworkInterfaceStats(interfaceName string) (*libcontainer.NetworkInterface, error) {
 	out := &libcontainer.NetworkInterface{Name: interfaceName}
 	// This can happen if the network runtime information is missing - possible if the
 	// container was created by an old version of libcontainer.
@@ -135,6 +136,10 @@ func Stats(containerDir string, containerMemoryLimit int64, machineMemory int64)
 		return nil, err
 	}
 	stats := &libcontainer.Stats{CgroupStats: cstats}
+	pids, err := cgroups.ReadProcsFile(state.CgroupPaths["devices"])
+	if err != nil {
+		return nil, err
+	}
 	// if the container does not have any memory limit specified set the
 	// limit to the machines memory
 	memoryLimit := containerMemoryLimit
@@ -144,7 +149,7 @@ func Stats(containerDir string, containerMemoryLimit int64, machineMemory int64)
 	for _, iface := range state.Networks {
 		switch iface.Type {
 		case "veth":
-			istats, err := getNetworkInterfaceStats(iface.HostInterfaceName)
+			istats, err := NetworkInterfaceStats(iface.HostInterfaceName)
 			if err != nil {
 				return nil, err
 			}
@@ -155,5 +160,6 @@ func Stats(containerDir string, containerMemoryLimit int64, machineMemory int64)
 		Stats:       stats,
 		Read:        now,
 		MemoryLimit: memoryLimit,
+		Pids:        uint64(len(pids)),
 	}, nil
 }
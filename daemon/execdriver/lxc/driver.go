@@ -501,6 +501,12 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 	return err
 }
 
+// Restore isn't supported, the containers are killed when the daemon
+// restarts.
+func (d *driver) Restore(c *execdriver.Command, pipes *execdriver.Pipes, restoreCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrNotRestorable
+}
+
 func (d *driver) Terminate(c *execdriver.Command) error {
 	return KillLxc(c.ID, 9)
 }
@@ -806,6 +812,10 @@ func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessCo
 	return -1, ErrExec
 }
 
+func (d *driver) RuntimeState(c *execdriver.Command) (*execdriver.RuntimeState, error) {
+	return nil, fmt.Errorf("The runtime state of the containers is not supported by the %s driver", DriverName)
+}
+
 func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
 	if _, ok := d.activeContainers[id]; !ok {
 		return nil, fmt.Errorf("%s is not a key in active containers", id)
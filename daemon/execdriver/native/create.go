@@ -124,20 +124,11 @@ func (d *driver) createNetwork(container *configs.Config, c *execdriver.Command)
 	}
 
 	if c.Network.ContainerID != "" {
-		d.Lock()
-		active := d.activeContainers[c.Network.ContainerID]
-		d.Unlock()
-
-		if active == nil {
-			return fmt.Errorf("%s is not a valid running container to join", c.Network.ContainerID)
-		}
-
-		state, err := active.State()
+		path, err := d.namespacePath(c.Network.ContainerID, configs.NEWNET)
 		if err != nil {
 			return err
 		}
-
-		container.Namespaces.Add(configs.NEWNET, state.NamespacePaths[configs.NEWNET])
+		container.Namespaces.Add(configs.NEWNET, path)
 	}
 
 	return nil
@@ -150,24 +141,42 @@ func (d *driver) createIpc(container *configs.Config, c *execdriver.Command) err
 	}
 
 	if c.Ipc.ContainerID != "" {
-		d.Lock()
-		active := d.activeContainers[c.Ipc.ContainerID]
-		d.Unlock()
-
-		if active == nil {
-			return fmt.Errorf("%s is not a valid running container to join", c.Ipc.ContainerID)
-		}
-
-		state, err := active.State()
+		path, err := d.namespacePath(c.Ipc.ContainerID, configs.NEWIPC)
 		if err != nil {
 			return err
 		}
-		container.Namespaces.Add(configs.NEWIPC, state.NamespacePaths[configs.NEWIPC])
+		container.Namespaces.Add(configs.NEWIPC, path)
 	}
 
 	return nil
 }
 
+// namespacePath returns the path of the namespace ns of the running
+// container id, to join it.
+func (d *driver) namespacePath(id string, ns configs.NamespaceType) (string, error) {
+	d.Lock()
+	active := d.activeContainers[id]
+	oci := d.ociContainers[id]
+	var pid int
+	if oci != nil {
+		pid = oci.pid
+	}
+	d.Unlock()
+
+	if pid != 0 {
+		n := configs.Namespace{Type: ns}
+		return n.GetPath(pid), nil
+	}
+	if active == nil {
+		return "", fmt.Errorf("%s is not a valid running container to join", id)
+	}
+	state, err := active.State()
+	if err != nil {
+		return "", err
+	}
+	return state.NamespacePaths[ns], nil
+}
+
 func (d *driver) createPid(container *configs.Config, c *execdriver.Command) error {
 	if c.Pid.HostPid {
 		container.Namespaces.Remove(configs.NEWPID)
@@ -204,8 +213,14 @@ func (d *driver) setPrivileged(container *configs.Config) (err error) {
 	return nil
 }
 
+// setCapabilities sets the capabilities of the container: the default set of
+// the daemon, then those added and dropped for the container.
 func (d *driver) setCapabilities(container *configs.Config, c *execdriver.Command) (err error) {
-	container.Capabilities, err = execdriver.TweakCapabilities(container.Capabilities, c.CapAdd, c.CapDrop)
+	caps, err := execdriver.TweakCapabilities(container.Capabilities, c.DefaultCapAdd, c.DefaultCapDrop)
+	if err != nil {
+		return err
+	}
+	container.Capabilities, err = execdriver.TweakCapabilities(caps, c.CapAdd, c.CapDrop)
 	return err
 }
 
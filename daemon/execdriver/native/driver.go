@@ -5,6 +5,7 @@ package native
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/seccomp"
 	sysinfo "github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/libcontainer"
@@ -36,12 +38,16 @@ type driver struct {
 	root             string
 	initPath         string
 	activeContainers map[string]libcontainer.Container
+	ociContainers    map[string]*ociContainer // the containers run by OCI runtimes
 	machineMemory    int64
 	factory          libcontainer.Factory
+	systemd          bool // the cgroups are managed by systemd
+	liveRestore      bool // the containers are run by shims
+	seccompEnabled   bool // the kernel filters syscalls with seccomp
 	sync.Mutex
 }
 
-func NewDriver(root, initPath string, options []string) (*driver, error) {
+func NewDriver(root, initPath string, options []string, liveRestore bool) (*driver, error) {
 	meminfo, err := sysinfo.ReadMemInfo()
 	if err != nil {
 		return nil, err
@@ -58,10 +64,7 @@ func NewDriver(root, initPath string, options []string) (*driver, error) {
 	// choose cgroup manager
 	// this makes sure there are no breaking changes to people
 	// who upgrade from versions without native.cgroupdriver opt
-	cgm := libcontainer.Cgroupfs
-	if systemd.UseSystemd() {
-		cgm = libcontainer.SystemdCgroups
-	}
+	useSystemd := systemd.UseSystemd()
 
 	// parse the options
 	for _, option := range options {
@@ -76,13 +79,13 @@ func NewDriver(root, initPath string, options []string) (*driver, error) {
 			switch val {
 			case "systemd":
 				if systemd.UseSystemd() {
-					cgm = libcontainer.SystemdCgroups
+					useSystemd = true
 				} else {
 					// warn them that they chose the wrong driver
 					logrus.Warn("You cannot use systemd as native.cgroupdriver, using cgroupfs instead")
 				}
 			case "cgroupfs":
-				cgm = libcontainer.Cgroupfs
+				useSystemd = false
 			default:
 				return nil, fmt.Errorf("Unknown native.cgroupdriver given %q. try cgroupfs or systemd", val)
 			}
@@ -91,24 +94,70 @@ func NewDriver(root, initPath string, options []string) (*driver, error) {
 		}
 	}
 
-	logrus.Debugf("Using %v as native.cgroupdriver", cgm)
-
-	f, err := libcontainer.New(
-		root,
-		cgm,
-		libcontainer.InitPath(reexec.Self(), DriverName),
-	)
+	f, err := newFactory(root, useSystemd)
 	if err != nil {
 		return nil, err
 	}
 
-	return &driver{
+	d := &driver{
 		root:             root,
 		initPath:         initPath,
 		activeContainers: make(map[string]libcontainer.Container),
+		ociContainers:    make(map[string]*ociContainer),
 		machineMemory:    meminfo.MemTotal,
 		factory:          f,
-	}, nil
+		systemd:          useSystemd,
+		liveRestore:      liveRestore,
+		seccompEnabled:   seccomp.IsEnabled(),
+	}
+	d.loadActiveContainers()
+	return d, nil
+}
+
+// loadActiveContainers loads the containers left running in the root by a
+// previous daemon, for the containers joining their namespaces to find them
+// before they're restored or terminated.
+func (d *driver) loadActiveContainers() {
+	fis, err := ioutil.ReadDir(d.root)
+	if err != nil {
+		logrus.Warnf("Error reading the containers of %s: %v", d.root, err)
+		return
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		// the shims and the bundles have no libcontainer state
+		cont, err := d.factory.Load(fi.Name())
+		if err != nil {
+			continue
+		}
+		state, err := cont.State()
+		if err != nil {
+			continue
+		}
+		// the pid of a container which exited may have been reused
+		if startTime, err := system.GetProcessStartTime(state.InitProcessPid); err != nil || startTime != state.InitProcessStartTime {
+			continue
+		}
+		logrus.Debugf("Loaded the running container %s", fi.Name())
+		d.activeContainers[fi.Name()] = cont
+	}
+}
+
+// newFactory returns the libcontainer factory of the containers in root.
+func newFactory(root string, useSystemd bool) (libcontainer.Factory, error) {
+	cgm := libcontainer.Cgroupfs
+	if useSystemd {
+		cgm = libcontainer.SystemdCgroups
+	}
+	logrus.Debugf("Using %v as native.cgroupdriver", cgm)
+
+	return libcontainer.New(
+		root,
+		cgm,
+		libcontainer.InitPath(reexec.Self(), DriverName),
+	)
 }
 
 type execOutput struct {
@@ -122,6 +171,17 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	if err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
+	if c.Runtime != nil {
+		return d.runOCI(c, container, pipes, startCallback)
+	}
+	profile, err := d.seccompProfile(c)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	if d.liveRestore {
+		return d.runShim(c, container, profile, pipes, startCallback)
+	}
 
 	p := &libcontainer.Process{
 		Args: append([]string{c.ProcessConfig.Entrypoint}, c.ProcessConfig.Arguments...),
@@ -146,7 +206,7 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		d.cleanContainer(c.ID)
 	}()
 
-	if err := cont.Start(p); err != nil {
+	if err := startProcess(cont.Start, p, container, profile); err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 
@@ -245,6 +305,9 @@ func waitInPIDHost(p *libcontainer.Process, c libcontainer.Container) func() (*o
 }
 
 func (d *driver) Kill(c *execdriver.Command, sig int) error {
+	if oci := d.ociContainer(c.ID); oci != nil {
+		return oci.kill(sig)
+	}
 	d.Lock()
 	active := d.activeContainers[c.ID]
 	d.Unlock()
@@ -259,6 +322,10 @@ func (d *driver) Kill(c *execdriver.Command, sig int) error {
 }
 
 func (d *driver) Pause(c *execdriver.Command) error {
+	if oci := d.ociContainer(c.ID); oci != nil {
+		_, err := oci.run("pause", c.ID)
+		return err
+	}
 	active := d.activeContainers[c.ID]
 	if active == nil {
 		return fmt.Errorf("active container for %s does not exist", c.ID)
@@ -267,6 +334,10 @@ func (d *driver) Pause(c *execdriver.Command) error {
 }
 
 func (d *driver) Unpause(c *execdriver.Command) error {
+	if oci := d.ociContainer(c.ID); oci != nil {
+		_, err := oci.run("resume", c.ID)
+		return err
+	}
 	active := d.activeContainers[c.ID]
 	if active == nil {
 		return fmt.Errorf("active container for %s does not exist", c.ID)
@@ -275,6 +346,9 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 }
 
 func (d *driver) Terminate(c *execdriver.Command) error {
+	if ok, err := d.terminateOCI(c.ID); ok {
+		return err
+	}
 	defer d.cleanContainer(c.ID)
 	container, err := d.factory.Load(c.ID)
 	if err != nil {
@@ -309,6 +383,9 @@ func (d *driver) Name() string {
 }
 
 func (d *driver) GetPidsForContainer(id string) ([]int, error) {
+	if oci := d.ociContainer(id); oci != nil {
+		return oci.processes()
+	}
 	d.Lock()
 	active := d.activeContainers[id]
 	d.Unlock()
@@ -323,6 +400,9 @@ func (d *driver) cleanContainer(id string) error {
 	d.Lock()
 	delete(d.activeContainers, id)
 	d.Unlock()
+	if err := os.RemoveAll(d.shimDir(id)); err != nil {
+		return err
+	}
 	return os.RemoveAll(filepath.Join(d.root, id))
 }
 
@@ -335,6 +415,9 @@ func (d *driver) Clean(id string) error {
 }
 
 func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
+	if oci := d.ociContainer(id); oci != nil {
+		return oci.stats(d.machineMemory)
+	}
 	c := d.activeContainers[id]
 	if c == nil {
 		return nil, execdriver.ErrNotRunning
@@ -344,6 +427,18 @@ func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
 	if err != nil {
 		return nil, err
 	}
+	pids, err := c.Processes()
+	if err != nil {
+		return nil, err
+	}
+	// the interfaces are named as in the container, not as on the host
+	for _, n := range c.Config().Networks {
+		for _, iface := range stats.Interfaces {
+			if n.HostInterfaceName != "" && iface.Name == n.HostInterfaceName {
+				iface.Name = n.Name
+			}
+		}
+	}
 	memoryLimit := c.Config().Cgroups.Memory
 	// if the container does not have any memory limit specified set the
 	// limit to the machines memory
@@ -354,6 +449,7 @@ func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
 		Stats:       stats,
 		Read:        now,
 		MemoryLimit: memoryLimit,
+		Pids:        uint64(len(pids)),
 	}, nil
 }
 
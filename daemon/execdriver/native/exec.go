@@ -15,14 +15,22 @@ import (
 )
 
 func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessConfig, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (int, error) {
+	if oci := d.ociContainer(c.ID); oci != nil {
+		return oci.exec(c, processConfig, pipes, startCallback)
+	}
 	active := d.activeContainers[c.ID]
 	if active == nil {
 		return -1, fmt.Errorf("No active container exists with ID %s", c.ID)
 	}
 
+	env := c.ProcessConfig.Env
+	if len(processConfig.Env) > 0 {
+		env = processConfig.Env
+	}
+
 	p := &libcontainer.Process{
 		Args: append([]string{processConfig.Entrypoint}, processConfig.Arguments...),
-		Env:  c.ProcessConfig.Env,
+		Env:  env,
 		Cwd:  c.WorkingDir,
 		User: processConfig.User,
 	}
@@ -36,7 +44,12 @@ func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessCo
 		return -1, err
 	}
 
-	if err := active.Start(p); err != nil {
+	// the process has the seccomp profile of the container
+	profile, err := d.seccompProfile(c)
+	if err != nil {
+		return -1, err
+	}
+	if err := startProcess(active.Start, p, &config, profile); err != nil {
 		return -1, err
 	}
 
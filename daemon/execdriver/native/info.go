@@ -11,6 +11,9 @@ type info struct {
 // pid file for a container.  If the file exists then the
 // container is currently running
 func (i *info) IsRunning() bool {
+	i.driver.Lock()
+	defer i.driver.Unlock()
 	_, ok := i.driver.activeContainers[i.ID]
-	return ok
+	_, oci := i.driver.ociContainers[i.ID]
+	return ok || oci
 }
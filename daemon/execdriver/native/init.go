@@ -3,16 +3,46 @@
 package native
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"runtime"
+	"syscall"
 
+	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/seccomp"
 	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/system"
+	"github.com/docker/libcontainer/user"
+	"github.com/syndtr/gocapability/capability"
 )
 
+// seccompExecName is the name of the reexec of the driver wrapping the process
+// of a container whose syscalls are filtered. The wrapper is the daemon binary,
+// opened by the driver and given to the container on seccompExecFd, and reads
+// its seccompExecConfig on seccompFd.
+const (
+	seccompExecName = "/proc/self/fd/4"
+	seccompFd       = 3
+	seccompExecFd   = 4
+)
+
+// seccompExecConfig is the configuration of the seccomp wrapper: the profile
+// to install, and the user, capabilities and groups of the process of the
+// container, which the wrapper switches to once the filter is installed.
+type seccompExecConfig struct {
+	Profile          json.RawMessage `json:"profile"`
+	User             string          `json:"user"`
+	Capabilities     []string        `json:"capabilities"`
+	AdditionalGroups []int           `json:"additional_groups"`
+	Home             bool            `json:"home"`
+}
+
 func init() {
 	reexec.Register(DriverName, initializer)
+	reexec.Register(seccompExecName, seccompExec)
 }
 
 func fatal(err error) {
@@ -39,6 +69,120 @@ func initializer() {
 	panic("unreachable")
 }
 
+// seccompExec is the wrapper of the process of a container whose syscalls are
+// filtered. libcontainer executes it as root, with CAP_SYS_ADMIN to install
+// the filter without setting no_new_privs, which would break the setuid
+// programs of the container. It then drops the privileges like libcontainer
+// would have and executes the process, so the profile applies to all the
+// syscalls of the process but not to those of the setup of the container.
+// The thread is locked, so the filter of the thread is the filter of the
+// process it executes.
+func seccompExec() {
+	runtime.GOMAXPROCS(1)
+	runtime.LockOSThread()
+	if err := setupSeccompExec(os.Args[1:]); err != nil {
+		fatal(err)
+	}
+
+	panic("unreachable")
+}
+
+func setupSeccompExec(args []string) error {
+	f := os.NewFile(seccompFd, "seccomp")
+	var config seccompExecConfig
+	err := json.NewDecoder(f).Decode(&config)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("reading the seccomp configuration: %v", err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no process to execute")
+	}
+	p, err := seccomp.LoadProfile(config.Profile)
+	if err != nil {
+		return err
+	}
+	filter, err := p.Compile()
+	if err != nil {
+		return err
+	}
+	caps, err := capabilityWhitelist(config.Capabilities)
+	if err != nil {
+		return err
+	}
+
+	passwdPath, err := user.GetPasswdPath()
+	if err != nil {
+		return err
+	}
+	groupPath, err := user.GetGroupPath()
+	if err != nil {
+		return err
+	}
+	execUser, err := user.GetExecUserPath(config.User, &user.ExecUser{Home: "/"}, passwdPath, groupPath)
+	if err != nil {
+		return err
+	}
+	if !config.Home {
+		if err := os.Setenv("HOME", execUser.Home); err != nil {
+			return err
+		}
+	}
+	name, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := seccomp.InstallFilter(filter); err != nil {
+		return fmt.Errorf("installing the seccomp filter: %v", err)
+	}
+	pid, err := capability.NewPid(os.Getpid())
+	if err != nil {
+		return err
+	}
+	pid.Clear(capability.BOUNDS)
+	pid.Set(capability.BOUNDS, caps...)
+	if err := pid.Apply(capability.BOUNDS); err != nil {
+		return err
+	}
+	if err := system.SetKeepCaps(); err != nil {
+		return err
+	}
+	if err := syscall.Setgroups(append(execUser.Sgids, config.AdditionalGroups...)); err != nil {
+		return err
+	}
+	if err := system.Setgid(execUser.Gid); err != nil {
+		return err
+	}
+	if err := system.Setuid(execUser.Uid); err != nil {
+		return err
+	}
+	if err := system.ClearKeepCaps(); err != nil {
+		return err
+	}
+	pid.Clear(capability.CAPS | capability.BOUNDS)
+	pid.Set(capability.CAPS|capability.BOUNDS, caps...)
+	if err := pid.Apply(capability.CAPS | capability.BOUNDS); err != nil {
+		return err
+	}
+
+	syscall.CloseOnExec(seccompExecFd)
+	return syscall.Exec(name, args, os.Environ())
+}
+
+// capabilityWhitelist returns the capabilities of their names, such as CHOWN.
+func capabilityWhitelist(names []string) ([]capability.Cap, error) {
+	var caps []capability.Cap
+	for _, name := range names {
+		c := execdriver.GetCapability(name)
+		if c == nil {
+			return nil, fmt.Errorf("unknown capability %q", name)
+		}
+		caps = append(caps, c.Value)
+	}
+	return caps, nil
+}
+
 func writeError(err error) {
 	fmt.Fprint(os.Stderr, err)
 	os.Exit(1)
@@ -0,0 +1,372 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/seccomp"
+	"github.com/docker/docker/pkg/symlink"
+	"github.com/docker/docker/pkg/ulimit"
+	"github.com/docker/libcontainer/configs"
+	"github.com/docker/libcontainer/user"
+)
+
+// ociVersion is the version of the OCI runtime specification of the
+// configuration of the bundles.
+const ociVersion = "1.0.0-rc1"
+
+// ociSpec is the configuration of an OCI bundle, config.json in the bundle.
+type ociSpec struct {
+	OCIVersion string      `json:"ociVersion"`
+	Platform   ociPlatform `json:"platform"`
+	Process    ociProcess  `json:"process"`
+	Root       ociRoot     `json:"root"`
+	Hostname   string      `json:"hostname,omitempty"`
+	Mounts     []ociMount  `json:"mounts"`
+	Hooks      ociHooks    `json:"hooks"`
+	Linux      ociLinux    `json:"linux"`
+}
+
+type ociPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+type ociProcess struct {
+	Terminal        bool        `json:"terminal"`
+	User            ociUser     `json:"user"`
+	Args            []string    `json:"args"`
+	Env             []string    `json:"env,omitempty"`
+	Cwd             string      `json:"cwd"`
+	Capabilities    []string    `json:"capabilities,omitempty"`
+	Rlimits         []ociRlimit `json:"rlimits,omitempty"`
+	ApparmorProfile string      `json:"apparmorProfile,omitempty"`
+	SelinuxLabel    string      `json:"selinuxLabel,omitempty"`
+}
+
+type ociUser struct {
+	UID            uint32   `json:"uid"`
+	GID            uint32   `json:"gid"`
+	AdditionalGids []uint32 `json:"additionalGids,omitempty"`
+}
+
+type ociRlimit struct {
+	Type string `json:"type"`
+	Hard uint64 `json:"hard"`
+	Soft uint64 `json:"soft"`
+}
+
+type ociRoot struct {
+	Path     string `json:"path"`
+	Readonly bool   `json:"readonly"`
+}
+
+type ociMount struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type"`
+	Source      string   `json:"source"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type ociHooks struct {
+	Prestart []ociHook `json:"prestart,omitempty"`
+}
+
+// ociHook is run by the runtime with the state of the container on its
+// standard input.
+type ociHook struct {
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
+}
+
+type ociLinux struct {
+	Namespaces    []ociNamespace   `json:"namespaces"`
+	Devices       []ociDevice      `json:"devices,omitempty"`
+	Resources     *ociResources    `json:"resources,omitempty"`
+	CgroupsPath   string           `json:"cgroupsPath,omitempty"`
+	MaskedPaths   []string         `json:"maskedPaths,omitempty"`
+	ReadonlyPaths []string         `json:"readonlyPaths,omitempty"`
+	MountLabel    string           `json:"mountLabel,omitempty"`
+	Seccomp       *seccomp.Profile `json:"seccomp,omitempty"`
+}
+
+type ociNamespace struct {
+	Type string `json:"type"`
+	Path string `json:"path,omitempty"`
+}
+
+// ociDevice is a device created in the container.
+type ociDevice struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Major    int64  `json:"major"`
+	Minor    int64  `json:"minor"`
+	FileMode uint32 `json:"fileMode"`
+	UID      uint32 `json:"uid"`
+	GID      uint32 `json:"gid"`
+}
+
+type ociResources struct {
+	Devices          []ociDeviceCgroup `json:"devices"`
+	DisableOOMKiller bool              `json:"disableOOMKiller"`
+	Memory           ociMemory         `json:"memory"`
+	CPU              ociCPU            `json:"cpu"`
+	BlockIO          ociBlockIO        `json:"blockIO"`
+}
+
+// ociDeviceCgroup is a rule of the devices cgroup of the container, the
+// major and minor numbers are wildcards when nil.
+type ociDeviceCgroup struct {
+	Allow  bool   `json:"allow"`
+	Type   string `json:"type,omitempty"`
+	Major  *int64 `json:"major,omitempty"`
+	Minor  *int64 `json:"minor,omitempty"`
+	Access string `json:"access,omitempty"`
+}
+
+type ociMemory struct {
+	Limit       int64 `json:"limit,omitempty"`
+	Reservation int64 `json:"reservation,omitempty"`
+	Swap        int64 `json:"swap,omitempty"`
+}
+
+type ociCPU struct {
+	Shares int64  `json:"shares,omitempty"`
+	Quota  int64  `json:"quota,omitempty"`
+	Period int64  `json:"period,omitempty"`
+	Cpus   string `json:"cpus,omitempty"`
+	Mems   string `json:"mems,omitempty"`
+}
+
+type ociBlockIO struct {
+	Weight int64 `json:"blkioWeight,omitempty"`
+}
+
+var ociNamespaceTypes = map[configs.NamespaceType]string{
+	configs.NEWNET:  "network",
+	configs.NEWPID:  "pid",
+	configs.NEWNS:   "mount",
+	configs.NEWUTS:  "uts",
+	configs.NEWIPC:  "ipc",
+	configs.NEWUSER: "user",
+}
+
+var ociMountFlags = []struct {
+	flag   int
+	option string
+}{
+	{syscall.MS_RDONLY, "ro"},
+	{syscall.MS_NOSUID, "nosuid"},
+	{syscall.MS_NODEV, "nodev"},
+	{syscall.MS_NOEXEC, "noexec"},
+	{syscall.MS_STRICTATIME, "strictatime"},
+}
+
+// ociSpec returns the configuration of the bundle of the container c, from
+// its libcontainer configuration. The networks of the container are set up
+// by the network hook, with the configuration in the bundle.
+func (d *driver) ociSpec(c *execdriver.Command, container *configs.Config, bundle string) (*ociSpec, error) {
+	process, err := ociProcessSpec(c.Rootfs, &c.ProcessConfig, c.WorkingDir, c.ProcessConfig.Env)
+	if err != nil {
+		return nil, err
+	}
+	for _, cap := range container.Capabilities {
+		process.Capabilities = append(process.Capabilities, "CAP_"+cap)
+	}
+	for _, r := range container.Rlimits {
+		rlimit := ulimit.Rlimit{Type: r.Type}
+		process.Rlimits = append(process.Rlimits, ociRlimit{Type: rlimit.Name(), Hard: r.Hard, Soft: r.Soft})
+	}
+	process.ApparmorProfile = container.AppArmorProfile
+	process.SelinuxLabel = container.ProcessLabel
+
+	profile, err := d.loadSeccompProfile(c)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &ociSpec{
+		OCIVersion: ociVersion,
+		Platform:   ociPlatform{OS: runtime.GOOS, Arch: runtime.GOARCH},
+		Process:    *process,
+		Root:       ociRoot{Path: c.Rootfs, Readonly: container.Readonlyfs},
+		Hostname:   container.Hostname,
+		Linux: ociLinux{
+			Resources:     ociResourcesSpec(container.Cgroups),
+			CgroupsPath:   filepath.Join("/", container.Cgroups.Parent, container.Cgroups.Name),
+			MaskedPaths:   container.MaskPaths,
+			ReadonlyPaths: container.ReadonlyPaths,
+			MountLabel:    container.MountLabel,
+			Seccomp:       profile,
+		},
+	}
+	for _, m := range container.Mounts {
+		spec.Mounts = append(spec.Mounts, ociMountSpec(m))
+	}
+	for _, ns := range container.Namespaces {
+		spec.Linux.Namespaces = append(spec.Linux.Namespaces, ociNamespace{Type: ociNamespaceTypes[ns.Type], Path: ns.Path})
+	}
+	for _, dev := range container.Devices {
+		spec.Linux.Devices = append(spec.Linux.Devices, ociDevice{
+			Path:     dev.Path,
+			Type:     string(dev.Type),
+			Major:    dev.Major,
+			Minor:    dev.Minor,
+			FileMode: uint32(dev.FileMode),
+			UID:      dev.Uid,
+			GID:      dev.Gid,
+		})
+	}
+	if len(container.Networks) > 0 {
+		spec.Hooks.Prestart = append(spec.Hooks.Prestart, ociHook{
+			Path: reexec.Self(),
+			Args: []string{ociNetworkHookName, filepath.Join(bundle, ociNetworkFile)},
+		})
+	}
+	return spec, nil
+}
+
+// ociProcessSpec returns the process of the configuration processConfig, run
+// in the container of rootfs. The user of the process is looked up in the
+// container.
+func ociProcessSpec(rootfs string, processConfig *execdriver.ProcessConfig, cwd string, env []string) (*ociProcess, error) {
+	u, err := ociUserSpec(rootfs, processConfig.User)
+	if err != nil {
+		return nil, err
+	}
+	if cwd == "" {
+		cwd = "/"
+	}
+	if u.home != "" && !hasEnv(env, "HOME") {
+		env = append(env, "HOME="+u.home)
+	}
+	return &ociProcess{
+		Terminal: processConfig.Tty,
+		User:     u.ociUser,
+		Args:     append([]string{processConfig.Entrypoint}, processConfig.Arguments...),
+		Env:      env,
+		Cwd:      cwd,
+	}, nil
+}
+
+type ociExecUser struct {
+	ociUser
+	home string
+}
+
+// ociUserSpec looks up the user spec, such as "user:group", in the passwd
+// and group files of rootfs. The runtimes only take the numeric IDs.
+func ociUserSpec(rootfs, spec string) (*ociExecUser, error) {
+	passwd, err := symlink.FollowSymlinkInScope(filepath.Join(rootfs, "etc", "passwd"), rootfs)
+	if err != nil {
+		return nil, err
+	}
+	group, err := symlink.FollowSymlinkInScope(filepath.Join(rootfs, "etc", "group"), rootfs)
+	if err != nil {
+		return nil, err
+	}
+	execUser, err := user.GetExecUserPath(spec, &user.ExecUser{Home: "/"}, passwd, group)
+	if err != nil {
+		return nil, fmt.Errorf("Error looking up the user %q of the container: %v", spec, err)
+	}
+	u := &ociExecUser{
+		ociUser: ociUser{UID: uint32(execUser.Uid), GID: uint32(execUser.Gid)},
+		home:    execUser.Home,
+	}
+	for _, gid := range execUser.Sgids {
+		u.AdditionalGids = append(u.AdditionalGids, uint32(gid))
+	}
+	return u, nil
+}
+
+func hasEnv(env []string, key string) bool {
+	for _, e := range env {
+		if strings.HasPrefix(e, key+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func ociMountSpec(m *configs.Mount) ociMount {
+	mount := ociMount{
+		Destination: m.Destination,
+		Type:        m.Device,
+		Source:      m.Source,
+	}
+	rec := m.Flags&syscall.MS_REC != 0
+	if m.Flags&syscall.MS_BIND != 0 {
+		mount.Options = append(mount.Options, recOption("bind", rec))
+	}
+	for _, f := range ociMountFlags {
+		if m.Flags&f.flag != 0 {
+			mount.Options = append(mount.Options, f.option)
+		}
+	}
+	if m.Flags&syscall.MS_SLAVE != 0 {
+		mount.Options = append(mount.Options, recOption("slave", rec))
+	}
+	if m.Flags&syscall.MS_PRIVATE != 0 {
+		mount.Options = append(mount.Options, recOption("private", rec))
+	}
+	if m.Data != "" {
+		mount.Options = append(mount.Options, strings.Split(m.Data, ",")...)
+	}
+	return mount
+}
+
+// recOption returns the option of the recursive mount, such as rbind.
+func recOption(option string, rec bool) string {
+	if rec {
+		return "r" + option
+	}
+	return option
+}
+
+func ociResourcesSpec(cgroup *configs.Cgroup) *ociResources {
+	r := &ociResources{
+		DisableOOMKiller: cgroup.OomKillDisable,
+		Memory: ociMemory{
+			Limit:       cgroup.Memory,
+			Reservation: cgroup.MemoryReservation,
+			Swap:        cgroup.MemorySwap,
+		},
+		CPU: ociCPU{
+			Shares: cgroup.CpuShares,
+			Quota:  cgroup.CpuQuota,
+			Period: cgroup.CpuPeriod,
+			Cpus:   cgroup.CpusetCpus,
+			Mems:   cgroup.CpusetMems,
+		},
+		BlockIO: ociBlockIO{Weight: cgroup.BlkioWeight},
+	}
+	if cgroup.AllowAllDevices {
+		r.Devices = []ociDeviceCgroup{{Allow: true, Access: "rwm"}}
+		return r
+	}
+	r.Devices = []ociDeviceCgroup{{Allow: false, Access: "rwm"}}
+	for _, dev := range cgroup.AllowedDevices {
+		r.Devices = append(r.Devices, ociDeviceCgroup{
+			Allow:  true,
+			Type:   string(dev.Type),
+			Major:  deviceNumber(dev.Major),
+			Minor:  deviceNumber(dev.Minor),
+			Access: dev.Permissions,
+		})
+	}
+	return r
+}
+
+func deviceNumber(n int64) *int64 {
+	if n == configs.Wildcard {
+		return nil
+	}
+	return &n
+}
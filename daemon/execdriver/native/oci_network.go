@@ -0,0 +1,185 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/libcontainer/configs"
+	"github.com/docker/libcontainer/netlink"
+	"github.com/docker/libcontainer/system"
+	"github.com/docker/libcontainer/utils"
+)
+
+// The networks of a container run by an OCI runtime are set up by the
+// network hook, a reexec of the daemon run by the runtime before the process
+// of the container starts, as the native driver sets them up: a veth pair
+// between the bridge and the network namespace of the container.
+const ociNetworkHookName = "native-oci-network"
+
+func init() {
+	reexec.Register(ociNetworkHookName, ociNetworkMain)
+}
+
+func ociNetworkMain() {
+	// the thread of the hook joins the network namespace of the container
+	runtime.LockOSThread()
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s NETWORKS\n", ociNetworkHookName)
+		os.Exit(1)
+	}
+	if err := setupOCINetworks(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// setupOCINetworks sets up the networks of the file path in the network
+// namespace of the container, whose state is given by the runtime on the
+// standard input.
+func setupOCINetworks(path string) error {
+	var state ociState
+	if err := json.NewDecoder(os.Stdin).Decode(&state); err != nil {
+		return fmt.Errorf("Error decoding the state of the container: %v", err)
+	}
+	var networks []*configs.Network
+	if err := readJSONFile(path, &networks); err != nil {
+		return err
+	}
+
+	peers := make(map[*configs.Network]string)
+	for _, n := range networks {
+		if n.Type != "veth" {
+			continue
+		}
+		peer, err := createVeth(n, state.Pid)
+		if err != nil {
+			return err
+		}
+		peers[n] = peer
+	}
+
+	ns, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", state.Pid))
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	if err := system.Setns(ns.Fd(), syscall.CLONE_NEWNET); err != nil {
+		return fmt.Errorf("Error joining the network namespace of the container: %v", err)
+	}
+	for _, n := range networks {
+		switch n.Type {
+		case "loopback":
+			lo, err := net.InterfaceByName("lo")
+			if err != nil {
+				return err
+			}
+			if err := netlink.NetworkLinkUp(lo); err != nil {
+				return err
+			}
+		case "veth":
+			if err := initializeVeth(n, peers[n]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createVeth creates the veth pair of the network n, attached to its bridge,
+// and moves its peer to the network namespace of nspid. It returns the
+// temporary name of the peer.
+func createVeth(n *configs.Network, nspid int) (peer string, err error) {
+	if peer, err = utils.GenerateRandomName("veth", 7); err != nil {
+		return "", err
+	}
+	bridge, err := net.InterfaceByName(n.Bridge)
+	if err != nil {
+		return "", err
+	}
+	if err := netlink.NetworkCreateVethPair(n.HostInterfaceName, peer, n.TxQueueLen); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			netlink.NetworkLinkDel(n.HostInterfaceName)
+		}
+	}()
+	host, err := net.InterfaceByName(n.HostInterfaceName)
+	if err != nil {
+		return "", err
+	}
+	if err := netlink.AddToBridge(host, bridge); err != nil {
+		return "", err
+	}
+	if err := netlink.NetworkSetMTU(host, n.Mtu); err != nil {
+		return "", err
+	}
+	if n.HairpinMode {
+		if err := netlink.SetHairpinMode(host, true); err != nil {
+			return "", err
+		}
+	}
+	if err := netlink.NetworkLinkUp(host); err != nil {
+		return "", err
+	}
+	child, err := net.InterfaceByName(peer)
+	if err != nil {
+		return "", err
+	}
+	return peer, netlink.NetworkSetNsPid(child, nspid)
+}
+
+// initializeVeth renames the peer of the veth pair of the network n in the
+// container, and sets its addresses and routes.
+func initializeVeth(n *configs.Network, peer string) error {
+	child, err := net.InterfaceByName(peer)
+	if err != nil {
+		return err
+	}
+	if err := netlink.NetworkChangeName(child, n.Name); err != nil {
+		return err
+	}
+	// the index of the interface changes with its name
+	if child, err = net.InterfaceByName(n.Name); err != nil {
+		return err
+	}
+	if n.MacAddress != "" {
+		if err := netlink.NetworkSetMacAddress(child, n.MacAddress); err != nil {
+			return err
+		}
+	}
+	for _, address := range []string{n.Address, n.IPv6Address} {
+		if address == "" {
+			continue
+		}
+		ip, ipNet, err := net.ParseCIDR(address)
+		if err != nil {
+			return err
+		}
+		if err := netlink.NetworkLinkAddIp(child, ip, ipNet); err != nil {
+			return err
+		}
+	}
+	if err := netlink.NetworkSetMTU(child, n.Mtu); err != nil {
+		return err
+	}
+	if err := netlink.NetworkLinkUp(child); err != nil {
+		return err
+	}
+	for _, gateway := range []string{n.Gateway, n.IPv6Gateway} {
+		if gateway == "" {
+			continue
+		}
+		if err := netlink.AddDefaultGw(gateway, n.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/cgroups/fs"
+	"github.com/docker/libcontainer/configs"
+	"github.com/docker/libcontainer/utils"
+	"github.com/kr/pty"
+)
+
+// A container with an OCI runtime is run from a bundle, in the directory of
+// the container in bundlesDir:
+//
+//   config.json    the configuration of the container, in the OCI format
+//   network.json   the networks set up by the network hook
+//   runtime.json   the runtime of the container, with its arguments and environment
+//   state          the root of the state of the runtime
+//
+// The runtime is driven through the command line of runc: run runs the
+// container in the foreground, and state, kill, pause, resume, ps, exec and
+// delete manage it.
+const (
+	bundlesDir = "bundles"
+
+	ociConfigFile  = "config.json"
+	ociNetworkFile = "network.json"
+	ociRuntimeFile = "runtime.json"
+	ociStateDir    = "state"
+
+	// ociStatePollInterval is the interval the state of a starting container
+	// is polled at, until the runtime reports its pid.
+	ociStatePollInterval = 20 * time.Millisecond
+)
+
+// ociContainer is a container run by an OCI runtime.
+type ociContainer struct {
+	id       string
+	runtime  *execdriver.Runtime
+	bundle   string
+	spec     *ociSpec
+	networks []*configs.Network // the networks set up by the network hook
+	pid      int                // the pid of the init of the container, once it started
+}
+
+// ociState is the state of a container, returned by the state command of
+// the runtimes and given to the hooks.
+type ociState struct {
+	ID     string `json:"id"`
+	Pid    int    `json:"pid"`
+	Status string `json:"status"`
+	Bundle string `json:"bundlePath"`
+}
+
+func (d *driver) bundleDir(id string) string {
+	return filepath.Join(d.root, bundlesDir, id)
+}
+
+// ociContainer returns the container id if it's run by an OCI runtime.
+func (d *driver) ociContainer(id string) *ociContainer {
+	d.Lock()
+	defer d.Unlock()
+	return d.ociContainers[id]
+}
+
+// runOCI runs the container of c with its OCI runtime, and blocks until it
+// exits.
+func (d *driver) runOCI(c *execdriver.Command, container *configs.Config, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	cont := &ociContainer{
+		id:       c.ID,
+		runtime:  c.Runtime,
+		bundle:   d.bundleDir(c.ID),
+		networks: container.Networks,
+	}
+	spec, err := d.ociSpec(c, container, cont.bundle)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	cont.spec = spec
+	if err := cont.createBundle(); err != nil {
+		os.RemoveAll(cont.bundle)
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	defer d.cleanOCIContainer(cont)
+
+	cmd := &c.ProcessConfig.Cmd
+	runtimeCmd := cont.command("run", "--bundle", cont.bundle, c.ID)
+	cmd.Path = runtimeCmd.Path
+	cmd.Args = runtimeCmd.Args
+	if err := setupOCIPipes(&c.ProcessConfig, pipes); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	d.Lock()
+	d.ociContainers[c.ID] = cont
+	d.Unlock()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	// the runtime reports the pid of the container once it started. A
+	// container which exited before is given the pid of the runtime.
+	var pid int
+	for pid == 0 {
+		select {
+		case err := <-exited:
+			if startCallback != nil {
+				startCallback(&c.ProcessConfig, cmd.Process.Pid)
+			}
+			return ociExitStatus(cmd, err)
+		case <-time.After(ociStatePollInterval):
+			if state, err := cont.state(); err == nil {
+				pid = state.Pid
+			}
+		}
+	}
+	d.Lock()
+	cont.pid = pid
+	d.Unlock()
+	if startCallback != nil {
+		startCallback(&c.ProcessConfig, pid)
+	}
+	return ociExitStatus(cmd, <-exited)
+}
+
+// ociExitStatus returns the exit status of the runtime cmd, which is the
+// exit status of the container.
+func ociExitStatus(cmd *exec.Cmd, err error) (execdriver.ExitStatus, error) {
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+	}
+	return execdriver.ExitStatus{ExitCode: utils.ExitStatus(cmd.ProcessState.Sys().(syscall.WaitStatus))}, nil
+}
+
+// setupOCIPipes attaches the pipes to the runtime running the process of
+// processConfig. With a tty, the runtime is given the slave of a pty as its
+// controlling terminal.
+func setupOCIPipes(processConfig *execdriver.ProcessConfig, pipes *execdriver.Pipes) error {
+	if !processConfig.Tty {
+		term, err := execdriver.NewStdConsole(processConfig, pipes)
+		if err != nil {
+			return err
+		}
+		processConfig.Terminal = term
+		return nil
+	}
+
+	master, slave, err := pty.Open()
+	if err != nil {
+		return err
+	}
+	term, err := NewTtyConsole(&ptyConsole{File: master, slave: slave.Name()}, pipes, 0)
+	if err != nil {
+		slave.Close()
+		return err
+	}
+	processConfig.Stdin = slave
+	processConfig.Stdout = slave
+	processConfig.Stderr = slave
+	if processConfig.SysProcAttr == nil {
+		processConfig.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	processConfig.SysProcAttr.Setsid = true
+	processConfig.SysProcAttr.Setctty = true
+	processConfig.Terminal = term
+	return nil
+}
+
+// ptyConsole is the master of a pty opened by the daemon.
+type ptyConsole struct {
+	*os.File
+	slave string
+}
+
+func (c *ptyConsole) Path() string {
+	return c.slave
+}
+
+var _ libcontainer.Console = &ptyConsole{}
+
+// createBundle creates the bundle of the container.
+func (c *ociContainer) createBundle() error {
+	if err := os.MkdirAll(c.stateDir(), 0700); err != nil {
+		return err
+	}
+	if err := writeJSONFile(filepath.Join(c.bundle, ociConfigFile), c.spec); err != nil {
+		return err
+	}
+	if err := writeJSONFile(filepath.Join(c.bundle, ociNetworkFile), c.networks); err != nil {
+		return err
+	}
+	return writeJSONFile(filepath.Join(c.bundle, ociRuntimeFile), c.runtime)
+}
+
+func (c *ociContainer) stateDir() string {
+	return filepath.Join(c.bundle, ociStateDir)
+}
+
+// command returns the command of the runtime managing the container.
+func (c *ociContainer) command(args ...string) *exec.Cmd {
+	runtimeArgs := append([]string{}, c.runtime.Args...)
+	runtimeArgs = append(runtimeArgs, "--root", c.stateDir())
+	cmd := exec.Command(c.runtime.Path, append(runtimeArgs, args...)...)
+	if len(c.runtime.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.runtime.Env...)
+	}
+	return cmd
+}
+
+// run runs the command of the runtime, returning its output.
+func (c *ociContainer) run(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := c.command(args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Error running %s %s on container %s: %v: %s", filepath.Base(c.runtime.Path), args[0], c.id, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
+
+func (c *ociContainer) state() (*ociState, error) {
+	out, err := c.run("state", c.id)
+	if err != nil {
+		return nil, err
+	}
+	var state ociState
+	if err := json.Unmarshal(out, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (c *ociContainer) kill(sig int) error {
+	_, err := c.run("kill", c.id, strconv.Itoa(sig))
+	return err
+}
+
+// processes returns the pids of the processes of the container.
+func (c *ociContainer) processes() ([]int, error) {
+	out, err := c.run("ps", "--format", "json", c.id)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	if err := json.Unmarshal(out, &pids); err != nil {
+		return nil, err
+	}
+	return pids, nil
+}
+
+// cgroupPaths returns the paths of the cgroups of the container by
+// subsystem, for the runtimes running the containers in cgroups.
+func (c *ociContainer) cgroupPaths() map[string]string {
+	paths := make(map[string]string)
+	subsystems, err := cgroups.GetAllSubsystems()
+	if err != nil {
+		return paths
+	}
+	for _, s := range subsystems {
+		mnt, err := cgroups.FindCgroupMountpoint(s)
+		if err != nil {
+			continue
+		}
+		p := filepath.Join(mnt, c.spec.Linux.CgroupsPath)
+		if _, err := os.Stat(p); err == nil {
+			paths[s] = p
+		}
+	}
+	return paths
+}
+
+// exec runs the process of processConfig in the container with the runtime,
+// and blocks until it exits.
+func (c *ociContainer) exec(cmd *execdriver.Command, processConfig *execdriver.ProcessConfig, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (int, error) {
+	env := cmd.ProcessConfig.Env
+	if len(processConfig.Env) > 0 {
+		env = processConfig.Env
+	}
+	process, err := ociProcessSpec(cmd.Rootfs, processConfig, cmd.WorkingDir, env)
+	if err != nil {
+		return -1, err
+	}
+	process.Capabilities = c.spec.Process.Capabilities
+	if processConfig.Privileged {
+		process.Capabilities = nil
+		for _, cap := range execdriver.GetAllCapabilities() {
+			process.Capabilities = append(process.Capabilities, "CAP_"+cap)
+		}
+	}
+	process.Rlimits = c.spec.Process.Rlimits
+	process.ApparmorProfile = c.spec.Process.ApparmorProfile
+	process.SelinuxLabel = c.spec.Process.SelinuxLabel
+
+	f, err := ioutil.TempFile(c.bundle, "exec-")
+	if err != nil {
+		return -1, err
+	}
+	defer os.Remove(f.Name())
+	err = json.NewEncoder(f).Encode(process)
+	f.Close()
+	if err != nil {
+		return -1, err
+	}
+
+	runtimeCmd := c.command("exec", "--process", f.Name(), c.id)
+	processConfig.Path = runtimeCmd.Path
+	processConfig.Args = runtimeCmd.Args
+	if err := setupOCIPipes(processConfig, pipes); err != nil {
+		return -1, err
+	}
+	if err := processConfig.Start(); err != nil {
+		return -1, err
+	}
+	if startCallback != nil {
+		startCallback(processConfig, processConfig.Process.Pid)
+	}
+	status, err := ociExitStatus(&processConfig.Cmd, processConfig.Wait())
+	return status.ExitCode, err
+}
+
+// stats returns the stats of the cgroups of the container, and of the host
+// interfaces of its networks.
+func (c *ociContainer) stats(machineMemory int64) (*execdriver.ResourceStats, error) {
+	paths := c.cgroupPaths()
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("The stats of container %s are not supported by its runtime %s", c.id, c.runtime.Path)
+	}
+	now := time.Now()
+	mgr := fs.Manager{Paths: paths}
+	cstats, err := mgr.GetStats()
+	if err != nil {
+		return nil, err
+	}
+	stats := &libcontainer.Stats{CgroupStats: cstats}
+	pids, err := cgroups.ReadProcsFile(paths["devices"])
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range c.networks {
+		if n.Type != "veth" {
+			continue
+		}
+		istats, err := execdriver.NetworkInterfaceStats(n.HostInterfaceName)
+		if err != nil {
+			return nil, err
+		}
+		// the interfaces are named as in the container, not as on the host
+		istats.Name = n.Name
+		stats.Interfaces = append(stats.Interfaces, istats)
+	}
+	memoryLimit := c.spec.Linux.Resources.Memory.Limit
+	if memoryLimit == 0 {
+		memoryLimit = machineMemory
+	}
+	return &execdriver.ResourceStats{
+		Stats:       stats,
+		Read:        now,
+		MemoryLimit: memoryLimit,
+		Pids:        uint64(len(pids)),
+	}, nil
+}
+
+// cleanOCIContainer deletes the container from its runtime, and removes its
+// bundle.
+func (d *driver) cleanOCIContainer(c *ociContainer) {
+	d.Lock()
+	delete(d.ociContainers, c.id)
+	d.Unlock()
+	if _, err := c.run("delete", c.id); err != nil {
+		logrus.Debug(err)
+	}
+	if err := os.RemoveAll(c.bundle); err != nil {
+		logrus.Warnf("Error removing the bundle of container %s: %v", c.id, err)
+	}
+}
+
+// terminateOCI kills the container id left running by a previous daemon, if
+// it was run by an OCI runtime.
+func (d *driver) terminateOCI(id string) (bool, error) {
+	bundle := d.bundleDir(id)
+	var runtime execdriver.Runtime
+	if err := readJSONFile(filepath.Join(bundle, ociRuntimeFile), &runtime); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return true, err
+	}
+	c := &ociContainer{id: id, runtime: &runtime, bundle: bundle}
+	err := c.kill(int(syscall.SIGKILL))
+	d.cleanOCIContainer(c)
+	return true, err
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(f).Encode(v)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,381 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer/configs"
+)
+
+// testRuntime is an OCI runtime writing its arguments to $ARGS, and the
+// state of a running container.
+const testRuntime = `#!/bin/sh
+echo "$@" >> "$ARGS"
+case " $* " in
+*" state "*) echo '{"id":"test","pid":42,"status":"running"}' ;;
+esac
+`
+
+func newTestRuntime(t *testing.T, dir string, args ...string) (*execdriver.Runtime, string) {
+	path := filepath.Join(dir, "runtime")
+	if err := ioutil.WriteFile(path, []byte(testRuntime), 0755); err != nil {
+		t.Fatal(err)
+	}
+	argsFile := filepath.Join(dir, "args")
+	return &execdriver.Runtime{Path: path, Args: args, Env: []string{"ARGS=" + argsFile}}, argsFile
+}
+
+func newTestRootfs(t *testing.T, dir string) string {
+	rootfs := filepath.Join(dir, "rootfs")
+	if err := os.MkdirAll(filepath.Join(rootfs, "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	passwd := "root:x:0:0:root:/root:/bin/sh\ndaemon:x:1:1:daemon:/usr/sbin:/bin/sh\n"
+	if err := ioutil.WriteFile(filepath.Join(rootfs, "etc", "passwd"), []byte(passwd), 0644); err != nil {
+		t.Fatal(err)
+	}
+	group := "root:x:0:\ndaemon:x:1:\nadm:x:4:daemon\n"
+	if err := ioutil.WriteFile(filepath.Join(rootfs, "etc", "group"), []byte(group), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return rootfs
+}
+
+func TestOCIContainerCommand(t *testing.T) {
+	tests := []struct {
+		runtime  execdriver.Runtime
+		args     []string
+		expected []string
+		env      []string
+	}{
+		{
+			runtime:  execdriver.Runtime{Path: "/usr/bin/runc"},
+			args:     []string{"run", "--bundle", "/bundle", "test"},
+			expected: []string{"/usr/bin/runc", "--root", "/bundle/state", "run", "--bundle", "/bundle", "test"},
+		},
+		{
+			runtime:  execdriver.Runtime{Path: "/usr/bin/runc", Args: []string{"--debug", "--systemd-cgroup"}},
+			args:     []string{"kill", "test", "9"},
+			expected: []string{"/usr/bin/runc", "--debug", "--systemd-cgroup", "--root", "/bundle/state", "kill", "test", "9"},
+		},
+		{
+			runtime:  execdriver.Runtime{Path: "/usr/bin/kata", Env: []string{"KATA_DEBUG=1"}},
+			args:     []string{"delete", "test"},
+			expected: []string{"/usr/bin/kata", "--root", "/bundle/state", "delete", "test"},
+			env:      []string{"KATA_DEBUG=1"},
+		},
+		{
+			runtime:  execdriver.Runtime{Path: "/usr/bin/runc"},
+			args:     []string{"exec", "--process", "/bundle/exec-1", "test"},
+			expected: []string{"/usr/bin/runc", "--root", "/bundle/state", "exec", "--process", "/bundle/exec-1", "test"},
+		},
+	}
+	for _, test := range tests {
+		runtime := test.runtime
+		c := &ociContainer{id: "test", runtime: &runtime, bundle: "/bundle"}
+		cmd := c.command(test.args...)
+		if cmd.Path != test.runtime.Path {
+			t.Fatalf("expected the runtime %s to be run, got %s", test.runtime.Path, cmd.Path)
+		}
+		if !reflect.DeepEqual(cmd.Args, test.expected) {
+			t.Fatalf("expected the arguments %v, got %v", test.expected, cmd.Args)
+		}
+		if test.env == nil {
+			if cmd.Env != nil {
+				t.Fatalf("expected the environment of the daemon, got %v", cmd.Env)
+			}
+			continue
+		}
+		if expected := append(os.Environ(), test.env...); !reflect.DeepEqual(cmd.Env, expected) {
+			t.Fatalf("expected the environment of the daemon with %v, got %v", test.env, cmd.Env)
+		}
+	}
+}
+
+func TestOCIContainerRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	runtime, argsFile := newTestRuntime(t, dir, "--debug")
+	bundle := filepath.Join(dir, "bundle")
+	c := &ociContainer{id: "test", runtime: runtime, bundle: bundle}
+
+	state, err := c.state()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Pid != 42 || state.Status != "running" {
+		t.Fatalf("expected the state of the runtime, got %+v", state)
+	}
+	if err := c.kill(int(syscall.SIGTERM)); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(bundle, ociStateDir)
+	expected := "--debug --root " + root + " state test\n" +
+		"--debug --root " + root + " kill test 15\n"
+	if string(out) != expected {
+		t.Fatalf("expected the runtime to be run with\n%s\ngot\n%s", expected, out)
+	}
+}
+
+func TestOCIContainerRunError(t *testing.T) {
+	c := &ociContainer{id: "test", runtime: &execdriver.Runtime{Path: "/bin/false"}, bundle: "/bundle"}
+	_, err := c.run("kill", "test", "9")
+	if err == nil || !strings.Contains(err.Error(), "Error running false kill on container test") {
+		t.Fatalf("expected the failure of the runtime, got %v", err)
+	}
+}
+
+func TestTerminateOCI(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	d := &driver{root: root, ociContainers: make(map[string]*ociContainer)}
+
+	// the containers of the native driver aren't run by runtimes
+	if ok, err := d.terminateOCI("native"); ok || err != nil {
+		t.Fatalf("expected a container without a bundle to be left alone, got %v, %v", ok, err)
+	}
+
+	// the runtime of the container is read from its bundle
+	runtime, argsFile := newTestRuntime(t, root)
+	c := &ociContainer{id: "test", runtime: runtime, bundle: d.bundleDir("test"), spec: &ociSpec{}}
+	if err := c.createBundle(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := d.terminateOCI("test"); !ok || err != nil {
+		t.Fatalf("expected the container to be terminated by its runtime, got %v, %v", ok, err)
+	}
+	out, err := ioutil.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := filepath.Join(c.bundle, ociStateDir)
+	expected := "--root " + state + " kill test 9\n" +
+		"--root " + state + " delete test\n"
+	if string(out) != expected {
+		t.Fatalf("expected the runtime to be run with\n%s\ngot\n%s", expected, out)
+	}
+	if _, err := os.Stat(c.bundle); !os.IsNotExist(err) {
+		t.Fatalf("expected the bundle to be removed, got %v", err)
+	}
+}
+
+func TestCreateBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := &ociContainer{
+		id:       "test",
+		runtime:  &execdriver.Runtime{Path: "/usr/bin/runc", Args: []string{"--debug"}, Env: []string{"A=b"}},
+		bundle:   filepath.Join(dir, "bundle"),
+		spec:     &ociSpec{OCIVersion: ociVersion, Hostname: "test"},
+		networks: []*configs.Network{{Type: "loopback"}},
+	}
+	if err := c.createBundle(); err != nil {
+		t.Fatal(err)
+	}
+
+	var spec ociSpec
+	if err := readJSONFile(filepath.Join(c.bundle, ociConfigFile), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OCIVersion != ociVersion || spec.Hostname != "test" {
+		t.Fatalf("expected the configuration of the container, got %+v", spec)
+	}
+	var networks []*configs.Network
+	if err := readJSONFile(filepath.Join(c.bundle, ociNetworkFile), &networks); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(networks, c.networks) {
+		t.Fatalf("expected the networks %v, got %v", c.networks, networks)
+	}
+	var runtime execdriver.Runtime
+	if err := readJSONFile(filepath.Join(c.bundle, ociRuntimeFile), &runtime); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&runtime, c.runtime) {
+		t.Fatalf("expected the runtime %+v, got %+v", c.runtime, runtime)
+	}
+	if fi, err := os.Stat(c.stateDir()); err != nil || !fi.IsDir() {
+		t.Fatalf("expected the state directory of the runtime, got %v", err)
+	}
+}
+
+func TestOCISpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rootfs := newTestRootfs(t, dir)
+
+	c := &execdriver.Command{
+		ID:         "test",
+		Rootfs:     rootfs,
+		WorkingDir: "/srv",
+		ProcessConfig: execdriver.ProcessConfig{
+			Entrypoint: "sh",
+			Arguments:  []string{"-c", "true"},
+			User:       "daemon",
+			Tty:        true,
+			Env:        []string{"PATH=/bin"},
+		},
+	}
+	container := &configs.Config{
+		Hostname:     "test",
+		Readonlyfs:   true,
+		Capabilities: []string{"CHOWN", "KILL"},
+		Rlimits:      []configs.Rlimit{{Type: syscall.RLIMIT_NOFILE, Hard: 2048, Soft: 1024}},
+		Namespaces:   configs.Namespaces{{Type: configs.NEWPID}, {Type: configs.NEWNET, Path: "/proc/1/ns/net"}},
+		Mounts: []*configs.Mount{
+			{Source: "/data", Destination: "/data", Device: "bind", Flags: syscall.MS_BIND | syscall.MS_REC | syscall.MS_RDONLY},
+		},
+		Devices:  []*configs.Device{{Path: "/dev/null", Type: 'c', Major: 1, Minor: 3, FileMode: 0666}},
+		Networks: []*configs.Network{{Type: "loopback"}},
+		Cgroups:  &configs.Cgroup{Name: "test", Parent: "docker", Memory: 1 << 20, AllowAllDevices: true},
+	}
+	d := &driver{}
+	spec, err := d.ociSpec(c, container, "/bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedProcess := ociProcess{
+		Terminal:     true,
+		User:         ociUser{UID: 1, GID: 1, AdditionalGids: []uint32{4}},
+		Args:         []string{"sh", "-c", "true"},
+		Env:          []string{"PATH=/bin", "HOME=/usr/sbin"},
+		Cwd:          "/srv",
+		Capabilities: []string{"CAP_CHOWN", "CAP_KILL"},
+		Rlimits:      []ociRlimit{{Type: "RLIMIT_NOFILE", Hard: 2048, Soft: 1024}},
+	}
+	if !reflect.DeepEqual(spec.Process, expectedProcess) {
+		t.Fatalf("expected the process %+v, got %+v", expectedProcess, spec.Process)
+	}
+	if spec.Root != (ociRoot{Path: rootfs, Readonly: true}) || spec.Hostname != "test" {
+		t.Fatalf("expected the root and the hostname of the container, got %+v and %q", spec.Root, spec.Hostname)
+	}
+	expectedMounts := []ociMount{{Destination: "/data", Type: "bind", Source: "/data", Options: []string{"rbind", "ro"}}}
+	if !reflect.DeepEqual(spec.Mounts, expectedMounts) {
+		t.Fatalf("expected the mounts %+v, got %+v", expectedMounts, spec.Mounts)
+	}
+	expectedNamespaces := []ociNamespace{{Type: "pid"}, {Type: "network", Path: "/proc/1/ns/net"}}
+	if !reflect.DeepEqual(spec.Linux.Namespaces, expectedNamespaces) {
+		t.Fatalf("expected the namespaces %+v, got %+v", expectedNamespaces, spec.Linux.Namespaces)
+	}
+	expectedDevices := []ociDevice{{Path: "/dev/null", Type: "c", Major: 1, Minor: 3, FileMode: 0666}}
+	if !reflect.DeepEqual(spec.Linux.Devices, expectedDevices) {
+		t.Fatalf("expected the devices %+v, got %+v", expectedDevices, spec.Linux.Devices)
+	}
+	if spec.Linux.CgroupsPath != "/docker/test" || spec.Linux.Resources.Memory.Limit != 1<<20 {
+		t.Fatalf("expected the cgroups of the container, got %q and %+v", spec.Linux.CgroupsPath, spec.Linux.Resources)
+	}
+	if spec.Linux.Seccomp != nil {
+		t.Fatalf("expected no seccomp profile without seccomp, got %+v", spec.Linux.Seccomp)
+	}
+	// the networks are set up by the network hook
+	if len(spec.Hooks.Prestart) != 1 || !reflect.DeepEqual(spec.Hooks.Prestart[0].Args, []string{ociNetworkHookName, "/bundle/" + ociNetworkFile}) {
+		t.Fatalf("expected the network hook, got %+v", spec.Hooks.Prestart)
+	}
+
+	// the configuration is valid JSON
+	if _, err := json.Marshal(spec); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOCIUserSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rootfs := newTestRootfs(t, dir)
+
+	tests := []struct {
+		spec     string
+		expected ociUser
+		home     string
+		err      bool
+	}{
+		{"", ociUser{}, "/root", false},
+		{"daemon", ociUser{UID: 1, GID: 1, AdditionalGids: []uint32{4}}, "/usr/sbin", false},
+		{"daemon:root", ociUser{UID: 1, GID: 0}, "/usr/sbin", false},
+		{"1000:1000", ociUser{UID: 1000, GID: 1000}, "/", false},
+		{"nobody", ociUser{}, "", true},
+	}
+	for _, test := range tests {
+		u, err := ociUserSpec(rootfs, test.spec)
+		if test.err {
+			if err == nil {
+				t.Fatalf("expected the user %q to be unknown, got %+v", test.spec, u)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(u.ociUser, test.expected) || u.home != test.home {
+			t.Fatalf("expected the user %q to be %+v with home %s, got %+v with home %s", test.spec, test.expected, test.home, u.ociUser, u.home)
+		}
+	}
+}
+
+func TestOCIMountSpec(t *testing.T) {
+	tests := []struct {
+		mount    configs.Mount
+		expected []string
+	}{
+		{configs.Mount{Device: "proc", Flags: syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC}, []string{"nosuid", "nodev", "noexec"}},
+		{configs.Mount{Device: "bind", Flags: syscall.MS_BIND}, []string{"bind"}},
+		{configs.Mount{Device: "bind", Flags: syscall.MS_BIND | syscall.MS_REC | syscall.MS_PRIVATE}, []string{"rbind", "rprivate"}},
+		{configs.Mount{Device: "bind", Flags: syscall.MS_BIND | syscall.MS_SLAVE}, []string{"bind", "slave"}},
+		{configs.Mount{Device: "tmpfs", Flags: syscall.MS_STRICTATIME, Data: "mode=755,size=65536k"}, []string{"strictatime", "mode=755", "size=65536k"}},
+	}
+	for _, test := range tests {
+		m := test.mount
+		if options := ociMountSpec(&m).Options; !reflect.DeepEqual(options, test.expected) {
+			t.Fatalf("expected the options %v of %+v, got %v", test.expected, test.mount, options)
+		}
+	}
+}
+
+func TestOCIResourcesSpec(t *testing.T) {
+	cgroup := &configs.Cgroup{
+		AllowedDevices: []*configs.Device{
+			{Type: 'c', Major: 1, Minor: 3, Permissions: "rwm"},
+			{Type: 'c', Major: 136, Minor: configs.Wildcard, Permissions: "rwm"},
+		},
+	}
+	devices := ociResourcesSpec(cgroup).Devices
+	if len(devices) != 3 || devices[0].Allow || devices[0].Access != "rwm" {
+		t.Fatalf("expected the devices to be denied but the allowed ones, got %+v", devices)
+	}
+	if *devices[1].Major != 1 || *devices[1].Minor != 3 || !devices[1].Allow || devices[1].Type != "c" {
+		t.Fatalf("expected /dev/null to be allowed, got %+v", devices[1])
+	}
+	if *devices[2].Major != 136 || devices[2].Minor != nil {
+		t.Fatalf("expected the minor number to be a wildcard, got %+v", devices[2])
+	}
+}
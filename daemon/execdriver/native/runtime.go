@@ -0,0 +1,110 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/daemon/execdriver"
+)
+
+// unlimited is the lower bound of the limits the kernel reports for the
+// cgroups without limit, rounded to its page size.
+const unlimited = 1 << 62
+
+func (d *driver) RuntimeState(c *execdriver.Command) (*execdriver.RuntimeState, error) {
+	if oci := d.ociContainer(c.ID); oci != nil {
+		paths := oci.cgroupPaths()
+		return &execdriver.RuntimeState{
+			CgroupPaths:     paths,
+			Resources:       cgroupResources(paths),
+			AppArmorProfile: oci.spec.Process.ApparmorProfile,
+			SeccompProfile:  d.seccompProfileName(c),
+		}, nil
+	}
+	d.Lock()
+	active := d.activeContainers[c.ID]
+	d.Unlock()
+	if active == nil {
+		return nil, execdriver.ErrNotRunning
+	}
+	state, err := active.State()
+	if err != nil {
+		return nil, err
+	}
+	return &execdriver.RuntimeState{
+		CgroupPaths:     state.CgroupPaths,
+		Resources:       cgroupResources(state.CgroupPaths),
+		AppArmorProfile: active.Config().AppArmorProfile,
+		SeccompProfile:  d.seccompProfileName(c),
+	}, nil
+}
+
+// seccompProfileName returns the name of the seccomp profile applied to the
+// container by seccompProfile.
+func (d *driver) seccompProfileName(c *execdriver.Command) string {
+	switch {
+	case c.ProcessConfig.Privileged || c.SeccompProfile == "unconfined" || !d.seccompEnabled:
+		return "unconfined"
+	case c.SeccompProfile == "":
+		return "default"
+	default:
+		return "custom"
+	}
+}
+
+// cgroupResources reads the limits in effect in the cgroups of paths, with
+// the conventions of the host config: 0 when there is no limit, and -1 for
+// the unlimited swap.
+func cgroupResources(paths map[string]string) execdriver.Resources {
+	var r execdriver.Resources
+	if p, ok := paths["memory"]; ok {
+		if r.Memory = readCgroupInt(p, "memory.limit_in_bytes"); r.Memory >= unlimited {
+			r.Memory = 0
+		}
+		if r.MemorySwap = readCgroupInt(p, "memory.memsw.limit_in_bytes"); r.MemorySwap >= unlimited {
+			r.MemorySwap = -1
+		}
+		r.OomKillDisable = strings.Contains(readCgroupFile(p, "memory.oom_control"), "oom_kill_disable 1")
+	}
+	if p, ok := paths["cpu"]; ok {
+		r.CpuShares = readCgroupInt(p, "cpu.shares")
+		r.CpuPeriod = readCgroupInt(p, "cpu.cfs_period_us")
+		if r.CpuQuota = readCgroupInt(p, "cpu.cfs_quota_us"); r.CpuQuota < 0 {
+			r.CpuQuota = 0
+		}
+	}
+	if p, ok := paths["cpuset"]; ok {
+		r.CpusetCpus = readCgroupFile(p, "cpuset.cpus")
+		r.CpusetMems = readCgroupFile(p, "cpuset.mems")
+	}
+	if p, ok := paths["blkio"]; ok {
+		r.BlkioWeight = readCgroupInt(p, "blkio.weight")
+	}
+	return r
+}
+
+// readCgroupFile returns the content of the file of the cgroup dir, or "" if
+// the kernel doesn't support it.
+func readCgroupFile(dir, file string) string {
+	content, err := ioutil.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func readCgroupInt(dir, file string) int64 {
+	v, err := strconv.ParseInt(readCgroupFile(dir, file), 10, 64)
+	if err != nil {
+		// the limits beyond the int64 range are unlimited
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			return unlimited
+		}
+		return 0
+	}
+	return v
+}
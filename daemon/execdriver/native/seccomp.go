@@ -0,0 +1,99 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/seccomp"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/configs"
+)
+
+// The process of a container whose syscalls are filtered is wrapped by the
+// seccomp wrapper, a reexec of the daemon binary given to the container,
+// which installs the filter of the profile once libcontainer set up the
+// container, then drops the privileges of the process and executes it, so the
+// profile applies to all the syscalls of the process, but not to those of the
+// setup.
+
+// loadSeccompProfile returns the seccomp profile of the container, or nil if
+// its syscalls aren't filtered.
+func (d *driver) loadSeccompProfile(c *execdriver.Command) (*seccomp.Profile, error) {
+	if c.ProcessConfig.Privileged || c.SeccompProfile == "unconfined" {
+		return nil, nil
+	}
+	if c.SeccompProfile == "" {
+		if !d.seccompEnabled {
+			return nil, nil
+		}
+		return seccomp.DefaultProfile(), nil
+	}
+	if !d.seccompEnabled {
+		return nil, fmt.Errorf("seccomp is not enabled in your kernel, cannot run a container with a seccomp profile")
+	}
+	return seccomp.LoadProfile([]byte(c.SeccompProfile))
+}
+
+// seccompProfile returns the seccomp profile of the container in JSON, as
+// given to its init, or nil if its syscalls aren't filtered.
+func (d *driver) seccompProfile(c *execdriver.Command) ([]byte, error) {
+	p, err := d.loadSeccompProfile(c)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	if _, err := p.Compile(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p)
+}
+
+// startProcess starts the process with start, wrapped by the seccomp wrapper
+// if the container has a seccomp profile. The wrapper runs as root with the
+// capabilities of the process, and those it needs to install the filter and
+// to switch to the user of the process.
+func startProcess(start func(*libcontainer.Process) error, p *libcontainer.Process, container *configs.Config, profile []byte) error {
+	if len(profile) == 0 {
+		return start(p)
+	}
+
+	self, err := os.Open("/proc/self/exe")
+	if err != nil {
+		return err
+	}
+	defer self.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	caps := container.Capabilities
+	if p.Capabilities != nil {
+		caps = p.Capabilities
+	}
+	config := seccompExecConfig{
+		Profile:          profile,
+		User:             p.User,
+		Capabilities:     caps,
+		AdditionalGroups: container.AdditionalGroups,
+		Home:             hasEnv(p.Env, "HOME"),
+	}
+	go func() {
+		json.NewEncoder(w).Encode(config)
+		w.Close()
+	}()
+
+	p.Args = append([]string{seccompExecName}, p.Args...)
+	p.User = "0:0"
+	p.Capabilities = append(seccompExecCapabilities, caps...)
+	p.ExtraFiles = []*os.File{r, self}
+	return start(p)
+}
+
+// seccompExecCapabilities are the capabilities the seccomp wrapper needs to
+// install the filter and to switch to the user of the process.
+var seccompExecCapabilities = []string{"SYS_ADMIN", "SETUID", "SETGID", "SETPCAP"}
@@ -0,0 +1,96 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/configs"
+)
+
+func TestStartProcessWithoutProfile(t *testing.T) {
+	p := &libcontainer.Process{Args: []string{"sh"}, User: "daemon"}
+	err := startProcess(func(p *libcontainer.Process) error {
+		if !reflect.DeepEqual(p.Args, []string{"sh"}) || p.User != "daemon" || p.ExtraFiles != nil {
+			t.Fatalf("expected the process to be started as is, got %+v", p)
+		}
+		return nil
+	}, p, &configs.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartProcessWithProfile(t *testing.T) {
+	profile := []byte(`{"defaultAction":"SCMP_ACT_ALLOW"}`)
+	container := &configs.Config{
+		Capabilities:     []string{"CHOWN", "KILL"},
+		AdditionalGroups: []int{10},
+	}
+	p := &libcontainer.Process{
+		Args: []string{"sh", "-c", "true"},
+		Env:  []string{"PATH=/bin", "HOME=/home/daemon"},
+		User: "daemon",
+	}
+
+	var config seccompExecConfig
+	err := startProcess(func(p *libcontainer.Process) error {
+		if len(p.ExtraFiles) != 2 {
+			t.Fatalf("expected the configuration and the wrapper to be given, got %d files", len(p.ExtraFiles))
+		}
+		return json.NewDecoder(p.ExtraFiles[0]).Decode(&config)
+	}, p, container, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{seccompExecName, "sh", "-c", "true"}; !reflect.DeepEqual(p.Args, expected) {
+		t.Fatalf("expected the arguments %v, got %v", expected, p.Args)
+	}
+	if p.User != "0:0" {
+		t.Fatalf("expected the wrapper to run as root, got %q", p.User)
+	}
+	if expected := []string{"SYS_ADMIN", "SETUID", "SETGID", "SETPCAP", "CHOWN", "KILL"}; !reflect.DeepEqual(p.Capabilities, expected) {
+		t.Fatalf("expected the capabilities %v, got %v", expected, p.Capabilities)
+	}
+
+	expected := seccompExecConfig{
+		Profile:          profile,
+		User:             "daemon",
+		Capabilities:     []string{"CHOWN", "KILL"},
+		AdditionalGroups: []int{10},
+		Home:             true,
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected the configuration %+v, got %+v", expected, config)
+	}
+}
+
+func TestStartProcessCapabilitiesOfProcess(t *testing.T) {
+	p := &libcontainer.Process{Args: []string{"sh"}, Capabilities: []string{"NET_ADMIN"}}
+	var config seccompExecConfig
+	err := startProcess(func(p *libcontainer.Process) error {
+		return json.NewDecoder(p.ExtraFiles[0]).Decode(&config)
+	}, p, &configs.Config{Capabilities: []string{"CHOWN"}}, []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config.Capabilities, []string{"NET_ADMIN"}) {
+		t.Fatalf("expected the capabilities of the process, got %v", config.Capabilities)
+	}
+	if config.Home {
+		t.Fatal("expected HOME to be missing from the environment")
+	}
+}
+
+func TestCapabilityWhitelist(t *testing.T) {
+	if _, err := capabilityWhitelist([]string{"CHOWN", "SYS_ADMIN"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := capabilityWhitelist([]string{"NOT_A_CAP"}); err == nil {
+		t.Fatal("expected an unknown capability to fail")
+	}
+}
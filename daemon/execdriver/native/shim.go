@@ -0,0 +1,616 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/term"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/configs"
+	"github.com/docker/libcontainer/utils"
+)
+
+// With live restore, a container is run by a shim, a reexec of the daemon
+// which is the parent of the container and holds its IO, so that the
+// container survives a restart of the daemon. The daemon and the shim talk
+// through the files of the directory of the shim:
+//
+//   config.json   the configuration of the container, written by the daemon
+//   stdin         the FIFO of the standard input of the container
+//   stdout        the FIFO of the standard output of the container
+//   stderr        the FIFO of the standard error of the container, without tty
+//   resize        the FIFO of the sizes of the tty of the container
+//   exit          the FIFO held open by the shim until it exits
+//   pid           the pid of the container, once it started
+//   exit.json     the exit status of the container, once it exited
+//   stdin.closed  created by the daemon when it closes the standard input
+//   shim.log      the errors of the shim
+const (
+	shimName = "native-shim"
+	shimsDir = "shims"
+
+	shimConfigFile   = "config.json"
+	shimStdin        = "stdin"
+	shimStdout       = "stdout"
+	shimStderr       = "stderr"
+	shimResize       = "resize"
+	shimExit         = "exit"
+	shimPidFile      = "pid"
+	shimExitFile     = "exit.json"
+	shimStdinClosed  = "stdin.closed"
+	shimLogFile      = "shim.log"
+	shimStartedFd    = 3 // the pipe of the shim reporting the start of the container
+	shimFifoFileMode = 0600
+)
+
+func init() {
+	reexec.Register(shimName, shimMain)
+}
+
+// shimConfig is the configuration of the container run by a shim.
+type shimConfig struct {
+	ID      string
+	Root    string // the root of the libcontainer factory
+	Systemd bool   // the cgroups are managed by systemd
+	Config  *configs.Config
+	Args    []string
+	Env     []string
+	Cwd     string
+	User    string
+	Tty     bool
+	Stdin   bool
+	Seccomp []byte // the seccomp profile of the container
+}
+
+// shimStarted is reported by a shim once the container started, or failed
+// to start.
+type shimStarted struct {
+	Pid   int
+	Error string
+}
+
+func (d *driver) shimDir(id string) string {
+	return filepath.Join(d.root, shimsDir, id)
+}
+
+// runShim runs the container of c with a shim, and blocks until it exits.
+func (d *driver) runShim(c *execdriver.Command, container *configs.Config, seccompProfile []byte, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	dir := d.shimDir(c.ID)
+	cfg := &shimConfig{
+		ID:      c.ID,
+		Root:    d.root,
+		Systemd: d.systemd,
+		Config:  container,
+		Args:    append([]string{c.ProcessConfig.Entrypoint}, c.ProcessConfig.Arguments...),
+		Env:     c.ProcessConfig.Env,
+		Cwd:     c.WorkingDir,
+		User:    c.ProcessConfig.User,
+		Tty:     c.ProcessConfig.Tty,
+		Stdin:   pipes.Stdin != nil,
+		Seccomp: seccompProfile,
+	}
+	if err := createShimDir(dir, cfg); err != nil {
+		os.RemoveAll(dir)
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	s, err := openShim(dir, cfg)
+	if err != nil {
+		os.RemoveAll(dir)
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	pid, err := s.start()
+	if err != nil {
+		s.Close()
+		d.cleanContainer(c.ID)
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	return d.monitorShim(c, s, pipes, pid, startCallback)
+}
+
+// Restore re-attaches to the shim of a container left running by a previous
+// daemon. The exit status of a container which exited while the daemon was
+// down is returned at once.
+func (d *driver) Restore(c *execdriver.Command, pipes *execdriver.Pipes, restoreCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	// the containers run by OCI runtimes aren't run by shims
+	if c.Runtime != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrNotRestorable
+	}
+	dir := d.shimDir(c.ID)
+	var cfg shimConfig
+	if err := readJSONFile(filepath.Join(dir, shimConfigFile), &cfg); err != nil {
+		if os.IsNotExist(err) {
+			return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrNotRestorable
+		}
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, shimPidFile))
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	pid, err := strconv.Atoi(string(b))
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	s, err := openShim(dir, &cfg)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	return d.monitorShim(c, s, pipes, pid, restoreCallback)
+}
+
+// monitorShim attaches the pipes to the container run by the shim s, and
+// blocks until the container exits.
+func (d *driver) monitorShim(c *execdriver.Command, s *shimClient, pipes *execdriver.Pipes, pid int, callback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	defer func() {
+		s.Close()
+		d.cleanContainer(c.ID)
+	}()
+
+	// the container can't be loaded once it exited
+	if cont, err := d.factory.Load(c.ID); err == nil {
+		d.Lock()
+		d.activeContainers[c.ID] = cont
+		d.Unlock()
+	}
+
+	s.attach(pipes)
+	if c.ProcessConfig.Tty {
+		c.ProcessConfig.Terminal = s
+	} else {
+		c.ProcessConfig.Terminal = &execdriver.StdConsole{}
+	}
+	if callback != nil {
+		callback(&c.ProcessConfig, pid)
+	}
+	return s.wait()
+}
+
+// createShimDir creates the directory of the shim running the container of
+// the configuration cfg, with its configuration and its FIFOs.
+func createShimDir(dir string, cfg *shimConfig) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, shimConfigFile), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(f).Encode(cfg)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	fifos := []string{shimStdout, shimExit}
+	if cfg.Stdin {
+		fifos = append(fifos, shimStdin)
+	}
+	if cfg.Tty {
+		fifos = append(fifos, shimResize)
+	} else {
+		fifos = append(fifos, shimStderr)
+	}
+	for _, name := range fifos {
+		if err := syscall.Mkfifo(filepath.Join(dir, name), shimFifoFileMode); err != nil {
+			return fmt.Errorf("Error creating the FIFO %s of the shim: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// shimClient is the daemon side of the shim of a container.
+type shimClient struct {
+	dir     string
+	stdin   *os.File
+	stdout  *os.File
+	stderr  *os.File
+	resize  *os.File
+	copying sync.WaitGroup
+	closing sync.Once
+}
+
+// openShim opens the FIFOs of the shim in dir. The FIFOs of the output are
+// opened before the shim starts, for the output of a container exiting at
+// once to be kept until it is read.
+func openShim(dir string, cfg *shimConfig) (s *shimClient, err error) {
+	s = &shimClient{dir: dir}
+	defer func() {
+		if err != nil {
+			s.Close()
+		}
+	}()
+
+	if s.stdout, err = openFifo(dir, shimStdout, syscall.O_RDONLY|syscall.O_NONBLOCK); err != nil {
+		return nil, err
+	}
+	if !cfg.Tty {
+		if s.stderr, err = openFifo(dir, shimStderr, syscall.O_RDONLY|syscall.O_NONBLOCK); err != nil {
+			return nil, err
+		}
+	}
+	// the FIFOs written by the daemon are opened for reading too, so that
+	// opening them doesn't block until the shim opens them
+	if cfg.Stdin {
+		if s.stdin, err = openFifo(dir, shimStdin, syscall.O_RDWR); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Tty {
+		if s.resize, err = openFifo(dir, shimResize, syscall.O_RDWR); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// start starts the shim, and waits for it to start the container. The shim
+// runs in its own session, to survive the daemon.
+func (s *shimClient) start() (int, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return -1, err
+	}
+	defer r.Close()
+	log, err := os.OpenFile(filepath.Join(s.dir, shimLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		w.Close()
+		return -1, err
+	}
+
+	cmd := reexec.Command(shimName, s.dir)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	cmd.ExtraFiles = []*os.File{w}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	err = cmd.Start()
+	w.Close()
+	log.Close()
+	if err != nil {
+		return -1, err
+	}
+	// the shim is reaped, when it exits before the daemon
+	go cmd.Wait()
+
+	return s.readStarted(r)
+}
+
+// readStarted reads the start of the container reported by the shim on r,
+// and returns the pid of the container.
+func (s *shimClient) readStarted(r io.Reader) (int, error) {
+	var started shimStarted
+	if err := json.NewDecoder(r).Decode(&started); err != nil {
+		return -1, fmt.Errorf("The shim of the container failed to start, see %s: %v", filepath.Join(s.dir, shimLogFile), err)
+	}
+	if started.Error != "" {
+		return -1, fmt.Errorf("%s", started.Error)
+	}
+	return started.Pid, nil
+}
+
+// attach copies the IO of the container from and to the pipes.
+func (s *shimClient) attach(pipes *execdriver.Pipes) {
+	copyOutput := func(w io.Writer, f *os.File) {
+		defer s.copying.Done()
+		syscall.SetNonblock(int(f.Fd()), false)
+		io.Copy(w, f)
+	}
+	s.copying.Add(1)
+	go copyOutput(pipes.Stdout, s.stdout)
+	if s.stderr != nil {
+		s.copying.Add(1)
+		go copyOutput(pipes.Stderr, s.stderr)
+	}
+
+	if pipes.Stdin != nil && s.stdin != nil {
+		go func() {
+			io.Copy(s.stdin, pipes.Stdin)
+			// the shim closes the standard input of the container only
+			// if the daemon closed it, rather than exited
+			if f, err := os.Create(filepath.Join(s.dir, shimStdinClosed)); err == nil {
+				f.Close()
+			}
+			s.stdin.Close()
+			pipes.Stdin.Close()
+		}()
+	}
+}
+
+// wait blocks until the shim exits, and returns the exit status of the
+// container.
+func (s *shimClient) wait() (execdriver.ExitStatus, error) {
+	// the shim holds the exit FIFO open until it exits
+	exit, err := openFifo(s.dir, shimExit, syscall.O_RDONLY|syscall.O_NONBLOCK)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	syscall.SetNonblock(int(exit.Fd()), false)
+	io.Copy(ioutil.Discard, exit)
+	exit.Close()
+
+	s.copying.Wait()
+
+	var exitStatus execdriver.ExitStatus
+	if err := readJSONFile(filepath.Join(s.dir, shimExitFile), &exitStatus); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("The shim of the container exited without its exit status, see %s: %v", filepath.Join(s.dir, shimLogFile), err)
+	}
+	return exitStatus, nil
+}
+
+// Resize resizes the tty of the container.
+func (s *shimClient) Resize(h, w int) error {
+	if s.resize == nil {
+		return nil
+	}
+	_, err := fmt.Fprintf(s.resize, "%d %d\n", h, w)
+	return err
+}
+
+// Close closes the FIFOs of the shim opened by the daemon. The shim keeps
+// running the container.
+func (s *shimClient) Close() error {
+	s.closing.Do(func() {
+		for _, f := range []*os.File{s.stdout, s.stderr, s.resize} {
+			if f != nil {
+				f.Close()
+			}
+		}
+	})
+	return nil
+}
+
+// shimMain is the main function of a shim, run with the directory of the
+// shim as its argument.
+func shimMain() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s DIR\n", shimName)
+		os.Exit(1)
+	}
+	started := os.NewFile(shimStartedFd, "started")
+	if err := runContainer(os.Args[1], started); err != nil {
+		// the daemon is told if the container didn't start
+		json.NewEncoder(started).Encode(&shimStarted{Error: err.Error()})
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runContainer runs the container of the shim in dir, reporting its start
+// to started, and writes its exit status once it exited.
+func runContainer(dir string, started *os.File) error {
+	var cfg shimConfig
+	if err := readJSONFile(filepath.Join(dir, shimConfigFile), &cfg); err != nil {
+		return err
+	}
+	factory, err := newFactory(cfg.Root, cfg.Systemd)
+	if err != nil {
+		return err
+	}
+
+	// the exit FIFO is open as long as the shim runs
+	exit, err := openFifo(dir, shimExit, syscall.O_RDWR)
+	if err != nil {
+		return err
+	}
+	defer exit.Close()
+
+	p := &libcontainer.Process{
+		Args: cfg.Args,
+		Env:  cfg.Env,
+		Cwd:  cfg.Cwd,
+		User: cfg.User,
+	}
+	streams, err := setupShimStreams(dir, &cfg, p)
+	if err != nil {
+		return err
+	}
+
+	cont, err := factory.Create(cfg.ID, cfg.Config)
+	if err != nil {
+		return err
+	}
+	if err := startProcess(cont.Start, p, cfg.Config, cfg.Seccomp); err != nil {
+		cont.Destroy()
+		return err
+	}
+	streams.started()
+
+	pid, err := p.Pid()
+	if err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		cont.Destroy()
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, shimPidFile), []byte(strconv.Itoa(pid)), 0600); err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		cont.Destroy()
+		return err
+	}
+	json.NewEncoder(started).Encode(&shimStarted{Pid: pid})
+	started.Close()
+
+	oom := notifyOnOOM(cont)
+	waitF := p.Wait
+	if nss := cont.Config().Namespaces; !nss.Contains(configs.NEWPID) {
+		// we need such hack for tracking processes with inherited fds,
+		// because cmd.Wait() waiting for all streams to be copied
+		waitF = waitInPIDHost(p, cont)
+	}
+	ps, err := waitF()
+	if err != nil {
+		execErr, ok := err.(*exec.ExitError)
+		if !ok {
+			cont.Destroy()
+			return err
+		}
+		ps = execErr.ProcessState
+	}
+	cont.Destroy()
+	_, oomKill := <-oom
+	streams.wait()
+
+	exitStatus := &execdriver.ExitStatus{
+		ExitCode:  utils.ExitStatus(ps.Sys().(syscall.WaitStatus)),
+		OOMKilled: oomKill,
+	}
+	// the exit status is renamed in place, so that the daemon never reads a
+	// partial one
+	tmp := filepath.Join(dir, shimExitFile+".tmp")
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(f).Encode(exitStatus)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, shimExitFile))
+}
+
+// shimStreams are the IO of the container of a shim.
+type shimStreams struct {
+	files   []*os.File // the ends of the container, closed once it started
+	copying sync.WaitGroup
+}
+
+// setupShimStreams sets the IO of the process p of the container from the
+// FIFOs of the shim in dir. The FIFOs of the output are opened for reading
+// too, so that the container blocks rather than fails writing its output
+// while the daemon is down.
+func setupShimStreams(dir string, cfg *shimConfig, p *libcontainer.Process) (*shimStreams, error) {
+	streams := &shimStreams{}
+	stdout, err := openFifo(dir, shimStdout, syscall.O_RDWR)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Tty {
+		rootuid, err := cfg.Config.HostUID()
+		if err != nil {
+			return nil, err
+		}
+		console, err := p.NewConsole(rootuid)
+		if err != nil {
+			return nil, err
+		}
+		streams.copying.Add(1)
+		go func() {
+			defer streams.copying.Done()
+			io.Copy(stdout, console)
+			stdout.Close()
+		}()
+		if cfg.Stdin {
+			go relayStdin(dir, console, false)
+		}
+		go resizeConsole(dir, console)
+		return streams, nil
+	}
+
+	stderr, err := openFifo(dir, shimStderr, syscall.O_RDWR)
+	if err != nil {
+		return nil, err
+	}
+	p.Stdout = stdout
+	p.Stderr = stderr
+	streams.files = append(streams.files, stdout, stderr)
+	if cfg.Stdin {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		p.Stdin = r
+		streams.files = append(streams.files, r)
+		go relayStdin(dir, w, true)
+	}
+	return streams, nil
+}
+
+// started closes the ends of the IO given to the container.
+func (streams *shimStreams) started() {
+	for _, f := range streams.files {
+		f.Close()
+	}
+}
+
+// wait waits for the output of the tty of the container to be copied.
+func (streams *shimStreams) wait() {
+	streams.copying.Wait()
+}
+
+// relayStdin copies the standard input of the container written by the
+// daemon to w, across the restarts of the daemon. w is closed once the daemon
+// closed the standard input, if closeStdin is true.
+func relayStdin(dir string, w io.WriteCloser, closeStdin bool) {
+	for {
+		// blocks until a daemon opens the FIFO
+		f, err := openFifo(dir, shimStdin, syscall.O_RDONLY)
+		if err != nil {
+			return
+		}
+		io.Copy(w, f)
+		f.Close()
+		if _, err := os.Stat(filepath.Join(dir, shimStdinClosed)); err == nil {
+			if closeStdin {
+				w.Close()
+			}
+			return
+		}
+	}
+}
+
+// resizeConsole resizes the tty of the container to the sizes written by
+// the daemon.
+func resizeConsole(dir string, console libcontainer.Console) {
+	f, err := openFifo(dir, shimResize, syscall.O_RDWR)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		h, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		w, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		term.SetWinsize(console.Fd(), &term.Winsize{Height: uint16(h), Width: uint16(w)})
+	}
+}
+
+func openFifo(dir, name string, flag int) (*os.File, error) {
+	return os.OpenFile(filepath.Join(dir, name), flag, 0)
+}
+
+func readJSONFile(path string, v interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
+}
@@ -0,0 +1,389 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/libcontainer"
+)
+
+func init() {
+	reexec.Init()
+}
+
+func newTestDriver(t *testing.T, root string) *driver {
+	factory, err := newFactory(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &driver{
+		root:             root,
+		factory:          factory,
+		activeContainers: make(map[string]libcontainer.Container),
+	}
+}
+
+func TestCreateShimDir(t *testing.T) {
+	tests := []struct {
+		tty, stdin bool
+		fifos      []string
+		missing    []string
+	}{
+		{false, false, []string{shimStdout, shimStderr, shimExit}, []string{shimStdin, shimResize}},
+		{false, true, []string{shimStdout, shimStderr, shimExit, shimStdin}, []string{shimResize}},
+		{true, true, []string{shimStdout, shimExit, shimStdin, shimResize}, []string{shimStderr}},
+	}
+	for _, test := range tests {
+		tmp, err := ioutil.TempDir("", "docker-shim")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+		dir := filepath.Join(tmp, "shim")
+
+		cfg := &shimConfig{ID: "test", Args: []string{"sh"}, Tty: test.tty, Stdin: test.stdin}
+		if err := createShimDir(dir, cfg); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range test.fifos {
+			fi, err := os.Stat(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode()&os.ModeNamedPipe == 0 || fi.Mode().Perm() != shimFifoFileMode {
+				t.Fatalf("expected %s to be a FIFO with mode %o, got %s", name, shimFifoFileMode, fi.Mode())
+			}
+		}
+		for _, name := range test.missing {
+			if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+				t.Fatalf("expected no %s with tty %v and stdin %v, got %v", name, test.tty, test.stdin, err)
+			}
+		}
+
+		var read shimConfig
+		if err := readJSONFile(filepath.Join(dir, shimConfigFile), &read); err != nil {
+			t.Fatal(err)
+		}
+		if read.ID != cfg.ID || read.Tty != cfg.Tty || read.Stdin != cfg.Stdin {
+			t.Fatalf("expected the configuration %+v, got %+v", cfg, read)
+		}
+	}
+}
+
+func TestShimAttach(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-shim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := &shimConfig{Stdin: true}
+	if err := createShimDir(dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// opening the FIFOs doesn't wait for the shim
+	s, err := openShim(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// the ends of the shim
+	stdout, err := openFifo(dir, shimStdout, syscall.O_RDWR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := openFifo(dir, shimStderr, syscall.O_RDWR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := openFifo(dir, shimStdin, syscall.O_RDONLY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	var outBuf, errBuf bytes.Buffer
+	s.attach(execdriver.NewPipes(ioutil.NopCloser(strings.NewReader("input")), &outBuf, &errBuf, true))
+	stdout.Write([]byte("output"))
+	stdout.Close()
+	stderr.Write([]byte("error"))
+	stderr.Close()
+
+	in, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(in) != "input" {
+		t.Fatalf("expected the standard input to be relayed, got %q", in)
+	}
+	s.copying.Wait()
+	if outBuf.String() != "output" || errBuf.String() != "error" {
+		t.Fatalf("expected the output to be copied, got %q and %q", outBuf.String(), errBuf.String())
+	}
+	// the standard input isn't closed until the daemon closed it
+	for i := 0; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, shimStdinClosed)); err == nil {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("expected %s to be created once the standard input is closed", shimStdinClosed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestShimResize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-shim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := &shimConfig{Tty: true}
+	if err := createShimDir(dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	s, err := openShim(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	resize, err := openFifo(dir, shimResize, syscall.O_RDONLY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resize.Close()
+	if err := s.Resize(24, 80); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(resize).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "24 80\n" {
+		t.Fatalf("expected the sizes of the tty, got %q", line)
+	}
+}
+
+func TestShimReadStarted(t *testing.T) {
+	tests := []struct {
+		started string
+		pid     int
+		err     string
+	}{
+		{`{"Pid":42}`, 42, ""},
+		{`{"Error":"no such file"}`, -1, "no such file"},
+		// the shim died before starting the container
+		{``, -1, "failed to start"},
+		{`{"Pid":`, -1, "failed to start"},
+	}
+	s := &shimClient{dir: "/shim"}
+	for _, test := range tests {
+		pid, err := s.readStarted(strings.NewReader(test.started))
+		if test.err == "" && err != nil {
+			t.Fatalf("expected %q to be read, got %v", test.started, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Fatalf("expected %q to fail with %q, got %v", test.started, test.err, err)
+		}
+		if pid != test.pid {
+			t.Fatalf("expected the pid %d of %q, got %d", test.pid, test.started, pid)
+		}
+	}
+}
+
+func TestShimStartReportsError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-shim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := &shimConfig{}
+	if err := createShimDir(dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, shimConfigFile), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := openShim(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// the shim reports it couldn't read its configuration
+	_, err = s.start()
+	if err == nil || strings.Contains(err.Error(), "failed to start") {
+		t.Fatalf("expected the error of the shim, got %v", err)
+	}
+}
+
+func TestShimWaitExitStatus(t *testing.T) {
+	tests := []struct {
+		exitStatus string // the content of exit.json, none if empty
+		expected   execdriver.ExitStatus
+		err        bool
+	}{
+		{`{"ExitCode":2,"OOMKilled":true}`, execdriver.ExitStatus{ExitCode: 2, OOMKilled: true}, false},
+		// the shim was killed before writing the exit status
+		{``, execdriver.ExitStatus{ExitCode: -1}, true},
+		{`{"ExitCode":`, execdriver.ExitStatus{ExitCode: -1}, true},
+	}
+	for _, test := range tests {
+		dir, err := ioutil.TempDir("", "docker-shim")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		cfg := &shimConfig{}
+		if err := createShimDir(dir, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if test.exitStatus != "" {
+			if err := ioutil.WriteFile(filepath.Join(dir, shimExitFile), []byte(test.exitStatus), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := openShim(dir, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// no shim holds the exit FIFO open
+		exitStatus, err := s.wait()
+		s.Close()
+		if test.err && (err == nil || !strings.Contains(err.Error(), shimLogFile)) {
+			t.Fatalf("expected reading %q to fail, got %v", test.exitStatus, err)
+		}
+		if !test.err && err != nil {
+			t.Fatal(err)
+		}
+		if exitStatus != test.expected {
+			t.Fatalf("expected the exit status %+v of %q, got %+v", test.expected, test.exitStatus, exitStatus)
+		}
+	}
+}
+
+func TestRestoreAfterDaemonRestart(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-native")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// the first daemon starts the shim, and dies
+	d := newTestDriver(t, root)
+	dir := d.shimDir("test")
+	cfg := &shimConfig{ID: "test"}
+	if err := createShimDir(dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	s, err := openShim(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit, err := openFifo(dir, shimExit, syscall.O_RDWR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := openFifo(dir, shimStdout, syscall.O_RDWR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := openFifo(dir, shimStderr, syscall.O_RDWR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, shimPidFile), []byte("4242"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// the output of the container is kept while the daemon is down
+	stdout.Write([]byte("before "))
+
+	// the restarted daemon re-attaches to the shim
+	d = newTestDriver(t, root)
+	var outBuf bytes.Buffer
+	restored := make(chan int)
+	done := make(chan error)
+	var exitStatus execdriver.ExitStatus
+	go func() {
+		var err error
+		exitStatus, err = d.Restore(&execdriver.Command{ID: "test"}, execdriver.NewPipes(nil, &outBuf, ioutil.Discard, false), func(_ *execdriver.ProcessConfig, pid int) {
+			restored <- pid
+		})
+		done <- err
+	}()
+	select {
+	case pid := <-restored:
+		if pid != 4242 {
+			t.Fatalf("expected the pid of the container, got %d", pid)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the container to be restored")
+	}
+
+	// the container exits
+	stdout.Write([]byte("after"))
+	if err := ioutil.WriteFile(filepath.Join(dir, shimExitFile), []byte(`{"ExitCode":3}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Close()
+	stderr.Close()
+	exit.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the container to exit")
+	}
+	if exitStatus.ExitCode != 3 {
+		t.Fatalf("expected the exit code of the container, got %d", exitStatus.ExitCode)
+	}
+	if outBuf.String() != "before after" {
+		t.Fatalf("expected the output of the container, got %q", outBuf.String())
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected the directory of the shim to be removed, got %v", err)
+	}
+}
+
+func TestRestoreWithoutShim(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-native")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	d := newTestDriver(t, root)
+
+	if _, err := d.Restore(&execdriver.Command{ID: "test"}, nil, nil); err != execdriver.ErrNotRestorable {
+		t.Fatalf("expected a container without a shim not to be restorable, got %v", err)
+	}
+	if _, err := d.Restore(&execdriver.Command{ID: "test", Runtime: &execdriver.Runtime{}}, nil, nil); err != execdriver.ErrNotRestorable {
+		t.Fatalf("expected a container of an OCI runtime not to be restorable, got %v", err)
+	}
+
+	// the shim died before writing the pid of the container
+	if err := createShimDir(d.shimDir("test"), &shimConfig{ID: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Restore(&execdriver.Command{ID: "test"}, nil, nil); err == nil {
+		t.Fatal("expected a shim without the pid of its container to fail to restore")
+	}
+}
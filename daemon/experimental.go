@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// experimentalFeatures are the descriptions of the features of the daemon
+// shipped behind a gate, by name. The features are disabled unless the daemon
+// runs with --experimental, or with --experimental-feature naming them. A
+// feature registers itself with registerExperimental in an init function,
+// and checks it's enabled with CheckExperimental at its entry points.
+var experimentalFeatures = make(map[string]string)
+
+// registerExperimental registers the experimental feature name.
+func registerExperimental(name, description string) {
+	if _, exists := experimentalFeatures[name]; exists {
+		panic(fmt.Sprintf("experimental feature %s registered twice", name))
+	}
+	experimentalFeatures[name] = description
+}
+
+// validateExperimental returns an error if the configuration enables an
+// experimental feature that doesn't exist.
+func validateExperimental(config *Config) error {
+	for _, name := range config.ExperimentalFeatures {
+		if _, exists := experimentalFeatures[name]; !exists {
+			names := make([]string, 0, len(experimentalFeatures))
+			for name := range experimentalFeatures {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if len(names) == 0 {
+				return fmt.Errorf("Unknown experimental feature %s, this daemon has none", name)
+			}
+			return fmt.Errorf("Unknown experimental feature %s, the experimental features are: %s", name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// ExperimentalEnabled returns whether the experimental feature name is
+// enabled on the daemon.
+func (daemon *Daemon) ExperimentalEnabled(name string) bool {
+	if daemon.config.Experimental {
+		return true
+	}
+	for _, n := range daemon.config.ExperimentalFeatures {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckExperimental returns an error telling how to enable the experimental
+// feature name if it's disabled on the daemon.
+func (daemon *Daemon) CheckExperimental(name string) error {
+	if daemon.ExperimentalEnabled(name) {
+		return nil
+	}
+	return fmt.Errorf("%s is an experimental feature, disabled on this daemon: start the daemon with --experimental, or --experimental-feature=%s", name, name)
+}
+
+// experimentalInfo returns the experimental features of the daemon, by
+// name.
+func (daemon *Daemon) experimentalInfo() []types.ExperimentalFeature {
+	features := []types.ExperimentalFeature{}
+	for name, description := range experimentalFeatures {
+		features = append(features, types.ExperimentalFeature{
+			Name:        name,
+			Description: description,
+			Enabled:     daemon.ExperimentalEnabled(name),
+		})
+	}
+	sort.Sort(experimentalByName(features))
+	return features
+}
+
+type experimentalByName []types.ExperimentalFeature
+
+func (f experimentalByName) Len() int           { return len(f) }
+func (f experimentalByName) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f experimentalByName) Less(i, j int) bool { return f[i].Name < f[j].Name }
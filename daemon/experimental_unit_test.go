@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExperimental(t *testing.T) {
+	registerExperimental("test-feature", "A feature of the tests")
+	defer delete(experimentalFeatures, "test-feature")
+
+	if err := validateExperimental(&Config{CommonConfig: CommonConfig{ExperimentalFeatures: []string{"unknown"}}}); err == nil || !strings.Contains(err.Error(), "test-feature") {
+		t.Fatalf("Expected an unknown feature to be refused, listing the features, got %v", err)
+	}
+	if err := validateExperimental(&Config{CommonConfig: CommonConfig{ExperimentalFeatures: []string{"test-feature"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := &Daemon{config: &Config{}}
+	if err := daemon.CheckExperimental("test-feature"); err == nil || !strings.Contains(err.Error(), "--experimental-feature=test-feature") {
+		t.Fatalf("Expected the disabled feature to be refused, got %v", err)
+	}
+	if info := daemon.experimentalInfo(); len(info) != 1 || info[0].Name != "test-feature" || info[0].Enabled {
+		t.Fatalf("Expected the feature to be listed disabled, got %v", info)
+	}
+
+	daemon.config.ExperimentalFeatures = []string{"test-feature"}
+	if err := daemon.CheckExperimental("test-feature"); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.CheckExperimental("other-feature"); err == nil {
+		t.Fatal("Expected a feature not enabled to be refused")
+	}
+
+	daemon.config = &Config{CommonConfig: CommonConfig{Experimental: true}}
+	if err := daemon.CheckExperimental("other-feature"); err != nil {
+		t.Fatal(err)
+	}
+	if info := daemon.experimentalInfo(); !info[0].Enabled {
+		t.Fatal("Expected --experimental to enable all the features")
+	}
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer/configs"
+	"github.com/docker/libcontainer/devices"
+)
+
+// gpuDevicesDir is the directory of the device nodes of the GPUs, a node
+// /dev/nvidiaN per GPU of index N.
+var gpuDevicesDir = "/dev"
+
+// gpuControlDevices are the device nodes of the driver shared by all the
+// GPUs, added to the containers with GPUs when they exist on the host.
+var gpuControlDevices = []string{"nvidiactl", "nvidia-uvm", "nvidia-uvm-tools", "nvidia-modeset"}
+
+// hostGpus returns the indexes of the GPUs of the host, in order.
+func hostGpus() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(gpuDevicesDir, "nvidia[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var indexes []int
+	for _, p := range paths {
+		if i, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(p), "nvidia")); err == nil {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+	gpus := make([]string, len(indexes))
+	for i, index := range indexes {
+		gpus[i] = strconv.Itoa(index)
+	}
+	return gpus, nil
+}
+
+// gpuDevices returns the device nodes of the GPUs of indexes gpus and of the
+// driver, allowed in the device cgroup of the container.
+func gpuDevices(gpus []string) ([]*configs.Device, error) {
+	var devs []*configs.Device
+	for _, gpu := range gpus {
+		dev, err := devices.DeviceFromPath(filepath.Join(gpuDevicesDir, "nvidia"+gpu), "rwm")
+		if err != nil {
+			return nil, fmt.Errorf("Error gathering the device of GPU %s: %v", gpu, err)
+		}
+		devs = append(devs, dev)
+	}
+	for _, name := range gpuControlDevices {
+		dev, err := devices.DeviceFromPath(filepath.Join(gpuDevicesDir, name), "rwm")
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Error gathering the GPU device %s: %v", name, err)
+		}
+		devs = append(devs, dev)
+	}
+	for _, dev := range devs {
+		// the nodes keep the paths of the host in the container
+		dev.Path = filepath.Join("/dev", filepath.Base(dev.Path))
+	}
+	return devs, nil
+}
+
+// runGpuHook runs the GPU hook with the indexes of the GPUs of the container
+// as arguments. The hook prints the absolute paths of the driver files of
+// the host, the libraries and the tools, one per line, which are bind
+// mounted read-only at the same paths in the container.
+func runGpuHook(hook string, gpus []string) ([]execdriver.Mount, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(hook, gpus...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Error running the GPU hook %s: %v: %s", hook, err, strings.TrimSpace(stderr.String()))
+	}
+	var mounts []execdriver.Mount
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		p := strings.TrimSpace(scanner.Text())
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			return nil, fmt.Errorf("The GPU hook %s listed the relative path %s", hook, p)
+		}
+		mounts = append(mounts, execdriver.Mount{
+			Source:      p,
+			Destination: filepath.Clean(p),
+			Writable:    false,
+			Private:     true,
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// setupGpus gathers the device nodes of the GPUs of the container, and the
+// driver files to mount in the container listed by the GPU hook of the
+// daemon, if any.
+func (container *Container) setupGpus() error {
+	container.gpuDevices = nil
+	container.gpuMounts = nil
+	mode := container.hostConfig.Gpus
+	if mode.IsNone() {
+		return nil
+	}
+
+	gpus := mode.Devices()
+	if mode.IsAll() {
+		var err error
+		if gpus, err = hostGpus(); err != nil {
+			return err
+		}
+		if len(gpus) == 0 {
+			return fmt.Errorf("Cannot start container %s with all the GPUs: no GPU found on the host", container.ID)
+		}
+	}
+	devs, err := gpuDevices(gpus)
+	if err != nil {
+		return err
+	}
+	var mounts []execdriver.Mount
+	if hook := container.daemon.config.GpuHook; hook != "" {
+		if mounts, err = runGpuHook(hook, gpus); err != nil {
+			return err
+		}
+	}
+	container.gpuDevices = devs
+	container.gpuMounts = mounts
+	return nil
+}
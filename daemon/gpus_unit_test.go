@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHostGpus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-gpus-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { gpuDevicesDir = d }(gpuDevicesDir)
+	gpuDevicesDir = dir
+
+	for _, name := range []string{"nvidia10", "nvidia2", "nvidiactl", "nvidia-uvm", "nvidia0"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gpus, err := hostGpus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(gpus, ",") != "0,2,10" {
+		t.Fatalf("Expected the GPUs 0, 2 and 10, got %v", gpus)
+	}
+
+	// the nodes must be devices
+	if _, err := gpuDevices([]string{"0"}); err == nil || !strings.Contains(err.Error(), "GPU 0") {
+		t.Fatalf("Expected an error for GPU 0, got %v", err)
+	}
+	if _, err := gpuDevices([]string{"3"}); err == nil || !strings.Contains(err.Error(), "GPU 3") {
+		t.Fatalf("Expected an error for the missing GPU 3, got %v", err)
+	}
+}
+
+func TestRunGpuHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-gpus-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	hook := filepath.Join(dir, "hook")
+	script := "#!/bin/sh\n[ \"$*\" = \"0 2\" ] || exit 1\necho /usr/lib/libcuda.so.1\necho\necho /usr/bin/nvidia-smi\n"
+	if err := ioutil.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mounts, err := runGpuHook(hook, []string{"0", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mounts) != 2 || mounts[0].Destination != "/usr/lib/libcuda.so.1" || mounts[1].Source != "/usr/bin/nvidia-smi" || mounts[1].Writable {
+		t.Fatalf("Unexpected mounts of the GPU hook: %#v", mounts)
+	}
+
+	if _, err := runGpuHook(hook, []string{"1"}); err == nil {
+		t.Fatal("Expected the failure of the GPU hook to be an error")
+	}
+	if err := ioutil.WriteFile(hook, []byte("#!/bin/sh\necho usr/lib/libcuda.so.1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runGpuHook(hook, nil); err == nil || !strings.Contains(err.Error(), "relative path") {
+		t.Fatalf("Expected an error for the relative path, got %v", err)
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/directory"
+	"github.com/docker/docker/pkg/fscrypt"
 	mountpk "github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/libcontainer/label"
@@ -172,9 +173,24 @@ func (a *Driver) Exists(id string) bool {
 // Three folders are created for each id
 // mnt, layers, and diff
 func (a *Driver) Create(id, parent string) error {
+	return a.create(id, parent, "")
+}
+
+// CreateEncrypted creates a layer whose diff directory, the writable branch
+// of its mount, is encrypted with the fscrypt key of descriptor.
+func (a *Driver) CreateEncrypted(id, parent, descriptor string) error {
+	return a.create(id, parent, descriptor)
+}
+
+func (a *Driver) create(id, parent, descriptor string) error {
 	if err := a.createDirsFor(id); err != nil {
 		return err
 	}
+	if descriptor != "" {
+		if err := fscrypt.SetPolicy(path.Join(a.rootPath(), "diff", id), descriptor); err != nil {
+			return err
+		}
+	}
 	// Write the layers metadata
 	f, err := os.Create(path.Join(a.rootPath(), "layers", id))
 	if err != nil {
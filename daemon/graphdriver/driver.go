@@ -124,6 +124,15 @@ type Driver interface {
 	DiffSize(id, parent string) (size int64, err error)
 }
 
+// EncryptedDriver is implemented by the drivers which can encrypt the files
+// written to a layer with fscrypt, such as the read-write layers of the
+// containers.
+type EncryptedDriver interface {
+	// CreateEncrypted creates a new layer like Create, the files written to
+	// it being encrypted with the fscrypt key of the given descriptor.
+	CreateEncrypted(id, parent, descriptor string) error
+}
+
 func init() {
 	drivers = make(map[string]InitFunc)
 }
@@ -0,0 +1,84 @@
+package graphdriver
+
+import (
+	"time"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/metrics"
+)
+
+var operationDuration = metrics.NewHistogram("engine_daemon_graphdriver_operation_duration_seconds",
+	"The duration of the operations of the graph driver in seconds.", metrics.DefaultBuckets, "driver", "operation")
+
+func init() {
+	metrics.MustRegister(operationDuration)
+}
+
+// instrumentedDriver is a Driver observing the duration of the operations of
+// the driver it wraps.
+type instrumentedDriver struct {
+	Driver
+}
+
+// InstrumentedDriver returns a driver exposing the duration of the
+// operations of driver in the metrics of the daemon.
+func InstrumentedDriver(driver Driver) Driver {
+	return &instrumentedDriver{Driver: driver}
+}
+
+func (d *instrumentedDriver) observe(operation string, start time.Time) {
+	operationDuration.ObserveSince(start, d.String(), operation)
+}
+
+func (d *instrumentedDriver) Create(id, parent string) error {
+	defer d.observe("create", time.Now())
+	return d.Driver.Create(id, parent)
+}
+
+// CreateEncrypted creates an encrypted layer if the wrapped driver supports
+// it.
+func (d *instrumentedDriver) CreateEncrypted(id, parent, descriptor string) error {
+	ed, ok := d.Driver.(EncryptedDriver)
+	if !ok {
+		return ErrNotSupported
+	}
+	defer d.observe("create", time.Now())
+	return ed.CreateEncrypted(id, parent, descriptor)
+}
+
+func (d *instrumentedDriver) Remove(id string) error {
+	defer d.observe("remove", time.Now())
+	return d.Driver.Remove(id)
+}
+
+func (d *instrumentedDriver) Get(id, mountLabel string) (string, error) {
+	defer d.observe("get", time.Now())
+	return d.Driver.Get(id, mountLabel)
+}
+
+func (d *instrumentedDriver) Put(id string) error {
+	defer d.observe("put", time.Now())
+	return d.Driver.Put(id)
+}
+
+// Diff observes the duration until the archive is returned, not until it is
+// read.
+func (d *instrumentedDriver) Diff(id, parent string) (archive.Archive, error) {
+	defer d.observe("diff", time.Now())
+	return d.Driver.Diff(id, parent)
+}
+
+func (d *instrumentedDriver) Changes(id, parent string) ([]archive.Change, error) {
+	defer d.observe("changes", time.Now())
+	return d.Driver.Changes(id, parent)
+}
+
+func (d *instrumentedDriver) ApplyDiff(id, parent string, diff archive.ArchiveReader) (int64, error) {
+	defer d.observe("applydiff", time.Now())
+	return d.Driver.ApplyDiff(id, parent, diff)
+}
+
+func (d *instrumentedDriver) DiffSize(id, parent string) (int64, error) {
+	defer d.observe("diffsize", time.Now())
+	return d.Driver.DiffSize(id, parent)
+}
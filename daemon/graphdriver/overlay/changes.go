@@ -0,0 +1,118 @@
+// +build linux
+
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/docker/docker/pkg/archive"
+)
+
+type changesByPath []archive.Change
+
+func (c changesByPath) Less(i, j int) bool { return c[i].Path < c[j].Path }
+func (c changesByPath) Len() int           { return len(c) }
+func (c changesByPath) Swap(i, j int)      { c[j], c[i] = c[i], c[j] }
+
+// copiedUpperChanges returns the changes of the upper directory upperDir,
+// from the lower layer, which are not in the upper directory parentUpperDir
+// it was copied from, with the files of parentUpperDir deleted since.
+func copiedUpperChanges(changes []archive.Change, upperDir, parentUpperDir string) ([]archive.Change, error) {
+	var result []archive.Change
+	for _, change := range changes {
+		f, err := os.Lstat(filepath.Join(upperDir, change.Path))
+		if err != nil {
+			return nil, err
+		}
+		parentF, err := os.Lstat(filepath.Join(parentUpperDir, change.Path))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			result = append(result, change)
+			continue
+		}
+		if sameFile(f, parentF) {
+			continue
+		}
+		if change.Kind != archive.ChangeDelete {
+			if isWhiteout(parentF) {
+				// the file was deleted in the parent and created again
+				change.Kind = archive.ChangeAdd
+			} else {
+				change.Kind = archive.ChangeModify
+			}
+		}
+		result = append(result, change)
+	}
+
+	// The files of the parent only in its upper directory are removed from
+	// upperDir when they're deleted, without whiteouts.
+	err := filepath.Walk(parentUpperDir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(parentUpperDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.Join("/", rel)
+		if rel == "/" || isWhiteout(f) {
+			return nil
+		}
+		if _, err := os.Lstat(filepath.Join(upperDir, rel)); err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			result = append(result, archive.Change{Path: rel, Kind: archive.ChangeDelete})
+			if f.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The directories of the changes are changed too, to restore their
+	// permissions with them.
+	seen := make(map[string]bool)
+	for _, change := range result {
+		seen[change.Path] = true
+	}
+	for _, change := range result {
+		for dir := filepath.Dir(change.Path); dir != "/" && !seen[dir]; dir = filepath.Dir(dir) {
+			seen[dir] = true
+			result = append(result, archive.Change{Path: dir, Kind: archive.ChangeModify})
+		}
+	}
+	sort.Sort(changesByPath(result))
+	return result, nil
+}
+
+// sameFile returns whether a and b are the same copied file. Like the naive
+// diff, the directories are compared on their mode and owner only.
+func sameFile(a, b os.FileInfo) bool {
+	sa, ok := a.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	sb, ok := b.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	return a.Mode() == b.Mode() &&
+		sa.Uid == sb.Uid && sa.Gid == sb.Gid && sa.Rdev == sb.Rdev &&
+		(a.IsDir() || (a.Size() == b.Size() && sa.Mtim == sb.Mtim))
+}
+
+// isWhiteout returns whether f is an overlay whiteout, a character device
+// with the 0/0 device number.
+func isWhiteout(f os.FileInfo) bool {
+	s, ok := f.Sys().(*syscall.Stat_t)
+	return ok && f.Mode()&os.ModeCharDevice != 0 && s.Rdev == 0
+}
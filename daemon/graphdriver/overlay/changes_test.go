@@ -0,0 +1,99 @@
+// +build linux
+
+package overlay
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/pkg/archive"
+)
+
+func TestCopiedUpperChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-overlay-changes-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the upper directory of the parent has the files of the init layer
+	parentUpper := path.Join(dir, "parent")
+	for _, p := range []string{"etc", "dev"} {
+		if err := os.MkdirAll(path.Join(parentUpper, p), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []string{"etc/hosts", "etc/hostname", "dev/console"} {
+		if err := ioutil.WriteFile(path.Join(parentUpper, p), []byte(p), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	upper := path.Join(dir, "upper")
+	if err := copyDir(parentUpper, upper, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// the layer modifies hosts, deletes hostname and console, and adds a file
+	if err := ioutil.WriteFile(path.Join(upper, "etc/hosts"), []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path.Join(upper, "etc/hostname")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(path.Join(upper, "dev")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(path.Join(upper, "var/lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(upper, "var/lib/data"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lower := path.Join(dir, "lower")
+	for _, p := range []string{"etc", "var"} {
+		if err := os.MkdirAll(path.Join(lower, p), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changes, err := archive.OverlayChanges([]string{lower}, upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err = copiedUpperChanges(changes, upper, parentUpper)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []archive.Change{
+		{Path: "/dev", Kind: archive.ChangeDelete},
+		{Path: "/etc", Kind: archive.ChangeModify},
+		{Path: "/etc/hostname", Kind: archive.ChangeDelete},
+		{Path: "/etc/hosts", Kind: archive.ChangeModify},
+		{Path: "/var", Kind: archive.ChangeModify},
+		{Path: "/var/lib", Kind: archive.ChangeAdd},
+		{Path: "/var/lib/data", Kind: archive.ChangeAdd},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Expected the changes %v, got %v", expected, changes)
+	}
+
+	// a file deleted in the parent and created again is added
+	if err := syscall.Mknod(path.Join(parentUpper, "etc/motd"), syscall.S_IFCHR, 0); err != nil {
+		t.Skipf("Cannot create an overlay whiteout: %v", err)
+	}
+	if err := ioutil.WriteFile(path.Join(upper, "etc/motd"), []byte("motd"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = copiedUpperChanges([]archive.Change{{Path: "/etc/motd", Kind: archive.ChangeModify}}, upper, parentUpper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) == 0 || changes[len(changes)-1] != (archive.Change{Path: "/etc/motd", Kind: archive.ChangeAdd}) {
+		t.Fatalf("Expected the file to be added, got %v", changes)
+	}
+}
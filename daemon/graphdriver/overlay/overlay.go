@@ -16,14 +16,17 @@ import (
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/fscrypt"
 	"github.com/docker/libcontainer/label"
 )
 
 // This is a small wrapper over the NaiveDiffWriter that lets us have a custom
-// implementation of ApplyDiff()
+// implementation of ApplyDiff(), and of Diff(), Changes() and DiffSize()
+// scanning the upper directory of a layer instead of its whole tree
 
 var (
 	ErrApplyDiffFallback = fmt.Errorf("Fall back to normal ApplyDiff")
+	ErrDiffFallback      = fmt.Errorf("Fall back to normal Diff")
 )
 
 type ApplyDiffProtoDriver interface {
@@ -31,12 +34,19 @@ type ApplyDiffProtoDriver interface {
 	ApplyDiff(id, parent string, diff archive.ArchiveReader) (size int64, err error)
 }
 
+type DiffProtoDriver interface {
+	ApplyDiffProtoDriver
+	Diff(id, parent string) (archive.Archive, error)
+	Changes(id, parent string) ([]archive.Change, error)
+	DiffSize(id, parent string) (size int64, err error)
+}
+
 type naiveDiffDriverWithApply struct {
 	graphdriver.Driver
-	applyDiff ApplyDiffProtoDriver
+	applyDiff DiffProtoDriver
 }
 
-func NaiveDiffDriverWithApply(driver ApplyDiffProtoDriver) graphdriver.Driver {
+func NaiveDiffDriverWithApply(driver DiffProtoDriver) graphdriver.Driver {
 	return &naiveDiffDriverWithApply{
 		Driver:    graphdriver.NaiveDiffDriver(driver),
 		applyDiff: driver,
@@ -51,6 +61,30 @@ func (d *naiveDiffDriverWithApply) ApplyDiff(id, parent string, diff archive.Arc
 	return b, err
 }
 
+func (d *naiveDiffDriverWithApply) Diff(id, parent string) (archive.Archive, error) {
+	arch, err := d.applyDiff.Diff(id, parent)
+	if err == ErrDiffFallback {
+		return d.Driver.Diff(id, parent)
+	}
+	return arch, err
+}
+
+func (d *naiveDiffDriverWithApply) Changes(id, parent string) ([]archive.Change, error) {
+	changes, err := d.applyDiff.Changes(id, parent)
+	if err == ErrDiffFallback {
+		return d.Driver.Changes(id, parent)
+	}
+	return changes, err
+}
+
+func (d *naiveDiffDriverWithApply) DiffSize(id, parent string) (int64, error) {
+	size, err := d.applyDiff.DiffSize(id, parent)
+	if err == ErrDiffFallback {
+		return d.Driver.DiffSize(id, parent)
+	}
+	return size, err
+}
+
 // This backend uses the overlay union filesystem for containers
 // plus hard link file sharing for images.
 
@@ -171,7 +205,17 @@ func (d *Driver) Cleanup() error {
 	return nil
 }
 
-func (d *Driver) Create(id string, parent string) (retErr error) {
+func (d *Driver) Create(id string, parent string) error {
+	return d.create(id, parent, "")
+}
+
+// CreateEncrypted creates a layer whose directory, with its upper and work
+// directories, is encrypted with the fscrypt key of descriptor.
+func (d *Driver) CreateEncrypted(id, parent, descriptor string) error {
+	return d.create(id, parent, descriptor)
+}
+
+func (d *Driver) create(id, parent, descriptor string) (retErr error) {
 	dir := d.dir(id)
 	if err := os.MkdirAll(path.Dir(dir), 0700); err != nil {
 		return err
@@ -187,6 +231,12 @@ func (d *Driver) Create(id string, parent string) (retErr error) {
 		}
 	}()
 
+	if descriptor != "" {
+		if err := fscrypt.SetPolicy(dir, descriptor); err != nil {
+			return err
+		}
+	}
+
 	// Toplevel images are just a "root" dir
 	if parent == "" {
 		if err := os.Mkdir(path.Join(dir, "root"), 0755); err != nil {
@@ -387,6 +437,68 @@ func (d *Driver) ApplyDiff(id string, parent string, diff archive.ArchiveReader)
 	return
 }
 
+// Diff produces an archive of the changes between the layer id and its
+// parent, from the upper directory of id.
+func (d *Driver) Diff(id, parent string) (archive.Archive, error) {
+	changes, err := d.Changes(id, parent)
+	if err != nil {
+		return nil, err
+	}
+	return archive.ExportChanges(path.Join(d.dir(id), "upper"), changes)
+}
+
+// DiffSize returns the size in bytes of the changes between the layer id
+// and its parent, from the upper directory of id.
+func (d *Driver) DiffSize(id, parent string) (int64, error) {
+	changes, err := d.Changes(id, parent)
+	if err != nil {
+		return 0, err
+	}
+	return archive.ChangesSize(path.Join(d.dir(id), "upper"), changes), nil
+}
+
+// Changes produces the changes between the layer id and its parent by
+// scanning the upper directory of id, when id is an overlay of its parent:
+// either the parent is the lower layer of id, like the init layer of a
+// container, or the upper directory of the parent was copied in the one of
+// id, like the layer of a container. The other layers fall back to the
+// comparison of their whole trees.
+func (d *Driver) Changes(id, parent string) ([]archive.Change, error) {
+	if parent == "" {
+		return nil, ErrDiffFallback
+	}
+	dir := d.dir(id)
+	lowerId, err := ioutil.ReadFile(path.Join(dir, "lower-id"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrDiffFallback
+		}
+		return nil, err
+	}
+	upperDir := path.Join(dir, "upper")
+	lowerDir := path.Join(d.dir(string(lowerId)), "root")
+
+	if parent == string(lowerId) {
+		return archive.OverlayChanges([]string{lowerDir}, upperDir)
+	}
+	parentLowerId, err := ioutil.ReadFile(path.Join(d.dir(parent), "lower-id"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrDiffFallback
+		}
+		return nil, err
+	}
+	if string(parentLowerId) != string(lowerId) {
+		return nil, ErrDiffFallback
+	}
+
+	changes, err := archive.OverlayChanges([]string{lowerDir}, upperDir)
+	if err != nil {
+		return nil, err
+	}
+	return copiedUpperChanges(changes, upperDir, path.Join(d.dir(parent), "upper"))
+}
+
 func (d *Driver) Exists(id string) bool {
 	_, err := os.Stat(d.dir(id))
 	return err == nil
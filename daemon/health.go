@@ -0,0 +1,245 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/runconfig"
+)
+
+const (
+	// defaults of the health check options
+	defaultProbeInterval = 30 * time.Second
+	defaultProbeTimeout  = 30 * time.Second
+	defaultProbeRetries  = 3
+
+	// probeKillTimeout is how long a probe which exceeded its timeout is
+	// given to exit once killed, and to release its streams.
+	probeKillTimeout = 10 * time.Second
+
+	// maxHealthLogEntries is the number of results kept in the log of the
+	// health of a container.
+	maxHealthLogEntries = 5
+
+	// maxHealthOutputLen is how much of the output of a check is kept.
+	maxHealthOutputLen = 4096
+)
+
+// Health is the state of the health check of a container while it runs.
+type Health struct {
+	types.Health
+	stop chan struct{} // closed to stop the health monitor
+}
+
+// String returns a human-readable description of the health, for docker ps.
+func (h *Health) String() string {
+	if h.Status == types.Starting {
+		return "health: starting"
+	}
+	return h.Status
+}
+
+// initHealthMonitor starts the health monitor of the container if its
+// configuration has a health check, after stopping the one of a previous run.
+// The container must be locked.
+func (container *Container) initHealthMonitor() {
+	container.stopHealthMonitor()
+	container.Health = nil
+
+	config := container.Config.Healthcheck
+	if config == nil || len(config.Test) == 0 || config.Test[0] == "NONE" {
+		return
+	}
+
+	h := &Health{
+		Health: types.Health{Status: types.Starting},
+		stop:   make(chan struct{}),
+	}
+	container.Health = h
+	go container.monitorHealth(config, h.stop)
+}
+
+// stopHealthMonitor stops the health monitor of the container, if it has one.
+// The container must be locked.
+func (container *Container) stopHealthMonitor() {
+	if container.Health != nil && container.Health.stop != nil {
+		close(container.Health.stop)
+		container.Health.stop = nil
+	}
+}
+
+// monitorHealth runs the health check of the container at every interval
+// until stop is closed.
+func (container *Container) monitorHealth(config *runconfig.HealthConfig, stop chan struct{}) {
+	interval := config.Interval
+	if interval == 0 {
+		interval = defaultProbeInterval
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		if container.IsPaused() {
+			continue
+		}
+
+		result, err := container.runHealthCheck(config)
+		if err != nil {
+			logrus.Warnf("Health check error for container %s: %v", container.ID, err)
+			result = &types.HealthcheckResult{
+				Start:    time.Now(),
+				End:      time.Now(),
+				ExitCode: -1,
+				Output:   err.Error(),
+			}
+		}
+		container.handleHealthResult(config, result, stop)
+	}
+}
+
+// runHealthCheck runs the health check once in the container, with an exec.
+func (container *Container) runHealthCheck(config *runconfig.HealthConfig) (*types.HealthcheckResult, error) {
+	var cmd []string
+	switch config.Test[0] {
+	case "CMD":
+		cmd = config.Test[1:]
+	case "CMD-SHELL":
+		cmd = append([]string{"/bin/sh", "-c"}, config.Test[1:]...)
+	default:
+		return nil, fmt.Errorf("Unknown health check type %s", config.Test[0])
+	}
+	if len(cmd) == 0 {
+		return nil, fmt.Errorf("No command in the health check")
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultProbeTimeout
+	}
+
+	d := container.daemon
+	execID, err := d.ContainerExecCreate(&runconfig.ExecConfig{
+		Container:    container.ID,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	execConfig := d.execCommands.Get(execID)
+	defer d.unregisterExecCommand(execConfig)
+
+	output := &limitedBuffer{}
+	result := &types.HealthcheckResult{Start: time.Now()}
+	done := make(chan error, 1)
+	go func() {
+		done <- d.ContainerExecStart(execID, nil, output, output)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+		result.ExitCode = execConfig.ExitCode
+	case <-time.After(timeout):
+		result.ExitCode = -1
+		output.WriteString(fmt.Sprintf("Health check exceeded timeout (%v)", timeout))
+		// the probe is killed, its streams are closed when it exits
+		if err := execConfig.kill(); err != nil {
+			logrus.Warnf("Error killing the health check of container %s: %v", container.ID, err)
+		}
+		select {
+		case <-done:
+		case <-time.After(probeKillTimeout):
+			logrus.Warnf("Health check of container %s still running %v after it was killed", container.ID, probeKillTimeout)
+		}
+	}
+	result.End = time.Now()
+	result.Output = output.String()
+	return result, nil
+}
+
+// handleHealthResult records result in the health of the container, and
+// updates its status according to the number of failures in a row. The result
+// is dropped if the monitor identified by stop has been stopped meanwhile.
+func (container *Container) handleHealthResult(config *runconfig.HealthConfig, result *types.HealthcheckResult, stop chan struct{}) {
+	container.Lock()
+	defer container.Unlock()
+
+	h := container.Health
+	if h == nil || h.stop != stop {
+		// the container stopped while the check ran
+		return
+	}
+
+	retries := config.Retries
+	if retries == 0 {
+		retries = defaultProbeRetries
+	}
+
+	h.Log = append(h.Log, result)
+	if len(h.Log) > maxHealthLogEntries {
+		h.Log = h.Log[len(h.Log)-maxHealthLogEntries:]
+	}
+
+	status := h.Status
+	if result.ExitCode == 0 {
+		h.FailingStreak = 0
+		status = types.Healthy
+	} else {
+		h.FailingStreak++
+		if h.FailingStreak >= retries {
+			status = types.Unhealthy
+		}
+	}
+
+	if status != h.Status {
+		h.Status = status
+		container.LogEvent("health_status: " + status)
+	}
+
+	if err := container.toDisk(); err != nil {
+		logrus.Errorf("Error saving the health of container %s: %v", container.ID, err)
+	}
+}
+
+// limitedBuffer is a buffer for the output of a health check, which only
+// keeps its first maxHealthOutputLen bytes.
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n := maxHealthOutputLen - b.buf.Len(); n < len(p) {
+		if n > 0 {
+			b.buf.Write(p[:n])
+		}
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) WriteString(s string) {
+	b.Write([]byte(s))
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
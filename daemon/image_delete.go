@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/parsers"
@@ -108,7 +110,7 @@ func (daemon *Daemon) imgDeleteHelper(name string, list *[]types.ImageDelete, fi
 				*list = append(*list, types.ImageDelete{
 					Untagged: utils.ImageReference(repoName, tag),
 				})
-				daemon.EventsService.Log("untag", img.ID, "")
+				daemon.EventsService.LogActor(events.ImageEventType, "untag", events.Actor{ID: img.ID, Attributes: img.ContainerConfig.Labels}, "")
 			}
 		}
 	}
@@ -124,7 +126,7 @@ func (daemon *Daemon) imgDeleteHelper(name string, list *[]types.ImageDelete, fi
 			*list = append(*list, types.ImageDelete{
 				Deleted: img.ID,
 			})
-			daemon.EventsService.Log("delete", img.ID, "")
+			daemon.EventsService.LogActor(events.ImageEventType, "delete", events.Actor{ID: img.ID, Attributes: img.ContainerConfig.Labels}, "")
 			if img.Parent != "" && !noprune {
 				err := daemon.imgDeleteHelper(img.Parent, list, false, force, noprune)
 				if first {
@@ -166,3 +168,87 @@ func (daemon *Daemon) canDeleteImage(imgID string, force bool) error {
 	}
 	return nil
 }
+
+var acceptedImagePruneFilterTags = map[string]struct{}{
+	"dangling": {},
+	"label":    {},
+	"until":    {},
+}
+
+// ImagesPrune removes the images used by no container, only the untagged
+// ones unless the filter dangling is false, matching the filters: the
+// images with a label, or the images created before the timestamp until.
+// The untagged parents of the images removed are removed with them. It
+// reports the images untagged and deleted, and the size of their layers.
+func (daemon *Daemon) ImagesPrune(filter string) (*types.ImagesPruneReport, error) {
+	pruneFilters, until, err := parsePruneFilters(filter, acceptedImagePruneFilterTags)
+	if err != nil {
+		return nil, err
+	}
+	danglingOnly := true
+	for _, value := range pruneFilters["dangling"] {
+		switch strings.ToLower(value) {
+		case "true", "1":
+		case "false", "0":
+			danglingOnly = false
+		default:
+			return nil, fmt.Errorf("Invalid filter 'dangling=%s'", value)
+		}
+	}
+
+	heads, err := daemon.Graph().Heads()
+	if err != nil {
+		return nil, err
+	}
+	// the images of the containers, and their parents, are kept
+	used := make(map[string]bool)
+	for _, container := range daemon.List() {
+		img, err := daemon.Graph().Get(container.ImageID)
+		if err != nil {
+			continue
+		}
+		img.WalkHistory(func(p *image.Image) error {
+			used[p.ID] = true
+			return nil
+		})
+	}
+	refs := daemon.Repositories().ByID()
+
+	report := &types.ImagesPruneReport{}
+	for id, img := range heads {
+		if used[id] || (danglingOnly && len(refs[id]) > 0) {
+			continue
+		}
+		if !until.IsZero() && !img.Created.Before(until) {
+			continue
+		}
+		if !pruneFilters.MatchKVList("label", img.ContainerConfig.Labels) {
+			continue
+		}
+
+		sizes := make(map[string]int64)
+		img.WalkHistory(func(p *image.Image) error {
+			sizes[p.ID] = p.Size
+			return nil
+		})
+		// a tagged image is deleted with its last reference
+		names := refs[id]
+		if len(names) == 0 {
+			names = []string{id}
+		}
+		for _, name := range names {
+			list, err := daemon.ImageDelete(name, false, false)
+			if err != nil {
+				logrus.Debugf("Error pruning image %s: %v", name, err)
+				break
+			}
+			for _, d := range list {
+				if d.Deleted != "" && sizes[d.Deleted] > 0 {
+					report.SpaceReclaimed += uint64(sizes[d.Deleted])
+				}
+			}
+			report.ImagesDeleted = append(report.ImagesDeleted, list...)
+		}
+	}
+	return report, nil
+}
@@ -3,6 +3,7 @@ package daemon
 import (
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -11,6 +12,7 @@ import (
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/parsers/kernel"
 	"github.com/docker/docker/pkg/parsers/operatingsystem"
+	"github.com/docker/docker/pkg/seccomp"
 	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
@@ -57,48 +59,86 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 	}
 
 	v := &types.Info{
-		ID:                 daemon.ID,
-		Containers:         len(daemon.List()),
-		Images:             imgcount,
-		Driver:             daemon.GraphDriver().String(),
-		DriverStatus:       daemon.GraphDriver().Status(),
-		MemoryLimit:        daemon.SystemConfig().MemoryLimit,
-		SwapLimit:          daemon.SystemConfig().SwapLimit,
-		CpuCfsPeriod:       daemon.SystemConfig().CpuCfsPeriod,
-		CpuCfsQuota:        daemon.SystemConfig().CpuCfsQuota,
-		IPv4Forwarding:     !daemon.SystemConfig().IPv4ForwardingDisabled,
-		Debug:              os.Getenv("DEBUG") != "",
-		NFd:                fileutils.GetTotalUsedFds(),
-		OomKillDisable:     daemon.SystemConfig().OomKillDisable,
-		NGoroutines:        runtime.NumGoroutine(),
-		SystemTime:         time.Now().Format(time.RFC3339Nano),
-		ExecutionDriver:    daemon.ExecutionDriver().Name(),
-		LoggingDriver:      daemon.defaultLogConfig.Type,
-		NEventsListener:    daemon.EventsService.SubscribersCount(),
-		KernelVersion:      kernelVersion,
-		OperatingSystem:    operatingSystem,
-		IndexServerAddress: registry.IndexServerAddress(),
-		RegistryConfig:     daemon.RegistryService.Config,
-		InitSha1:           dockerversion.INITSHA1,
-		InitPath:           initPath,
-		NCPU:               runtime.NumCPU(),
-		MemTotal:           meminfo.MemTotal,
-		DockerRootDir:      daemon.Config().Root,
-		Labels:             daemon.Config().Labels,
+		ID:                   daemon.ID,
+		Containers:           len(daemon.List()),
+		Images:               imgcount,
+		Driver:               daemon.GraphDriver().String(),
+		DriverStatus:         daemon.GraphDriver().Status(),
+		MemoryLimit:          daemon.SystemConfig().MemoryLimit,
+		SwapLimit:            daemon.SystemConfig().SwapLimit,
+		CpuCfsPeriod:         daemon.SystemConfig().CpuCfsPeriod,
+		CpuCfsQuota:          daemon.SystemConfig().CpuCfsQuota,
+		IPv4Forwarding:       !daemon.SystemConfig().IPv4ForwardingDisabled,
+		Debug:                os.Getenv("DEBUG") != "",
+		NFd:                  fileutils.GetTotalUsedFds(),
+		OomKillDisable:       daemon.SystemConfig().OomKillDisable,
+		NGoroutines:          runtime.NumGoroutine(),
+		SystemTime:           time.Now().Format(time.RFC3339Nano),
+		ExecutionDriver:      daemon.ExecutionDriver().Name(),
+		LoggingDriver:        daemon.defaultLogConfig.Type,
+		NEventsListener:      daemon.EventsService.SubscribersCount(),
+		KernelVersion:        kernelVersion,
+		OperatingSystem:      operatingSystem,
+		IndexServerAddress:   registry.IndexServerAddress(),
+		RegistryConfig:       daemon.RegistryService.Config,
+		InitSha1:             dockerversion.INITSHA1,
+		InitPath:             initPath,
+		NCPU:                 runtime.NumCPU(),
+		MemTotal:             meminfo.MemTotal,
+		DockerRootDir:        daemon.Config().Root,
+		Labels:               daemon.Config().Labels,
+		SecurityOptions:      daemon.securityOptions(),
+		DefaultCapAdd:        daemon.Config().DefaultCapAdd,
+		DefaultCapDrop:       daemon.Config().DefaultCapDrop,
+		Runtimes:             make(map[string]types.Runtime),
+		Experimental:         daemon.Config().Experimental,
+		ExperimentalFeatures: daemon.experimentalInfo(),
 	}
-
-	if httpProxy := os.Getenv("http_proxy"); httpProxy != "" {
-		v.HttpProxy = httpProxy
-	}
-	if httpsProxy := os.Getenv("https_proxy"); httpsProxy != "" {
-		v.HttpsProxy = httpsProxy
+	for name, r := range daemon.runtimes {
+		ri := types.Runtime{Path: r.Path, Args: r.Args}
+		for _, env := range r.Env {
+			ri.Env = append(ri.Env, strings.SplitN(env, "=", 2)[0])
+		}
+		v.Runtimes[name] = ri
 	}
-	if noProxy := os.Getenv("no_proxy"); noProxy != "" {
-		v.NoProxy = noProxy
+
+	// the proxies of the configuration replace those of the environment
+	if proxy := daemon.RegistryService.Config.Proxy; proxy != nil {
+		v.HttpProxy = proxy.HTTPProxy
+		v.HttpsProxy = proxy.HTTPSProxy
+		v.NoProxy = proxy.NoProxy
+	} else {
+		if httpProxy := os.Getenv("http_proxy"); httpProxy != "" {
+			v.HttpProxy = httpProxy
+		}
+		if httpsProxy := os.Getenv("https_proxy"); httpsProxy != "" {
+			v.HttpsProxy = httpsProxy
+		}
+		if noProxy := os.Getenv("no_proxy"); noProxy != "" {
+			v.NoProxy = noProxy
+		}
 	}
+	v.RegistryProxies = daemon.RegistryService.Config.RegistryProxies
 	if hostname, err := os.Hostname(); err == nil {
 		v.Name = hostname
 	}
 
 	return v, nil
 }
+
+// securityOptions returns the security features of the host applied to the
+// containers by default.
+func (daemon *Daemon) securityOptions() []string {
+	options := []string{}
+	if daemon.SystemConfig().AppArmor {
+		options = append(options, "apparmor")
+	}
+	// only the native driver filters the syscalls of the containers
+	if seccomp.IsEnabled() && strings.HasPrefix(daemon.ExecutionDriver().Name(), "native") {
+		options = append(options, "seccomp")
+	}
+	if selinuxEnabled() {
+		options = append(options, "selinux")
+	}
+	return options
+}
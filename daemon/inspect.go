@@ -3,6 +3,7 @@ package daemon
 import (
 	"fmt"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/runconfig"
 )
@@ -36,16 +37,23 @@ func (daemon *Daemon) ContainerInspect(name string) (*types.ContainerJSON, error
 	}
 
 	containerState := &types.ContainerState{
-		Running:    container.State.Running,
-		Paused:     container.State.Paused,
-		Restarting: container.State.Restarting,
-		OOMKilled:  container.State.OOMKilled,
-		Dead:       container.State.Dead,
-		Pid:        container.State.Pid,
-		ExitCode:   container.State.ExitCode,
-		Error:      container.State.Error,
-		StartedAt:  container.State.StartedAt,
-		FinishedAt: container.State.FinishedAt,
+		Running:     container.State.Running,
+		Paused:      container.State.Paused,
+		Restarting:  container.State.Restarting,
+		OOMKilled:   container.State.OOMKilled,
+		Dead:        container.State.Dead,
+		Pid:         container.State.Pid,
+		ExitCode:    container.State.ExitCode,
+		Error:       container.State.Error,
+		StartedAt:   container.State.StartedAt,
+		FinishedAt:  container.State.FinishedAt,
+		PausedAt:    container.State.PausedAt,
+		NextRestart: container.State.NextRestart,
+	}
+	if container.State.Health != nil {
+		health := container.State.Health.Health
+		health.Log = append([]*types.HealthcheckResult(nil), health.Log...)
+		containerState.Health = &health
 	}
 
 	contJSON := &types.ContainerJSON{
@@ -72,11 +80,46 @@ func (daemon *Daemon) ContainerInspect(name string) (*types.ContainerJSON, error
 		AppArmorProfile: container.AppArmorProfile,
 		ExecIDs:         container.GetExecIDs(),
 		HostConfig:      &hostConfig,
+		RuntimeState:    daemon.runtimeState(container),
 	}
 
 	return contJSON, nil
 }
 
+// runtimeState returns the state of the running container in the exec
+// driver, or nil if it isn't running or the driver doesn't support it.
+func (daemon *Daemon) runtimeState(container *Container) *types.ContainerRuntimeState {
+	if !container.Running || container.command == nil {
+		return nil
+	}
+	state, err := daemon.execDriver.RuntimeState(container.command)
+	if err != nil {
+		logrus.Debugf("Error getting the runtime state of container %s: %v", container.ID, err)
+		return nil
+	}
+	r := state.Resources
+	runtimeState := &types.ContainerRuntimeState{
+		CgroupPaths: state.CgroupPaths,
+		Resources: types.ContainerResources{
+			Memory:         r.Memory,
+			MemorySwap:     r.MemorySwap,
+			OomKillDisable: r.OomKillDisable,
+			CpuShares:      r.CpuShares,
+			CpuPeriod:      r.CpuPeriod,
+			CpuQuota:       r.CpuQuota,
+			CpusetCpus:     r.CpusetCpus,
+			CpusetMems:     r.CpusetMems,
+			BlkioWeight:    r.BlkioWeight,
+		},
+		AppArmorProfile: state.AppArmorProfile,
+		SeccompProfile:  state.SeccompProfile,
+	}
+	if stats, err := daemon.Stats(container); err == nil {
+		runtimeState.Stats = resourceStatsToAPIType(stats)
+	}
+	return runtimeState
+}
+
 func (daemon *Daemon) ContainerExecInspect(id string) (*execConfig, error) {
 	eConfig, err := daemon.getExecConfig(id)
 	if err != nil {
@@ -85,3 +128,15 @@ func (daemon *Daemon) ContainerExecInspect(id string) (*execConfig, error) {
 
 	return eConfig, nil
 }
+
+// VolumeInspect returns the named volume with the given name, or the
+// anonymous volume with the given ID, with its usage.
+func (daemon *Daemon) VolumeInspect(name string) (*types.Volume, error) {
+	v := daemon.volumes.GetByName(name)
+	if v == nil {
+		return nil, fmt.Errorf("No such volume: %s", name)
+	}
+	volume := volumeToAPIType(v)
+	volume.UsageData = volumeUsage(v)
+	return volume, nil
+}
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"fmt"
+	"strconv"
 	"syscall"
 )
 
@@ -26,6 +27,9 @@ func (daemon *Daemon) ContainerKill(name string, sig uint64) error {
 			return fmt.Errorf("Cannot kill container %s: %s", name, err)
 		}
 	}
-	container.LogEvent("kill")
+	if sig == 0 {
+		sig = uint64(syscall.SIGKILL)
+	}
+	container.logEventWithAttributes("kill", map[string]string{"signal": strconv.FormatUint(sig, 10)})
 	return nil
 }
@@ -0,0 +1,177 @@
+package daemon
+
+import (
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/units"
+)
+
+var (
+	// layerGCInterval is how often the layers are collected when no event
+	// triggers a collection.
+	layerGCInterval = time.Minute
+	// layerGCGracePeriod is how long a new layer is kept unreferenced, for the
+	// pulls and builds in progress to reference it.
+	layerGCGracePeriod = 10 * time.Minute
+)
+
+// the events after which the layers may be collected
+var layerGCTriggers = map[string]bool{
+	"pull":    true,
+	"import":  true,
+	"untag":   true,
+	"destroy": true,
+}
+
+// newLayerGC returns a new layerGC deleting the unreferenced layers of the
+// daemon in the background, as long as the layers take more than budget bytes.
+func newLayerGC(daemon *Daemon, budget int64) *layerGC {
+	gc := &layerGC{
+		daemon: daemon,
+		budget: budget,
+		done:   make(chan struct{}),
+	}
+	_, gc.events = daemon.EventsService.Subscribe()
+	go gc.run()
+	return gc
+}
+
+// layerGC collects the layers referenced by no tag, no container and no child
+// layer, the least recently used first.
+type layerGC struct {
+	daemon *Daemon
+	budget int64
+	events chan interface{}
+	done   chan struct{}
+}
+
+func (gc *layerGC) run() {
+	ticker := time.NewTicker(layerGCInterval)
+	defer ticker.Stop()
+	gc.collect()
+	for {
+		select {
+		case ev, ok := <-gc.events:
+			if !ok {
+				return
+			}
+			if m, ok := ev.(*events.Message); ok && layerGCTriggers[m.Action] {
+				gc.collect()
+			}
+		case <-ticker.C:
+			gc.collect()
+		case <-gc.done:
+			return
+		}
+	}
+}
+
+// stop stops the collection of the layers.
+func (gc *layerGC) stop() {
+	gc.daemon.EventsService.Evict(gc.events)
+	close(gc.done)
+}
+
+// collect deletes the unreferenced layers until the layers fit in the budget.
+func (gc *layerGC) collect() {
+	g := gc.daemon.Graph()
+	images, err := g.Map()
+	if err != nil {
+		logrus.Errorf("Error listing the layers to collect: %v", err)
+		return
+	}
+	var total int64
+	for _, img := range images {
+		total += img.Size
+	}
+	if total <= gc.budget {
+		return
+	}
+
+	refs := make(map[string]int)
+	for id, names := range gc.daemon.Repositories().ByID() {
+		refs[id] += len(names)
+	}
+	for _, c := range gc.daemon.List() {
+		if c.ImageID != "" {
+			refs[c.ImageID]++
+		}
+	}
+	// the layers registered lately are referenced by the pulls and builds in
+	// progress, which haven't tagged them yet
+	now := time.Now()
+	touched := make(map[string]time.Time, len(images))
+	for id := range images {
+		fi, err := os.Stat(g.ImageRoot(id))
+		if err != nil || now.Sub(fi.ModTime()) < layerGCGracePeriod {
+			refs[id]++
+			continue
+		}
+		touched[id] = fi.ModTime()
+	}
+
+	var (
+		deleted   int
+		reclaimed int64
+	)
+	for _, id := range gcPlan(images, refs, touched, total, gc.budget) {
+		// the checks of rmi keep the layers referenced since the plan
+		if _, err := gc.daemon.ImageDelete(id, false, true); err != nil {
+			logrus.Debugf("Error collecting layer %s: %v", id, err)
+			continue
+		}
+		deleted++
+		reclaimed += images[id].Size
+	}
+	if deleted > 0 {
+		logrus.Infof("Collected %d unreferenced layers, reclaiming %s", deleted, units.HumanSize(float64(reclaimed)))
+	}
+}
+
+// gcPlan returns the layers to delete for the layers of total size to fit in
+// the budget, in order. The layers referenced by refs or by a child layer are
+// kept, and the least recently touched ones are deleted first. A layer whose
+// children are all deleted can be deleted in turn.
+func gcPlan(images map[string]*image.Image, refs map[string]int, touched map[string]time.Time, total, budget int64) []string {
+	counts := make(map[string]int, len(images))
+	for id, n := range refs {
+		counts[id] = n
+	}
+	for _, img := range images {
+		if img.Parent != "" {
+			counts[img.Parent]++
+		}
+	}
+	var candidates []string
+	for id := range images {
+		if counts[id] == 0 {
+			candidates = append(candidates, id)
+		}
+	}
+
+	var plan []string
+	for total > budget && len(candidates) > 0 {
+		oldest := 0
+		for i, id := range candidates {
+			if touched[id].Before(touched[candidates[oldest]]) {
+				oldest = i
+			}
+		}
+		id := candidates[oldest]
+		candidates = append(candidates[:oldest], candidates[oldest+1:]...)
+		plan = append(plan, id)
+		total -= images[id].Size
+
+		parent := images[id].Parent
+		if _, exists := images[parent]; exists {
+			if counts[parent]--; counts[parent] == 0 {
+				candidates = append(candidates, parent)
+			}
+		}
+	}
+	return plan
+}
@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/image"
+)
+
+func TestGCPlan(t *testing.T) {
+	// base <- app <- app2, base <- tool, and the tagged other
+	images := map[string]*image.Image{
+		"base":  {ID: "base", Size: 100},
+		"app":   {ID: "app", Parent: "base", Size: 10},
+		"app2":  {ID: "app2", Parent: "app", Size: 10},
+		"tool":  {ID: "tool", Parent: "base", Size: 50},
+		"other": {ID: "other", Size: 30},
+	}
+	now := time.Now()
+	touched := map[string]time.Time{
+		"base":  now.Add(-5 * time.Hour),
+		"app":   now.Add(-4 * time.Hour),
+		"app2":  now.Add(-3 * time.Hour),
+		"tool":  now.Add(-time.Hour),
+		"other": now.Add(-6 * time.Hour),
+	}
+	refs := map[string]int{"other": 1}
+
+	if plan := gcPlan(images, refs, touched, 200, 200); len(plan) != 0 {
+		t.Fatalf("Expected nothing to collect within the budget, got %v", plan)
+	}
+	// app2 is older than tool, and app is unreferenced once app2 is deleted
+	if plan := strings.Join(gcPlan(images, refs, touched, 200, 190), ","); plan != "app2" {
+		t.Fatalf("Expected app2 to be collected, got %s", plan)
+	}
+	if plan := strings.Join(gcPlan(images, refs, touched, 200, 175), ","); plan != "app2,app,tool" {
+		t.Fatalf("Expected app2, app and tool to be collected, got %s", plan)
+	}
+	if plan := strings.Join(gcPlan(images, refs, touched, 200, 0), ","); plan != "app2,app,tool,base" {
+		t.Fatalf("Expected all the unreferenced layers to be collected, got %s", plan)
+	}
+
+	// a container of tool keeps tool and base
+	refs["tool"] = 1
+	if plan := strings.Join(gcPlan(images, refs, touched, 200, 0), ","); plan != "app2,app" {
+		t.Fatalf("Expected the layers of the container to be kept, got %s", plan)
+	}
+}
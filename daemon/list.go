@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/pkg/graphdb"
@@ -17,6 +18,74 @@ func (daemon *Daemon) List() []*Container {
 	return daemon.containers.List()
 }
 
+var acceptedVolumeFilterTags = map[string]struct{}{
+	"dangling": {},
+	"driver":   {},
+	"label":    {},
+}
+
+// Volumes returns the named and the anonymous volumes of the daemon matching
+// the filters: the volumes used by no container with dangling=true, the
+// volumes of a volume driver with driver, or the volumes with a label.
+func (daemon *Daemon) Volumes(filter string) ([]*types.Volume, error) {
+	volFilters, err := filters.FromParam(filter)
+	if err != nil {
+		return nil, err
+	}
+	for name := range volFilters {
+		if _, ok := acceptedVolumeFilterTags[name]; !ok {
+			return nil, fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
+
+	var filtDangling, dangling bool
+	for _, value := range volFilters["dangling"] {
+		switch strings.ToLower(value) {
+		case "true", "1":
+			dangling = true
+		case "false", "0":
+			dangling = false
+		default:
+			return nil, fmt.Errorf("Invalid filter 'dangling=%s'", value)
+		}
+		filtDangling = true
+	}
+
+	var volumes []*types.Volume
+	for _, v := range daemon.volumes.List() {
+		if filtDangling && (len(v.Containers()) == 0) != dangling {
+			continue
+		}
+		if !volFilters.Match("driver", v.DriverName()) {
+			continue
+		}
+		if !volFilters.MatchKVList("label", v.Labels) {
+			continue
+		}
+		volumes = append(volumes, volumeToAPIType(v))
+	}
+	return volumes, nil
+}
+
+var acceptedPsFilterTags = map[string]struct{}{
+	"ancestor": {},
+	"exited":   {},
+	"id":       {},
+	"label":    {},
+	"name":     {},
+	"network":  {},
+	"status":   {},
+	"volume":   {},
+}
+
+var acceptedPsStatuses = map[string]struct{}{
+	"restarting": {},
+	"running":    {},
+	"paused":     {},
+	"exited":     {},
+	"dead":       {},
+}
+
 type ContainersConfig struct {
 	All     bool
 	Since   string
@@ -41,6 +110,11 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 	if err != nil {
 		return nil, err
 	}
+	for name := range psFilters {
+		if _, ok := acceptedPsFilterTags[name]; !ok {
+			return nil, fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
 	if i, ok := psFilters["exited"]; ok {
 		for _, value := range i {
 			code, err := strconv.Atoi(value)
@@ -53,11 +127,21 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 
 	if i, ok := psFilters["status"]; ok {
 		for _, value := range i {
-			if value == "exited" {
+			if _, ok := acceptedPsStatuses[value]; !ok {
+				return nil, fmt.Errorf("Invalid filter 'status=%s'", value)
+			}
+			if value == "exited" || value == "dead" {
 				all = true
 			}
 		}
 	}
+
+	var ancestors map[string]struct{}
+	if values, ok := psFilters["ancestor"]; ok {
+		if ancestors, err = daemon.imageDescendants(values); err != nil {
+			return nil, err
+		}
+	}
 	names := map[string][]string{}
 	daemon.ContainerGraph().Walk("/", func(p string, e *graphdb.Entity) error {
 		names[e.ID()] = append(names[e.ID()], p)
@@ -86,7 +170,8 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 		if !container.Running && !all && n <= 0 && config.Since == "" && config.Before == "" {
 			return nil
 		}
-		if !psFilters.Match("name", container.Name) {
+		// the name matches with or without its leading slash
+		if !psFilters.Match("name", container.Name) && !psFilters.Match("name", strings.TrimPrefix(container.Name, "/")) {
 			return nil
 		}
 
@@ -98,6 +183,20 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 			return nil
 		}
 
+		if ancestors != nil {
+			if _, ok := ancestors[container.ImageID]; !ok {
+				return nil
+			}
+		}
+
+		if !matchExact(psFilters["network"], container.networkName()) {
+			return nil
+		}
+
+		if !matchExact(psFilters["volume"], daemon.containerVolumeNames(container)...) {
+			return nil
+		}
+
 		if config.Before != "" && !foundBefore {
 			if container.ID == beforeCont.ID {
 				foundBefore = true
@@ -193,3 +292,77 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 	}
 	return containers, nil
 }
+
+// imageDescendants returns the IDs of the images referenced by names, and of
+// all the images built on top of them. The names of images which don't exist
+// match no container.
+func (daemon *Daemon) imageDescendants(names []string) (map[string]struct{}, error) {
+	images := map[string]struct{}{}
+	var ids []string
+	for _, name := range names {
+		img, err := daemon.Repositories().LookupImage(name)
+		if err != nil {
+			logrus.Debugf("Ignoring the ancestor filter of image %s: %v", name, err)
+			continue
+		}
+		ids = append(ids, img.ID)
+	}
+	if len(ids) == 0 {
+		return images, nil
+	}
+
+	byParent, err := daemon.Graph().ByParent()
+	if err != nil {
+		return nil, err
+	}
+	for len(ids) > 0 {
+		id := ids[0]
+		ids = ids[1:]
+		if _, ok := images[id]; ok {
+			continue
+		}
+		images[id] = struct{}{}
+		for _, child := range byParent[id] {
+			ids = append(ids, child.ID)
+		}
+	}
+	return images, nil
+}
+
+// networkName returns the network mode of the container, "bridge" by
+// default.
+func (container *Container) networkName() string {
+	if container.hostConfig == nil || container.hostConfig.NetworkMode == "" {
+		return "bridge"
+	}
+	return string(container.hostConfig.NetworkMode)
+}
+
+// containerVolumeNames returns the paths of the volumes in the container,
+// with the names of its named volumes.
+func (daemon *Daemon) containerVolumeNames(container *Container) []string {
+	var names []string
+	for dest, path := range container.Volumes {
+		names = append(names, dest)
+		if v := daemon.volumes.Get(path); v != nil && v.Name != "" {
+			names = append(names, v.Name)
+		}
+	}
+	return names
+}
+
+// matchExact returns whether one of the values is one of the sources, or
+// true without any value.
+func matchExact(values []string, sources ...string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		for _, source := range sources {
+			if value == source {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package awslogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	serviceName    = "logs"
+	targetPrefix   = "Logs_20140328."
+	contentType    = "application/x-amz-json-1.1"
+	metadataURL    = "http://169.254.169.254/latest/meta-data/"
+	requestTimeout = 30 * time.Second
+
+	resourceAlreadyExistsCode = "ResourceAlreadyExistsException"
+	dataAlreadyAcceptedCode   = "DataAlreadyAcceptedException"
+	invalidSequenceTokenCode  = "InvalidSequenceTokenException"
+)
+
+// api is the part of the CloudWatch Logs API used by the driver.
+type api interface {
+	CreateLogStream(group, stream string) error
+	PutLogEvents(input *putLogEventsInput) (string, error)
+}
+
+type inputLogEvent struct {
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"` // milliseconds since the epoch
+}
+
+type putLogEventsInput struct {
+	LogGroupName  string          `json:"logGroupName"`
+	LogStreamName string          `json:"logStreamName"`
+	LogEvents     []inputLogEvent `json:"logEvents"`
+	SequenceToken string          `json:"sequenceToken,omitempty"`
+}
+
+// awsError is an error returned by the API, with its code, such as
+// InvalidSequenceTokenException.
+type awsError struct {
+	Code                  string
+	Message               string
+	ExpectedSequenceToken string
+}
+
+func (e *awsError) Error() string {
+	return fmt.Sprintf("awslogs: %s: %s", e.Code, e.Message)
+}
+
+// expectedToken returns the sequence token expected by the API after an
+// InvalidSequenceTokenException or a DataAlreadyAcceptedException, which
+// older versions of the API only give at the end of the message.
+func (e *awsError) expectedToken() string {
+	if e.ExpectedSequenceToken != "" {
+		return e.ExpectedSequenceToken
+	}
+	if i := strings.LastIndex(e.Message, ": "); i != -1 {
+		return strings.TrimSpace(e.Message[i+2:])
+	}
+	return ""
+}
+
+// client calls the CloudWatch Logs API of a region with the JSON protocol,
+// signing the requests with the credentials of the environment or of the
+// IAM role of the EC2 instance.
+type client struct {
+	endpoint   string
+	region     string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	creds *credentials
+}
+
+func newClient(region string) *client {
+	return &client{
+		endpoint:   fmt.Sprintf("https://logs.%s.amazonaws.com/", region),
+		region:     region,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (c *client) CreateLogStream(group, stream string) error {
+	return c.call("CreateLogStream", map[string]string{
+		"logGroupName":  group,
+		"logStreamName": stream,
+	}, nil)
+}
+
+func (c *client) PutLogEvents(input *putLogEventsInput) (string, error) {
+	var output struct {
+		NextSequenceToken string `json:"nextSequenceToken"`
+	}
+	if err := c.call("PutLogEvents", input, &output); err != nil {
+		return "", err
+	}
+	return output.NextSequenceToken, nil
+}
+
+func (c *client) call(action string, input, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	creds, err := c.credentials()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Target", targetPrefix+action)
+	signRequest(req, body, creds, c.region, serviceName, time.Now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("awslogs: %s failed: %v", action, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type                  string `json:"__type"`
+			Message               string `json:"message"`
+			ExpectedSequenceToken string `json:"expectedSequenceToken"`
+		}
+		if err := json.Unmarshal(data, &e); err != nil || e.Type == "" {
+			return fmt.Errorf("awslogs: %s failed with status %d: %s", action, resp.StatusCode, bytes.TrimSpace(data))
+		}
+		// the type is prefixed with the namespace of the service
+		code := e.Type[strings.LastIndex(e.Type, "#")+1:]
+		return &awsError{Code: code, Message: e.Message, ExpectedSequenceToken: e.ExpectedSequenceToken}
+	}
+	if output == nil {
+		return nil
+	}
+	return json.Unmarshal(data, output)
+}
+
+type credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	expiration      time.Time // zero for the credentials of the environment
+}
+
+// credentials returns the credentials of the environment, or else the
+// temporary credentials of the IAM role of the instance, renewed a few
+// minutes before they expire.
+func (c *client) credentials() (*credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.creds != nil && (c.creds.expiration.IsZero() || time.Now().Add(5*time.Minute).Before(c.creds.expiration)) {
+		return c.creds, nil
+	}
+	if creds := envCredentials(); creds != nil {
+		c.creds = creds
+		return creds, nil
+	}
+	creds, err := instanceCredentials()
+	if err != nil {
+		return nil, err
+	}
+	c.creds = creds
+	return creds, nil
+}
+
+func envCredentials() *credentials {
+	creds := &credentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.accessKeyID == "" {
+		creds.accessKeyID = os.Getenv("AWS_ACCESS_KEY")
+	}
+	if creds.secretAccessKey == "" {
+		creds.secretAccessKey = os.Getenv("AWS_SECRET_KEY")
+	}
+	if creds.accessKeyID == "" || creds.secretAccessKey == "" {
+		return nil
+	}
+	return creds
+}
+
+func instanceCredentials() (*credentials, error) {
+	roles, err := getMetadata("iam/security-credentials/")
+	if err != nil {
+		return nil, errors.New("awslogs: no credentials in the environment nor in the EC2 instance metadata")
+	}
+	role := strings.TrimSpace(strings.SplitN(roles, "\n", 2)[0])
+	data, err := getMetadata("iam/security-credentials/" + role)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Code            string
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		Token           string
+		Expiration      time.Time
+	}
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		return nil, fmt.Errorf("awslogs: invalid credentials of the instance role %s: %v", role, err)
+	}
+	if resp.Code != "Success" {
+		return nil, fmt.Errorf("awslogs: cannot get the credentials of the instance role %s: %s", role, resp.Code)
+	}
+	return &credentials{
+		accessKeyID:     resp.AccessKeyID,
+		secretAccessKey: resp.SecretAccessKey,
+		sessionToken:    resp.Token,
+		expiration:      resp.Expiration,
+	}, nil
+}
+
+// instanceRegion returns the region of the EC2 instance the daemon runs on.
+func instanceRegion() (string, error) {
+	zone, err := getMetadata("placement/availability-zone")
+	if err != nil {
+		return "", err
+	}
+	zone = strings.TrimSpace(zone)
+	if zone == "" {
+		return "", errors.New("awslogs: empty availability zone in the EC2 instance metadata")
+	}
+	// the availability zone is the region followed by a letter
+	return zone[:len(zone)-1], nil
+}
+
+func getMetadata(path string) (string, error) {
+	client := &http.Client{Timeout: time.Second}
+	resp, err := client.Get(metadataURL + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("awslogs: metadata %s not found: status %d", path, resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	return string(data), err
+}
@@ -0,0 +1,271 @@
+// Package awslogs provides the log driver for forwarding container logs to
+// Amazon CloudWatch Logs.
+package awslogs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+)
+
+const (
+	name = "awslogs"
+
+	regionKey    = "awslogs-region"
+	logGroupKey  = "awslogs-group"
+	logStreamKey = "awslogs-stream"
+	regionEnvKey = "AWS_REGION"
+
+	batchPublishFrequency = 5 * time.Second
+
+	// Limits of the PutLogEvents API: every event counts for the size of
+	// its message plus 26 bytes.
+	perEventBytes          = 26
+	maximumBytesPerPut     = 1048576
+	maximumLogEventsPerPut = 10000
+	maximumBytesPerEvent   = 262144 - perEventBytes
+)
+
+type logStream struct {
+	logStreamName string
+	logGroupName  string
+	client        api
+	messages      chan *logger.Message
+	sequenceToken string // only used by the publishing goroutine
+
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+}
+
+func init() {
+	if err := logger.RegisterLogDriver(name, New); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logger.RegisterLogOptValidator(name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// New creates an awslogs logger for the container of ctx, publishing its
+// logs to the awslogs-stream stream, by default the ID of the container, of
+// the awslogs-group group. The group must exist, the stream is created if
+// it doesn't. Messages are published in batches every 5 seconds.
+func New(ctx logger.Context) (logger.Logger, error) {
+	region, err := getRegion(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
+	logStreamName := ctx.Config[logStreamKey]
+	if logStreamName == "" {
+		logStreamName = ctx.ContainerID
+	}
+	return newLogStream(newClient(region), ctx.Config[logGroupKey], logStreamName)
+}
+
+func newLogStream(client api, logGroupName, logStreamName string) (*logStream, error) {
+	l := &logStream{
+		logGroupName:  logGroupName,
+		logStreamName: logStreamName,
+		client:        client,
+		messages:      make(chan *logger.Message, 4096),
+		done:          make(chan struct{}),
+	}
+	if err := l.create(); err != nil {
+		return nil, err
+	}
+	ticker := time.NewTicker(batchPublishFrequency)
+	go func() {
+		l.collectBatch(ticker.C)
+		ticker.Stop()
+	}()
+	return l, nil
+}
+
+// create creates the log stream, unless it exists already.
+func (l *logStream) create() error {
+	err := l.client.CreateLogStream(l.logGroupName, l.logStreamName)
+	if err, ok := err.(*awsError); ok && err.Code == resourceAlreadyExistsCode {
+		return nil
+	}
+	return err
+}
+
+// getRegion returns the awslogs-region of the config, or else the region
+// of the environment or of the EC2 instance the daemon runs on.
+func getRegion(cfg map[string]string) (string, error) {
+	if region := cfg[regionKey]; region != "" {
+		return region, nil
+	}
+	if region := os.Getenv(regionEnvKey); region != "" {
+		return region, nil
+	}
+	region, err := instanceRegion()
+	if err != nil {
+		return "", fmt.Errorf("awslogs: missing log opt %s, and the region of the instance is unknown: %v", regionKey, err)
+	}
+	return region, nil
+}
+
+func (l *logStream) Log(msg *logger.Message) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return fmt.Errorf("awslogs: log stream %s is closed", l.logStreamName)
+	}
+	// the line is only valid until Log returns
+	line := make([]byte, len(msg.Line))
+	copy(line, msg.Line)
+	l.messages <- &logger.Message{
+		ContainerID: msg.ContainerID,
+		Line:        line,
+		Source:      msg.Source,
+		Timestamp:   msg.Timestamp,
+	}
+	return nil
+}
+
+// Close publishes the pending messages and stops the logger.
+func (l *logStream) Close() error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.messages)
+	}
+	l.mu.Unlock()
+	<-l.done
+	return nil
+}
+
+func (l *logStream) Name() string {
+	return name
+}
+
+func (l *logStream) GetReader() (io.Reader, error) {
+	return nil, logger.ReadLogsNotSupported
+}
+
+// collectBatch collects the messages in batches within the limits of the
+// API, publishing them when full, on every tick, and when the logger is
+// closed.
+func (l *logStream) collectBatch(ticker <-chan time.Time) {
+	defer close(l.done)
+
+	var (
+		events []inputLogEvent
+		bytes  int
+	)
+	publish := func() {
+		if len(events) > 0 {
+			l.publishBatch(events)
+		}
+		events = nil
+		bytes = 0
+	}
+
+	for {
+		select {
+		case <-ticker:
+			publish()
+		case msg, more := <-l.messages:
+			if !more {
+				publish()
+				return
+			}
+			timestamp := msg.Timestamp.UnixNano() / int64(time.Millisecond)
+			for _, line := range splitLine(msg.Line) {
+				lineBytes := len(line) + perEventBytes
+				if bytes+lineBytes > maximumBytesPerPut || len(events) >= maximumLogEventsPerPut {
+					publish()
+				}
+				events = append(events, inputLogEvent{Message: string(line), Timestamp: timestamp})
+				bytes += lineBytes
+			}
+		}
+	}
+}
+
+// splitLine splits a line in events no larger than the API allows, without
+// splitting UTF-8 characters. Empty lines, which the API rejects, have no
+// events.
+func splitLine(line []byte) [][]byte {
+	var parts [][]byte
+	for len(line) > maximumBytesPerEvent {
+		n := maximumBytesPerEvent
+		for n > 0 && !utf8.RuneStart(line[n]) {
+			n--
+		}
+		if n == 0 {
+			n = maximumBytesPerEvent
+		}
+		parts = append(parts, line[:n])
+		line = line[n:]
+	}
+	if len(line) > 0 {
+		parts = append(parts, line)
+	}
+	return parts
+}
+
+type byTimestamp []inputLogEvent
+
+func (e byTimestamp) Len() int           { return len(e) }
+func (e byTimestamp) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e byTimestamp) Less(i, j int) bool { return e[i].Timestamp < e[j].Timestamp }
+
+// publishBatch publishes events, retrying once with the sequence token
+// expected by the API when the one of the logger is out of date, such as
+// when the stream was written to before.
+func (l *logStream) publishBatch(events []inputLogEvent) {
+	// the events of a batch must be in chronological order, while stdout
+	// and stderr are copied concurrently
+	sort.Stable(byTimestamp(events))
+
+	nextToken, err := l.putLogEvents(events, l.sequenceToken)
+	if awsErr, ok := err.(*awsError); ok {
+		switch awsErr.Code {
+		case dataAlreadyAcceptedCode:
+			// the batch was published already, keep going with the
+			// next token
+			nextToken, err = awsErr.expectedToken(), nil
+		case invalidSequenceTokenCode:
+			nextToken, err = l.putLogEvents(events, awsErr.expectedToken())
+		}
+	}
+	if err != nil {
+		logrus.Errorf("Failed to publish logs to log stream %s: %v", l.logStreamName, err)
+		return
+	}
+	l.sequenceToken = nextToken
+}
+
+func (l *logStream) putLogEvents(events []inputLogEvent, sequenceToken string) (string, error) {
+	return l.client.PutLogEvents(&putLogEventsInput{
+		LogGroupName:  l.logGroupName,
+		LogStreamName: l.logStreamName,
+		LogEvents:     events,
+		SequenceToken: sequenceToken,
+	})
+}
+
+// ValidateLogOpt checks the options of the awslogs log driver.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case regionKey, logGroupKey, logStreamKey:
+		default:
+			return fmt.Errorf("unknown log opt '%s' for awslogs log driver", key)
+		}
+	}
+	if cfg[logGroupKey] == "" {
+		return fmt.Errorf("awslogs: missing log opt %s", logGroupKey)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package awslogs
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+type mockClient struct {
+	mu      sync.Mutex
+	streams []string
+	puts    []*putLogEventsInput
+	token   int
+	// the errors returned by the next calls of PutLogEvents
+	putErrors []error
+}
+
+func (c *mockClient) CreateLogStream(group, stream string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.streams = append(c.streams, group+"/"+stream)
+	return nil
+}
+
+func (c *mockClient) PutLogEvents(input *putLogEventsInput) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.puts = append(c.puts, input)
+	if len(c.putErrors) > 0 {
+		err := c.putErrors[0]
+		c.putErrors = c.putErrors[1:]
+		if err != nil {
+			return "", err
+		}
+	}
+	c.token++
+	return fmt.Sprint(c.token), nil
+}
+
+func TestLogStreamPublish(t *testing.T) {
+	client := &mockClient{}
+	l, err := newLogStream(client, "group", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.streams) != 1 || client.streams[0] != "group/stream" {
+		t.Fatalf("Expected the stream to be created, got %v", client.streams)
+	}
+
+	now := time.Now()
+	line := []byte("line2")
+	l.Log(&logger.Message{Line: line, Source: "stdout", Timestamp: now.Add(time.Millisecond)})
+	// the line may be reused once logged
+	copy(line, "xxxxx")
+	l.Log(&logger.Message{Line: []byte("line1"), Source: "stderr", Timestamp: now})
+	l.Log(&logger.Message{Line: []byte(""), Source: "stdout", Timestamp: now})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(client.puts) != 1 {
+		t.Fatalf("Expected 1 batch, got %d", len(client.puts))
+	}
+	events := client.puts[0].LogEvents
+	if len(events) != 2 || events[0].Message != "line1" || events[1].Message != "line2" {
+		t.Fatalf("Unexpected events %v", events)
+	}
+	if events[0].Timestamp != now.UnixNano()/int64(time.Millisecond) {
+		t.Fatalf("Unexpected timestamp %d", events[0].Timestamp)
+	}
+	if err := l.Log(&logger.Message{Line: []byte("late"), Timestamp: now}); err == nil {
+		t.Fatal("Expected an error logging to a closed stream")
+	}
+}
+
+func TestLogStreamBatchLimits(t *testing.T) {
+	client := &mockClient{}
+	l, err := newLogStream(client, "group", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := bytes.Repeat([]byte{'a'}, maximumBytesPerEvent)
+	for i := 0; i < 5; i++ {
+		l.Log(&logger.Message{Line: line, Timestamp: time.Now()})
+	}
+	l.Close()
+
+	if len(client.puts) != 2 {
+		t.Fatalf("Expected 2 batches, got %d", len(client.puts))
+	}
+	for _, put := range client.puts {
+		size := 0
+		for _, e := range put.LogEvents {
+			size += len(e.Message) + perEventBytes
+		}
+		if size > maximumBytesPerPut {
+			t.Fatalf("Batch of %d bytes is over the limit", size)
+		}
+	}
+	if client.puts[1].SequenceToken != "1" {
+		t.Fatalf("Expected the second batch to use the token of the first one, got %q", client.puts[1].SequenceToken)
+	}
+}
+
+func TestLogStreamInvalidSequenceToken(t *testing.T) {
+	client := &mockClient{putErrors: []error{&awsError{
+		Code:    invalidSequenceTokenCode,
+		Message: "The given sequenceToken is invalid. The next expected sequenceToken is: 49540",
+	}}}
+	l, err := newLogStream(client, "group", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Log(&logger.Message{Line: []byte("hello"), Timestamp: time.Now()})
+	l.Close()
+
+	if len(client.puts) != 2 {
+		t.Fatalf("Expected the batch to be retried, got %d calls", len(client.puts))
+	}
+	if client.puts[1].SequenceToken != "49540" {
+		t.Fatalf("Expected the retry to use the expected token, got %q", client.puts[1].SequenceToken)
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	line := append(bytes.Repeat([]byte{'a'}, maximumBytesPerEvent-1), []byte("é and more")...)
+	parts := splitLine(line)
+	if len(parts) != 2 || len(parts[0]) != maximumBytesPerEvent-1 || string(parts[1]) != "é and more" {
+		t.Fatalf("Unexpected split in %d parts", len(parts))
+	}
+	if parts := splitLine(nil); len(parts) != 0 {
+		t.Fatalf("Expected no parts for an empty line, got %d", len(parts))
+	}
+}
+
+func TestClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "Logs_20140328.CreateLogStream" || r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"__type":"com.amazonaws.logs#ResourceAlreadyExistsException","message":"The specified log stream already exists"}`)
+	}))
+	defer server.Close()
+
+	c := newClient("us-east-1")
+	c.endpoint = server.URL
+	c.creds = &credentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "secret"}
+	err := c.CreateLogStream("group", "stream")
+	if err, ok := err.(*awsError); !ok || err.Code != resourceAlreadyExistsCode {
+		t.Fatalf("Expected a ResourceAlreadyExistsException, got %v", err)
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	if err := ValidateLogOpt(map[string]string{regionKey: "us-east-1", logGroupKey: "group", logStreamKey: "stream"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []map[string]string{
+		{},
+		{regionKey: "us-east-1"},
+		{logGroupKey: "group", "awslogs-foo": "bar"},
+	} {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("Expected an error for %v", cfg)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package awslogs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignRequest checks the signature of the example of the AWS Signature
+// Version 4 documentation.
+func TestSignRequest(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := &credentials{
+		accessKeyID:     "AKIDEXAMPLE",
+		secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	signRequest(req, nil, creds, "us-east-1", "iam", time.Date(2015, time.August, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if auth := req.Header.Get("Authorization"); auth != expected {
+		t.Fatalf("Expected %q, got %q", expected, auth)
+	}
+	if date := req.Header.Get("X-Amz-Date"); date != "20150830T123600Z" {
+		t.Fatalf("Unexpected X-Amz-Date %s", date)
+	}
+}
+
+func TestSignRequestSessionToken(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://logs.us-east-1.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := &credentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "secret", sessionToken: "token"}
+	signRequest(req, []byte("{}"), creds, "us-east-1", "logs", time.Now())
+
+	if req.Header.Get("X-Amz-Security-Token") != "token" {
+		t.Fatal("Expected the session token in the request")
+	}
+	if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Fatalf("Expected the session token to be signed: %s", auth)
+	}
+	if req.ContentLength != 2 {
+		t.Fatalf("Expected the body to be set, got a length of %d", req.ContentLength)
+	}
+}
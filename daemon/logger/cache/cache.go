@@ -0,0 +1,147 @@
+// Package cache keeps a local copy of the logs of the containers using a
+// logging driver that can't read them back, so that `docker logs` works
+// whatever the logging driver.
+package cache
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/jsonfilelog"
+)
+
+const (
+	keyPrefix   = "cache-"
+	disabledKey = "cache-disabled"
+	maxSizeKey  = "cache-max-size"
+	maxFileKey  = "cache-max-file"
+
+	defaultMaxSize = "20m"
+	defaultMaxFile = "5"
+)
+
+// loggerWithCache logs to a logging driver and to the local cache, a
+// json-file log rotated within the size limits of the cache, which the logs
+// are read from.
+type loggerWithCache struct {
+	l     logger.Logger
+	cache *jsonfilelog.JSONFileLogger
+}
+
+// WithLocalCache returns a logger logging to l and to the local cache at
+// ctx.LogPath, configured by the cache options of ctx. The cache keeps the
+// labels and env attributes of the messages selected by the options of the
+// logging driver.
+func WithLocalCache(l logger.Logger, ctx logger.Context) (logger.Logger, error) {
+	driverCfg, cacheCfg := SplitLogOpts(ctx.Config)
+	ctx.Config = JSONFileConfig(cacheCfg)
+	for _, key := range []string{"labels", "env"} {
+		if v, ok := driverCfg[key]; ok {
+			ctx.Config[key] = v
+		}
+	}
+	cache, err := jsonfilelog.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &loggerWithCache{
+		l:     l,
+		cache: cache.(*jsonfilelog.JSONFileLogger),
+	}, nil
+}
+
+func (l *loggerWithCache) Log(msg *logger.Message) error {
+	// the logs of the driver matter more than the ones of the cache
+	err := l.l.Log(msg)
+	if cacheErr := l.cache.Log(msg); cacheErr != nil && err == nil {
+		err = fmt.Errorf("Error writing the logs to the local cache: %v", cacheErr)
+	}
+	return err
+}
+
+func (l *loggerWithCache) Name() string {
+	return l.l.Name()
+}
+
+func (l *loggerWithCache) GetReader() (io.Reader, error) {
+	return l.cache.GetReader()
+}
+
+// LogPath returns the path of the local cache.
+func (l *loggerWithCache) LogPath() string {
+	return l.cache.LogPath()
+}
+
+func (l *loggerWithCache) Close() error {
+	err := l.l.Close()
+	if cacheErr := l.cache.Close(); err == nil {
+		err = cacheErr
+	}
+	return err
+}
+
+// Enabled returns whether the logs of a container using the logging driver
+// name with the options cfg are cached. The json-file logging driver, and the
+// logging driver plugins able to, read their logs themselves.
+func Enabled(name string, cfg map[string]string) bool {
+	if name == jsonfilelog.Name || name == "none" || logger.GetCapability(name).ReadLogs {
+		return false
+	}
+	disabled, _ := strconv.ParseBool(cfg[disabledKey])
+	return !disabled
+}
+
+// SplitLogOpts splits the options of a logging driver from the options of
+// the local cache.
+func SplitLogOpts(cfg map[string]string) (map[string]string, map[string]string) {
+	driverCfg := make(map[string]string)
+	cacheCfg := make(map[string]string)
+	for k, v := range cfg {
+		if strings.HasPrefix(k, keyPrefix) {
+			cacheCfg[k] = v
+		} else {
+			driverCfg[k] = v
+		}
+	}
+	return driverCfg, cacheCfg
+}
+
+// JSONFileConfig returns the options of the json-file log of the cache, 5
+// files of up to 20MB unless configured otherwise.
+func JSONFileConfig(cfg map[string]string) map[string]string {
+	maxSize, maxFile := defaultMaxSize, defaultMaxFile
+	if s := cfg[maxSizeKey]; s != "" {
+		maxSize = s
+	}
+	if s := cfg[maxFileKey]; s != "" {
+		maxFile = s
+	}
+	return map[string]string{"max-size": maxSize, "max-file": maxFile}
+}
+
+// ValidateLogOpts checks the options of the logging driver name, along
+// with the options of its local cache.
+func ValidateLogOpts(name string, cfg map[string]string) error {
+	if name == jsonfilelog.Name {
+		return logger.ValidateLogOpts(name, cfg)
+	}
+	driverCfg, cacheCfg := SplitLogOpts(cfg)
+	for key, value := range cacheCfg {
+		switch key {
+		case disabledKey:
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("invalid value for log opt '%s': %v", key, err)
+			}
+		case maxSizeKey, maxFileKey:
+		default:
+			return fmt.Errorf("unknown log opt '%s' for the local cache of the logs", key)
+		}
+	}
+	if err := jsonfilelog.ValidateLogOpt(JSONFileConfig(cacheCfg)); err != nil {
+		return fmt.Errorf("invalid local cache options: %v", err)
+	}
+	return logger.ValidateLogOpts(name, driverCfg)
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+type testLogger struct {
+	messages []string
+	closed   bool
+}
+
+func (l *testLogger) Log(msg *logger.Message) error {
+	l.messages = append(l.messages, string(msg.Line))
+	return nil
+}
+
+func (l *testLogger) Name() string { return "test" }
+
+func (l *testLogger) Close() error {
+	l.closed = true
+	return nil
+}
+
+func (l *testLogger) GetReader() (io.Reader, error) {
+	return nil, logger.ReadLogsNotSupported
+}
+
+func TestWithLocalCache(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	driver := &testLogger{}
+	l, err := WithLocalCache(driver, logger.Context{
+		ContainerID:     "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657",
+		ContainerLabels: map[string]string{"com.example.app": "web"},
+		LogPath:         filepath.Join(tmp, "container.log"),
+		Config:          map[string]string{maxSizeKey: "1k", "gelf-address": "udp://graylog:12201", "labels": "com.example.app"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Log(&logger.Message{Line: []byte("line1"), Source: "stdout"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(driver.messages) != 1 || driver.messages[0] != "line1" {
+		t.Fatalf("Expected the message to be logged to the driver, got %v", driver.messages)
+	}
+	if l.Name() != "test" {
+		t.Fatalf("Expected the name of the driver, got %s", l.Name())
+	}
+
+	r, err := l.GetReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(r)
+	r.(io.Closer).Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"log":"line1\n"`) || !strings.Contains(string(data), `"attrs":{"com.example.app":"web"}`) {
+		t.Fatalf("Expected the message and its attributes in the local cache, got %q", data)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !driver.closed {
+		t.Fatal("Expected the driver to be closed")
+	}
+}
+
+func TestSplitLogOpts(t *testing.T) {
+	driverCfg, cacheCfg := SplitLogOpts(map[string]string{
+		"gelf-address": "udp://graylog:12201",
+		maxSizeKey:     "1m",
+		disabledKey:    "false",
+	})
+	if len(driverCfg) != 1 || driverCfg["gelf-address"] == "" {
+		t.Fatalf("Unexpected driver options %v", driverCfg)
+	}
+	if len(cacheCfg) != 2 || cacheCfg[maxSizeKey] != "1m" {
+		t.Fatalf("Unexpected cache options %v", cacheCfg)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	if !Enabled("syslog", nil) {
+		t.Fatal("Expected the cache to be enabled by default")
+	}
+	if Enabled("syslog", map[string]string{disabledKey: "true"}) {
+		t.Fatal("Expected the cache to be disabled")
+	}
+	if Enabled("json-file", nil) || Enabled("none", nil) {
+		t.Fatal("Expected no cache for the json-file and none drivers")
+	}
+}
+
+func TestValidateLogOpts(t *testing.T) {
+	// the test driver doesn't take any option of its own
+	if err := ValidateLogOpts("test", map[string]string{maxSizeKey: "10m", maxFileKey: "2", disabledKey: "false"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []map[string]string{
+		{disabledKey: "maybe"},
+		{maxSizeKey: "big"},
+		{maxFileKey: "0"},
+		{"cache-foo": "bar"},
+		{"test-foo": "bar"},
+	} {
+		if err := ValidateLogOpts("test", cfg); err == nil {
+			t.Fatalf("Expected an error for %v", cfg)
+		}
+	}
+	if err := ValidateLogOpts("json-file", map[string]string{maxSizeKey: "10m"}); err == nil {
+		t.Fatal("Expected the json-file driver to reject the cache options")
+	}
+}
@@ -2,23 +2,122 @@ package logger
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/stringid"
 )
 
 // Creator is a method that builds a logging driver instance with given context
 type Creator func(Context) (Logger, error)
 
+// LogOptValidator checks the options given to a logging driver.
+type LogOptValidator func(cfg map[string]string) error
+
 // Context provides enough information for a logging driver to do its function
 type Context struct {
-	Config        map[string]string
-	ContainerID   string
-	ContainerName string
-	LogPath       string
+	Config              map[string]string
+	ContainerID         string
+	ContainerName       string
+	ContainerEntrypoint string
+	ContainerArgs       []string
+	ContainerImageID    string
+	ContainerImageName  string
+	ContainerCreated    time.Time
+	ContainerEnv        []string
+	ContainerLabels     map[string]string
+	LogPath             string
+}
+
+// ExtraAttributes returns the labels and the environment variables of the
+// container named by the comma-separated lists of the labels and env
+// options, with their keys passed through keyMod unless it is nil. An
+// environment variable takes precedence over a label of the same key.
+func (ctx *Context) ExtraAttributes(keyMod func(string) string) map[string]string {
+	extra := make(map[string]string)
+	if labels, ok := ctx.Config["labels"]; ok && len(labels) > 0 {
+		for _, l := range strings.Split(labels, ",") {
+			if v, ok := ctx.ContainerLabels[l]; ok {
+				if keyMod != nil {
+					l = keyMod(l)
+				}
+				extra[l] = v
+			}
+		}
+	}
+	if env, ok := ctx.Config["env"]; ok && len(env) > 0 {
+		envMapping := make(map[string]string)
+		for _, kv := range ctx.ContainerEnv {
+			if parts := strings.SplitN(kv, "=", 2); len(parts) == 2 {
+				envMapping[parts[0]] = parts[1]
+			}
+		}
+		for _, l := range strings.Split(env, ",") {
+			if v, ok := envMapping[l]; ok {
+				if keyMod != nil {
+					l = keyMod(l)
+				}
+				extra[l] = v
+			}
+		}
+	}
+	return extra
+}
+
+// Hostname returns the hostname of the host the container runs on.
+func (ctx *Context) Hostname() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("logger: can not resolve hostname: %v", err)
+	}
+	return hostname, nil
+}
+
+// ID returns the short ID of the container, its first 12 characters.
+func (ctx *Context) ID() string {
+	return stringid.TruncateID(ctx.ContainerID)
+}
+
+// FullID returns the full ID of the container.
+func (ctx *Context) FullID() string {
+	return ctx.ContainerID
+}
+
+// Name returns the name of the container, without its leading slash.
+func (ctx *Context) Name() string {
+	return strings.TrimPrefix(ctx.ContainerName, "/")
+}
+
+// ImageID returns the short ID of the image of the container.
+func (ctx *Context) ImageID() string {
+	return stringid.TruncateID(ctx.ContainerImageID)
+}
+
+// ImageFullID returns the full ID of the image of the container.
+func (ctx *Context) ImageFullID() string {
+	return ctx.ContainerImageID
+}
+
+// ImageName returns the name of the image of the container, as given to
+// create it.
+func (ctx *Context) ImageName() string {
+	return ctx.ContainerImageName
+}
+
+// Command returns the command the container runs, its entrypoint followed
+// by its arguments.
+func (ctx *Context) Command() string {
+	terms := []string{ctx.ContainerEntrypoint}
+	terms = append(terms, ctx.ContainerArgs...)
+	return strings.Join(terms, " ")
 }
 
 type logdriverFactory struct {
-	registry map[string]Creator
-	m        sync.Mutex
+	registry     map[string]Creator
+	optValidator map[string]LogOptValidator
+	m            sync.Mutex
 }
 
 func (lf *logdriverFactory) register(name string, c Creator) error {
@@ -32,6 +131,24 @@ func (lf *logdriverFactory) register(name string, c Creator) error {
 	return nil
 }
 
+func (lf *logdriverFactory) registerLogOptValidator(name string, l LogOptValidator) error {
+	lf.m.Lock()
+	defer lf.m.Unlock()
+
+	if _, ok := lf.optValidator[name]; ok {
+		return fmt.Errorf("logger: log opt validator named '%s' is already registered", name)
+	}
+	lf.optValidator[name] = l
+	return nil
+}
+
+func (lf *logdriverFactory) getLogOptValidator(name string) LogOptValidator {
+	lf.m.Lock()
+	defer lf.m.Unlock()
+
+	return lf.optValidator[name]
+}
+
 func (lf *logdriverFactory) get(name string) (Creator, error) {
 	lf.m.Lock()
 	defer lf.m.Unlock()
@@ -43,7 +160,7 @@ func (lf *logdriverFactory) get(name string) (Creator, error) {
 	return c, nil
 }
 
-var factory = &logdriverFactory{registry: make(map[string]Creator)} // global factory instance
+var factory = &logdriverFactory{registry: make(map[string]Creator), optValidator: make(map[string]LogOptValidator)} // global factory instance
 
 // RegisterLogDriver registers the given logging driver builder with given logging
 // driver name.
@@ -51,7 +168,47 @@ func RegisterLogDriver(name string, c Creator) error {
 	return factory.register(name, c)
 }
 
-// GetLogDriver provides the logging driver builder for a logging driver name.
+// GetLogDriver provides the logging driver builder for a logging driver name,
+// a built-in driver or else a logging driver plugin.
 func GetLogDriver(name string) (Creator, error) {
-	return factory.get(name)
+	c, err := factory.get(name)
+	if err == nil {
+		return c, nil
+	}
+	d, pluginErr := getPluginDriver(name)
+	if pluginErr != nil {
+		return nil, err
+	}
+	return func(ctx Context) (Logger, error) {
+		return newPluginLogger(name, d, ctx)
+	}, nil
+}
+
+// RegisterLogOptValidator registers the validator of the options of the
+// logging driver with given name.
+func RegisterLogOptValidator(name string, l LogOptValidator) error {
+	return factory.registerLogOptValidator(name, l)
+}
+
+// ValidateLogOpts checks the options given to the logging driver name, and
+// the options of the delivery mode of its messages. A built-in logging
+// driver without a validator doesn't take any other option.
+func ValidateLogOpts(name string, cfg map[string]string) error {
+	cfg, modeCfg := SplitModeOpts(cfg)
+	if _, _, err := parseModeOpts(modeCfg); err != nil {
+		return err
+	}
+	if l := factory.getLogOptValidator(name); l != nil {
+		return l(cfg)
+	}
+	if _, err := factory.get(name); err != nil {
+		if _, err := getPluginDriver(name); err == nil {
+			// the plugin checks its options when it starts logging
+			return nil
+		}
+	}
+	for key := range cfg {
+		return fmt.Errorf("unknown log opt '%s' for %s log driver", key, name)
+	}
+	return nil
 }
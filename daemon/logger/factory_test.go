@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtraAttributes(t *testing.T) {
+	ctx := Context{
+		Config: map[string]string{
+			"labels": "com.example.app,com.example.missing",
+			"env":    "ENV,MISSING,EMPTY",
+		},
+		ContainerLabels: map[string]string{"com.example.app": "web", "com.example.other": "db"},
+		ContainerEnv:    []string{"ENV=prod", "EMPTY=", "PATH=/bin", "NOVALUE"},
+	}
+	expected := map[string]string{"com.example.app": "web", "ENV": "prod", "EMPTY": ""}
+	if attrs := ctx.ExtraAttributes(nil); !reflect.DeepEqual(attrs, expected) {
+		t.Fatalf("Expected %v, got %v", expected, attrs)
+	}
+
+	expected = map[string]string{"_COM.EXAMPLE.APP": "web", "_ENV": "prod", "_EMPTY": ""}
+	attrs := ctx.ExtraAttributes(func(key string) string { return "_" + strings.ToUpper(key) })
+	if !reflect.DeepEqual(attrs, expected) {
+		t.Fatalf("Expected %v, got %v", expected, attrs)
+	}
+
+	ctx.Config = nil
+	if attrs := ctx.ExtraAttributes(nil); len(attrs) != 0 {
+		t.Fatalf("Expected no attributes without the labels and env options, got %v", attrs)
+	}
+}
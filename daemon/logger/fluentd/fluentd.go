@@ -0,0 +1,313 @@
+// Package fluentd provides the log driver for forwarding container logs to
+// a fluentd collector with its forward protocol.
+package fluentd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+	"github.com/docker/docker/pkg/units"
+)
+
+const (
+	name = "fluentd"
+
+	defaultAddress     = "localhost:24224"
+	defaultBufferLimit = 8 * 1024 * 1024
+	defaultRetryWait   = time.Second
+	defaultMaxRetries  = 10
+	maxRetryWait       = time.Minute
+	dialTimeout        = 10 * time.Second
+
+	addressKey      = "fluentd-address"
+	tagKey          = "fluentd-tag" // deprecated by the tag option
+	asyncConnectKey = "fluentd-async-connect"
+	bufferLimitKey  = "fluentd-buffer-limit"
+	retryWaitKey    = "fluentd-retry-wait"
+	maxRetriesKey   = "fluentd-max-retries"
+)
+
+type fluentd struct {
+	tag           string
+	containerID   string
+	containerName string
+	extra         map[string]string // the labels and env attributes
+
+	network    string
+	address    string
+	retryWait  time.Duration
+	maxRetries int
+	conn       net.Conn // only used by the sending goroutine
+
+	mu          sync.Mutex
+	cond        *sync.Cond
+	pending     [][]byte // encoded messages waiting to be sent
+	pendingSize int
+	bufferLimit int
+	closed      bool
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+func init() {
+	if err := logger.RegisterLogDriver(name, New); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logger.RegisterLogOptValidator(name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// New creates a fluentd logger for the container of ctx. Messages are sent
+// in the background, and buffered while the collector can't be reached.
+// Unless fluentd-async-connect is set, the collector must be reachable when
+// the container starts.
+func New(ctx logger.Context) (logger.Logger, error) {
+	network, address, err := parseAddress(ctx.Config[addressKey])
+	if err != nil {
+		return nil, err
+	}
+
+	// fluentd-tag is the former option of the tag, without a template
+	defaultTemplate := "docker.{{.ID}}"
+	if tag := ctx.Config[tagKey]; tag != "" {
+		defaultTemplate = tag
+	}
+	tag, err := loggerutils.ParseLogTag(ctx, defaultTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	bufferLimit := defaultBufferLimit
+	if s := ctx.Config[bufferLimitKey]; s != "" {
+		limit, err := units.RAMInBytes(s)
+		if err != nil {
+			return nil, err
+		}
+		bufferLimit = int(limit)
+	}
+
+	retryWait := defaultRetryWait
+	if s := ctx.Config[retryWaitKey]; s != "" {
+		if retryWait, err = time.ParseDuration(s); err != nil {
+			return nil, err
+		}
+	}
+
+	maxRetries := defaultMaxRetries
+	if s := ctx.Config[maxRetriesKey]; s != "" {
+		if maxRetries, err = strconv.Atoi(s); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &fluentd{
+		tag:           tag,
+		containerID:   ctx.ContainerID,
+		containerName: ctx.ContainerName,
+		extra:         ctx.ExtraAttributes(nil),
+		network:       network,
+		address:       address,
+		retryWait:     retryWait,
+		maxRetries:    maxRetries,
+		bufferLimit:   bufferLimit,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	f.cond = sync.NewCond(&f.mu)
+
+	if ctx.Config[asyncConnectKey] != "true" {
+		if f.conn, err = net.DialTimeout(network, address, dialTimeout); err != nil {
+			return nil, fmt.Errorf("fluentd: cannot connect to %s: %v", address, err)
+		}
+	}
+
+	go f.run()
+	return f, nil
+}
+
+// Log queues msg to be sent to the collector. It fails without blocking
+// when the buffer is full.
+func (f *fluentd) Log(msg *logger.Message) error {
+	record := map[string]string{
+		"container_id":   f.containerID,
+		"container_name": f.containerName,
+		"source":         msg.Source,
+		"log":            string(msg.Line),
+	}
+	// the labels and env attributes don't override the fields above
+	for k, v := range f.extra {
+		if _, ok := record[k]; !ok {
+			record[k] = v
+		}
+	}
+	data := encodeMessage(f.tag, msg.Timestamp, record)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return fmt.Errorf("fluentd: logger is closed")
+	}
+	if f.pendingSize+len(data) > f.bufferLimit {
+		return fmt.Errorf("fluentd: buffer full, message dropped")
+	}
+	f.pending = append(f.pending, data)
+	f.pendingSize += len(data)
+	f.cond.Signal()
+	return nil
+}
+
+// run sends the queued messages until the logger is closed and everything
+// that could be sent has been.
+func (f *fluentd) run() {
+	defer close(f.done)
+	for {
+		f.mu.Lock()
+		for len(f.pending) == 0 && !f.closed {
+			f.cond.Wait()
+		}
+		batch := f.pending
+		f.pending = nil
+		f.pendingSize = 0
+		f.mu.Unlock()
+
+		if len(batch) == 0 {
+			break
+		}
+		for i, data := range batch {
+			if err := f.send(data); err != nil {
+				logrus.Errorf("fluentd: dropping %d messages for container %s: %v", len(batch)-i, f.containerID, err)
+				break
+			}
+		}
+	}
+	if f.conn != nil {
+		f.conn.Close()
+	}
+}
+
+// send writes data to the collector, reconnecting to it as needed with an
+// exponential backoff. It gives up after maxRetries retries, or after the
+// first failure once the logger is closed.
+func (f *fluentd) send(data []byte) error {
+	var err error
+	for retry := 0; ; retry++ {
+		if f.conn == nil {
+			f.conn, err = net.DialTimeout(f.network, f.address, dialTimeout)
+		}
+		if f.conn != nil {
+			if _, err = f.conn.Write(data); err == nil {
+				return nil
+			}
+			f.conn.Close()
+			f.conn = nil
+		}
+		if retry >= f.maxRetries {
+			return err
+		}
+
+		wait := f.retryWait << uint(retry)
+		if wait > maxRetryWait || wait <= 0 {
+			wait = maxRetryWait
+		}
+		select {
+		case <-time.After(wait):
+		case <-f.stop:
+			return err
+		}
+	}
+}
+
+// Close stops accepting messages, and waits for the buffered ones to be
+// sent without retrying to reach the collector any longer.
+func (f *fluentd) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	f.cond.Broadcast()
+	f.mu.Unlock()
+
+	close(f.stop)
+	<-f.done
+	return nil
+}
+
+func (f *fluentd) Name() string {
+	return name
+}
+
+func (f *fluentd) GetReader() (io.Reader, error) {
+	return nil, logger.ReadLogsNotSupported
+}
+
+// ValidateLogOpt checks the options of the fluentd log driver.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key, value := range cfg {
+		var err error
+		switch key {
+		case addressKey:
+			_, _, err = parseAddress(value)
+		case loggerutils.TagKey:
+			err = loggerutils.ValidateLogTag(cfg)
+		case tagKey:
+			err = loggerutils.ValidateLogTag(map[string]string{loggerutils.TagKey: value})
+		case asyncConnectKey:
+			_, err = strconv.ParseBool(value)
+		case bufferLimitKey:
+			_, err = units.RAMInBytes(value)
+		case retryWaitKey:
+			_, err = time.ParseDuration(value)
+		case maxRetriesKey:
+			_, err = strconv.Atoi(value)
+		case "labels", "env":
+		default:
+			return fmt.Errorf("unknown log opt '%s' for fluentd log driver", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for log opt '%s': %v", key, err)
+		}
+	}
+	return nil
+}
+
+// parseAddress parses the address of a collector, either host[:port],
+// tcp://host[:port] or unix:///path/to/socket.
+func parseAddress(address string) (string, string, error) {
+	if address == "" {
+		return "tcp", defaultAddress, nil
+	}
+	if strings.HasPrefix(address, "unix://") {
+		path := strings.TrimPrefix(address, "unix://")
+		if path == "" {
+			return "", "", fmt.Errorf("fluentd: missing socket path in %s", address)
+		}
+		return "unix", path, nil
+	}
+	address = strings.TrimPrefix(address, "tcp://")
+	if strings.Contains(address, "://") {
+		return "", "", fmt.Errorf("fluentd: unsupported address %s, must be tcp:// or unix://", address)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		if !strings.Contains(err.Error(), "missing port") {
+			return "", "", fmt.Errorf("fluentd: invalid address %s: %v", address, err)
+		}
+		host, port = address, "24224"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", "", fmt.Errorf("fluentd: invalid port in %s", address)
+	}
+	return "tcp", net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,172 @@
+package fluentd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+func TestEncodeMessage(t *testing.T) {
+	data := encodeMessage("docker.abc", time.Unix(1, 0), map[string]string{"log": "hi", "source": "stdout"})
+	expected := []byte{
+		0x93,
+		0xaa, 'd', 'o', 'c', 'k', 'e', 'r', '.', 'a', 'b', 'c',
+		0xce, 0, 0, 0, 1,
+		0x82,
+		0xa3, 'l', 'o', 'g', 0xa2, 'h', 'i',
+		0xa6, 's', 'o', 'u', 'r', 'c', 'e', 0xa6, 's', 't', 'd', 'o', 'u', 't',
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("Expected %x, got %x", expected, data)
+	}
+
+	long := string(bytes.Repeat([]byte{'a'}, 300))
+	data = appendString(nil, long)
+	if data[0] != 0xda || data[1] != 0x01 || data[2] != 0x2c || len(data) != 303 {
+		t.Fatalf("Unexpected encoding of a 300 bytes string: %x", data[:3])
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	valid := map[string][2]string{
+		"":                      {"tcp", "localhost:24224"},
+		"fluent":                {"tcp", "fluent:24224"},
+		"fluent:24225":          {"tcp", "fluent:24225"},
+		"tcp://10.0.0.1:24225":  {"tcp", "10.0.0.1:24225"},
+		"unix:///var/fluent.sk": {"unix", "/var/fluent.sk"},
+	}
+	for address, expected := range valid {
+		network, addr, err := parseAddress(address)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", address, err)
+		}
+		if network != expected[0] || addr != expected[1] {
+			t.Fatalf("Expected %v for %q, got %s %s", expected, address, network, addr)
+		}
+	}
+	for _, address := range []string{"udp://fluent:24224", "fluent:port", "unix://"} {
+		if _, _, err := parseAddress(address); err == nil {
+			t.Fatalf("Expected an error for %q", address)
+		}
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	if err := ValidateLogOpt(map[string]string{
+		addressKey:      "fluent:24224",
+		tagKey:          "app",
+		asyncConnectKey: "true",
+		bufferLimitKey:  "1m",
+		retryWaitKey:    "2s",
+		maxRetriesKey:   "5",
+		"tag":           "{{.ImageName}}.{{.Name}}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []map[string]string{
+		{"fluentd-foo": "bar"},
+		{retryWaitKey: "2"},
+		{asyncConnectKey: "maybe"},
+		{"tag": "{{.Foo}}"},
+		{tagKey: "{{.Name"},
+	} {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("Expected an error for %v", cfg)
+		}
+	}
+}
+
+func TestFluentdTag(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	ctx := logger.Context{
+		ContainerID:        "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657",
+		ContainerName:      "/test",
+		ContainerImageName: "busybox",
+	}
+	cases := []struct {
+		config   map[string]string
+		expected string
+	}{
+		{map[string]string{}, "docker.a7317399f3f8"},
+		{map[string]string{tagKey: "app"}, "app"},
+		{map[string]string{"tag": "{{.ImageName}}.{{.Name}}"}, "busybox.test"},
+		{map[string]string{"tag": "{{.Name}}", tagKey: "app"}, "test"},
+	}
+	for _, c := range cases {
+		ctx.Config = c.config
+		ctx.Config[addressKey] = l.Addr().String()
+		ctx.Config[asyncConnectKey] = "true"
+		f, err := New(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tag := f.(*fluentd).tag; tag != c.expected {
+			t.Fatalf("Expected the tag %q for %v, got %q", c.expected, c.config, tag)
+		}
+		f.Close()
+	}
+}
+
+func TestFluentdForward(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	received := make(chan []byte)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		data, _ := ioutil.ReadAll(conn)
+		received <- data
+	}()
+
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	f, err := New(logger.Context{
+		Config:        map[string]string{addressKey: l.Addr().String(), tagKey: "app"},
+		ContainerID:   cid,
+		ContainerName: "/test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	var expected []byte
+	for _, line := range []string{"line1", "line2"} {
+		if err := f.Log(&logger.Message{ContainerID: cid, Line: []byte(line), Source: "stdout", Timestamp: now}); err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, encodeMessage("app", now, map[string]string{
+			"container_id":   cid,
+			"container_name": "/test",
+			"source":         "stdout",
+			"log":            line,
+		})...)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-received:
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("Expected %x, got %x", expected, data)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the messages")
+	}
+}
@@ -0,0 +1,65 @@
+package fluentd
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// encodeMessage encodes a record in the message mode of the fluentd forward
+// protocol: the msgpack array [tag, time, record].
+func encodeMessage(tag string, t time.Time, record map[string]string) []byte {
+	buf := []byte{0x93} // fixarray of 3 elements
+	buf = appendString(buf, tag)
+	buf = appendUint(buf, uint64(t.Unix()))
+
+	keys := make([]string, 0, len(record))
+	for k := range record {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf = appendMapHeader(buf, len(keys))
+	for _, k := range keys {
+		buf = appendString(buf, k)
+		buf = appendString(buf, record[k])
+	}
+	return buf
+}
+
+func appendString(buf []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n < 1<<8:
+		buf = append(buf, 0xd9, byte(n))
+	case n < 1<<16:
+		buf = append(buf, 0xda, 0, 0)
+		binary.BigEndian.PutUint16(buf[len(buf)-2:], uint16(n))
+	default:
+		buf = append(buf, 0xdb, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(n))
+	}
+	return append(buf, s...)
+}
+
+func appendUint(buf []byte, v uint64) []byte {
+	if v < 1<<32 {
+		buf = append(buf, 0xce, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(v))
+		return buf
+	}
+	buf = append(buf, 0xcf, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(buf[len(buf)-8:], v)
+	return buf
+}
+
+func appendMapHeader(buf []byte, n int) []byte {
+	if n < 16 {
+		return append(buf, 0x80|byte(n))
+	}
+	buf = append(buf, 0xde, 0, 0)
+	binary.BigEndian.PutUint16(buf[len(buf)-2:], uint16(n))
+	return buf
+}
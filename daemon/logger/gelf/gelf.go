@@ -0,0 +1,170 @@
+// Package gelf provides the log driver for forwarding container logs to a
+// Graylog server, or any other server taking GELF messages.
+package gelf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+)
+
+const (
+	name = "gelf"
+
+	addressKey          = "gelf-address"
+	compressionTypeKey  = "gelf-compression-type"
+	compressionLevelKey = "gelf-compression-level"
+
+	// syslog levels of the messages
+	levelError = 3
+	levelInfo  = 6
+)
+
+type gelfLogger struct {
+	w        writer
+	hostname string
+	fields   map[string]interface{} // additional fields of every message
+}
+
+func init() {
+	if err := logger.RegisterLogDriver(name, New); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logger.RegisterLogOptValidator(name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// New creates a gelf logger for the container of ctx, sending to the
+// gelf-address of its config.
+func New(ctx logger.Context) (logger.Logger, error) {
+	hostname, err := ctx.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	network, address, err := parseAddress(ctx.Config[addressKey])
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := loggerutils.ParseLogTag(ctx, loggerutils.DefaultTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var w writer
+	if network == "udp" {
+		compression, level, err := parseCompression(ctx.Config)
+		if err != nil {
+			return nil, err
+		}
+		w, err = newUDPWriter(address, compression, level)
+	} else {
+		w, err = newTCPWriter(address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gelf: cannot connect to %s: %v", address, err)
+	}
+
+	// Strip the leading slash like the journald driver does, so that
+	// messages can be searched by the name given to docker run.
+	containerName := strings.TrimPrefix(ctx.ContainerName, "/")
+
+	fields := map[string]interface{}{
+		"_container_id":   ctx.ContainerID,
+		"_container_name": containerName,
+		"_image_id":       ctx.ContainerImageID,
+		"_image_name":     ctx.ContainerImageName,
+		"_command":        ctx.Command(),
+		"_created":        ctx.ContainerCreated,
+		"_tag":            tag,
+	}
+	// the labels and env attributes are additional fields too
+	for k, v := range ctx.ExtraAttributes(func(key string) string { return "_" + key }) {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+
+	return &gelfLogger{
+		w:        w,
+		hostname: hostname,
+		fields:   fields,
+	}, nil
+}
+
+func (s *gelfLogger) Log(msg *logger.Message) error {
+	m := make(map[string]interface{}, len(s.fields)+5)
+	for k, v := range s.fields {
+		m[k] = v
+	}
+	m["version"] = "1.1"
+	m["host"] = s.hostname
+	m["short_message"] = string(msg.Line)
+	m["timestamp"] = float64(msg.Timestamp.UnixNano()) / 1e9
+	m["level"] = levelInfo
+	if msg.Source == "stderr" {
+		m["level"] = levelError
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.w.write(data)
+}
+
+func (s *gelfLogger) Close() error {
+	return s.w.Close()
+}
+
+func (s *gelfLogger) Name() string {
+	return name
+}
+
+func (s *gelfLogger) GetReader() (io.Reader, error) {
+	return nil, logger.ReadLogsNotSupported
+}
+
+// ValidateLogOpt checks the options of the gelf log driver.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case addressKey, compressionTypeKey, compressionLevelKey, loggerutils.TagKey:
+		case "labels", "env":
+		default:
+			return fmt.Errorf("unknown log opt '%s' for gelf log driver", key)
+		}
+	}
+	if err := loggerutils.ValidateLogTag(cfg); err != nil {
+		return err
+	}
+	if _, _, err := parseAddress(cfg[addressKey]); err != nil {
+		return err
+	}
+	_, _, err := parseCompression(cfg)
+	return err
+}
+
+// parseAddress parses the address of a gelf server, udp://host:port or
+// tcp://host:port.
+func parseAddress(address string) (string, string, error) {
+	if address == "" {
+		return "", "", fmt.Errorf("gelf: missing log opt %s", addressKey)
+	}
+	parts := strings.SplitN(address, "://", 2)
+	if len(parts) != 2 || (parts[0] != "udp" && parts[0] != "tcp") {
+		return "", "", fmt.Errorf("gelf: unsupported address %s, must be udp://host:port or tcp://host:port", address)
+	}
+	if _, _, err := net.SplitHostPort(parts[1]); err != nil {
+		return "", "", fmt.Errorf("gelf: invalid address %s: %v", address, err)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,192 @@
+package gelf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+func TestSplitChunks(t *testing.T) {
+	data := bytes.Repeat([]byte{'a'}, 3*(chunkSize-chunkHeaderSize)-10)
+	chunks, err := splitChunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+	var joined []byte
+	for i, chunk := range chunks {
+		if len(chunk) > chunkSize {
+			t.Fatalf("Chunk %d is larger than %d bytes: %d", i, chunkSize, len(chunk))
+		}
+		if !bytes.Equal(chunk[:2], chunkMagic) || int(chunk[10]) != i || int(chunk[11]) != 3 {
+			t.Fatalf("Invalid header for chunk %d: %x", i, chunk[:chunkHeaderSize])
+		}
+		if !bytes.Equal(chunk[2:10], chunks[0][2:10]) {
+			t.Fatalf("Chunk %d has a different message id", i)
+		}
+		joined = append(joined, chunk[chunkHeaderSize:]...)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Fatal("The chunks don't add up to the message")
+	}
+
+	if _, err := splitChunks(make([]byte, maxChunks*chunkSize)); err == nil {
+		t.Fatal("Expected an error for a message too large")
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	valid := []map[string]string{
+		{addressKey: "udp://127.0.0.1:12201"},
+		{addressKey: "tcp://graylog:12201"},
+		{addressKey: "udp://graylog:12201", compressionTypeKey: "zlib", compressionLevelKey: "9"},
+		{addressKey: "udp://graylog:12201", "tag": "{{.ImageName}}/{{.Name}}"},
+	}
+	for _, cfg := range valid {
+		if err := ValidateLogOpt(cfg); err != nil {
+			t.Fatalf("Unexpected error for %v: %v", cfg, err)
+		}
+	}
+	invalid := []map[string]string{
+		{},
+		{addressKey: "graylog:12201"},
+		{addressKey: "http://graylog:12201"},
+		{addressKey: "udp://graylog"},
+		{addressKey: "udp://graylog:12201", compressionTypeKey: "lz4"},
+		{addressKey: "udp://graylog:12201", compressionLevelKey: "10"},
+		{addressKey: "udp://graylog:12201", "gelf-foo": "bar"},
+		{addressKey: "udp://graylog:12201", "tag": "{{.Foo}}"},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("Expected an error for %v", cfg)
+		}
+	}
+}
+
+var testContext = logger.Context{
+	ContainerID:         "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657",
+	ContainerName:       "/test",
+	ContainerEntrypoint: "echo",
+	ContainerArgs:       []string{"hello"},
+	ContainerImageID:    "8dbd9e392a964056420e5d58ca5cc376ef18e2de93b5cc90e868a1bbc8318c1c",
+	ContainerImageName:  "busybox",
+}
+
+func checkMessage(t *testing.T, data []byte, line string, level int) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"version":         "1.1",
+		"short_message":   line,
+		"level":           float64(level),
+		"_container_id":   testContext.ContainerID,
+		"_container_name": "test",
+		"_image_name":     "busybox",
+		"_command":        "echo hello",
+		"_tag":            "a7317399f3f8",
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Fatalf("Expected %s to be %v, got %v", k, v, m[k])
+		}
+	}
+}
+
+func TestGelfUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := testContext
+	ctx.Config = map[string]string{addressKey: "udp://" + conn.LocalAddr().String()}
+	l, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if err := l.Log(&logger.Message{Line: []byte("oops"), Source: "stderr", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, chunkSize)
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(buf[:n]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMessage(t, data, "oops", levelError)
+}
+
+func TestGelfTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan [][]byte)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		var messages [][]byte
+		r := bufio.NewReader(conn)
+		for {
+			data, err := r.ReadBytes(0)
+			if err != nil {
+				break
+			}
+			messages = append(messages, data[:len(data)-1])
+		}
+		received <- messages
+	}()
+
+	ctx := testContext
+	ctx.Config = map[string]string{addressKey: "tcp://" + ln.Addr().String()}
+	l, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"line1", "line2"} {
+		if err := l.Log(&logger.Message{Line: []byte(line), Source: "stdout", Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	select {
+	case messages := <-received:
+		if len(messages) != 2 {
+			t.Fatalf("Expected 2 messages, got %d", len(messages))
+		}
+		checkMessage(t, messages[0], "line1", levelInfo)
+		checkMessage(t, messages[1], "line2", levelInfo)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the messages")
+	}
+}
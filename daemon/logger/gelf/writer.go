@@ -0,0 +1,220 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// chunkSize is the largest UDP datagram sent, which fits in the MTU of
+	// most networks. Larger messages are split in chunks.
+	chunkSize       = 1420
+	chunkHeaderSize = 12
+	maxChunks       = 128
+
+	dialTimeout = 10 * time.Second
+)
+
+var chunkMagic = []byte{0x1e, 0x0f}
+
+type compressionType string
+
+const (
+	compressionGzip compressionType = "gzip"
+	compressionZlib compressionType = "zlib"
+	compressionNone compressionType = "none"
+)
+
+// writer sends the JSON encoded messages to a gelf server.
+type writer interface {
+	write(data []byte) error
+	Close() error
+}
+
+type udpWriter struct {
+	conn        net.Conn
+	compression compressionType
+	level       int
+}
+
+func newUDPWriter(address string, compression compressionType, level int) (*udpWriter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &udpWriter{
+		conn:        conn,
+		compression: compression,
+		level:       level,
+	}, nil
+}
+
+// write sends data, compressed, in a single datagram, or in chunks if it
+// doesn't fit.
+func (w *udpWriter) write(data []byte) error {
+	data, err := compress(data, w.compression, w.level)
+	if err != nil {
+		return err
+	}
+	if len(data) <= chunkSize {
+		_, err := w.conn.Write(data)
+		return err
+	}
+
+	chunks, err := splitChunks(data)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *udpWriter) Close() error {
+	return w.conn.Close()
+}
+
+// splitChunks splits data in chunks of a GELF chunked message, each with
+// the chunk header: the magic bytes, the id of the message, the sequence
+// number of the chunk and the number of chunks.
+func splitChunks(data []byte) ([][]byte, error) {
+	payloadSize := chunkSize - chunkHeaderSize
+	count := (len(data) + payloadSize - 1) / payloadSize
+	if count > maxChunks {
+		return nil, fmt.Errorf("gelf: message of %d bytes is too large, it would need %d chunks", len(data), count)
+	}
+
+	id := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, err
+	}
+
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * payloadSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := make([]byte, 0, chunkHeaderSize+end-seq*payloadSize)
+		chunk = append(chunk, chunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunk = append(chunk, data[seq*payloadSize:end]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+func compress(data []byte, compression compressionType, level int) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		zw  io.WriteCloser
+		err error
+	)
+	switch compression {
+	case compressionNone:
+		return data, nil
+	case compressionZlib:
+		zw, err = zlib.NewWriterLevel(&buf, level)
+	default:
+		zw, err = gzip.NewWriterLevel(&buf, level)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseCompression returns the compression type and level of the UDP
+// messages, gzip with the default level unless configured otherwise.
+func parseCompression(cfg map[string]string) (compressionType, int, error) {
+	compression := compressionGzip
+	if s := cfg[compressionTypeKey]; s != "" {
+		compression = compressionType(s)
+		switch compression {
+		case compressionGzip, compressionZlib, compressionNone:
+		default:
+			return "", 0, fmt.Errorf("gelf: unknown compression type %s, must be gzip, zlib or none", s)
+		}
+	}
+
+	level := flate.DefaultCompression
+	if s := cfg[compressionLevelKey]; s != "" {
+		var err error
+		if level, err = strconv.Atoi(s); err != nil || level < flate.DefaultCompression || level > flate.BestCompression {
+			return "", 0, fmt.Errorf("gelf: invalid compression level %s, must be between -1 and 9", s)
+		}
+	}
+	return compression, level, nil
+}
+
+// tcpWriter sends the messages uncompressed, each followed by a null byte,
+// reconnecting once to the server when it fails.
+type tcpWriter struct {
+	address string
+	mu      sync.Mutex
+	conn    net.Conn
+}
+
+func newTCPWriter(address string) (*tcpWriter, error) {
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpWriter{
+		address: address,
+		conn:    conn,
+	}, nil
+}
+
+func (w *tcpWriter) write(data []byte) error {
+	data = append(data, 0)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	for retry := 0; retry < 2; retry++ {
+		if w.conn == nil {
+			if w.conn, err = net.DialTimeout("tcp", w.address, dialTimeout); err != nil {
+				continue
+			}
+		}
+		if _, err = w.conn.Write(data); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+func (w *tcpWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -5,6 +5,7 @@ package journald
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/coreos/go-systemd/journal"
@@ -21,6 +22,25 @@ func init() {
 	if err := logger.RegisterLogDriver(name, New); err != nil {
 		logrus.Fatal(err)
 	}
+	if err := logger.RegisterLogOptValidator(name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// sanitizeKeyMod returns the journal field name of the key of a label or
+// an environment variable: journal field names are made of uppercase
+// letters, digits and underscores, and can't start with an underscore.
+func sanitizeKeyMod(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, s)
+	return strings.TrimLeft(s, "_")
 }
 
 func New(ctx logger.Context) (logger.Logger, error) {
@@ -37,9 +57,26 @@ func New(ctx logger.Context) (logger.Logger, error) {
 		"CONTAINER_ID":      ctx.ContainerID[:12],
 		"CONTAINER_ID_FULL": ctx.ContainerID,
 		"CONTAINER_NAME":    name}
+	for k, v := range ctx.ExtraAttributes(sanitizeKeyMod) {
+		if _, ok := jmap[k]; !ok && k != "" {
+			jmap[k] = v
+		}
+	}
 	return &Journald{Jmap: jmap}, nil
 }
 
+// ValidateLogOpt checks the options of the journald log driver.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case "labels", "env":
+		default:
+			return fmt.Errorf("unknown log opt '%s' for journald log driver", key)
+		}
+	}
+	return nil
+}
+
 func (s *Journald) Log(msg *logger.Message) error {
 	if msg.Source == "stderr" {
 		return journal.Send(string(msg.Line), journal.PriErr, s.Jmap)
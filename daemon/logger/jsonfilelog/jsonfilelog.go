@@ -2,18 +2,26 @@ package jsonfilelog
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/pkg/jsonlog"
+	"github.com/docker/docker/pkg/tailfile"
 	"github.com/docker/docker/pkg/timeutils"
+	"github.com/docker/docker/pkg/units"
 )
 
 const (
 	Name = "json-file"
+
+	maxSizeKey = "max-size"
+	maxFileKey = "max-file"
 )
 
 // JSONFileLogger is Logger implementation for default docker logging:
@@ -23,6 +31,12 @@ type JSONFileLogger struct {
 	f   *os.File   // store for closing
 	mu  sync.Mutex // protects buffer
 
+	capacity    int64 // maximum size of the file before rotating it, -1 for no limit
+	maxFiles    int   // number of files kept, including the current one
+	currentSize int64 // size of the current file
+
+	extra []byte // the JSON of the labels and env attributes of each message
+
 	ctx logger.Context
 }
 
@@ -30,21 +44,83 @@ func init() {
 	if err := logger.RegisterLogDriver(Name, New); err != nil {
 		logrus.Fatal(err)
 	}
+	if err := logger.RegisterLogOptValidator(Name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
 }
 
-// New creates new JSONFileLogger which writes to filename
+// New creates new JSONFileLogger which writes to filename. With the max-size
+// option, the file is rotated when it reaches that size, keeping max-file
+// files in total, the previous ones suffixed with .1, .2 and so on.
 func New(ctx logger.Context) (logger.Logger, error) {
+	capacity, maxFiles, err := parseRotation(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
 	log, err := os.OpenFile(ctx.LogPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, err
 	}
+	size, err := log.Seek(0, os.SEEK_END)
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	var extra []byte
+	if attrs := ctx.ExtraAttributes(nil); len(attrs) > 0 {
+		if extra, err = json.Marshal(attrs); err != nil {
+			log.Close()
+			return nil, err
+		}
+	}
 	return &JSONFileLogger{
-		f:   log,
-		buf: bytes.NewBuffer(nil),
-		ctx: ctx,
+		f:           log,
+		buf:         bytes.NewBuffer(nil),
+		capacity:    capacity,
+		maxFiles:    maxFiles,
+		currentSize: size,
+		extra:       extra,
+		ctx:         ctx,
 	}, nil
 }
 
+// parseRotation returns the maximum size of the log file and the number of
+// files kept, -1 and 1 unless configured otherwise.
+func parseRotation(cfg map[string]string) (int64, int, error) {
+	capacity := int64(-1)
+	if s, ok := cfg[maxSizeKey]; ok {
+		var err error
+		if capacity, err = units.FromHumanSize(s); err != nil || capacity <= 0 {
+			return 0, 0, fmt.Errorf("invalid value for log opt '%s': %s, must be a positive size such as 10m", maxSizeKey, s)
+		}
+	}
+	maxFiles := 1
+	if s, ok := cfg[maxFileKey]; ok {
+		if capacity == -1 {
+			return 0, 0, fmt.Errorf("log opt '%s' requires '%s'", maxFileKey, maxSizeKey)
+		}
+		var err error
+		if maxFiles, err = strconv.Atoi(s); err != nil || maxFiles < 1 {
+			return 0, 0, fmt.Errorf("invalid value for log opt '%s': %s, must be a number of files of at least 1", maxFileKey, s)
+		}
+	}
+	return capacity, maxFiles, nil
+}
+
+// ValidateLogOpt checks the options of the json-file log driver.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case maxSizeKey, maxFileKey:
+		case "labels", "env":
+		default:
+			return fmt.Errorf("unknown log opt '%s' for json-file log driver", key)
+		}
+	}
+	_, _, err := parseRotation(cfg)
+	return err
+}
+
 // Log converts logger.Message to jsonlog.JSONLog and serializes it to file
 func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	l.mu.Lock()
@@ -54,12 +130,19 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	if err != nil {
 		return err
 	}
-	err = (&jsonlog.JSONLogBytes{Log: append(msg.Line, '\n'), Stream: msg.Source, Created: timestamp}).MarshalJSONBuf(l.buf)
+	err = (&jsonlog.JSONLogBytes{Log: append(msg.Line, '\n'), Stream: msg.Source, Attrs: l.extra, Created: timestamp}).MarshalJSONBuf(l.buf)
 	if err != nil {
 		return err
 	}
 	l.buf.WriteByte('\n')
-	_, err = l.buf.WriteTo(l.f)
+	if l.capacity != -1 && l.currentSize > 0 && l.currentSize+int64(l.buf.Len()) > l.capacity {
+		if err := l.rotate(); err != nil {
+			l.buf.Reset()
+			return err
+		}
+	}
+	n, err := l.buf.WriteTo(l.f)
+	l.currentSize += n
 	if err != nil {
 		// this buffer is screwed, replace it with another to avoid races
 		l.buf = bytes.NewBuffer(nil)
@@ -68,8 +151,115 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	return nil
 }
 
+// rotate shifts the log files, dropping the oldest one, and starts a new
+// current file.
+func (l *JSONFileLogger) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if l.maxFiles > 1 {
+		for i := l.maxFiles - 1; i > 1; i-- {
+			if err := os.Rename(rotatedPath(l.ctx.LogPath, i-1), rotatedPath(l.ctx.LogPath, i)); err != nil && !os.IsNotExist(err) {
+				logrus.Errorf("Failed to rotate log file %s: %v", rotatedPath(l.ctx.LogPath, i-1), err)
+			}
+		}
+		if err := os.Rename(l.ctx.LogPath, rotatedPath(l.ctx.LogPath, 1)); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(l.ctx.LogPath, os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	l.f = f
+	l.currentSize = 0
+	return nil
+}
+
+func rotatedPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// openLogFiles opens the log files from the oldest rotated one to the
+// current one.
+func (l *JSONFileLogger) openLogFiles() ([]*os.File, error) {
+	var files []*os.File
+	for i := l.maxFiles - 1; i > 0; i-- {
+		f, err := os.Open(rotatedPath(l.ctx.LogPath, i))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			closeFiles(files)
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	f, err := os.Open(l.ctx.LogPath)
+	if err != nil {
+		closeFiles(files)
+		return nil, err
+	}
+	return append(files, f), nil
+}
+
+func closeFiles(files []*os.File) error {
+	var err error
+	for _, f := range files {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// logReader reads the log files one after the other, and closes them all.
+type logReader struct {
+	io.Reader
+	files []*os.File
+}
+
+func (r *logReader) Close() error {
+	return closeFiles(r.files)
+}
+
+// GetReader returns a reader of all the logs, across the rotated files. The
+// reader must be closed.
 func (l *JSONFileLogger) GetReader() (io.Reader, error) {
-	return os.Open(l.ctx.LogPath)
+	files, err := l.openLogFiles()
+	if err != nil {
+		return nil, err
+	}
+	readers := make([]io.Reader, len(files))
+	for i, f := range files {
+		readers[i] = f
+	}
+	return &logReader{Reader: io.MultiReader(readers...), files: files}, nil
+}
+
+// Tail returns a reader of the last n lines of the logs, across the rotated
+// files.
+func (l *JSONFileLogger) Tail(n int) (io.Reader, error) {
+	files, err := l.openLogFiles()
+	if err != nil {
+		return nil, err
+	}
+	defer closeFiles(files)
+
+	var lines [][]byte
+	for i := len(files) - 1; i >= 0 && len(lines) < n; i-- {
+		ls, err := tailfile.TailFile(files[i], n-len(lines))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(ls, lines...)
+	}
+	buf := bytes.NewBuffer(nil)
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf, nil
 }
 
 func (l *JSONFileLogger) LogPath() string {
@@ -1,6 +1,8 @@
 package jsonfilelog
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -51,6 +53,134 @@ func TestJSONFileLogger(t *testing.T) {
 	}
 }
 
+func TestJSONFileLoggerWithLabelsEnv(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	l, err := New(logger.Context{
+		ContainerID:     cid,
+		LogPath:         filename,
+		Config:          map[string]string{"labels": "rack,dc", "env": "environ,debug,ssl"},
+		ContainerLabels: map[string]string{"rack": "101", "dc": "lhr"},
+		ContainerEnv:    []string{"environ=production", "debug=false", "port=10001", "ssl=true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line"), Source: "src1"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"log":"line\n","stream":"src1","attrs":{"dc":"lhr","debug":"false","environ":"production","rack":"101","ssl":"true"},"time":"0001-01-01T00:00:00Z"}
+`
+	if string(res) != expected {
+		t.Fatalf("Wrong log content: %q, expected %q", res, expected)
+	}
+}
+
+func TestJSONFileLoggerWithOpts(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	config := map[string]string{"max-file": "3", "max-size": "100"}
+	l, err := New(logger.Context{
+		ContainerID: cid,
+		LogPath:     filename,
+		Config:      config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 1; i <= 5; i++ {
+		if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte(fmt.Sprintf("line%d", i)), Source: "src1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		filename:        `{"log":"line5\n","stream":"src1","time":"0001-01-01T00:00:00Z"}` + "\n",
+		filename + ".1": `{"log":"line4\n","stream":"src1","time":"0001-01-01T00:00:00Z"}` + "\n",
+		filename + ".2": `{"log":"line3\n","stream":"src1","time":"0001-01-01T00:00:00Z"}` + "\n",
+	}
+	for name, content := range expected {
+		res, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res) != content {
+			t.Fatalf("Wrong content of %s: %q, expected %q", name, res, content)
+		}
+	}
+	if _, err := os.Stat(filename + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("Expected only 3 log files, got %v", err)
+	}
+
+	jl := l.(*JSONFileLogger)
+	r, err := jl.GetReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ioutil.ReadAll(r)
+	r.(io.Closer).Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := expected[filename+".2"] + expected[filename+".1"] + expected[filename]
+	if string(res) != all {
+		t.Fatalf("Wrong logs across the files: %q, expected %q", res, all)
+	}
+
+	r, err = jl.Tail(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tail := expected[filename+".1"] + expected[filename]; string(res) != tail {
+		t.Fatalf("Wrong tail across the files: %q, expected %q", res, tail)
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	for _, cfg := range []map[string]string{
+		{},
+		{"max-size": "10m"},
+		{"max-size": "10m", "max-file": "5"},
+		{"labels": "rack", "env": "environ"},
+	} {
+		if err := ValidateLogOpt(cfg); err != nil {
+			t.Fatalf("Unexpected error for %v: %v", cfg, err)
+		}
+	}
+	for _, cfg := range []map[string]string{
+		{"max-file": "5"},
+		{"max-size": "-1"},
+		{"max-size": "10m", "max-file": "0"},
+		{"max-size": "10m", "foo": "bar"},
+	} {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("Expected an error for %v", cfg)
+		}
+	}
+}
+
 func BenchmarkJSONFileLogger(b *testing.B) {
 	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
 	tmp, err := ioutil.TempDir("", "docker-logger-")
@@ -0,0 +1,50 @@
+// Package loggerutils provides helpers shared by the logging drivers.
+package loggerutils
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+// TagKey is the log opt setting the template of the tag of the messages.
+const TagKey = "tag"
+
+// DefaultTemplate is the default template of the tag, the short ID of the
+// container.
+const DefaultTemplate = "{{.ID}}"
+
+// ParseLogTag returns the tag of the messages of the container of ctx, from
+// the template of its tag log opt, or defaultTemplate if it isn't set.
+func ParseLogTag(ctx logger.Context, defaultTemplate string) (string, error) {
+	tagTemplate := ctx.Config[TagKey]
+	if tagTemplate == "" {
+		tagTemplate = defaultTemplate
+	}
+	return executeTagTemplate(tagTemplate, &ctx)
+}
+
+// ValidateLogTag checks the template of the tag log opt of cfg, if any.
+func ValidateLogTag(cfg map[string]string) error {
+	tagTemplate, ok := cfg[TagKey]
+	if !ok {
+		return nil
+	}
+	// fields missing from the template fail when executed only
+	_, err := executeTagTemplate(tagTemplate, &logger.Context{})
+	return err
+}
+
+func executeTagTemplate(tagTemplate string, ctx *logger.Context) (string, error) {
+	tmpl, err := template.New("log-tag").Parse(tagTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid log tag template %q: %v", tagTemplate, err)
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, ctx); err != nil {
+		return "", fmt.Errorf("invalid log tag template %q: %v", tagTemplate, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,56 @@
+package loggerutils
+
+import (
+	"testing"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+var testContext = logger.Context{
+	ContainerID:        "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657",
+	ContainerName:      "/test-container",
+	ContainerImageID:   "8dbd9e392a964056420e5d58ca5cc376ef18e2de93b5cc90e868a1bbc8318c1c",
+	ContainerImageName: "test-image",
+}
+
+func TestParseLogTag(t *testing.T) {
+	cases := map[string]string{
+		"":                                 "a7317399f3f8",
+		"{{.ID}}":                          "a7317399f3f8",
+		"{{.FullID}}":                      testContext.ContainerID,
+		"{{.Name}}":                        "test-container",
+		"{{.ImageID}}":                     "8dbd9e392a96",
+		"{{.ImageFullID}}":                 testContext.ContainerImageID,
+		"{{.ImageName}}/{{.Name}}/{{.ID}}": "test-image/test-container/a7317399f3f8",
+		"app.{{.Name}}":                    "app.test-container",
+		"static":                           "static",
+	}
+	for tmpl, expected := range cases {
+		ctx := testContext
+		ctx.Config = map[string]string{}
+		if tmpl != "" {
+			ctx.Config[TagKey] = tmpl
+		}
+		tag, err := ParseLogTag(ctx, DefaultTemplate)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tmpl, err)
+		}
+		if tag != expected {
+			t.Fatalf("Expected %q for %q, got %q", expected, tmpl, tag)
+		}
+	}
+}
+
+func TestValidateLogTag(t *testing.T) {
+	if err := ValidateLogTag(map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLogTag(map[string]string{TagKey: "{{.ImageName}}/{{.Name}}"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tmpl := range []string{"{{.Foo}}", "{{.Name", "{{template \"x\"}}"} {
+		if err := ValidateLogTag(map[string]string{TagKey: tmpl}); err == nil {
+			t.Fatalf("Expected an error for %q", tmpl)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// pluginExtPoint is the extension point implemented by the logging driver
+// plugins.
+const pluginExtPoint = "LogDriver"
+
+// Capability lists what a logging driver can do besides logging.
+type Capability struct {
+	// ReadLogs is whether the logging driver can read the logs back, in
+	// which case they aren't kept in the local cache of the daemon.
+	ReadLogs bool
+}
+
+// LogReader reads the logs of a container back, as a stream of JSON
+// messages in the format of the json-file logging driver.
+type LogReader interface {
+	GetReader() (io.Reader, error)
+	Tail(n int) (io.Reader, error)
+	Close() error
+}
+
+type pluginDriver struct {
+	client *plugins.Client
+	cap    Capability
+}
+
+type pluginResponse struct {
+	Err string
+}
+
+type capabilitiesResponse struct {
+	Cap Capability
+	Err string
+}
+
+type startLoggingRequest struct {
+	ID   string
+	Info Context
+}
+
+type stopLoggingRequest struct {
+	ID string
+}
+
+type readLogsRequest struct {
+	ID   string
+	Info Context
+	Tail int
+}
+
+// logStreamHeader starts the stream of the messages of a container.
+type logStreamHeader struct {
+	ID string
+}
+
+// logEntry is a message in the stream of the messages of a container.
+type logEntry struct {
+	Source string
+	Time   time.Time
+	Line   string
+}
+
+var pluginDrivers = struct {
+	sync.Mutex
+	drivers map[string]*pluginDriver
+}{drivers: make(map[string]*pluginDriver)}
+
+// getPluginDriver returns the logging driver plugin name, negotiating its
+// capabilities the first time it is used.
+func getPluginDriver(name string) (*pluginDriver, error) {
+	pluginDrivers.Lock()
+	defer pluginDrivers.Unlock()
+
+	if d, ok := pluginDrivers.drivers[name]; ok {
+		return d, nil
+	}
+	p, err := plugins.Get(name, pluginExtPoint)
+	if err != nil {
+		return nil, err
+	}
+	d := &pluginDriver{client: p.Client}
+	var res capabilitiesResponse
+	if err := p.Client.Call("LogDriver.Capabilities", nil, &res); err != nil {
+		// the plugin doesn't tell its capabilities, so it only logs
+		logrus.Debugf("logger: no capabilities for the %s logging driver plugin: %v", name, err)
+	} else if res.Err == "" {
+		d.cap = res.Cap
+	}
+	pluginDrivers.drivers[name] = d
+	return d, nil
+}
+
+// pluginLogger streams the messages of a container to a logging driver
+// plugin.
+type pluginLogger struct {
+	name   string
+	id     string
+	client *plugins.Client
+	cap    Capability
+	ctx    Context
+
+	mu     sync.Mutex
+	enc    *json.Encoder
+	w      *io.PipeWriter
+	closed chan error
+}
+
+func newPluginLogger(name string, d *pluginDriver, ctx Context) (Logger, error) {
+	l := &pluginLogger{
+		name:   name,
+		id:     ctx.ContainerID,
+		client: d.client,
+		cap:    d.cap,
+		ctx:    ctx,
+		closed: make(chan error, 1),
+	}
+	var res pluginResponse
+	if err := l.client.Call("LogDriver.StartLogging", startLoggingRequest{ID: l.id, Info: ctx}, &res); err != nil {
+		return nil, fmt.Errorf("logger: error starting to log to the %s logging driver plugin: %v", name, err)
+	}
+	if res.Err != "" {
+		return nil, fmt.Errorf("logger: error starting to log to the %s logging driver plugin: %s", name, res.Err)
+	}
+
+	r, w := io.Pipe()
+	l.w = w
+	l.enc = json.NewEncoder(w)
+	go func() {
+		var res pluginResponse
+		err := l.client.SendStream("LogDriver.Log", r, &res)
+		if err == nil && res.Err != "" {
+			err = fmt.Errorf("%s", res.Err)
+		}
+		// fail the messages logged after the stream ended
+		r.CloseWithError(fmt.Errorf("the stream of the messages ended: %v", err))
+		l.closed <- err
+	}()
+	if err := l.enc.Encode(logStreamHeader{ID: l.id}); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("logger: error streaming the messages to the %s logging driver plugin: %v", name, err)
+	}
+	return l, nil
+}
+
+func (l *pluginLogger) Log(msg *Message) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(logEntry{Source: msg.Source, Time: msg.Timestamp, Line: string(msg.Line)}); err != nil {
+		return fmt.Errorf("logger: error sending the message to the %s logging driver plugin: %v", l.name, err)
+	}
+	return nil
+}
+
+func (l *pluginLogger) Name() string {
+	return l.name
+}
+
+func (l *pluginLogger) GetReader() (io.Reader, error) {
+	return newPluginLogReader(l.client, l.cap, l.ctx).GetReader()
+}
+
+// Close ends the stream of the messages and then stops logging the
+// container.
+func (l *pluginLogger) Close() error {
+	l.mu.Lock()
+	l.w.Close()
+	l.mu.Unlock()
+
+	err := <-l.closed
+	var res pluginResponse
+	if stopErr := l.client.Call("LogDriver.StopLogging", stopLoggingRequest{ID: l.id}, &res); stopErr != nil {
+		err = stopErr
+	} else if res.Err != "" {
+		err = fmt.Errorf("%s", res.Err)
+	}
+	if err != nil {
+		return fmt.Errorf("logger: error stopping to log to the %s logging driver plugin: %v", l.name, err)
+	}
+	return nil
+}
+
+// pluginLogReader reads the logs of a container from a logging driver
+// plugin able to read them.
+type pluginLogReader struct {
+	client *plugins.Client
+	cap    Capability
+	ctx    Context
+}
+
+func newPluginLogReader(client *plugins.Client, cap Capability, ctx Context) *pluginLogReader {
+	return &pluginLogReader{client: client, cap: cap, ctx: ctx}
+}
+
+func (r *pluginLogReader) GetReader() (io.Reader, error) {
+	return r.Tail(-1)
+}
+
+// Tail reads the last n messages, or all of them if n is negative.
+func (r *pluginLogReader) Tail(n int) (io.Reader, error) {
+	if !r.cap.ReadLogs {
+		return nil, ReadLogsNotSupported
+	}
+	return r.client.Stream("LogDriver.ReadLogs", readLogsRequest{ID: r.ctx.ContainerID, Info: r.ctx, Tail: n})
+}
+
+func (r *pluginLogReader) Close() error {
+	return nil
+}
+
+// GetCapability returns the capabilities of the logging driver name. Only
+// the logging driver plugins tell their capabilities, the built-in drivers
+// being handled by the daemon.
+func GetCapability(name string) Capability {
+	if _, err := factory.get(name); err == nil {
+		return Capability{}
+	}
+	d, err := getPluginDriver(name)
+	if err != nil {
+		return Capability{}
+	}
+	return d.cap
+}
+
+// GetLogReader returns the reader of the logs of the container of ctx from
+// the logging driver plugin name, if it can read them.
+func GetLogReader(name string, ctx Context) (LogReader, error) {
+	d, err := getPluginDriver(name)
+	if err != nil {
+		return nil, err
+	}
+	if !d.cap.ReadLogs {
+		return nil, ReadLogsNotSupported
+	}
+	return newPluginLogReader(d.client, d.cap, ctx), nil
+}
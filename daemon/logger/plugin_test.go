@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// testPlugin is a logging driver plugin keeping the messages it is sent.
+type testPlugin struct {
+	sync.Mutex
+	started  []startLoggingRequest
+	stopped  []string
+	streams  map[string][]logEntry
+	readLogs readLogsRequest
+}
+
+func newTestPlugin(t *testing.T) (*testPlugin, *httptest.Server) {
+	p := &testPlugin{streams: make(map[string][]logEntry)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/LogDriver.StartLogging", func(w http.ResponseWriter, r *http.Request) {
+		var req startLoggingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		p.Lock()
+		p.started = append(p.started, req)
+		p.Unlock()
+		json.NewEncoder(w).Encode(pluginResponse{})
+	})
+	mux.HandleFunc("/LogDriver.Log", func(w http.ResponseWriter, r *http.Request) {
+		dec := json.NewDecoder(r.Body)
+		var header logStreamHeader
+		if err := dec.Decode(&header); err != nil {
+			t.Fatal(err)
+		}
+		for {
+			var e logEntry
+			if err := dec.Decode(&e); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			p.Lock()
+			p.streams[header.ID] = append(p.streams[header.ID], e)
+			p.Unlock()
+		}
+		json.NewEncoder(w).Encode(pluginResponse{})
+	})
+	mux.HandleFunc("/LogDriver.StopLogging", func(w http.ResponseWriter, r *http.Request) {
+		var req stopLoggingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		p.Lock()
+		p.stopped = append(p.stopped, req.ID)
+		p.Unlock()
+		json.NewEncoder(w).Encode(pluginResponse{})
+	})
+	mux.HandleFunc("/LogDriver.ReadLogs", func(w http.ResponseWriter, r *http.Request) {
+		p.Lock()
+		defer p.Unlock()
+		if err := json.NewDecoder(r.Body).Decode(&p.readLogs); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, `{"log":"line\n","stream":"stdout","time":"2015-06-01T12:00:00Z"}`+"\n")
+	})
+	return p, httptest.NewServer(mux)
+}
+
+func TestPluginLogger(t *testing.T) {
+	p, server := newTestPlugin(t)
+	defer server.Close()
+
+	d := &pluginDriver{client: plugins.NewClient(server.URL)}
+	ctx := Context{
+		Config:        map[string]string{"plugin-opt": "value"},
+		ContainerID:   "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657",
+		ContainerName: "/test",
+	}
+	l, err := newPluginLogger("test", d, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name() != "test" {
+		t.Fatalf("Expected the name of the plugin, got %q", l.Name())
+	}
+	now := time.Now().UTC()
+	for _, line := range []string{"line1", "line2"} {
+		if err := l.Log(&Message{Line: []byte(line), Source: "stdout", Timestamp: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	if len(p.started) != 1 || p.started[0].ID != ctx.ContainerID || p.started[0].Info.Name() != "test" || p.started[0].Info.Config["plugin-opt"] != "value" {
+		t.Fatalf("Expected the plugin to start logging the container, got %+v", p.started)
+	}
+	entries := p.streams[ctx.ContainerID]
+	if len(entries) != 2 || entries[0].Line != "line1" || entries[1].Line != "line2" || entries[1].Source != "stdout" || !entries[1].Time.Equal(now) {
+		t.Fatalf("Expected the plugin to get the messages, got %+v", entries)
+	}
+	if len(p.stopped) != 1 || p.stopped[0] != ctx.ContainerID {
+		t.Fatalf("Expected the plugin to stop logging the container, got %v", p.stopped)
+	}
+}
+
+func TestPluginLogReader(t *testing.T) {
+	p, server := newTestPlugin(t)
+	defer server.Close()
+
+	ctx := Context{ContainerID: "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"}
+	r := newPluginLogReader(plugins.NewClient(server.URL), Capability{}, ctx)
+	if _, err := r.GetReader(); err != ReadLogsNotSupported {
+		t.Fatalf("Expected the plugin not to read the logs without the capability, got %v", err)
+	}
+
+	r = newPluginLogReader(plugins.NewClient(server.URL), Capability{ReadLogs: true}, ctx)
+	rdr, err := r.Tail(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rdr.(io.Closer).Close()
+	b, err := ioutil.ReadAll(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"log":"line\n","stream":"stdout","time":"2015-06-01T12:00:00Z"}`+"\n" {
+		t.Fatalf("Expected the logs of the plugin, got %q", b)
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.readLogs.ID != ctx.ContainerID || p.readLogs.Tail != 10 {
+		t.Fatalf("Expected the plugin to read the last 10 messages of the container, got %+v", p.readLogs)
+	}
+}
@@ -0,0 +1,200 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/units"
+)
+
+const (
+	modeKey          = "mode"
+	maxBufferSizeKey = "max-buffer-size"
+
+	// ModeBlocking delivers the messages to the logging driver as they are
+	// logged, blocking the output of the container while it is busy.
+	ModeBlocking = "blocking"
+	// ModeNonBlocking buffers the messages for the logging driver, dropping
+	// them when the buffer is full, so that the output of the container
+	// never blocks.
+	ModeNonBlocking = "non-blocking"
+
+	defaultMaxBufferSize = 1024 * 1024
+)
+
+var errRingClosed = errors.New("logger: the buffer of the messages is closed")
+
+// RingLogger buffers the messages for a logging driver in memory, up to a
+// maximum size, and delivers them in the background. The messages logged
+// while the buffer is full are dropped and counted.
+type RingLogger struct {
+	l       Logger
+	maxSize int64
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []*Message
+	size   int64
+	closed bool
+	done   chan struct{}
+
+	dropped      uint64
+	droppedBytes uint64
+}
+
+// NewRingLogger returns a logger buffering up to maxSize bytes of messages
+// for l.
+func NewRingLogger(l Logger, maxSize int64) *RingLogger {
+	r := &RingLogger{
+		l:       l,
+		maxSize: maxSize,
+		done:    make(chan struct{}),
+	}
+	r.cond = sync.NewCond(&r.mu)
+	go r.run()
+	return r
+}
+
+// Log buffers the message, or drops it if the buffer is full. A message
+// larger than the buffer is only buffered when the buffer is empty.
+func (r *RingLogger) Log(msg *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return errRingClosed
+	}
+	size := int64(len(msg.Line))
+	if r.size > 0 && r.size+size > r.maxSize {
+		atomic.AddUint64(&r.dropped, 1)
+		atomic.AddUint64(&r.droppedBytes, uint64(size))
+		return nil
+	}
+	// the line is reused by the copier once logged
+	m := *msg
+	m.Line = append([]byte(nil), msg.Line...)
+	r.queue = append(r.queue, &m)
+	r.size += size
+	r.cond.Signal()
+	return nil
+}
+
+// run delivers the buffered messages to the logging driver, until the
+// buffer is closed and empty.
+func (r *RingLogger) run() {
+	defer close(r.done)
+	for {
+		r.mu.Lock()
+		for len(r.queue) == 0 && !r.closed {
+			r.cond.Wait()
+		}
+		if len(r.queue) == 0 {
+			r.mu.Unlock()
+			return
+		}
+		msg := r.queue[0]
+		r.queue[0] = nil
+		r.queue = r.queue[1:]
+		r.size -= int64(len(msg.Line))
+		r.mu.Unlock()
+
+		if err := r.l.Log(msg); err != nil {
+			logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, r.l.Name(), err)
+		}
+	}
+}
+
+// Dropped returns the number of messages dropped while the buffer was full,
+// and their size in bytes.
+func (r *RingLogger) Dropped() (uint64, uint64) {
+	return atomic.LoadUint64(&r.dropped), atomic.LoadUint64(&r.droppedBytes)
+}
+
+func (r *RingLogger) Name() string {
+	return r.l.Name()
+}
+
+func (r *RingLogger) GetReader() (io.Reader, error) {
+	return r.l.GetReader()
+}
+
+// LogPath returns the path of the log file of the logging driver, if it
+// has one.
+func (r *RingLogger) LogPath() string {
+	if l, ok := r.l.(interface {
+		LogPath() string
+	}); ok {
+		return l.LogPath()
+	}
+	return ""
+}
+
+// Close delivers the messages left in the buffer, and then closes the
+// logging driver.
+func (r *RingLogger) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.cond.Signal()
+	r.mu.Unlock()
+	<-r.done
+	return r.l.Close()
+}
+
+// SplitModeOpts splits the options of a logging driver from the options of
+// the delivery mode of its messages.
+func SplitModeOpts(cfg map[string]string) (map[string]string, map[string]string) {
+	driverCfg := make(map[string]string)
+	modeCfg := make(map[string]string)
+	for k, v := range cfg {
+		switch k {
+		case modeKey, maxBufferSizeKey:
+			modeCfg[k] = v
+		default:
+			driverCfg[k] = v
+		}
+	}
+	return driverCfg, modeCfg
+}
+
+// WithMode returns l delivering its messages in the mode configured by the
+// options cfg, as split by SplitModeOpts: l itself in blocking mode, or l
+// behind a RingLogger in non-blocking mode.
+func WithMode(l Logger, cfg map[string]string) (Logger, error) {
+	mode, maxSize, err := parseModeOpts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if mode == ModeBlocking {
+		return l, nil
+	}
+	return NewRingLogger(l, maxSize), nil
+}
+
+func parseModeOpts(cfg map[string]string) (string, int64, error) {
+	mode := cfg[modeKey]
+	switch mode {
+	case "":
+		mode = ModeBlocking
+	case ModeBlocking, ModeNonBlocking:
+	default:
+		return "", 0, fmt.Errorf("invalid value for log opt '%s': %q, must be %q or %q", modeKey, mode, ModeBlocking, ModeNonBlocking)
+	}
+	maxSize := int64(defaultMaxBufferSize)
+	if s, ok := cfg[maxBufferSizeKey]; ok {
+		if mode != ModeNonBlocking {
+			return "", 0, fmt.Errorf("log opt '%s' is only valid with '%s=%s'", maxBufferSizeKey, modeKey, ModeNonBlocking)
+		}
+		size, err := units.FromHumanSize(s)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid value for log opt '%s': %v", maxBufferSizeKey, err)
+		}
+		if size <= 0 {
+			return "", 0, fmt.Errorf("invalid value for log opt '%s': %q, must be positive", maxBufferSizeKey, s)
+		}
+		maxSize = size
+	}
+	return mode, maxSize, nil
+}
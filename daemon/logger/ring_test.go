@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+)
+
+// blockingLogger keeps the lines it logs, once released.
+type blockingLogger struct {
+	release chan struct{}
+	lines   []string
+	closed  bool
+}
+
+func (l *blockingLogger) Log(m *Message) error {
+	<-l.release
+	l.lines = append(l.lines, string(m.Line))
+	return nil
+}
+
+func (l *blockingLogger) Close() error {
+	l.closed = true
+	return nil
+}
+
+func (l *blockingLogger) Name() string { return "blocking" }
+
+func (l *blockingLogger) GetReader() (io.Reader, error) {
+	return nil, errors.New("not used in the test")
+}
+
+func TestRingLoggerDropsWhenFull(t *testing.T) {
+	l := &blockingLogger{release: make(chan struct{})}
+	r := NewRingLogger(l, 10)
+
+	// the first message is taken by the blocked driver, the next two fill
+	// the buffer
+	line := []byte("12345")
+	for i := 0; i < 3; i++ {
+		if err := r.Log(&Message{Line: line, Source: "stdout"}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			// wait for the driver to take the first message
+			for {
+				r.mu.Lock()
+				n := len(r.queue)
+				r.mu.Unlock()
+				if n == 0 {
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+	}
+	// the line is reused by the caller, like the copier does
+	copy(line, "abcde")
+	if err := r.Log(&Message{Line: []byte("dropped"), Source: "stdout"}); err != nil {
+		t.Fatal(err)
+	}
+	if dropped, droppedBytes := r.Dropped(); dropped != 1 || droppedBytes != 7 {
+		t.Fatalf("Expected 1 message of 7 bytes dropped, got %d messages of %d bytes", dropped, droppedBytes)
+	}
+
+	close(l.release)
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.lines) != 3 || l.lines[1] != "12345" || l.lines[2] != "12345" {
+		t.Fatalf("Expected the buffered messages to be delivered, got %v", l.lines)
+	}
+	if !l.closed {
+		t.Fatal("Expected the driver to be closed")
+	}
+	if err := r.Log(&Message{Line: []byte("closed")}); err != errRingClosed {
+		t.Fatalf("Expected an error logging after close, got %v", err)
+	}
+}
+
+func TestRingLoggerLargeMessage(t *testing.T) {
+	l := &blockingLogger{release: make(chan struct{})}
+	close(l.release)
+	r := NewRingLogger(l, 4)
+	if err := r.Log(&Message{Line: []byte("larger than the buffer")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if dropped, _ := r.Dropped(); dropped != 0 || len(l.lines) != 1 {
+		t.Fatalf("Expected the message larger than the empty buffer to be delivered, got %v and %d dropped", l.lines, dropped)
+	}
+}
+
+func TestWithMode(t *testing.T) {
+	l := &blockingLogger{}
+	if ml, err := WithMode(l, map[string]string{}); err != nil || ml != l {
+		t.Fatalf("Expected the driver itself by default, got %v, %v", ml, err)
+	}
+	if ml, err := WithMode(l, map[string]string{modeKey: ModeBlocking}); err != nil || ml != l {
+		t.Fatalf("Expected the driver itself in blocking mode, got %v, %v", ml, err)
+	}
+	ml, err := WithMode(l, map[string]string{modeKey: ModeNonBlocking, maxBufferSizeKey: "4m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := ml.(*RingLogger)
+	if !ok || r.maxSize != 4*1000*1000 {
+		t.Fatalf("Expected a ring logger of 4MB in non-blocking mode, got %#v", ml)
+	}
+	r.Close()
+}
+
+func TestValidateModeOpts(t *testing.T) {
+	for _, cfg := range []map[string]string{
+		{modeKey: ModeBlocking},
+		{modeKey: ModeNonBlocking},
+		{modeKey: ModeNonBlocking, maxBufferSizeKey: "512k"},
+	} {
+		if err := ValidateLogOpts("test-mode", cfg); err != nil {
+			t.Fatalf("Expected %v to be valid: %v", cfg, err)
+		}
+	}
+	for _, cfg := range []map[string]string{
+		{modeKey: "maybe"},
+		{maxBufferSizeKey: "1m"},
+		{modeKey: ModeBlocking, maxBufferSizeKey: "1m"},
+		{modeKey: ModeNonBlocking, maxBufferSizeKey: "lots"},
+		{modeKey: ModeNonBlocking, maxBufferSizeKey: "0"},
+		{modeKey: ModeNonBlocking, "foo": "bar"},
+	} {
+		if err := ValidateLogOpts("test-mode", cfg); err == nil {
+			t.Fatalf("Expected %v to be invalid", cfg)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// +build linux
+
+package syslog
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const dialTimeout = 10 * time.Second
+
+// localSockets are the sockets the local syslog daemon listens on, as
+// searched by the log/syslog package.
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// conn is a connection to a syslog server, reconnecting once when a write
+// fails.
+type conn struct {
+	network   string // empty for the local syslog daemon
+	address   string
+	tlsConfig *tls.Config
+
+	// local is set when sending to a unix socket, where the messages
+	// don't carry a hostname.
+	local bool
+	// octetCounting frames the messages on streams by prefixing their
+	// length instead of terminating them with a newline (RFC 6587).
+	octetCounting bool
+
+	mu       sync.Mutex
+	c        net.Conn
+	datagram bool // whether c sends each write as a datagram
+}
+
+func dial(network, address string, tlsConfig *tls.Config) (*conn, error) {
+	c := &conn{
+		network:   network,
+		address:   address,
+		tlsConfig: tlsConfig,
+		local:     network == "" || network == "unix" || network == "unixgram",
+	}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *conn) connect() error {
+	var err error
+	switch c.network {
+	case "":
+		for _, path := range localSockets {
+			for _, network := range []string{"unixgram", "unix"} {
+				if c.c, err = net.Dial(network, path); err == nil {
+					c.datagram = network == "unixgram"
+					return nil
+				}
+			}
+		}
+		return errors.New("syslog: unix syslog delivery error")
+	case "tcp+tls":
+		c.c, err = tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", c.address, c.tlsConfig)
+	default:
+		c.c, err = net.DialTimeout(c.network, c.address, dialTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("syslog: cannot connect to %s: %v", c.address, err)
+	}
+	c.datagram = c.network == "udp" || c.network == "unixgram"
+	return nil
+}
+
+// write sends a formatted message, framed for the transport of the
+// connection.
+func (c *conn) write(msg string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	for retry := 0; retry < 2; retry++ {
+		if c.c == nil {
+			if err = c.connect(); err != nil {
+				continue
+			}
+		}
+		if _, err = c.c.Write(c.frame(msg)); err == nil {
+			return nil
+		}
+		c.c.Close()
+		c.c = nil
+	}
+	return err
+}
+
+// frame returns msg as sent on the connection: datagrams hold a single
+// message, messages on streams are terminated by a newline or prefixed with
+// their length.
+func (c *conn) frame(msg string) []byte {
+	if c.datagram {
+		return []byte(msg)
+	}
+	if c.octetCounting && !c.local {
+		return []byte(strconv.Itoa(len(msg)) + " " + msg)
+	}
+	return []byte(msg + "\n")
+}
+
+func (c *conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.c == nil {
+		return nil
+	}
+	err := c.c.Close()
+	c.c = nil
+	return err
+}
+
+// parseTLSConfig builds the TLS configuration of a tcp+tls connection from
+// the log opts, verifying the server against the system roots unless a CA
+// certificate is given.
+func parseTLSConfig(cfg map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS10,
+	}
+	if s := cfg[tlsSkipVerifyKey]; s != "" {
+		skip, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for log opt '%s': %v", tlsSkipVerifyKey, err)
+		}
+		tlsConfig.InsecureSkipVerify = skip
+	}
+	if ca := cfg[tlsCACertKey]; ca != "" {
+		certPool := x509.NewCertPool()
+		file, err := ioutil.ReadFile(ca)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't read CA certificate: %v", err)
+		}
+		if !certPool.AppendCertsFromPEM(file) {
+			return nil, fmt.Errorf("Couldn't parse CA certificate %s", ca)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	if cert, key := cfg[tlsCertKey], cfg[tlsKeyKey]; cert != "" || key != "" {
+		certificate, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't load X509 key pair: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
@@ -1,51 +1,158 @@
 // +build linux
 
+// Package syslog provides the log driver for forwarding container logs to
+// the local syslog daemon or to a remote syslog server.
 package syslog
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
-	"log/syslog"
+	"net"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
 )
 
-const name = "syslog"
+const (
+	name = "syslog"
+
+	addressKey       = "syslog-address"
+	facilityKey      = "syslog-facility"
+	formatKey        = "syslog-format"
+	tlsCACertKey     = "syslog-tls-ca-cert"
+	tlsCertKey       = "syslog-tls-cert"
+	tlsKeyKey        = "syslog-tls-key"
+	tlsSkipVerifyKey = "syslog-tls-skip-verify"
+
+	severityErr  = 3
+	severityInfo = 6
+
+	formatRFC3164 = "rfc3164"
+	formatRFC5424 = "rfc5424"
+)
+
+var facilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
 
 type Syslog struct {
-	writer *syslog.Writer
+	conn     *conn
+	facility int
+	format   string
+	tag      string
+	hostname string
+	pid      int
 }
 
 func init() {
 	if err := logger.RegisterLogDriver(name, New); err != nil {
 		logrus.Fatal(err)
 	}
+	if err := logger.RegisterLogOptValidator(name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
 }
 
+// New creates a syslog logger for the container of ctx, logging to the local
+// syslog daemon unless syslog-address is set.
 func New(ctx logger.Context) (logger.Logger, error) {
-	tag := ctx.ContainerID[:12]
-	log, err := syslog.New(syslog.LOG_DAEMON, fmt.Sprintf("%s/%s", path.Base(os.Args[0]), tag))
+	network, address, err := parseAddress(ctx.Config[addressKey])
+	if err != nil {
+		return nil, err
+	}
+	facility, err := parseFacility(ctx.Config[facilityKey])
+	if err != nil {
+		return nil, err
+	}
+	format, err := parseFormat(ctx.Config[formatKey])
 	if err != nil {
 		return nil, err
 	}
+	hostname, err := ctx.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	tag, err := loggerutils.ParseLogTag(ctx, loggerutils.DefaultTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tls.Config
+	if network == "tcp+tls" {
+		if tlsConfig, err = parseTLSConfig(ctx.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := dial(network, address, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	// RFC 5424 messages are framed by their length on streams, RFC 3164
+	// ones by a newline
+	c.octetCounting = format == formatRFC5424
 
 	return &Syslog{
-		writer: log,
+		conn:     c,
+		facility: facility,
+		format:   format,
+		tag:      fmt.Sprintf("%s/%s", path.Base(os.Args[0]), tag),
+		hostname: hostname,
+		pid:      os.Getpid(),
 	}, nil
 }
 
 func (s *Syslog) Log(msg *logger.Message) error {
+	severity := severityInfo
 	if msg.Source == "stderr" {
-		return s.writer.Err(string(msg.Line))
+		severity = severityErr
+	}
+	return s.conn.write(s.formatMessage(severity, msg.Timestamp, string(msg.Line)))
+}
+
+// formatMessage formats a message in the format of the logger. Messages to
+// the local syslog daemon don't have a hostname in the RFC 3164 format.
+func (s *Syslog) formatMessage(severity int, timestamp time.Time, line string) string {
+	priority := s.facility*8 + severity
+	if s.format == formatRFC5424 {
+		// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+		return fmt.Sprintf("<%d>1 %s %s %s %d - - %s",
+			priority, timestamp.Format("2006-01-02T15:04:05.000000Z07:00"), s.hostname, s.tag, s.pid, line)
+	}
+	if s.conn.local {
+		return fmt.Sprintf("<%d>%s %s[%d]: %s", priority, timestamp.Format(time.Stamp), s.tag, s.pid, line)
 	}
-	return s.writer.Info(string(msg.Line))
+	return fmt.Sprintf("<%d>%s %s %s[%d]: %s", priority, timestamp.Format(time.Stamp), s.hostname, s.tag, s.pid, line)
 }
 
 func (s *Syslog) Close() error {
-	return s.writer.Close()
+	return s.conn.Close()
 }
 
 func (s *Syslog) Name() string {
@@ -55,3 +162,103 @@ func (s *Syslog) Name() string {
 func (s *Syslog) GetReader() (io.Reader, error) {
 	return nil, logger.ReadLogsNotSupported
 }
+
+// ValidateLogOpt checks the options of the syslog log driver.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case addressKey, facilityKey, formatKey, tlsCACertKey, tlsCertKey, tlsKeyKey, tlsSkipVerifyKey, loggerutils.TagKey:
+		default:
+			return fmt.Errorf("unknown log opt '%s' for syslog log driver", key)
+		}
+	}
+	if err := loggerutils.ValidateLogTag(cfg); err != nil {
+		return err
+	}
+	network, _, err := parseAddress(cfg[addressKey])
+	if err != nil {
+		return err
+	}
+	if _, err := parseFacility(cfg[facilityKey]); err != nil {
+		return err
+	}
+	if _, err := parseFormat(cfg[formatKey]); err != nil {
+		return err
+	}
+	if network != "tcp+tls" {
+		for _, key := range []string{tlsCACertKey, tlsCertKey, tlsKeyKey, tlsSkipVerifyKey} {
+			if _, ok := cfg[key]; ok {
+				return fmt.Errorf("log opt '%s' requires a tcp+tls:// %s", key, addressKey)
+			}
+		}
+		return nil
+	}
+	if _, ok := cfg[tlsSkipVerifyKey]; ok {
+		if _, err := strconv.ParseBool(cfg[tlsSkipVerifyKey]); err != nil {
+			return fmt.Errorf("invalid value for log opt '%s': %v", tlsSkipVerifyKey, err)
+		}
+	}
+	if (cfg[tlsCertKey] == "") != (cfg[tlsKeyKey] == "") {
+		return fmt.Errorf("log opts '%s' and '%s' must be set together", tlsCertKey, tlsKeyKey)
+	}
+	return nil
+}
+
+// parseAddress parses the address of a syslog server:
+// udp|tcp|tcp+tls://host[:port] or unix|unixgram:///path. The empty address
+// is the local syslog daemon.
+func parseAddress(address string) (string, string, error) {
+	if address == "" {
+		return "", "", nil
+	}
+	parts := strings.SplitN(address, "://", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("syslog: invalid address %s, must be protocol://address", address)
+	}
+	network, addr := parts[0], parts[1]
+	switch network {
+	case "unix", "unixgram":
+		if addr == "" {
+			return "", "", fmt.Errorf("syslog: missing socket path in %s", address)
+		}
+		return network, addr, nil
+	case "udp", "tcp", "tcp+tls":
+	default:
+		return "", "", fmt.Errorf("syslog: unsupported protocol %s, must be udp, tcp, tcp+tls, unix or unixgram", network)
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		// RFC 5425 assigns a port of its own to syslog over TLS
+		port := "514"
+		if network == "tcp+tls" {
+			port = "6514"
+		}
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), port)
+	}
+	if host, _, err := net.SplitHostPort(addr); err != nil || host == "" {
+		return "", "", fmt.Errorf("syslog: invalid address %s", address)
+	}
+	return network, addr, nil
+}
+
+func parseFacility(facility string) (int, error) {
+	if facility == "" {
+		return facilities["daemon"], nil
+	}
+	if f, ok := facilities[facility]; ok {
+		return f, nil
+	}
+	if f, err := strconv.Atoi(facility); err == nil && f >= 0 && f <= 23 {
+		return f, nil
+	}
+	return 0, fmt.Errorf("syslog: invalid facility %s, must be a name like local0 or a number from 0 to 23", facility)
+}
+
+func parseFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return formatRFC3164, nil
+	case formatRFC3164, formatRFC5424:
+		return format, nil
+	}
+	return "", fmt.Errorf("syslog: unknown format %s, must be rfc3164 or rfc5424", format)
+}
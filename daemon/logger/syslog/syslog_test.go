@@ -0,0 +1,199 @@
+// +build linux
+
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+func TestParseAddress(t *testing.T) {
+	valid := map[string][2]string{
+		"":                       {"", ""},
+		"udp://10.0.0.1":         {"udp", "10.0.0.1:514"},
+		"tcp://syslog:1514":      {"tcp", "syslog:1514"},
+		"tcp+tls://syslog":       {"tcp+tls", "syslog:6514"},
+		"udp://[::1]":            {"udp", "[::1]:514"},
+		"unix:///dev/log":        {"unix", "/dev/log"},
+		"unixgram:///run/syslog": {"unixgram", "/run/syslog"},
+	}
+	for address, expected := range valid {
+		network, addr, err := parseAddress(address)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", address, err)
+		}
+		if network != expected[0] || addr != expected[1] {
+			t.Fatalf("Expected %v for %q, got %s %s", expected, address, network, addr)
+		}
+	}
+	for _, address := range []string{"syslog:514", "http://syslog", "udp://", "unix://", "tcp://:514"} {
+		if _, _, err := parseAddress(address); err == nil {
+			t.Fatalf("Expected an error for %q", address)
+		}
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	valid := []map[string]string{
+		{},
+		{addressKey: "udp://syslog", facilityKey: "local3", formatKey: "rfc5424"},
+		{facilityKey: "16"},
+		{addressKey: "tcp+tls://syslog", tlsCACertKey: "/ca.pem", tlsCertKey: "/cert.pem", tlsKeyKey: "/key.pem"},
+		{addressKey: "tcp+tls://syslog", tlsSkipVerifyKey: "true"},
+		{"tag": "{{.ImageName}}/{{.Name}}"},
+	}
+	for _, cfg := range valid {
+		if err := ValidateLogOpt(cfg); err != nil {
+			t.Fatalf("Unexpected error for %v: %v", cfg, err)
+		}
+	}
+	invalid := []map[string]string{
+		{"syslog-foo": "bar"},
+		{facilityKey: "local8"},
+		{facilityKey: "24"},
+		{formatKey: "json"},
+		{addressKey: "tcp://syslog", tlsCACertKey: "/ca.pem"},
+		{addressKey: "tcp+tls://syslog", tlsCertKey: "/cert.pem"},
+		{addressKey: "tcp+tls://syslog", tlsSkipVerifyKey: "maybe"},
+		{"tag": "{{.Foo}}"},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("Expected an error for %v", cfg)
+		}
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	timestamp := time.Date(2015, time.June, 1, 9, 5, 3, 4000, time.UTC)
+	s := &Syslog{
+		conn:     &conn{},
+		facility: facilities["local0"],
+		format:   formatRFC3164,
+		tag:      "docker/a7317399f3f8",
+		hostname: "host",
+		pid:      42,
+	}
+	expected := "<134>Jun  1 09:05:03 host docker/a7317399f3f8[42]: hello"
+	if msg := s.formatMessage(severityInfo, timestamp, "hello"); msg != expected {
+		t.Fatalf("Expected %q, got %q", expected, msg)
+	}
+
+	s.conn.local = true
+	expected = "<131>Jun  1 09:05:03 docker/a7317399f3f8[42]: oops"
+	if msg := s.formatMessage(severityErr, timestamp, "oops"); msg != expected {
+		t.Fatalf("Expected %q, got %q", expected, msg)
+	}
+
+	s.format = formatRFC5424
+	expected = "<134>1 2015-06-01T09:05:03.000004Z host docker/a7317399f3f8 42 - - hello"
+	if msg := s.formatMessage(severityInfo, timestamp, "hello"); msg != expected {
+		t.Fatalf("Expected %q, got %q", expected, msg)
+	}
+}
+
+var testContext = logger.Context{
+	ContainerID:   "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657",
+	ContainerName: "/test",
+}
+
+func TestSyslogUDP(t *testing.T) {
+	c, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := testContext
+	ctx.Config = map[string]string{addressKey: "udp://" + c.LocalAddr().String(), facilityKey: "local0"}
+	l, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if err := l.Log(&logger.Message{Line: []byte("oops"), Source: "stderr", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	n, _, err := c.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<131>") || !strings.HasSuffix(msg, "/a7317399f3f8["+strconv.Itoa(os.Getpid())+"]: oops") {
+		t.Fatalf("Unexpected message %q", msg)
+	}
+}
+
+func TestSyslogTCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		var messages []string
+		r := bufio.NewReader(c)
+		for {
+			prefix, err := r.ReadString(' ')
+			if err != nil {
+				break
+			}
+			length, err := strconv.Atoi(strings.TrimSuffix(prefix, " "))
+			if err != nil {
+				break
+			}
+			buf := make([]byte, length)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				break
+			}
+			messages = append(messages, string(buf))
+		}
+		received <- messages
+	}()
+
+	ctx := testContext
+	ctx.Config = map[string]string{addressKey: "tcp://" + ln.Addr().String(), formatKey: "rfc5424", "tag": "{{.Name}}"}
+	l, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"line1", "line 2"} {
+		if err := l.Log(&logger.Message{Line: []byte(line), Source: "stdout", Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	select {
+	case messages := <-received:
+		if len(messages) != 2 {
+			t.Fatalf("Expected 2 messages, got %d: %q", len(messages), messages)
+		}
+		for i, line := range []string{"line1", "line 2"} {
+			if !strings.HasPrefix(messages[i], "<30>1 ") || !strings.HasSuffix(messages[i], "/test "+strconv.Itoa(os.Getpid())+" - - "+line) {
+				t.Fatalf("Unexpected message %q", messages[i])
+			}
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the messages")
+	}
+}
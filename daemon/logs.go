@@ -1,28 +1,25 @@
 package daemon
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
-	"os"
 	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/Sirupsen/logrus"
-	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/pkg/jsonlog"
 	"github.com/docker/docker/pkg/stdcopy"
-	"github.com/docker/docker/pkg/tailfile"
 	"github.com/docker/docker/pkg/timeutils"
 )
 
 type ContainerLogsConfig struct {
 	Follow, Timestamps   bool
+	Details              bool
 	Tail                 string
-	Since                time.Time
+	Since, Until         time.Time
 	UseStdout, UseStderr bool
 	OutStream            io.Writer
 }
@@ -58,14 +55,17 @@ func (daemon *Daemon) ContainerLogs(name string, config *ContainerLogsConfig) er
 		errStream = outStream
 	}
 
-	if container.LogDriverType() != jsonfilelog.Name {
-		return fmt.Errorf("\"logs\" endpoint is supported only for \"json-file\" logging driver")
+	logReader, err := container.getLogReader()
+	if err != nil {
+		return err
 	}
-	logDriver, err := container.getLogger()
-	cLog, err := logDriver.GetReader()
+	defer logReader.Close()
+	cLog, err := logReader.GetReader()
 	if err != nil {
 		logrus.Errorf("Error reading logs: %s", err)
 	} else {
+		defer cLog.(io.Closer).Close()
+
 		// json-file driver
 		if config.Tail != "all" {
 			var err error
@@ -78,16 +78,11 @@ func (daemon *Daemon) ContainerLogs(name string, config *ContainerLogsConfig) er
 
 		if lines != 0 {
 			if lines > 0 {
-				f := cLog.(*os.File)
-				ls, err := tailfile.TailFile(f, lines)
+				// read the last lines across the rotated log files
+				cLog, err = logReader.Tail(lines)
 				if err != nil {
 					return err
 				}
-				tmp := bytes.NewBuffer([]byte{})
-				for _, l := range ls {
-					fmt.Fprintf(tmp, "%s\n", l)
-				}
-				cLog = tmp
 			}
 
 			dec := json.NewDecoder(cLog)
@@ -100,10 +95,16 @@ func (daemon *Daemon) ContainerLogs(name string, config *ContainerLogsConfig) er
 					logrus.Errorf("Error streaming logs: %s", err)
 					break
 				}
-				logLine := l.Log
 				if !config.Since.IsZero() && l.Created.Before(config.Since) {
 					continue
 				}
+				if !config.Until.IsZero() && l.Created.After(config.Until) {
+					continue
+				}
+				if config.Details {
+					l.Log = jsonlog.FormatDetails(l.Attrs) + " " + l.Log
+				}
+				logLine := l.Log
 				if config.Timestamps {
 					// format can be "" or time format, so here can't be error
 					logLine, _ = l.Format(format)
@@ -118,9 +119,21 @@ func (daemon *Daemon) ContainerLogs(name string, config *ContainerLogsConfig) er
 		}
 	}
 
-	if config.Follow && container.IsRunning() {
+	// there is nothing to follow once until is past
+	if config.Follow && container.IsRunning() && (config.Until.IsZero() || config.Until.After(time.Now())) {
 		chErr := make(chan error)
-		var stdoutPipe, stderrPipe io.ReadCloser
+		var (
+			stdoutPipe, stderrPipe io.ReadCloser
+			streams                int
+		)
+
+		// the logs streamed from the container don't carry their
+		// attributes, which are the same for all of them
+		var attrs map[string]string
+		if config.Details {
+			ctx := container.getLogContext(container.getLogConfig())
+			attrs = ctx.ExtraAttributes(nil)
+		}
 
 		// write an empty chunk of data (this is to ensure that the
 		// HTTP Response is sent immediatly, even if the container has
@@ -128,30 +141,44 @@ func (daemon *Daemon) ContainerLogs(name string, config *ContainerLogsConfig) er
 		outStream.Write(nil)
 
 		if config.UseStdout {
+			streams++
 			stdoutPipe = container.StdoutLogPipe()
 			go func() {
 				logrus.Debug("logs: stdout stream begin")
-				chErr <- jsonlog.WriteLog(stdoutPipe, outStream, format, config.Since)
+				chErr <- jsonlog.WriteLog(stdoutPipe, outStream, format, config.Since, config.Until, attrs)
 				logrus.Debug("logs: stdout stream end")
 			}()
 		}
 		if config.UseStderr {
+			streams++
 			stderrPipe = container.StderrLogPipe()
 			go func() {
 				logrus.Debug("logs: stderr stream begin")
-				chErr <- jsonlog.WriteLog(stderrPipe, errStream, format, config.Since)
+				chErr <- jsonlog.WriteLog(stderrPipe, errStream, format, config.Since, config.Until, attrs)
 				logrus.Debug("logs: stderr stream end")
 			}()
 		}
 
-		err = <-chErr
+		var untilC <-chan time.Time
+		if !config.Until.IsZero() {
+			untilC = time.After(config.Until.Sub(time.Now()))
+		}
+		select {
+		case err = <-chErr:
+			streams--
+		case <-untilC:
+			// stop following when until is reached, even if the
+			// container is silent
+		}
 		if stdoutPipe != nil {
 			stdoutPipe.Close()
 		}
 		if stderrPipe != nil {
 			stderrPipe.Close()
 		}
-		<-chErr // wait for 2nd goroutine to exit, otherwise bad things will happen
+		for ; streams > 0; streams-- {
+			<-chErr // wait for the other goroutines to exit, otherwise bad things will happen
+		}
 
 		if err != nil && err != io.EOF && err != io.ErrClosedPipe {
 			if e, ok := err.(*net.OpError); ok && e.Err != syscall.EPIPE {
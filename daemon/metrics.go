@@ -0,0 +1,27 @@
+package daemon
+
+import (
+	"github.com/docker/docker/pkg/metrics"
+)
+
+// registerMetrics exposes the metrics of the daemon.
+func (daemon *Daemon) registerMetrics() error {
+	return metrics.Register(metrics.NewGaugeFunc("engine_daemon_container_states_containers",
+		"The number of containers, by state.", "state", daemon.containerStates))
+}
+
+// containerStates returns the number of containers in each state.
+func (daemon *Daemon) containerStates() map[string]float64 {
+	states := map[string]float64{"running": 0, "paused": 0, "stopped": 0}
+	for _, container := range daemon.List() {
+		switch {
+		case container.IsPaused():
+			states["paused"]++
+		case container.IsRunning():
+			states["running"]++
+		default:
+			states["stopped"]++
+		}
+	}
+	return states
+}
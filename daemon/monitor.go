@@ -50,6 +50,10 @@ type containerMonitor struct {
 
 	// lastStartTime is the time which the monitor last exec'd the container's process
 	lastStartTime time.Time
+
+	// restore is true until the monitor re-attached to the process of a
+	// container left running by a previous daemon
+	restore bool
 }
 
 // newContainerMonitor returns an initialized containerMonitor for the provided container
@@ -115,13 +119,22 @@ func (m *containerMonitor) Start() error {
 			defer m.container.Unlock()
 		}
 		m.Close()
+		// a container which failed to start is removed by ContainerStart
+		if afterRun && m.container.hostConfig.AutoRemove && m.container.daemon != nil {
+			// the container is removed once it's unlocked
+			go m.container.daemon.autoRemove(m.container)
+		}
 	}()
 
-	// reset the restart count
-	m.container.RestartCount = -1
+	// reset the restart count, unless the container is restored
+	if !m.restore {
+		m.container.RestartCount = -1
+	}
 
 	for {
-		m.container.RestartCount++
+		if !m.restore {
+			m.container.RestartCount++
+		}
 
 		if err := m.container.startLogging(); err != nil {
 			m.resetContainer(false)
@@ -131,14 +144,21 @@ func (m *containerMonitor) Start() error {
 
 		pipes := execdriver.NewPipes(m.container.stdin, m.container.stdout, m.container.stderr, m.container.Config.OpenStdin)
 
-		m.container.LogEvent("start")
+		if m.restore {
+			m.lastStartTime = m.container.StartedAt
+
+			exitStatus, err = m.container.daemon.Restore(m.container, pipes, m.restoreCallback)
+		} else {
+			m.container.LogEvent("start")
 
-		m.lastStartTime = time.Now()
+			m.lastStartTime = time.Now()
 
-		if exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback); err != nil {
-			// if we receive an internal error from the initial start of a container then lets
-			// return it instead of entering the restart loop
-			if m.container.RestartCount == 0 {
+			exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback)
+		}
+		if err != nil {
+			// if we receive an internal error from the initial start or the restore of a
+			// container then lets return it instead of entering the restart loop
+			if m.container.RestartCount == 0 || m.restore {
 				m.container.ExitCode = -1
 				m.resetContainer(false)
 
@@ -150,15 +170,16 @@ func (m *containerMonitor) Start() error {
 
 		// here container.Lock is already lost
 		afterRun = true
+		m.restore = false
 
 		m.resetMonitor(err == nil && exitStatus.ExitCode == 0)
 
 		if m.shouldRestart(exitStatus.ExitCode) {
-			m.container.SetRestarting(&exitStatus)
+			m.container.SetRestarting(&exitStatus, time.Now().UTC().Add(m.restartDelay()))
 			if exitStatus.OOMKilled {
 				m.container.LogEvent("oom")
 			}
-			m.container.LogEvent("die")
+			m.container.logDieEvent(exitStatus.ExitCode)
 			m.resetContainer(true)
 
 			// sleep with a small time increment between each restart to help avoid issues cased by quickly
@@ -175,7 +196,7 @@ func (m *containerMonitor) Start() error {
 		if exitStatus.OOMKilled {
 			m.container.LogEvent("oom")
 		}
-		m.container.LogEvent("die")
+		m.container.logDieEvent(exitStatus.ExitCode)
 		m.resetContainer(true)
 		return err
 	}
@@ -191,8 +212,12 @@ func (m *containerMonitor) resetMonitor(successful bool) {
 		m.timeIncrement = defaultTimeIncrement
 	} else {
 		// otherwise we need to increment the amount of time we wait before restarting
-		// the process.  We will build up by multiplying the increment by 2
+		// the process.  We will build up by multiplying the increment by 2, up
+		// to the maximum delay of the daemon
 		m.timeIncrement *= 2
+		if max := m.maxRestartDelay(); max > 0 && m.restartDelay() > max {
+			m.timeIncrement = int(max / time.Millisecond)
+		}
 	}
 
 	// the container exited successfully so we need to reset the failure counter
@@ -203,11 +228,25 @@ func (m *containerMonitor) resetMonitor(successful bool) {
 	}
 }
 
+// restartDelay returns the time to wait before restarting the container.
+func (m *containerMonitor) restartDelay() time.Duration {
+	return time.Duration(m.timeIncrement) * time.Millisecond
+}
+
+// maxRestartDelay returns the maximum time to wait before restarting the
+// container, or 0 without a maximum.
+func (m *containerMonitor) maxRestartDelay() time.Duration {
+	if m.container.daemon == nil || m.container.daemon.config == nil {
+		return 0
+	}
+	return m.container.daemon.config.RestartMaxDelay
+}
+
 // waitForNextRestart waits with the default time increment to restart the container unless
 // a user or docker asks for the container to be stopped
 func (m *containerMonitor) waitForNextRestart() {
 	select {
-	case <-time.After(time.Duration(m.timeIncrement) * time.Millisecond):
+	case <-time.After(m.restartDelay()):
 	case <-m.stopChan:
 	}
 }
@@ -262,11 +301,33 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 		close(m.startSignal)
 	}
 
+	// the container is locked by Start until the process has started
+	m.container.Lock()
+	m.container.initHealthMonitor()
+	m.container.Unlock()
+
 	if err := m.container.ToDisk(); err != nil {
 		logrus.Debugf("%s", err)
 	}
 }
 
+// restoreCallback ensures that the container's state is up to date after the
+// execution driver re-attached to the process of a restored container
+func (m *containerMonitor) restoreCallback(processConfig *execdriver.ProcessConfig, pid int) {
+	m.container.Pid = pid
+
+	select {
+	case <-m.startSignal:
+	default:
+		close(m.startSignal)
+	}
+
+	// the container is locked by restore until the process is re-attached
+	m.container.Lock()
+	m.container.initHealthMonitor()
+	m.container.Unlock()
+}
+
 // resetContainer resets the container's IO and ensures that the command is able to be executed again
 // by copying the data into a new struct
 // if lock is true, then container locked during reset
@@ -277,6 +338,8 @@ func (m *containerMonitor) resetContainer(lock bool) {
 		defer container.Unlock()
 	}
 
+	container.stopHealthMonitor()
+
 	if container.Config.OpenStdin {
 		if err := container.stdin.Close(); err != nil {
 			logrus.Errorf("%s: Error close stdin: %s", container.ID, err)
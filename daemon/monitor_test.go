@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/runconfig"
+)
+
+func TestContainerMonitorRestartDelay(t *testing.T) {
+	container := &Container{
+		State:  NewState(),
+		daemon: &Daemon{config: &Config{CommonConfig: CommonConfig{RestartMaxDelay: time.Second}}},
+	}
+	m := newContainerMonitor(container, runconfig.RestartPolicy{Name: "always"})
+
+	expected := defaultTimeIncrement * time.Millisecond
+	for i := 0; i < 10; i++ {
+		m.lastStartTime = time.Now()
+		m.resetMonitor(false)
+		if expected *= 2; expected > time.Second {
+			expected = time.Second
+		}
+		if delay := m.restartDelay(); delay != expected {
+			t.Fatalf("Expected a delay of %s after %d failures, got %s", expected, i+1, delay)
+		}
+	}
+
+	// a container running for long enough restarts at once again
+	m.lastStartTime = time.Now().Add(-time.Minute)
+	m.resetMonitor(false)
+	if delay := m.restartDelay(); delay != defaultTimeIncrement*time.Millisecond {
+		t.Fatalf("Expected the delay to be reset, got %s", delay)
+	}
+}
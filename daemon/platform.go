@@ -0,0 +1,30 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/platform"
+	"github.com/docker/docker/pkg/stringid"
+)
+
+// checkPlatform returns an error if the image of the container is of a
+// platform the host neither runs natively nor emulates with binfmt_misc,
+// unless the container skips the check.
+func (container *Container) checkPlatform() error {
+	if container.ImageID == "" {
+		return nil
+	}
+	img, err := container.GetImage()
+	if err != nil {
+		return err
+	}
+	if err := platform.Check(img.OS, img.Architecture); err != nil {
+		if container.hostConfig != nil && container.hostConfig.SkipPlatformCheck {
+			logrus.Warnf("Starting container %s anyway: image %s: %v", container.ID, stringid.TruncateID(img.ID), err)
+			return nil
+		}
+		return fmt.Errorf("Cannot start container %s: image %s: %v, use --skip-platform-check to start it anyway", container.ID, stringid.TruncateID(img.ID), err)
+	}
+	return nil
+}
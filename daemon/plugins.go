@@ -0,0 +1,352 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/plugins"
+	"github.com/docker/libtrust"
+)
+
+var validPluginName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// pluginManifest is the payload of the signed manifest of a plugin.
+type pluginManifest struct {
+	Name       string
+	Implements []string // the extension points of the plugin, such as VolumeDriver
+	Address    string   // the address the plugin listens on, such as unix:///run/plugin.sock
+	Privileges pluginPrivileges
+}
+
+// pluginPrivileges are the privileges on the host a plugin requires.
+type pluginPrivileges struct {
+	Network      string   // "host" if the plugin uses the network of the host
+	Devices      []string // the devices of the host the plugin accesses
+	Mounts       []string // the paths of the host the plugin mounts
+	Capabilities []string // the capabilities the plugin runs with
+}
+
+func (m *pluginManifest) validate() error {
+	if !validPluginName.MatchString(m.Name) {
+		return fmt.Errorf("Bad parameter: invalid plugin name %q, only %s are allowed", m.Name, validPluginName)
+	}
+	if len(m.Implements) == 0 {
+		return fmt.Errorf("Bad parameter: plugin %s implements no extension point", m.Name)
+	}
+	if u, err := url.Parse(m.Address); err != nil || u.Scheme == "" {
+		return fmt.Errorf("Bad parameter: invalid address %q of plugin %s", m.Address, m.Name)
+	}
+	if m.Privileges.Network != "" && m.Privileges.Network != "host" {
+		return fmt.Errorf("Bad parameter: invalid network %q of plugin %s, only host is allowed", m.Privileges.Network, m.Name)
+	}
+	for _, paths := range [][]string{m.Privileges.Devices, m.Privileges.Mounts} {
+		for _, p := range paths {
+			if !filepath.IsAbs(p) {
+				return fmt.Errorf("Bad parameter: the path %s of plugin %s is not absolute", p, m.Name)
+			}
+		}
+	}
+	for _, cap := range m.Privileges.Capabilities {
+		if execdriver.GetCapability(strings.ToUpper(cap)) == nil {
+			return fmt.Errorf("Bad parameter: unknown capability %q of plugin %s", cap, m.Name)
+		}
+	}
+	return nil
+}
+
+// privileges returns the privileges of the plugin, in the order they're
+// shown to the user.
+func (m *pluginManifest) privileges() []types.PluginPrivilege {
+	privileges := []types.PluginPrivilege{}
+	if m.Privileges.Network != "" {
+		privileges = append(privileges, types.PluginPrivilege{
+			Name:        "network",
+			Description: "access to the network of the host",
+			Value:       []string{m.Privileges.Network},
+		})
+	}
+	if len(m.Privileges.Devices) > 0 {
+		privileges = append(privileges, types.PluginPrivilege{
+			Name:        "devices",
+			Description: "access to the devices of the host",
+			Value:       m.Privileges.Devices,
+		})
+	}
+	if len(m.Privileges.Mounts) > 0 {
+		privileges = append(privileges, types.PluginPrivilege{
+			Name:        "mounts",
+			Description: "access to the paths of the host",
+			Value:       m.Privileges.Mounts,
+		})
+	}
+	if len(m.Privileges.Capabilities) > 0 {
+		privileges = append(privileges, types.PluginPrivilege{
+			Name:        "capabilities",
+			Description: "the Linux capabilities",
+			Value:       m.Privileges.Capabilities,
+		})
+	}
+	return privileges
+}
+
+func samePrivileges(a, b []types.PluginPrivilege) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || len(a[i].Value) != len(b[i].Value) {
+			return false
+		}
+		for j := range a[i].Value {
+			if a[i].Value[j] != b[i].Value[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pluginStore keeps the plugins installed in the daemon, a JSON file per
+// plugin in the directory root. The manifests of the plugins must be signed
+// by a publisher whose public key is in the directory trustDir.
+type pluginStore struct {
+	sync.Mutex
+	root     string
+	trustDir string
+}
+
+// newPluginStore registers the plugins installed in root, for the extension
+// points to find them.
+func newPluginStore(root, trustDir string) (*pluginStore, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+	s := &pluginStore{root: root, trustDir: trustDir}
+	installed, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range installed {
+		plugins.Register(p.Name, p.Address)
+	}
+	return s, nil
+}
+
+// trustedKeys returns the public keys of the trusted publishers by ID.
+func (s *pluginStore) trustedKeys() (map[string]libtrust.PublicKey, error) {
+	keys := make(map[string]libtrust.PublicKey)
+	fis, err := ioutil.ReadDir(s.trustDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return nil, err
+	}
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		key, err := libtrust.LoadPublicKeyFile(filepath.Join(s.trustDir, fi.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Error loading the trusted plugin publisher key %s: %v", fi.Name(), err)
+		}
+		keys[key.KeyID()] = key
+	}
+	return keys, nil
+}
+
+// verify checks the signature of the manifest of a plugin, returning the
+// manifest and the ID of the key of its publisher.
+func (s *pluginStore) verify(manifest []byte) (*pluginManifest, string, error) {
+	sig, err := libtrust.ParsePrettySignature(manifest, "signatures")
+	if err != nil {
+		return nil, "", fmt.Errorf("Bad parameter: error parsing the signature of the plugin manifest: %v", err)
+	}
+	keys, err := sig.Verify()
+	if err != nil {
+		return nil, "", fmt.Errorf("Bad parameter: error verifying the signature of the plugin manifest: %v", err)
+	}
+	payload, err := sig.Payload()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var m pluginManifest
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return nil, "", fmt.Errorf("Bad parameter: error decoding the plugin manifest: %v", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, "", err
+	}
+
+	trusted, err := s.trustedKeys()
+	if err != nil {
+		return nil, "", err
+	}
+	for _, key := range keys {
+		if _, ok := trusted[key.KeyID()]; ok {
+			return &m, key.KeyID(), nil
+		}
+	}
+	return nil, "", fmt.Errorf("Bad parameter: plugin %s is not signed by a trusted publisher", m.Name)
+}
+
+func (s *pluginStore) install(manifest []byte, granted []types.PluginPrivilege) (*types.Plugin, error) {
+	m, publisher, err := s.verify(manifest)
+	if err != nil {
+		return nil, err
+	}
+	privileges := m.privileges()
+	if !samePrivileges(privileges, granted) {
+		return nil, fmt.Errorf("Bad parameter: the privileges granted to plugin %s are not the ones it requires", m.Name)
+	}
+
+	p := &types.Plugin{
+		Name:        m.Name,
+		Implements:  m.Implements,
+		Address:     m.Address,
+		Publisher:   publisher,
+		Privileges:  privileges,
+		InstalledAt: time.Now().UTC(),
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	f, err := os.OpenFile(filepath.Join(s.root, m.Name+".json"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("Conflict: plugin %s is already installed", m.Name)
+		}
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	plugins.Register(p.Name, p.Address)
+	return p, nil
+}
+
+func (s *pluginStore) get(name string) (*types.Plugin, error) {
+	if !validPluginName.MatchString(name) {
+		return nil, fmt.Errorf("No such plugin: %s", name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(s.root, name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("No such plugin: %s", name)
+		}
+		return nil, err
+	}
+	var p types.Plugin
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("Error decoding plugin %s: %v", name, err)
+	}
+	return &p, nil
+}
+
+func (s *pluginStore) list() ([]*types.Plugin, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	fis, err := ioutil.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+	installed := []*types.Plugin{}
+	for _, fi := range fis {
+		name := strings.TrimSuffix(fi.Name(), ".json")
+		if name == fi.Name() {
+			continue
+		}
+		p, err := s.get(name)
+		if err != nil {
+			return nil, err
+		}
+		installed = append(installed, p)
+	}
+	return installed, nil
+}
+
+func (s *pluginStore) remove(name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := os.Remove(filepath.Join(s.root, name+".json")); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("No such plugin: %s", name)
+		}
+		return err
+	}
+	plugins.Unregister(name)
+	return nil
+}
+
+// PluginPrivileges returns the privileges on the host required by the plugin
+// of the signed manifest, to be granted to install it.
+func (daemon *Daemon) PluginPrivileges(manifest []byte) ([]types.PluginPrivilege, error) {
+	m, _, err := daemon.plugins.verify(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return m.privileges(), nil
+}
+
+// PluginInstall installs the plugin of the signed manifest, given the
+// privileges it requires.
+func (daemon *Daemon) PluginInstall(manifest []byte, granted []types.PluginPrivilege) (*types.Plugin, error) {
+	return daemon.plugins.install(manifest, granted)
+}
+
+// Plugins returns the plugins installed in the daemon.
+func (daemon *Daemon) Plugins() ([]*types.Plugin, error) {
+	return daemon.plugins.list()
+}
+
+// Plugin returns the installed plugin name, with the privileges granted to it.
+func (daemon *Daemon) Plugin(name string) (*types.Plugin, error) {
+	return daemon.plugins.get(name)
+}
+
+// PluginRm removes the installed plugin name, which must not be used by the
+// daemon nor by a volume.
+func (daemon *Daemon) PluginRm(name string) error {
+	if _, err := daemon.plugins.get(name); err != nil {
+		return err
+	}
+	for _, authz := range daemon.config.AuthZPlugins {
+		if authz == name {
+			return fmt.Errorf("Conflict: plugin %s is an authorization plugin of the daemon", name)
+		}
+	}
+	for _, policy := range daemon.config.ImagePolicyPlugins {
+		if policy == name {
+			return fmt.Errorf("Conflict: plugin %s is an image policy plugin of the daemon", name)
+		}
+	}
+	for _, v := range daemon.volumes.List() {
+		if v.DriverName() == name {
+			return fmt.Errorf("Conflict: plugin %s is the driver of volume %s", name, v.DisplayName())
+		}
+	}
+	return daemon.plugins.remove(name)
+}
@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/libtrust"
+)
+
+func signPluginManifest(t *testing.T, key libtrust.PrivateKey, manifest string) []byte {
+	sig, err := libtrust.NewJSONSignature([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sig.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := sig.PrettySignature("signatures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestPluginStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-plugins-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	publisher, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trustDir := filepath.Join(root, "trust")
+	if err := os.MkdirAll(trustDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := libtrust.SavePublicKey(filepath.Join(trustDir, "publisher.json"), publisher.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := newPluginStore(filepath.Join(root, "plugins"), trustDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := signPluginManifest(t, publisher, `{
+   "Name": "test-plugin",
+   "Implements": ["VolumeDriver"],
+   "Address": "unix:///run/test-plugin.sock",
+   "Privileges": {"Network": "host", "Mounts": ["/var/lib/test-plugin"], "Capabilities": ["SYS_ADMIN"]}
+}`)
+	expected := []types.PluginPrivilege{
+		{Name: "network", Value: []string{"host"}},
+		{Name: "mounts", Value: []string{"/var/lib/test-plugin"}},
+		{Name: "capabilities", Value: []string{"SYS_ADMIN"}},
+	}
+	m, keyID, err := s.verify(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != publisher.KeyID() {
+		t.Fatalf("Expected the key of the publisher %s, got %s", publisher.KeyID(), keyID)
+	}
+	if !samePrivileges(m.privileges(), expected) {
+		t.Fatalf("Expected the privileges %v, got %v", expected, m.privileges())
+	}
+
+	for _, c := range []struct {
+		manifest []byte
+		err      string
+	}{
+		{[]byte(`{"Name": "test-plugin"}`), "error parsing the signature"},
+		{signPluginManifest(t, other, `{"Name": "test-plugin", "Implements": ["VolumeDriver"], "Address": "tcp://localhost:8080"}`), "not signed by a trusted publisher"},
+		{signPluginManifest(t, publisher, `{"Name": "test/plugin", "Implements": ["VolumeDriver"], "Address": "tcp://localhost:8080"}`), "invalid plugin name"},
+		{signPluginManifest(t, publisher, `{"Name": "test-plugin", "Implements": ["VolumeDriver"], "Address": "localhost"}`), "invalid address"},
+		{signPluginManifest(t, publisher, `{"Name": "test-plugin", "Implements": ["VolumeDriver"], "Address": "tcp://localhost:8080", "Privileges": {"Devices": ["sda"]}}`), "is not absolute"},
+		{signPluginManifest(t, publisher, `{"Name": "test-plugin", "Implements": ["VolumeDriver"], "Address": "tcp://localhost:8080", "Privileges": {"Capabilities": ["CHPASS"]}}`), "unknown capability"},
+	} {
+		if _, _, err := s.verify(c.manifest); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("Expected an error containing %q for %s, got %v", c.err, c.manifest, err)
+		}
+	}
+
+	// the tampered manifest fails to verify
+	tampered := []byte(strings.Replace(string(manifest), "test-plugin.sock", "other-plugin.sock", 1))
+	if _, _, err := s.verify(tampered); err == nil || !strings.Contains(err.Error(), "error verifying the signature") {
+		t.Fatalf("Expected the tampered manifest to fail to verify, got %v", err)
+	}
+
+	if _, err := s.install(manifest, expected[:2]); err == nil || !strings.Contains(err.Error(), "not the ones it requires") {
+		t.Fatalf("Expected an error installing the plugin without all its privileges, got %v", err)
+	}
+	p, err := s.install(manifest, expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "test-plugin" || p.Publisher != publisher.KeyID() || len(p.Privileges) != 3 {
+		t.Fatalf("Unexpected installed plugin: %#v", p)
+	}
+	if _, err := s.install(manifest, expected); err == nil || !strings.Contains(err.Error(), "Conflict") {
+		t.Fatalf("Expected a conflict installing the plugin twice, got %v", err)
+	}
+
+	// the installed plugins are kept across restarts
+	if s, err = newPluginStore(filepath.Join(root, "plugins"), trustDir); err != nil {
+		t.Fatal(err)
+	}
+	installed, err := s.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 1 || installed[0].Address != "unix:///run/test-plugin.sock" || !samePrivileges(installed[0].Privileges, expected) {
+		t.Fatalf("Expected the installed plugin, got %v", installed)
+	}
+
+	if err := s.remove("test-plugin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.get("test-plugin"); err == nil || !strings.Contains(err.Error(), "No such plugin") {
+		t.Fatalf("Expected the plugin to be removed, got %v", err)
+	}
+}
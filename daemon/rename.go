@@ -2,8 +2,12 @@ package daemon
 
 import (
 	"fmt"
+	"strings"
 )
 
+// ContainerRename changes the name of the container. The links of the
+// container are kept, as they reference it by its ID: the aliases of the
+// linked containers, and their records in the hosts files, don't change.
 func (daemon *Daemon) ContainerRename(oldName, newName string) error {
 	if oldName == "" || newName == "" {
 		return fmt.Errorf("usage: docker rename OLD_NAME NEW_NAME")
@@ -18,6 +22,9 @@ func (daemon *Daemon) ContainerRename(oldName, newName string) error {
 
 	container.Lock()
 	defer container.Unlock()
+	if strings.TrimPrefix(newName, "/") == strings.TrimPrefix(oldName, "/") {
+		return fmt.Errorf("Renaming a container with the same name as its current name")
+	}
 	if newName, err = daemon.reserveName(container.ID, newName); err != nil {
 		return fmt.Errorf("Error when allocating new name: %s", err)
 	}
@@ -40,5 +47,8 @@ func (daemon *Daemon) ContainerRename(oldName, newName string) error {
 		return err
 	}
 
+	container.logEventWithAttributes("rename", map[string]string{
+		"oldName": strings.TrimPrefix(oldName, "/"),
+	})
 	return nil
 }
@@ -7,6 +7,9 @@ func (daemon *Daemon) ContainerRestart(name string, seconds int) error {
 	if err != nil {
 		return err
 	}
+	if container.IsPaused() {
+		return errContainerPaused(container, "restarting it")
+	}
 	if err := container.Restart(seconds); err != nil {
 		return fmt.Errorf("Cannot restart container %s: %s\n", name, err)
 	}
@@ -0,0 +1,240 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/mount"
+)
+
+// secretsMountPath is the directory of the secrets in the containers.
+const secretsMountPath = "/run/secrets"
+
+// secretStore keeps the secrets of the daemon, a file readable only by
+// root per secret in the directory root.
+type secretStore struct {
+	sync.Mutex
+	root string
+}
+
+func newSecretStore(root string) (*secretStore, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+	return &secretStore{root: root}, nil
+}
+
+func (s *secretStore) create(name string, data []byte) (*types.Secret, error) {
+	if _, err := opts.ValidateSecretName(name); err != nil {
+		return nil, fmt.Errorf("Bad parameter: %v", err)
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(s.root, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("Conflict: secret %s already exists", name)
+		}
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return s.stat(name)
+}
+
+func (s *secretStore) stat(name string) (*types.Secret, error) {
+	fi, err := os.Stat(filepath.Join(s.root, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("No such secret: %s", name)
+		}
+		return nil, err
+	}
+	return &types.Secret{Name: name, Size: fi.Size(), CreatedAt: fi.ModTime().UTC()}, nil
+}
+
+func (s *secretStore) get(name string) ([]byte, error) {
+	if _, err := opts.ValidateSecretName(name); err != nil {
+		return nil, fmt.Errorf("No such secret: %s", name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(s.root, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("No such secret: %s", name)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *secretStore) list() ([]*types.Secret, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	fis, err := ioutil.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+	secrets := []*types.Secret{}
+	for _, fi := range fis {
+		secrets = append(secrets, &types.Secret{Name: fi.Name(), Size: fi.Size(), CreatedAt: fi.ModTime().UTC()})
+	}
+	return secrets, nil
+}
+
+func (s *secretStore) remove(name string) error {
+	if _, err := opts.ValidateSecretName(name); err != nil {
+		return fmt.Errorf("No such secret: %s", name)
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if err := os.Remove(filepath.Join(s.root, name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("No such secret: %s", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// SecretCreate creates the secret name with the data, which can't be read
+// back through the API.
+func (daemon *Daemon) SecretCreate(name string, data []byte) (*types.Secret, error) {
+	return daemon.secrets.create(name, data)
+}
+
+// Secrets returns the secrets of the daemon, without their data.
+func (daemon *Daemon) Secrets() ([]*types.Secret, error) {
+	return daemon.secrets.list()
+}
+
+// SecretRm removes the secret name, which must not be given to a container.
+func (daemon *Daemon) SecretRm(name string) error {
+	if _, err := daemon.secrets.stat(name); err != nil {
+		return err
+	}
+	var containers []string
+	for _, c := range daemon.List() {
+		for _, secret := range c.hostConfig.Secrets {
+			if secret == name {
+				containers = append(containers, c.ID)
+				break
+			}
+		}
+	}
+	if len(containers) > 0 {
+		sort.Strings(containers)
+		return fmt.Errorf("Conflict: secret %s is in use by containers %v", name, containers)
+	}
+	return daemon.secrets.remove(name)
+}
+
+// verifySecrets checks that the secrets given to a container exist.
+func (daemon *Daemon) verifySecrets(secrets []string) error {
+	for _, name := range secrets {
+		if _, err := daemon.secrets.stat(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretsDir is the directory of the tmpfs of the secrets of the container.
+func (container *Container) secretsDir() string {
+	return filepath.Join(container.root, "secrets")
+}
+
+// setupSecrets writes the secrets of the container to a tmpfs, which is
+// bind mounted read-only in /run/secrets while the container runs, so the
+// secrets are never written to disk nor to the container's filesystem.
+func (container *Container) setupSecrets() (err error) {
+	container.secretMounts = nil
+	if len(container.hostConfig.Secrets) == 0 {
+		return nil
+	}
+
+	dir := container.secretsDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := mount.Mount("tmpfs", dir, "tmpfs", "mode=0755"); err != nil {
+		return fmt.Errorf("Error mounting tmpfs for the secrets: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			container.unmountSecrets()
+		}
+	}()
+
+	for _, name := range container.hostConfig.Secrets {
+		data, err := container.daemon.secrets.get(name)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0444); err != nil {
+			return err
+		}
+	}
+
+	// the mount point created by libcontainer for the secrets, in the
+	// directories missing in the image, is removed once the container
+	// stops so it doesn't end up in the container's changes
+	container.secretsMountPoint = ""
+	for p := secretsMountPath; p != "/"; p = path.Dir(p) {
+		resPath, err := container.GetResourcePath(p)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(resPath); !os.IsNotExist(err) {
+			break
+		}
+		container.secretsMountPoint = resPath
+	}
+
+	container.secretMounts = []execdriver.Mount{{
+		Source:      dir,
+		Destination: secretsMountPath,
+		Writable:    false,
+		Private:     true,
+	}}
+	return nil
+}
+
+// unmountSecrets removes the secrets of the container once it stopped,
+// while its filesystem is mounted.
+func (container *Container) unmountSecrets() {
+	container.secretMounts = nil
+	if container.secretsMountPoint != "" {
+		if err := os.RemoveAll(container.secretsMountPoint); err != nil {
+			logrus.Debugf("%s: failed to remove the mount point of the secrets: %v", container.ID, err)
+		}
+		container.secretsMountPoint = ""
+	}
+
+	dir := container.secretsDir()
+	if mounted, _ := mount.Mounted(dir); mounted {
+		if err := mount.Unmount(dir); err != nil {
+			logrus.Errorf("%s: failed to unmount the secrets: %v", container.ID, err)
+			return
+		}
+	}
+	os.RemoveAll(dir)
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// shutdownContainers stops the running containers within the shutdown
+// timeout of the daemon, after which they are killed. The containers using
+// the links, the volumes, the network or the IPC of others are stopped
+// before them.
+func (daemon *Daemon) shutdownContainers() {
+	remaining := make(map[string]*Container)
+	for _, c := range daemon.List() {
+		if c.IsRunning() {
+			remaining[c.ID] = c
+		}
+	}
+	dependencies := make(map[string][]*Container)
+	for id, c := range remaining {
+		dependencies[id] = daemon.dependencies(c)
+	}
+
+	deadline := time.Now().Add(time.Duration(daemon.config.ShutdownTimeout) * time.Second)
+	for len(remaining) > 0 {
+		// stop the containers which no other running container depends on,
+		// or all of them if they depend on each other
+		used := make(map[string]bool)
+		for id := range remaining {
+			for _, d := range dependencies[id] {
+				if d.ID != id {
+					used[d.ID] = true
+				}
+			}
+		}
+		var stopping []*Container
+		for id, c := range remaining {
+			if !used[id] {
+				stopping = append(stopping, c)
+			}
+		}
+		if len(stopping) == 0 {
+			for _, c := range remaining {
+				stopping = append(stopping, c)
+			}
+		}
+
+		seconds := int(math.Ceil(deadline.Sub(time.Now()).Seconds()))
+		if seconds < 0 {
+			seconds = 0
+		}
+		group := sync.WaitGroup{}
+		for _, c := range stopping {
+			delete(remaining, c.ID)
+			group.Add(1)
+			go func(c *Container) {
+				defer group.Done()
+				logrus.Debugf("stopping %s", c.ID)
+				if c.IsPaused() {
+					if err := c.Unpause(); err != nil {
+						logrus.Debugf("unpause error for %s - %s", c.ID, err)
+					}
+				}
+				if err := c.Stop(seconds); err != nil {
+					logrus.Debugf("stop error for %s - %s", c.ID, err)
+				}
+				logrus.Debugf("container stopped %s", c.ID)
+			}(c)
+		}
+		group.Wait()
+	}
+}
+
+// dependencies returns the containers whose links, volumes, network or IPC
+// the container uses.
+func (daemon *Daemon) dependencies(container *Container) []*Container {
+	var dependencies []*Container
+	add := func(name string) {
+		if c, err := daemon.Get(name); err == nil {
+			dependencies = append(dependencies, c)
+		}
+	}
+
+	if children, err := daemon.Children(container.Name); err == nil {
+		for _, c := range children {
+			dependencies = append(dependencies, c)
+		}
+	}
+
+	hostConfig := container.hostConfig
+	if hostConfig == nil {
+		return dependencies
+	}
+	for _, spec := range hostConfig.VolumesFrom {
+		if id, _, err := parseVolumesFromSpec(spec); err == nil {
+			add(id)
+		}
+	}
+	if hostConfig.NetworkMode.IsContainer() {
+		add(strings.SplitN(string(hostConfig.NetworkMode), ":", 2)[1])
+	}
+	if hostConfig.IpcMode.IsContainer() {
+		add(hostConfig.IpcMode.Container())
+	}
+	return dependencies
+}
@@ -34,6 +34,9 @@ func (daemon *Daemon) ContainerStart(name string, hostConfig *runconfig.HostConf
 
 	if err := container.Start(); err != nil {
 		container.LogEvent("die")
+		if container.hostConfig.AutoRemove {
+			daemon.autoRemove(container)
+		}
 		return fmt.Errorf("Cannot start container %s: %s", name, err)
 	}
 
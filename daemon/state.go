@@ -16,18 +16,26 @@ type State struct {
 	Restarting        bool
 	OOMKilled         bool
 	removalInProgress bool // Not need for this to be persistent on disk.
+	pausedForCommit   bool // paused by a commit rather than by the user
 	Dead              bool
 	Pid               int
 	ExitCode          int
 	Error             string // contains last known error when starting the container
 	StartedAt         time.Time
 	FinishedAt        time.Time
+	PausedAt          time.Time // when the container was paused, while it is
+	NextRestart       time.Time // when the restarting container is started again
+	Health            *Health   `json:",omitempty"`
 	waitChan          chan struct{}
+	exitChan          chan struct{} // closed on the next exit of the process
+	removedChan       chan struct{} // closed once the container is removed
 }
 
 func NewState() *State {
 	return &State{
-		waitChan: make(chan struct{}),
+		waitChan:    make(chan struct{}),
+		exitChan:    make(chan struct{}),
+		removedChan: make(chan struct{}),
 	}
 }
 
@@ -41,6 +49,9 @@ func (s *State) String() string {
 			return fmt.Sprintf("Restarting (%d) %s ago", s.ExitCode, units.HumanDuration(time.Now().UTC().Sub(s.FinishedAt)))
 		}
 
+		if s.Health != nil {
+			return fmt.Sprintf("Up %s (%s)", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)), s.Health.String())
+		}
 		return fmt.Sprintf("Up %s", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)))
 	}
 
@@ -127,6 +138,30 @@ func (s *State) WaitStop(timeout time.Duration) (int, error) {
 	return s.GetExitCode(), nil
 }
 
+// WaitNextExit waits until the next exit of the process, even if the state
+// is already stopped, or until the process is restarted. If you want wait
+// forever you must supply negative timeout. Returns the exit code of the
+// process.
+func (s *State) WaitNextExit(timeout time.Duration) (int, error) {
+	s.Lock()
+	exitChan := s.exitChan
+	s.Unlock()
+	if err := wait(exitChan, timeout); err != nil {
+		return -1, err
+	}
+	return s.GetExitCode(), nil
+}
+
+// WaitRemoved waits until the container is removed. If you want wait forever
+// you must supply negative timeout. Returns the last exit code of the
+// container.
+func (s *State) WaitRemoved(timeout time.Duration) (int, error) {
+	if err := wait(s.removedChan, timeout); err != nil {
+		return -1, err
+	}
+	return s.GetExitCode(), nil
+}
+
 func (s *State) IsRunning() bool {
 	s.Lock()
 	res := s.Running
@@ -157,11 +192,12 @@ func (s *State) SetRunning(pid int) {
 func (s *State) setRunning(pid int) {
 	s.Error = ""
 	s.Running = true
-	s.Paused = false
+	s.setUnpaused()
 	s.Restarting = false
 	s.ExitCode = 0
 	s.Pid = pid
 	s.StartedAt = time.Now().UTC()
+	s.NextRestart = time.Time{}
 	close(s.waitChan) // fire waiters for start
 	s.waitChan = make(chan struct{})
 }
@@ -175,17 +211,20 @@ func (s *State) SetStopped(exitStatus *execdriver.ExitStatus) {
 func (s *State) setStopped(exitStatus *execdriver.ExitStatus) {
 	s.Running = false
 	s.Restarting = false
+	s.NextRestart = time.Time{}
 	s.Pid = 0
 	s.FinishedAt = time.Now().UTC()
 	s.ExitCode = exitStatus.ExitCode
 	s.OOMKilled = exitStatus.OOMKilled
 	close(s.waitChan) // fire waiters for stop
 	s.waitChan = make(chan struct{})
+	close(s.exitChan)
+	s.exitChan = make(chan struct{})
 }
 
 // SetRestarting is when docker handles the auto restart of containers when they are
-// in the middle of a stop and being restarted again
-func (s *State) SetRestarting(exitStatus *execdriver.ExitStatus) {
+// in the middle of a stop and being restarted again, at nextRestart
+func (s *State) SetRestarting(exitStatus *execdriver.ExitStatus, nextRestart time.Time) {
 	s.Lock()
 	// we should consider the container running when it is restarting because of
 	// all the checks in docker around rm/stop/etc
@@ -195,8 +234,11 @@ func (s *State) SetRestarting(exitStatus *execdriver.ExitStatus) {
 	s.FinishedAt = time.Now().UTC()
 	s.ExitCode = exitStatus.ExitCode
 	s.OOMKilled = exitStatus.OOMKilled
+	s.NextRestart = nextRestart
 	close(s.waitChan) // fire waiters for stop
 	s.waitChan = make(chan struct{})
+	close(s.exitChan)
+	s.exitChan = make(chan struct{})
 	s.Unlock()
 }
 
@@ -216,16 +258,27 @@ func (s *State) IsRestarting() bool {
 
 func (s *State) SetPaused() {
 	s.Lock()
-	s.Paused = true
+	s.setPaused()
 	s.Unlock()
 }
 
+func (s *State) setPaused() {
+	s.Paused = true
+	s.PausedAt = time.Now().UTC()
+}
+
 func (s *State) SetUnpaused() {
 	s.Lock()
-	s.Paused = false
+	s.setUnpaused()
 	s.Unlock()
 }
 
+func (s *State) setUnpaused() {
+	s.Paused = false
+	s.PausedAt = time.Time{}
+	s.pausedForCommit = false
+}
+
 func (s *State) IsPaused() bool {
 	s.Lock()
 	res := s.Paused
@@ -254,3 +307,11 @@ func (s *State) SetDead() {
 	s.Dead = true
 	s.Unlock()
 }
+
+// SetRemoved fires the waiters for the removal of the container. It is only
+// called once, when the container is removed from the daemon.
+func (s *State) SetRemoved() {
+	s.Lock()
+	close(s.removedChan)
+	s.Unlock()
+}
@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -102,3 +103,94 @@ func TestStateTimeoutWait(t *testing.T) {
 	}
 
 }
+
+func TestStateWaitNextExit(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 1})
+
+	// the state is already stopped, the next exit is waited for
+	if _, err := s.WaitNextExit(100 * time.Millisecond); err == nil {
+		t.Fatal("Expected WaitNextExit to time out on a stopped state")
+	}
+
+	exited := make(chan int)
+	go func() {
+		exitCode, _ := s.WaitNextExit(-1 * time.Second)
+		exited <- exitCode
+	}()
+	// starting the process doesn't fire the waiters of the next exit
+	s.SetRunning(43)
+	select {
+	case <-exited:
+		t.Fatal("WaitNextExit returned on the start of the process")
+	case <-time.After(100 * time.Millisecond):
+	}
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 2})
+	select {
+	case exitCode := <-exited:
+		if exitCode != 2 {
+			t.Fatalf("ExitCode %v, expected 2", exitCode)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Exit callback doesn't fire in 100 milliseconds")
+	}
+}
+
+func TestStateWaitRemoved(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 3})
+
+	removed := make(chan int)
+	go func() {
+		exitCode, _ := s.WaitRemoved(-1 * time.Second)
+		removed <- exitCode
+	}()
+	select {
+	case <-removed:
+		t.Fatal("WaitRemoved returned before the removal")
+	case <-time.After(100 * time.Millisecond):
+	}
+	s.SetRemoved()
+	select {
+	case exitCode := <-removed:
+		if exitCode != 3 {
+			t.Fatalf("ExitCode %v, expected 3", exitCode)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Removal callback doesn't fire in 100 milliseconds")
+	}
+
+	// the waiters after the removal return at once
+	if exitCode, err := s.WaitRemoved(100 * time.Millisecond); err != nil || exitCode != 3 {
+		t.Fatalf("WaitRemoved returned exit code: %v, err: %v, expected exit code: 3", exitCode, err)
+	}
+}
+
+func TestStatePausedAt(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+	if !s.PausedAt.IsZero() {
+		t.Fatalf("PausedAt %v of a running container, expected zero", s.PausedAt)
+	}
+	before := time.Now().UTC()
+	s.SetPaused()
+	if !s.IsPaused() || s.PausedAt.Before(before) {
+		t.Fatalf("PausedAt %v, expected after %v", s.PausedAt, before)
+	}
+	if !strings.Contains(s.String(), "(Paused)") {
+		t.Fatalf("State %q, expected to be paused", s.String())
+	}
+	s.SetUnpaused()
+	if s.IsPaused() || !s.PausedAt.IsZero() {
+		t.Fatalf("PausedAt %v of an unpaused container, expected zero", s.PausedAt)
+	}
+
+	// a container paused when it restarts runs again unpaused
+	s.SetPaused()
+	s.SetRunning(43)
+	if s.IsPaused() || !s.PausedAt.IsZero() {
+		t.Fatalf("PausedAt %v of a restarted container, expected zero", s.PausedAt)
+	}
+}
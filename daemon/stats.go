@@ -10,18 +10,32 @@ import (
 	"github.com/docker/libcontainer/cgroups"
 )
 
+// ContainerStats writes the stats of the container to out, every time they're
+// collected if stream is set, else once. The stats written once are the
+// second ones collected, for their PreCpuStats to be set.
 func (daemon *Daemon) ContainerStats(name string, stream bool, out io.Writer) error {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return err
+	}
 	updates, err := daemon.SubscribeToContainerStats(name)
 	if err != nil {
 		return err
 	}
-	enc := json.NewEncoder(out)
+	var (
+		enc         = json.NewEncoder(out)
+		preCpuStats types.CpuStats
+		first       = true
+	)
 	for v := range updates {
-		update := v.(*execdriver.ResourceStats)
-		ss := convertToAPITypes(update.Stats)
-		ss.MemoryStats.Limit = uint64(update.MemoryLimit)
-		ss.Read = update.Read
-		ss.CpuStats.SystemUsage = update.SystemUsage
+		ss := resourceStatsToAPIType(v.(*execdriver.ResourceStats))
+		ss.LogStats = container.logStats()
+		ss.PreCpuStats = preCpuStats
+		preCpuStats = ss.CpuStats
+		if !stream && first {
+			first = false
+			continue
+		}
 		if err := enc.Encode(ss); err != nil {
 			// TODO: handle the specific broken pipe
 			daemon.UnsubscribeToContainerStats(name, updates)
@@ -34,13 +48,35 @@ func (daemon *Daemon) ContainerStats(name string, stream bool, out io.Writer) er
 	return nil
 }
 
+// resourceStatsToAPIType converts the stats of the exec driver to the api
+// specific structs.
+func resourceStatsToAPIType(update *execdriver.ResourceStats) *types.Stats {
+	ss := convertToAPITypes(update.Stats)
+	ss.MemoryStats.Limit = uint64(update.MemoryLimit)
+	ss.Read = update.Read
+	ss.CpuStats.SystemUsage = update.SystemUsage
+	ss.PidsStats.Current = update.Pids
+	return ss
+}
+
 // convertToAPITypes converts the libcontainer.Stats to the api specific
 // structs.  This is done to preserve API compatibility and versioning.
 func convertToAPITypes(ls *libcontainer.Stats) *types.Stats {
 	s := &types.Stats{}
 	if ls.Interfaces != nil {
 		s.Network = types.Network{}
+		s.Networks = make(map[string]types.Network)
 		for _, iface := range ls.Interfaces {
+			s.Networks[iface.Name] = types.Network{
+				RxBytes:   iface.RxBytes,
+				RxPackets: iface.RxPackets,
+				RxErrors:  iface.RxErrors,
+				RxDropped: iface.RxDropped,
+				TxBytes:   iface.TxBytes,
+				TxPackets: iface.TxPackets,
+				TxErrors:  iface.TxErrors,
+				TxDropped: iface.TxDropped,
+			}
 			s.Network.RxBytes += iface.RxBytes
 			s.Network.RxPackets += iface.RxPackets
 			s.Network.RxErrors += iface.RxErrors
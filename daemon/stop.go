@@ -10,6 +10,9 @@ func (daemon *Daemon) ContainerStop(name string, seconds int) error {
 	if !container.IsRunning() {
 		return fmt.Errorf("Container already stopped")
 	}
+	if container.IsPaused() {
+		return errContainerPaused(container, "stopping it")
+	}
 	if err := container.Stop(seconds); err != nil {
 		return fmt.Errorf("Cannot stop container %s: %s\n", name, err)
 	}
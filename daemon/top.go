@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"bytes"
 	"fmt"
 	"os/exec"
 	"strconv"
@@ -11,7 +12,7 @@ import (
 
 func (daemon *Daemon) ContainerTop(name string, psArgs string) (*types.ContainerProcessList, error) {
 	if psArgs == "" {
-		psArgs = "-ef"
+		psArgs = "-f"
 	}
 
 	container, err := daemon.Get(name)
@@ -28,11 +29,31 @@ func (daemon *Daemon) ContainerTop(name string, psArgs string) (*types.Container
 		return nil, err
 	}
 
-	output, err := exec.Command("ps", strings.Split(psArgs, " ")...).Output()
+	// ps only lists the processes of the container, unless its options
+	// select other processes too, such as with "aux", in which case the
+	// output is filtered by PID
+	args := strings.Fields(psArgs)
+	if len(pids) > 0 {
+		list := make([]string, len(pids))
+		for i, pid := range pids {
+			list[i] = strconv.Itoa(pid)
+		}
+		args = append(args, "-p", strings.Join(list, ","))
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("ps", args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("Error running ps: %s", err)
+		return nil, fmt.Errorf("Error running ps: %s: %s", err, strings.TrimSpace(stderr.String()))
 	}
+	return parsePsOutput(output, pids)
+}
 
+// parsePsOutput returns the processes of the output of ps which have one of
+// the pids, with all of their threads.
+func parsePsOutput(output []byte, pids []int) (*types.ContainerProcessList, error) {
 	procList := &types.ContainerProcessList{}
 
 	lines := strings.Split(string(output), "\n")
@@ -48,6 +69,11 @@ func (daemon *Daemon) ContainerTop(name string, psArgs string) (*types.Container
 		return nil, fmt.Errorf("Couldn't find PID field in ps output")
 	}
 
+	wanted := make(map[int]bool, len(pids))
+	for _, pid := range pids {
+		wanted[pid] = true
+	}
+
 	for _, line := range lines[1:] {
 		if len(line) == 0 {
 			continue
@@ -58,14 +84,12 @@ func (daemon *Daemon) ContainerTop(name string, psArgs string) (*types.Container
 			return nil, fmt.Errorf("Unexpected pid '%s': %s", fields[pidIndex], err)
 		}
 
-		for _, pid := range pids {
-			if pid == p {
-				// Make sure number of fields equals number of header titles
-				// merging "overhanging" fields
-				process := fields[:len(procList.Titles)-1]
-				process = append(process, strings.Join(fields[len(procList.Titles)-1:], " "))
-				procList.Processes = append(procList.Processes, process)
-			}
+		if wanted[p] {
+			// Make sure number of fields equals number of header titles
+			// merging "overhanging" fields
+			process := fields[:len(procList.Titles)-1]
+			process = append(process, strings.Join(fields[len(procList.Titles)-1:], " "))
+			procList.Processes = append(procList.Processes, process)
 		}
 	}
 	return procList, nil
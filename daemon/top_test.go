@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePsOutput(t *testing.T) {
+	output := []byte(`USER       PID   LWP %CPU WCHAN  COMMAND
+root         1     1  0.0 -      /sbin/init
+root        42    42  0.0 poll_s top -b
+root        42    43  0.0 futex_ top -b
+root        51    51  0.0 -      sh -c sleep 10
+`)
+	procList, err := parsePsOutput(output, []int{42, 51})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"USER", "PID", "LWP", "%CPU", "WCHAN", "COMMAND"}; !reflect.DeepEqual(procList.Titles, expected) {
+		t.Fatalf("Expected the titles %v, got %v", expected, procList.Titles)
+	}
+	expected := [][]string{
+		{"root", "42", "42", "0.0", "poll_s", "top -b"},
+		{"root", "42", "43", "0.0", "futex_", "top -b"},
+		{"root", "51", "51", "0.0", "-", "sh -c sleep 10"},
+	}
+	if !reflect.DeepEqual(procList.Processes, expected) {
+		t.Fatalf("Expected the processes %v, got %v", expected, procList.Processes)
+	}
+}
+
+func TestParsePsOutputWithoutPID(t *testing.T) {
+	if _, err := parsePsOutput([]byte("USER COMMAND\nroot top\n"), []int{1}); err == nil {
+		t.Fatal("Expected an error without a PID column")
+	}
+}
@@ -6,23 +6,66 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/directory"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/symlink"
+	"github.com/docker/docker/volumes"
 )
 
 type volumeMount struct {
 	containerPath string
 	hostPath      string
+	name          string // the name of a named volume, instead of hostPath
 	writable      bool
 	copyData      bool
 	from          string
 }
 
+func volumeToAPIType(v *volumes.Volume) *types.Volume {
+	return &types.Volume{
+		Name:       v.DisplayName(),
+		Driver:     v.DriverName(),
+		Mountpoint: v.Path,
+		From:       v.From,
+		Options:    v.Options,
+		Labels:     v.Labels,
+	}
+}
+
+// volumeUsage returns the usage of a volume: the size of its data and the
+// containers referencing it.
+func volumeUsage(v *volumes.Volume) *types.VolumeUsageData {
+	containers := v.Containers()
+	sort.Strings(containers)
+	return &types.VolumeUsageData{
+		Size:       volumeSize(v),
+		Containers: containers,
+	}
+}
+
+// volumeSize returns the size of the data of a local volume, or -1 if it
+// isn't available: the volumes of the volume driver plugins and the local
+// volumes with mount options are only on the host while they are mounted.
+func volumeSize(v *volumes.Volume) int64 {
+	if v.Mountable() {
+		return -1
+	}
+	size, err := directory.Size(v.Path)
+	if err != nil {
+		logrus.Debugf("Error getting the size of volume %s: %v", v.DisplayName(), err)
+		return -1
+	}
+	return size
+}
+
 func (container *Container) prepareVolumes() error {
 	if container.Volumes == nil || len(container.Volumes) == 0 {
 		container.Volumes = make(map[string]string)
@@ -112,7 +155,12 @@ func (container *Container) createVolumes() error {
 		}
 
 		// Create the actual volume
-		v, err := container.daemon.volumes.FindOrCreateVolume(mnt.hostPath, mnt.writable)
+		var v *volumes.Volume
+		if mnt.name != "" {
+			v, err = container.daemon.volumes.FindOrCreateNamedVolume(mnt.name, container.hostConfig.VolumeDriver, nil, nil, mnt.writable)
+		} else {
+			v, err = container.daemon.volumes.FindOrCreateVolume(mnt.hostPath, mnt.writable)
+		}
 		if err != nil {
 			return err
 		}
@@ -124,7 +172,9 @@ func (container *Container) createVolumes() error {
 			container.AppliedVolumesFrom[mnt.from] = struct{}{}
 		}
 
-		if mnt.writable && mnt.copyData {
+		// the volumes of the volume driver plugins, and the local volumes
+		// with mount options, are only mounted while the containers run
+		if mnt.writable && mnt.copyData && !v.Mountable() {
 			// Copy whatever is in the container at the containerPath to the volume
 			copyExistingContents(containerMntPath, v.Path)
 		}
@@ -201,12 +251,20 @@ func parseBindMountSpec(spec string) (*volumeMount, error) {
 		return nil, fmt.Errorf("Invalid volume specification: %s", spec)
 	}
 
+	mnt.containerPath = filepath.Clean(mnt.containerPath)
+
 	if !filepath.IsAbs(mnt.hostPath) {
-		return nil, fmt.Errorf("cannot bind mount volume: %s volume paths must be absolute.", mnt.hostPath)
+		// not a path, but the name of a named volume, which is filled
+		// with the content of the container like an anonymous volume
+		if volumes.ValidateName(mnt.hostPath) != nil {
+			return nil, fmt.Errorf("cannot bind mount volume: %s volume paths must be absolute.", mnt.hostPath)
+		}
+		mnt.name, mnt.hostPath = mnt.hostPath, ""
+		mnt.copyData = true
+		return mnt, nil
 	}
 
 	mnt.hostPath = filepath.Clean(mnt.hostPath)
-	mnt.containerPath = filepath.Clean(mnt.containerPath)
 	return mnt, nil
 }
 
@@ -269,11 +327,26 @@ func (container *Container) setupMounts() error {
 	}
 
 	mounts = append(mounts, container.specialMounts()...)
+	mounts = append(mounts, container.tmpMounts...)
+	mounts = append(mounts, container.secretMounts...)
+	mounts = append(mounts, container.gpuMounts...)
 
 	container.command.Mounts = mounts
 	return nil
 }
 
+// AddTmpMount bind mounts source read-only at destination when the
+// container is started. Unlike volumes, the mount is not stored with the
+// container, so it is gone once the daemon or the container is restarted.
+func (container *Container) AddTmpMount(source, destination string) {
+	container.tmpMounts = append(container.tmpMounts, execdriver.Mount{
+		Source:      source,
+		Destination: filepath.Clean(destination),
+		Writable:    false,
+		Private:     true,
+	})
+}
+
 func (container *Container) volumeMounts() map[string]*volumeMount {
 	mounts := make(map[string]*volumeMount)
 
@@ -342,6 +415,80 @@ func (container *Container) mountVolumes() error {
 	return nil
 }
 
+// mountDriverVolumes mounts the volumes of the volume driver plugins, and the
+// local volumes with mount options, used by the container, for it to start.
+func (container *Container) mountDriverVolumes() error {
+	for _, path := range container.Volumes {
+		v := container.daemon.volumes.Get(path)
+		if v == nil || !v.Mountable() {
+			continue
+		}
+		if err := v.Mount(); err != nil {
+			return err
+		}
+		container.mountedVolumes = append(container.mountedVolumes, v)
+	}
+	container.logVolumeEvents("mount")
+	container.volumesMounted = true
+	return nil
+}
+
+// restoreDriverVolumes records the volumes mounted for a container left
+// running by a previous daemon, to release them once the container stopped.
+// The volume driver plugins still have the volumes mounted, so only the local
+// volumes with options count their mounts again.
+func (container *Container) restoreDriverVolumes() error {
+	for _, path := range container.Volumes {
+		v := container.daemon.volumes.Get(path)
+		if v == nil || !v.Mountable() {
+			continue
+		}
+		if v.Driver == "" {
+			if err := v.Mount(); err != nil {
+				return err
+			}
+		}
+		container.mountedVolumes = append(container.mountedVolumes, v)
+	}
+	container.volumesMounted = true
+	return nil
+}
+
+// unmountDriverVolumes releases the volumes mounted by mountDriverVolumes,
+// once the container stopped.
+func (container *Container) unmountDriverVolumes() {
+	for _, v := range container.mountedVolumes {
+		if err := v.Unmount(); err != nil {
+			logrus.Errorf("%v: Failed to unmount volume %s: %v", container.ID, v.DisplayName(), err)
+		}
+	}
+	container.mountedVolumes = nil
+	if container.volumesMounted {
+		container.logVolumeEvents("unmount")
+		container.volumesMounted = false
+	}
+}
+
+// logVolumeEvents logs an event of each volume of the container, other than
+// its bind mounts.
+func (container *Container) logVolumeEvents(action string) {
+	for dest, path := range container.Volumes {
+		v := container.daemon.volumes.Get(path)
+		if v == nil || v.IsBindMount {
+			continue
+		}
+		container.daemon.EventsService.LogActor(events.VolumeEventType, action, events.Actor{
+			ID: v.DisplayName(),
+			Attributes: map[string]string{
+				"container":   container.ID,
+				"destination": dest,
+				"driver":      v.DriverName(),
+				"read/write":  strconv.FormatBool(container.VolumesRW[dest]),
+			},
+		}, "")
+	}
+}
+
 func (container *Container) unmountVolumes() {
 	for dest := range container.Volumes {
 		destPath, err := container.GetResourcePath(dest)
@@ -0,0 +1,186 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/volumes"
+)
+
+func newVolumesTestDaemon(t *testing.T, root string) *Daemon {
+	driver, err := graphdriver.GetDriver("vfs", filepath.Join(root, "graph"), []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := volumes.NewRepository(filepath.Join(root, "volumes"), driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Daemon{volumes: repo}
+}
+
+func TestVolumesFilters(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-volumes-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	daemon := newVolumesTestDaemon(t, root)
+
+	if _, err := daemon.VolumeCreate("data", "", nil, map[string]string{"env": "prod", "team": "web"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.VolumeCreate("logs", "", nil, map[string]string{"env": "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := daemon.VolumeCreate("", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon.volumes.GetByName("logs").AddContainer("1234")
+
+	for filter, expected := range map[string][]string{
+		``:                                      {v.Name, "data", "logs"},
+		`{"label":["env"]}`:                     {"data", "logs"},
+		`{"label":["env=prod"]}`:                {"data"},
+		`{"label":["env=prod","team=web"]}`:     {"data"},
+		`{"label":["env=prod","team=db"]}`:      {},
+		`{"dangling":["true"]}`:                 {v.Name, "data"},
+		`{"dangling":["false"]}`:                {"logs"},
+		`{"dangling":["true"],"label":["env"]}`: {"data"},
+		`{"driver":["local"]}`:                  {v.Name, "data", "logs"},
+		`{"driver":["other"]}`:                  {},
+	} {
+		list, err := daemon.Volumes(filter)
+		if err != nil {
+			t.Fatalf("%s: %v", filter, err)
+		}
+		names := make(map[string]bool)
+		for _, v := range list {
+			names[v.Name] = true
+		}
+		if len(names) != len(expected) {
+			t.Fatalf("%s: expected %v, got %v", filter, expected, names)
+		}
+		for _, name := range expected {
+			if !names[name] {
+				t.Fatalf("%s: expected %v, got %v", filter, expected, names)
+			}
+		}
+	}
+
+	for _, filter := range []string{`{"dangling":["maybe"]}`, `{"size":["1"]}`} {
+		if _, err := daemon.Volumes(filter); err == nil {
+			t.Fatalf("%s: expected an invalid filter", filter)
+		}
+	}
+}
+
+func TestVolumesPrune(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-volumes-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	daemon := newVolumesTestDaemon(t, root)
+
+	data, err := daemon.VolumeCreate("data", "", nil, map[string]string{"env": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(data.Mountpoint, "file"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.VolumeCreate("logs", "", nil, map[string]string{"env": "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.VolumeCreate("used", "", nil, map[string]string{"env": "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	daemon.volumes.GetByName("used").AddContainer("1234")
+
+	for _, filter := range []string{`{"dangling":["true"]}`, `{"until":["yesterday"]}`} {
+		if _, err := daemon.VolumesPrune(filter); err == nil {
+			t.Fatalf("%s: expected an invalid filter", filter)
+		}
+	}
+
+	// all the volumes were created after the timestamp
+	report, err := daemon.VolumesPrune(`{"until":["2000-01-01"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.VolumesDeleted) != 0 {
+		t.Fatalf("expected no volume pruned, got %v", report.VolumesDeleted)
+	}
+
+	report, err = daemon.VolumesPrune(`{"label":["env=prod"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.VolumesDeleted) != 1 || report.VolumesDeleted[0] != "data" {
+		t.Fatalf("expected the volume data pruned, got %v", report.VolumesDeleted)
+	}
+	if report.SpaceReclaimed < 100 {
+		t.Fatalf("expected at least 100 bytes reclaimed, got %d", report.SpaceReclaimed)
+	}
+
+	report, err = daemon.VolumesPrune("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.VolumesDeleted) != 1 || report.VolumesDeleted[0] != "logs" {
+		t.Fatalf("expected the volume logs pruned, got %v", report.VolumesDeleted)
+	}
+	if daemon.volumes.GetByName("used") == nil {
+		t.Fatal("expected the volume used by a container to be kept")
+	}
+}
+
+func TestVolumeInspectUsage(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-volumes-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	daemon := newVolumesTestDaemon(t, root)
+
+	v, err := daemon.VolumeCreate("data", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.UsageData != nil {
+		t.Fatalf("expected no usage of a created volume, got %+v", v.UsageData)
+	}
+	if err := ioutil.WriteFile(filepath.Join(v.Mountpoint, "file"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	daemon.volumes.GetByName("data").AddContainer("5678")
+	daemon.volumes.GetByName("data").AddContainer("1234")
+
+	v, err = daemon.VolumeInspect("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.UsageData == nil || v.UsageData.Size < 100 {
+		t.Fatalf("expected the size of the volume, got %+v", v.UsageData)
+	}
+	if len(v.UsageData.Containers) != 2 || v.UsageData.Containers[0] != "1234" || v.UsageData.Containers[1] != "5678" {
+		t.Fatalf("expected the containers using the volume, got %v", v.UsageData.Containers)
+	}
+
+	// the size of a mounted volume isn't available
+	if _, err := daemon.VolumeCreate("tmp", "", map[string]string{"type": "tmpfs", "device": "tmpfs"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	v, err = daemon.VolumeInspect("tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.UsageData.Size != -1 || len(v.UsageData.Containers) != 0 {
+		t.Fatalf("expected the size of the volume to be unavailable, got %+v", v.UsageData)
+	}
+}
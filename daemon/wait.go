@@ -1,12 +1,41 @@
 package daemon
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// The conditions of ContainerWaitCondition.
+const (
+	// WaitConditionNotRunning waits until the container isn't running,
+	// returning at once if it's already stopped.
+	WaitConditionNotRunning = "not-running"
+	// WaitConditionNextExit waits for the next exit of the container, even
+	// if it's already stopped.
+	WaitConditionNextExit = "next-exit"
+	// WaitConditionRemoved waits until the container is removed.
+	WaitConditionRemoved = "removed"
+)
 
 func (daemon *Daemon) ContainerWait(name string, timeout time.Duration) (int, error) {
+	return daemon.ContainerWaitCondition(name, WaitConditionNotRunning, timeout)
+}
+
+// ContainerWaitCondition waits until the condition of the container is met,
+// and returns its exit code. An empty condition is WaitConditionNotRunning.
+func (daemon *Daemon) ContainerWaitCondition(name, condition string, timeout time.Duration) (int, error) {
 	container, err := daemon.Get(name)
 	if err != nil {
 		return -1, err
 	}
 
-	return container.WaitStop(timeout)
+	switch condition {
+	case "", WaitConditionNotRunning:
+		return container.WaitStop(timeout)
+	case WaitConditionNextExit:
+		return container.WaitNextExit(timeout)
+	case WaitConditionRemoved:
+		return container.WaitRemoved(timeout)
+	}
+	return -1, fmt.Errorf("Bad parameter: invalid wait condition %q, expected %s, %s or %s", condition, WaitConditionNotRunning, WaitConditionNextExit, WaitConditionRemoved)
 }
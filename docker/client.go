@@ -8,6 +8,9 @@ import (
 
 const CanDaemon = false
 
+func mergeDaemonConfigFile() {
+}
+
 func mainDaemon() {
 	log.Fatal("This is a client-only binary - running the Docker daemon is not supported.")
 }
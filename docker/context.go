@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// applyContext sets the host and the TLS options of the client from the
+// context given by --context, or DOCKER_CONTEXT, or from the current context
+// of the configuration. The context is ignored when -H or DOCKER_HOST gives
+// the daemon, unless --context is given too, and by the context commands,
+// which don't connect to the daemon.
+func applyContext() error {
+	if flag.Arg(0) == "context" {
+		return nil
+	}
+	name := *flContext
+	if name != "" && len(flHosts) > 0 {
+		return fmt.Errorf("Conflicting options: either specify --host or --context, not both")
+	}
+	if name == "" {
+		if len(flHosts) > 0 || os.Getenv("DOCKER_HOST") != "" {
+			return nil
+		}
+		name = os.Getenv("DOCKER_CONTEXT")
+	}
+
+	config, err := cliconfig.Load("")
+	if err != nil {
+		if name == "" {
+			// the errors are reported by the client
+			return nil
+		}
+		return err
+	}
+	if name == "" {
+		name = config.CurrentContext
+	}
+	if name == "" || name == cliconfig.DefaultContext {
+		return nil
+	}
+	ctx, exists := config.Contexts[name]
+	if !exists {
+		return fmt.Errorf("No such context: %s, see 'docker context ls'", name)
+	}
+
+	host, err := opts.ValidateHost(ctx.Host)
+	if err != nil {
+		return fmt.Errorf("Invalid host of the context %s: %v", name, err)
+	}
+	flHosts = []string{host}
+	// the options given on the command line override those of the context
+	if !flag.IsSet("-tls") && !flag.IsSet("-tlsverify") {
+		*flTls, *flTlsVerify = ctx.TLS, ctx.TLSVerify
+	}
+	for _, f := range []struct {
+		flag  string
+		value *string
+		path  string
+	}{
+		{"-tlscacert", flCa, ctx.TLSCACert},
+		{"-tlscert", flCert, ctx.TLSCert},
+		{"-tlskey", flKey, ctx.TLSKey},
+	} {
+		if f.path != "" && !flag.IsSet(f.flag) {
+			*f.value = f.path
+		}
+	}
+	return nil
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	gosignal "os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -15,11 +17,13 @@ import (
 	"github.com/docker/docker/daemon"
 	_ "github.com/docker/docker/daemon/execdriver/lxc"
 	_ "github.com/docker/docker/daemon/execdriver/native"
+	"github.com/docker/docker/pkg/audit"
 	"github.com/docker/docker/pkg/homedir"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/pidfile"
 	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/pkg/systemd"
 	"github.com/docker/docker/pkg/timeutils"
 	"github.com/docker/docker/registry"
 )
@@ -27,16 +31,32 @@ import (
 const CanDaemon = true
 
 var (
-	daemonCfg   = &daemon.Config{}
-	registryCfg = &registry.Options{}
+	daemonCfg    = &daemon.Config{}
+	registryCfg  = &registry.Options{}
+	flConfigFile *string
 )
 
 func init() {
 	if daemonCfg.LogConfig.Config == nil {
 		daemonCfg.LogConfig.Config = make(map[string]string)
 	}
+	if daemonCfg.Runtimes == nil {
+		daemonCfg.Runtimes = make(map[string]string)
+	}
 	daemonCfg.InstallFlags()
 	registryCfg.InstallFlags()
+	flConfigFile = flag.String([]string{"-config-file"}, daemon.DefaultConfigFile, "Daemon configuration file")
+}
+
+// mergeDaemonConfigFile sets the options of the daemon from its
+// configuration file, which is optional unless it's given explicitly.
+func mergeDaemonConfigFile() {
+	if err := daemon.MergeConfigFile(*flConfigFile, flag.CommandLine); err != nil {
+		if os.IsNotExist(err) && !flag.IsSet("-config-file") {
+			return
+		}
+		logrus.Fatal(err)
+	}
 }
 
 func migrateKey() (err error) {
@@ -102,16 +122,31 @@ func mainDaemon() {
 	}
 
 	serverConfig := &apiserver.ServerConfig{
-		Logging:     true,
-		EnableCors:  daemonCfg.EnableCors,
-		CorsHeaders: daemonCfg.CorsHeaders,
-		Version:     dockerversion.VERSION,
-		SocketGroup: daemonCfg.SocketGroup,
-		Tls:         *flTls,
-		TlsVerify:   *flTlsVerify,
-		TlsCa:       *flCa,
-		TlsCert:     *flCert,
-		TlsKey:      *flKey,
+		Logging:      true,
+		EnableCors:   daemonCfg.EnableCors,
+		CorsHeaders:  daemonCfg.CorsHeaders,
+		Version:      dockerversion.VERSION,
+		SocketGroup:  daemonCfg.SocketGroup,
+		SocketMode:   daemonCfg.SocketMode,
+		Tls:          *flTls,
+		TlsVerify:    *flTlsVerify,
+		TlsCa:        *flCa,
+		TlsCert:      *flCert,
+		TlsKey:       *flKey,
+		TlsCrl:       daemonCfg.TlsCrl,
+		AuthZPlugins: daemonCfg.AuthZPlugins,
+
+		MaxConcurrentBuilds:  daemonCfg.MaxConcurrentBuilds,
+		MaxConcurrentPulls:   daemonCfg.MaxConcurrentPulls,
+		MaxConcurrentCreates: daemonCfg.MaxConcurrentCreates,
+	}
+	if daemonCfg.AuditLog != "" {
+		auditLog, err := audit.New(daemonCfg.AuditLog)
+		if err != nil {
+			logrus.Fatalf("Error opening the audit log: %v", err)
+		}
+		defer auditLog.Close()
+		serverConfig.AuditLog = auditLog
 	}
 
 	api := apiserver.New(serverConfig)
@@ -145,6 +180,13 @@ func mainDaemon() {
 		logrus.Fatalf("Error starting daemon: %v", err)
 	}
 
+	if err := startMetricsServer(daemonCfg.MetricsAddress); err != nil {
+		logrus.Fatalf("Error starting the metrics server: %v", err)
+	}
+	if err := startDebugServer(daemonCfg.DebugAddress); err != nil {
+		logrus.Fatalf("Error starting the debug server: %v", err)
+	}
+
 	logrus.Info("Daemon has completed initialization")
 
 	logrus.WithFields(logrus.Fields{
@@ -154,10 +196,13 @@ func mainDaemon() {
 		"graphdriver": d.GraphDriver().String(),
 	}).Info("Docker daemon")
 
+	reloadTLSOnSignal(api)
+
 	signal.Trap(func() {
+		systemd.SdNotify("STOPPING=1")
 		api.Close()
 		<-serveAPIWait
-		shutdownDaemon(d, 15)
+		shutdownDaemon(d, d.ShutdownTimeout())
 		if pfile != nil {
 			if err := pfile.Remove(); err != nil {
 				logrus.Error(err)
@@ -172,7 +217,7 @@ func mainDaemon() {
 	// Daemon is fully initialized and handling API traffic
 	// Wait for serve API to complete
 	errAPI := <-serveAPIWait
-	shutdownDaemon(d, 15)
+	shutdownDaemon(d, d.ShutdownTimeout())
 	if errAPI != nil {
 		if pfile != nil {
 			if err := pfile.Remove(); err != nil {
@@ -195,11 +240,27 @@ func shutdownDaemon(d *daemon.Daemon, timeout time.Duration) {
 	select {
 	case <-ch:
 		logrus.Debug("Clean shutdown succeded")
-	case <-time.After(timeout * time.Second):
+	case <-time.After(timeout):
 		logrus.Error("Force shutdown daemon")
 	}
 }
 
+// reloadTLSOnSignal reloads the TLS certificates and the CRL of the API when
+// the daemon receives a SIGHUP, so that they are rotated without downtime.
+func reloadTLSOnSignal(api *apiserver.Server) {
+	c := make(chan os.Signal, 1)
+	gosignal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for range c {
+			if err := api.ReloadTLS(); err != nil {
+				logrus.Errorf("Error reloading the TLS configuration, keeping the current one: %v", err)
+				continue
+			}
+			logrus.Info("Reloaded the TLS configuration")
+		}
+	}()
+}
+
 // currentUserIsOwner checks whether the current user is the owner of the given
 // file.
 func currentUserIsOwner(f string) bool {
@@ -0,0 +1,42 @@
+// +build daemon
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+	apiserver "github.com/docker/docker/api/server"
+	"github.com/gorilla/mux"
+)
+
+// startDebugServer exposes the profiling of the daemon on /debug/pprof/, its
+// expvars on /debug/vars and the stacks of its goroutines on /debug/stacks,
+// at the TCP address addr, which must be a loopback address.
+func startDebugServer(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("the debug address %s is not a loopback address", addr)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	r := mux.NewRouter()
+	apiserver.ProfilerSetup(r, "/debug/")
+	go func() {
+		logrus.Infof("Listening for debugging on %s", addr)
+		if err := http.Serve(l, r); err != nil {
+			logrus.Errorf("Debug server error: %v", err)
+		}
+	}()
+	return nil
+}
@@ -38,6 +38,10 @@ func main() {
 	flag.Parse()
 	// FIXME: validate daemon flags here
 
+	if *flDaemon {
+		mergeDaemonConfigFile()
+	}
+
 	if *flVersion {
 		showVersion()
 		return
@@ -59,6 +63,13 @@ func main() {
 		setLogLevel(logrus.DebugLevel)
 	}
 
+	if !*flDaemon {
+		if err := applyContext(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	if len(flHosts) == 0 {
 		defaultHost := os.Getenv("DOCKER_HOST")
 		if defaultHost == "" || *flDaemon {
@@ -111,6 +122,11 @@ func main() {
 		*flTls = true
 	}
 
+	// ssh encrypts and authenticates the connections to the ssh hosts
+	if protoAddrParts[0] == "ssh" {
+		*flTls, *flTlsVerify = false, false
+	}
+
 	// If we should verify the server, we need to load a trusted ca
 	if *flTlsVerify {
 		certPool := x509.NewCertPool()
@@ -31,13 +31,17 @@ var (
 		{"attach", "Attach to a running container"},
 		{"build", "Build an image from a Dockerfile"},
 		{"commit", "Create a new image from a container's changes"},
-		{"cp", "Copy files/folders from a container's filesystem to the host path"},
+		{"completion", "Output the shell completion code for the docker commands"},
+		{"container", "Manage Docker containers"},
+		{"context", "Manage the contexts, the daemons the client connects to by name"},
+		{"cp", "Copy files/folders between a container and the local filesystem"},
 		{"create", "Create a new container"},
 		{"diff", "Inspect changes on a container's filesystem"},
 		{"events", "Get real time events from the server"},
 		{"exec", "Run a command in a running container"},
 		{"export", "Stream the contents of a container as a tar archive"},
 		{"history", "Show the history of an image"},
+		{"image", "Manage Docker images"},
 		{"images", "List images"},
 		{"import", "Create a new filesystem image from the contents of a tarball"},
 		{"info", "Display system-wide information"},
@@ -47,8 +51,10 @@ var (
 		{"login", "Register or log in to a Docker registry server"},
 		{"logout", "Log out from a Docker registry server"},
 		{"logs", "Fetch the logs of a container"},
+		{"manifest", "Manage the manifest lists of the images of several platforms"},
 		{"port", "Lookup the public-facing port that is NAT-ed to PRIVATE_PORT"},
 		{"pause", "Pause all processes within a container"},
+		{"plugin", "Manage the plugins of the daemon"},
 		{"ps", "List containers"},
 		{"pull", "Pull an image or a repository from a Docker registry server"},
 		{"push", "Push an image or a repository to a Docker registry server"},
@@ -59,13 +65,17 @@ var (
 		{"run", "Run a command in a new container"},
 		{"save", "Save an image to a tar archive"},
 		{"search", "Search for an image on the Docker Hub"},
+		{"secret", "Manage the secrets of the daemon"},
+		{"stack", "Manage the stacks of containers described by compose files"},
 		{"start", "Start a stopped container"},
 		{"stats", "Display a stream of a containers' resource usage statistics"},
 		{"stop", "Stop a running container"},
+		{"system", "Manage Docker"},
 		{"tag", "Tag an image into a repository"},
 		{"top", "Lookup the running processes of a container"},
 		{"unpause", "Unpause a paused container"},
 		{"version", "Show the Docker version information"},
+		{"volume", "Manage Docker volumes"},
 		{"wait", "Block until a container stops, then print its exit code"},
 	}
 )
@@ -92,6 +102,7 @@ var (
 	flTls       = flag.Bool([]string{"-tls"}, false, "Use TLS; implied by --tlsverify")
 	flHelp      = flag.Bool([]string{"h", "-help"}, false, "Print usage")
 	flTlsVerify = flag.Bool([]string{"-tlsverify"}, dockerTlsVerify, "Use TLS and verify the remote")
+	flContext   = flag.String([]string{"c", "-context"}, "", "Name of the context connecting to the daemon, see docker context")
 
 	// these are initialized in init() below since their default values depend on dockerCertPath which isn't fully initialized until init() runs
 	flTrustKey *string
@@ -0,0 +1,32 @@
+// +build daemon
+
+package main
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/metrics"
+)
+
+// startMetricsServer exposes the metrics of the daemon on /metrics at the
+// TCP address addr.
+func startMetricsServer(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	go func() {
+		logrus.Infof("Listening for metrics on %s", addr)
+		if err := http.Serve(l, mux); err != nil {
+			logrus.Errorf("Metrics server error: %v", err)
+		}
+	}()
+	return nil
+}
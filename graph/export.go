@@ -1,13 +1,21 @@
 package graph
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/registry"
@@ -15,15 +23,35 @@ import (
 
 // CmdImageExport exports all images with the given tag. All versions
 // containing the same tag are exported. The resulting output is an
-// uncompressed tar ball.
+// uncompressed tar ball, whose layers are compressed with Compression.
 // name is the set of tags to export.
 // out is the writer where the images are written to.
 type ImageExportConfig struct {
-	Names     []string
-	Outstream io.Writer
+	Names            []string
+	BuildCache       bool   // also export the images recorded as build cache
+	Compression      string // the compression of the layers, gzip or zstd, if any
+	CompressionLevel int    // the level of the compression, 0 for the default one
+	Outstream        io.Writer
+}
+
+// ImageLoadConfig is the configuration of the loads of images, the
+// complementary of their exports.
+type ImageLoadConfig struct {
+	OutStream io.Writer
+	JSON      bool // stream JSON messages, with the loaded images as aux messages
+	Quiet     bool // don't stream the progress of the layers
+}
+
+// the maximum levels of the compressions of the exported layers
+var exportCompressionLevels = map[string]int{
+	"gzip": gzip.BestCompression,
+	"zstd": 19,
 }
 
 func (s *TagStore) ImageExport(imageExportConfig *ImageExportConfig) error {
+	if err := checkExportCompression(imageExportConfig.Compression, imageExportConfig.CompressionLevel); err != nil {
+		return err
+	}
 
 	// get image json
 	tempdir, err := ioutil.TempDir("", "docker-export-")
@@ -49,7 +77,7 @@ func (s *TagStore) ImageExport(imageExportConfig *ImageExportConfig) error {
 			// this is a base repo name, like 'busybox'
 			for tag, id := range rootRepo {
 				addKey(name, tag, id)
-				if err := s.exportImage(id, tempdir); err != nil {
+				if err := s.exportImage(id, tempdir, imageExportConfig.BuildCache); err != nil {
 					return err
 				}
 			}
@@ -68,13 +96,13 @@ func (s *TagStore) ImageExport(imageExportConfig *ImageExportConfig) error {
 				if len(repoTag) > 0 {
 					addKey(repoName, repoTag, img.ID)
 				}
-				if err := s.exportImage(img.ID, tempdir); err != nil {
+				if err := s.exportImage(img.ID, tempdir, imageExportConfig.BuildCache); err != nil {
 					return err
 				}
 
 			} else {
 				// this must be an ID that didn't get looked up just right?
-				if err := s.exportImage(name, tempdir); err != nil {
+				if err := s.exportImage(name, tempdir, imageExportConfig.BuildCache); err != nil {
 					return err
 				}
 			}
@@ -97,6 +125,11 @@ func (s *TagStore) ImageExport(imageExportConfig *ImageExportConfig) error {
 	} else {
 		logrus.Debugf("There were no repositories to write")
 	}
+	if imageExportConfig.Compression != "" {
+		if err := compressLayers(tempdir, imageExportConfig.Compression, imageExportConfig.CompressionLevel); err != nil {
+			return err
+		}
+	}
 
 	fs, err := archive.Tar(tempdir, archive.Uncompressed)
 	if err != nil {
@@ -112,7 +145,7 @@ func (s *TagStore) ImageExport(imageExportConfig *ImageExportConfig) error {
 }
 
 // FIXME: this should be a top-level function, not a class method
-func (s *TagStore) exportImage(name, tempdir string) error {
+func (s *TagStore) exportImage(name, tempdir string, buildCache bool) error {
 	for n := name; n != ""; {
 		// temporary directory
 		tmpImageDir := filepath.Join(tempdir, n)
@@ -153,6 +186,10 @@ func (s *TagStore) exportImage(name, tempdir string) error {
 			return err
 		}
 		if err := s.ImageTarLayer(n, fsTar); err != nil {
+			fsTar.Close()
+			return err
+		}
+		if err := fsTar.Close(); err != nil {
 			return err
 		}
 
@@ -161,7 +198,141 @@ func (s *TagStore) exportImage(name, tempdir string) error {
 		if err != nil {
 			return err
 		}
+		if buildCache {
+			if err := s.exportBuildCache(img, tmpImageDir, tempdir); err != nil {
+				return err
+			}
+		}
 		n = img.Parent
 	}
 	return nil
 }
+
+// exportBuildCache exports the images recorded as the build cache of img,
+// and records them in imageDir so that loading the image restores them as
+// its build cache.
+func (s *TagStore) exportBuildCache(img *image.Image, imageDir, tempdir string) error {
+	ids, err := img.GetBuildCache(s.graph.ImageRoot(img.ID))
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	if err := img.SaveBuildCache(imageDir, ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.exportImage(id, tempdir, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkExportCompression returns an error if the layers can't be compressed
+// with compression at level.
+func checkExportCompression(compression string, level int) error {
+	if compression == "" {
+		if level != 0 {
+			return fmt.Errorf("Bad parameter: a compression level needs a compression")
+		}
+		return nil
+	}
+	max, ok := exportCompressionLevels[compression]
+	if !ok {
+		return fmt.Errorf("Bad parameter: unsupported compression %q, expected gzip or zstd", compression)
+	}
+	if level < 0 || level > max {
+		return fmt.Errorf("Bad parameter: the level of the %s compression must be between 1 and %d, or 0 for the default level", compression, max)
+	}
+	if compression == "zstd" {
+		if _, err := exec.LookPath("zstd"); err != nil {
+			return fmt.Errorf("Bad parameter: the zstd compression needs the zstd command on the host of the daemon: %v", err)
+		}
+	}
+	return nil
+}
+
+// compressLayers compresses the layer.tar files of the images exported in
+// tempdir in place, in parallel, with a worker per CPU. The compressed layers
+// keep their name, their compression is detected when they're loaded.
+func compressLayers(tempdir, compression string, level int) error {
+	dirs, err := ioutil.ReadDir(tempdir)
+	if err != nil {
+		return err
+	}
+	var (
+		paths    = make(chan string)
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				err := compressLayer(path, compression, level)
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, fi := range dirs {
+		if fi.IsDir() {
+			paths <- filepath.Join(tempdir, fi.Name(), "layer.tar")
+		}
+	}
+	close(paths)
+	wg.Wait()
+	return firstErr
+}
+
+// compressLayer replaces the layer at path by its compression.
+func compressLayer(path, compression string, level int) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	switch compression {
+	case "gzip":
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		w, err := gzip.NewWriterLevel(dst, level)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, src); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	case "zstd":
+		args := []string{"-q", "-c"}
+		if level != 0 {
+			args = append(args, "-"+strconv.Itoa(level))
+		}
+		var stderr bytes.Buffer
+		cmd := exec.Command("zstd", args...)
+		cmd.Stdin = src
+		cmd.Stdout = dst
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("Error compressing the layer with zstd: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
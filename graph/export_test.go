@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/archive"
+)
+
+func TestCheckExportCompression(t *testing.T) {
+	type compressionLevel struct {
+		compression string
+		level       int
+	}
+	valids := []compressionLevel{{"", 0}, {"gzip", 0}, {"gzip", 9}}
+	if _, err := exec.LookPath("zstd"); err == nil {
+		valids = append(valids, compressionLevel{"zstd", 0}, compressionLevel{"zstd", 19})
+	}
+	for _, valid := range valids {
+		if err := checkExportCompression(valid.compression, valid.level); err != nil {
+			t.Fatalf("Expected %s at level %d to be valid, got %v", valid.compression, valid.level, err)
+		}
+	}
+	for _, invalid := range []compressionLevel{{"", 3}, {"xz", 0}, {"gzip", 10}, {"zstd", 20}, {"zstd", -1}} {
+		if err := checkExportCompression(invalid.compression, invalid.level); err == nil {
+			t.Fatalf("Expected %s at level %d to be refused", invalid.compression, invalid.level)
+		}
+	}
+}
+
+func TestCheckExportCompressionWithoutZstd(t *testing.T) {
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", "")
+	if err := checkExportCompression("zstd", 3); err == nil || !strings.Contains(err.Error(), "needs the zstd command") {
+		t.Fatalf("Expected zstd to be refused without the zstd command, got %v", err)
+	}
+	if err := checkExportCompression("gzip", 3); err != nil {
+		t.Fatalf("Expected gzip not to need any command, got %v", err)
+	}
+}
+
+func TestCompressLayers(t *testing.T) {
+	compressions := map[string]archive.Compression{"gzip": archive.Gzip}
+	if _, err := exec.LookPath("zstd"); err == nil {
+		compressions["zstd"] = archive.Zstd
+	}
+	layer := bytes.Repeat([]byte("layer data "), 1000)
+
+	for name, expected := range compressions {
+		tempdir, err := ioutil.TempDir("", "docker-export-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tempdir)
+		for _, id := range []string{"a", "b", "c"} {
+			if err := os.Mkdir(filepath.Join(tempdir, id), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(tempdir, id, "layer.tar"), layer, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ioutil.WriteFile(filepath.Join(tempdir, "repositories"), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := compressLayers(tempdir, name, 0); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"a", "b", "c"} {
+			f, err := os.Open(filepath.Join(tempdir, id, "layer.tar"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			header := make([]byte, 10)
+			if _, err := f.Read(header); err != nil {
+				t.Fatal(err)
+			}
+			if compression := archive.DetectCompression(header); compression != expected {
+				t.Fatalf("Expected the layer %s to be compressed with %s, got %s", id, name, compression.Extension())
+			}
+			if _, err := f.Seek(0, 0); err != nil {
+				t.Fatal(err)
+			}
+			r, err := archive.DecompressStream(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := ioutil.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, layer) {
+				t.Fatalf("Expected the %s layer %s to decompress to the original one", name, id)
+			}
+		}
+	}
+}
@@ -135,6 +135,12 @@ func (graph *Graph) Create(layerData archive.ArchiveReader, containerID, contain
 		img.Parent = containerImage
 		img.Container = containerID
 		img.ContainerConfig = *containerConfig
+		// the images committed from an emulated container are of the
+		// platform of their parent
+		if parent, err := graph.Get(containerImage); err == nil && parent.Architecture != "" {
+			img.Architecture = parent.Architecture
+			img.OS = parent.OS
+		}
 	}
 
 	if err := graph.Register(img, layerData); err != nil {
@@ -435,3 +441,52 @@ func (graph *Graph) ImageRoot(id string) string {
 func (graph *Graph) Driver() graphdriver.Driver {
 	return graph.driver
 }
+
+// SetBuildCache records ids as the build cache of the image id, so that
+// exporting the image with its build cache includes them.
+func (graph *Graph) SetBuildCache(id string, ids []string) error {
+	img, err := graph.Get(id)
+	if err != nil {
+		return err
+	}
+	return img.SaveBuildCache(graph.ImageRoot(img.ID), ids)
+}
+
+// BuildCache returns the IDs of the images recorded as the build cache of
+// the image id.
+func (graph *Graph) BuildCache(id string) ([]string, error) {
+	img, err := graph.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return img.GetBuildCache(graph.ImageRoot(img.ID))
+}
+
+// addBlobSource records that the layer blob of img is available in the
+// repository remoteName on the registry indexName, so later pushes of the
+// same layer to other repositories on that registry can mount it instead of
+// uploading it again.
+func (graph *Graph) addBlobSource(img *image.Image, indexName, remoteName string) error {
+	root := graph.ImageRoot(img.ID)
+	sources, err := img.GetBlobSources(root)
+	if err != nil {
+		return err
+	}
+	for _, name := range sources[indexName] {
+		if name == remoteName {
+			return nil
+		}
+	}
+	sources[indexName] = append(sources[indexName], remoteName)
+	return img.SaveBlobSources(root, sources)
+}
+
+// blobSources returns the repositories on the registry indexName which are
+// known to hold the layer blob of img.
+func (graph *Graph) blobSources(img *image.Image, indexName string) ([]string, error) {
+	sources, err := img.GetBlobSources(graph.ImageRoot(img.ID))
+	if err != nil {
+		return nil, err
+	}
+	return sources[indexName], nil
+}
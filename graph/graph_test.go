@@ -264,6 +264,29 @@ func TestByParent(t *testing.T) {
 	}
 }
 
+func TestBuildCache(t *testing.T) {
+	graph, _ := tempGraph(t)
+	defer nukeGraph(graph)
+	img := createTestImage(graph, t)
+	stage := createTestImage(graph, t)
+
+	if ids, err := graph.BuildCache(img.ID); err != nil {
+		t.Fatal(err)
+	} else if len(ids) != 0 {
+		t.Fatalf("Expected no build cache, found %v", ids)
+	}
+	if err := graph.SetBuildCache(img.ID, []string{stage.ID}); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := graph.BuildCache(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != stage.ID {
+		t.Fatalf("Expected build cache [%s], found %v", stage.ID, ids)
+	}
+}
+
 func createTestImage(graph *Graph, t *testing.T) *image.Image {
 	archive, err := fakeTar()
 	if err != nil {
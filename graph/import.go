@@ -15,6 +15,7 @@ import (
 
 type ImageImportConfig struct {
 	Changes         []string
+	Message         string // the comment of the image, instead of its source
 	InConfig        io.ReadCloser
 	OutStream       io.Writer
 	ContainerConfig *runconfig.Config
@@ -57,7 +58,11 @@ func (s *TagStore) Import(src string, repo string, tag string, imageImportConfig
 		archive = progressReader
 	}
 
-	img, err := s.graph.Create(archive, "", "", "Imported from "+src, "", nil, imageImportConfig.ContainerConfig)
+	comment := imageImportConfig.Message
+	if comment == "" {
+		comment = "Imported from " + src
+	}
+	img, err := s.graph.Create(archive, "", "", comment, "", nil, imageImportConfig.ContainerConfig)
 	if err != nil {
 		return err
 	}
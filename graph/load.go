@@ -8,16 +8,28 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/progressreader"
+	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/utils"
 )
 
 // Loads a set of images into the repository. This is the complementary of ImageExport.
 // The input stream is an uncompressed tar ball containing images and metadata.
-func (s *TagStore) Load(inTar io.ReadCloser, outStream io.Writer) error {
+func (s *TagStore) Load(inTar io.ReadCloser, imageLoadConfig *ImageLoadConfig) error {
+	sf := streamformatter.NewStreamFormatter()
+	out := imageLoadConfig.OutStream
+	if imageLoadConfig.JSON {
+		sf = streamformatter.NewJSONStreamFormatter()
+		out = &streamformatter.StdoutFormater{Writer: out, StreamFormatter: sf}
+	}
 	tmpImageDir, err := ioutil.TempDir("", "docker-import-")
 	if err != nil {
 		return err
@@ -50,40 +62,74 @@ func (s *TagStore) Load(inTar io.ReadCloser, outStream io.Writer) error {
 		return err
 	}
 
+	var loaded []string
 	for _, d := range dirs {
 		if d.IsDir() {
-			if err := s.recursiveLoad(d.Name(), tmpImageDir); err != nil {
+			if err := s.recursiveLoad(d.Name(), tmpImageDir, imageLoadConfig.OutStream, sf, imageLoadConfig.Quiet); err != nil {
 				return err
 			}
+			loaded = append(loaded, d.Name())
 		}
 	}
 
+	repositories := map[string]Repository{}
 	reposJSONFile, err := os.Open(filepath.Join(tmpImageDir, "repo", "repositories"))
 	if err != nil {
 		if !os.IsNotExist(err) {
 			return err
 		}
-		return nil
-	}
-	defer reposJSONFile.Close()
-
-	repositories := map[string]Repository{}
-	if err := json.NewDecoder(reposJSONFile).Decode(&repositories); err != nil {
-		return err
+	} else {
+		defer reposJSONFile.Close()
+		if err := json.NewDecoder(reposJSONFile).Decode(&repositories); err != nil {
+			return err
+		}
 	}
 
+	var results []types.ImageLoad
+	tagged := make(map[string]bool)
 	for imageName, tagMap := range repositories {
 		for tag, address := range tagMap {
-			if err := s.SetLoad(imageName, tag, address, true, outStream); err != nil {
+			if err := s.SetLoad(imageName, tag, address, true, out); err != nil {
 				return err
 			}
+			results = append(results, types.ImageLoad{Name: utils.ImageReference(imageName, tag), ID: address})
+			tagged[address] = true
+		}
+	}
+	// the untagged images of the archive are reported by ID, but for the
+	// parents of the other ones
+	parents := make(map[string]bool)
+	for _, id := range loaded {
+		if img, err := s.graph.Get(id); err == nil && img.Parent != "" {
+			parents[img.Parent] = true
 		}
 	}
+	for _, id := range loaded {
+		if !tagged[id] && !parents[id] {
+			results = append(results, types.ImageLoad{ID: id})
+		}
+	}
+	sort.Sort(imageLoadsByName(results))
+	for _, result := range results {
+		imageLoadConfig.OutStream.Write(sf.FormatAux(result))
+	}
 
 	return nil
 }
 
-func (s *TagStore) recursiveLoad(address, tmpImageDir string) error {
+// imageLoadsByName sorts the loaded images by name, then by ID.
+type imageLoadsByName []types.ImageLoad
+
+func (r imageLoadsByName) Len() int      { return len(r) }
+func (r imageLoadsByName) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r imageLoadsByName) Less(i, j int) bool {
+	if r[i].Name != r[j].Name {
+		return r[i].Name < r[j].Name
+	}
+	return r[i].ID < r[j].ID
+}
+
+func (s *TagStore) recursiveLoad(address, tmpImageDir string, out io.Writer, sf *streamformatter.StreamFormatter, quiet bool) error {
 	if _, err := s.LookupImage(address); err != nil {
 		logrus.Debugf("Loading %s", address)
 
@@ -123,14 +169,45 @@ func (s *TagStore) recursiveLoad(address, tmpImageDir string) error {
 
 		if img.Parent != "" {
 			if !s.graph.Exists(img.Parent) {
-				if err := s.recursiveLoad(img.Parent, tmpImageDir); err != nil {
+				if err := s.recursiveLoad(img.Parent, tmpImageDir, out, sf, quiet); err != nil {
 					return err
 				}
 			}
 		}
-		if err := s.graph.Register(img, layer); err != nil {
+		var layerData archive.ArchiveReader = layer
+		if !quiet {
+			fi, err := layer.Stat()
+			if err != nil {
+				return err
+			}
+			progressReader := progressreader.New(progressreader.Config{
+				In:        layer,
+				Out:       out,
+				Formatter: sf,
+				Size:      int(fi.Size()),
+				NewLines:  false,
+				ID:        stringid.TruncateID(img.ID),
+				Action:    "Loading layer",
+			})
+			defer progressReader.Close()
+			layerData = progressReader
+		}
+		if err := s.graph.Register(img, layerData); err != nil {
+			return err
+		}
+		if !quiet {
+			out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Load complete", nil))
+		}
+
+		buildCache, err := img.GetBuildCache(filepath.Join(tmpImageDir, "repo", address))
+		if err != nil {
 			return err
 		}
+		if len(buildCache) > 0 {
+			if err := s.graph.SetBuildCache(img.ID, buildCache); err != nil {
+				return err
+			}
+		}
 	}
 	logrus.Debugf("Completed processing %s", address)
 
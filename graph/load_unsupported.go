@@ -7,6 +7,6 @@ import (
 	"io"
 )
 
-func (s *TagStore) Load(inTar io.ReadCloser, outStream io.Writer) error {
+func (s *TagStore) Load(inTar io.ReadCloser, imageLoadConfig *ImageLoadConfig) error {
 	return fmt.Errorf("Load is not supported on this platform")
 }
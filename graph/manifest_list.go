@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/platform"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/utils"
+)
+
+// ManifestConfig is the configuration of the requests of the manifests of a
+// registry.
+type ManifestConfig struct {
+	MetaHeaders map[string][]string
+	AuthConfig  *cliconfig.AuthConfig
+}
+
+// parseManifestList returns the manifest list of the manifest payload, if
+// it's one.
+func parseManifestList(payload []byte) (*registry.ManifestList, bool) {
+	var list registry.ManifestList
+	if err := json.Unmarshal(payload, &list); err != nil || list.MediaType != registry.MediaTypeManifestList {
+		return nil, false
+	}
+	return &list, true
+}
+
+// selectManifest returns the manifest of the list for the platform p, or for
+// the platform of the host if p is nil.
+func selectManifest(list *registry.ManifestList, p *platform.Platform, ref string) (*registry.ManifestDescriptor, error) {
+	if p == nil {
+		p = platform.Host()
+	}
+	for i := range list.Manifests {
+		d := &list.Manifests[i]
+		if d.Platform != nil && p.Match(d.Platform.OS, d.Platform.Architecture) && (p.Variant == "" || p.Variant == d.Platform.Variant) {
+			return d, nil
+		}
+	}
+	return nil, platformError(fmt.Sprintf("manifest list %s has no image of platform %s", ref, p))
+}
+
+// v2Session opens a session with the v2 registry of the repository name.
+func (s *TagStore) v2Session(name string, config *ManifestConfig, readOnly bool) (*registry.Session, *registry.Endpoint, *registry.RepositoryInfo, *registry.RequestAuthorization, error) {
+	repoInfo, err := s.registryService.ResolveRepository(name)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := validateRepoName(repoInfo.LocalName); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	endpoint, err := repoInfo.GetEndpoint()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	r, err := registry.NewSession(config.AuthConfig, registry.HTTPRequestFactory(config.MetaHeaders), endpoint, true)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	ep, err := r.V2RegistryEndpoint(repoInfo.Index)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("The registry of %s has no v2 API: %v", repoInfo.CanonicalName, err)
+	}
+	auth, err := r.GetV2Authorization(ep, repoInfo.RemoteName, readOnly)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("error getting authorization: %s", err)
+	}
+	return r, ep, repoInfo, auth, nil
+}
+
+// ManifestInspect returns the descriptor of the manifest of the image name
+// in its registry, with the manifests of the platforms if it's a manifest
+// list.
+func (s *TagStore) ManifestInspect(name string, config *ManifestConfig) (*types.DistributionInspect, error) {
+	remote, tag := parsers.ParseRepositoryTag(name)
+	if tag == "" {
+		tag = DEFAULTTAG
+	}
+	r, ep, repoInfo, auth, err := s.v2Session(remote, config, true)
+	if err != nil {
+		return nil, err
+	}
+	manifestBytes, manifestDigest, err := r.GetV2ImageManifest(ep, repoInfo.RemoteName, tag, auth)
+	if err != nil {
+		if err == registry.ErrDoesNotExist {
+			return nil, fmt.Errorf("No such manifest: %s", utils.ImageReference(repoInfo.CanonicalName, tag))
+		}
+		return nil, err
+	}
+
+	if list, ok := parseManifestList(manifestBytes); ok {
+		dgst, err := digest.FromBytes(manifestBytes)
+		if err != nil {
+			return nil, err
+		}
+		return &types.DistributionInspect{
+			Descriptor: registry.ManifestDescriptor{
+				MediaType: registry.MediaTypeManifestList,
+				Size:      int64(len(manifestBytes)),
+				Digest:    dgst.String(),
+			},
+			Manifests: list.Manifests,
+		}, nil
+	}
+
+	manifest, _, err := s.loadManifest(manifestBytes, manifestDigest, tag)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying manifest: %s", err)
+	}
+	if err := checkValidManifest(manifest); err != nil {
+		return nil, err
+	}
+	if manifestDigest == "" {
+		return nil, fmt.Errorf("The registry returned no digest for %s", utils.ImageReference(repoInfo.CanonicalName, tag))
+	}
+	img, err := image.NewImgJSON([]byte(manifest.History[0].V1Compatibility))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json: %s", err)
+	}
+	// old images have no platform, they're linux/amd64 ones
+	p := &registry.ManifestPlatform{OS: img.OS, Architecture: img.Architecture}
+	if p.OS == "" {
+		p.OS = "linux"
+	}
+	if p.Architecture == "" {
+		p.Architecture = "amd64"
+	}
+	return &types.DistributionInspect{
+		Descriptor: registry.ManifestDescriptor{
+			MediaType: registry.MediaTypeSignedManifest,
+			Size:      int64(len(manifestBytes)),
+			Digest:    manifestDigest,
+			Platform:  p,
+		},
+	}, nil
+}
+
+// ManifestPush pushes the manifest list with the tagged reference name. The
+// manifests it points at must be in the repository of name.
+func (s *TagStore) ManifestPush(name string, list *registry.ManifestList, config *ManifestConfig) (string, error) {
+	remote, tag := parsers.ParseRepositoryTag(name)
+	if tag == "" {
+		tag = DEFAULTTAG
+	}
+	if utils.DigestReference(tag) {
+		return "", fmt.Errorf("Bad parameter: the manifest list %s must be pushed with a tag", name)
+	}
+	if len(list.Manifests) == 0 {
+		return "", fmt.Errorf("Bad parameter: the manifest list %s has no manifest", name)
+	}
+	for _, d := range list.Manifests {
+		if _, err := digest.ParseDigest(d.Digest); err != nil {
+			return "", fmt.Errorf("Bad parameter: invalid digest %q in manifest list %s", d.Digest, name)
+		}
+		if d.Platform == nil || d.Platform.OS == "" || d.Platform.Architecture == "" {
+			return "", fmt.Errorf("Bad parameter: the manifest %s in manifest list %s has no platform", d.Digest, name)
+		}
+	}
+	list.SchemaVersion = 2
+	list.MediaType = registry.MediaTypeManifestList
+	data, err := json.MarshalIndent(list, "", "   ")
+	if err != nil {
+		return "", err
+	}
+
+	r, ep, repoInfo, auth, err := s.v2Session(remote, config, false)
+	if err != nil {
+		return "", err
+	}
+	dgst, err := r.PutV2ManifestList(ep, repoInfo.RemoteName, tag, data, auth)
+	if err != nil {
+		return "", fmt.Errorf("Error pushing manifest list %s: %s", utils.ImageReference(repoInfo.CanonicalName, tag), err)
+	}
+	s.eventsService.Log("push", utils.ImageReference(repoInfo.LocalName, tag), "")
+	return dgst.String(), nil
+}
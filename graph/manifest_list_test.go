@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/docker/docker/pkg/platform"
+	"github.com/docker/docker/registry"
+)
+
+func TestParseManifestList(t *testing.T) {
+	payload := []byte(`{
+   "schemaVersion": 2,
+   "mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+   "manifests": [
+      {
+         "mediaType": "application/vnd.docker.distribution.manifest.v1+prettyjws",
+         "size": 2094,
+         "digest": "sha256:b8dd6b6e2ec5dc89f1ad2d31aa5e8fc0fae8a4a39b4b67eb3fcb2d2ab47dc6e2",
+         "platform": {"architecture": "arm64", "os": "linux"}
+      }
+   ]
+}`)
+	list, ok := parseManifestList(payload)
+	if !ok {
+		t.Fatal("Expected a manifest list")
+	}
+	if len(list.Manifests) != 1 || list.Manifests[0].Platform.Architecture != "arm64" {
+		t.Fatalf("Unexpected manifests %+v", list.Manifests)
+	}
+	if _, ok := parseManifestList([]byte(`{"schemaVersion": 1, "name": "busybox"}`)); ok {
+		t.Fatal("Expected a signed manifest not to be a manifest list")
+	}
+}
+
+func TestSelectManifest(t *testing.T) {
+	list := &registry.ManifestList{
+		Manifests: []registry.ManifestDescriptor{
+			{Digest: "sha256:1", Platform: &registry.ManifestPlatform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:2", Platform: &registry.ManifestPlatform{OS: "linux", Architecture: "arm", Variant: "v6"}},
+			{Digest: "sha256:3", Platform: &registry.ManifestPlatform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+		},
+	}
+	for p, expected := range map[string]string{
+		"linux/x86_64": "sha256:1",
+		"linux/arm":    "sha256:2",
+		"linux/arm/v7": "sha256:3",
+	} {
+		parsed, err := platform.Parse(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := selectManifest(list, parsed, "test:latest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Digest != expected {
+			t.Fatalf("Expected %s for %s, got %s", expected, p, d.Digest)
+		}
+	}
+
+	_, err := selectManifest(list, &platform.Platform{OS: "linux", Architecture: "s390x"}, "test:latest")
+	if _, ok := err.(platformError); !ok {
+		t.Fatalf("Expected a platform error, got %v", err)
+	}
+}
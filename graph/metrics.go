@@ -0,0 +1,32 @@
+package graph
+
+import (
+	"time"
+
+	"github.com/docker/docker/pkg/metrics"
+)
+
+// pullBuckets are the buckets of the durations of the pulls in seconds, from
+// 1s to 30m.
+var pullBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+var (
+	imagePulls = metrics.NewCounter("engine_daemon_image_pulls_total",
+		"The number of image pulls, by result.", "result")
+	imagePullDuration = metrics.NewHistogram("engine_daemon_image_pull_duration_seconds",
+		"The duration of the image pulls in seconds.", pullBuckets)
+)
+
+func init() {
+	metrics.MustRegister(imagePulls, imagePullDuration)
+}
+
+// observePull records a pull started at start, which failed if err isn't nil.
+func observePull(start time.Time, err error) {
+	imagePullDuration.ObserveSince(start)
+	if err != nil {
+		imagePulls.Inc("failure")
+		return
+	}
+	imagePulls.Inc("success")
+}
@@ -14,7 +14,9 @@ import (
 	"github.com/docker/distribution/digest"
 	"github.com/docker/docker/cliconfig"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/platform"
 	"github.com/docker/docker/pkg/progressreader"
+	"github.com/docker/docker/pkg/ratelimit"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/registry"
@@ -25,9 +27,46 @@ type ImagePullConfig struct {
 	MetaHeaders map[string][]string
 	AuthConfig  *cliconfig.AuthConfig
 	OutStream   io.Writer
+	Platform    *platform.Platform // the platform the pulled images must be of, any if nil
 }
 
-func (s *TagStore) Pull(image string, tag string, imagePullConfig *ImagePullConfig) error {
+// platformError is the error of a pulled image not of the requested platform,
+// not retried with the v1 registry.
+type platformError string
+
+func (e platformError) Error() string {
+	return string(e)
+}
+
+// checkPlatform returns an error if the image img of the reference ref isn't
+// of the platform p.
+func checkPlatform(p *platform.Platform, ref string, img *image.Image) error {
+	if p == nil || p.Match(img.OS, img.Architecture) {
+		return nil
+	}
+	imgOS, imgArch := img.OS, img.Architecture
+	if imgOS == "" {
+		imgOS = "linux"
+	}
+	if imgArch == "" {
+		imgArch = "amd64"
+	}
+	return platformError(fmt.Sprintf("image %s is a %s/%s image, not a %s one", ref, imgOS, imgArch, p))
+}
+
+// warnPlatform warns on out if the host can't run the image img of the
+// reference ref, pulled for no platform in particular.
+func warnPlatform(out io.Writer, sf *streamformatter.StreamFormatter, ref string, img *image.Image) {
+	if err := platform.Check(img.OS, img.Architecture); err != nil {
+		out.Write(sf.FormatStatus("", "WARNING: %s won't run on this host: %v", ref, err))
+	}
+}
+
+func (s *TagStore) Pull(image string, tag string, imagePullConfig *ImagePullConfig) (err error) {
+	defer func(start time.Time) {
+		observePull(start, err)
+	}(time.Now())
+
 	var (
 		sf = streamformatter.NewJSONStreamFormatter()
 	)
@@ -76,9 +115,11 @@ func (s *TagStore) Pull(image string, tag string, imagePullConfig *ImagePullConf
 		}
 
 		logrus.Debugf("pulling v2 repository with local name %q", repoInfo.LocalName)
-		if err := s.pullV2Repository(r, imagePullConfig.OutStream, repoInfo, tag, sf); err == nil {
+		if err := s.pullV2Repository(r, imagePullConfig.OutStream, repoInfo, tag, sf, imagePullConfig.Platform); err == nil {
 			s.eventsService.Log("pull", logName, "")
 			return nil
+		} else if _, ok := err.(platformError); ok {
+			return err
 		} else if err != registry.ErrDoesNotExist && err != ErrV2RegistryUnavailable {
 			logrus.Errorf("Error from V2 registry: %s", err)
 		}
@@ -87,7 +128,7 @@ func (s *TagStore) Pull(image string, tag string, imagePullConfig *ImagePullConf
 	}
 
 	logrus.Debugf("pulling v1 repository with local name %q", repoInfo.LocalName)
-	if err = s.pullRepository(r, imagePullConfig.OutStream, repoInfo, tag, sf); err != nil {
+	if err = s.pullRepository(r, imagePullConfig.OutStream, repoInfo, tag, sf, imagePullConfig.Platform); err != nil {
 		return err
 	}
 
@@ -96,7 +137,7 @@ func (s *TagStore) Pull(image string, tag string, imagePullConfig *ImagePullConf
 	return nil
 }
 
-func (s *TagStore) pullRepository(r *registry.Session, out io.Writer, repoInfo *registry.RepositoryInfo, askedTag string, sf *streamformatter.StreamFormatter) error {
+func (s *TagStore) pullRepository(r *registry.Session, out io.Writer, repoInfo *registry.RepositoryInfo, askedTag string, sf *streamformatter.StreamFormatter, p *platform.Platform) error {
 	out.Write(sf.FormatStatus("", "Pulling repository %s", repoInfo.CanonicalName))
 
 	repoData, err := r.GetRepositoryData(repoInfo.RemoteName)
@@ -222,6 +263,25 @@ func (s *TagStore) pullRepository(r *registry.Session, out io.Writer, repoInfo *
 		return lastError
 	}
 
+	// the registries serve an image per tag, of a platform the images must
+	// match before they're tagged
+	for tag, id := range tagsList {
+		if askedTag != "" && tag != askedTag {
+			continue
+		}
+		img, err := s.graph.Get(id)
+		if err != nil {
+			return err
+		}
+		ref := utils.ImageReference(repoInfo.CanonicalName, tag)
+		if err := checkPlatform(p, ref, img); err != nil {
+			return err
+		}
+		if p == nil {
+			warnPlatform(out, sf, ref, img)
+		}
+	}
+
 	for tag, id := range tagsList {
 		if askedTag != "" && tag != askedTag {
 			continue
@@ -313,7 +373,7 @@ func (s *TagStore) pullImage(r *registry.Session, out io.Writer, imgID, endpoint
 
 				err = s.graph.Register(img,
 					progressreader.New(progressreader.Config{
-						In:        layer,
+						In:        ratelimit.NewReadCloser(layer, s.downloadLimiter),
 						Out:       out,
 						Formatter: sf,
 						Size:      imgSize,
@@ -356,7 +416,7 @@ type downloadInfo struct {
 	err        chan error
 }
 
-func (s *TagStore) pullV2Repository(r *registry.Session, out io.Writer, repoInfo *registry.RepositoryInfo, tag string, sf *streamformatter.StreamFormatter) error {
+func (s *TagStore) pullV2Repository(r *registry.Session, out io.Writer, repoInfo *registry.RepositoryInfo, tag string, sf *streamformatter.StreamFormatter, p *platform.Platform) error {
 	endpoint, err := r.V2RegistryEndpoint(repoInfo.Index)
 	if err != nil {
 		if repoInfo.Index.Official {
@@ -380,14 +440,14 @@ func (s *TagStore) pullV2Repository(r *registry.Session, out io.Writer, repoInfo
 			return registry.ErrDoesNotExist
 		}
 		for _, t := range tags {
-			if downloaded, err := s.pullV2Tag(r, out, endpoint, repoInfo, t, sf, auth); err != nil {
+			if downloaded, err := s.pullV2Tag(r, out, endpoint, repoInfo, t, sf, auth, p); err != nil {
 				return err
 			} else if downloaded {
 				layersDownloaded = true
 			}
 		}
 	} else {
-		if downloaded, err := s.pullV2Tag(r, out, endpoint, repoInfo, tag, sf, auth); err != nil {
+		if downloaded, err := s.pullV2Tag(r, out, endpoint, repoInfo, tag, sf, auth, p); err != nil {
 			return err
 		} else if downloaded {
 			layersDownloaded = true
@@ -402,7 +462,7 @@ func (s *TagStore) pullV2Repository(r *registry.Session, out io.Writer, repoInfo
 	return nil
 }
 
-func (s *TagStore) pullV2Tag(r *registry.Session, out io.Writer, endpoint *registry.Endpoint, repoInfo *registry.RepositoryInfo, tag string, sf *streamformatter.StreamFormatter, auth *registry.RequestAuthorization) (bool, error) {
+func (s *TagStore) pullV2Tag(r *registry.Session, out io.Writer, endpoint *registry.Endpoint, repoInfo *registry.RepositoryInfo, tag string, sf *streamformatter.StreamFormatter, auth *registry.RequestAuthorization, p *platform.Platform) (bool, error) {
 	logrus.Debugf("Pulling tag from V2 registry: %q", tag)
 
 	manifestBytes, manifestDigest, err := r.GetV2ImageManifest(endpoint, repoInfo.RemoteName, tag, auth)
@@ -410,9 +470,38 @@ func (s *TagStore) pullV2Tag(r *registry.Session, out io.Writer, endpoint *regis
 		return false, err
 	}
 
+	// a manifest list points at the manifests of the platforms, the one of
+	// the requested platform, or of the host, is pulled. The digest of the
+	// pull is the one of the list.
+	var (
+		manifestRef = tag
+		list        *registry.ManifestList
+		pullDigest  = manifestDigest
+	)
+	if l, ok := parseManifestList(manifestBytes); ok {
+		list = l
+		listDigest, err := digest.FromBytes(manifestBytes)
+		if err != nil {
+			return false, err
+		}
+		if utils.DigestReference(tag) && tag != listDigest.String() {
+			return false, fmt.Errorf("mismatching manifest list digest: got %q, expected %q", listDigest, tag)
+		}
+		d, err := selectManifest(list, p, utils.ImageReference(repoInfo.CanonicalName, tag))
+		if err != nil {
+			return false, err
+		}
+		logrus.Debugf("Pulling manifest %s of platform %s/%s from manifest list %s", d.Digest, d.Platform.OS, d.Platform.Architecture, listDigest)
+		if manifestBytes, manifestDigest, err = r.GetV2ImageManifest(endpoint, repoInfo.RemoteName, d.Digest, auth); err != nil {
+			return false, err
+		}
+		manifestRef = d.Digest
+		pullDigest = listDigest.String()
+	}
+
 	// loadManifest ensures that the manifest payload has the expected digest
 	// if the tag is a digest reference.
-	manifest, verified, err := s.loadManifest(manifestBytes, manifestDigest, tag)
+	manifest, verified, err := s.loadManifest(manifestBytes, manifestDigest, manifestRef)
 	if err != nil {
 		return false, fmt.Errorf("error verifying manifest: %s", err)
 	}
@@ -421,6 +510,22 @@ func (s *TagStore) pullV2Tag(r *registry.Session, out io.Writer, endpoint *regis
 		return false, err
 	}
 
+	// the platform of the image is the one of its top layer, checked before
+	// any layer is downloaded, unless it was selected in a manifest list
+	if list == nil {
+		img, err := image.NewImgJSON([]byte(manifest.History[0].V1Compatibility))
+		if err != nil {
+			return false, fmt.Errorf("failed to parse json: %s", err)
+		}
+		ref := utils.ImageReference(repoInfo.CanonicalName, tag)
+		if err := checkPlatform(p, ref, img); err != nil {
+			return false, err
+		}
+		if p == nil {
+			warnPlatform(out, sf, ref, img)
+		}
+	}
+
 	if verified {
 		logrus.Printf("Image manifest for %s has been verified", utils.ImageReference(repoInfo.CanonicalName, tag))
 	}
@@ -440,18 +545,18 @@ func (s *TagStore) pullV2Tag(r *registry.Session, out io.Writer, endpoint *regis
 		}
 		downloads[i].img = img
 
-		// Check if exists
-		if s.graph.Exists(img.ID) {
-			logrus.Debugf("Image already exists: %s", img.ID)
-			continue
-		}
-
 		dgst, err := digest.ParseDigest(sumStr)
 		if err != nil {
 			return false, err
 		}
 		downloads[i].digest = dgst
 
+		// Check if exists
+		if s.graph.Exists(img.ID) {
+			logrus.Debugf("Image already exists: %s", img.ID)
+			continue
+		}
+
 		out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Pulling fs layer", nil))
 
 		downloadFunc := func(di *downloadInfo) error {
@@ -484,7 +589,7 @@ func (s *TagStore) pullV2Tag(r *registry.Session, out io.Writer, endpoint *regis
 				}
 
 				if _, err := io.Copy(tmpFile, progressreader.New(progressreader.Config{
-					In:        ioutil.NopCloser(io.TeeReader(r, verifier)),
+					In:        ratelimit.NewReadCloser(ioutil.NopCloser(io.TeeReader(r, verifier)), s.downloadLimiter),
 					Out:       out,
 					Formatter: sf,
 					Size:      int(l),
@@ -576,8 +681,14 @@ func (s *TagStore) pullV2Tag(r *registry.Session, out io.Writer, endpoint *regis
 		out.Write(sf.FormatStatus(utils.ImageReference(repoInfo.CanonicalName, tag), "The image you are pulling has been verified. Important: image verification is a tech preview feature and should not be relied on to provide security."))
 	}
 
-	if manifestDigest != "" {
-		out.Write(sf.FormatStatus("", "Digest: %s", manifestDigest))
+	for _, d := range downloads {
+		if err := s.recordV2Blob(d.img, d.digest, repoInfo); err != nil {
+			logrus.Debugf("Unable to record blob source for %s: %s", d.img.ID, err)
+		}
+	}
+
+	if pullDigest != "" {
+		out.Write(sf.FormatStatus("", "Digest: %s", pullDigest))
 	}
 
 	if utils.DigestReference(tag) {
@@ -593,3 +704,26 @@ func (s *TagStore) pullV2Tag(r *registry.Session, out io.Writer, endpoint *regis
 
 	return tagUpdated, nil
 }
+
+// recordV2Blob caches the blob digest of a pulled layer as its checksum and
+// notes the repository it was pulled from, so that pushing the layer to
+// another repository on the same registry can mount it.
+func (s *TagStore) recordV2Blob(img *image.Image, dgst digest.Digest, repoInfo *registry.RepositoryInfo) error {
+	root := s.graph.ImageRoot(img.ID)
+	checksum, err := img.GetCheckSum(root)
+	if err != nil {
+		return err
+	}
+	if checksum == "" {
+		if err := img.SaveCheckSum(root, dgst.String()); err != nil {
+			return err
+		}
+		checksum = dgst.String()
+	}
+	if checksum != dgst.String() {
+		// The local layer is known under a different blob, e.g. one
+		// compressed differently, the sources recorded are for that one.
+		return nil
+	}
+	return s.graph.addBlobSource(img, repoInfo.Index.Name, repoInfo.RemoteName)
+}
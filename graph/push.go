@@ -16,6 +16,7 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/progressreader"
+	"github.com/docker/docker/pkg/ratelimit"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/registry"
@@ -277,7 +278,7 @@ func (s *TagStore) pushImage(r *registry.Session, out io.Writer, imgID, ep strin
 
 	checksum, checksumPayload, err := r.PushImageLayerRegistry(imgData.ID,
 		progressreader.New(progressreader.Config{
-			In:        layerData,
+			In:        ratelimit.NewReadCloser(layerData, s.uploadLimiter),
 			Out:       out,
 			Formatter: sf,
 			Size:      int(layerData.Size),
@@ -381,33 +382,45 @@ func (s *TagStore) pushV2Repository(r *registry.Session, localRepo Repository, o
 				return fmt.Errorf("error getting image checksum: %s", err)
 			}
 
-			var exists bool
+			var exists, mounted bool
 			if len(checksum) > 0 {
 				dgst, err := digest.ParseDigest(checksum)
 				if err != nil {
 					return fmt.Errorf("Invalid checksum %s: %s", checksum, err)
 				}
 
-				// Call mount blob
 				exists, err = r.HeadV2ImageBlob(endpoint, repoInfo.RemoteName, dgst, auth)
 				if err != nil {
 					out.Write(sf.FormatProgress(stringid.TruncateID(layer.ID), "Image push failed", nil))
 					return err
 				}
+				if !exists {
+					if mounted, err = s.mountV2Blob(r, endpoint, repoInfo, layer, dgst, sf, out); err != nil {
+						return err
+					}
+				}
 			}
-			if !exists {
+			if !exists && !mounted {
 				if cs, err := s.pushV2Image(r, layer, endpoint, repoInfo.RemoteName, sf, out, auth); err != nil {
 					return err
 				} else if cs != checksum {
-					// Cache new checksum
-					if err := layer.SaveCheckSum(s.graph.ImageRoot(layer.ID), cs); err != nil {
+					// Cache new checksum, the repositories recorded for the
+					// old one do not necessarily hold this blob.
+					root := s.graph.ImageRoot(layer.ID)
+					if err := layer.SaveCheckSum(root, cs); err != nil {
+						return err
+					}
+					if err := layer.SaveBlobSources(root, map[string][]string{}); err != nil {
 						return err
 					}
 					checksum = cs
 				}
-			} else {
+			} else if exists {
 				out.Write(sf.FormatProgress(stringid.TruncateID(layer.ID), "Image already exists", nil))
 			}
+			if err := s.graph.addBlobSource(layer, repoInfo.Index.Name, repoInfo.RemoteName); err != nil {
+				logrus.Debugf("Unable to record blob source for %s: %s", layer.ID, err)
+			}
 			m.FSLayers[i] = &registry.FSLayer{BlobSum: checksum}
 			m.History[i] = &registry.ManifestHistory{V1Compatibility: string(jsonData)}
 		}
@@ -447,6 +460,38 @@ func (s *TagStore) pushV2Repository(r *registry.Session, localRepo Repository, o
 	return nil
 }
 
+// mountV2Blob tries to link the layer blob dgst into the repository being
+// pushed from another repository on the same registry which, according to
+// earlier pulls and pushes, already holds it. It reports whether the blob
+// was mounted; any failure to mount just means the blob has to be uploaded.
+func (s *TagStore) mountV2Blob(r *registry.Session, endpoint *registry.Endpoint, repoInfo *registry.RepositoryInfo, img *image.Image, dgst digest.Digest, sf *streamformatter.StreamFormatter, out io.Writer) (bool, error) {
+	sources, err := s.graph.blobSources(img, repoInfo.Index.Name)
+	if err != nil {
+		return false, err
+	}
+	for _, from := range sources {
+		if from == repoInfo.RemoteName {
+			continue
+		}
+		auth, err := r.GetV2Authorization(endpoint, repoInfo.RemoteName, false)
+		if err != nil {
+			return false, fmt.Errorf("error getting authorization: %s", err)
+		}
+		auth.AddScope("repository", from, []string{"pull"})
+
+		mounted, err := r.MountV2ImageBlob(endpoint, repoInfo.RemoteName, from, dgst, auth)
+		if err != nil {
+			logrus.Debugf("Unable to mount layer %s from %s: %s", img.ID, from, err)
+			continue
+		}
+		if mounted {
+			out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), fmt.Sprintf("Mounted from %s", from), nil))
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // PushV2Image pushes the image content to the v2 registry, first buffering the contents to disk
 func (s *TagStore) pushV2Image(r *registry.Session, img *image.Image, endpoint *registry.Endpoint, imageName string, sf *streamformatter.StreamFormatter, out io.Writer, auth *registry.RequestAuthorization) (string, error) {
 	out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Buffering to Disk", nil))
@@ -477,7 +522,7 @@ func (s *TagStore) pushV2Image(r *registry.Session, img *image.Image, endpoint *
 
 	if err := r.PutV2ImageBlob(endpoint, imageName, dgst,
 		progressreader.New(progressreader.Config{
-			In:        tf,
+			In:        ratelimit.NewReadCloser(tf, s.uploadLimiter),
 			Out:       out,
 			Formatter: sf,
 			Size:      int(size),
@@ -17,6 +17,7 @@ import (
 	"github.com/docker/docker/graph/tags"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/ratelimit"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/trust"
@@ -44,6 +45,9 @@ type TagStore struct {
 	registryService *registry.Service
 	eventsService   *events.Events
 	trustService    *trust.TrustStore
+	// the limits of the bandwidths of all the layers pulled and pushed
+	downloadLimiter *ratelimit.Limiter
+	uploadLimiter   *ratelimit.Limiter
 }
 
 type Repository map[string]string
@@ -72,6 +76,10 @@ type TagStoreConfig struct {
 	Registry *registry.Service
 	Events   *events.Events
 	Trust    *trust.TrustStore
+	// the bandwidths of all the layers pulled and pushed, in bytes per
+	// second, no limit when 0
+	DownloadBandwidth int64
+	UploadBandwidth   int64
 }
 
 func NewTagStore(path string, cfg *TagStoreConfig) (*TagStore, error) {
@@ -90,6 +98,8 @@ func NewTagStore(path string, cfg *TagStoreConfig) (*TagStore, error) {
 		registryService: cfg.Registry,
 		eventsService:   cfg.Events,
 		trustService:    cfg.Trust,
+		downloadLimiter: ratelimit.NewLimiter(cfg.DownloadBandwidth),
+		uploadLimiter:   ratelimit.NewLimiter(cfg.UploadBandwidth),
 	}
 	// Load the json file if it exists, otherwise create it.
 	if err := store.reload(); os.IsNotExist(err) {
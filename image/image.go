@@ -133,6 +133,66 @@ func (img *Image) GetCheckSum(root string) (string, error) {
 	return string(cs), err
 }
 
+// SaveBlobSources stores, keyed by registry index name, the remote
+// repositories known to already hold the blob identified by the image's
+// checksum.
+func (img *Image) SaveBlobSources(root string, sources map[string][]string) error {
+	data, err := json.Marshal(sources)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "blobsources"), data, 0600); err != nil {
+		return fmt.Errorf("Error storing blob sources in %s/blobsources: %s", root, err)
+	}
+	return nil
+}
+
+// GetBlobSources returns the remote repositories recorded by SaveBlobSources.
+func (img *Image) GetBlobSources(root string) (map[string][]string, error) {
+	sources := make(map[string][]string)
+	data, err := ioutil.ReadFile(filepath.Join(root, "blobsources"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sources, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// SaveBuildCache stores the IDs of the images, other than its parents, that
+// the build which produced the image used as build cache, such as the images
+// of the earlier stages of a multi-stage build.
+func (img *Image) SaveBuildCache(root string, ids []string) error {
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "buildcache"), data, 0600); err != nil {
+		return fmt.Errorf("Error storing build cache in %s/buildcache: %s", root, err)
+	}
+	return nil
+}
+
+// GetBuildCache returns the image IDs recorded by SaveBuildCache.
+func (img *Image) GetBuildCache(root string) ([]string, error) {
+	var ids []string
+	data, err := ioutil.ReadFile(filepath.Join(root, "buildcache"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 func jsonPath(root string) string {
 	return filepath.Join(root, "json")
 }
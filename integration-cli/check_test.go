@@ -35,6 +35,8 @@ type DockerSuite struct {
 func (s *DockerSuite) TearDownTest(c *check.C) {
 	deleteAllContainers()
 	deleteAllImages()
+	deleteAllVolumes()
+	deleteAllSecrets()
 	s.TimerSuite.TearDownTest(c)
 }
 
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"code.google.com/p/go.net/websocket"
 )
 
+// the streams are written as is to the text frames before the API version 1.19
 func (s *DockerSuite) TestGetContainersAttachWebsocket(c *check.C) {
 	runCmd := exec.Command(dockerBinary, "run", "-dit", "busybox", "cat")
 	out, _, err := runCommandWithOutput(runCmd)
@@ -25,7 +28,7 @@ func (s *DockerSuite) TestGetContainersAttachWebsocket(c *check.C) {
 
 	cleanedContainerID := strings.TrimSpace(out)
 	config, err := websocket.NewConfig(
-		"/containers/"+cleanedContainerID+"/attach/ws?stream=1&stdin=1&stdout=1&stderr=1",
+		"/v1.18/containers/"+cleanedContainerID+"/attach/ws?stream=1&stdin=1&stdout=1&stderr=1",
 		"http://localhost",
 	)
 	if err != nil {
@@ -77,3 +80,109 @@ func (s *DockerSuite) TestGetContainersAttachWebsocket(c *check.C) {
 		c.Fatal("Expected output on websocket to match input")
 	}
 }
+
+// attachWebsocket attaches a websocket to the container id with the query.
+func attachWebsocket(c *check.C, id, query string) *websocket.Conn {
+	rwc, err := sockConn(time.Duration(10 * time.Second))
+	if err != nil {
+		c.Fatal(err)
+	}
+	config, err := websocket.NewConfig("/containers/"+id+"/attach/ws?"+query, "http://localhost")
+	if err != nil {
+		c.Fatal(err)
+	}
+	ws, err := websocket.NewClient(config, rwc)
+	if err != nil {
+		c.Fatal(err)
+	}
+	return ws
+}
+
+// readWebsocketUntil reads the binary frames of the stream from ws until
+// their output contains expected.
+func readWebsocketUntil(c *check.C, ws *websocket.Conn, stream byte, expected string) {
+	done := make(chan error)
+	go func() {
+		var out []byte
+		for !bytes.Contains(out, []byte(expected)) {
+			var frame []byte
+			if err := websocket.Message.Receive(ws, &frame); err != nil {
+				done <- fmt.Errorf("%v, got %q", err, out)
+				return
+			}
+			if len(frame) == 0 || frame[0] != stream {
+				done <- fmt.Errorf("expected a frame of the stream %d, got %q", stream, frame)
+				return
+			}
+			out = append(out, frame[1:]...)
+		}
+		close(done)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			c.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		c.Fatalf("Timeout reading %q from ws", expected)
+	}
+}
+
+func (s *DockerSuite) TestGetContainersAttachWebsocketStreams(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-di", "busybox", "sh", "-c", "read line; echo $line; echo $line >&2; read line")
+	id := strings.TrimSpace(out)
+
+	ws := attachWebsocket(c, id, "stream=1&stdin=1&stdout=1&stderr=1")
+	defer ws.Close()
+
+	if err := websocket.Message.Send(ws, []byte("hello\n")); err != nil {
+		c.Fatal(err)
+	}
+	var frames [][]byte
+	for len(frames) < 2 {
+		var frame []byte
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := websocket.Message.Receive(ws, &frame); err != nil {
+			c.Fatal(err)
+		}
+		frames = append(frames, frame)
+	}
+	var stdout, stderr bool
+	for _, frame := range frames {
+		switch {
+		case bytes.Equal(frame, []byte("\x01hello\n")):
+			stdout = true
+		case bytes.Equal(frame, []byte("\x02hello\n")):
+			stderr = true
+		}
+	}
+	if !stdout || !stderr {
+		c.Fatalf("Expected the output on stdout and stderr, got %q", frames)
+	}
+
+	// the websocket is closed once the container exited
+	if err := websocket.Message.Send(ws, []byte("bye\n")); err != nil {
+		c.Fatal(err)
+	}
+	var frame []byte
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := websocket.Message.Receive(ws, &frame); err != io.EOF {
+		c.Fatalf("Expected the websocket to be closed, got %v %q", err, frame)
+	}
+}
+
+func (s *DockerSuite) TestGetContainersAttachWebsocketResize(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-dit", "busybox", "sh")
+	id := strings.TrimSpace(out)
+
+	ws := attachWebsocket(c, id, "stream=1&stdin=1&stdout=1&stderr=1")
+	defer ws.Close()
+
+	if err := websocket.Message.Send(ws, `{"Type": "resize", "Height": 42, "Width": 123}`); err != nil {
+		c.Fatal(err)
+	}
+	if err := websocket.Message.Send(ws, []byte("stty size\n")); err != nil {
+		c.Fatal(err)
+	}
+	readWebsocketUntil(c, ws, 1, "42 123")
+}
@@ -3,10 +3,12 @@ package main
 import (
 	"archive/tar"
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"os"
 	"os/exec"
 	"strings"
@@ -1306,3 +1308,111 @@ func (s *DockerSuite) TestPostContainerStop(c *check.C) {
 		c.Fatal(err)
 	}
 }
+
+func (s *DockerSuite) TestContainerApiArchive(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-d", "busybox", "sh", "-c", "echo hello > /file")
+	cID := strings.TrimSpace(out)
+	dockerCmd(c, "wait", cID)
+
+	res, body, err := sockRequestRaw("HEAD", "/containers/"+cID+"/archive?path=/file", nil, "")
+	c.Assert(err, check.IsNil)
+	body.Close()
+	c.Assert(res.StatusCode, check.Equals, http.StatusOK)
+
+	statJSON, err := base64.StdEncoding.DecodeString(res.Header.Get("X-Docker-Container-Path-Stat"))
+	c.Assert(err, check.IsNil)
+	var stat types.ContainerPathStat
+	c.Assert(json.Unmarshal(statJSON, &stat), check.IsNil)
+	c.Assert(stat.Name, check.Equals, "file")
+	c.Assert(stat.Size, check.Equals, int64(len("hello\n")))
+
+	res, body, err = sockRequestRaw("GET", "/containers/"+cID+"/archive?path=/file", nil, "")
+	c.Assert(err, check.IsNil)
+	defer body.Close()
+	c.Assert(res.StatusCode, check.Equals, http.StatusOK)
+	tr := tar.NewReader(body)
+	hdr, err := tr.Next()
+	c.Assert(err, check.IsNil)
+	c.Assert(hdr.Name, check.Equals, "file")
+
+	res, body, err = sockRequestRaw("HEAD", "/containers/"+cID+"/archive?path=/nonexistent", nil, "")
+	c.Assert(err, check.IsNil)
+	body.Close()
+	c.Assert(res.StatusCode, check.Equals, http.StatusNotFound)
+}
+
+func (s *DockerSuite) TestGetContainerStatsNoStream(c *check.C) {
+	name := "statscontainer"
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top")); err != nil {
+		c.Fatalf("Error on container creation: %v, output: %q", err, out)
+	}
+
+	status, body, err := sockRequest("GET", "/containers/"+name+"/stats?stream=false", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK)
+
+	dec := json.NewDecoder(bytes.NewBuffer(body))
+	var stats types.Stats
+	if err := dec.Decode(&stats); err != nil {
+		c.Fatal(err)
+	}
+	if err := dec.Decode(&types.Stats{}); err != io.EOF {
+		c.Fatalf("Expected the stats once, got %v: %s", err, body)
+	}
+	if stats.PreCpuStats.SystemUsage == 0 || stats.PreCpuStats.SystemUsage >= stats.CpuStats.SystemUsage {
+		c.Fatalf("Expected the CPU stats of the previous read, got %#v", stats.PreCpuStats)
+	}
+	if eth0, ok := stats.Networks["eth0"]; !ok || eth0 != stats.Network {
+		c.Fatalf("Expected the stats of eth0, got %#v", stats.Networks)
+	}
+	if stats.PidsStats.Current == 0 {
+		c.Fatal("Expected the processes of the container to be counted")
+	}
+}
+
+func (s *DockerSuite) TestContainersApiPrune(c *check.C) {
+	dockerCmd(c, "run", "--name=prunedev", "--label=env=dev", "busybox", "true")
+	dockerCmd(c, "run", "--name=pruneprod", "--label=env=prod", "busybox", "true")
+	dockerCmd(c, "run", "-d", "--name=prunerunning", "--label=env=dev", "busybox", "top")
+	id, err := inspectField("prunedev", "Id")
+	c.Assert(err, check.IsNil)
+
+	status, b, err := sockRequest("POST", "/containers/prune?filters="+url.QueryEscape(`{"dangling":["true"]}`), nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusInternalServerError, check.Commentf(string(b)))
+
+	status, b, err = sockRequest("POST", "/containers/prune?filters="+url.QueryEscape(`{"label":["env=dev"]}`), nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK, check.Commentf(string(b)))
+
+	var report types.ContainersPruneReport
+	c.Assert(json.Unmarshal(b, &report), check.IsNil)
+	c.Assert(report.ContainersDeleted, check.DeepEquals, []string{id})
+
+	// the stopped container of another label and the running one are kept
+	for _, name := range []string{"pruneprod", "prunerunning"} {
+		status, _, err = sockRequest("GET", "/containers/"+name+"/json", nil)
+		c.Assert(err, check.IsNil)
+		c.Assert(status, check.Equals, http.StatusOK)
+	}
+}
+
+func (s *DockerSuite) TestGetContainerStatsNoStreamBeforeV119(c *check.C) {
+	name := "statscontainer"
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top")); err != nil {
+		c.Fatalf("Error on container creation: %v, output: %q", err, out)
+	}
+
+	// stream defaults to false before v1.19
+	status, body, err := sockRequest("GET", "/v1.18/containers/"+name+"/stats", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK)
+
+	dec := json.NewDecoder(bytes.NewBuffer(body))
+	if err := dec.Decode(&types.Stats{}); err != nil {
+		c.Fatal(err)
+	}
+	if err := dec.Decode(&types.Stats{}); err != io.EOF {
+		c.Fatalf("Expected the stats once, got %v: %s", err, body)
+	}
+}
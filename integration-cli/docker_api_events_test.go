@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-check/check"
+)
+
+type eventActor struct {
+	ID         string
+	Attributes map[string]string
+}
+
+type eventMessage struct {
+	Status string `json:"status"`
+	ID     string `json:"id"`
+	From   string `json:"from"`
+	Type   string
+	Action string
+	Actor  *eventActor
+}
+
+func getEvents(c *check.C, endpoint string) []eventMessage {
+	status, body, err := sockRequest("GET", endpoint, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK)
+
+	var events []eventMessage
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var ev eventMessage
+		if err := dec.Decode(&ev); err == io.EOF {
+			break
+		} else if err != nil {
+			c.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func (s *DockerSuite) TestEventsAPIActor(c *check.C) {
+	since := daemonTime(c).Unix()
+	dockerCmd(c, "run", "--name", "events-actor", "--label", "com.example.events=actor", "busybox", "true")
+	out, _ := dockerCmd(c, "inspect", "-f", "{{.Id}}", "events-actor")
+	id := strings.TrimSpace(out)
+
+	v := url.Values{}
+	v.Set("since", fmt.Sprintf("%d", since))
+	v.Set("until", fmt.Sprintf("%d", daemonTime(c).Unix()))
+	v.Set("filters", `{"type":["container"],"label":["com.example.events=actor"]}`)
+	events := getEvents(c, "/events?"+v.Encode())
+	if len(events) == 0 {
+		c.Fatal("Expected the events of the container")
+	}
+	for _, ev := range events {
+		if ev.Type != "container" || ev.Action != ev.Status || ev.Actor == nil || ev.Actor.ID != id {
+			c.Fatalf("Expected a container event of %s, got %+v", id, ev)
+		}
+		attrs := ev.Actor.Attributes
+		if attrs["name"] != "events-actor" || attrs["image"] != "busybox" || attrs["com.example.events"] != "actor" {
+			c.Fatalf("Expected the name, the image and the labels of the container, got %v", attrs)
+		}
+	}
+
+	// the events of the API versions before 1.19 don't have an actor
+	for _, ev := range getEvents(c, "/v1.18/events?"+v.Encode()) {
+		if ev.Actor != nil || ev.ID != id {
+			c.Fatalf("Expected an event without actor, got %+v", ev)
+		}
+	}
+}
+
+func (s *DockerSuite) TestEventsAPIKillSignals(c *check.C) {
+	since := daemonTime(c).Unix()
+	dockerCmd(c, "run", "-d", "--name", "events-signals", "busybox", "sh", "-c", "trap 'echo usr1' USR1; trap 'echo usr2' USR2; while true; do sleep 1; done")
+	// the signals are named with or without their prefix, in any case, or
+	// numbered
+	dockerCmd(c, "kill", "-s", "sigusr1", "events-signals")
+	dockerCmd(c, "kill", "-s", "12", "events-signals")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "kill", "-s", "SIGFOO", "events-signals")); err == nil || !strings.Contains(out, "Invalid signal: SIGFOO") {
+		c.Fatalf("Expected the unknown signal to be refused, got %v: %s", err, out)
+	}
+	// the traps run once the sleep of the container returns
+	var out string
+	for i := 0; i < 50; i++ {
+		out, _ = dockerCmd(c, "logs", "events-signals")
+		if strings.Contains(out, "usr1") && strings.Contains(out, "usr2") {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !strings.Contains(out, "usr1") || !strings.Contains(out, "usr2") {
+		c.Fatalf("Expected the signals to be handled by the container, got %q", out)
+	}
+	dockerCmd(c, "kill", "events-signals")
+
+	v := url.Values{}
+	v.Set("since", fmt.Sprintf("%d", since))
+	v.Set("until", fmt.Sprintf("%d", daemonTime(c).Unix()))
+	v.Set("filters", `{"container":["events-signals"],"event":["kill"]}`)
+	var signals []string
+	for _, ev := range getEvents(c, "/events?"+v.Encode()) {
+		signals = append(signals, ev.Actor.Attributes["signal"])
+	}
+	if strings.Join(signals, " ") != "10 12 9" {
+		c.Fatalf("Expected the kill events with the signals delivered, got %v", signals)
+	}
+}
+
+func (s *DockerSuite) TestEventsAPIExecNetworkVolume(c *check.C) {
+	since := daemonTime(c).Unix()
+	dockerCmd(c, "run", "-d", "--name", "events-types", "-v", "events-volume:/data", "busybox", "top")
+	dockerCmd(c, "exec", "events-types", "sh", "-c", "exit 3")
+	dockerCmd(c, "stop", "events-types")
+	out, _ := dockerCmd(c, "inspect", "-f", "{{.Id}}", "events-types")
+	id := strings.TrimSpace(out)
+
+	v := url.Values{}
+	v.Set("since", fmt.Sprintf("%d", since))
+	v.Set("until", fmt.Sprintf("%d", daemonTime(c).Unix()))
+	v.Set("filters", `{"container":["events-types"]}`)
+	actions := make(map[string]eventMessage)
+	for _, ev := range getEvents(c, "/events?"+v.Encode()) {
+		actions[ev.Type+" "+ev.Action] = ev
+	}
+
+	for _, action := range []string{"network connect", "network disconnect"} {
+		ev, ok := actions[action]
+		if !ok || ev.Actor.ID != "bridge" || ev.Actor.Attributes["container"] != id || ev.Actor.Attributes["type"] != "bridge" {
+			c.Fatalf("Expected the %s event of the container, got %+v", action, actions)
+		}
+	}
+	for _, action := range []string{"volume mount", "volume unmount"} {
+		ev, ok := actions[action]
+		if !ok || ev.Actor.ID != "events-volume" {
+			c.Fatalf("Expected the %s event of the volume, got %+v", action, actions)
+		}
+		attrs := ev.Actor.Attributes
+		if attrs["container"] != id || attrs["destination"] != "/data" || attrs["driver"] != "local" || attrs["read/write"] != "true" {
+			c.Fatalf("Expected the attributes of the mount of the volume, got %v", attrs)
+		}
+	}
+	ev, ok := actions["container exec_die"]
+	if !ok || ev.Actor.Attributes["exitCode"] != "3" || ev.Actor.Attributes["execID"] == "" {
+		c.Fatalf("Expected the exec_die event of the container, got %+v", actions)
+	}
+	if ev, ok := actions["container die"]; !ok || ev.Actor.Attributes["exitCode"] == "" {
+		c.Fatalf("Expected the die event of the container with its exit code, got %+v", actions)
+	}
+
+	// the volume events are filtered by volume
+	v.Set("filters", `{"volume":["events-volume"]}`)
+	for _, ev := range getEvents(c, "/events?"+v.Encode()) {
+		if ev.Type != "volume" || ev.Actor.ID != "events-volume" {
+			c.Fatalf("Expected the events of the volume, got %+v", ev)
+		}
+	}
+
+	// the clients of the API versions before 1.19 only get the events of the
+	// containers and the images
+	v.Set("filters", `{"container":["events-types"]}`)
+	for _, ev := range getEvents(c, "/v1.18/events?"+v.Encode()) {
+		if ev.ID != id {
+			c.Fatalf("Expected the events of the container, got %+v", ev)
+		}
+	}
+}
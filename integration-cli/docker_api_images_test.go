@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os/exec"
@@ -74,6 +75,11 @@ func (s *DockerSuite) TestApiImagesSaveAndLoad(c *check.C) {
 	c.Assert(res.StatusCode, check.Equals, http.StatusOK)
 
 	defer loadBody.Close()
+	loadOut, err := ioutil.ReadAll(loadBody)
+	c.Assert(err, check.IsNil)
+	if !strings.Contains(string(loadOut), `"aux":{"ID":"`+id+`"}`) {
+		c.Fatalf("expected the loaded image to be reported, got %s", loadOut)
+	}
 
 	inspectOut, err := exec.Command(dockerBinary, "inspect", "--format='{{ .Id }}'", id).CombinedOutput()
 	if err != nil {
@@ -132,3 +138,38 @@ func (s *DockerSuite) TestApiImagesHistory(c *check.C) {
 	c.Assert(len(historydata), check.Not(check.Equals), 0)
 	c.Assert(historydata[0].Tags[0], check.Equals, "test-api-images-history:latest")
 }
+
+func (s *DockerSuite) TestImagesApiPrune(c *check.C) {
+	dockerCmd(c, "run", "--name=prunetest", "--label=prune=test", "busybox", "touch", "/test")
+	out, _ := dockerCmd(c, "commit", "prunetest")
+	dangling := strings.TrimSpace(out)
+	out, _ = dockerCmd(c, "commit", "prunetest", "prunetest:tagged")
+	tagged := strings.TrimSpace(out)
+	defer deleteImages("prunetest:tagged")
+	dockerCmd(c, "create", "--name=pruneuser", dangling)
+
+	prune := func(filters string) types.ImagesPruneReport {
+		status, b, err := sockRequest("POST", "/images/prune?filters="+url.QueryEscape(filters), nil)
+		c.Assert(err, check.IsNil)
+		c.Assert(status, check.Equals, http.StatusOK, check.Commentf(string(b)))
+		var report types.ImagesPruneReport
+		c.Assert(json.Unmarshal(b, &report), check.IsNil)
+		return report
+	}
+
+	// the image of a container and the tagged image are kept
+	report := prune(`{"label":["prune=test"]}`)
+	c.Assert(report.ImagesDeleted, check.HasLen, 0)
+
+	dockerCmd(c, "rm", "pruneuser")
+	report = prune(`{"label":["prune=test"]}`)
+	c.Assert(report.ImagesDeleted, check.DeepEquals, []types.ImageDelete{{Deleted: dangling}})
+	c.Assert(report.SpaceReclaimed > 0, check.Equals, true)
+
+	report = prune(`{"label":["prune=test"],"dangling":["false"]}`)
+	c.Assert(report.ImagesDeleted, check.DeepEquals, []types.ImageDelete{{Untagged: "prunetest:tagged"}, {Deleted: tagged}})
+
+	status, b, err := sockRequest("POST", "/images/prune?filters="+url.QueryEscape(`{"dangling":["maybe"]}`), nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusInternalServerError, check.Commentf(string(b)))
+}
@@ -3,7 +3,12 @@ package main
 import (
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"sync"
 
+	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/go-check/check"
@@ -23,3 +28,43 @@ func (s *DockerSuite) TestGetVersion(c *check.C) {
 		c.Fatal("Version mismatch")
 	}
 }
+
+func (s *DockerSuite) TestPingAPIVersion(c *check.C) {
+	resp, body, err := sockRequestRaw("GET", "/_ping", nil, "")
+	c.Assert(err, check.IsNil)
+	body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Api-Version"), check.Equals, string(api.APIVERSION))
+}
+
+// the client uses the API version of an older daemon
+func (s *DockerSuite) TestAPIVersionNegotiation(c *check.C) {
+	var (
+		mu   sync.Mutex
+		uris []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		uris = append(uris, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Api-Version", "1.18")
+		if r.URL.Path == "/_ping" {
+			w.Write([]byte("OK"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&types.Version{Version: "1.6.0", ApiVersion: "1.18"})
+	}))
+	defer server.Close()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "-H="+server.URL[7:], "version"))
+	if err != nil {
+		c.Fatalf("failed to execute docker version: %s, %v", out, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(uris) != 2 || uris[0] != "/_ping" || !strings.HasPrefix(uris[1], "/v1.18/") {
+		c.Fatalf("Expected a ping and then a request of the version 1.18, got %v", uris)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestVolumesApiCreate(c *check.C) {
+	status, b, err := sockRequest("POST", "/volumes/create", types.VolumeCreateRequest{Name: "test"})
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusCreated, check.Commentf(string(b)))
+
+	var v types.Volume
+	c.Assert(json.Unmarshal(b, &v), check.IsNil)
+	c.Assert(v.Name, check.Equals, "test")
+	c.Assert(v.Driver, check.Equals, "local")
+	c.Assert(v.Mountpoint, check.Not(check.Equals), "")
+}
+
+func (s *DockerSuite) TestVolumesApiList(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=test")
+
+	status, b, err := sockRequest("GET", "/volumes", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK)
+
+	var volumes types.VolumesListResponse
+	c.Assert(json.Unmarshal(b, &volumes), check.IsNil)
+	c.Assert(len(volumes.Volumes), check.Equals, 1, check.Commentf("\n%v", volumes.Volumes))
+	c.Assert(volumes.Volumes[0].Name, check.Equals, "test")
+}
+
+func (s *DockerSuite) TestVolumesApiListFilters(c *check.C) {
+	status, b, err := sockRequest("POST", "/volumes/create", types.VolumeCreateRequest{Name: "test", Labels: map[string]string{"env": "prod"}})
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusCreated, check.Commentf(string(b)))
+	dockerCmd(c, "volume", "create", "--name=other")
+
+	status, b, err = sockRequest("GET", "/volumes?filters="+url.QueryEscape(`{"label":["env=prod"]}`), nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK)
+
+	var volumes types.VolumesListResponse
+	c.Assert(json.Unmarshal(b, &volumes), check.IsNil)
+	c.Assert(len(volumes.Volumes), check.Equals, 1, check.Commentf("\n%v", volumes.Volumes))
+	c.Assert(volumes.Volumes[0].Name, check.Equals, "test")
+	c.Assert(volumes.Volumes[0].Labels["env"], check.Equals, "prod")
+}
+
+func (s *DockerSuite) TestVolumesApiInspect(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=test")
+
+	status, b, err := sockRequest("GET", "/volumes/test", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK)
+
+	var v types.Volume
+	c.Assert(json.Unmarshal(b, &v), check.IsNil)
+	c.Assert(v.Name, check.Equals, "test")
+
+	status, _, err = sockRequest("GET", "/volumes/doesntexist", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusNotFound)
+}
+
+func (s *DockerSuite) TestVolumesApiRemove(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=test")
+	dockerCmd(c, "create", "-v", "test:/foo", "--name=test", "busybox")
+
+	status, _, err := sockRequest("DELETE", "/volumes/test", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusConflict)
+
+	dockerCmd(c, "rm", "test")
+	status, _, err = sockRequest("DELETE", "/volumes/test", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusNoContent)
+
+	status, _, err = sockRequest("DELETE", "/volumes/test", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusNotFound)
+}
+
+func (s *DockerSuite) TestVolumesApiPrune(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=test")
+	dockerCmd(c, "volume", "create", "--name=used")
+	dockerCmd(c, "create", "-v", "used:/foo", "busybox")
+
+	status, b, err := sockRequest("POST", "/volumes/prune?filters="+url.QueryEscape(`{"dangling":["true"]}`), nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusInternalServerError, check.Commentf(string(b)))
+
+	status, b, err = sockRequest("POST", "/volumes/prune", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK, check.Commentf(string(b)))
+
+	var report types.VolumesPruneReport
+	c.Assert(json.Unmarshal(b, &report), check.IsNil)
+	c.Assert(report.VolumesDeleted, check.DeepEquals, []string{"test"})
+
+	status, _, err = sockRequest("GET", "/volumes/used", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK)
+}
+
+func (s *DockerSuite) TestVolumesApiCreateFrom(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=data")
+
+	status, b, err := sockRequest("POST", "/volumes/create", types.VolumeCreateRequest{Name: "test", From: "data"})
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusCreated, check.Commentf(string(b)))
+
+	var v types.Volume
+	c.Assert(json.Unmarshal(b, &v), check.IsNil)
+	c.Assert(v.Name, check.Equals, "test")
+	c.Assert(v.From, check.Equals, "data")
+
+	status, _, err = sockRequest("POST", "/volumes/create", types.VolumeCreateRequest{Name: "test", From: "data"})
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusConflict)
+
+	status, _, err = sockRequest("POST", "/volumes/create", types.VolumeCreateRequest{Name: "other", From: "doesntexist"})
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusNotFound)
+}
@@ -0,0 +1,101 @@
+// +build !windows
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/pkg/authorization"
+	"github.com/go-check/check"
+)
+
+const authzPluginName = "test-authz-plugin"
+
+// authzPlugin is an authorization plugin denying the privileged containers,
+// recording the URIs of the requests it allowed.
+type authzPlugin struct {
+	sync.Mutex
+	server   *httptest.Server
+	requests []string
+}
+
+func newAuthzPlugin(c *check.C) *authzPlugin {
+	p := &authzPlugin{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Plugin.Activate", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"Implements": ["authz"]}`)
+	})
+	mux.HandleFunc("/"+authorization.AuthZApiRequest, func(w http.ResponseWriter, r *http.Request) {
+		var authReq authorization.Request
+		if err := json.NewDecoder(r.Body).Decode(&authReq); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(string(authReq.RequestBody), `"Privileged":true`) {
+			json.NewEncoder(w).Encode(&authorization.Response{Msg: "privileged containers are not allowed"})
+			return
+		}
+		p.Lock()
+		p.requests = append(p.requests, authReq.RequestMethod+" "+authReq.RequestURI)
+		p.Unlock()
+		json.NewEncoder(w).Encode(&authorization.Response{Allow: true})
+	})
+	mux.HandleFunc("/"+authorization.AuthZApiResponse, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&authorization.Response{Allow: true})
+	})
+	p.server = httptest.NewServer(mux)
+
+	if err := os.MkdirAll("/usr/share/docker/plugins", 0755); err != nil {
+		c.Fatal(err)
+	}
+	spec := filepath.Join("/usr/share/docker/plugins", authzPluginName+".spec")
+	if err := ioutil.WriteFile(spec, []byte(p.server.URL), 0644); err != nil {
+		c.Fatal(err)
+	}
+	return p
+}
+
+func (p *authzPlugin) Close() {
+	p.server.Close()
+	os.Remove(filepath.Join("/usr/share/docker/plugins", authzPluginName+".spec"))
+}
+
+func (s *DockerDaemonSuite) TestAuthZPluginDenyRequest(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	p := newAuthzPlugin(c)
+	defer p.Close()
+
+	if err := s.d.StartWithBusybox("--authorization-plugin=" + authzPluginName); err != nil {
+		c.Fatal(err)
+	}
+
+	if out, err := s.d.Cmd("run", "--rm", "busybox", "true"); err != nil {
+		c.Fatal(err, out)
+	}
+
+	out, err := s.d.Cmd("run", "--privileged", "busybox", "true")
+	if err == nil || !strings.Contains(out, "Authorization denied by plugin "+authzPluginName+": privileged containers are not allowed") {
+		c.Fatalf("Expected the privileged container to be denied, got %v: %s", err, out)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	var created bool
+	for _, uri := range p.requests {
+		if strings.HasPrefix(uri, "POST /v") && strings.Contains(uri, "/containers/create") {
+			created = true
+		}
+	}
+	if !created {
+		c.Fatalf("Expected the plugin to see the requests, got %v", p.requests)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -3591,6 +3592,33 @@ func (s *DockerSuite) TestBuildDockerignoringRenamedDockerfile(c *check.C) {
 
 }
 
+func (s *DockerSuite) TestBuildDockerignorePerDockerfile(c *check.C) {
+	name := "testbuilddockerignoreperdockerfile"
+	defer deleteImages(name)
+	dockerfile := `
+        FROM busybox
+		ADD . /tmp/
+		RUN ls /tmp/app
+		RUN ! ls /tmp/docs
+		RUN ls /tmp/docs.txt`
+	ctx, err := fakeContext("FROM busybox", map[string]string{
+		"app.Dockerfile":              dockerfile,
+		"app.Dockerfile.dockerignore": "docs\n",
+		".dockerignore":               "app\ndocs.txt\n",
+		"app":                         "",
+		"docs/README.md":              "",
+		"docs.txt":                    "",
+	})
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer ctx.Close()
+
+	if out, _, err := dockerCmdInDir(c, ctx.Dir, "build", "-f", "app.Dockerfile", "-t", name, "."); err != nil {
+		c.Fatalf("Didn't use app.Dockerfile.dockerignore: %s, %v", out, err)
+	}
+}
+
 func (s *DockerSuite) TestBuildDockerignoringDockerignore(c *check.C) {
 	name := "testbuilddockerignoredockerignore"
 	dockerfile := `
@@ -4965,6 +4993,48 @@ RUN find /tmp/`)
 
 }
 
+func (s *DockerSuite) TestBuildDockerfileFromStdinWithContext(c *check.C) {
+	name := "testbuilddockerfilefromstdin"
+	defer deleteImages(name)
+	ctx, err := fakeContext(`FROM busybox
+RUN echo from Dockerfile`,
+		map[string]string{
+			"foo":           "foo",
+			"ignored":       "ignored",
+			".dockerignore": "ignored",
+		})
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer ctx.Close()
+
+	// The Dockerfile from stdin is used with the context of the current
+	// dir, and neither it nor the ignored files end up in the context
+	dockerCommand := exec.Command(dockerBinary, "build", "-f", "-", "-t", name, ".")
+	dockerCommand.Dir = ctx.Dir
+	dockerCommand.Stdin = strings.NewReader(`FROM busybox
+RUN echo from stdin
+COPY . /ctx/
+RUN [ "$(cat /ctx/foo)" = "foo" ] && [ ! -e /ctx/ignored ]
+RUN ls -A /ctx/`)
+	out, _, err := runCommandWithOutput(dockerCommand)
+	if err != nil {
+		c.Fatalf("Error building: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "from stdin") || strings.Contains(out, "from Dockerfile") {
+		c.Fatalf("Expected the Dockerfile from stdin to be used: %s", out)
+	}
+	if strings.Contains(out, ".dockerfile.") {
+		c.Fatalf("Expected the Dockerfile from stdin not to be in the context: %s", out)
+	}
+
+	dockerCommand = exec.Command(dockerBinary, "build", "-f", "-", "-")
+	dockerCommand.Stdin = strings.NewReader("FROM busybox")
+	if out, _, err := runCommandWithOutput(dockerCommand); err == nil || !strings.Contains(out, "can't use stdin for both") {
+		c.Fatalf("Expected -f - to conflict with a context from stdin: %s", out)
+	}
+}
+
 func (s *DockerSuite) TestBuildFromOfficialNames(c *check.C) {
 	name := "testbuildfromofficial"
 	fromNames := []string{
@@ -5426,3 +5496,403 @@ func (s *DockerSuite) TestBuildRUNErrMsg(c *check.C) {
 		c.Fatalf("RUN doesn't have the correct output:\nGot:%s\nExpected:%s", out, exp)
 	}
 }
+
+func (s *DockerSuite) TestBuildBuildTimeArg(c *check.C) {
+	name := "testbuildbuildtimearg"
+	envKey := "foo"
+	envVal := "bar"
+	dockerfile := fmt.Sprintf(`FROM busybox
+		ARG %s
+		RUN echo $%s
+		CMD echo $%s`, envKey, envKey, envKey)
+
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--build-arg", fmt.Sprintf("%s=%s", envKey, envVal), "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+	if !strings.Contains(out, envVal) {
+		c.Fatalf("run should have used the build-arg value %q: %s", envVal, out)
+	}
+
+	containerName := "bldargCont"
+	if out, _ := dockerCmd(c, "run", "--name", containerName, name); out != "\n" {
+		c.Fatalf("run produced invalid output: %q, expected empty string", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildBuildTimeArgNotConsumed(c *check.C) {
+	name := "testbuildbuildtimeargunconsumed"
+	dockerfile := `FROM busybox
+		RUN echo $foo`
+
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--build-arg", "foo=bar", "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err == nil {
+		c.Fatal("build should have failed with an unconsumed build-arg")
+	}
+	if !strings.Contains(out, "were not consumed") {
+		c.Fatalf("unexpected error output: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildMultiStageCopyFrom(c *check.C) {
+	name := "testbuildmultistagecopyfrom"
+	defer deleteImages(name)
+	_, err := buildImage(name, `
+  FROM busybox AS first
+  RUN echo hello > /hello
+
+  FROM busybox
+  COPY --from=first /hello /copied
+  RUN [ "$(cat /copied)" = "hello" ]
+  RUN [ ! -e /hello ]`, true)
+	if err != nil {
+		c.Fatal(err)
+	}
+
+	// stages can also be referred to by index
+	_, err = buildImage(name, `
+  FROM busybox
+  RUN echo hello > /hello
+
+  FROM busybox
+  COPY --from=0 /hello /copied
+  RUN [ "$(cat /copied)" = "hello" ]`, true)
+	if err != nil {
+		c.Fatal(err)
+	}
+}
+
+func (s *DockerSuite) TestBuildMultiStageTarget(c *check.C) {
+	name := "testbuildmultistagetarget"
+	defer deleteImages(name)
+	dockerfile := `
+  FROM busybox AS build
+  CMD ["build"]
+
+  FROM busybox
+  CMD ["final"]`
+
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--target", "build", "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+	res, err := inspectFieldJSON(name, "Config.Cmd")
+	if err != nil {
+		c.Fatal(err)
+	}
+	if res != `["build"]` {
+		c.Fatalf("Cmd %s, expected [\"build\"]", res)
+	}
+
+	buildCmd = exec.Command(dockerBinary, "build", "-t", name, "--target", "nosuchstage", "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err = runCommandWithOutput(buildCmd)
+	if err == nil {
+		c.Fatal("build should have failed with an unknown target")
+	}
+	if !strings.Contains(out, "Failed to reach build target nosuchstage") {
+		c.Fatalf("unexpected error output: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildCacheFrom(c *check.C) {
+	name := "testbuildcachefrom"
+	defer deleteImages(name, name+"2")
+	dockerfile := `FROM busybox
+		ENV FOO=bar
+		RUN echo cachefrom > /cachefrom`
+
+	if _, err := buildImage(name, dockerfile, true); err != nil {
+		c.Fatal(err)
+	}
+
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name+"2", "--cache-from", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+	if strings.Count(out, "Using cache") != 2 {
+		c.Fatalf("expected the steps to be cached from %s: %s", name, out)
+	}
+
+	// images other than the --cache-from ones are not used as cache
+	buildCmd = exec.Command(dockerBinary, "build", "-t", name+"2", "--cache-from", "busybox", "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err = runCommandWithOutput(buildCmd)
+	if err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+	if strings.Contains(out, "Using cache") {
+		c.Fatalf("expected no cache hits with --cache-from busybox: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildSquash(c *check.C) {
+	name := "testbuildsquash"
+	defer deleteImages(name)
+	dockerfile := `FROM busybox
+		RUN echo hello > /hello
+		RUN echo removed > /removed
+		RUN rm /removed`
+
+	buildCmd := exec.Command(dockerBinary, "build", "--squash", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+
+	busybox, err := inspectField("busybox", "Id")
+	c.Assert(err, check.IsNil)
+	parent, err := inspectField(name, "Parent")
+	c.Assert(err, check.IsNil)
+	if parent != busybox {
+		c.Fatalf("expected the squashed image to be on top of busybox %s, got %s", busybox, parent)
+	}
+
+	out, _ := dockerCmd(c, "run", "--rm", name, "sh", "-c", "cat /hello; test -e /removed || echo removed")
+	if out != "hello\nremoved\n" {
+		c.Fatalf("unexpected content in the squashed image: %q", out)
+	}
+
+	// the intermediate images are kept as build cache
+	buildCmd = exec.Command(dockerBinary, "build", "--squash", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err = runCommandWithOutput(buildCmd)
+	if err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+	if strings.Count(out, "Using cache") != 3 {
+		c.Fatalf("expected the steps to be cached: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildNetworkNone(c *check.C) {
+	name := "testbuildnetworknone"
+	defer deleteImages(name)
+	dockerfile := `FROM busybox
+		RUN [ "$(ls /sys/class/net)" = "lo" ]`
+
+	buildCmd := exec.Command(dockerBinary, "build", "--network=none", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+
+	buildCmd = exec.Command(dockerBinary, "build", "--network=foo", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err == nil || !strings.Contains(out, "invalid network mode") {
+		c.Fatalf("expected the build to fail with an invalid network mode: %q", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildAddHost(c *check.C) {
+	name := "testbuildaddhost"
+	defer deleteImages(name)
+	dockerfile := `FROM busybox
+		RUN grep "10.0.0.5[[:space:]]mirror.example.com" /etc/hosts`
+
+	buildCmd := exec.Command(dockerBinary, "build", "--add-host", "mirror.example.com:10.0.0.5", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+
+	buildCmd = exec.Command(dockerBinary, "build", "--network=host", "--add-host", "mirror.example.com:10.0.0.5", "-t", name, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err == nil || !strings.Contains(out, "Conflicting options") {
+		c.Fatalf("expected --add-host to conflict with --network=host: %q", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildOutput(c *check.C) {
+	dockerfile := `FROM busybox AS build
+		RUN mkdir /out && echo hello > /out/hello && echo world > /out/world
+		FROM scratch
+		COPY --from=build /out /`
+
+	out, err := ioutil.TempDir("", "test-build-output")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(out)
+
+	dest := filepath.Join(out, "local")
+	buildCmd := exec.Command(dockerBinary, "build", "--output", "type=local,dest="+dest, "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	buildOut, _, err := runCommandWithOutput(buildCmd)
+	if err != nil {
+		c.Fatalf("build failed to complete: %q %v", buildOut, err)
+	}
+	// no image is kept for the exported result
+	m := regexp.MustCompile(`Successfully built ([0-9a-f]+)`).FindStringSubmatch(buildOut)
+	if m == nil {
+		c.Fatalf("expected the ID of the build result: %q", buildOut)
+	}
+	if _, _, err := runCommandWithOutput(exec.Command(dockerBinary, "inspect", m[1])); err == nil {
+		c.Fatalf("expected the image %s of the build result to be deleted", m[1])
+	}
+	for file, content := range map[string]string{"hello": "hello\n", "world": "world\n"} {
+		data, err := ioutil.ReadFile(filepath.Join(dest, file))
+		c.Assert(err, check.IsNil)
+		if string(data) != content {
+			c.Fatalf("unexpected content of %s: %q", file, data)
+		}
+	}
+
+	// the result is written to stdout as a tar archive with -o -
+	buildCmd = exec.Command(dockerBinary, "build", "-o", "-", "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	var stdout bytes.Buffer
+	buildCmd.Stdout = &stdout
+	if _, err := runCommand(buildCmd); err != nil {
+		c.Fatalf("build failed to complete: %v", err)
+	}
+	files := map[string]bool{}
+	tr := tar.NewReader(&stdout)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		files[filepath.Clean(hdr.Name)] = true
+	}
+	if !files["hello"] || !files["world"] {
+		c.Fatalf("expected hello and world in the archive, got %v", files)
+	}
+
+	buildCmd = exec.Command(dockerBinary, "build", "-o", "-", "-t", "testbuildoutput", "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	if out, _, err := runCommandWithOutput(buildCmd); err == nil || !strings.Contains(out, "Conflicting options") {
+		c.Fatalf("expected --output to conflict with --tag: %q", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildCopyAddChown(c *check.C) {
+	name := "testbuildcopyaddchown"
+	defer deleteImages(name)
+	ctx, err := fakeContext(`FROM busybox
+RUN echo 'dockerio:x:1001:1002::/bin:/bin/false' >> /etc/passwd
+RUN echo 'dockerio:x:1002:' >> /etc/group
+RUN echo 'other:x:1003:' >> /etc/group
+COPY --chown=dockerio test_file /user
+COPY --chown=dockerio:other test_file /usergroup
+COPY --chown=2000 test_dir /uid
+ADD --chown=1001:1003 test_dir /ids
+RUN [ $(ls -l /user | awk '{print $3":"$4}') = 'dockerio:dockerio' ]
+RUN [ $(ls -l /usergroup | awk '{print $3":"$4}') = 'dockerio:other' ]
+RUN [ $(ls -ln /uid/a_file | awk '{print $3":"$4}') = '2000:2000' ]
+RUN [ $(ls -ln /ids/a_file | awk '{print $3":"$4}') = '1001:1003' ]`,
+		map[string]string{
+			"test_file":       "test1",
+			"test_dir/a_file": "test2",
+		})
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer ctx.Close()
+
+	if _, err := buildImageFromContext(name, ctx, true); err != nil {
+		c.Fatal(err)
+	}
+
+	if err := ctx.Add("Dockerfile", `FROM busybox
+COPY --chown=nosuchuser test_file /`); err != nil {
+		c.Fatal(err)
+	}
+	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), "nosuchuser") {
+		c.Fatalf("Expected the build to fail with an unknown user, got %v", err)
+	}
+}
+
+func (s *DockerSuite) TestBuildSecretMount(c *check.C) {
+	name := "testbuildsecretmount"
+	defer deleteImages(name)
+
+	secretFile, err := ioutil.TempFile("", "secret")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.Remove(secretFile.Name())
+	if _, err := secretFile.WriteString("s3cr3t"); err != nil {
+		c.Fatal(err)
+	}
+	secretFile.Close()
+
+	dockerfile := `FROM busybox
+		RUN --mount=type=secret,id=mysecret [ "$(cat /run/secrets/mysecret)" = "s3cr3t" ]
+		RUN [ ! -e /run/secrets ]`
+
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--secret", "id=mysecret,src="+secretFile.Name(), "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+
+	out, _ = dockerCmd(c, "history", "--no-trunc", name)
+	if strings.Contains(out, "s3cr3t") {
+		c.Fatalf("secret found in the history of the image: %s", out)
+	}
+
+	buildCmd = exec.Command(dockerBinary, "build", "-t", name, "--no-cache", "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	out, _, err = runCommandWithOutput(buildCmd)
+	if err == nil {
+		c.Fatal("build should have failed without the secret")
+	}
+	if !strings.Contains(out, "Secret mysecret was not provided") {
+		c.Fatalf("unexpected error output: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildSSHMountWithoutAgent(c *check.C) {
+	name := "testbuildsshmountwithoutagent"
+	_, out, err := buildImageWithOut(name, `FROM busybox
+		RUN --mount=type=ssh true`, false)
+	if err == nil {
+		c.Fatal("build should have failed without a forwarded SSH agent")
+	}
+	if !strings.Contains(out, "requires the SSH agent to be forwarded") {
+		c.Fatalf("unexpected error output: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildLabelsFlag(c *check.C) {
+	name := "testbuildlabelsflag"
+	defer deleteImages(name)
+	dockerfile := `FROM busybox
+		LABEL env=dev
+		RUN true`
+
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--label", "env=$prod", "--label", "team=infra", "-")
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		c.Fatalf("build failed to complete: %q %v", out, err)
+	}
+
+	// the labels of the flag override those of the Dockerfile, unexpanded
+	labels, err := inspectFieldJSON(name, "Config.Labels")
+	if err != nil {
+		c.Fatal(err)
+	}
+	if expected := `{"env":"$prod","team":"infra"}`; labels != expected {
+		c.Fatalf("Expected the labels %s, got %s", expected, labels)
+	}
+
+	id, err := inspectField(name, "Id")
+	if err != nil {
+		c.Fatal(err)
+	}
+	out, _ := dockerCmd(c, "images", "--no-trunc", "-q", "--filter", "label=team=infra")
+	if strings.TrimSpace(out) != id {
+		c.Fatalf("Expected the image %s to be listed with its label, got %q", id, out)
+	}
+}
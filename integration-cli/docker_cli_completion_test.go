@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestCompletionBash(c *check.C) {
+	out, _ := dockerCmd(c, "completion", "bash")
+	for _, expected := range []string{
+		"complete -F _docker docker",
+		"'volume')\n\t\t\tprintf '%s\\n' 'create' 'inspect' 'ls' 'prune' 'rm' 'snapshot'",
+		"'--format'",
+		"'--condition'",
+	} {
+		if !strings.Contains(out, expected) {
+			c.Fatalf("Expected %q in the bash completion, got %s", expected, out)
+		}
+	}
+	if strings.Contains(out, "'--sinceId'") || strings.Contains(out, "'-notrunc'") {
+		c.Fatalf("Expected no deprecated flag in the bash completion, got %s", out)
+	}
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		cmd := exec.Command("bash", "-n")
+		cmd.Stdin = strings.NewReader(out)
+		if out, _, err := runCommandWithOutput(cmd); err != nil {
+			c.Fatalf("Invalid bash completion: %s, %v", out, err)
+		}
+	}
+}
+
+func (s *DockerSuite) TestCompletionZshAndFish(c *check.C) {
+	out, _ := dockerCmd(c, "completion", "zsh")
+	if !strings.HasPrefix(out, "#compdef docker\n") || !strings.Contains(out, "'--format:Pretty-print containers using a Go template'") {
+		c.Fatalf("Unexpected zsh completion: %s", out)
+	}
+
+	out, _ = dockerCmd(c, "completion", "fish")
+	expected := "complete -c docker -n '__fish_docker_command_is ps' -s 'a' -l 'all' -d 'Show all containers (default shows just running)'"
+	if !strings.Contains(out, expected) {
+		c.Fatalf("Expected %q in the fish completion, got %s", expected, out)
+	}
+}
+
+func (s *DockerSuite) TestCompletionUnsupportedShell(c *check.C) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "completion", "tcsh"))
+	if err == nil || !strings.Contains(out, "unsupported shell 'tcsh'") {
+		c.Fatalf("Expected an error for an unsupported shell, got %s, %v", out, err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestContextCreateUseLsRm(c *check.C) {
+	home, err := ioutil.TempDir("", "docker-context")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	// the contexts apply when DOCKER_HOST isn't set
+	env := []string{"HOME=" + home, "PATH=" + os.Getenv("PATH")}
+	docker := func(args ...string) (string, error) {
+		cmd := exec.Command(dockerBinary, args...)
+		cmd.Env = env
+		out, _, err := runCommandWithOutput(cmd)
+		return out, err
+	}
+
+	if out, err := docker("context", "create", "--host", daemonHost(), "--description", "The test daemon", "test"); err != nil || strings.TrimSpace(out) != "test" {
+		c.Fatalf("Expected the name of the context, got %s, %v", out, err)
+	}
+	if out, err := docker("context", "create", "--host", "tcp://127.0.0.1:1", "unreachable"); err != nil {
+		c.Fatal(out, err)
+	}
+	if out, err := docker("context", "create", "--host", daemonHost(), "test"); err == nil || !strings.Contains(out, "already exists") {
+		c.Fatalf("Expected an existing context to be refused, got %s", out)
+	}
+	if out, err := docker("context", "create", "--host", daemonHost(), "default"); err == nil || !strings.Contains(out, "reserved") {
+		c.Fatalf("Expected the default context to be reserved, got %s", out)
+	}
+
+	if out, err := docker("--context", "test", "version"); err != nil || !strings.Contains(out, "Server version") {
+		c.Fatalf("Expected to reach the daemon of the context, got %s, %v", out, err)
+	}
+	if out, err := docker("--context", "unreachable", "-H", daemonHost(), "version"); err == nil || !strings.Contains(out, "Conflicting options") {
+		c.Fatalf("Expected --context and -H to conflict, got %s", out)
+	}
+	if out, err := docker("--context", "missing", "version"); err == nil || !strings.Contains(out, "No such context: missing") {
+		c.Fatalf("Expected an unknown context to be refused, got %s", out)
+	}
+
+	if out, err := docker("context", "use", "unreachable"); err != nil {
+		c.Fatal(out, err)
+	}
+	if out, err := docker("ps"); err == nil {
+		c.Fatalf("Expected the current context to be used, got %s", out)
+	}
+	out, err := docker("context", "ls")
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	if !strings.Contains(out, "unreachable *") || !strings.Contains(out, "The test daemon") {
+		c.Fatalf("Expected the contexts with the current one marked, got %s", out)
+	}
+	if out, err := docker("context", "rm", "unreachable"); err == nil || !strings.Contains(out, "Cannot remove the current context") {
+		c.Fatalf("Expected the current context to be kept, got %s", out)
+	}
+
+	// DOCKER_HOST overrides the current context
+	cmd := exec.Command(dockerBinary, "ps")
+	cmd.Env = append(env, "DOCKER_HOST="+daemonHost())
+	if out, _, err := runCommandWithOutput(cmd); err != nil {
+		c.Fatal(out, err)
+	}
+
+	if out, err := docker("context", "use", "test"); err != nil {
+		c.Fatal(out, err)
+	}
+	if out, err := docker("ps"); err != nil {
+		c.Fatal(out, err)
+	}
+	if out, err := docker("context", "rm", "unreachable"); err != nil {
+		c.Fatal(out, err)
+	}
+	if out, err := docker("context", "ls", "-q"); err != nil || out != "default\ntest\n" {
+		c.Fatalf("Expected the remaining contexts, got %s, %v", out, err)
+	}
+}
@@ -596,3 +596,105 @@ func (s *DockerSuite) TestCpNameHasColon(c *check.C) {
 		c.Fatalf("Wrong content in copied file %q, should be %q", content, "lololol\n")
 	}
 }
+
+func (s *DockerSuite) TestCpToContainer(c *check.C) {
+	out, _ := dockerCmd(c, "create", "busybox", "cat", "/tmp/copied", "/tmp/dir/sub/file", "/tmp/contents/file")
+	cID := strings.TrimSpace(out)
+
+	tmpdir, err := ioutil.TempDir("", "docker-integration")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	if err := os.MkdirAll(filepath.Join(tmpdir, "dir", "sub"), 0755); err != nil {
+		c.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpdir, "file"), []byte("file\n"), 0644); err != nil {
+		c.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpdir, "dir", "sub", "file"), []byte("sub\n"), 0644); err != nil {
+		c.Fatal(err)
+	}
+
+	// a file to a new path, a directory into a directory, and the contents
+	// of a directory to a new directory
+	dockerCmd(c, "cp", filepath.Join(tmpdir, "file"), cID+":/tmp/copied")
+	dockerCmd(c, "cp", filepath.Join(tmpdir, "dir"), cID+":/tmp")
+	dockerCmd(c, "cp", filepath.Join(tmpdir, "dir", "sub")+"/.", cID+":/tmp/contents")
+
+	out, _ = dockerCmd(c, "start", "-a", cID)
+	if expected := "file\nsub\nsub\n"; out != expected {
+		c.Fatalf("Expected %q in the container, got %q", expected, out)
+	}
+
+	// a directory over a file
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "cp", filepath.Join(tmpdir, "dir"), cID+":/tmp/copied"))
+	if err == nil || !strings.Contains(out, "cannot copy directory") {
+		c.Fatalf("Expected an error copying a directory over a file, got %q", out)
+	}
+}
+
+func (s *DockerSuite) TestCpToContainerFromStdin(c *check.C) {
+	out, _ := dockerCmd(c, "create", "busybox", "cat", "/tmp/stdin")
+	cID := strings.TrimSpace(out)
+
+	out, _, err := runCommandPipelineWithOutput(
+		exec.Command("sh", "-c", "cd $(mktemp -d) && echo stdin > stdin && tar -c stdin"),
+		exec.Command(dockerBinary, "cp", "-", cID+":/tmp"))
+	if err != nil {
+		c.Fatalf("Failed to copy from stdin: %s, %v", out, err)
+	}
+
+	out, _ = dockerCmd(c, "start", "-a", cID)
+	if out != "stdin\n" {
+		c.Fatalf("Expected %q in the container, got %q", "stdin\n", out)
+	}
+}
+
+func (s *DockerSuite) TestCpToContainerReadOnly(c *check.C) {
+	out, _ := dockerCmd(c, "create", "--read-only", "-v", "/data", "busybox", "cat", "/data/file")
+	cID := strings.TrimSpace(out)
+
+	tmpdir, err := ioutil.TempDir("", "docker-integration")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	if err := ioutil.WriteFile(filepath.Join(tmpdir, "file"), []byte("file\n"), 0644); err != nil {
+		c.Fatal(err)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "cp", filepath.Join(tmpdir, "file"), cID+":/tmp"))
+	if err == nil || !strings.Contains(out, "read-only") {
+		c.Fatalf("Expected an error copying to the read-only root filesystem, got %q", out)
+	}
+
+	// the volume is writable
+	dockerCmd(c, "cp", filepath.Join(tmpdir, "file"), cID+":/data")
+	out, _ = dockerCmd(c, "start", "-a", cID)
+	if out != "file\n" {
+		c.Fatalf("Expected %q in the volume, got %q", "file\n", out)
+	}
+}
+
+func (s *DockerSuite) TestCpFromContainerRenamed(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-d", "busybox", "sh", "-c", "mkdir -p /dir/sub && echo sub > /dir/sub/file && ln -s /dir /link")
+	cID := strings.TrimSpace(out)
+	dockerCmd(c, "wait", cID)
+
+	tmpdir, err := ioutil.TempDir("", "docker-integration")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	// a directory to a new directory, through a symlink
+	dockerCmd(c, "cp", cID+":/link", filepath.Join(tmpdir, "renamed"))
+	content, err := ioutil.ReadFile(filepath.Join(tmpdir, "renamed", "sub", "file"))
+	if err != nil {
+		c.Fatal(err)
+	}
+	if string(content) != "sub\n" {
+		c.Fatalf("Wrong content in copied file %q, should be %q", content, "sub\n")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -58,6 +59,97 @@ func (s *DockerDaemonSuite) TestDaemonRestartWithRunningContainersPorts(c *check
 	testRun(map[string]bool{"top1": true, "top2": false}, "After daemon restart: ")
 }
 
+func (s *DockerDaemonSuite) TestDaemonLiveRestore(c *check.C) {
+	if err := s.d.StartWithBusybox("--live-restore"); err != nil {
+		c.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+
+	out, err := s.d.Cmd("run", "-d", "--name", "top", "busybox:latest", "top")
+	if err != nil {
+		c.Fatalf("Could not run top: err=%v\n%s", err, out)
+	}
+	pid, err := s.d.Cmd("inspect", "-f", "{{.State.Pid}}", "top")
+	if err != nil {
+		c.Fatalf("Could not inspect top: err=%v\n%s", err, pid)
+	}
+
+	if err := s.d.Restart("--live-restore"); err != nil {
+		c.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	out, err = s.d.Cmd("inspect", "-f", "{{.State.Running}} {{.State.Pid}}", "top")
+	if err != nil {
+		c.Fatalf("Could not inspect top: err=%v\n%s", err, out)
+	}
+	if expected := "true " + strings.TrimSpace(pid); strings.TrimSpace(out) != expected {
+		c.Fatalf("Expected the container to be kept running as %q, got %q", expected, out)
+	}
+
+	if out, err := s.d.Cmd("exec", "top", "true"); err != nil {
+		c.Fatalf("Could not exec in the restored container: err=%v\n%s", err, out)
+	}
+	if out, err := s.d.Cmd("stop", "top"); err != nil {
+		c.Fatalf("Could not stop the restored container: err=%v\n%s", err, out)
+	}
+	out, err = s.d.Cmd("inspect", "-f", "{{.State.Running}}", "top")
+	if err != nil || strings.TrimSpace(out) != "false" {
+		c.Fatalf("Expected the restored container to be stopped, got %v: %s", err, out)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonLiveRestoreJoinNamespaces(c *check.C) {
+	testRequires(c, NativeExecDriver)
+	if err := s.d.StartWithBusybox("--live-restore"); err != nil {
+		c.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+
+	if out, err := s.d.Cmd("run", "-d", "--name", "top", "busybox:latest", "top"); err != nil {
+		c.Fatalf("Could not run top: err=%v\n%s", err, out)
+	}
+	ip, err := s.d.Cmd("inspect", "-f", "{{.NetworkSettings.IPAddress}}", "top")
+	if err != nil {
+		c.Fatalf("Could not inspect top: err=%v\n%s", err, ip)
+	}
+
+	if err := s.d.Restart("--live-restore"); err != nil {
+		c.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	// the namespaces of the container started by the previous daemon are
+	// joined by the containers of the new one
+	out, err := s.d.Cmd("run", "--net=container:top", "--ipc=container:top", "busybox:latest", "ip", "-o", "-4", "addr", "show", "eth0")
+	if err != nil || !strings.Contains(out, " "+strings.TrimSpace(ip)+"/") {
+		c.Fatalf("Expected to join the namespaces of the restored container, got %v: %s", err, out)
+	}
+	if out, err := s.d.Cmd("stop", "top"); err != nil {
+		c.Fatalf("Could not stop the restored container: err=%v\n%s", err, out)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonRestartKeepsEvents(c *check.C) {
+	if err := s.d.StartWithBusybox(); err != nil {
+		c.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+
+	out, err := s.d.Cmd("run", "-d", "busybox:latest", "true")
+	if err != nil {
+		c.Fatalf("Could not run busybox: err=%v\n%s", err, out)
+	}
+	id := strings.TrimSpace(out)
+
+	if err := s.d.Restart(); err != nil {
+		c.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	out, err = s.d.Cmd("events", "--since=1", fmt.Sprintf("--until=%d", time.Now().Unix()), "--filter", "container="+id, "--filter", "event=die")
+	if err != nil {
+		c.Fatalf("Could not get the events: err=%v\n%s", err, out)
+	}
+	if !strings.Contains(out, id) {
+		c.Fatalf("Expected the events before the restart to be replayed, got %s", out)
+	}
+}
+
 func (s *DockerDaemonSuite) TestDaemonRestartWithVolumesRefs(c *check.C) {
 	if err := s.d.StartWithBusybox(); err != nil {
 		c.Fatal(err)
@@ -907,8 +999,44 @@ func (s *DockerDaemonSuite) TestDaemonLoggingDriverNoneLogsError(c *check.C) {
 	if err == nil {
 		c.Fatalf("Logs should fail with \"none\" driver")
 	}
-	if !strings.Contains(out, `"logs" command is supported only for "json-file" logging driver`) {
-		c.Fatalf("There should be error about non-json-file driver, got: %s", out)
+	if !strings.Contains(out, `"logs" command is not available for the "none" logging driver`) {
+		c.Fatalf("There should be error about the none driver, got: %s", out)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonLoggingDriverLocalCache(c *check.C) {
+	// gelf over UDP doesn't need a server to send the logs to
+	if err := s.d.StartWithBusybox("--log-driver=gelf", "--log-opt", "gelf-address=udp://127.0.0.1:12201"); err != nil {
+		c.Fatal(err)
+	}
+
+	out, err := s.d.Cmd("run", "-d", "busybox", "echo", "testline")
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+	if out, err := s.d.Cmd("wait", id); err != nil {
+		c.Fatal(out, err)
+	}
+	out, err = s.d.Cmd("logs", id)
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	if strings.TrimSpace(out) != "testline" {
+		c.Fatalf("Expected the logs from the local cache, got: %q", out)
+	}
+
+	out, err = s.d.Cmd("run", "-d", "--log-driver=gelf", "--log-opt", "gelf-address=udp://127.0.0.1:12201", "--log-opt", "cache-disabled=true", "busybox", "echo", "testline")
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	id = strings.TrimSpace(out)
+	out, err = s.d.Cmd("logs", id)
+	if err == nil {
+		c.Fatalf("Logs should fail with the local cache disabled")
+	}
+	if !strings.Contains(out, "local cache disabled") {
+		c.Fatalf("There should be error about the disabled cache, got: %s", out)
 	}
 }
 
@@ -980,6 +1108,36 @@ func (s *DockerDaemonSuite) TestDaemonUnixSockCleanedUp(c *check.C) {
 	}
 }
 
+func (s *DockerDaemonSuite) TestDaemonUnixSockPermissions(c *check.C) {
+	dir, err := ioutil.TempDir("", "socket-permissions-test")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opsPath := filepath.Join(dir, "docker-ops.sock")
+	if err := s.d.Start("--socket-mode=0600", "--host", "unix://"+opsPath+"?group=0&mode=0640"); err != nil {
+		c.Fatal(err)
+	}
+
+	for path, expected := range map[string]os.FileMode{
+		filepath.Join(s.d.folder, "docker.sock"): 0600,
+		opsPath:                                  0640,
+	} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			c.Fatal(err)
+		}
+		if mode := fi.Mode().Perm(); mode != expected {
+			c.Fatalf("Expected the mode of %s to be %o, got %o", path, expected, mode)
+		}
+	}
+
+	if out, err := s.d.CmdWithArgs([]string{"--host", "unix://" + opsPath}, "version"); err != nil {
+		c.Fatalf("Could not use the second socket: err=%v\n%s", err, out)
+	}
+}
+
 func (s *DockerDaemonSuite) TestDaemonWithWrongkey(c *check.C) {
 	type Config struct {
 		Crv string `json:"crv"`
@@ -1170,3 +1328,508 @@ func (s *DockerDaemonSuite) TestDaemonRestartWithSockerAsVolume(c *check.C) {
 	c.Assert(err, check.IsNil, check.Commentf("Output: %s", out))
 	c.Assert(s.d.Restart(), check.IsNil)
 }
+
+func (s *DockerDaemonSuite) TestDaemonMetrics(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	metricsAddr := "localhost:4273"
+	c.Assert(s.d.StartWithBusybox("--metrics-addr="+metricsAddr), check.IsNil)
+
+	out, err := s.d.Cmd("run", "-d", "busybox", "top")
+	c.Assert(err, check.IsNil, check.Commentf("Output: %s", out))
+
+	resp, err := http.Get("http://" + metricsAddr + "/metrics")
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, check.IsNil)
+	c.Assert(resp.Header.Get("Content-Type"), check.Equals, "text/plain; version=0.0.4")
+
+	for _, expected := range []string{
+		`engine_daemon_container_states_containers{state="running"} 1`,
+		`engine_daemon_api_request_duration_seconds_count{method="POST",route="/containers/create"} 1`,
+		`engine_daemon_graphdriver_operation_duration_seconds_count{driver=`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			c.Fatalf("Expected %q in the metrics, got:\n%s", expected, body)
+		}
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonDebugAddr(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	debugAddr := "127.0.0.1:4274"
+	c.Assert(s.d.Start("--debug-addr="+debugAddr), check.IsNil)
+
+	resp, err := http.Get("http://" + debugAddr + "/debug/stacks")
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, check.IsNil)
+	if !strings.Contains(string(body), "goroutine ") {
+		c.Fatalf("Expected the stacks of the goroutines, got:\n%s", body)
+	}
+
+	resp, err = http.Get("http://" + debugAddr + "/debug/pprof/goroutine?debug=1")
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+
+	c.Assert(s.d.Stop(), check.IsNil)
+
+	// the debug address must be a loopback address
+	c.Assert(s.d.Start("--debug-addr=0.0.0.0:4274"), check.NotNil)
+	if content, _ := ioutil.ReadFile(s.d.logFile.Name()); !strings.Contains(string(content), "is not a loopback address") {
+		c.Fatalf("Expected the daemon to refuse the debug address, got:\n%s", content)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonAuditLog(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	dir, err := ioutil.TempDir("", "docker-audit-test")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+	auditLog := filepath.Join(dir, "audit.log")
+	c.Assert(s.d.StartWithBusybox("--audit-log="+auditLog), check.IsNil)
+
+	out, err := s.d.Cmd("create", "--name", "audited", "busybox", "top")
+	c.Assert(err, check.IsNil, check.Commentf("Output: %s", out))
+	_, err = s.d.Cmd("ps", "-a")
+	c.Assert(err, check.IsNil)
+	_, err = s.d.Cmd("rm", "not-audited")
+	c.Assert(err, check.NotNil)
+
+	content, err := ioutil.ReadFile(auditLog)
+	c.Assert(err, check.IsNil)
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry map[string]interface{}
+		c.Assert(json.Unmarshal([]byte(line), &entry), check.IsNil)
+		// skip the load of busybox
+		if strings.Contains(entry["URI"].(string), "/containers/") {
+			entries = append(entries, entry)
+		}
+	}
+	// the GET requests of ps are not recorded
+	if len(entries) != 2 {
+		c.Fatalf("Expected the entries of the creation and of the removal, got:\n%s", content)
+	}
+	create, rm := entries[0], entries[1]
+	if !strings.Contains(create["URI"].(string), "/containers/create?name=audited") || create["Status"].(float64) != http.StatusCreated {
+		c.Fatalf("Unexpected entry of the creation: %v", create)
+	}
+	if body, ok := create["Body"].(map[string]interface{}); !ok || body["Image"] != "busybox" {
+		c.Fatalf("Expected the body of the creation, got %v", create["Body"])
+	}
+	if rm["Method"] != "DELETE" || rm["Status"].(float64) != http.StatusNotFound || rm["Error"] == nil {
+		c.Fatalf("Unexpected entry of the removal: %v", rm)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonMaxConcurrentBuilds(c *check.C) {
+	if err := s.d.StartWithBusybox("--max-concurrent-builds=1"); err != nil {
+		c.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+
+	build := func(name string) *exec.Cmd {
+		cmd := exec.Command(dockerBinary, "--host", s.d.sock(), "build", "-t", name, "-")
+		cmd.Stdin = strings.NewReader("FROM busybox\nRUN sleep 10\n")
+		return cmd
+	}
+
+	slow := build("slowbuild")
+	if err := slow.Start(); err != nil {
+		c.Fatal(err)
+	}
+	defer slow.Wait()
+
+	// wait for the container of the first build to run
+	for i := 0; ; i++ {
+		out, err := s.d.Cmd("ps", "-q")
+		if err != nil {
+			c.Fatalf("Could not list the containers: err=%v\n%s", err, out)
+		}
+		if strings.TrimSpace(out) != "" {
+			break
+		}
+		if i == 100 {
+			c.Fatal("The first build didn't start in time")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	out, err := build("rejectedbuild").CombinedOutput()
+	if err == nil {
+		c.Fatalf("Expected the build over the limit to fail, got\n%s", out)
+	}
+	if !strings.Contains(string(out), "Too many concurrent builds") {
+		c.Fatalf("Expected the build to be rejected over the limit, got\n%s", out)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonRestartMaxDelay(c *check.C) {
+	if err := s.d.StartWithBusybox("--restart-max-delay=500ms"); err != nil {
+		c.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+
+	out, err := s.d.Cmd("run", "-d", "--name", "crashloop", "--restart=always", "busybox", "false")
+	if err != nil {
+		c.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+
+	// the delay between the restarts doubles from 100ms, up to the maximum
+	var state struct {
+		Restarting  bool
+		FinishedAt  time.Time
+		NextRestart time.Time
+	}
+	for i := 0; ; i++ {
+		out, err := s.d.Cmd("inspect", "-f", "{{json .State}}", "crashloop")
+		if err != nil {
+			c.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+		}
+		if err := json.Unmarshal([]byte(out), &state); err != nil {
+			c.Fatal(err)
+		}
+		if state.Restarting {
+			if state.NextRestart.Before(state.FinishedAt) {
+				c.Fatalf("Expected the next restart after %s, got %s", state.FinishedAt, state.NextRestart)
+			}
+			if delay := state.NextRestart.Sub(state.FinishedAt); delay > time.Second {
+				c.Fatalf("Expected the delay between the restarts to be at most 500ms, got %s", delay)
+			}
+		}
+		if i == 50 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	count, err := s.d.Cmd("inspect", "-f", "{{.RestartCount}}", "crashloop")
+	if err != nil {
+		c.Fatalf("Could not inspect the container: err=%v\n%s", err, count)
+	}
+	if n, _ := strconv.Atoi(strings.TrimSpace(count)); n < 5 {
+		c.Fatalf("Expected the container to be restarted at least 5 times in 5 seconds, got %s", count)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonConfigFileLabels(c *check.C) {
+	f, err := ioutil.TempFile("", "docker-config-file")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(`{"label": ["rack=r12", "storage=ssd"]}`); err != nil {
+		c.Fatal(err)
+	}
+	f.Close()
+
+	if err := s.d.Start("--config-file", f.Name()); err != nil {
+		c.Fatalf("Could not start daemon with the configuration file: %v", err)
+	}
+	out, err := s.d.Cmd("info")
+	if err != nil {
+		c.Fatalf("Could not get the info of the daemon: err=%v\n%s", err, out)
+	}
+	for _, label := range []string{"rack=r12", "storage=ssd"} {
+		if !strings.Contains(out, label) {
+			c.Fatalf("Expected the label %s in the info of the daemon, got\n%s", label, out)
+		}
+	}
+	if err := s.d.Stop(); err != nil {
+		c.Fatal(err)
+	}
+
+	// an option can't be both in the file and a flag
+	if err := s.d.Start("--config-file", f.Name(), "--label", "rack=r13"); err == nil {
+		c.Fatal("Expected the daemon to fail with a label in the file and as a flag")
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonConfigFileProxies(c *check.C) {
+	f, err := ioutil.TempFile("", "docker-config-file")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(`{
+		"http-proxy": "proxy.example.com:3128",
+		"no-proxy": "internal.example.com",
+		"registry-proxy": {"registry.example.com:5000": "http://other-proxy.example.com:8080"}
+	}`); err != nil {
+		c.Fatal(err)
+	}
+	f.Close()
+
+	if err := s.d.Start("--config-file", f.Name()); err != nil {
+		c.Fatalf("Could not start daemon with the configuration file: %v", err)
+	}
+	out, err := s.d.Cmd("info")
+	if err != nil {
+		c.Fatalf("Could not get the info of the daemon: err=%v\n%s", err, out)
+	}
+	for _, line := range []string{
+		"Http Proxy: http://proxy.example.com:3128",
+		"No Proxy: internal.example.com",
+		"registry.example.com:5000: http://other-proxy.example.com:8080",
+	} {
+		if !strings.Contains(out, line) {
+			c.Fatalf("Expected %q in the info of the daemon, got\n%s", line, out)
+		}
+	}
+	if err := s.d.Stop(); err != nil {
+		c.Fatal(err)
+	}
+
+	if err := s.d.Start("--registry-proxy", "registry.example.com=ftp://proxy"); err == nil {
+		c.Fatal("Expected the daemon to fail with an ftp proxy")
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonBandwidthLimits(c *check.C) {
+	if err := s.d.Start("--max-download-bandwidth", "10m", "--max-upload-bandwidth", "512k"); err != nil {
+		c.Fatalf("Could not start daemon with bandwidth limits: %v", err)
+	}
+	if err := s.d.Stop(); err != nil {
+		c.Fatal(err)
+	}
+
+	if err := s.d.Start("--max-download-bandwidth", "fast"); err == nil {
+		c.Fatal("Expected the daemon to fail with an invalid download bandwidth")
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonShutdownOrder(c *check.C) {
+	if err := s.d.StartWithBusybox("--shutdown-timeout=2"); err != nil {
+		c.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+
+	// sleep ignores SIGTERM as the init of the containers, they are killed
+	// after the shutdown timeout
+	for _, args := range [][]string{
+		{"run", "-d", "--name", "db", "busybox", "sleep", "300"},
+		{"run", "-d", "--name", "web", "--link", "db:db", "busybox", "sleep", "300"},
+	} {
+		if out, err := s.d.Cmd(args[0], args[1:]...); err != nil {
+			c.Fatalf("Could not run the container: err=%v\n%s", err, out)
+		}
+	}
+
+	start := time.Now()
+	if err := s.d.Stop(); err != nil {
+		c.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		c.Fatalf("Expected the daemon to stop within its shutdown timeout, took %s", elapsed)
+	}
+
+	if err := s.d.Start(); err != nil {
+		c.Fatal(err)
+	}
+	finished := make(map[string]time.Time)
+	for _, name := range []string{"db", "web"} {
+		out, err := s.d.Cmd("inspect", "-f", "{{json .State.FinishedAt}}", name)
+		if err != nil {
+			c.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		var t time.Time
+		if err := json.Unmarshal([]byte(out), &t); err != nil {
+			c.Fatal(err)
+		}
+		finished[name] = t
+	}
+	if finished["web"].After(finished["db"]) {
+		c.Fatalf("Expected web to stop before db it links to, web stopped at %s and db at %s", finished["web"], finished["db"])
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonDefaultCapabilities(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	if err := s.d.StartWithBusybox("--default-cap-drop=MKNOD", "--default-cap-add=NET_ADMIN"); err != nil {
+		c.Fatal(err)
+	}
+
+	out, err := s.d.Cmd("run", "busybox", "sh", "-c", "mknod /tmp/sda b 8 0 && echo ok")
+	if err == nil || strings.Contains(out, "ok") {
+		c.Fatalf("expected mknod to be denied by the default capabilities, got %v: %s", err, out)
+	}
+	out, err = s.d.Cmd("run", "busybox", "sh", "-c", "ip link set lo down && echo ok")
+	if err != nil || strings.TrimSpace(out) != "ok" {
+		c.Fatalf("expected NET_ADMIN to be in the default capabilities, got %v: %s", err, out)
+	}
+
+	// the capabilities of the container apply on top of the default ones
+	out, err = s.d.Cmd("run", "--cap-add=MKNOD", "--cap-drop=NET_ADMIN", "busybox", "sh", "-c", "mknod /tmp/sda b 8 0 && echo ok")
+	if err != nil || strings.TrimSpace(out) != "ok" {
+		c.Fatalf("expected --cap-add to add back MKNOD, got %v: %s", err, out)
+	}
+	out, err = s.d.Cmd("run", "--cap-drop=NET_ADMIN", "busybox", "sh", "-c", "ip link set lo down && echo ok")
+	if err == nil || strings.Contains(out, "ok") {
+		c.Fatalf("expected --cap-drop to drop NET_ADMIN, got %v: %s", err, out)
+	}
+
+	// the default capabilities are shown in info
+	out, err = s.d.Cmd("info")
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if !strings.Contains(out, "Default Capabilities Added: NET_ADMIN") || !strings.Contains(out, "Default Capabilities Dropped: MKNOD") {
+		c.Fatalf("expected the default capabilities in info, got %s", out)
+	}
+
+	if err := s.d.Stop(); err != nil {
+		c.Fatal(err)
+	}
+	if err := s.d.Start("--default-cap-drop=CHPASS"); err == nil {
+		c.Fatal("expected the daemon to refuse an unknown capability")
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonEncryptRwLayers(c *check.C) {
+	testRequires(c, SameHostDaemon, FsEncryption)
+
+	if err := s.d.StartWithBusybox(); err != nil {
+		c.Fatal(err)
+	}
+	out, err := s.d.Cmd("info")
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if !strings.Contains(out, "Storage Driver: overlay\n") && !strings.Contains(out, "Storage Driver: aufs\n") {
+		c.Skip("Test requires the overlay or aufs storage driver")
+	}
+	if err := s.d.Restart("--encrypt-rw-layers"); err != nil {
+		c.Fatal(err)
+	}
+
+	out, err = s.d.Cmd("run", "--name", "encrypted", "busybox", "sh", "-c", "cat /secret 2>/dev/null || echo top secret > /secret")
+	if err != nil || strings.TrimSpace(out) != "" {
+		c.Fatalf("Expected the secret to be written, got %v: %s", err, out)
+	}
+	out, err = s.d.Cmd("inspect", "-f", "{{.Id}}", "encrypted")
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	keyPath := filepath.Join("/var/run/docker/layer-keys", strings.TrimSpace(out))
+	if _, err := os.Stat(keyPath); err != nil {
+		c.Fatalf("Expected the key of the read-write layer of the container: %v", err)
+	}
+
+	// the key of the layer is kept across restarts
+	if err := s.d.Restart("--encrypt-rw-layers"); err != nil {
+		c.Fatal(err)
+	}
+	out, err = s.d.Cmd("start", "-a", "encrypted")
+	if err != nil || strings.TrimSpace(out) != "top secret" {
+		c.Fatalf("Expected the secret to be read back, got %v: %s", err, out)
+	}
+
+	if out, err := s.d.Cmd("rm", "encrypted"); err != nil {
+		c.Fatal(err, out)
+	}
+	if _, err := os.Stat(keyPath); !os.IsNotExist(err) {
+		c.Fatalf("Expected the key to be removed with the container, got %v", err)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonGpuHook(c *check.C) {
+	if err := s.d.Start("--gpu-hook=/nonexistent/gpu-hook"); err == nil {
+		c.Fatal("expected the daemon to refuse a missing GPU hook")
+	}
+	if err := s.d.Start("--gpu-hook=/bin/true"); err != nil {
+		c.Fatal(err)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonStorageBudget(c *check.C) {
+	if err := s.d.Start("--storage-budget=lots"); err == nil {
+		c.Fatal("expected the daemon to refuse an invalid storage budget")
+	}
+	if err := s.d.Start("--storage-budget=1"); err != nil {
+		c.Fatal(err)
+	}
+
+	// the layers of the tagged image are kept, even over the budget
+	if out, err := s.d.Cmd("pull", "busybox:latest"); err != nil {
+		c.Fatal(out, err)
+	}
+	if out, err := s.d.Cmd("inspect", "busybox:latest"); err != nil {
+		c.Fatalf("expected the tagged image to be kept: %s, %v", out, err)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonExperimental(c *check.C) {
+	if err := s.d.Start("--experimental-feature=unknown"); err == nil {
+		c.Fatal("expected the daemon to refuse an unknown experimental feature")
+	}
+
+	if err := s.d.Start(); err != nil {
+		c.Fatal(err)
+	}
+	out, err := s.d.Cmd("info")
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	if strings.Contains(out, "Experimental: true") {
+		c.Fatalf("expected the experimental features to be disabled by default: %s", out)
+	}
+	if err := s.d.Restart("--experimental"); err != nil {
+		c.Fatal(err)
+	}
+	if out, err = s.d.Cmd("info"); err != nil {
+		c.Fatal(out, err)
+	}
+	if !strings.Contains(out, "Experimental: true") {
+		c.Fatalf("expected --experimental in the info: %s", out)
+	}
+}
+
+func (s *DockerDaemonSuite) TestDaemonRuntimes(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	if err := s.d.Start("--add-runtime=oci=bin/runc"); err == nil {
+		c.Fatal("expected the daemon to refuse a runtime with a relative path")
+	}
+	if err := s.d.Start("--add-runtime=native=/bin/true"); err == nil {
+		c.Fatal("expected the daemon to refuse to replace the native runtime")
+	}
+
+	runc, err := exec.LookPath("runc")
+	if err != nil {
+		runc = "/bin/true"
+	}
+	if err := s.d.Start("--add-runtime=oci="+runc, "--runtime-arg=other=--debug"); err == nil {
+		c.Fatal("expected the daemon to refuse an argument of an unknown runtime")
+	}
+	if err := s.d.StartWithBusybox("--add-runtime=oci="+runc, "--runtime-arg=oci=--debug", "--runtime-env=oci=OCI_TEST=secret"); err != nil {
+		c.Fatal(err)
+	}
+	out, err := s.d.Cmd("info")
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if !strings.Contains(out, " oci: "+runc+" --debug\n") || !strings.Contains(out, "Environment: OCI_TEST\n") || strings.Contains(out, "secret") {
+		c.Fatalf("expected the runtime with its arguments in info, got %s", out)
+	}
+	out, err = s.d.Cmd("run", "--runtime=unknown", "busybox", "true")
+	if err == nil || !strings.Contains(out, "Unknown runtime unknown") {
+		c.Fatalf("expected the unknown runtime to be refused, got %v: %s", err, out)
+	}
+	out, err = s.d.Cmd("run", "--runtime=native", "busybox", "echo", "native")
+	if err != nil || strings.TrimSpace(out) != "native" {
+		c.Fatalf("expected the container to be run by the exec driver, got %v: %s", err, out)
+	}
+	if runc == "/bin/true" {
+		return
+	}
+
+	out, err = s.d.Cmd("run", "--name", "oci", "--runtime=oci", "busybox", "ip", "-o", "addr", "show", "eth0")
+	if err != nil || !strings.Contains(out, "inet ") {
+		c.Fatalf("expected the container to be run by runc with a network, got %v: %s", err, out)
+	}
+	out, err = s.d.Cmd("inspect", "-f", "{{.HostConfig.Runtime}}", "oci")
+	if err != nil || strings.TrimSpace(out) != "oci" {
+		c.Fatalf("expected the runtime of the container, got %v: %s", err, out)
+	}
+}
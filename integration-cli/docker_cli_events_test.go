@@ -372,6 +372,42 @@ func (s *DockerSuite) TestEventsFilters(c *check.C) {
 
 }
 
+func (s *DockerSuite) TestEventsFilterLabels(c *check.C) {
+	since := daemonTime(c).Unix()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--label", "com.example.events=labeled", "busybox", "true"))
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	labeled := strings.TrimSpace(out)
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--label", "com.example.events=other", "busybox", "true"))
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	other := strings.TrimSpace(out)
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "busybox", "true"))
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	unlabeled := strings.TrimSpace(out)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", daemonTime(c).Unix()), "--filter", "label=com.example.events=labeled"))
+	if err != nil {
+		c.Fatalf("Failed to get events: %s", err)
+	}
+	if !strings.Contains(out, labeled) || strings.Contains(out, other) || strings.Contains(out, unlabeled) {
+		c.Fatalf("Expected only the events of the labeled container, got %s", out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", daemonTime(c).Unix()), "--filter", "label=com.example.events"))
+	if err != nil {
+		c.Fatalf("Failed to get events: %s", err)
+	}
+	if !strings.Contains(out, labeled) || !strings.Contains(out, other) || strings.Contains(out, unlabeled) {
+		c.Fatalf("Expected only the events of the containers with the label, got %s", out)
+	}
+}
+
 func (s *DockerSuite) TestEventsFilterImageName(c *check.C) {
 	since := daemonTime(c).Unix()
 
@@ -38,6 +38,28 @@ func (s *DockerSuite) TestExec(c *check.C) {
 
 }
 
+func (s *DockerSuite) TestExecEnvFlags(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "testing", "-e", "FOO=container", "-e", "BAR=container", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+
+	execCmd := exec.Command(dockerBinary, "exec", "-e", "FOO=exec", "-e", "BAZ=exec", "testing", "env")
+	out, _, err := runCommandWithOutput(execCmd)
+	if err != nil {
+		c.Fatal(out, err)
+	}
+
+	for _, expected := range []string{"FOO=exec", "BAR=container", "BAZ=exec"} {
+		if !strings.Contains(out, expected+"\n") {
+			c.Fatalf("Expected %q in the environment of the exec, got %q", expected, out)
+		}
+	}
+	if strings.Contains(out, "FOO=container") {
+		c.Fatalf("Expected FOO to be overridden by the exec, got %q", out)
+	}
+}
+
 func (s *DockerSuite) TestExecInteractive(c *check.C) {
 
 	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "testing", "busybox", "sh", "-c", "echo test > /tmp/file && top")
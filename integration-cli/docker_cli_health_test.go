@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/runconfig"
+	"github.com/go-check/check"
+)
+
+func waitForHealthStatus(c *check.C, name, prev, expected string) {
+	for i := 0; i < 30; i++ {
+		out, err := inspectField(name, "State.Health.Status")
+		c.Assert(err, check.IsNil)
+		if out == expected {
+			return
+		}
+		if out != prev {
+			c.Fatalf("expected the health status of %s to go from %q to %q, got %q", name, prev, expected, out)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	c.Fatalf("timeout waiting for the health status of %s to be %q", name, expected)
+}
+
+func getHealth(c *check.C, name string) *types.Health {
+	out, err := inspectFieldJSON(name, "State.Health")
+	c.Assert(err, check.IsNil)
+	var health types.Health
+	if err := json.Unmarshal([]byte(out), &health); err != nil {
+		c.Fatalf("unable to unmarshal the health of %s: %v", name, err)
+	}
+	return &health
+}
+
+func (s *DockerSuite) TestBuildHealthcheck(c *check.C) {
+	name := "testbuildhealthcheck"
+	defer deleteImages(name)
+	_, err := buildImage(name,
+		`FROM busybox
+		HEALTHCHECK --interval=5s --timeout=3s --retries=2 CMD cat /status`,
+		true)
+	if err != nil {
+		c.Fatal(err)
+	}
+
+	out, err := inspectFieldJSON(name, "Config.Healthcheck")
+	c.Assert(err, check.IsNil)
+	var config runconfig.HealthConfig
+	if err := json.Unmarshal([]byte(out), &config); err != nil {
+		c.Fatal(err)
+	}
+	if len(config.Test) != 2 || config.Test[0] != "CMD-SHELL" || config.Test[1] != "cat /status" {
+		c.Fatalf("unexpected health check command: %q", config.Test)
+	}
+	if config.Interval != 5*time.Second || config.Timeout != 3*time.Second || config.Retries != 2 {
+		c.Fatalf("unexpected health check options: %s", out)
+	}
+
+	// HEALTHCHECK NONE disables the check of the base image
+	child := "testbuildhealthchecknone"
+	defer deleteImages(child)
+	_, err = buildImage(child, "FROM "+name+"\nHEALTHCHECK NONE", true)
+	if err != nil {
+		c.Fatal(err)
+	}
+	out, err = inspectField(child, "Config.Healthcheck.Test")
+	c.Assert(err, check.IsNil)
+	if out != "[NONE]" {
+		c.Fatalf("expected the health check to be disabled, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestHealthRun(c *check.C) {
+	name := "testhealthrun"
+	defer deleteImages(name)
+	_, err := buildImage(name,
+		`FROM busybox
+		RUN echo OK > /status
+		CMD ["/bin/sleep", "120"]
+		HEALTHCHECK --interval=1s --timeout=30s CMD cat /status`,
+		true)
+	if err != nil {
+		c.Fatal(err)
+	}
+
+	dockerCmd(c, "run", "-d", "--name=fatty", name)
+	out, _ := dockerCmd(c, "ps")
+	if !strings.Contains(out, "(health: starting)") {
+		c.Fatalf("expected the health to be starting in docker ps: %s", out)
+	}
+	waitForHealthStatus(c, "fatty", "starting", "healthy")
+
+	// the container becomes unhealthy after the default 3 retries
+	dockerCmd(c, "exec", "fatty", "rm", "/status")
+	waitForHealthStatus(c, "fatty", "healthy", "unhealthy")
+
+	health := getHealth(c, "fatty")
+	if health.FailingStreak < 3 {
+		c.Fatalf("expected at least 3 failed checks, got %d", health.FailingStreak)
+	}
+	last := health.Log[len(health.Log)-1]
+	if last.ExitCode != 1 || !strings.Contains(last.Output, "No such file or directory") {
+		c.Fatalf("unexpected result of the last check: %+v", last)
+	}
+
+	dockerCmd(c, "exec", "fatty", "sh", "-c", "echo OK > /status")
+	waitForHealthStatus(c, "fatty", "unhealthy", "healthy")
+
+	// --no-healthcheck disables the check of the image
+	dockerCmd(c, "run", "-d", "--name=nocheck", "--no-healthcheck", name)
+	out, err = inspectFieldJSON("nocheck", "State.Health")
+	c.Assert(err, check.IsNil)
+	if out != "null" {
+		c.Fatalf("expected no health for nocheck, got %s", out)
+	}
+
+	// the check of the image can be overridden
+	dockerCmd(c, "run", "-d", "--name=fail", "--health-cmd=false", "--health-interval=1s", "--health-retries=1", name)
+	waitForHealthStatus(c, "fail", "starting", "unhealthy")
+}
+
+func (s *DockerSuite) TestHealthEvents(c *check.C) {
+	since := daemonTime(c).Unix()
+	dockerCmd(c, "run", "-d", "--name=healthevents", "--health-cmd=true", "--health-interval=1s", "busybox", "sleep", "120")
+	waitForHealthStatus(c, "healthevents", "starting", "healthy")
+
+	out, _ := dockerCmd(c, "events",
+		fmt.Sprintf("--since=%d", since),
+		fmt.Sprintf("--until=%d", daemonTime(c).Unix()))
+	if !strings.Contains(out, "health_status: healthy") {
+		c.Fatalf("expected a health_status event: %s", out)
+	}
+}
@@ -0,0 +1,108 @@
+// +build !windows
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/pkg/imagepolicy"
+	"github.com/go-check/check"
+)
+
+const imagePolicyPluginName = "test-image-policy-plugin"
+
+// imagePolicyPlugin is an image policy plugin denying the images labeled
+// "denied", counting the images it checked.
+type imagePolicyPlugin struct {
+	sync.Mutex
+	server *httptest.Server
+	checks int
+}
+
+func newImagePolicyPlugin(c *check.C) *imagePolicyPlugin {
+	p := &imagePolicyPlugin{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Plugin.Activate", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"Implements": [%q]}`, imagepolicy.ImagePolicyImplements)
+	})
+	mux.HandleFunc("/"+imagepolicy.ImagePolicyCheck, func(w http.ResponseWriter, r *http.Request) {
+		var req imagepolicy.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p.Lock()
+		p.checks++
+		p.Unlock()
+		if _, ok := req.Labels["denied"]; ok {
+			json.NewEncoder(w).Encode(&imagepolicy.Response{Msg: "the image is labeled denied"})
+			return
+		}
+		json.NewEncoder(w).Encode(&imagepolicy.Response{Allow: true})
+	})
+	p.server = httptest.NewServer(mux)
+
+	if err := os.MkdirAll("/usr/share/docker/plugins", 0755); err != nil {
+		c.Fatal(err)
+	}
+	spec := filepath.Join("/usr/share/docker/plugins", imagePolicyPluginName+".spec")
+	if err := ioutil.WriteFile(spec, []byte(p.server.URL), 0644); err != nil {
+		c.Fatal(err)
+	}
+	return p
+}
+
+func (p *imagePolicyPlugin) Close() {
+	p.server.Close()
+	os.Remove(filepath.Join("/usr/share/docker/plugins", imagePolicyPluginName+".spec"))
+}
+
+func (p *imagePolicyPlugin) Checks() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.checks
+}
+
+func (s *DockerDaemonSuite) TestImagePolicyPluginDenyImage(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	p := newImagePolicyPlugin(c)
+	defer p.Close()
+
+	if err := s.d.StartWithBusybox("--image-policy-plugin=" + imagePolicyPluginName); err != nil {
+		c.Fatal(err)
+	}
+
+	// the decisions are cached per image
+	for i := 0; i < 2; i++ {
+		if out, err := s.d.Cmd("run", "--rm", "busybox", "true"); err != nil {
+			c.Fatal(err, out)
+		}
+	}
+	if checks := p.Checks(); checks != 1 {
+		c.Fatalf("Expected the image to be checked once, got %d checks", checks)
+	}
+
+	cmd := exec.Command(dockerBinary, "--host", s.d.sock(), "build", "-t", "denied-image", "-")
+	cmd.Stdin = strings.NewReader("FROM busybox\nLABEL denied=true")
+	if out, _, err := runCommandWithOutput(cmd); err != nil {
+		c.Fatal(err, out)
+	}
+
+	out, err := s.d.Cmd("run", "denied-image", "true")
+	if err == nil || !strings.Contains(out, "Image denied-image denied by image policy plugin "+imagePolicyPluginName+": the image is labeled denied") {
+		c.Fatalf("Expected the image to be denied, got %v: %s", err, out)
+	}
+	if out, err := s.d.Cmd("ps", "-aq"); err != nil || strings.TrimSpace(out) != "" {
+		c.Fatalf("Expected no container to be created, got %v: %s", err, out)
+	}
+}
@@ -201,3 +201,20 @@ func (s *DockerSuite) TestImagesEnsureDanglingImageOnlyListedOnce(c *check.C) {
 	}
 
 }
+
+func (s *DockerSuite) TestImagesFormat(c *check.C) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "tag", "busybox", "formatted:v1"))
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	defer deleteImages("formatted:v1")
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "images", "--format", "{{.Repository}}:{{.Tag}}", "formatted"))
+	if err != nil {
+		c.Fatal(out, err)
+	}
+
+	if expected := "formatted:v1"; strings.TrimSpace(out) != expected {
+		c.Fatalf("Expected %q, got %q", expected, out)
+	}
+}
@@ -50,3 +50,37 @@ func (s *DockerSuite) TestImportBadURL(c *check.C) {
 		c.Fatalf("expected an error msg but didn't get one:\n%s", out)
 	}
 }
+
+func (s *DockerSuite) TestImportWithMessageAndChanges(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		c.Fatal("failed to create a container", out, err)
+	}
+	cleanedContainerID := strings.TrimSpace(out)
+
+	out, _, err = runCommandPipelineWithOutput(
+		exec.Command(dockerBinary, "export", cleanedContainerID),
+		exec.Command(dockerBinary, "import", "-m", "imported with a message", "-c", "ENV DEBUG true", "-"),
+	)
+	if err != nil {
+		c.Fatalf("import failed with errors: %v, output: %q", err, out)
+	}
+	image := strings.TrimSpace(out)
+
+	comment, err := inspectField(image, "Comment")
+	if err != nil {
+		c.Fatal(err)
+	}
+	if comment != "imported with a message" {
+		c.Fatalf("Expected the comment of the image to be the message, got %q", comment)
+	}
+
+	env, err := inspectFieldJSON(image, "Config.Env")
+	if err != nil {
+		c.Fatal(err)
+	}
+	if !strings.Contains(env, "DEBUG=true") {
+		c.Fatalf("Expected the changes to be applied to the image, got the env %s", env)
+	}
+}
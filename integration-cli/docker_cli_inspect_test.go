@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -89,3 +91,81 @@ func (s *DockerSuite) TestInspectContainerFilterInt(c *check.C) {
 		c.Fatalf("Expected exitcode: %d for container: %s", exitCode, id)
 	}
 }
+
+func (s *DockerSuite) TestInspectTypeFlag(c *check.C) {
+	// the container has the name of the image
+	dockerCmd(c, "create", "--name", "busybox", "busybox", "true")
+
+	out, _ := dockerCmd(c, "inspect", "-f", "{{.Name}}", "busybox")
+	if strings.TrimSpace(out) != "/busybox" {
+		c.Fatalf("Expected the container without --type, got %q", out)
+	}
+	out, _ = dockerCmd(c, "inspect", "--type", "container", "-f", "{{.Name}}", "busybox")
+	if strings.TrimSpace(out) != "/busybox" {
+		c.Fatalf("Expected the container with --type container, got %q", out)
+	}
+	out, _ = dockerCmd(c, "inspect", "--type", "image", "-f", "{{.Os}}", "busybox")
+	if strings.TrimSpace(out) != "linux" {
+		c.Fatalf("Expected the image with --type image, got %q", out)
+	}
+
+	dockerCmd(c, "volume", "create", "--name", "inspecttype")
+	out, _ = dockerCmd(c, "inspect", "--type", "volume", "-f", "{{.Name}} {{.Driver}}", "inspecttype")
+	if strings.TrimSpace(out) != "inspecttype local" {
+		c.Fatalf("Expected the volume with --type volume, got %q", out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "inspect", "--type", "volume", "busybox"))
+	if err == nil || !strings.Contains(out, "No such volume: busybox") {
+		c.Fatalf("Expected no volume named busybox, got %s, %v", out, err)
+	}
+}
+
+func (s *DockerSuite) TestInspectInvalidTypeFlag(c *check.C) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "inspect", "--type", "foo", "busybox"))
+	if err == nil || !strings.Contains(out, `"foo" is not a valid value for --type`) {
+		c.Fatalf("Expected an error for an invalid type, got %s, %v", out, err)
+	}
+}
+
+func (s *DockerSuite) TestInspectMultipleObjectsWithErrors(c *check.C) {
+	dockerCmd(c, "create", "--name", "inspect1", "busybox", "true")
+	dockerCmd(c, "create", "--name", "inspect2", "busybox", "true")
+
+	// the template fails on the image, which is reported with the missing object
+	runCmd := exec.Command(dockerBinary, "inspect", "-f", "{{.Name}} {{len .Args}}", "inspect1", "missing", "busybox", "inspect2")
+	stdout, stderr, _, err := runCommandWithStdoutStderr(runCmd)
+	if err == nil {
+		c.Fatalf("Expected an error, got %s", stdout)
+	}
+	if stdout != "/inspect1 0\n/inspect2 0\n" {
+		c.Fatalf("Expected the containers in order, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "No such container, image or volume: missing") || !strings.Contains(stderr, "Template execution error for busybox") {
+		c.Fatalf("Expected the errors of the missing object and the image, got %q", stderr)
+	}
+}
+
+func (s *DockerSuite) TestInspectRuntimeState(c *check.C) {
+	testRequires(c, NativeExecDriver, SameHostDaemon)
+	out, _ := dockerCmd(c, "run", "-d", "-m", "32m", "--cpu-shares", "512", "--security-opt", "seccomp:unconfined", "busybox", "top")
+	id := strings.TrimSpace(out)
+
+	out, _ = dockerCmd(c, "inspect", "-f", "{{.RuntimeState.Resources.Memory}} {{.RuntimeState.Resources.CpuShares}} {{.RuntimeState.SeccompProfile}}", id)
+	if expected := "33554432 512 unconfined"; strings.TrimSpace(out) != expected {
+		c.Fatalf("Expected the runtime state %q, got %q", expected, out)
+	}
+
+	out, _ = dockerCmd(c, "inspect", "-f", "{{.RuntimeState.CgroupPaths.memory}}", id)
+	limit, err := ioutil.ReadFile(filepath.Join(strings.TrimSpace(out), "memory.limit_in_bytes"))
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.TrimSpace(string(limit)), check.Equals, "33554432")
+
+	out, _ = dockerCmd(c, "inspect", "-f", "{{.RuntimeState.Stats.PidsStats.Current}}", id)
+	c.Assert(strings.TrimSpace(out), check.Equals, "1")
+
+	// the runtime state is only set while the container is running
+	dockerCmd(c, "stop", id)
+	out, _ = dockerCmd(c, "inspect", "-f", "{{.RuntimeState}}", id)
+	c.Assert(strings.TrimSpace(out), check.Equals, "<nil>")
+}
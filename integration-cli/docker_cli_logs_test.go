@@ -247,6 +247,85 @@ func (s *DockerSuite) TestLogsTail(c *check.C) {
 	deleteContainer(cleanedContainerID)
 }
 
+func (s *DockerSuite) TestLogsRotatedFiles(c *check.C) {
+	testLen := 100
+	out, _ := dockerCmd(c, "run", "-d", "--log-opt", "max-size=1k", "--log-opt", "max-file=3", "busybox", "sh", "-c", fmt.Sprintf("for i in $(seq 1 %d); do echo line$i; done;", testLen))
+	cleanedContainerID := strings.TrimSpace(out)
+	dockerCmd(c, "wait", cleanedContainerID)
+
+	// the tail spans the current and the rotated log files
+	out, _ = dockerCmd(c, "logs", "--tail", "20", cleanedContainerID)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 20 {
+		c.Fatalf("Expected log %d lines, received %d\n", 20, len(lines))
+	}
+	for i, line := range lines {
+		if expected := fmt.Sprintf("line%d", testLen-19+i); line != expected {
+			c.Fatalf("Expected %q, got %q", expected, line)
+		}
+	}
+
+	// the oldest lines were dropped with the oldest log file, the others
+	// are in order
+	out, _ = dockerCmd(c, "logs", cleanedContainerID)
+	lines = strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) >= testLen {
+		c.Fatalf("Expected the oldest lines to be dropped, got %d lines", len(lines))
+	}
+	for i, line := range lines {
+		if expected := fmt.Sprintf("line%d", testLen-len(lines)+1+i); line != expected {
+			c.Fatalf("Expected %q, got %q", expected, line)
+		}
+	}
+
+	deleteContainer(cleanedContainerID)
+}
+
+func (s *DockerSuite) TestLogsNonBlockingMode(c *check.C) {
+	testLen := 100
+	out, _ := dockerCmd(c, "run", "-d", "--log-opt", "mode=non-blocking", "--log-opt", "max-buffer-size=1m", "busybox", "sh", "-c", fmt.Sprintf("for i in $(seq 1 %d); do echo line$i; done;", testLen))
+	cleanedContainerID := strings.TrimSpace(out)
+	dockerCmd(c, "wait", cleanedContainerID)
+
+	// the buffer is large enough for all the lines to be delivered
+	out, _ = dockerCmd(c, "logs", cleanedContainerID)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != testLen {
+		c.Fatalf("Expected log %d lines, received %d\n", testLen, len(lines))
+	}
+	for i, line := range lines {
+		if expected := fmt.Sprintf("line%d", i+1); line != expected {
+			c.Fatalf("Expected %q, got %q", expected, line)
+		}
+	}
+
+	deleteContainer(cleanedContainerID)
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--log-opt", "mode=maybe", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "invalid value for log opt 'mode'") {
+		c.Fatalf("Expected an error about the invalid mode, got %v: %s", err, out)
+	}
+}
+
+func (s *DockerSuite) TestLogsDetails(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-d", "--label", "com.example.app=web", "-e", "ENV=prod", "--log-opt", "labels=com.example.app", "--log-opt", "env=ENV", "busybox", "echo", "hello")
+	cleanedContainerID := strings.TrimSpace(out)
+	dockerCmd(c, "wait", cleanedContainerID)
+
+	out, _ = dockerCmd(c, "logs", "--details", cleanedContainerID)
+	if expected := "ENV=prod,com.example.app=web hello\n"; out != expected {
+		c.Fatalf("Expected %q, got %q", expected, out)
+	}
+
+	// the details are only shown when asked for
+	out, _ = dockerCmd(c, "logs", cleanedContainerID)
+	if out != "hello\n" {
+		c.Fatalf("Expected the logs without details, got %q", out)
+	}
+
+	deleteContainer(cleanedContainerID)
+}
+
 func (s *DockerSuite) TestLogsFollowStopped(c *check.C) {
 	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "echo", "hello")
 
@@ -354,6 +433,67 @@ func (s *DockerSuite) TestLogsSinceFutureFollow(c *check.C) {
 	}
 }
 
+func (s *DockerSuite) TestLogsUntil(c *check.C) {
+	name := "testlogsuntil"
+	out, _ := dockerCmd(c, "run", "--name="+name, "busybox", "/bin/sh", "-c", "for i in $(seq 1 3); do echo `date +%s` log$i; sleep 2; done")
+
+	log2Line := strings.Split(strings.Split(out, "\n")[1], " ")
+	t, err := strconv.ParseInt(log2Line[0], 10, 64) // the timestamp log2 is writen
+	c.Assert(err, check.IsNil)
+	until := t + 1 // add 1s so log3 doesn't show up
+	out, _ = dockerCmd(c, "logs", fmt.Sprintf("--until=%v", until), name)
+	if !strings.Contains(out, "log1") || !strings.Contains(out, "log2") {
+		c.Fatalf("expected log1 and log2 until=%v\nout=%v", until, out)
+	}
+	if strings.Contains(out, "log3") {
+		c.Fatalf("unexpected log message returned=log3, until=%v\nout=%v", until, out)
+	}
+
+	// relative times are relative to the time of the client
+	out, _ = dockerCmd(c, "logs", "--until=1h", name)
+	if strings.TrimSpace(out) != "" {
+		c.Fatalf("expected no logs until an hour ago\nout=%v", out)
+	}
+	out, _ = dockerCmd(c, "logs", "--since=1h", name)
+	for _, v := range []string{"log1", "log2", "log3"} {
+		if !strings.Contains(out, v) {
+			c.Fatalf("expected %v since an hour ago\nout=%v", v, out)
+		}
+	}
+}
+
+func (s *DockerSuite) TestLogsUntilFollow(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-d", "busybox", "/bin/sh", "-c", "while true; do date +%s; sleep 1; done")
+	cleanedContainerID := strings.TrimSpace(out)
+	defer deleteContainer(cleanedContainerID)
+
+	until := daemonTime(c).Unix() + 2
+	logCmd := exec.Command(dockerBinary, "logs", "-f", fmt.Sprintf("--until=%v", until), cleanedContainerID)
+	errChan := make(chan error)
+	go func() {
+		var err error
+		out, _, err = runCommandWithOutput(logCmd)
+		errChan <- err
+	}()
+
+	// following stops at until even though the container keeps running
+	select {
+	case err := <-errChan:
+		c.Assert(err, check.IsNil)
+	case <-time.After(10 * time.Second):
+		c.Fatal("Following logs didn't stop at until")
+	}
+	for _, v := range strings.Split(strings.TrimSpace(out), "\n") {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.Fatalf("cannot parse timestamp output from log: '%v'\nout=%s", v, out)
+		}
+		if ts > until {
+			c.Fatalf("later log found. until=%v logdate=%v", until, ts)
+		}
+	}
+}
+
 // Regression test for #8832
 func (s *DockerSuite) TestLogsFollowSlowStdoutConsumer(c *check.C) {
 	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "/bin/sh", "-c", `usleep 200000;yes X | head -c 200000`)
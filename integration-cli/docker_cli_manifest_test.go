@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/registry"
+	"github.com/go-check/check"
+)
+
+func (s *DockerRegistrySuite) TestManifestCreatePush(c *check.C) {
+	repoName := fmt.Sprintf("%v/dockercli/busybox", privateRegistryURL)
+	image := repoName + ":" + runtime.GOARCH
+	list := repoName + ":multi"
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "tag", "busybox", image)); err != nil {
+		c.Fatalf("Failed to tag image %v: error %v, output %q", image, err, out)
+	}
+	if out, err := exec.Command(dockerBinary, "push", image).CombinedOutput(); err != nil {
+		c.Fatalf("Failed to push image %v: error %v, output %q", image, err, string(out))
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "manifest", "create", list, image)); err != nil {
+		c.Fatalf("Failed to create manifest list: error %v, output %q", err, out)
+	}
+	defer exec.Command(dockerBinary, "manifest", "rm", list).Run()
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "manifest", "create", list, image)); err == nil || !strings.Contains(out, "--amend") {
+		c.Fatalf("Expected the existing manifest list not to be created again, got %v: %s", err, out)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "manifest", "create", list+"-other", "busybox:latest")); err == nil || !strings.Contains(out, "is not in the repository") {
+		c.Fatalf("Expected an image of another repository to be refused, got %v: %s", err, out)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "manifest", "annotate", "--variant", "v1", list, image)); err != nil {
+		c.Fatalf("Failed to annotate manifest list: error %v, output %q", err, out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "manifest", "inspect", list))
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	var manifestList registry.ManifestList
+	if err := json.Unmarshal([]byte(out), &manifestList); err != nil {
+		c.Fatal(err, out)
+	}
+	if len(manifestList.Manifests) != 1 {
+		c.Fatalf("Expected one manifest, got %s", out)
+	}
+	if p := manifestList.Manifests[0].Platform; p.OS != "linux" || p.Architecture != runtime.GOARCH || p.Variant != "v1" {
+		c.Fatalf("Unexpected platform %+v", p)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "manifest", "push", "--purge", list)); err != nil || !strings.HasPrefix(out, "sha256:") {
+		c.Fatalf("Failed to push manifest list: error %v, output %q", err, out)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "manifest", "push", list)); err == nil || !strings.Contains(out, "No such manifest list") {
+		c.Fatalf("Expected the pushed manifest list to be purged, got %v: %s", err, out)
+	}
+
+	// the image of the platform of the host is pulled with the list
+	if out, err := exec.Command(dockerBinary, "rmi", image).CombinedOutput(); err != nil {
+		c.Fatalf("Failed to clean images: error %v, output %q", err, string(out))
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "pull", list)); err != nil {
+		c.Fatalf("Failed to pull %v: error %v, output %q", list, err, out)
+	}
+	dockerCmd(c, "run", "--rm", list, "true")
+
+	other := "linux/s390x"
+	if runtime.GOARCH == "s390x" {
+		other = "linux/arm64"
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "pull", "--platform", other, list))
+	if err == nil || !strings.Contains(out, "has no image of platform "+other) {
+		c.Fatalf("Expected the pull of another platform to fail, got %v: %s", err, out)
+	}
+}
@@ -95,3 +95,42 @@ func (s *DockerSuite) TestPauseMultipleContainers(c *check.C) {
 	}
 
 }
+
+func (s *DockerSuite) TestPauseConflicts(c *check.C) {
+	defer unpauseAllContainers()
+
+	name := "testpauseconflicts"
+	dockerCmd(c, "run", "-d", "--name", name, "busybox", "top")
+	dockerCmd(c, "pause", name)
+
+	out, _ := dockerCmd(c, "inspect", "-f", "{{.State.PausedAt.IsZero}}", name)
+	if strings.TrimSpace(out) != "false" {
+		c.Fatalf("expected the time the container was paused, got %s", out)
+	}
+
+	for _, args := range [][]string{
+		{"stop", name},
+		{"restart", name},
+		{"kill", name},
+		{"exec", name, "true"},
+	} {
+		out, _, err := runCommandWithOutput(exec.Command(dockerBinary, args...))
+		if err == nil || !strings.Contains(out, "is paused, unpause the container before") {
+			c.Fatalf("expected %s to be refused for the paused container, got %v: %s", args[0], err, out)
+		}
+	}
+
+	// a commit of the paused container leaves it paused
+	dockerCmd(c, "commit", name, "testpauseconflicts")
+	defer deleteImages("testpauseconflicts")
+	out, _ = dockerCmd(c, "inspect", "-f", "{{.State.Paused}}", name)
+	if strings.TrimSpace(out) != "true" {
+		c.Fatalf("expected the container to be kept paused by the commit, got %s", out)
+	}
+
+	// the paused container is removed with rm -f
+	dockerCmd(c, "rm", "-f", name)
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "inspect", name)); err == nil {
+		c.Fatalf("expected the paused container to be removed, got %s", out)
+	}
+}
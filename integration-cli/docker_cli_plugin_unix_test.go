@@ -0,0 +1,158 @@
+// +build !windows
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/libtrust"
+	"github.com/go-check/check"
+)
+
+const signedPluginName = "test-signed-volume-driver"
+
+// writeSignedPluginManifest writes the manifest of a plugin signed by key to
+// a file of dir.
+func writeSignedPluginManifest(c *check.C, dir string, key libtrust.PrivateKey, addr, privileges string) string {
+	manifest := fmt.Sprintf(`{
+   "Name": %q,
+   "Implements": ["VolumeDriver"],
+   "Address": %q,
+   "Privileges": %s
+}`, signedPluginName, addr, privileges)
+	sig, err := libtrust.NewJSONSignature([]byte(manifest))
+	if err != nil {
+		c.Fatal(err)
+	}
+	if err := sig.Sign(key); err != nil {
+		c.Fatal(err)
+	}
+	signed, err := sig.PrettySignature("signatures")
+	if err != nil {
+		c.Fatal(err)
+	}
+	f, err := ioutil.TempFile(dir, "manifest")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(signed); err != nil {
+		c.Fatal(err)
+	}
+	return f.Name()
+}
+
+func (s *DockerDaemonSuite) TestPluginInstallSigned(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	p := newExternalVolumePlugin(c)
+	defer p.Close()
+
+	dir, err := ioutil.TempDir("", "docker-plugin-test")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	publisher, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		c.Fatal(err)
+	}
+	trustDir := filepath.Join(dir, "trust")
+	if err := os.MkdirAll(trustDir, 0700); err != nil {
+		c.Fatal(err)
+	}
+	if err := libtrust.SavePublicKey(filepath.Join(trustDir, "publisher.json"), publisher.PublicKey()); err != nil {
+		c.Fatal(err)
+	}
+	if err := s.d.StartWithBusybox("--plugin-trust-dir", trustDir); err != nil {
+		c.Fatal(err)
+	}
+
+	manifest := writeSignedPluginManifest(c, dir, publisher, p.server.URL, `{"Mounts": ["/var/lib/test-plugin"], "Capabilities": ["SYS_ADMIN"]}`)
+
+	// the privileges of the plugin are shown before they're granted
+	cmd := exec.Command(dockerBinary, "--host", s.d.sock(), "plugin", "install", manifest)
+	cmd.Stdin = strings.NewReader("n\n")
+	out, _, err := runCommandWithOutput(cmd)
+	if err == nil || !strings.Contains(out, "mounts (access to the paths of the host): /var/lib/test-plugin") || !strings.Contains(out, "capabilities (the Linux capabilities): SYS_ADMIN") {
+		c.Fatalf("Expected the privileges to be denied, got %v: %s", err, out)
+	}
+	if out, err := s.d.Cmd("plugin", "ls", "-q"); err != nil || strings.TrimSpace(out) != "" {
+		c.Fatalf("Expected no plugin to be installed, got %v: %s", err, out)
+	}
+
+	cmd = exec.Command(dockerBinary, "--host", s.d.sock(), "plugin", "install", manifest)
+	cmd.Stdin = strings.NewReader("y\n")
+	if out, _, err := runCommandWithOutput(cmd); err != nil || !strings.Contains(out, signedPluginName) {
+		c.Fatalf("Could not install the plugin: %v: %s", err, out)
+	}
+
+	out, err = s.d.Cmd("plugin", "inspect", "-f", "{{.Address}} {{.Publisher}} {{len .Privileges}}", signedPluginName)
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if expected := fmt.Sprintf("%s %s 2", p.server.URL, publisher.KeyID()); strings.TrimSpace(out) != expected {
+		c.Fatalf("Expected %q, got %q", expected, out)
+	}
+
+	// the installed plugin is a volume driver of the daemon
+	out, err = s.d.Cmd("run", "--rm", "--volume-driver", signedPluginName, "-v", "signed-volume-test:/tmp/signed-volume-test", "busybox", "cat", "/tmp/signed-volume-test/test")
+	if err != nil || strings.TrimSpace(out) != p.server.URL {
+		c.Fatalf("Expected the content of the volume of the plugin, got %v: %s", err, out)
+	}
+	if out, err := s.d.Cmd("plugin", "rm", signedPluginName); err == nil || !strings.Contains(out, "is the driver of volume signed-volume-test") {
+		c.Fatalf("Expected an error removing the plugin of a volume, got %v: %s", err, out)
+	}
+	if out, err := s.d.Cmd("volume", "rm", "signed-volume-test"); err != nil {
+		c.Fatal(err, out)
+	}
+
+	// the installed plugins are kept across restarts
+	if err := s.d.Restart("--plugin-trust-dir", trustDir); err != nil {
+		c.Fatal(err)
+	}
+	if out, err := s.d.Cmd("plugin", "ls", "-q"); err != nil || strings.TrimSpace(out) != signedPluginName {
+		c.Fatalf("Expected the plugin to be installed, got %v: %s", err, out)
+	}
+	if out, err := s.d.Cmd("plugin", "rm", signedPluginName); err != nil {
+		c.Fatal(err, out)
+	}
+	if out, err := s.d.Cmd("plugin", "inspect", signedPluginName); err == nil || !strings.Contains(out, "No such plugin") {
+		c.Fatalf("Expected the plugin to be removed, got %v: %s", err, out)
+	}
+}
+
+func (s *DockerDaemonSuite) TestPluginInstallUntrusted(c *check.C) {
+	testRequires(c, SameHostDaemon)
+
+	dir, err := ioutil.TempDir("", "docker-plugin-test")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := s.d.Start("--plugin-trust-dir", filepath.Join(dir, "trust")); err != nil {
+		c.Fatal(err)
+	}
+
+	key, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		c.Fatal(err)
+	}
+	manifest := writeSignedPluginManifest(c, dir, key, "tcp://localhost:8080", "{}")
+	out, err := s.d.Cmd("plugin", "install", "--grant-all-permissions", manifest)
+	if err == nil || !strings.Contains(out, "is not signed by a trusted publisher") {
+		c.Fatalf("Expected the plugin of an untrusted publisher to be refused, got %v: %s", err, out)
+	}
+
+	if err := ioutil.WriteFile(manifest, []byte(`{"Name": "test-signed-volume-driver"}`), 0644); err != nil {
+		c.Fatal(err)
+	}
+	out, err = s.d.Cmd("plugin", "install", "--grant-all-permissions", manifest)
+	if err == nil || !strings.Contains(out, "error parsing the signature") {
+		c.Fatalf("Expected the unsigned plugin to be refused, got %v: %s", err, out)
+	}
+}
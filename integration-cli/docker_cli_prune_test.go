@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestSystemPruneCli(c *check.C) {
+	dockerCmd(c, "run", "--name=prunecli", "--label=prune=cli", "busybox", "touch", "/test")
+	id, err := inspectField("prunecli", "Id")
+	c.Assert(err, check.IsNil)
+	out, _ := dockerCmd(c, "commit", "prunecli")
+	image := strings.TrimSpace(out)
+	dockerCmd(c, "volume", "create", "--name=prunecli", "--label", "prune=cli")
+
+	// nothing is removed without a confirmation
+	cmd := exec.Command(dockerBinary, "system", "prune", "--volumes", "--filter", "label=prune=cli")
+	cmd.Stdin = strings.NewReader("n\n")
+	out, _, err = runCommandWithOutput(cmd)
+	c.Assert(err, check.IsNil, check.Commentf(out))
+	c.Assert(out, check.Not(check.Matches), "(?s).*Deleted.*")
+	c.Assert(out, check.Matches, "(?s).*all volumes not used by at least one container.*")
+
+	out, _ = dockerCmd(c, "container", "prune", "-f", "--filter", "label=prune=cli")
+	c.Assert(out, check.Matches, "(?s)Deleted Containers:\n"+id+"\n.*Total reclaimed space: .*")
+
+	// the volumes are only pruned with --volumes
+	out, _ = dockerCmd(c, "system", "prune", "-f", "--filter", "label=prune=cli")
+	c.Assert(out, check.Matches, "(?s).*Deleted Images:\nDeleted: "+image+"\n.*")
+	c.Assert(out, check.Not(check.Matches), "(?s).*Deleted Volumes.*")
+
+	out, _ = dockerCmd(c, "system", "prune", "-f", "--volumes", "--filter", "label=prune=cli")
+	c.Assert(out, check.Matches, "(?s).*Deleted Volumes:\nprunecli\n.*")
+}
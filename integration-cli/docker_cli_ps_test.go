@@ -415,6 +415,109 @@ func (s *DockerSuite) TestPsListContainersFilterName(c *check.C) {
 
 }
 
+func (s *DockerSuite) TestPsListContainersFilterNameRegex(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name=regex_name_one", "busybox")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	firstID := strings.TrimSpace(out)
+
+	runCmd = exec.Command(dockerBinary, "run", "-d", "--name=other_regex_name", "busybox")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+
+	// the name is matched without its leading slash
+	runCmd = exec.Command(dockerBinary, "ps", "-a", "-q", "--no-trunc", "--filter=name=^regex_name")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+	if containerOut := strings.TrimSpace(out); containerOut != firstID {
+		c.Fatalf("Expected id %s, got %s for name filter, output: %q", firstID, containerOut, out)
+	}
+}
+
+func (s *DockerSuite) TestPsListContainersFilterAncestor(c *check.C) {
+	name := "testpsancestor"
+	defer deleteImages(name)
+	if _, err := buildImage(name, "FROM busybox\nLABEL test=ancestor", true); err != nil {
+		c.Fatal(err)
+	}
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	baseID := strings.TrimSpace(out)
+
+	runCmd = exec.Command(dockerBinary, "run", "-d", name)
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+	childID := strings.TrimSpace(out)
+
+	// the containers of the images built on top of the ancestor match too
+	runCmd = exec.Command(dockerBinary, "ps", "-a", "-q", "--no-trunc", "--filter=ancestor=busybox")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+	if !strings.Contains(out, baseID) || !strings.Contains(out, childID) {
+		c.Fatalf("Expected ids %s and %s for ancestor filter, output: %q", baseID, childID, out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "ps", "-a", "-q", "--no-trunc", "--filter=ancestor="+name)
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+	if containerOut := strings.TrimSpace(out); containerOut != childID {
+		c.Fatalf("Expected id %s, got %s for ancestor filter, output: %q", childID, containerOut, out)
+	}
+}
+
+func (s *DockerSuite) TestPsListContainersFilterNetworkAndVolume(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--net=host", "-v", "/test-ps-volume", "busybox", "top")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	firstID := strings.TrimSpace(out)
+
+	runCmd = exec.Command(dockerBinary, "run", "-d", "busybox", "top")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+
+	runCmd = exec.Command(dockerBinary, "ps", "-q", "--no-trunc", "--filter=network=host")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+	if containerOut := strings.TrimSpace(out); containerOut != firstID {
+		c.Fatalf("Expected id %s, got %s for network filter, output: %q", firstID, containerOut, out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "ps", "-q", "--no-trunc", "--filter=volume=/test-ps-volume")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+	if containerOut := strings.TrimSpace(out); containerOut != firstID {
+		c.Fatalf("Expected id %s, got %s for volume filter, output: %q", firstID, containerOut, out)
+	}
+}
+
+func (s *DockerSuite) TestPsListContainersInvalidFilter(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "ps", "--filter=nonexistent=value")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "Invalid filter 'nonexistent'") {
+		c.Fatalf("Expected an error for an invalid filter, got %v: %q", err, out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "ps", "--filter=status=unknown")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "Invalid filter 'status=unknown'") {
+		c.Fatalf("Expected an error for an invalid status, got %v: %q", err, out)
+	}
+}
+
 func (s *DockerSuite) TestPsListContainersFilterLabel(c *check.C) {
 	// start container
 	runCmd := exec.Command(dockerBinary, "run", "-d", "-l", "match=me", "-l", "second=tag", "busybox")
@@ -666,3 +769,24 @@ func (s *DockerSuite) TestPsGroupPortRange(c *check.C) {
 	}
 
 }
+
+func (s *DockerSuite) TestPsFormat(c *check.C) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", "formatted", "--label", "com.example.app=web", "busybox", "top"))
+	if err != nil {
+		c.Fatal(out, err)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "ps", "--format", `{{.Names}} {{.Label "com.example.app"}} {{.Labels}}`))
+	if err != nil {
+		c.Fatal(out, err)
+	}
+
+	if expected := "formatted web com.example.app=web"; strings.TrimSpace(out) != expected {
+		c.Fatalf("Expected %q, got %q", expected, out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "ps", "--format", "{{.Names"))
+	if err == nil || !strings.Contains(out, "Template parsing error") {
+		c.Fatalf("Expected a template parsing error, got %q", out)
+	}
+}
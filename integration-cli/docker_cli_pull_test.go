@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os/exec"
+	"runtime"
 	"strings"
 
 	"github.com/go-check/check"
@@ -48,6 +49,45 @@ func (s *DockerRegistrySuite) TestPullImageWithAliases(c *check.C) {
 	}
 }
 
+func (s *DockerRegistrySuite) TestPullPlatform(c *check.C) {
+	repoName := fmt.Sprintf("%v/dockercli/busybox:platform", privateRegistryURL)
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "tag", "busybox", repoName)); err != nil {
+		c.Fatalf("Failed to tag image %v: error %v, output %q", repoName, err, out)
+	}
+	if out, err := exec.Command(dockerBinary, "push", repoName).CombinedOutput(); err != nil {
+		c.Fatalf("Failed to push image %v: error %v, output %q", repoName, err, string(out))
+	}
+	if out, err := exec.Command(dockerBinary, "rmi", repoName).CombinedOutput(); err != nil {
+		c.Fatalf("Failed to clean images: error %v, output %q", err, string(out))
+	}
+
+	other := "linux/s390x"
+	if runtime.GOARCH == "s390x" {
+		other = "linux/arm64"
+	}
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "pull", "--platform", other, repoName))
+	if err == nil || !strings.Contains(out, "not a "+other+" one") {
+		c.Fatalf("Expected the pull of another platform to fail, got %v: %s", err, out)
+	}
+	if err := exec.Command(dockerBinary, "inspect", repoName).Run(); err == nil {
+		c.Fatalf("Image %v shouldn't have been tagged", repoName)
+	}
+
+	// the image of the platform of the host is used by run without pull
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "pull", "--platform", "linux/"+runtime.GOARCH, repoName)); err != nil {
+		c.Fatalf("Failed to pull %v: error %v, output %q", repoName, err, out)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--rm", "--platform", "linux/"+runtime.GOARCH, repoName, "true"))
+	if err != nil || strings.Contains(out, "Unable to find image") {
+		c.Fatalf("Expected the local image to be run, got %v: %s", err, out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "pull", "--platform", "linux", repoName))
+	if err == nil || !strings.Contains(out, "invalid platform") {
+		c.Fatalf("Expected an invalid platform error, got %v: %s", err, out)
+	}
+}
+
 // pulling library/hello-world should show verified message
 func (s *DockerSuite) TestPullVerified(c *check.C) {
 	c.Skip("Skipping hub dependent test")
@@ -89,7 +89,7 @@ func (s *DockerSuite) TestRenameCheckNames(c *check.C) {
 	}
 
 	name, err = inspectField("first_name", "Name")
-	if err == nil && !strings.Contains(err.Error(), "No such image or container: first_name") {
+	if err == nil && !strings.Contains(err.Error(), "No such container, image or volume: first_name") {
 		c.Fatal(err)
 	}
 }
@@ -110,3 +110,49 @@ func (s *DockerSuite) TestRenameInvalidName(c *check.C) {
 		c.Fatalf("Output of docker ps should have included 'myname': %s\n%v", out, err)
 	}
 }
+
+func (s *DockerSuite) TestRenameSameName(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "run", "--name", "samename", "-d", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		c.Fatalf(out, err)
+	}
+
+	runCmd = exec.Command(dockerBinary, "rename", "samename", "samename")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "same name as its current name") {
+		c.Fatalf("Renaming container to its own name should have failed: %s\n%v", out, err)
+	}
+}
+
+func (s *DockerSuite) TestRenameLinkedContainer(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "run", "--name", "linkedparent", "-d", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		c.Fatalf(out, err)
+	}
+	runCmd = exec.Command(dockerBinary, "run", "--name", "linkedchild", "--link", "linkedparent:alias", "-d", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		c.Fatalf(out, err)
+	}
+
+	runCmd = exec.Command(dockerBinary, "rename", "linkedparent", "renamedparent")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		c.Fatalf(out, err)
+	}
+	runCmd = exec.Command(dockerBinary, "rename", "linkedchild", "renamedchild")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		c.Fatalf(out, err)
+	}
+
+	// the link is kept under the new names
+	links, err := inspectFieldJSON("renamedchild", "HostConfig.Links")
+	if err != nil {
+		c.Fatal(err)
+	}
+	if expected := `["/renamedparent:/renamedchild/alias"]`; links != expected {
+		c.Fatalf("Expected the links %s, got %s", expected, links)
+	}
+
+	runCmd = exec.Command(dockerBinary, "exec", "renamedchild", "ping", "-c", "1", "alias")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		c.Fatalf(out, err)
+	}
+}
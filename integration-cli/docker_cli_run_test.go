@@ -3088,6 +3088,33 @@ func (s *DockerSuite) TestRunContainerWithRmFlagCannotStartContainer(c *check.C)
 	}
 }
 
+// the daemon removes a detached container run with --rm when it exits
+func (s *DockerSuite) TestRunDetachedWithRmFlag(c *check.C) {
+	name := "detachedrm"
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", name, "--rm", "busybox", "sleep", "1")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		c.Fatal(out, err)
+	}
+
+	autoRemove, err := inspectField(name, "HostConfig.AutoRemove")
+	if err != nil {
+		c.Fatal(err)
+	}
+	if autoRemove != "true" {
+		c.Fatalf("Expected HostConfig.AutoRemove to be true, got %s", autoRemove)
+	}
+
+	for i := 0; ; i++ {
+		if _, err := inspectField(name, "Id"); err != nil {
+			break
+		}
+		if i == 100 {
+			c.Fatal("Expected the container to be removed when it exits")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
 func (s *DockerSuite) TestRunPidHostWithChildIsKillable(c *check.C) {
 	name := "ibuildthecloud"
 	if out, err := exec.Command(dockerBinary, "run", "-d", "--pid=host", "--name", name, "busybox", "sh", "-c", "sleep 30; echo hi").CombinedOutput(); err != nil {
@@ -250,3 +250,93 @@ func (s *DockerSuite) TestRunAttachDetach(c *check.C) {
 		c.Fatal("timed out waiting for container to exit")
 	}
 }
+
+func (s *DockerSuite) TestRunSeccompDefaultProfile(c *check.C) {
+	testRequires(c, NativeExecDriver, SameHostDaemon, SeccompEnabled)
+	out, _ := dockerCmd(c, "run", "busybox", "grep", "^Seccomp:", "/proc/self/status")
+	if !strings.Contains(out, "2") {
+		c.Fatalf("expected the container to be filtered by seccomp, got %q", out)
+	}
+
+	out, _ = dockerCmd(c, "run", "--privileged", "busybox", "grep", "^Seccomp:", "/proc/self/status")
+	if !strings.Contains(out, "0") {
+		c.Fatalf("expected the privileged container not to be filtered by seccomp, got %q", out)
+	}
+}
+
+func (s *DockerSuite) TestRunSeccompUnconfined(c *check.C) {
+	testRequires(c, NativeExecDriver, SameHostDaemon, SeccompEnabled)
+	out, _ := dockerCmd(c, "run", "--security-opt", "seccomp=unconfined", "busybox", "grep", "^Seccomp:", "/proc/self/status")
+	if !strings.Contains(out, "0") {
+		c.Fatalf("expected the container not to be filtered by seccomp, got %q", out)
+	}
+}
+
+func (s *DockerSuite) TestRunSeccompProfile(c *check.C) {
+	testRequires(c, NativeExecDriver, SameHostDaemon, SeccompEnabled)
+	profile := `{
+	"defaultAction": "SCMP_ACT_ALLOW",
+	"syscalls": [
+		{"name": "chmod", "action": "SCMP_ACT_ERRNO"},
+		{"name": "fchmodat", "action": "SCMP_ACT_ERRNO"}
+	]
+}`
+	tmpDir, err := ioutil.TempDir("", "docker-seccomp-test")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	profilePath := filepath.Join(tmpDir, "profile.json")
+	if err := ioutil.WriteFile(profilePath, []byte(profile), 0644); err != nil {
+		c.Fatal(err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--security-opt", "seccomp="+profilePath, "busybox", "chmod", "400", "/etc/hostname"))
+	if err == nil || !strings.Contains(out, "Operation not permitted") {
+		c.Fatalf("expected chmod to be denied by the seccomp profile, got %v: %s", err, out)
+	}
+
+	dockerCmd(c, "run", "--security-opt", "seccomp="+profilePath, "busybox", "touch", "/tmp/file")
+}
+
+func (s *DockerSuite) TestRunSeccompInvalidProfile(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--security-opt", "seccomp=/nonexistent/profile.json", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "Opening seccomp profile") {
+		c.Fatalf("expected an error opening the seccomp profile, got %v: %s", err, out)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "docker-seccomp-test")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	profilePath := filepath.Join(tmpDir, "profile.json")
+	if err := ioutil.WriteFile(profilePath, []byte(`{"defaultAction": "SCMP_ACT_NONE"}`), 0644); err != nil {
+		c.Fatal(err)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--security-opt", "seccomp="+profilePath, "busybox", "true"))
+	if err == nil || !strings.Contains(out, "invalid seccomp action") {
+		c.Fatalf("expected an invalid seccomp profile to be refused, got %v: %s", err, out)
+	}
+}
+
+func (s *DockerSuite) TestRunGpusWithoutGpus(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	if _, err := os.Stat("/dev/nvidia0"); err == nil {
+		c.Skip("Test requires a host without GPU")
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--gpus=device=gpu0", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "invalid GPUs") {
+		c.Fatalf("Expected the GPUs to be invalid, got %v: %s", err, out)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--gpus=all", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "no GPU found on the host") {
+		c.Fatalf("Expected no GPU to be found, got %v: %s", err, out)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--gpus=device=0", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "Error gathering the device of GPU 0") {
+		c.Fatalf("Expected GPU 0 to be missing, got %v: %s", err, out)
+	}
+}
@@ -228,6 +228,71 @@ func (s *DockerSuite) TestSaveAndLoadRepoFlags(c *check.C) {
 	}
 }
 
+func (s *DockerSuite) TestSaveCompressedAndLoad(c *check.C) {
+	repoName := "foobar-save-compressed-test"
+	if out, err := exec.Command(dockerBinary, "tag", "busybox:latest", repoName).CombinedOutput(); err != nil {
+		c.Fatalf("failed to tag repo: %s, %v", out, err)
+	}
+	before, _ := dockerCmd(c, "inspect", "--format", "{{.Id}}", repoName)
+
+	out, _, err := runCommandPipelineWithOutput(
+		exec.Command(dockerBinary, "save", "--compress=gzip", "--compress-level=1", repoName),
+		exec.Command("tar", "t"))
+	if err != nil {
+		c.Fatalf("failed to save the compressed repo: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "/layer.tar") {
+		c.Fatalf("expected the layers to keep their name, got %s", out)
+	}
+
+	deleteImages(repoName)
+	out, _, err = runCommandPipelineWithOutput(
+		exec.Command(dockerBinary, "save", "--compress=gzip", "busybox:latest"),
+		exec.Command(dockerBinary, "load"))
+	if err != nil {
+		c.Fatalf("failed to save and load the compressed image: %s, %v", out, err)
+	}
+	after, _ := dockerCmd(c, "inspect", "--format", "{{.Id}}", "busybox:latest")
+	if before != after {
+		c.Fatalf("expected the loaded image to be %s, got %s", before, after)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "save", "-o", "/dev/null", "--compress=lz4", "busybox")); err == nil || !strings.Contains(out, "unsupported compression") {
+		c.Fatalf("expected the unsupported compression to be refused: %s, %v", out, err)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "save", "-o", "/dev/null", "--compress=gzip", "--compress-level=10", "busybox")); err == nil {
+		c.Fatalf("expected the invalid compression level to be refused: %s", out)
+	}
+}
+
+func (s *DockerSuite) TestLoadQuietAndFormat(c *check.C) {
+	repoName := "foobar-load-format-test"
+	dockerCmd(c, "tag", "busybox:latest", repoName)
+	id, _ := dockerCmd(c, "inspect", "--format", "{{.Id}}", repoName)
+	id = strings.TrimSpace(id)
+
+	tmpFile, err := ioutil.TempFile("", "foobar-load-format-test.tar")
+	if err != nil {
+		c.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+	dockerCmd(c, "save", "-o", tmpFile.Name(), repoName)
+
+	deleteImages(repoName)
+	out, _ := dockerCmd(c, "load", "-i", tmpFile.Name())
+	if !strings.Contains(out, "Loaded image: "+repoName+":latest") {
+		c.Fatalf("expected the loaded image to be printed, got %s", out)
+	}
+
+	deleteImages(repoName)
+	out, _ = dockerCmd(c, "load", "-q", "--format", "{{json .}}", "-i", tmpFile.Name())
+	expected := fmt.Sprintf(`{"Name":"%s:latest","ID":"%s"}`, repoName, id)
+	if strings.TrimSpace(out) != expected {
+		c.Fatalf("expected only %s, got %s", expected, out)
+	}
+}
+
 func (s *DockerSuite) TestSaveMultipleNames(c *check.C) {
 	repoName := "foobar-save-multi-name-test"
 
@@ -103,3 +103,36 @@ func (s *DockerSuite) TestSearchCmdOptions(c *check.C) {
 	}
 
 }
+
+func (s *DockerSuite) TestSearchWithFiltersAndLimit(c *check.C) {
+	testRequires(c, Network)
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "search", "--filter", "is-official=true", "--format", "{{.Name}} {{.IsOfficial}}", "busybox"))
+	if err != nil {
+		c.Fatalf("failed to search with a filter: %s, %v", out, err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if !strings.HasSuffix(line, " true") {
+			c.Fatalf("Expected only official images, got %q", out)
+		}
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "search", "--limit", "3", "--format", "{{.Name}}", "busybox"))
+	if err != nil {
+		c.Fatalf("failed to search with a limit: %s, %v", out, err)
+	}
+	if n := len(strings.Split(strings.TrimSpace(out), "\n")); n > 3 {
+		c.Fatalf("Expected at most 3 results, got %d: %q", n, out)
+	}
+}
+
+func (s *DockerSuite) TestSearchWithInvalidFilterAndLimit(c *check.C) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "search", "--filter", "unknown=1", "busybox"))
+	if err == nil || !strings.Contains(out, "invalid filter 'unknown'") {
+		c.Fatalf("Expected an error for an invalid filter, got %v: %s", err, out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "search", "--limit", "200", "busybox"))
+	if err == nil || !strings.Contains(out, "outside the range") {
+		c.Fatalf("Expected an error for an invalid limit, got %v: %s", err, out)
+	}
+}
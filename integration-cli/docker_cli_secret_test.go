@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestSecretCliCreateLsRm(c *check.C) {
+	createCmd := exec.Command(dockerBinary, "secret", "create", "password")
+	createCmd.Stdin = strings.NewReader("s3cr3t")
+	out, _, err := runCommandWithOutput(createCmd)
+	if err != nil || strings.TrimSpace(out) != "password" {
+		c.Fatalf("Expected the name of the secret, got %s, %v", out, err)
+	}
+
+	createCmd = exec.Command(dockerBinary, "secret", "create", "password")
+	createCmd.Stdin = strings.NewReader("other")
+	if out, _, err := runCommandWithOutput(createCmd); err == nil || !strings.Contains(out, "already exists") {
+		c.Fatalf("Expected an error creating an existing secret, got %s", out)
+	}
+
+	createCmd = exec.Command(dockerBinary, "secret", "create", "../password")
+	createCmd.Stdin = strings.NewReader("other")
+	if out, _, err := runCommandWithOutput(createCmd); err == nil || !strings.Contains(out, "invalid secret name") {
+		c.Fatalf("Expected an error creating a secret with an invalid name, got %s", out)
+	}
+
+	out, _ = dockerCmd(c, "secret", "ls")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "password") || strings.Contains(out, "s3cr3t") {
+		c.Fatalf("Expected the header and the secret without its content, got %s", out)
+	}
+
+	out, _ = dockerCmd(c, "secret", "rm", "password")
+	if strings.TrimSpace(out) != "password" {
+		c.Fatalf("Expected the name of the removed secret, got %s", out)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "secret", "rm", "password")); err == nil || !strings.Contains(out, "No such secret") {
+		c.Fatalf("Expected an error removing a secret which doesn't exist, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestRunSecret(c *check.C) {
+	testRequires(c, NativeExecDriver)
+	createCmd := exec.Command(dockerBinary, "secret", "create", "password")
+	createCmd.Stdin = strings.NewReader("s3cr3t")
+	if out, _, err := runCommandWithOutput(createCmd); err != nil {
+		c.Fatal(out, err)
+	}
+
+	out, _ := dockerCmd(c, "run", "--name=test", "--secret=password", "busybox", "sh", "-c", "cat /run/secrets/password && grep /run/secrets /proc/mounts")
+	if !strings.HasPrefix(out, "s3cr3t") || !strings.Contains(out, "tmpfs") || !strings.Contains(out, "ro,") {
+		c.Fatalf("Expected the secret on a read-only tmpfs, got %s", out)
+	}
+
+	// the secret is neither in the container's filesystem nor in inspect
+	out, _ = dockerCmd(c, "diff", "test")
+	if strings.Contains(out, "secrets") {
+		c.Fatalf("Expected no secrets in the changes of the container, got %s", out)
+	}
+	out, _ = dockerCmd(c, "inspect", "test")
+	if strings.Contains(out, "s3cr3t") {
+		c.Fatalf("Expected no content of the secret in inspect, got %s", out)
+	}
+
+	// the secret can't be removed while a container uses it
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "secret", "rm", "password")); err == nil || !strings.Contains(out, "is in use by containers") {
+		c.Fatalf("Expected an error removing a secret in use, got %s", out)
+	}
+	dockerCmd(c, "rm", "test")
+	dockerCmd(c, "secret", "rm", "password")
+}
+
+func (s *DockerSuite) TestRunSecretDoesntExist(c *check.C) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--secret=doesntexist", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "No such secret: doesntexist") {
+		c.Fatalf("Expected an error running a container with a secret which doesn't exist, got %s", out)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestSystemDialStdio(c *check.C) {
+	dialCmd := exec.Command(dockerBinary, "system", "dial-stdio")
+	dialCmd.Stdin = strings.NewReader("GET /_ping HTTP/1.0\r\n\r\n")
+	out, _, err := runCommandWithOutput(dialCmd)
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	if !strings.HasPrefix(out, "HTTP/1.0 200 OK") || !strings.HasSuffix(out, "OK") {
+		c.Fatalf("Expected the response of the daemon, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestSSHHost(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	dir, err := ioutil.TempDir("", "docker-ssh")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the fake ssh runs the command on the same host, recording its options
+	fakeSSH := filepath.Join(dir, "ssh")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s/args\nwhile [ \"$1\" != \"--\" ]; do shift; done\nshift 3\nexec %s \"$@\"\n", dir, dockerBinary)
+	if err := ioutil.WriteFile(fakeSSH, []byte(script), 0755); err != nil {
+		c.Fatal(err)
+	}
+
+	runCmd := exec.Command(dockerBinary, "-H", "ssh://admin@remote:2222", "run", "--rm", "busybox", "echo", "over ssh")
+	runCmd.Env = append(os.Environ(), "DOCKER_SSH_COMMAND="+fakeSSH)
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	if strings.TrimSpace(out) != "over ssh" {
+		c.Fatalf("Expected the output of the container, got %s", out)
+	}
+	args, err := ioutil.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		c.Fatal(err)
+	}
+	if expected := "-l admin -p 2222 -- remote docker system dial-stdio"; strings.TrimSpace(string(args)) != expected {
+		c.Fatalf("Expected ssh to run %q, got %q", expected, args)
+	}
+
+	runCmd = exec.Command(dockerBinary, "-H", "ssh://admin@remote", "version")
+	runCmd.Env = append(os.Environ(), "DOCKER_SSH_COMMAND=/bin/false")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "Cannot connect to admin@remote") {
+		c.Fatalf("Expected the ssh failure to be reported, got %s", out)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+const testStackCompose = `version: "2"
+services:
+  web:
+    image: busybox
+    command: top
+    depends_on:
+      - db
+    environment:
+      MODE: test
+  db:
+    image: busybox
+    command: top
+    volumes:
+      - data:/data
+volumes:
+  data:
+`
+
+func (s *DockerSuite) TestStackDeployAndRm(c *check.C) {
+	deployCmd := exec.Command(dockerBinary, "stack", "deploy", "-f", "-", "app")
+	deployCmd.Stdin = strings.NewReader(testStackCompose)
+	out, _, err := runCommandWithOutput(deployCmd)
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	// the volume, then db before web
+	if expected := "Creating volume app_data\nCreating app_db\nCreating app_web\n"; out != expected {
+		c.Fatalf("Expected %q, got %q", expected, out)
+	}
+
+	out, _ = dockerCmd(c, "inspect", "--format", "{{.State.Running}} {{.Config.Labels}} {{.HostConfig.Links}}", "app_web")
+	if !strings.HasPrefix(out, "true") || !strings.Contains(out, "com.docker.stack.service:web") || !strings.Contains(out, "/app_db:/app_web/db") {
+		c.Fatalf("Expected web running, labeled and linked to db, got %s", out)
+	}
+	out, _ = dockerCmd(c, "exec", "app_web", "sh", "-c", "echo $MODE && ping -c 1 db")
+	if !strings.HasPrefix(out, "test") {
+		c.Fatalf("Expected the environment of web, got %s", out)
+	}
+	dockerCmd(c, "exec", "app_db", "touch", "/data/kept")
+
+	// a new deploy replaces the containers, and keeps the volumes
+	deployCmd = exec.Command(dockerBinary, "stack", "deploy", "-f", "-", "app")
+	deployCmd.Stdin = strings.NewReader(testStackCompose)
+	if out, _, err = runCommandWithOutput(deployCmd); err != nil {
+		c.Fatal(out, err)
+	}
+	if !strings.Contains(out, "Removing app_db") || strings.Contains(out, "Creating volume") {
+		c.Fatalf("Expected the containers to be replaced, got %s", out)
+	}
+	dockerCmd(c, "exec", "app_db", "ls", "/data/kept")
+
+	out, _ = dockerCmd(c, "stack", "rm", "--volumes", "app")
+	if !strings.Contains(out, "Removing app_web") || !strings.Contains(out, "Removing volume app_data") {
+		c.Fatalf("Expected the containers and the volume of the stack to be removed, got %s", out)
+	}
+	out, _ = dockerCmd(c, "ps", "-a", "--filter", "label=com.docker.stack.namespace=app", "-q")
+	if strings.TrimSpace(out) != "" {
+		c.Fatalf("Expected no container left, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestStackDeployInvalid(c *check.C) {
+	deployCmd := exec.Command(dockerBinary, "stack", "deploy", "-f", "-", "app")
+	deployCmd.Stdin = strings.NewReader("version: \"2\"\nservices:\n  web:\n    image: busybox\n    depends_on: [db]\n")
+	if out, _, err := runCommandWithOutput(deployCmd); err == nil || !strings.Contains(out, "undefined service db") {
+		c.Fatalf("Expected the undefined service to be refused, got %s", out)
+	}
+	out, _ := dockerCmd(c, "ps", "-a", "--filter", "label=com.docker.stack.namespace=app", "-q")
+	if strings.TrimSpace(out) != "" {
+		c.Fatalf("Expected no container created, got %s", out)
+	}
+}
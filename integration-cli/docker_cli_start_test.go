@@ -1,11 +1,14 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
 	"fmt"
 	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/go-check/check"
 )
 
@@ -256,3 +259,64 @@ func (s *DockerSuite) TestStartAttachMultipleContainers(c *check.C) {
 	}
 
 }
+
+// loadImageOfPlatform loads an image of an empty layer tagged name, recorded
+// as an image of the OS and architecture os and arch.
+func loadImageOfPlatform(c *check.C, name, os, arch string) {
+	id := stringid.GenerateRandomID()
+	var layer bytes.Buffer
+	if err := tar.NewWriter(&layer).Close(); err != nil {
+		c.Fatal(err)
+	}
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{id + "/VERSION", []byte("1.0")},
+		{id + "/json", []byte(fmt.Sprintf(`{"id":%q,"os":%q,"architecture":%q}`, id, os, arch))},
+		{id + "/layer.tar", layer.Bytes()},
+		{"repositories", []byte(fmt.Sprintf(`{%q:{"latest":%q}}`, name, id))},
+	}
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data))}); err != nil {
+			c.Fatal(err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			c.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		c.Fatal(err)
+	}
+	loadCmd := exec.Command(dockerBinary, "load")
+	loadCmd.Stdin = &archive
+	if out, _, err := runCommandWithOutput(loadCmd); err != nil {
+		c.Fatalf("failed to load the image: %s, %v", out, err)
+	}
+}
+
+func (s *DockerSuite) TestStartForeignPlatform(c *check.C) {
+	loadImageOfPlatform(c, "foreign-platform", "windows", "amd64")
+	defer deleteImages("foreign-platform")
+
+	out, _ := dockerCmd(c, "create", "--name", "foreign", "foreign-platform", "true")
+	if !strings.Contains(out, "The container won't start: windows images don't run on linux hosts") {
+		c.Fatalf("expected a warning about the platform of the image, got %s", out)
+	}
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "start", "foreign"))
+	if err == nil || !strings.Contains(out, "--skip-platform-check") {
+		c.Fatalf("expected the container of a foreign platform to be refused: %s, %v", out, err)
+	}
+
+	// the container of the skipped check goes as far as running the command
+	out, _ = dockerCmd(c, "create", "--name", "foreign-skipped", "--skip-platform-check", "foreign-platform", "true")
+	if strings.Contains(out, "won't start") {
+		c.Fatalf("expected no warning with --skip-platform-check, got %s", out)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "start", "foreign-skipped"))
+	if strings.Contains(out, "--skip-platform-check") {
+		c.Fatalf("expected the platform check to be skipped: %s, %v", out, err)
+	}
+}
@@ -34,3 +34,21 @@ func (s *DockerSuite) TestCliStatsNoStream(c *check.C) {
 		c.Fatalf("stats did not return immediately when not streaming")
 	}
 }
+
+func (s *DockerSuite) TestCliStatsAllRunningFormat(c *check.C) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", "statsall", "busybox", "top"))
+	if err != nil {
+		c.Fatalf("Error on container creation: %v, output: %s", err, out)
+	}
+	if err := waitRun("statsall"); err != nil {
+		c.Fatalf("error waiting for container to start: %v", err)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "stats", "--no-stream", "--format", "{{.Container}} {{.PIDs}}"))
+	if err != nil {
+		c.Fatalf("Error running stats: %v, output: %s", err, out)
+	}
+	if !strings.Contains(out, "statsall 1\n") {
+		c.Fatalf("Expected the stats of the running container with 1 process, got %q", out)
+	}
+}
@@ -103,3 +103,24 @@ func (s *DockerSuite) TestTopPrivileged(c *check.C) {
 	}
 
 }
+
+func (s *DockerSuite) TestTopBSDOptions(c *check.C) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "busybox", "top"))
+	if err != nil {
+		c.Fatalf("failed to start the container: %s, %v", out, err)
+	}
+	cleanedContainerID := strings.TrimSpace(out)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "top", cleanedContainerID, "aux"))
+	if err != nil {
+		c.Fatalf("failed to run top: %s, %v", out, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		c.Fatalf("expected the header and the process of the container only: %s", out)
+	}
+	if !strings.Contains(lines[0], "%CPU") || !strings.Contains(lines[1], "top") {
+		c.Fatalf("expected the aux columns of top: %s", out)
+	}
+}
@@ -0,0 +1,139 @@
+// +build !windows
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-check/check"
+)
+
+const volumePluginName = "test-external-volume-driver"
+
+// externalVolumePlugin is a volume driver plugin storing its volumes in a
+// directory of the host, counting the calls of each method.
+type externalVolumePlugin struct {
+	sync.Mutex
+	root   string
+	calls  map[string]int
+	server *httptest.Server
+}
+
+func newExternalVolumePlugin(c *check.C) *externalVolumePlugin {
+	root, err := ioutil.TempDir("", "external-volume-test")
+	if err != nil {
+		c.Fatal(err)
+	}
+	p := &externalVolumePlugin{root: root, calls: make(map[string]int)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Plugin.Activate", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"Implements": ["VolumeDriver"]}`)
+	})
+	for _, method := range []string{"Create", "Remove", "Path", "Mount", "Unmount"} {
+		method := method
+		mux.HandleFunc("/VolumeDriver."+method, func(w http.ResponseWriter, r *http.Request) {
+			var req struct{ Name string }
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			p.Lock()
+			p.calls[method]++
+			p.Unlock()
+
+			path := filepath.Join(p.root, req.Name)
+			switch method {
+			case "Mount":
+				if err := os.MkdirAll(path, 0755); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if err := ioutil.WriteFile(filepath.Join(path, "test"), []byte(p.server.URL), 0644); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			case "Remove":
+				os.RemoveAll(path)
+			}
+			json.NewEncoder(w).Encode(map[string]string{"Mountpoint": path})
+		})
+	}
+	p.server = httptest.NewServer(mux)
+
+	if err := os.MkdirAll("/usr/share/docker/plugins", 0755); err != nil {
+		c.Fatal(err)
+	}
+	spec := filepath.Join("/usr/share/docker/plugins", volumePluginName+".spec")
+	if err := ioutil.WriteFile(spec, []byte(p.server.URL), 0644); err != nil {
+		c.Fatal(err)
+	}
+	return p
+}
+
+func (p *externalVolumePlugin) Close() {
+	p.server.Close()
+	os.Remove(filepath.Join("/usr/share/docker/plugins", volumePluginName+".spec"))
+	os.RemoveAll(p.root)
+}
+
+func (p *externalVolumePlugin) Calls(method string) int {
+	p.Lock()
+	defer p.Unlock()
+	return p.calls[method]
+}
+
+func (s *DockerSuite) TestVolumeDriverNamedVolume(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	p := newExternalVolumePlugin(c)
+	defer p.Close()
+
+	out, _ := dockerCmd(c, "run", "--rm", "--name", "test-data", "--volume-driver", volumePluginName, "-v", "external-volume-test:/tmp/external-volume-test", "busybox", "cat", "/tmp/external-volume-test/test")
+	if strings.TrimSpace(out) != p.server.URL {
+		c.Fatalf("Expected the content of the volume of the plugin, got %q", out)
+	}
+	if p.Calls("Create") != 1 || p.Calls("Mount") != 1 {
+		c.Fatalf("Expected the plugin to create and mount the volume once, got %v", p.calls)
+	}
+
+	out, _ = dockerCmd(c, "volume", "inspect", "-f", "{{ .Driver }}", "external-volume-test")
+	if strings.TrimSpace(out) != volumePluginName {
+		c.Fatalf("Expected the volume of the plugin, got %q", out)
+	}
+
+	dockerCmd(c, "volume", "rm", "external-volume-test")
+	if p.Calls("Remove") != 1 {
+		c.Fatalf("Expected the plugin to remove the volume, got %v", p.calls)
+	}
+}
+
+func (s *DockerSuite) TestVolumeDriverCreate(c *check.C) {
+	testRequires(c, SameHostDaemon)
+	p := newExternalVolumePlugin(c)
+	defer p.Close()
+
+	dockerCmd(c, "volume", "create", "--driver", volumePluginName, "--name", "external-volume-test")
+	if p.Calls("Create") != 1 || p.Calls("Path") != 1 {
+		c.Fatalf("Expected the plugin to create the volume, got %v", p.calls)
+	}
+
+	// the existing volume keeps its driver
+	out, _ := dockerCmd(c, "run", "--rm", "-v", "external-volume-test:/tmp/external-volume-test", "busybox", "cat", "/tmp/external-volume-test/test")
+	if strings.TrimSpace(out) != p.server.URL {
+		c.Fatalf("Expected the content of the volume of the plugin, got %q", out)
+	}
+
+	// anonymous volumes are always local
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "create", "--driver", volumePluginName)); err == nil {
+		c.Fatalf("Expected an error creating an anonymous volume with a plugin, got %s", out)
+	}
+}
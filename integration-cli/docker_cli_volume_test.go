@@ -0,0 +1,259 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestVolumeCliCreate(c *check.C) {
+	out, _ := dockerCmd(c, "volume", "create")
+	anonymous := strings.TrimSpace(out)
+	if len(anonymous) != 64 {
+		c.Fatalf("Expected the ID of an anonymous volume, got %q", anonymous)
+	}
+
+	out, _ = dockerCmd(c, "volume", "create", "--name=test")
+	if name := strings.TrimSpace(out); name != "test" {
+		c.Fatalf("Expected the name of the volume, got %q", name)
+	}
+
+	// creating an existing volume returns it
+	out, _ = dockerCmd(c, "volume", "create", "--name=test")
+	if name := strings.TrimSpace(out); name != "test" {
+		c.Fatalf("Expected the name of the volume, got %q", name)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "create", "--name=-test"))
+	if err == nil || !strings.Contains(out, "Invalid volume name") {
+		c.Fatalf("Expected an error creating a volume with an invalid name, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliInspect(c *check.C) {
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "inspect", "doesntexist")); err == nil {
+		c.Fatalf("Expected an error inspecting a volume which doesn't exist, got %s", out)
+	}
+
+	dockerCmd(c, "volume", "create", "--name=test")
+	out, _ := dockerCmd(c, "volume", "inspect", "-f", "{{ .Name }}", "test")
+	if name := strings.TrimSpace(out); name != "test" {
+		c.Fatalf("Expected the name of the volume, got %q", name)
+	}
+	out, _ = dockerCmd(c, "volume", "inspect", "-f", "{{ .Driver }}", "test")
+	if driver := strings.TrimSpace(out); driver != "local" {
+		c.Fatalf("Expected the local driver, got %q", driver)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliLs(c *check.C) {
+	out, _ := dockerCmd(c, "volume", "create")
+	anonymous := strings.TrimSpace(out)
+	dockerCmd(c, "volume", "create", "--name=test")
+
+	out, _ = dockerCmd(c, "volume", "ls")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		c.Fatalf("Expected the header and 2 volumes, got %s", out)
+	}
+	if !strings.Contains(out, anonymous) || !strings.Contains(out, "test") {
+		c.Fatalf("Expected both volumes to be listed, got %s", out)
+	}
+
+	out, _ = dockerCmd(c, "volume", "ls", "-q")
+	if names := strings.Fields(out); len(names) != 2 {
+		c.Fatalf("Expected the names of 2 volumes, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliRm(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=test")
+	dockerCmd(c, "run", "-v", "test:/foo", "--name=test", "busybox", "sh", "-c", "echo hello > /foo/bar")
+
+	// the volume is in use by the container
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "rm", "test"))
+	if err == nil || !strings.Contains(out, "in use") {
+		c.Fatalf("Expected an error removing a volume in use, got %s", out)
+	}
+
+	// the named volume isn't removed with the container
+	dockerCmd(c, "rm", "-v", "test")
+	out, _ = dockerCmd(c, "run", "-v", "test:/foo", "busybox", "cat", "/foo/bar")
+	if strings.TrimSpace(out) != "hello" {
+		c.Fatalf("Expected the content of the named volume to be kept, got %q", out)
+	}
+
+	deleteAllContainers()
+	dockerCmd(c, "volume", "rm", "test")
+	out, _ = dockerCmd(c, "volume", "ls", "-q")
+	if strings.TrimSpace(out) != "" {
+		c.Fatalf("Expected the volume to be removed, got %s", out)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "rm", "doesntexist")); err == nil {
+		c.Fatalf("Expected an error removing a volume which doesn't exist, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliRunNamedVolume(c *check.C) {
+	// a named volume is created on first use, with the content of the image
+	out, _ := dockerCmd(c, "run", "-v", "testetc:/etc", "busybox", "ls", "/etc/passwd")
+	if strings.TrimSpace(out) != "/etc/passwd" {
+		c.Fatalf("Expected the named volume to be filled with the content of the image, got %q", out)
+	}
+	out, _ = dockerCmd(c, "volume", "inspect", "-f", "{{ .Name }}", "testetc")
+	if strings.TrimSpace(out) != "testetc" {
+		c.Fatalf("Expected the named volume to be created, got %q", out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-v", "test/etc:/etc", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "must be absolute") {
+		c.Fatalf("Expected an error with a relative path, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliLsFilters(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=testprod", "--label", "env=prod", "--label", "team")
+	dockerCmd(c, "volume", "create", "--name=testdev", "--label", "env=dev")
+	dockerCmd(c, "create", "-v", "testdev:/foo", "busybox")
+
+	for filter, expected := range map[string]string{
+		"label=env":      "testdev testprod",
+		"label=env=prod": "testprod",
+		"label=team":     "testprod",
+		"dangling=true":  "testprod",
+		"dangling=false": "testdev",
+		"driver=local":   "testdev testprod",
+	} {
+		out, _ := dockerCmd(c, "volume", "ls", "-q", "--filter", filter)
+		if names := strings.Join(strings.Fields(out), " "); names != expected {
+			c.Fatalf("Expected %q with the filter %s, got %q", expected, filter, names)
+		}
+	}
+
+	out, _ := dockerCmd(c, "volume", "inspect", "-f", "{{ .Labels.env }}", "testprod")
+	if strings.TrimSpace(out) != "prod" {
+		c.Fatalf("Expected the label of the volume, got %q", out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "ls", "--filter", "size=1"))
+	if err == nil || !strings.Contains(out, "Invalid filter") {
+		c.Fatalf("Expected an error with an invalid filter, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliPrune(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=testprod", "--label", "env=prod")
+	dockerCmd(c, "volume", "create", "--name=testdev", "--label", "env=dev")
+	dockerCmd(c, "volume", "create", "--name=testused")
+	dockerCmd(c, "create", "-v", "testused:/foo", "busybox")
+
+	// the volumes are kept without a confirmation
+	cmd := exec.Command(dockerBinary, "volume", "prune")
+	cmd.Stdin = strings.NewReader("n\n")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if strings.Contains(out, "Deleted Volumes") {
+		c.Fatalf("Expected no volume pruned without a confirmation, got %s", out)
+	}
+
+	out, _ = dockerCmd(c, "volume", "prune", "-f", "--filter", "label=env=prod")
+	if !strings.Contains(out, "testprod") || strings.Contains(out, "testdev") {
+		c.Fatalf("Expected the volume testprod pruned, got %s", out)
+	}
+
+	out, _ = dockerCmd(c, "volume", "prune", "-f")
+	if !strings.Contains(out, "testdev") || strings.Contains(out, "testused") {
+		c.Fatalf("Expected the volume testdev pruned, got %s", out)
+	}
+	if !strings.Contains(out, "Total reclaimed space") {
+		c.Fatalf("Expected the reclaimed space, got %s", out)
+	}
+
+	out, _ = dockerCmd(c, "volume", "ls", "-q")
+	if names := strings.Join(strings.Fields(out), " "); names != "testused" {
+		c.Fatalf("Expected only the volume used by a container, got %q", names)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliCreateLocalOptions(c *check.C) {
+	testRequires(c, SameHostDaemon, NativeExecDriver)
+	dockerCmd(c, "volume", "create", "--name=test", "-o", "type=tmpfs", "-o", "device=tmpfs", "-o", "o=size=1m")
+
+	out, _ := dockerCmd(c, "volume", "inspect", "-f", "{{ .Options.type }}", "test")
+	if strings.TrimSpace(out) != "tmpfs" {
+		c.Fatalf("Expected the options of the volume, got %q", out)
+	}
+
+	out, _ = dockerCmd(c, "run", "--rm", "-v", "test:/foo", "busybox", "grep", "/foo", "/proc/mounts")
+	if !strings.Contains(out, "tmpfs") {
+		c.Fatalf("Expected the volume to be mounted as a tmpfs, got %q", out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "create", "--name=invalid", "-o", "size=1m"))
+	if err == nil || !strings.Contains(out, "invalid option size") {
+		c.Fatalf("Expected an error with an invalid option, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliInspectUsage(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name=test")
+	out, _ := dockerCmd(c, "create", "-v", "test:/foo", "busybox")
+	id := strings.TrimSpace(out)
+	dockerCmd(c, "run", "--rm", "-v", "test:/foo", "busybox", "sh", "-c", "dd if=/dev/zero of=/foo/file bs=1k count=100")
+
+	out, _ = dockerCmd(c, "volume", "inspect", "-f", "{{ .UsageData.Containers }}", "test")
+	if containers := strings.TrimSpace(out); containers != "["+id+"]" {
+		c.Fatalf("Expected the container using the volume, got %q", containers)
+	}
+
+	out, _ = dockerCmd(c, "volume", "inspect", "-f", "{{ .UsageData.Size }}", "test")
+	size, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		c.Fatal(err)
+	}
+	if size < 100*1024 {
+		c.Fatalf("Expected the size of the data of the volume, got %d", size)
+	}
+}
+
+func (s *DockerSuite) TestVolumeCliSnapshot(c *check.C) {
+	dockerCmd(c, "run", "--rm", "-v", "data:/db", "busybox", "sh", "-c", "echo rows > /db/table")
+
+	out, _ := dockerCmd(c, "volume", "create", "--name=test-data", "--from", "data")
+	if strings.TrimSpace(out) != "test-data" {
+		c.Fatalf("Expected the name of the copy, got %q", out)
+	}
+	out, _ = dockerCmd(c, "volume", "inspect", "-f", "{{ .From }}", "test-data")
+	if strings.TrimSpace(out) != "data" {
+		c.Fatalf("Expected the volume copied, got %q", out)
+	}
+	dockerCmd(c, "run", "--rm", "-v", "test-data:/db", "busybox", "sh", "-c", "echo changed > /db/table")
+	out, _ = dockerCmd(c, "run", "--rm", "-v", "data:/db", "busybox", "cat", "/db/table")
+	if strings.TrimSpace(out) != "rows" {
+		c.Fatalf("Expected the volume copied to be unchanged, got %q", out)
+	}
+
+	out, _ = dockerCmd(c, "volume", "snapshot", "data")
+	snapshot := strings.TrimSpace(out)
+	if !strings.HasPrefix(snapshot, "data-") {
+		c.Fatalf("Expected the snapshot to be named after the volume, got %q", snapshot)
+	}
+	out, _ = dockerCmd(c, "run", "--rm", "-v", snapshot+":/db", "busybox", "cat", "/db/table")
+	if strings.TrimSpace(out) != "rows" {
+		c.Fatalf("Expected the data of the volume in the snapshot, got %q", out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "snapshot", "data", "test-data"))
+	if err == nil || !strings.Contains(out, "already exists") {
+		c.Fatalf("Expected an error creating a snapshot as an existing volume, got %s", out)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "volume", "snapshot", "doesntexist"))
+	if err == nil || !strings.Contains(out, "No such volume") {
+		c.Fatalf("Expected an error copying a volume which doesn't exist, got %s", out)
+	}
+}
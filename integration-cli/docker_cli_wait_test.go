@@ -140,3 +140,89 @@ func (s *DockerSuite) TestWaitBlockedExitRandom(c *check.C) {
 		c.Fatal("timeout waiting for `docker wait` to exit")
 	}
 }
+
+// waitCondition starts `docker wait` with the condition on the container, and
+// returns the channel of its result and its output.
+func waitCondition(c *check.C, containerID, condition string) (*exec.Cmd, chan error, *bytes.Buffer) {
+	chWait := make(chan error)
+	waitCmd := exec.Command(dockerBinary, "wait", "--condition="+condition, containerID)
+	waitCmdOut := bytes.NewBuffer(nil)
+	waitCmd.Stdout = waitCmdOut
+	if err := waitCmd.Start(); err != nil {
+		c.Fatal(err)
+	}
+	go func() {
+		chWait <- waitCmd.Wait()
+	}()
+	// let the daemon receive the wait request
+	time.Sleep(500 * time.Millisecond)
+	return waitCmd, chWait, waitCmdOut
+}
+
+// blocking wait for the next exit of a stopped container
+func (s *DockerSuite) TestWaitConditionNextExit(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-d", "busybox", "sh", "-c", "exit 7")
+	containerID := strings.TrimSpace(out)
+	dockerCmd(c, "wait", containerID)
+
+	waitCmd, chWait, waitCmdOut := waitCondition(c, containerID, "next-exit")
+	select {
+	case <-chWait:
+		c.Fatal("`docker wait --condition=next-exit` returned before the next exit")
+	default:
+	}
+
+	dockerCmd(c, "start", containerID)
+
+	select {
+	case err := <-chWait:
+		if err != nil {
+			c.Fatal(err)
+		}
+		if status := strings.TrimSpace(waitCmdOut.String()); status != "7" {
+			c.Fatalf("expected exit 7, got %s", status)
+		}
+	case <-time.After(5 * time.Second):
+		waitCmd.Process.Kill()
+		c.Fatal("timeout waiting for `docker wait` to exit")
+	}
+}
+
+// blocking wait for the removal of a container
+func (s *DockerSuite) TestWaitConditionRemoved(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-d", "busybox", "sh", "-c", "exit 3")
+	containerID := strings.TrimSpace(out)
+	dockerCmd(c, "wait", containerID)
+
+	waitCmd, chWait, waitCmdOut := waitCondition(c, containerID, "removed")
+	select {
+	case <-chWait:
+		c.Fatal("`docker wait --condition=removed` returned before the removal")
+	default:
+	}
+
+	dockerCmd(c, "rm", containerID)
+
+	select {
+	case err := <-chWait:
+		if err != nil {
+			c.Fatal(err)
+		}
+		if status := strings.TrimSpace(waitCmdOut.String()); status != "3" {
+			c.Fatalf("expected exit 3, got %s", status)
+		}
+	case <-time.After(5 * time.Second):
+		waitCmd.Process.Kill()
+		c.Fatal("timeout waiting for `docker wait` to exit")
+	}
+}
+
+func (s *DockerSuite) TestWaitInvalidCondition(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-d", "busybox", "true")
+	containerID := strings.TrimSpace(out)
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "wait", "--condition=invalid", containerID))
+	if err == nil || !strings.Contains(out, "invalid wait condition") {
+		c.Fatalf("Expected an error for an invalid condition, got %v: %s", err, out)
+	}
+}
@@ -465,6 +465,38 @@ func deleteAllImages() error {
 	return nil
 }
 
+func deleteAllVolumes() error {
+	out, err := exec.Command(dockerBinary, "volume", "ls", "-q").CombinedOutput()
+	if err != nil {
+		return err
+	}
+	volumes := strings.Fields(string(out))
+	if len(volumes) == 0 {
+		return nil
+	}
+	args := append([]string{"volume", "rm"}, volumes...)
+	if err := exec.Command(dockerBinary, args...).Run(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func deleteAllSecrets() error {
+	out, err := exec.Command(dockerBinary, "secret", "ls", "-q").CombinedOutput()
+	if err != nil {
+		return err
+	}
+	secrets := strings.Fields(string(out))
+	if len(secrets) == 0 {
+		return nil
+	}
+	args := append([]string{"secret", "rm"}, secrets...)
+	if err := exec.Command(dockerBinary, args...).Run(); err != nil {
+		return err
+	}
+	return nil
+}
+
 func getPausedContainers() (string, error) {
 	getPausedContainersCmd := exec.Command(dockerBinary, "ps", "-f", "status=paused", "-q", "-a")
 	out, exitCode, err := runCommandWithOutput(getPausedContainersCmd)
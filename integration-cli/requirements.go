@@ -3,11 +3,14 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
 	"os/exec"
 	"strings"
 
+	"github.com/docker/docker/pkg/fscrypt"
 	"github.com/go-check/check"
 )
 
@@ -89,6 +92,26 @@ var (
 		},
 		"Test requires underlying root filesystem not be backed by overlay.",
 	}
+
+	SeccompEnabled = TestRequirement{
+		func() bool {
+			cmd := exec.Command("grep", "^Seccomp:", "/proc/self/status")
+			return cmd.Run() == nil
+		},
+		"Test requires a kernel supporting seccomp.",
+	}
+
+	FsEncryption = TestRequirement{
+		func() bool {
+			dir, err := ioutil.TempDir(os.Getenv("DEST"), "encryption-check")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+			return fscrypt.SetPolicy(dir, "0011223344556677") == nil
+		},
+		"Test requires a filesystem supporting encryption for the daemons.",
+	}
 )
 
 // testRequires checks if the environment satisfies the requirements
@@ -1,44 +1,35 @@
 package opts
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 )
 
 /*
 Read in a line delimited file with environment variables enumerated
+
+Blank lines and lines starting with '#' are ignored. A value quoted with
+double quotes may contain the escapes \n, \t, \", \\ and \$, and a value
+quoted with single quotes is kept as is; a quoted value may span several
+lines. An unquoted value is kept as is, and is continued on the next line
+after a trailing backslash. A variable without '=' is passed through from
+the environment of the client, and left out when it isn't set there.
 */
 func ParseEnvFile(filename string) ([]string, error) {
-	fh, err := os.Open(filename)
+	content, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return []string{}, err
 	}
-	defer fh.Close()
-
-	lines := []string{}
-	scanner := bufio.NewScanner(fh)
-	for scanner.Scan() {
-		line := scanner.Text()
-		// line is not empty, and not starting with '#'
-		if len(line) > 0 && !strings.HasPrefix(line, "#") {
-			if strings.Contains(line, "=") {
-				data := strings.SplitN(line, "=", 2)
-
-				// trim the front of a variable, but nothing else
-				variable := strings.TrimLeft(data[0], whiteSpaces)
-				if strings.ContainsAny(variable, whiteSpaces) {
-					return []string{}, ErrBadEnvVariable{fmt.Sprintf("variable '%s' has white spaces", variable)}
-				}
-
-				// pass the value through, no trimming
-				lines = append(lines, fmt.Sprintf("%s=%s", variable, data[1]))
-			} else {
-				// if only a pass-through variable is given, clean it up.
-				lines = append(lines, fmt.Sprintf("%s=%s", strings.TrimSpace(line), os.Getenv(line)))
-			}
-		}
+	p := &envFileParser{
+		filename: filename,
+		lines:    strings.Split(strings.Replace(string(content), "\r\n", "\n", -1), "\n"),
+	}
+	lines, err := p.parse()
+	if err != nil {
+		return []string{}, err
 	}
 	return lines, nil
 }
@@ -52,3 +43,124 @@ type ErrBadEnvVariable struct {
 func (e ErrBadEnvVariable) Error() string {
 	return fmt.Sprintf("poorly formatted environment: %s", e.msg)
 }
+
+// envFileParser parses the lines of an env file, the values spanning
+// several lines.
+type envFileParser struct {
+	filename string
+	lines    []string
+	// next is the index of the next line to parse, which is also the
+	// number of the line being parsed
+	next int
+}
+
+func (p *envFileParser) errorf(line int, format string, args ...interface{}) error {
+	return ErrBadEnvVariable{fmt.Sprintf("%s, line %d: %s", p.filename, line, fmt.Sprintf(format, args...))}
+}
+
+func (p *envFileParser) parse() ([]string, error) {
+	lines := []string{}
+	for p.next < len(p.lines) {
+		line := p.lines[p.next]
+		p.next++
+		lineNumber := p.next
+
+		// line is not empty, and not starting with '#'
+		trimmed := strings.TrimLeft(line, whiteSpaces)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.Contains(line, "=") {
+			// if only a pass-through variable is given, clean it up.
+			variable := strings.TrimSpace(line)
+			if strings.ContainsAny(variable, whiteSpaces) {
+				return nil, p.errorf(lineNumber, "variable '%s' has white spaces", variable)
+			}
+			if doesEnvExist(variable) {
+				lines = append(lines, fmt.Sprintf("%s=%s", variable, os.Getenv(variable)))
+			}
+			continue
+		}
+
+		data := strings.SplitN(line, "=", 2)
+		// trim the front of a variable, but nothing else
+		variable := strings.TrimLeft(data[0], whiteSpaces)
+		if variable == "" {
+			return nil, p.errorf(lineNumber, "no variable name before '='")
+		}
+		if strings.ContainsAny(variable, whiteSpaces) {
+			return nil, p.errorf(lineNumber, "variable '%s' has white spaces", variable)
+		}
+		value, err := p.parseValue(variable, data[1], lineNumber)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, fmt.Sprintf("%s=%s", variable, value))
+	}
+	return lines, nil
+}
+
+// parseValue returns the value starting on the line, reading the next
+// lines it spans.
+func (p *envFileParser) parseValue(variable, value string, lineNumber int) (string, error) {
+	if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+		return p.parseQuotedValue(variable, value, lineNumber)
+	}
+	// pass the value through, no trimming
+	for strings.HasSuffix(value, `\`) && p.next < len(p.lines) {
+		value = value[:len(value)-1] + p.lines[p.next]
+		p.next++
+	}
+	return value, nil
+}
+
+func (p *envFileParser) parseQuotedValue(variable, value string, lineNumber int) (string, error) {
+	var (
+		quote = value[0]
+		buf   bytes.Buffer
+		rest  = value[1:]
+	)
+	for {
+		escapedNewline := false
+		for i := 0; i < len(rest); i++ {
+			c := rest[i]
+			if c == quote {
+				// only a comment may follow the value
+				if tail := strings.TrimLeft(rest[i+1:], whiteSpaces); tail != "" && !strings.HasPrefix(tail, "#") {
+					return "", p.errorf(p.next, "unexpected '%s' after the quoted value of variable '%s'", tail, variable)
+				}
+				return buf.String(), nil
+			}
+			if c != '\\' || quote != '"' {
+				buf.WriteByte(c)
+				continue
+			}
+			if i == len(rest)-1 {
+				escapedNewline = true
+				break
+			}
+			i++
+			switch rest[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case '"', '\\', '$':
+				buf.WriteByte(rest[i])
+			default:
+				buf.WriteByte('\\')
+				buf.WriteByte(rest[i])
+			}
+		}
+
+		if p.next == len(p.lines) {
+			return "", p.errorf(lineNumber, "the quoted value of variable '%s' is not terminated", variable)
+		}
+		if !escapedNewline {
+			buf.WriteByte('\n')
+		}
+		rest = p.lines[p.next]
+		p.next++
+	}
+}
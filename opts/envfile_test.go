@@ -0,0 +1,87 @@
+package opts
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func tmpEnvFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "envfile-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestParseEnvFile(t *testing.T) {
+	os.Setenv("ENVFILE_TEST_PASSTHROUGH", "from client")
+	defer os.Unsetenv("ENVFILE_TEST_PASSTHROUGH")
+	os.Unsetenv("ENVFILE_TEST_UNSET")
+
+	content := `# a comment
+  # an indented comment
+
+FOO=bar baz
+	SPACED=  kept as is
+EMPTY=
+DOUBLE="with \"escapes\"\tand\na newline" # a comment
+SINGLE='no \n escapes'
+MULTI="first
+second"
+CONTINUED=first \
+second
+ESCAPED="first \
+second"
+ENVFILE_TEST_PASSTHROUGH
+ENVFILE_TEST_UNSET
+`
+	filename := tmpEnvFile(t, content)
+	defer os.Remove(filename)
+
+	lines, err := ParseEnvFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"FOO=bar baz",
+		"SPACED=  kept as is",
+		"EMPTY=",
+		"DOUBLE=with \"escapes\"\tand\na newline",
+		`SINGLE=no \n escapes`,
+		"MULTI=first\nsecond",
+		"CONTINUED=first second",
+		"ESCAPED=first second",
+		"ENVFILE_TEST_PASSTHROUGH=from client",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("Expected %q, got %q", expected, lines)
+	}
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	for content, expected := range map[string]string{
+		"FOO=bar\nf o o=bar":          "line 2: variable 'f o o' has white spaces",
+		"FOO=bar\n\nf o o":            "line 3: variable 'f o o' has white spaces",
+		"=bar":                        "line 1: no variable name before '='",
+		"FOO=\"bar\nbaz\nBAR=qux":     "line 1: the quoted value of variable 'FOO' is not terminated",
+		"FOO='bar\n\nbaz' qux":        "line 3: unexpected 'qux' after the quoted value of variable 'FOO'",
+		"FOO=\"bar\"baz\nBAR=\"qux\"": "line 1: unexpected 'baz' after the quoted value of variable 'FOO'",
+	} {
+		filename := tmpEnvFile(t, content)
+		_, err := ParseEnvFile(filename)
+		os.Remove(filename)
+		if _, ok := err.(ErrBadEnvVariable); !ok {
+			t.Fatalf("Expected an ErrBadEnvVariable for %q, got %v", content, err)
+		}
+		if !strings.Contains(err.Error(), filename+", "+expected) {
+			t.Fatalf("Expected %q in the error for %q, got %v", expected, content, err)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 var (
 	alphaRegexp     = regexp.MustCompile(`[a-zA-Z]`)
+	secretRegexp    = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
 	domainRegexp    = regexp.MustCompile(`^(:?(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9]))(:?\.(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9])))*)\.?\s*$`)
 	DefaultHTTPHost = "127.0.0.1" // Default HTTP Host used if only port is provided to -H flag e.g. docker -d -H tcp://:8080
 	// TODO Windows. DefaultHTTPPort is only used on Windows if a -H parameter
@@ -36,6 +37,18 @@ func LogOptsVar(values map[string]string, names []string, usage string) {
 	flag.Var(newMapOpt(values, ValidateLogOpts), names, usage)
 }
 
+func RuntimesVar(values map[string]string, names []string, usage string) {
+	flag.Var(newMapOpt(values, ValidateRuntime), names, usage)
+}
+
+func RuntimeArgsVar(values *[]string, names []string, usage string) {
+	flag.Var(newListOptsRef(values, ValidateRuntimeArg), names, usage)
+}
+
+func RuntimeEnvVar(values *[]string, names []string, usage string) {
+	flag.Var(newListOptsRef(values, ValidateRuntimeEnv), names, usage)
+}
+
 func HostListVar(values *[]string, names []string, usage string) {
 	flag.Var(newListOptsRef(values, ValidateHost), names, usage)
 }
@@ -176,13 +189,50 @@ func newMapOpt(values map[string]string, validator ValidatorFctType) *MapOpts {
 type ValidatorFctType func(val string) (string, error)
 type ValidatorFctListType func(val string) ([]string, error)
 
+// ValidateLogOpts checks that val is a key=value log opt. The keys are
+// checked by the logging driver they are given to.
 func ValidateLogOpts(val string) (string, error) {
-	allowedKeys := map[string]string{}
-	vals := strings.Split(val, "=")
-	if allowedKeys[vals[0]] != "" {
-		return val, nil
+	vals := strings.SplitN(val, "=", 2)
+	if len(vals) != 2 || vals[0] == "" {
+		return "", fmt.Errorf("%s is not a valid log opt, must be key=value", val)
+	}
+	return val, nil
+}
+
+// ValidateRuntime checks that val is a name=path OCI runtime. The name
+// native is the native driver.
+func ValidateRuntime(val string) (string, error) {
+	vals := strings.SplitN(val, "=", 2)
+	if len(vals) != 2 || !secretRegexp.MatchString(vals[0]) {
+		return "", fmt.Errorf("%s is not a valid runtime, must be name=path with a name in [a-zA-Z0-9][a-zA-Z0-9_.-]", val)
+	}
+	if vals[0] == "native" {
+		return "", fmt.Errorf("invalid runtime %s, native is the name of the native driver", val)
+	}
+	if !path.IsAbs(vals[1]) {
+		return "", fmt.Errorf("invalid runtime %s, the path of the runtime must be absolute", val)
 	}
-	return "", fmt.Errorf("%s is not a valid log opt", vals[0])
+	return val, nil
+}
+
+// ValidateRuntimeArg checks that val is a name=arg argument of an OCI
+// runtime.
+func ValidateRuntimeArg(val string) (string, error) {
+	vals := strings.SplitN(val, "=", 2)
+	if len(vals) != 2 || !secretRegexp.MatchString(vals[0]) || vals[1] == "" {
+		return "", fmt.Errorf("%s is not a valid runtime argument, must be name=arg", val)
+	}
+	return val, nil
+}
+
+// ValidateRuntimeEnv checks that val is a name=KEY=value environment variable
+// of an OCI runtime.
+func ValidateRuntimeEnv(val string) (string, error) {
+	vals := strings.SplitN(val, "=", 3)
+	if len(vals) != 3 || !secretRegexp.MatchString(vals[0]) || vals[1] == "" {
+		return "", fmt.Errorf("%s is not a valid runtime environment variable, must be name=KEY=value", val)
+	}
+	return val, nil
 }
 
 func ValidateAttach(val string) (string, error) {
@@ -290,6 +340,15 @@ func ValidateLabel(val string) (string, error) {
 	return val, nil
 }
 
+// ValidateSecretName checks the name of a secret, which is also the name of
+// its file in the containers.
+func ValidateSecretName(val string) (string, error) {
+	if !secretRegexp.MatchString(val) {
+		return "", fmt.Errorf("invalid secret name %q, only [a-zA-Z0-9][a-zA-Z0-9_.-] are allowed", val)
+	}
+	return val, nil
+}
+
 func ValidateHost(val string) (string, error) {
 	host, err := parsers.ParseHost(DefaultHTTPHost, DefaultUnixSocket, val)
 	if err != nil {
@@ -179,6 +179,54 @@ func TestValidateExtraHosts(t *testing.T) {
 	}
 }
 
+func TestValidateRuntime(t *testing.T) {
+	valid := []string{
+		`runc=/usr/local/bin/runc`,
+		`runv-0.1=/opt/runv/bin/runv`,
+	}
+	invalid := map[string]string{
+		`runc`:                 `not a valid runtime`,
+		`-runc=/usr/bin/runc`:  `not a valid runtime`,
+		`native=/usr/bin/runc`: `native driver`,
+		`runc=runc`:            `must be absolute`,
+	}
+
+	for _, runtime := range valid {
+		if _, err := ValidateRuntime(runtime); err != nil {
+			t.Fatalf("ValidateRuntime(`%s`) should succeed: error %v", runtime, err)
+		}
+	}
+
+	for runtime, expectedError := range invalid {
+		if _, err := ValidateRuntime(runtime); err == nil || !strings.Contains(err.Error(), expectedError) {
+			t.Fatalf("ValidateRuntime(`%s`) should fail with %q, got %v", runtime, expectedError, err)
+		}
+	}
+}
+
+func TestValidateRuntimeArgAndEnv(t *testing.T) {
+	if _, err := ValidateRuntimeArg("runc=--debug"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateRuntimeArg("kata=--kata-config=/etc/kata/configuration.toml"); err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range []string{"--debug", "runc=", "-runc=--debug"} {
+		if _, err := ValidateRuntimeArg(arg); err == nil || !strings.Contains(err.Error(), "not a valid runtime argument") {
+			t.Fatalf("ValidateRuntimeArg(`%s`) should fail, got %v", arg, err)
+		}
+	}
+
+	if _, err := ValidateRuntimeEnv("kata=KATA_DEBUG=1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range []string{"KATA_DEBUG=1", "kata=KATA_DEBUG", "kata==1"} {
+		if _, err := ValidateRuntimeEnv(env); err == nil || !strings.Contains(err.Error(), "not a valid runtime environment variable") {
+			t.Fatalf("ValidateRuntimeEnv(`%s`) should fail, got %v", env, err)
+		}
+	}
+}
+
 func logOptsValidator(val string) (string, error) {
 	allowedKeys := map[string]string{"max-size": "1", "max-file": "2"}
 	vals := strings.Split(val, "=")
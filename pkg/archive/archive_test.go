@@ -157,6 +157,19 @@ func TestExtensionXz(t *testing.T) {
 		t.Fatalf("The extension of a bzip2 archive should be 'tar.xz'")
 	}
 }
+func TestExtensionZstd(t *testing.T) {
+	compression := Zstd
+	output := compression.Extension()
+	if output != "tar.zst" {
+		t.Fatalf("The extension of a zstd archive should be 'tar.zst'")
+	}
+}
+
+func TestDetectCompressionZstd(t *testing.T) {
+	if compression := DetectCompression([]byte{0x28, 0xB5, 0x2F, 0xFD, 0x04, 0x58}); compression != Zstd {
+		t.Fatalf("Expected the zstd frame to be detected, got %s", compression.Extension())
+	}
+}
 
 func TestCmdStreamLargeStderr(t *testing.T) {
 	cmd := exec.Command("/bin/sh", "-c", "dd if=/dev/zero bs=1k count=1000 of=/dev/stderr; echo hello")
@@ -629,6 +642,36 @@ func TestTarUntar(t *testing.T) {
 	}
 }
 
+func TestUntarChownOpts(t *testing.T) {
+	origin, err := ioutil.TempDir("", "docker-test-untar-chown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(origin)
+	if err := ioutil.WriteFile(path.Join(origin, "1"), []byte("hello world"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := Tar(origin, Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	dest := path.Join(origin, "dest")
+	if err := Untar(archive, dest, &TarOptions{ChownOpts: &TarChownOptions{UID: 1234, GID: 5678}}); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Lstat(path.Join(dest, "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := fi.Sys().(*syscall.Stat_t)
+	if st.Uid != 1234 || st.Gid != 5678 {
+		t.Fatalf("Expected the file to be owned by 1234:5678, got %d:%d", st.Uid, st.Gid)
+	}
+}
+
 func TestTarUntarWithXattr(t *testing.T) {
 	origin, err := ioutil.TempDir("", "docker-test-untar-origin")
 	if err != nil {
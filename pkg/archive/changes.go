@@ -68,6 +68,24 @@ func sameFsTimeSpec(a, b syscall.Timespec) bool {
 // Changes walks the path rw and determines changes for the files in the path,
 // with respect to the parent layers
 func Changes(layers []string, rw string) ([]Change, error) {
+	return changes(layers, rw, aufsDeletedFile)
+}
+
+// deletedFileFunc returns the path of the file deleted by the file at path
+// in rw, or "" if it doesn't delete a file of the parent layers.
+type deletedFileFunc func(rw, path string, f os.FileInfo) (string, error)
+
+// aufsDeletedFile returns the path of the file deleted by the AUFS whiteout
+// at path, a file named after it with a .wh. prefix.
+func aufsDeletedFile(rw, path string, f os.FileInfo) (string, error) {
+	file := filepath.Base(path)
+	if strings.HasPrefix(file, ".wh.") {
+		return filepath.Join(filepath.Dir(path), file[len(".wh."):]), nil
+	}
+	return "", nil
+}
+
+func changes(layers []string, rw string, deletedFile deletedFileFunc) ([]Change, error) {
 	var changes []Change
 	err := filepath.Walk(rw, func(path string, f os.FileInfo, err error) error {
 		if err != nil {
@@ -96,11 +114,13 @@ func Changes(layers []string, rw string) ([]Change, error) {
 		}
 
 		// Find out what kind of modification happened
-		file := filepath.Base(path)
+		deleted, err := deletedFile(rw, path, f)
+		if err != nil {
+			return err
+		}
 		// If there is a whiteout, then the file was removed
-		if strings.HasPrefix(file, ".wh.") {
-			originalFile := file[len(".wh."):]
-			change.Path = filepath.Join(filepath.Dir(path), originalFile)
+		if deleted != "" {
+			change.Path = deleted
 			change.Kind = ChangeDelete
 		} else {
 			// Otherwise, the file was added
@@ -0,0 +1,82 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/docker/docker/pkg/system"
+)
+
+// OverlayChanges walks the upper directory rw of an overlay mount and
+// determines changes for the files in the path, with respect to its lower
+// layers.
+func OverlayChanges(layers []string, rw string) ([]Change, error) {
+	changes, err := changes(layers, rw, overlayDeletedFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// The files of the lower layers in an opaque directory were deleted,
+	// the directory was removed and created again.
+	for _, change := range changes {
+		if change.Kind == ChangeDelete {
+			continue
+		}
+		fi, err := os.Lstat(filepath.Join(rw, change.Path))
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			continue
+		}
+		opaque, err := isOverlayOpaque(filepath.Join(rw, change.Path))
+		if err != nil {
+			return nil, err
+		}
+		if !opaque {
+			continue
+		}
+		for _, layer := range layers {
+			fis, err := ioutil.ReadDir(filepath.Join(layer, change.Path))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			for _, fi := range fis {
+				path := filepath.Join(change.Path, fi.Name())
+				if _, err := os.Lstat(filepath.Join(rw, path)); os.IsNotExist(err) {
+					changes = append(changes, Change{Path: path, Kind: ChangeDelete})
+				}
+			}
+		}
+	}
+	return changes, nil
+}
+
+// overlayDeletedFile returns path if the file at path is an overlay
+// whiteout, a character device with the 0/0 device number.
+func overlayDeletedFile(rw, path string, f os.FileInfo) (string, error) {
+	if f.Mode()&os.ModeCharDevice == 0 {
+		return "", nil
+	}
+	s, ok := f.Sys().(*syscall.Stat_t)
+	if ok && major(uint64(s.Rdev)) == 0 && minor(uint64(s.Rdev)) == 0 {
+		return path, nil
+	}
+	return "", nil
+}
+
+func isOverlayOpaque(path string) (bool, error) {
+	opaque, err := system.Lgetxattr(path, "trusted.overlay.opaque")
+	if err != nil {
+		if err == syscall.ENOTSUP {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(opaque) == 1 && opaque[0] == 'y', nil
+}
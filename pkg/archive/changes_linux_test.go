@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/pkg/system"
+)
+
+func TestOverlayChanges(t *testing.T) {
+	upper, err := ioutil.TempDir("", "docker-changes-test-upper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(upper)
+	lower, err := ioutil.TempDir("", "docker-changes-test-layer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(lower)
+	createSampleDir(t, lower)
+
+	// file1 is modified, file2 is deleted, dir2 is deleted and created again
+	// with a new file, and dir5 is added
+	if err := ioutil.WriteFile(path.Join(upper, "file1"), []byte("modified\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mknod(path.Join(upper, "file2"), syscall.S_IFCHR, 0); err != nil {
+		t.Skipf("Cannot create an overlay whiteout: %v", err)
+	}
+	if err := os.Mkdir(path.Join(upper, "dir2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Lsetxattr(path.Join(upper, "dir2"), "trusted.overlay.opaque", []byte("y"), 0); err != nil {
+		t.Skipf("Cannot set the opaque attribute of a directory: %v", err)
+	}
+	if err := ioutil.WriteFile(path.Join(upper, "dir2", "file2-2"), []byte("new\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path.Join(upper, "dir5"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := OverlayChanges([]string{lower}, upper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Sort(changesByPath(changes))
+
+	expectedChanges := []Change{
+		{"/dir2", ChangeModify},
+		{"/dir2/file2-1", ChangeDelete},
+		{"/dir2/file2-2", ChangeModify},
+		{"/dir5", ChangeAdd},
+		{"/file1", ChangeModify},
+		{"/file2", ChangeDelete},
+	}
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Fatalf("Expected the changes %v, got %v", expectedChanges, changes)
+	}
+}
@@ -0,0 +1,201 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Errors used or returned by the copy functions.
+var (
+	ErrNotDirectory  = errors.New("not a directory")
+	ErrDirNotExists  = errors.New("no such directory")
+	ErrCannotCopyDir = errors.New("cannot copy directory")
+)
+
+// CopyInfo holds the information about a source or a destination of a copy:
+// its path, and whether it exists and is a directory.
+type CopyInfo struct {
+	Path   string
+	Exists bool
+	IsDir  bool
+}
+
+// HasTrailingPathSeparator returns whether the path ends with a path
+// separator, which means it must be a directory.
+func HasTrailingPathSeparator(path string) bool {
+	return len(path) > 0 && os.IsPathSeparator(path[len(path)-1])
+}
+
+// IsContentsPath returns whether the path ends with "/.", which copies the
+// contents of the directory instead of the directory itself.
+func IsContentsPath(path string) bool {
+	return strings.HasSuffix(path, string(filepath.Separator)+".") || path == "."
+}
+
+// PreserveTrailingDotOrSeparator returns the cleaned path with the trailing
+// "/." or path separator of the original path, which filepath.Clean removes.
+func PreserveTrailingDotOrSeparator(cleanedPath, originalPath string) string {
+	if IsContentsPath(originalPath) && !IsContentsPath(cleanedPath) {
+		if !HasTrailingPathSeparator(cleanedPath) {
+			cleanedPath += string(filepath.Separator)
+		}
+		cleanedPath += "."
+	}
+	if !HasTrailingPathSeparator(cleanedPath) && HasTrailingPathSeparator(originalPath) {
+		cleanedPath += string(filepath.Separator)
+	}
+	return cleanedPath
+}
+
+// SplitPathDirEntry splits the path into its directory and its base name,
+// "." for a path ending with "/.".
+func SplitPathDirEntry(path string) (dir, base string) {
+	cleanedPath := filepath.Clean(path)
+	if IsContentsPath(path) {
+		return cleanedPath, "."
+	}
+	return filepath.Dir(cleanedPath), filepath.Base(cleanedPath)
+}
+
+// TarResource archives the file or the directory at sourcePath, its entries
+// being named after its base name, or the contents of the directory when the
+// path ends with "/.".
+func TarResource(sourcePath string) (io.ReadCloser, error) {
+	if _, err := os.Lstat(filepath.Clean(sourcePath)); err != nil {
+		return nil, err
+	}
+	dir, base := SplitPathDirEntry(sourcePath)
+	return TarWithOptions(dir, &TarOptions{
+		Compression:  Uncompressed,
+		IncludeFiles: []string{base},
+	})
+}
+
+// CopyInfoSourcePath returns the information about the local source of a copy.
+func CopyInfoSourcePath(path string) (CopyInfo, error) {
+	fi, err := os.Stat(filepath.Clean(path))
+	if err != nil {
+		return CopyInfo{}, err
+	}
+	if HasTrailingPathSeparator(path) && !fi.IsDir() {
+		return CopyInfo{}, ErrNotDirectory
+	}
+	return CopyInfo{Path: path, Exists: true, IsDir: fi.IsDir()}, nil
+}
+
+// CopyInfoDestinationPath returns the information about the local
+// destination of a copy, which doesn't need to exist.
+func CopyInfoDestinationPath(path string) (CopyInfo, error) {
+	fi, err := os.Stat(filepath.Clean(path))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return CopyInfo{}, err
+		}
+		// the parent directory must exist
+		parent, _ := SplitPathDirEntry(path)
+		if _, err := os.Stat(parent); err != nil {
+			if os.IsNotExist(err) {
+				return CopyInfo{}, ErrDirNotExists
+			}
+			return CopyInfo{}, err
+		}
+		return CopyInfo{Path: path}, nil
+	}
+	return CopyInfo{Path: path, Exists: true, IsDir: fi.IsDir()}, nil
+}
+
+// PrepareArchiveCopy returns the directory in which the archive of the
+// source of a copy, made by TarResource, is to be extracted to copy it to
+// the destination, and the archive with its entries renamed after the
+// destination as needed:
+//
+//   - a source copied into an existing directory keeps its name;
+//   - a source copied to a path which doesn't exist, or to an existing file,
+//     is named after the path;
+//   - the contents of a directory, with a source path ending with "/.", are
+//     copied into the destination directory, which is created as needed.
+func PrepareArchiveCopy(srcContent io.Reader, srcInfo, dstInfo CopyInfo) (string, io.ReadCloser, error) {
+	_, srcBase := SplitPathDirEntry(srcInfo.Path)
+	dstDir, dstBase := SplitPathDirEntry(dstInfo.Path)
+
+	switch {
+	case dstInfo.Exists && dstInfo.IsDir:
+		// copy the source into the directory, or its contents
+		return filepath.Clean(dstInfo.Path), ioutil.NopCloser(srcContent), nil
+	case dstInfo.Exists:
+		if srcInfo.IsDir {
+			return "", nil, ErrCannotCopyDir
+		}
+		return dstDir, RebaseArchiveEntries(srcContent, srcBase, dstBase), nil
+	case srcInfo.IsDir:
+		// the directory, or its contents, are copied to a new directory
+		return dstDir, RebaseArchiveEntries(srcContent, srcBase, dstBase), nil
+	case HasTrailingPathSeparator(dstInfo.Path):
+		// a file can't be copied into a directory which doesn't exist
+		return "", nil, ErrDirNotExists
+	default:
+		return dstDir, RebaseArchiveEntries(srcContent, srcBase, dstBase), nil
+	}
+}
+
+// RebaseArchiveEntries returns the archive with its entries named after
+// oldBase renamed after newBase. With an oldBase of ".", all the entries are
+// moved under newBase.
+func RebaseArchiveEntries(srcContent io.Reader, oldBase, newBase string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		tr := tar.NewReader(srcContent)
+		tw := tar.NewWriter(pw)
+		if oldBase == "." {
+			// the root of the contents, which isn't in the archive
+			if err := tw.WriteHeader(&tar.Header{Name: newBase + "/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			hdr.Name = rebaseName(hdr.Name, oldBase, newBase)
+			if hdr.Typeflag == tar.TypeLink {
+				hdr.Linkname = rebaseName(hdr.Linkname, oldBase, newBase)
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		if err := tw.Close(); err != nil {
+			logrus.Debugf("Can't close tar writer: %s", err)
+		}
+		pw.Close()
+	}()
+	return pr
+}
+
+func rebaseName(name, oldBase, newBase string) string {
+	if oldBase == "." {
+		return newBase + "/" + strings.TrimPrefix(name, "./")
+	}
+	if name == oldBase || strings.HasPrefix(name, oldBase+"/") {
+		return newBase + name[len(oldBase):]
+	}
+	return name
+}
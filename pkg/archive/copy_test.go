@@ -0,0 +1,167 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitPathDirEntry(t *testing.T) {
+	for _, tc := range []struct {
+		path, dir, base string
+	}{
+		{"/foo/bar", "/foo", "bar"},
+		{"/foo/bar/", "/foo", "bar"},
+		{"/foo/bar/.", "/foo/bar", "."},
+		{"foo", ".", "foo"},
+		{"/", "/", "/"},
+	} {
+		dir, base := SplitPathDirEntry(tc.path)
+		if dir != tc.dir || base != tc.base {
+			t.Errorf("SplitPathDirEntry(%q) = %q, %q, want %q, %q", tc.path, dir, base, tc.dir, tc.base)
+		}
+	}
+}
+
+func TestPreserveTrailingDotOrSeparator(t *testing.T) {
+	for _, tc := range []struct {
+		original, expected string
+	}{
+		{"/foo//bar", "/foo/bar"},
+		{"/foo/bar/", "/foo/bar/"},
+		{"/foo/bar/.", "/foo/bar/."},
+	} {
+		if got := PreserveTrailingDotOrSeparator(filepath.Clean(tc.original), tc.original); got != tc.expected {
+			t.Errorf("PreserveTrailingDotOrSeparator(%q) = %q, want %q", tc.original, got, tc.expected)
+		}
+	}
+}
+
+// copyResource copies the local resource at src to dst like docker cp.
+func copyResource(t *testing.T, src, dst string) error {
+	srcInfo, err := CopyInfoSourcePath(src)
+	if err != nil {
+		return err
+	}
+	content, err := TarResource(src)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	dstInfo, err := CopyInfoDestinationPath(dst)
+	if err != nil {
+		return err
+	}
+	dstDir, preparedContent, err := PrepareArchiveCopy(content, srcInfo, dstInfo)
+	if err != nil {
+		return err
+	}
+	defer preparedContent.Close()
+	return Untar(preparedContent, dstDir, &TarOptions{NoLchown: true, NoOverwriteDirNonDir: true})
+}
+
+func setupCopyTest(t *testing.T) string {
+	tmp, err := ioutil.TempDir("", "docker-copy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmp, "src", "dir", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"src/file", "src/dir/a", "src/dir/sub/b"} {
+		if err := ioutil.WriteFile(filepath.Join(tmp, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tmp, "dst"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return tmp
+}
+
+func assertFileContent(t *testing.T, path, content string) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != content {
+		t.Fatalf("Expected %q in %s, got %q", content, path, b)
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	tmp := setupCopyTest(t)
+	defer os.RemoveAll(tmp)
+
+	// into an existing directory
+	if err := copyResource(t, filepath.Join(tmp, "src", "file"), filepath.Join(tmp, "dst")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(tmp, "dst", "file"), "src/file")
+
+	// to a new file
+	if err := copyResource(t, filepath.Join(tmp, "src", "file"), filepath.Join(tmp, "dst", "renamed")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(tmp, "dst", "renamed"), "src/file")
+
+	// over an existing file
+	if err := copyResource(t, filepath.Join(tmp, "src", "dir", "a"), filepath.Join(tmp, "dst", "renamed")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(tmp, "dst", "renamed"), "src/dir/a")
+
+	// into a directory which doesn't exist
+	if err := copyResource(t, filepath.Join(tmp, "src", "file"), filepath.Join(tmp, "dst", "nodir")+"/"); err != ErrDirNotExists {
+		t.Fatalf("Expected %v, got %v", ErrDirNotExists, err)
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	tmp := setupCopyTest(t)
+	defer os.RemoveAll(tmp)
+
+	// into an existing directory
+	if err := copyResource(t, filepath.Join(tmp, "src", "dir"), filepath.Join(tmp, "dst")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(tmp, "dst", "dir", "sub", "b"), "src/dir/sub/b")
+
+	// to a new directory
+	if err := copyResource(t, filepath.Join(tmp, "src", "dir"), filepath.Join(tmp, "dst", "new")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(tmp, "dst", "new", "a"), "src/dir/a")
+
+	// the contents merged into an existing directory
+	if err := copyResource(t, filepath.Join(tmp, "src", "dir")+"/.", filepath.Join(tmp, "dst")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(tmp, "dst", "a"), "src/dir/a")
+	assertFileContent(t, filepath.Join(tmp, "dst", "dir", "a"), "src/dir/a")
+
+	// the contents to a new directory
+	if err := copyResource(t, filepath.Join(tmp, "src", "dir")+"/.", filepath.Join(tmp, "dst", "contents")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(tmp, "dst", "contents", "sub", "b"), "src/dir/sub/b")
+
+	// over an existing file
+	if err := copyResource(t, filepath.Join(tmp, "src", "dir"), filepath.Join(tmp, "dst", "a")); err != ErrCannotCopyDir {
+		t.Fatalf("Expected %v, got %v", ErrCannotCopyDir, err)
+	}
+}
+
+func TestUntarNoOverwriteDirNonDir(t *testing.T) {
+	tmp := setupCopyTest(t)
+	defer os.RemoveAll(tmp)
+
+	// a file named like an existing directory
+	if err := os.Mkdir(filepath.Join(tmp, "dst", "file"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyResource(t, filepath.Join(tmp, "src", "file"), filepath.Join(tmp, "dst")); err == nil {
+		t.Fatal("Expected an error overwriting a directory with a file")
+	}
+}
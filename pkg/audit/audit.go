@@ -0,0 +1,74 @@
+// Package audit records the requests changing the state of the daemon, who
+// made them, from where, and their result, to a file or to syslog.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is the record of a request.
+type Entry struct {
+	Time            time.Time
+	User            string // the user authenticated by its TLS certificate, if any
+	UserAuthNMethod string
+	RemoteAddr      string
+	Method          string
+	URI             string
+	Body            json.RawMessage `json:",omitempty"` // the JSON body of the request
+	BodyTruncated   bool            `json:",omitempty"` // the body is larger than MaxBodySize
+	Status          int
+	Error           string `json:",omitempty"`
+}
+
+// MaxBodySize is the maximum size of the bodies of the requests recorded.
+const MaxBodySize = 65536
+
+// Logger records the entries to a sink.
+type Logger interface {
+	Log(e *Entry) error
+	Close() error
+}
+
+// New returns the logger recording the entries to dest, "syslog" or the
+// absolute path of a file the entries are appended to as JSON, one per line.
+func New(dest string) (Logger, error) {
+	if dest == "syslog" {
+		return newSyslogLogger()
+	}
+	if !filepath.IsAbs(dest) {
+		return nil, fmt.Errorf("Invalid audit log %q, it must be syslog or an absolute path", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLogger{f: f}, nil
+}
+
+type fileLogger struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (l *fileLogger) Log(e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.f.Write(append(b, '\n'))
+	return err
+}
+
+func (l *fileLogger) Close() error {
+	return l.f.Close()
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := New("audit.log"); err == nil {
+		t.Fatal("Expected an error for a relative path")
+	}
+	path := filepath.Join(dir, "audit", "audit.log")
+	l, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []int{201, 404} {
+		if err := l.Log(&Entry{Method: "POST", URI: "/containers/create", Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var statuses []int
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		statuses = append(statuses, e.Status)
+	}
+	if len(statuses) != 2 || statuses[0] != 201 || statuses[1] != 404 {
+		t.Fatalf("Expected the entries to be appended, got the statuses %v", statuses)
+	}
+}
+
+func TestBodyRecorder(t *testing.T) {
+	body := `{"Image": "busybox"}`
+	r := NewBodyRecorder(ioutil.NopCloser(strings.NewReader(body)))
+	if b, err := ioutil.ReadAll(r); err != nil || string(b) != body {
+		t.Fatalf("Expected the body to be read, got %v: %s", err, b)
+	}
+	var e Entry
+	r.Record(&e)
+	if string(e.Body) != body || e.BodyTruncated {
+		t.Fatalf("Expected the body to be recorded, got %s", e.Body)
+	}
+
+	// the bodies which aren't JSON are not recorded
+	r = NewBodyRecorder(ioutil.NopCloser(strings.NewReader("FROM busybox")))
+	ioutil.ReadAll(r)
+	e = Entry{}
+	r.Record(&e)
+	if e.Body != nil {
+		t.Fatalf("Expected no body, got %s", e.Body)
+	}
+
+	large := append([]byte(`"`), bytes.Repeat([]byte("a"), MaxBodySize)...)
+	r = NewBodyRecorder(ioutil.NopCloser(bytes.NewReader(append(large, '"'))))
+	if b, err := ioutil.ReadAll(r); err != nil || len(b) != MaxBodySize+2 {
+		t.Fatalf("Expected the whole body to be read, got %v: %d bytes", err, len(b))
+	}
+	e = Entry{}
+	r.Record(&e)
+	if e.Body != nil || !e.BodyTruncated {
+		t.Fatalf("Expected the body to be truncated, got %s", e.Body)
+	}
+}
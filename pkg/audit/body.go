@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// BodyRecorder records the first MaxBodySize bytes of the body of a request
+// as the handler of the request reads it.
+type BodyRecorder struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	truncated bool
+}
+
+// NewBodyRecorder returns the recorder of body, to replace it.
+func NewBodyRecorder(body io.ReadCloser) *BodyRecorder {
+	return &BodyRecorder{ReadCloser: body}
+}
+
+func (r *BodyRecorder) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if left := MaxBodySize - r.buf.Len(); n > left {
+		r.buf.Write(p[:left])
+		r.truncated = true
+	} else {
+		r.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// Record sets the body of the entry to the body read, if it is valid JSON.
+func (r *BodyRecorder) Record(e *Entry) {
+	if r.truncated {
+		e.BodyTruncated = true
+		return
+	}
+	var v interface{}
+	if b := bytes.TrimSpace(r.buf.Bytes()); json.Unmarshal(b, &v) == nil {
+		e.Body = b
+	}
+}
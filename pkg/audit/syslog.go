@@ -0,0 +1,33 @@
+// +build !windows
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+)
+
+type syslogLogger struct {
+	w *syslog.Writer
+}
+
+func newSyslogLogger() (Logger, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "docker-audit")
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to syslog for the audit log: %v", err)
+	}
+	return &syslogLogger{w: w}, nil
+}
+
+func (l *syslogLogger) Log(e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return l.w.Info(string(b))
+}
+
+func (l *syslogLogger) Close() error {
+	return l.w.Close()
+}
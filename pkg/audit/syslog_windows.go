@@ -0,0 +1,7 @@
+package audit
+
+import "fmt"
+
+func newSyslogLogger() (Logger, error) {
+	return nil, fmt.Errorf("The audit log to syslog is not supported on Windows")
+}
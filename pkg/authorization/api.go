@@ -0,0 +1,47 @@
+package authorization
+
+const (
+	// AuthZApiImplements is the extension point implemented by the
+	// authorization plugins.
+	AuthZApiImplements = "authz"
+
+	// AuthZApiRequest is the method of the plugins authorizing a request,
+	// before the daemon handles it.
+	AuthZApiRequest = "AuthZPlugin.AuthZReq"
+
+	// AuthZApiResponse is the method of the plugins authorizing the response
+	// of a request, before it is sent to the client.
+	AuthZApiResponse = "AuthZPlugin.AuthZRes"
+)
+
+// Request is a request of the remote API, and its response in the response
+// phase, sent to the authorization plugins.
+type Request struct {
+	// User is the user who made the request, the common name of the TLS
+	// client certificate, or empty if the request isn't authenticated
+	User string `json:"User,omitempty"`
+
+	// UserAuthNMethod is the method which authenticated the user, "TLS"
+	UserAuthNMethod string `json:"UserAuthNMethod,omitempty"`
+
+	RequestMethod  string            `json:"RequestMethod,omitempty"`
+	RequestURI     string            `json:"RequestUri,omitempty"`
+	RequestBody    []byte            `json:"RequestBody,omitempty"` // only for the small JSON bodies
+	RequestHeaders map[string]string `json:"RequestHeaders,omitempty"`
+
+	ResponseStatusCode int               `json:"ResponseStatusCode,omitempty"`
+	ResponseBody       []byte            `json:"ResponseBody,omitempty"` // only for the small JSON bodies
+	ResponseHeaders    map[string]string `json:"ResponseHeaders,omitempty"`
+}
+
+// Response is the decision of an authorization plugin.
+type Response struct {
+	// Allow allows the request, or its response
+	Allow bool `json:"Allow"`
+
+	// Msg is the message explaining a denial to the client
+	Msg string `json:"Msg,omitempty"`
+
+	// Err is an error of the plugin, denying the request
+	Err string `json:"Err,omitempty"`
+}
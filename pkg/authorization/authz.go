@@ -0,0 +1,132 @@
+package authorization
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// maxBodySize is the maximum size of the bodies sent to the authorization
+// plugins. The larger bodies, such as the build contexts and the image
+// tarballs, are streamed without being sent to the plugins.
+const maxBodySize = 1048576 // 1MB
+
+// Ctx is the authorization context of a request of the remote API, sent in
+// turn to each authorization plugin. A request is allowed only if all the
+// plugins allow it.
+type Ctx struct {
+	plugins []Plugin
+	authReq *Request
+}
+
+// NewCtx returns the authorization context of the request of the given user,
+// authenticated with the method userAuthNMethod.
+func NewCtx(plugins []Plugin, user, userAuthNMethod, requestMethod, requestURI string) *Ctx {
+	return &Ctx{
+		plugins: plugins,
+		authReq: &Request{
+			User:            user,
+			UserAuthNMethod: userAuthNMethod,
+			RequestMethod:   requestMethod,
+			RequestURI:      requestURI,
+		},
+	}
+}
+
+// AuthZRequest authorizes the request r, before the daemon handles it. The
+// body of the request is kept for the daemon.
+func (ctx *Ctx) AuthZRequest(r *http.Request) error {
+	if isJSON(r.Header) && r.Body != nil {
+		body, rd, err := drainBody(r.Body)
+		if err != nil {
+			return err
+		}
+		r.Body = rd
+		ctx.authReq.RequestBody = body
+	}
+	ctx.authReq.RequestHeaders = headers(r.Header)
+
+	for _, plugin := range ctx.plugins {
+		logrus.Debugf("AuthZ request using plugin %s", plugin.Name())
+		authRes, err := plugin.AuthZRequest(ctx.authReq)
+		if err := authorized(plugin, authRes, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthZResponse authorizes the response of the request, of the given status
+// code and headers, before it is sent to the client. The body is nil if it
+// isn't sent to the plugins.
+func (ctx *Ctx) AuthZResponse(statusCode int, header http.Header, body []byte) error {
+	ctx.authReq.ResponseStatusCode = statusCode
+	ctx.authReq.ResponseHeaders = headers(header)
+	if isJSON(header) && len(body) <= maxBodySize {
+		ctx.authReq.ResponseBody = body
+	}
+
+	for _, plugin := range ctx.plugins {
+		logrus.Debugf("AuthZ response using plugin %s", plugin.Name())
+		authRes, err := plugin.AuthZResponse(ctx.authReq)
+		if err := authorized(plugin, authRes, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorized returns the error denying a request, for the response authRes
+// of a plugin, or the error of the plugin.
+func authorized(plugin Plugin, authRes *Response, err error) error {
+	if err != nil {
+		return fmt.Errorf("Authorization plugin %s failed with error: %v", plugin.Name(), err)
+	}
+	if authRes.Err != "" {
+		return fmt.Errorf("Authorization plugin %s failed with error: %s", plugin.Name(), authRes.Err)
+	}
+	if !authRes.Allow {
+		return fmt.Errorf("Authorization denied by plugin %s: %s", plugin.Name(), authRes.Msg)
+	}
+	return nil
+}
+
+// drainBody returns the body if it is smaller than maxBodySize, and a reader
+// of the whole body to replace it.
+func drainBody(body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	rd := bufio.NewReaderSize(body, maxBodySize)
+	b, err := rd.Peek(maxBodySize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, nil, err
+	}
+	rc := struct {
+		io.Reader
+		io.Closer
+	}{rd, body}
+	if err == io.EOF {
+		return append([]byte(nil), b...), rc, nil
+	}
+	// the body is too large
+	return nil, rc, nil
+}
+
+func isJSON(header http.Header) bool {
+	return strings.HasPrefix(header.Get("Content-Type"), "application/json")
+}
+
+// headers returns the headers sent to the plugins, without the credentials
+// of the registries.
+func headers(header http.Header) map[string]string {
+	h := make(map[string]string)
+	for k, v := range header {
+		if k == "X-Registry-Auth" || k == "X-Registry-Config" {
+			continue
+		}
+		h[k] = strings.Join(v, ",")
+	}
+	return h
+}
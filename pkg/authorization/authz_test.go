@@ -0,0 +1,184 @@
+package authorization
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// testPlugin allows the requests for which allow returns true, recording the
+// requests it saw.
+type testPlugin struct {
+	name     string
+	allow    func(*Request) bool
+	requests []Request
+}
+
+func (p *testPlugin) Name() string {
+	return p.name
+}
+
+func (p *testPlugin) AuthZRequest(authReq *Request) (*Response, error) {
+	p.requests = append(p.requests, *authReq)
+	return &Response{Allow: p.allow(authReq), Msg: "denied by " + p.name}, nil
+}
+
+func (p *testPlugin) AuthZResponse(authReq *Request) (*Response, error) {
+	return p.AuthZRequest(authReq)
+}
+
+func allowAll(*Request) bool { return true }
+
+func TestAuthZRequest(t *testing.T) {
+	first := &testPlugin{name: "first", allow: allowAll}
+	second := &testPlugin{name: "second", allow: func(authReq *Request) bool {
+		return !bytes.Contains(authReq.RequestBody, []byte(`"Privileged":true`))
+	}}
+	plugins := []Plugin{first, second}
+
+	body := `{"Image":"busybox","HostConfig":{"Privileged":false}}`
+	r, _ := http.NewRequest("POST", "/containers/create", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Registry-Auth", "secret")
+	if err := NewCtx(plugins, "alice", "TLS", r.Method, r.RequestURI).AuthZRequest(r); err != nil {
+		t.Fatal(err)
+	}
+	if len(first.requests) != 1 || len(second.requests) != 1 {
+		t.Fatalf("expected each plugin to authorize the request, got %d and %d", len(first.requests), len(second.requests))
+	}
+	authReq := second.requests[0]
+	if authReq.User != "alice" || authReq.UserAuthNMethod != "TLS" || authReq.RequestMethod != "POST" {
+		t.Fatalf("expected the user and the method of the request, got %+v", authReq)
+	}
+	if string(authReq.RequestBody) != body {
+		t.Fatalf("expected the body of the request, got %q", authReq.RequestBody)
+	}
+	if _, exists := authReq.RequestHeaders["X-Registry-Auth"]; exists {
+		t.Fatal("expected the credentials of the registries to be kept from the plugins")
+	}
+	// the body is kept for the daemon
+	if b, _ := ioutil.ReadAll(r.Body); string(b) != body {
+		t.Fatalf("expected the body of the request to be kept, got %q", b)
+	}
+
+	r, _ = http.NewRequest("POST", "/containers/create", strings.NewReader(`{"HostConfig":{"Privileged":true}}`))
+	r.Header.Set("Content-Type", "application/json")
+	err := NewCtx(plugins, "", "", r.Method, r.RequestURI).AuthZRequest(r)
+	if err == nil || !strings.Contains(err.Error(), "Authorization denied by plugin second: denied by second") {
+		t.Fatalf("expected the request to be denied, got %v", err)
+	}
+}
+
+func TestAuthZRequestLargeBody(t *testing.T) {
+	plugin := &testPlugin{name: "test", allow: allowAll}
+
+	body := bytes.Repeat([]byte("a"), maxBodySize+1)
+	r, _ := http.NewRequest("POST", "/build", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if err := NewCtx([]Plugin{plugin}, "", "", r.Method, r.RequestURI).AuthZRequest(r); err != nil {
+		t.Fatal(err)
+	}
+	if plugin.requests[0].RequestBody != nil {
+		t.Fatal("expected a large body not to be sent to the plugins")
+	}
+	if b, _ := ioutil.ReadAll(r.Body); !bytes.Equal(b, body) {
+		t.Fatalf("expected the whole body to be kept, got %d bytes", len(b))
+	}
+}
+
+func TestResponseModifier(t *testing.T) {
+	plugin := &testPlugin{name: "test", allow: func(authReq *Request) bool {
+		return !bytes.Contains(authReq.ResponseBody, []byte("secret"))
+	}}
+	authCtx := NewCtx([]Plugin{plugin}, "", "", "GET", "/info")
+
+	w := httptest.NewRecorder()
+	rm := NewResponseModifier(w, authCtx.AuthZResponse)
+	rm.Header().Set("Content-Type", "application/json")
+	rm.WriteHeader(http.StatusCreated)
+	rm.Write([]byte(`{"Name":"public"}`))
+	if w.Body.Len() != 0 {
+		t.Fatal("expected the response to be held until it is authorized")
+	}
+	if err := rm.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusCreated || w.Body.String() != `{"Name":"public"}` {
+		t.Fatalf("expected the response to be written, got %d %q", w.Code, w.Body.String())
+	}
+	if authReq := plugin.requests[0]; authReq.ResponseStatusCode != http.StatusCreated || string(authReq.ResponseBody) != `{"Name":"public"}` {
+		t.Fatalf("expected the status code and the body of the response, got %+v", authReq)
+	}
+
+	w = httptest.NewRecorder()
+	rm = NewResponseModifier(w, authCtx.AuthZResponse)
+	rm.Header().Set("Content-Type", "application/json")
+	rm.Write([]byte(`{"Name":"secret"}`))
+	if err := rm.FlushAll(); err == nil {
+		t.Fatal("expected the response to be denied")
+	}
+	if w.Code != http.StatusForbidden || strings.Contains(w.Body.String(), `"Name"`) {
+		t.Fatalf("expected the response to be replaced by the denial, got %d %q", w.Code, w.Body.String())
+	}
+	if _, err := rm.Write([]byte("more")); err == nil {
+		t.Fatal("expected an error writing a denied response")
+	}
+
+	// a streamed response is authorized when it is first flushed
+	w = httptest.NewRecorder()
+	rm = NewResponseModifier(w, authCtx.AuthZResponse)
+	rm.Header().Set("Content-Type", "application/json")
+	rm.Write([]byte(`{"status":"start"}`))
+	rm.Flush()
+	if !w.Flushed || w.Body.String() != `{"status":"start"}` {
+		t.Fatalf("expected the response to be streamed, got %q", w.Body.String())
+	}
+	rm.Write([]byte(`{"status":"secret"}`))
+	if !strings.HasSuffix(w.Body.String(), `{"status":"secret"}`) {
+		t.Fatalf("expected the rest of the response to be streamed, got %q", w.Body.String())
+	}
+}
+
+func TestAuthorizationPlugin(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+AuthZApiRequest, func(w http.ResponseWriter, r *http.Request) {
+		var authReq Request
+		if err := json.NewDecoder(r.Body).Decode(&authReq); err != nil {
+			t.Fatal(err)
+		}
+		json.NewEncoder(w).Encode(&Response{Allow: authReq.RequestMethod == "GET", Msg: "read only"})
+	})
+	mux.HandleFunc("/"+AuthZApiResponse, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&Response{Err: "broken"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	plugin := &authorizationPlugin{name: "test", client: plugins.NewClient(server.URL)}
+	authRes, err := plugin.AuthZRequest(&Request{RequestMethod: "GET"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !authRes.Allow {
+		t.Fatalf("expected the plugin to allow the request, got %+v", authRes)
+	}
+	authRes, err = plugin.AuthZRequest(&Request{RequestMethod: "POST"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authRes.Allow || authRes.Msg != "read only" {
+		t.Fatalf("expected the plugin to deny the request, got %+v", authRes)
+	}
+
+	// an error of a plugin denies the response
+	err = NewCtx([]Plugin{plugin}, "", "", "GET", "/info").AuthZResponse(http.StatusOK, http.Header{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed with error: broken") {
+		t.Fatalf("expected the error of the plugin, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package authorization
+
+import (
+	"sync"
+
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// Plugin authorizes the requests of the remote API, and their responses.
+type Plugin interface {
+	// Name returns the name of the plugin.
+	Name() string
+
+	// AuthZRequest authorizes a request, before the daemon handles it.
+	AuthZRequest(*Request) (*Response, error)
+
+	// AuthZResponse authorizes the response of a request, before it is sent
+	// to the client.
+	AuthZResponse(*Request) (*Response, error)
+}
+
+// NewPlugins returns the authorization plugins with the given names, in the
+// order in which they authorize the requests.
+func NewPlugins(names []string) []Plugin {
+	var plugins []Plugin
+	for _, name := range names {
+		plugins = append(plugins, &authorizationPlugin{name: name})
+	}
+	return plugins
+}
+
+// authorizationPlugin is a plugin implementing the authz extension point,
+// activated the first time it authorizes a request.
+type authorizationPlugin struct {
+	name   string
+	client *plugins.Client
+	once   sync.Once
+	err    error
+}
+
+func (a *authorizationPlugin) Name() string {
+	return a.name
+}
+
+func (a *authorizationPlugin) AuthZRequest(authReq *Request) (*Response, error) {
+	return a.call(AuthZApiRequest, authReq)
+}
+
+func (a *authorizationPlugin) AuthZResponse(authReq *Request) (*Response, error) {
+	return a.call(AuthZApiResponse, authReq)
+}
+
+func (a *authorizationPlugin) call(method string, authReq *Request) (*Response, error) {
+	if err := a.initPlugin(); err != nil {
+		return nil, err
+	}
+	authRes := &Response{}
+	if err := a.client.Call(method, authReq, authRes); err != nil {
+		return nil, err
+	}
+	return authRes, nil
+}
+
+// initPlugin activates the plugin the first time it is used. A plugin which
+// can't be activated denies all the requests.
+func (a *authorizationPlugin) initPlugin() error {
+	a.once.Do(func() {
+		if a.client != nil {
+			return
+		}
+		plugin, err := plugins.Get(a.name, AuthZApiImplements)
+		if err != nil {
+			a.err = err
+			return
+		}
+		a.client = plugin.Client
+	})
+	return a.err
+}
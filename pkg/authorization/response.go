@@ -0,0 +1,129 @@
+package authorization
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ResponseModifier is an http.ResponseWriter holding a response until it is
+// authorized by the authorization plugins. The response is authorized once
+// the handler returns, or before it is first streamed to the client by a
+// flush, or when the connection is hijacked. A denied response is replaced
+// by the denial.
+type ResponseModifier struct {
+	w         http.ResponseWriter
+	authorize func(statusCode int, header http.Header, body []byte) error
+	status    int
+	body      bytes.Buffer
+	truncated bool // the body is larger than maxBodySize
+	done      bool
+	err       error
+}
+
+// NewResponseModifier returns a ResponseModifier holding the response written
+// to w until it is authorized by authorize.
+func NewResponseModifier(w http.ResponseWriter, authorize func(statusCode int, header http.Header, body []byte) error) *ResponseModifier {
+	return &ResponseModifier{w: w, authorize: authorize}
+}
+
+func (rm *ResponseModifier) Header() http.Header {
+	return rm.w.Header()
+}
+
+func (rm *ResponseModifier) WriteHeader(status int) {
+	if rm.done {
+		if rm.err == nil {
+			rm.w.WriteHeader(status)
+		}
+		return
+	}
+	rm.status = status
+}
+
+func (rm *ResponseModifier) Write(b []byte) (int, error) {
+	if !rm.done && rm.body.Len()+len(b) > maxBodySize {
+		// the large bodies are streamed without being sent to the plugins
+		rm.truncated = true
+		if err := rm.FlushAll(); err != nil {
+			return 0, err
+		}
+	}
+	if rm.done {
+		if rm.err != nil {
+			return 0, rm.err
+		}
+		return rm.w.Write(b)
+	}
+	return rm.body.Write(b)
+}
+
+// Flush authorizes the response, and streams it to the client.
+func (rm *ResponseModifier) Flush() {
+	if err := rm.FlushAll(); err != nil {
+		return
+	}
+	if flusher, ok := rm.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack authorizes the response, with the status code and the headers set
+// so far, before the connection is hijacked.
+func (rm *ResponseModifier) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if err := rm.FlushAll(); err != nil {
+		return nil, nil, err
+	}
+	hijacker, ok := rm.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Internal response writer doesn't support the Hijacker interface")
+	}
+	return hijacker.Hijack()
+}
+
+func (rm *ResponseModifier) CloseNotify() <-chan bool {
+	if notifier, ok := rm.w.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// FlushAll authorizes the response, the first time it is called, and writes
+// what was held of it. A denied response is replaced by the denial, and the
+// error denying it is returned.
+func (rm *ResponseModifier) FlushAll() error {
+	if rm.done {
+		return rm.err
+	}
+	rm.done = true
+
+	status := rm.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	var body []byte
+	if !rm.truncated {
+		body = rm.body.Bytes()
+	}
+	if err := rm.authorize(status, rm.w.Header(), body); err != nil {
+		rm.err = err
+		rm.w.Header().Del("Content-Length")
+		http.Error(rm.w, err.Error(), http.StatusForbidden)
+		return err
+	}
+
+	// nothing is written to a response which isn't started, for the
+	// connection to be hijacked
+	if rm.status != 0 {
+		rm.w.WriteHeader(rm.status)
+	}
+	if rm.body.Len() > 0 {
+		if _, err := rm.w.Write(rm.body.Bytes()); err != nil {
+			return err
+		}
+	}
+	rm.body.Reset()
+	return nil
+}
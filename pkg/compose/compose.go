@@ -0,0 +1,587 @@
+// Package compose loads the compose files describing the services of an
+// application, run in containers, and the volumes they use.
+package compose
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Config is a compose file.
+type Config struct {
+	Services map[string]*Service
+	Volumes  map[string]*Volume
+}
+
+// Volume is a named volume of a compose file.
+type Volume struct {
+	Name       string
+	Driver     string
+	DriverOpts map[string]string
+	Labels     map[string]string
+	External   bool // the volume exists, and keeps its name
+}
+
+// Service is a service of a compose file, run in a container.
+type Service struct {
+	Name          string
+	Image         string
+	ContainerName string
+	Command       []string // the command of the image if nil
+	Entrypoint    []string // the entrypoint of the image if nil
+	Environment   []string // as KEY=value
+	Labels        map[string]string
+	Ports         []string
+	Expose        []string
+	Volumes       []string
+	VolumesFrom   []string // the services, or the containers as container:NAME
+	Links         []string // the services, as SERVICE or SERVICE:ALIAS
+	DependsOn     []string
+	NetworkMode   string
+	Restart       string
+	User          string
+	WorkingDir    string
+	Hostname      string
+	MemLimit      string
+	CPUShares     string
+	Privileged    bool
+	ReadOnly      bool
+	Tty           bool
+	StdinOpen     bool
+	CapAdd        []string
+	CapDrop       []string
+	DNS           []string
+	ExtraHosts    []string
+}
+
+// Dependencies returns the services the service depends on, sorted: the
+// services of its depends_on, links, volumes_from and network_mode.
+func (s *Service) Dependencies() []string {
+	seen := make(map[string]bool)
+	for _, name := range s.DependsOn {
+		seen[name] = true
+	}
+	for _, link := range s.Links {
+		seen[strings.SplitN(link, ":", 2)[0]] = true
+	}
+	for _, from := range s.VolumesFrom {
+		if !strings.HasPrefix(from, "container:") {
+			seen[strings.SplitN(from, ":", 2)[0]] = true
+		}
+	}
+	if strings.HasPrefix(s.NetworkMode, "service:") {
+		seen[strings.TrimPrefix(s.NetworkMode, "service:")] = true
+	}
+	deps := make([]string, 0, len(seen))
+	for name := range seen {
+		deps = append(deps, name)
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// Load loads the compose file data. The relative paths of the volumes are
+// resolved from dir, and the variables of the values, as $VAR or ${VAR}, are
+// replaced by their value returned by env. The version 1 files, listing the
+// services at the top level, and the version 2 and 3 files, listing them in
+// the services section, are supported.
+func Load(data []byte, dir string, env func(string) string) (*Config, error) {
+	v, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	if v, err = interpolate(v, env); err != nil {
+		return nil, err
+	}
+	top, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("The compose file must be a mapping")
+	}
+
+	config := &Config{
+		Services: make(map[string]*Service),
+		Volumes:  make(map[string]*Volume),
+	}
+	services := top
+	if _, exists := top["services"]; exists {
+		version, _ := top["version"].(string)
+		if !strings.HasPrefix(version, "2") && !strings.HasPrefix(version, "3") {
+			return nil, fmt.Errorf("Unsupported compose file version %q, the versions 1, 2 and 3 are supported", version)
+		}
+		for key, value := range top {
+			switch key {
+			case "version":
+			case "services":
+				if services, ok = value.(map[string]interface{}); !ok {
+					return nil, fmt.Errorf("services must be a mapping")
+				}
+			case "volumes":
+				if err := loadVolumes(config, value); err != nil {
+					return nil, err
+				}
+			case "networks":
+				if value != nil {
+					return nil, errNetworks
+				}
+			default:
+				// the extension fields hold the anchors used by the services
+				if !strings.HasPrefix(key, "x-") {
+					return nil, fmt.Errorf("Unsupported key %s", key)
+				}
+			}
+		}
+	} else if _, exists := top["version"]; exists {
+		return nil, fmt.Errorf("The compose file has no services")
+	}
+
+	for name, value := range services {
+		s, err := loadService(name, value, dir)
+		if err != nil {
+			return nil, fmt.Errorf("Service %s: %v", name, err)
+		}
+		config.Services[name] = s
+	}
+	if len(config.Services) == 0 {
+		return nil, fmt.Errorf("The compose file has no services")
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+var errNetworks = fmt.Errorf("Networks aren't supported, the services reach the services they depend on by links")
+
+// validate checks the services reference the services and the volumes of
+// the file.
+func (c *Config) validate() error {
+	for _, s := range c.Services {
+		for _, dep := range s.Dependencies() {
+			if _, exists := c.Services[dep]; !exists {
+				return fmt.Errorf("Service %s depends on the undefined service %s", s.Name, dep)
+			}
+		}
+		if len(c.Volumes) == 0 {
+			// the version 1 files pass the names of the volumes as is
+			continue
+		}
+		for _, v := range s.Volumes {
+			if name := volumeName(v); name != "" {
+				if _, exists := c.Volumes[name]; !exists {
+					return fmt.Errorf("Service %s uses the undefined volume %s", s.Name, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Order returns the services sorted so that each service comes after the
+// services it depends on, by name otherwise.
+func (c *Config) Order() ([]*Service, error) {
+	var (
+		order   []*Service
+		pending = make(map[string]int)
+		needs   = make(map[string][]string)
+	)
+	for name, s := range c.Services {
+		deps := s.Dependencies()
+		pending[name] = len(deps)
+		for _, dep := range deps {
+			needs[dep] = append(needs[dep], name)
+		}
+	}
+	var ready []string
+	for name, n := range pending {
+		if n == 0 {
+			ready = append(ready, name)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		delete(pending, name)
+		order = append(order, c.Services[name])
+		for _, dependent := range needs[name] {
+			if pending[dependent]--; pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	if len(pending) > 0 {
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("Circular dependency between the services %s", strings.Join(names, ", "))
+	}
+	return order, nil
+}
+
+// volumeName returns the name of the named volume of the volume spec of a
+// service, or "" for a host directory or an anonymous volume.
+func volumeName(spec string) string {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) == 1 || isPath(parts[0]) {
+		return ""
+	}
+	return parts[0]
+}
+
+func isPath(s string) bool {
+	return strings.HasPrefix(s, "/") || strings.HasPrefix(s, ".") || strings.HasPrefix(s, "~")
+}
+
+func loadVolumes(config *Config, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	volumes, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("volumes must be a mapping")
+	}
+	for name, value := range volumes {
+		v := &Volume{Name: name}
+		if value != nil {
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("Volume %s must be a mapping", name)
+			}
+			for key, value := range fields {
+				var err error
+				switch key {
+				case "driver":
+					v.Driver, err = toString(value)
+				case "driver_opts":
+					v.DriverOpts, err = toMap(value)
+				case "labels":
+					v.Labels, err = toMap(value)
+				case "external":
+					v.External, err = toBool(value)
+				default:
+					err = fmt.Errorf("unsupported key %s", key)
+				}
+				if err != nil {
+					return fmt.Errorf("Volume %s: %v", name, err)
+				}
+			}
+		}
+		config.Volumes[name] = v
+	}
+	return nil
+}
+
+func loadService(name string, value interface{}, dir string) (*Service, error) {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be a mapping")
+	}
+	s := &Service{Name: name}
+	for key, value := range fields {
+		var err error
+		switch key {
+		case "image":
+			s.Image, err = toString(value)
+		case "container_name":
+			s.ContainerName, err = toString(value)
+		case "command":
+			s.Command, err = toCommand(value)
+		case "entrypoint":
+			s.Entrypoint, err = toCommand(value)
+		case "environment":
+			s.Environment, err = toKeyValues(value)
+		case "labels":
+			s.Labels, err = toMap(value)
+		case "ports":
+			s.Ports, err = toStrings(value)
+		case "expose":
+			s.Expose, err = toStrings(value)
+		case "volumes":
+			s.Volumes, err = toStrings(value)
+		case "volumes_from":
+			s.VolumesFrom, err = toStrings(value)
+		case "links":
+			s.Links, err = toStrings(value)
+		case "depends_on":
+			s.DependsOn, err = toStrings(value)
+		case "network_mode", "net":
+			s.NetworkMode, err = toString(value)
+		case "restart":
+			s.Restart, err = toString(value)
+		case "user":
+			s.User, err = toString(value)
+		case "working_dir":
+			s.WorkingDir, err = toString(value)
+		case "hostname":
+			s.Hostname, err = toString(value)
+		case "mem_limit":
+			s.MemLimit, err = toString(value)
+		case "cpu_shares":
+			s.CPUShares, err = toString(value)
+		case "privileged":
+			s.Privileged, err = toBool(value)
+		case "read_only":
+			s.ReadOnly, err = toBool(value)
+		case "tty":
+			s.Tty, err = toBool(value)
+		case "stdin_open":
+			s.StdinOpen, err = toBool(value)
+		case "cap_add":
+			s.CapAdd, err = toStrings(value)
+		case "cap_drop":
+			s.CapDrop, err = toStrings(value)
+		case "dns":
+			s.DNS, err = toStrings(value)
+		case "extra_hosts":
+			s.ExtraHosts, err = toKeyValues(value)
+			for i, host := range s.ExtraHosts {
+				s.ExtraHosts[i] = strings.Replace(host, "=", ":", 1)
+			}
+		case "networks":
+			err = errNetworks
+		case "build":
+			err = fmt.Errorf("build isn't supported, build and tag the image with docker build first")
+		default:
+			err = fmt.Errorf("unsupported key %s", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.Image == "" {
+		return nil, fmt.Errorf("no image")
+	}
+	// the host directories are relative to the compose file
+	for i, spec := range s.Volumes {
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) == 2 && strings.HasPrefix(parts[0], ".") {
+			s.Volumes[i] = filepath.Join(dir, parts[0]) + ":" + parts[1]
+		}
+	}
+	return s, nil
+}
+
+func toString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("expected a string, got %v", value)
+}
+
+func toBool(value interface{}) (bool, error) {
+	s, err := toString(value)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(s) {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected a boolean, got %s", s)
+}
+
+// toStrings converts a sequence of strings, or a string.
+func toStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := toString(item)
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, s)
+		}
+		return strs, nil
+	}
+	return nil, fmt.Errorf("expected a list of strings, got %v", value)
+}
+
+// toKeyValues converts a mapping, or a sequence of KEY=value, to a sorted
+// list of KEY=value. The keys mapped to null are listed without a value.
+func toKeyValues(value interface{}) ([]string, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return toStrings(value)
+	}
+	kvs := make([]string, 0, len(m))
+	for k, v := range m {
+		if v == nil {
+			kvs = append(kvs, k)
+			continue
+		}
+		s, err := toString(v)
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, k+"="+s)
+	}
+	sort.Strings(kvs)
+	return kvs, nil
+}
+
+// toMap converts a mapping, or a sequence of KEY=value.
+func toMap(value interface{}) (map[string]string, error) {
+	kvs, err := toKeyValues(value)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 1 {
+			parts = append(parts, "")
+		}
+		m[parts[0]] = parts[1]
+	}
+	return m, nil
+}
+
+// toCommand converts a sequence of arguments, or a command line split like
+// by a shell.
+func toCommand(value interface{}) ([]string, error) {
+	if s, ok := value.(string); ok {
+		return splitWords(s)
+	}
+	return toStrings(value)
+}
+
+// splitWords splits a command line into words, like a shell without
+// expansions.
+func splitWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    []byte
+		inWord  bool
+		quote   byte
+		escaped bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			word = append(word, c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word = append(word, c)
+		case c == '"' || c == '\'':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, string(word))
+				word, inWord = nil, false
+			}
+		default:
+			word = append(word, c)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote in %s", s)
+	}
+	if inWord {
+		words = append(words, string(word))
+	}
+	return words, nil
+}
+
+// interpolate replaces the variables of the strings of v by their value.
+func interpolate(v interface{}, env func(string) string) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return interpolateString(v, env)
+	case []interface{}:
+		for i, item := range v {
+			var err error
+			if v[i], err = interpolate(item, env); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			var err error
+			if v[k], err = interpolate(item, env); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// interpolateString replaces $VAR, ${VAR}, and ${VAR:-default} or
+// ${VAR-default} if VAR is empty, by their value; $$ is a dollar.
+func interpolateString(s string, env func(string) string) (string, error) {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b = append(b, s[i])
+			continue
+		}
+		i++
+		switch {
+		case i == len(s):
+			return "", fmt.Errorf("Invalid interpolation in %s", s)
+		case s[i] == '$':
+			b = append(b, '$')
+		case s[i] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("Invalid interpolation in %s", s)
+			}
+			name := s[i+1 : i+end]
+			def := ""
+			if sep := strings.Index(name, "-"); sep >= 0 {
+				name, def = strings.TrimSuffix(name[:sep], ":"), name[sep+1:]
+			}
+			if !isVariableName(name) {
+				return "", fmt.Errorf("Invalid interpolation in %s", s)
+			}
+			value := env(name)
+			if value == "" {
+				value = def
+			}
+			b = append(b, value...)
+			i += end
+		default:
+			end := i
+			for end < len(s) && isVariableName(s[i:end+1]) {
+				end++
+			}
+			if end == i {
+				return "", fmt.Errorf("Invalid interpolation in %s", s)
+			}
+			b = append(b, env(s[i:end])...)
+			i = end - 1
+		}
+	}
+	return string(b), nil
+}
+
+func isVariableName(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for _, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testEnv(name string) string {
+	return map[string]string{"TAG": "9.4", "PORT": "8080"}[name]
+}
+
+func TestLoad(t *testing.T) {
+	data := `version: "2"
+services:
+  web:
+    image: nginx
+    command: nginx -g 'daemon off;'
+    ports:
+      - "${PORT}:80"
+    links:
+      - app:backend
+    environment:
+      MODE: prod
+      DEBUG:
+    volumes:
+      - ./html:/usr/share/nginx/html:ro
+  app:
+    image: app
+    depends_on: [db, cache]
+    read_only: true
+  db:
+    image: postgres:${TAG}
+    volumes:
+      - data:/var/lib/postgresql/data
+    restart: always
+  cache:
+    image: redis:${REDIS_TAG:-3}
+    volumes_from:
+      - container:shared
+volumes:
+  data:
+    driver: local
+    labels:
+      - backup=daily
+`
+	config, err := Load([]byte(data), "/srv/app", testEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := config.Services["web"]
+	if !reflect.DeepEqual(web.Command, []string{"nginx", "-g", "daemon off;"}) {
+		t.Fatalf("Expected the command to be split, got %q", web.Command)
+	}
+	if !reflect.DeepEqual(web.Ports, []string{"8080:80"}) {
+		t.Fatalf("Expected the port to be interpolated, got %v", web.Ports)
+	}
+	if !reflect.DeepEqual(web.Environment, []string{"DEBUG", "MODE=prod"}) {
+		t.Fatalf("Expected the environment as KEY=value, got %v", web.Environment)
+	}
+	if !reflect.DeepEqual(web.Volumes, []string{"/srv/app/html:/usr/share/nginx/html:ro"}) {
+		t.Fatalf("Expected the host directory to be resolved, got %v", web.Volumes)
+	}
+	if image := config.Services["db"].Image; image != "postgres:9.4" {
+		t.Fatalf("Expected postgres:9.4, got %s", image)
+	}
+	if image := config.Services["cache"].Image; image != "redis:3" {
+		t.Fatalf("Expected the default tag, got %s", image)
+	}
+	if !config.Services["app"].ReadOnly {
+		t.Fatal("Expected app to be read-only")
+	}
+	if v := config.Volumes["data"]; v.Driver != "local" || v.Labels["backup"] != "daily" {
+		t.Fatalf("Expected the volume data, got %+v", v)
+	}
+
+	services, err := config.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range services {
+		names = append(names, s.Name)
+	}
+	if order := strings.Join(names, ","); order != "cache,db,app,web" {
+		t.Fatalf("Expected the dependencies first, got %s", order)
+	}
+}
+
+func TestLoadVersion1(t *testing.T) {
+	config, err := Load([]byte("web:\n  image: nginx\n  links: [db]\ndb:\n  image: postgres\n  volumes: [pgdata:/data]\n"), "/", testEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Services) != 2 || config.Services["web"].Links[0] != "db" {
+		t.Fatalf("Expected the top level services, got %v", config.Services)
+	}
+}
+
+func TestLoadAnchors(t *testing.T) {
+	data := `version: "3.4"
+x-defaults: &defaults
+  image: app:${TAG}
+  environment: &env
+    MODE: prod
+services:
+  web:
+    <<: *defaults
+    command: >
+      app
+      --port 80
+  worker:
+    <<: *defaults
+    environment:
+      <<: *env
+      QUEUE: jobs
+`
+	config, err := Load([]byte(data), "/", testEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web, worker := config.Services["web"], config.Services["worker"]
+	if web.Image != "app:9.4" || worker.Image != "app:9.4" {
+		t.Fatalf("Expected the merged image to be interpolated, got %q and %q", web.Image, worker.Image)
+	}
+	if !reflect.DeepEqual(web.Command, []string{"app", "--port", "80"}) {
+		t.Fatalf("Expected the folded command to be split, got %q", web.Command)
+	}
+	if !reflect.DeepEqual(worker.Environment, []string{"MODE=prod", "QUEUE=jobs"}) {
+		t.Fatalf("Expected the merged environment, got %v", worker.Environment)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for data, expected := range map[string]string{
+		"version: \"2\"\nservices:\n  web:\n    build: .":                                      "build isn't supported",
+		"version: \"2\"\nservices:\n  web:\n    command: ls":                                   "Service web: no image",
+		"version: \"2\"\nservices:\n  web:\n    image: a\n    depends_on: [db]":                "undefined service db",
+		"version: \"2\"\nservices:\n  web:\n    image: a\n    foo: bar":                        "unsupported key foo",
+		"version: \"2\"\nservices:\n  web:\n    image: a\nnetworks:\n  front:":                 "Networks aren't supported",
+		"version: \"4\"\nservices:\n  web:\n    image: a":                                      "Unsupported compose file version",
+		"version: \"2\"\nservices:\n  web:\n    image: a\n    volumes: [x:/x]\nvolumes:\n  y:": "undefined volume x",
+		"version: \"2\"\nservices:\n  web:\n    image: ${":                                     "Invalid interpolation",
+	} {
+		if _, err := Load([]byte(data), "/", testEnv); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected the error %q loading %q, got %v", expected, data, err)
+		}
+	}
+
+	config, err := Load([]byte("a:\n  image: a\n  depends_on: [b]\nb:\n  image: b\n  links: [a]\nc:\n  image: c\n"), "/", testEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Order(); err == nil || !strings.Contains(err.Error(), "Circular dependency between the services a, b") {
+		t.Fatalf("Expected the circular dependency to be refused, got %v", err)
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	words, err := splitWords(`sh -c "echo \"hi\" there" 'a b'\ c`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"sh", "-c", `echo "hi" there`, "a b c"}; !reflect.DeepEqual(words, expected) {
+		t.Fatalf("Expected %q, got %q", expected, words)
+	}
+	if _, err := splitWords(`echo "hi`); err == nil {
+		t.Fatal("Expected an unterminated quote to be refused")
+	}
+}
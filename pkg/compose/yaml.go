@@ -0,0 +1,1068 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseYAML parses the YAML of a compose file, a single document with:
+//
+//   - block mappings and sequences, and flow mappings and sequences such as
+//     {a: b} or [a, "b"], on one or several lines
+//   - plain, single and double quoted scalars on one or several lines, and
+//     the literal | and folded > block scalars
+//   - anchors and aliases, and the << merge keys
+//   - explicit ? keys, comments, directives and document markers
+//
+// The mappings are returned as map[string]interface{}, the sequences as
+// []interface{}, the scalars as strings, and the null values as nil. The
+// tags are ignored, but for !!str keeping null scalars as strings and !!null.
+// The keys of the mappings must be scalars.
+func parseYAML(data []byte) (interface{}, error) {
+	src, err := yamlDocument(strings.Replace(string(data), "\r\n", "\n", -1))
+	if err != nil {
+		return nil, err
+	}
+	p := &yamlParser{src: src, anchors: make(map[string]interface{})}
+	if err := p.skipToContent(); err != nil {
+		return nil, err
+	}
+	if p.eof() {
+		return nil, nil
+	}
+	v, err := p.node(-1, yamlTop)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.skipToContent(); err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("bad indentation")
+	}
+	return v, nil
+}
+
+// yamlDocument returns the document of src, without its directives and its
+// markers. The lines are kept in place, for the errors to have their number.
+func yamlDocument(src string) (string, error) {
+	lines := strings.SplitAfter(src, "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		text := strings.TrimSpace(stripComment(lines[i]))
+		if strings.HasPrefix(lines[i], "%") {
+			lines[i] = "\n"
+			continue
+		}
+		if text != "" {
+			break
+		}
+	}
+	if i < len(lines) && isDocumentMarker(lines[i], "---") {
+		lines[i] = "   " + lines[i][3:]
+		i++
+	}
+	for j := i; j < len(lines); j++ {
+		if !isDocumentMarker(lines[j], "---") && !isDocumentMarker(lines[j], "...") {
+			continue
+		}
+		// the rest must be empty, but for the end of the document
+		for k := j; k < len(lines); k++ {
+			text := strings.TrimSpace(stripComment(lines[k]))
+			if k == j {
+				text = strings.TrimSpace(stripComment(lines[k][3:]))
+			}
+			if text != "" {
+				return "", fmt.Errorf("line %d: multiple documents aren't supported", j+1)
+			}
+		}
+		lines = lines[:j]
+		break
+	}
+	return strings.Join(lines, ""), nil
+}
+
+func isDocumentMarker(line, marker string) bool {
+	return strings.HasPrefix(line, marker) && (len(line) == 3 || isBlankOrBreak(line[3]))
+}
+
+// stripComment returns the line without its comment.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '#' && (i == 0 || isBlank(line[i-1])):
+			return line[:i]
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:-", line[i-1]) >= 0):
+			quote = c
+		}
+	}
+	return line
+}
+
+// yamlContext is where a node is parsed.
+type yamlContext int
+
+const (
+	yamlTop   yamlContext = iota // the node of the document
+	yamlValue                    // the value of a block mapping entry
+	yamlItem                     // an item of a block sequence, or an explicit key
+)
+
+type yamlParser struct {
+	src     string
+	pos     int
+	anchors map[string]interface{}
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isBlankOrBreak(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isFlowIndicator(c byte) bool {
+	return c == ',' || c == '[' || c == ']' || c == '{' || c == '}'
+}
+
+func (p *yamlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// at returns the byte at the offset i of the position, or 0 past the end.
+func (p *yamlParser) at(i int) byte {
+	if p.pos+i < len(p.src) {
+		return p.src[p.pos+i]
+	}
+	return 0
+}
+
+func (p *yamlParser) line() int {
+	return strings.Count(p.src[:p.pos], "\n") + 1
+}
+
+func (p *yamlParser) column() int {
+	return p.pos - strings.LastIndex(p.src[:p.pos], "\n") - 1
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line(), fmt.Sprintf(format, args...))
+}
+
+// followedByBlank returns whether the byte at the offset i of the position is
+// followed by a blank, a line break or the end.
+func (p *yamlParser) followedByBlank(i int) bool {
+	return p.pos+i+1 >= len(p.src) || isBlankOrBreak(p.src[p.pos+i+1])
+}
+
+func (p *yamlParser) isSequenceItem() bool {
+	return p.at(0) == '-' && p.followedByBlank(0)
+}
+
+func (p *yamlParser) skipBlanks() {
+	for !p.eof() && isBlank(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *yamlParser) skipComment() {
+	if p.at(0) == '#' && (p.pos == 0 || isBlankOrBreak(p.src[p.pos-1])) {
+		for !p.eof() && p.src[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// atLineEnd skips the blanks and returns whether the rest of the line is
+// empty or a comment.
+func (p *yamlParser) atLineEnd() bool {
+	p.skipBlanks()
+	p.skipComment()
+	return p.eof() || p.src[p.pos] == '\n'
+}
+
+// endLine checks the rest of the line is empty or a comment.
+func (p *yamlParser) endLine() error {
+	if !p.atLineEnd() {
+		rest := p.src[p.pos:]
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[:i]
+		}
+		if strings.HasPrefix(rest, ":") {
+			return p.errorf("mapping values aren't allowed here")
+		}
+		return p.errorf("unexpected %q", rest)
+	}
+	return nil
+}
+
+// skipToContent skips the blanks, the comments and the empty lines.
+func (p *yamlParser) skipToContent() error {
+	for !p.eof() {
+		switch p.src[p.pos] {
+		case ' ', '\n':
+			p.pos++
+		case '\t':
+			// the tabs may separate, but not indent
+			start := p.pos
+			if p.atLineEnd() {
+				continue
+			}
+			indent := p.src[strings.LastIndex(p.src[:start], "\n")+1 : p.pos]
+			if strings.TrimLeft(indent, " \t") == "" {
+				p.pos = start
+				return p.errorf("tabs aren't allowed in the indentation")
+			}
+		case '#':
+			p.skipComment()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// properties scans the anchor and the tag of a node.
+func (p *yamlParser) properties() (anchor, tag string, err error) {
+	for {
+		switch p.at(0) {
+		case '&':
+			if anchor != "" {
+				return "", "", p.errorf("a node can't have two anchors")
+			}
+			p.pos++
+			if anchor = p.name(); anchor == "" {
+				return "", "", p.errorf("the anchor has no name")
+			}
+		case '!':
+			if tag != "" {
+				return "", "", p.errorf("a node can't have two tags")
+			}
+			start := p.pos
+			if p.at(1) == '<' {
+				end := strings.IndexByte(p.src[p.pos:], '>')
+				if end < 0 {
+					return "", "", p.errorf("unterminated tag")
+				}
+				p.pos += end + 1
+			} else {
+				p.name()
+			}
+			tag = p.src[start:p.pos]
+		default:
+			return anchor, tag, nil
+		}
+		p.skipBlanks()
+	}
+}
+
+// name scans the name of an anchor or an alias, or of a tag.
+func (p *yamlParser) name() string {
+	start := p.pos
+	for !p.eof() && !isBlankOrBreak(p.src[p.pos]) && !isFlowIndicator(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// resolve returns the value of a scalar, given its tag, the plain scalars
+// being null if they're empty, ~ or null.
+func resolve(v interface{}, plain bool, tag string) interface{} {
+	switch {
+	case tag == "!!null":
+		return nil
+	case !plain || tag == "!!str":
+		return v
+	}
+	switch v {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	}
+	return v
+}
+
+// node parses the node after the indicator of its context, in the block
+// context of the collection indented by parentIndent.
+func (p *yamlParser) node(parentIndent int, ctx yamlContext) (interface{}, error) {
+	p.skipBlanks()
+	start := p.pos
+	anchor, tag, err := p.properties()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		v     interface{}
+		plain bool
+	)
+	switch {
+	case !p.atLineEnd():
+		if ctx != yamlValue && (anchor != "" || tag != "") && p.isImplicitKey() {
+			// the properties are the ones of the first key
+			p.pos = start
+			anchor, tag = "", ""
+		}
+		if v, plain, err = p.blockContent(parentIndent, true, ctx); err != nil {
+			return nil, err
+		}
+	default:
+		if err := p.skipToContent(); err != nil {
+			return nil, err
+		}
+		col := p.column()
+		if !p.eof() && (col > parentIndent || (ctx == yamlValue && col == parentIndent && p.isSequenceItem())) {
+			if v, plain, err = p.blockContent(parentIndent, false, ctx); err != nil {
+				return nil, err
+			}
+		} else {
+			// an empty node
+			v, plain = "", true
+		}
+	}
+
+	v = resolve(v, plain, tag)
+	if anchor != "" {
+		p.anchors[anchor] = v
+	}
+	return v, nil
+}
+
+// blockContent parses the node starting at the position, on the line of the
+// indicator of its context if inline. plain is true for the plain scalars,
+// which are returned unresolved.
+func (p *yamlParser) blockContent(parentIndent int, inline bool, ctx yamlContext) (v interface{}, plain bool, err error) {
+	col := p.column()
+	switch c := p.at(0); {
+	case p.isSequenceItem():
+		if inline && ctx == yamlValue {
+			return nil, false, p.errorf("a block sequence can't start on the line of its key")
+		}
+		v, err = p.blockSequence(col)
+		return v, false, err
+	case (c == '?' && p.followedByBlank(0)) || p.isImplicitKey():
+		if inline && ctx == yamlValue {
+			return nil, false, p.errorf("mapping values aren't allowed here")
+		}
+		v, err = p.blockMapping(col)
+		return v, false, err
+	case c == '|' || c == '>':
+		v, err = p.blockScalar(parentIndent)
+		return v, false, err
+	case c == '*':
+		v, err = p.alias()
+	case c == '[' || c == '{':
+		v, err = p.flowCollection()
+	case c == '"' || c == '\'':
+		v, err = p.quoted()
+	default:
+		if v, err = p.plain(parentIndent, false); err != nil {
+			return nil, false, err
+		}
+		plain = true
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return v, plain, p.endLine()
+}
+
+// isImplicitKey returns whether the line is a mapping entry from the
+// position, a scalar key followed by a colon.
+func (p *yamlParser) isImplicitKey() bool {
+	start := p.pos
+	defer func() { p.pos = start }()
+	if _, _, err := p.properties(); err != nil {
+		return false
+	}
+	switch c := p.at(0); {
+	case c == '"' || c == '\'':
+		if _, err := p.quoted(); err != nil || strings.Count(p.src[start:p.pos], "\n") > 0 {
+			return false
+		}
+		p.skipBlanks()
+		return p.at(0) == ':' && p.followedByBlank(0)
+	case c == 0 || isBlankOrBreak(c) || isFlowIndicator(c) || strings.IndexByte("#&*!|>%@`", c) >= 0:
+		return false
+	case (c == '-' || c == '?' || c == ':') && p.followedByBlank(0):
+		return false
+	}
+	for !p.eof() && p.src[p.pos] != '\n' {
+		switch {
+		case p.src[p.pos] == ':' && p.followedByBlank(0):
+			return true
+		case p.src[p.pos] == '#' && isBlank(p.src[p.pos-1]):
+			return false
+		}
+		p.pos++
+	}
+	return false
+}
+
+// blockSequence parses the block sequence whose dashes are at column indent.
+func (p *yamlParser) blockSequence(indent int) (interface{}, error) {
+	seq := []interface{}{}
+	for {
+		p.pos++
+		v, err := p.node(indent, yamlItem)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+
+		if err := p.skipToContent(); err != nil {
+			return nil, err
+		}
+		if p.eof() {
+			return seq, nil
+		}
+		switch col := p.column(); {
+		case col < indent || (col == indent && !p.isSequenceItem()):
+			return seq, nil
+		case col > indent:
+			return nil, p.errorf("bad indentation")
+		}
+	}
+}
+
+// blockMapping parses the block mapping whose keys are at column indent.
+func (p *yamlParser) blockMapping(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	var merges []interface{}
+	for {
+		line := p.line()
+		var (
+			key   string
+			plain bool
+			err   error
+			v     interface{}
+		)
+		if p.at(0) == '?' && p.followedByBlank(0) {
+			// an explicit key, with its value on the next line
+			p.pos++
+			k, err := p.node(indent, yamlItem)
+			if err != nil {
+				return nil, err
+			}
+			if key, err = p.keyString(k); err != nil {
+				return nil, err
+			}
+			if err := p.skipToContent(); err != nil {
+				return nil, err
+			}
+			if !p.eof() && p.column() == indent && p.at(0) == ':' && p.followedByBlank(0) {
+				p.pos++
+				if v, err = p.node(indent, yamlValue); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			if key, plain, err = p.implicitKey(); err != nil {
+				return nil, err
+			}
+			if v, err = p.node(indent, yamlValue); err != nil {
+				return nil, err
+			}
+		}
+
+		if key == "<<" && plain {
+			merges = append(merges, v)
+		} else {
+			if _, exists := m[key]; exists {
+				return nil, fmt.Errorf("line %d: duplicate key %s", line, key)
+			}
+			m[key] = v
+		}
+
+		if err := p.skipToContent(); err != nil {
+			return nil, err
+		}
+		if p.eof() {
+			break
+		}
+		col := p.column()
+		if col < indent {
+			break
+		}
+		if col > indent {
+			return nil, p.errorf("bad indentation")
+		}
+		if !p.isImplicitKey() && !(p.at(0) == '?' && p.followedByBlank(0)) {
+			if p.isSequenceItem() {
+				return nil, p.errorf("bad indentation of a sequence item")
+			}
+			return nil, p.errorf("expected a key followed by a colon")
+		}
+	}
+	if err := p.merge(m, merges); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// implicitKey parses the key of a mapping entry and its colon. plain is true
+// for the plain keys.
+func (p *yamlParser) implicitKey() (key string, plain bool, err error) {
+	anchor, _, err := p.properties()
+	if err != nil {
+		return "", false, err
+	}
+	if c := p.at(0); c == '"' || c == '\'' {
+		if key, err = p.quoted(); err != nil {
+			return "", false, err
+		}
+	} else {
+		start := p.pos
+		for !(p.src[p.pos] == ':' && p.followedByBlank(0)) {
+			p.pos++
+		}
+		key, plain = strings.TrimRight(p.src[start:p.pos], " \t"), true
+	}
+	p.skipBlanks()
+	if p.at(0) != ':' {
+		return "", false, p.errorf("expected a colon after the key %s", key)
+	}
+	p.pos++
+	if anchor != "" {
+		p.anchors[anchor] = key
+	}
+	return key, plain, nil
+}
+
+func (p *yamlParser) keyString(k interface{}) (string, error) {
+	switch k := k.(type) {
+	case string:
+		return k, nil
+	case nil:
+		return "", p.errorf("null keys aren't supported")
+	}
+	return "", p.errorf("the keys of the mappings must be scalars")
+}
+
+// merge merges the mappings of the << merge keys of m, its own keys and the
+// first merged mappings taking precedence.
+func (p *yamlParser) merge(m map[string]interface{}, merges []interface{}) error {
+	var mappings []map[string]interface{}
+	for _, v := range merges {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			mappings = append(mappings, v)
+		case []interface{}:
+			for _, item := range v {
+				mapping, ok := item.(map[string]interface{})
+				if !ok {
+					return p.errorf("the << merge key takes a mapping or a sequence of mappings")
+				}
+				mappings = append(mappings, mapping)
+			}
+		default:
+			return p.errorf("the << merge key takes a mapping or a sequence of mappings")
+		}
+	}
+	for _, mapping := range mappings {
+		for k, v := range mapping {
+			if _, exists := m[k]; !exists {
+				m[k] = copyYAML(v)
+			}
+		}
+	}
+	return nil
+}
+
+// copyYAML returns a deep copy of v, as the values are modified in place once
+// parsed.
+func copyYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = copyYAML(item)
+		}
+		return m
+	case []interface{}:
+		seq := make([]interface{}, len(v))
+		for i, item := range v {
+			seq[i] = copyYAML(item)
+		}
+		return seq
+	}
+	return v
+}
+
+func (p *yamlParser) alias() (interface{}, error) {
+	p.pos++
+	name := p.name()
+	v, ok := p.anchors[name]
+	if !ok {
+		return nil, p.errorf("unknown anchor %s", name)
+	}
+	return copyYAML(v), nil
+}
+
+// plain scans a plain scalar, continued on the next lines indented below
+// parentIndent in the block context. The line breaks are folded.
+func (p *yamlParser) plain(parentIndent int, flow bool) (string, error) {
+	var b []byte
+	for {
+		start := p.pos
+		for !p.eof() {
+			c := p.src[p.pos]
+			if c == '\n' || (c == '#' && isBlank(p.src[p.pos-1])) ||
+				(c == ':' && (p.followedByBlank(0) || (flow && isFlowIndicator(p.at(1))))) ||
+				(flow && isFlowIndicator(c)) {
+				break
+			}
+			p.pos++
+		}
+		b = append(b, strings.TrimRight(p.src[start:p.pos], " \t")...)
+		if p.at(0) != '\n' {
+			return string(b), nil
+		}
+
+		// the scalar goes on if the next line is indented below the parent,
+		// and isn't a comment or the end of a flow collection
+		end := p.pos
+		breaks := 0
+		for !p.eof() && p.src[p.pos] == '\n' {
+			p.pos++
+			breaks++
+			p.skipBlanks()
+		}
+		c := p.at(0)
+		if p.eof() || c == '#' || (!flow && p.column() <= parentIndent) || (flow && (isFlowIndicator(c) || c == ':')) {
+			p.pos = end
+			return string(b), nil
+		}
+		if !flow && p.isImplicitKey() {
+			return "", p.errorf("bad indentation of a mapping entry")
+		}
+		if breaks == 1 {
+			b = append(b, ' ')
+		} else {
+			b = append(b, strings.Repeat("\n", breaks-1)...)
+		}
+	}
+}
+
+// quoted scans a single or double quoted scalar, on one or several lines.
+// The line breaks are folded.
+func (p *yamlParser) quoted() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var b []byte
+	kept := 0 // the length of b the trailing blanks are trimmed down to
+	for !p.eof() {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == quote && quote == '\'' && p.at(0) == '\'':
+			b = append(b, '\'')
+			p.pos++
+		case c == quote:
+			return string(b), nil
+		case c == '\n':
+			for len(b) > kept && isBlank(b[len(b)-1]) {
+				b = b[:len(b)-1]
+			}
+			breaks := 0
+			for {
+				p.skipBlanks()
+				if p.at(0) != '\n' {
+					break
+				}
+				p.pos++
+				breaks++
+			}
+			if breaks == 0 {
+				b = append(b, ' ')
+			} else {
+				b = append(b, strings.Repeat("\n", breaks)...)
+			}
+		case c == '\\' && quote == '"':
+			if p.at(0) == '\n' {
+				// an escaped line break is removed
+				p.pos++
+				p.skipBlanks()
+				break
+			}
+			var err error
+			if b, err = p.escape(b); err != nil {
+				return "", err
+			}
+		default:
+			b = append(b, c)
+			if !isBlank(c) {
+				kept = len(b)
+			}
+			continue
+		}
+		kept = len(b)
+	}
+	return "", p.errorf("unterminated quoted scalar")
+}
+
+// escape appends the character of the escape sequence of a double quoted
+// scalar to b.
+func (p *yamlParser) escape(b []byte) ([]byte, error) {
+	if p.eof() {
+		return nil, p.errorf("unterminated quoted scalar")
+	}
+	e := p.at(0)
+	p.pos++
+	switch e {
+	case '0':
+		return append(b, 0), nil
+	case 'a':
+		return append(b, '\a'), nil
+	case 'b':
+		return append(b, '\b'), nil
+	case 't', '\t':
+		return append(b, '\t'), nil
+	case 'n':
+		return append(b, '\n'), nil
+	case 'v':
+		return append(b, '\v'), nil
+	case 'f':
+		return append(b, '\f'), nil
+	case 'r':
+		return append(b, '\r'), nil
+	case 'e':
+		return append(b, 0x1b), nil
+	case ' ', '"', '/', '\\':
+		return append(b, e), nil
+	case 'N':
+		return appendRune(b, 0x85), nil
+	case '_':
+		return appendRune(b, 0xa0), nil
+	case 'L':
+		return appendRune(b, 0x2028), nil
+	case 'P':
+		return appendRune(b, 0x2029), nil
+	case 'x', 'u', 'U':
+		n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+		if p.pos+n > len(p.src) {
+			return nil, p.errorf("invalid escape \\%c", e)
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil {
+			return nil, p.errorf("invalid escape \\%c%s", e, p.src[p.pos:p.pos+n])
+		}
+		p.pos += n
+		return appendRune(b, rune(r)), nil
+	}
+	return nil, p.errorf("unsupported escape \\%c", e)
+}
+
+func appendRune(b []byte, r rune) []byte {
+	buf := make([]byte, utf8.UTFMax)
+	return append(b, buf[:utf8.EncodeRune(buf, r)]...)
+}
+
+// blockScalar scans a literal or folded block scalar, in the collection
+// indented by parentIndent.
+func (p *yamlParser) blockScalar(parentIndent int) (string, error) {
+	folded := p.src[p.pos] == '>'
+	p.pos++
+	var chomping byte
+	indent := 0
+	for i := 0; i < 2; i++ {
+		switch c := p.at(0); {
+		case (c == '-' || c == '+') && chomping == 0:
+			chomping = c
+			p.pos++
+		case c >= '1' && c <= '9' && indent == 0:
+			indent = int(c - '0')
+			if parentIndent > 0 {
+				indent += parentIndent
+			}
+			p.pos++
+		}
+	}
+	if !p.atLineEnd() {
+		return "", p.errorf("invalid block scalar header")
+	}
+	if !p.eof() {
+		p.pos++
+	}
+
+	minIndent := parentIndent + 1
+	if minIndent < 1 {
+		minIndent = 1
+	}
+	if indent == 0 {
+		// the indentation is the one of the first line which isn't empty
+		for i := p.pos; i < len(p.src); {
+			n := 0
+			for i+n < len(p.src) && p.src[i+n] == ' ' {
+				n++
+			}
+			if i+n < len(p.src) && p.src[i+n] == '\n' {
+				i += n + 1
+				continue
+			}
+			indent = n
+			break
+		}
+		if indent < minIndent {
+			indent = minIndent
+		}
+	}
+
+	var lines []string
+	terminated := false // the last line ends with a line break
+	for !p.eof() {
+		n := 0
+		for n < indent && p.at(n) == ' ' {
+			n++
+		}
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		line := p.src[p.pos : p.pos+end]
+		if n < indent && strings.TrimLeft(line, " ") != "" {
+			break
+		}
+		if n < len(line) {
+			lines = append(lines, line[n:])
+		} else {
+			lines = append(lines, "")
+		}
+		p.pos += end
+		terminated = !p.eof()
+		if terminated {
+			p.pos++
+		}
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	var s string
+	if folded {
+		s = foldLines(lines)
+	} else {
+		s = strings.Join(lines, "\n")
+	}
+	switch {
+	case chomping == '-':
+	case chomping == '+':
+		if len(lines) > 0 {
+			trailing++
+		}
+		s += strings.Repeat("\n", trailing)
+	case len(lines) > 0:
+		s += "\n"
+	}
+	return s, nil
+}
+
+// foldLines folds the lines of a folded block scalar: the line breaks between
+// two lines of text are spaces, but around the more indented lines.
+func foldLines(lines []string) string {
+	var b []byte
+	breaks := 0
+	text, moreIndented := false, false
+	for _, line := range lines {
+		if line == "" {
+			breaks++
+			continue
+		}
+		more := isBlank(line[0])
+		switch {
+		case !text:
+			b = append(b, strings.Repeat("\n", breaks)...)
+		case more || moreIndented:
+			b = append(b, strings.Repeat("\n", breaks+1)...)
+		case breaks == 0:
+			b = append(b, ' ')
+		default:
+			b = append(b, strings.Repeat("\n", breaks)...)
+		}
+		b = append(b, line...)
+		breaks = 0
+		text, moreIndented = true, more
+	}
+	return string(b)
+}
+
+// flowCollection parses a flow sequence or mapping, on one or several lines.
+func (p *yamlParser) flowCollection() (interface{}, error) {
+	if p.src[p.pos] == '[' {
+		return p.flowSequence()
+	}
+	return p.flowMapping()
+}
+
+// skipFlowSpace skips the blanks, the line breaks and the comments in a flow
+// collection.
+func (p *yamlParser) skipFlowSpace() {
+	for !p.eof() {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n':
+			p.pos++
+		case '#':
+			if !isBlankOrBreak(p.src[p.pos-1]) {
+				return
+			}
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// flowNode parses a node in a flow collection.
+func (p *yamlParser) flowNode() (interface{}, error) {
+	anchor, tag, err := p.properties()
+	if err != nil {
+		return nil, err
+	}
+	p.skipFlowSpace()
+	var (
+		v     interface{}
+		plain bool
+	)
+	switch c := p.at(0); {
+	case c == '[' || c == '{':
+		v, err = p.flowCollection()
+	case c == '*':
+		v, err = p.alias()
+	case c == '"' || c == '\'':
+		v, err = p.quoted()
+	case c == ',' || c == ']' || c == '}' || c == ':':
+		v, plain = "", true
+	default:
+		v, err = p.plain(-1, true)
+		plain = true
+	}
+	if err != nil {
+		return nil, err
+	}
+	v = resolve(v, plain, tag)
+	if anchor != "" {
+		p.anchors[anchor] = v
+	}
+	return v, nil
+}
+
+func (p *yamlParser) flowSequence() (interface{}, error) {
+	p.pos++
+	seq := []interface{}{}
+	for {
+		p.skipFlowSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated flow sequence")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			return seq, nil
+		}
+		v, err := p.flowNode()
+		if err != nil {
+			return nil, err
+		}
+		p.skipFlowSpace()
+		if p.at(0) == ':' {
+			// a single pair mapping
+			key, err := p.keyString(v)
+			if err != nil {
+				return nil, err
+			}
+			p.pos++
+			p.skipFlowSpace()
+			var value interface{}
+			if c := p.at(0); c != ',' && c != ']' {
+				if value, err = p.flowNode(); err != nil {
+					return nil, err
+				}
+			}
+			v = map[string]interface{}{key: value}
+			p.skipFlowSpace()
+		}
+		seq = append(seq, v)
+		switch p.at(0) {
+		case ',':
+			p.pos++
+		case ']':
+		case 0:
+			return nil, p.errorf("unterminated flow sequence")
+		default:
+			return nil, p.errorf("expected a comma or ] in the flow sequence")
+		}
+	}
+}
+
+func (p *yamlParser) flowMapping() (interface{}, error) {
+	p.pos++
+	m := make(map[string]interface{})
+	var merges []interface{}
+	for {
+		p.skipFlowSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated flow mapping")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			if err := p.merge(m, merges); err != nil {
+				return nil, err
+			}
+			return m, nil
+		}
+		if p.at(0) == '?' && p.followedByBlank(0) {
+			p.pos++
+			p.skipFlowSpace()
+		}
+		line := p.line()
+		plainKey := p.at(0) != '"' && p.at(0) != '\''
+		k, err := p.flowNode()
+		if err != nil {
+			return nil, err
+		}
+		key, err := p.keyString(k)
+		if err != nil {
+			return nil, err
+		}
+		p.skipFlowSpace()
+		var v interface{}
+		if p.at(0) == ':' {
+			p.pos++
+			p.skipFlowSpace()
+			if c := p.at(0); c != ',' && c != '}' {
+				if v, err = p.flowNode(); err != nil {
+					return nil, err
+				}
+			}
+			p.skipFlowSpace()
+		}
+		if key == "<<" && plainKey {
+			merges = append(merges, v)
+		} else {
+			if _, exists := m[key]; exists {
+				return nil, fmt.Errorf("line %d: duplicate key %s", line, key)
+			}
+			m[key] = v
+		}
+		switch p.at(0) {
+		case ',':
+			p.pos++
+		case '}':
+		case 0:
+			return nil, p.errorf("unterminated flow mapping")
+		default:
+			return nil, p.errorf("expected a comma or } in the flow mapping")
+		}
+	}
+}
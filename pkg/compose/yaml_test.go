@@ -0,0 +1,151 @@
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	data := `---
+# a comment
+version: "2"
+services:
+  web:
+    image: nginx:1.9 # the server
+    ports:
+      - "8080:80"
+      - 443:443
+    environment:
+      - MODE=prod
+    command: [nginx, "-g", 'daemon off;']
+    labels:
+      tier: front
+      "owner": ops
+    empty:
+  db:
+    image: postgres
+    volumes:
+    - data:/var/lib/postgresql/data
+    healthcheck:
+      - test: pg_isready
+        retries: 3
+    script: "line one\n  indented\n"
+volumes:
+  data: ~
+`
+	v, err := parseYAML([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"version": "2",
+		"services": map[string]interface{}{
+			"web": map[string]interface{}{
+				"image":       "nginx:1.9",
+				"ports":       []interface{}{"8080:80", "443:443"},
+				"environment": []interface{}{"MODE=prod"},
+				"command":     []interface{}{"nginx", "-g", "daemon off;"},
+				"labels":      map[string]interface{}{"tier": "front", "owner": "ops"},
+				"empty":       nil,
+			},
+			"db": map[string]interface{}{
+				"image":   "postgres",
+				"volumes": []interface{}{"data:/var/lib/postgresql/data"},
+				"healthcheck": []interface{}{
+					map[string]interface{}{"test": "pg_isready", "retries": "3"},
+				},
+				"script": "line one\n  indented\n",
+			},
+		},
+		"volumes": map[string]interface{}{"data": nil},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, v)
+	}
+}
+
+func TestParseYAMLConstructs(t *testing.T) {
+	for _, test := range []struct {
+		data     string
+		expected interface{}
+	}{
+		{"", nil},
+		{"# only a comment\n", nil},
+		{"just a scalar line", "just a scalar line"},
+		{"a: b\n  c\n\n  d", map[string]interface{}{"a": "b c\nd"}},
+		{"- b\n  c # comment", []interface{}{"b c"}},
+		{"a: 'b\n  ''c'''", map[string]interface{}{"a": "b 'c'"}},
+		{"a: \"b \\\n  c\\td\\u00e9\\x41\"", map[string]interface{}{"a": "b c\td\u00e9A"}},
+		{"a: |\n  b\n    c\n\nd: e", map[string]interface{}{"a": "b\n  c\n", "d": "e"}},
+		{"a: |-\n  b\n\n", map[string]interface{}{"a": "b"}},
+		{"a: |+\n  b\n\n", map[string]interface{}{"a": "b\n\n"}},
+		{"a: |2\n   b\n", map[string]interface{}{"a": " b\n"}},
+		{"a:\n  - >\n    b\n    c\n\n    d\n      e\n", map[string]interface{}{"a": []interface{}{"b c\nd\n  e\n"}}},
+		{"a: {b: c, 'd': [e, {f: g}], h}", map[string]interface{}{"a": map[string]interface{}{
+			"b": "c", "d": []interface{}{"e", map[string]interface{}{"f": "g"}}, "h": nil,
+		}}},
+		{"a: [\n  b,   # comment\n  \"c\":d,\n]", map[string]interface{}{"a": []interface{}{"b", map[string]interface{}{"c": "d"}}}},
+		{"- - a\n  - b\n- c: d\n  e: f", []interface{}{[]interface{}{"a", "b"}, map[string]interface{}{"c": "d", "e": "f"}}},
+		{"a: &x\n  b: c\nd: *x", map[string]interface{}{"a": map[string]interface{}{"b": "c"}, "d": map[string]interface{}{"b": "c"}}},
+		{"a: &n ~\nb:\n  *n", map[string]interface{}{"a": nil, "b": nil}},
+		{"&k a: &v b\nc: *k\nd: *v", map[string]interface{}{"a": "b", "c": "a", "d": "b"}},
+		{"x: &x {a: 1, b: 2}\ny: &y {b: 3, c: 4}\nz:\n  <<: [*x, *y]\n  a: 5", map[string]interface{}{
+			"x": map[string]interface{}{"a": "1", "b": "2"},
+			"y": map[string]interface{}{"b": "3", "c": "4"},
+			"z": map[string]interface{}{"a": "5", "b": "2", "c": "4"},
+		}},
+		{"a: !!str null\nb: !!null c\nc: !custom d", map[string]interface{}{"a": "null", "b": nil, "c": "d"}},
+		{"? a\n: b\n? c", map[string]interface{}{"a": "b", "c": nil}},
+		{"%YAML 1.2\n---\na: b\n...\n", map[string]interface{}{"a": "b"}},
+		{"a: b\n---\n", map[string]interface{}{"a": "b"}},
+	} {
+		v, err := parseYAML([]byte(test.data))
+		if err != nil {
+			t.Fatalf("Expected %q to be parsed, got %v", test.data, err)
+		}
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Fatalf("Expected %#v parsing %q, got %#v", test.expected, test.data, v)
+		}
+	}
+}
+
+func TestParseYAMLAliasesAreCopies(t *testing.T) {
+	v, err := parseYAML([]byte("a: &x [b]\nc: *x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := v.(map[string]interface{})
+	m["c"].([]interface{})[0] = "d"
+	if expected := []interface{}{"b"}; !reflect.DeepEqual(m["a"], expected) {
+		t.Fatalf("Expected the anchored node to stay %v, got %v", expected, m["a"])
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for data, expected := range map[string]string{
+		"a: 1\n  b: 2":      "line 2: bad indentation",
+		"a: 1\na: 2":        "line 2: duplicate key a",
+		"a: {b: 1, b: 2}":   "line 1: duplicate key b",
+		"a:\n\t- b":         "line 2: tabs",
+		"a: [b, c":          "line 1: unterminated flow sequence",
+		"a: {b: c":          "line 1: unterminated flow mapping",
+		"a: [b, \"c\" d]":   "line 1: expected a comma",
+		"a: \"b":            "line 1: unterminated quoted scalar",
+		"a: \"\\q\"":        "line 1: unsupported escape",
+		"a: *anchor":        "line 1: unknown anchor anchor",
+		"a:\n  <<: b":       "line 2: the << merge key",
+		"a: {[b]: c}":       "line 1: the keys of the mappings must be scalars",
+		"a: b: c":           "line 1: mapping values aren't allowed here",
+		"a: - b":            "line 1: a block sequence can't start",
+		"a: |x\n  b":        "line 1: invalid block scalar header",
+		"a: b\n---\nc: d":   "line 2: multiple documents",
+		"a:\n  - b\n  c: d": "line 3: bad indentation",
+		"- a\nb: c":         "line 2: bad indentation",
+		"a: b\n- c":         "line 2: bad indentation of a sequence item",
+	} {
+		if _, err := parseYAML([]byte(data)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected the error %q parsing %q, got %v", expected, data, err)
+		}
+	}
+}
@@ -44,11 +44,12 @@ func CleanPatterns(patterns []string) ([]string, [][]string, bool, error) {
 				return nil, nil, false, errors.New("Illegal exclusion pattern: !")
 			}
 			exceptions = true
-		}
-		pattern = filepath.Clean(pattern)
-		cleanedPatterns = append(cleanedPatterns, pattern)
-		if Exclusion(pattern) {
-			pattern = pattern[1:]
+			// clean what follows the !, as "!./foo" has to match like "!foo"
+			pattern = filepath.Clean(pattern[1:])
+			cleanedPatterns = append(cleanedPatterns, "!"+pattern)
+		} else {
+			pattern = filepath.Clean(pattern)
+			cleanedPatterns = append(cleanedPatterns, pattern)
 		}
 		patternDirs = append(patternDirs, strings.Split(pattern, "/"))
 	}
@@ -57,7 +58,10 @@ func CleanPatterns(patterns []string) ([]string, [][]string, bool, error) {
 }
 
 // Matches returns true if file matches any of the patterns
-// and isn't excluded by any of the subsequent patterns.
+// and isn't excluded by any of the subsequent patterns. The patterns
+// are evaluated in order and the last one matching file wins, so an
+// exception (prefixed with !) only re-includes files excluded by the
+// patterns before it.
 func Matches(file string, patterns []string) (bool, error) {
 	file = filepath.Clean(file)
 
@@ -355,3 +355,27 @@ func TestCleanPatternsFolderSplit(t *testing.T) {
 		t.Errorf("expected first element in dirs slice to be config, got %v", dirs[0][1])
 	}
 }
+
+// An exception is only relative to the patterns before it.
+func TestExclusionPatternOrdering(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		match    bool
+	}{
+		{[]string{"docs", "!docs/README.md", "docs/README.md"}, true},
+		{[]string{"docs", "docs/README.md", "!docs/README.md"}, false},
+		{[]string{"docs/*", "!docs/*.md", "docs/README*"}, true},
+		{[]string{"*", "!docs"}, false},
+		{[]string{"!docs", "*"}, true},
+		{[]string{"docs", "!./docs//README.md"}, false},
+	}
+	for _, c := range cases {
+		match, err := Matches("docs/README.md", c.patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != c.match {
+			t.Errorf("expected match %v for %v, got %v", c.match, c.patterns, match)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package fscrypt encrypts the files of directories with the native
+// encryption of the filesystems of Linux, such as ext4 and f2fs. The files
+// of an encrypted directory are encrypted with a key of the kernel keyrings,
+// and can't be read once the key is removed.
+package fscrypt
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+)
+
+// KeySize is the size of the keys encrypting the directories.
+const KeySize = 64
+
+// ErrNotSupported is returned when the filesystem of a directory can't
+// encrypt it.
+var ErrNotSupported = errors.New("the filesystem doesn't support encryption")
+
+// NewKey returns a new random key.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Descriptor returns the descriptor of key, which identifies the key in the
+// encryption policies of the directories without revealing it.
+func Descriptor(key []byte) string {
+	h := sha512.Sum512(key)
+	h = sha512.Sum512(h[:])
+	return hex.EncodeToString(h[:descriptorSize])
+}
+
+const descriptorSize = 8
@@ -0,0 +1,127 @@
+package fscrypt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// the special keyring of the session of the process, inherited by its
+	// children such as the processes of the containers
+	keySpecSessionKeyring = -3
+
+	keyctlSearch     = 10
+	keyctlInvalidate = 21
+
+	fsIocSetEncryptionPolicy = 0x800c6613
+
+	modeAES256XTS = 1
+	modeAES256CTS = 4
+	policyPad32   = 0x03
+)
+
+// policy is struct fscrypt_policy of linux/fs.h.
+type policy struct {
+	version       uint8
+	contentsMode  uint8
+	filenamesMode uint8
+	flags         uint8
+	keyDescriptor [descriptorSize]byte
+}
+
+// payload is struct fscrypt_key of linux/fs.h, the payload of the keys.
+type payload struct {
+	mode uint32
+	raw  [KeySize]byte
+	size uint32
+}
+
+func keyDescription(descriptor string) string {
+	return "fscrypt:" + descriptor
+}
+
+// AddKey adds key to the session keyring of the process, for the directories
+// encrypted with it to be read and written by the process and its children.
+// It returns the descriptor of the key.
+func AddKey(key []byte) (string, error) {
+	if len(key) != KeySize {
+		return "", fmt.Errorf("invalid key size %d, expected %d", len(key), KeySize)
+	}
+	descriptor := Descriptor(key)
+	p := payload{size: KeySize}
+	copy(p.raw[:], key)
+
+	keyType, err := syscall.BytePtrFromString("logon")
+	if err != nil {
+		return "", err
+	}
+	desc, err := syscall.BytePtrFromString(keyDescription(descriptor))
+	if err != nil {
+		return "", err
+	}
+	ringID := keySpecSessionKeyring
+	if _, _, errno := syscall.Syscall6(syscall.SYS_ADD_KEY, uintptr(unsafe.Pointer(keyType)), uintptr(unsafe.Pointer(desc)),
+		uintptr(unsafe.Pointer(&p)), unsafe.Sizeof(p), uintptr(ringID), 0); errno != 0 {
+		return "", fmt.Errorf("error adding the encryption key %s: %v", descriptor, errno)
+	}
+	return descriptor, nil
+}
+
+// RemoveKey removes the key of descriptor from the keyrings, so that the
+// files encrypted with it can't be read anymore. It's not an error if the
+// key isn't in the session keyring of the process.
+func RemoveKey(descriptor string) error {
+	keyType, err := syscall.BytePtrFromString("logon")
+	if err != nil {
+		return err
+	}
+	desc, err := syscall.BytePtrFromString(keyDescription(descriptor))
+	if err != nil {
+		return err
+	}
+	ringID := keySpecSessionKeyring
+	id, _, errno := syscall.Syscall6(syscall.SYS_KEYCTL, keyctlSearch, uintptr(ringID),
+		uintptr(unsafe.Pointer(keyType)), uintptr(unsafe.Pointer(desc)), 0, 0)
+	if errno != 0 {
+		if errno == syscall.ENOKEY {
+			return nil
+		}
+		return fmt.Errorf("error searching the encryption key %s: %v", descriptor, errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_KEYCTL, keyctlInvalidate, id, 0); errno != 0 {
+		return fmt.Errorf("error removing the encryption key %s: %v", descriptor, errno)
+	}
+	return nil
+}
+
+// SetPolicy encrypts the files of the empty directory dir, and of its
+// subdirectories, with the key of descriptor. The key doesn't need to be in
+// the keyrings to set the policy, only to write the files.
+func SetPolicy(dir, descriptor string) error {
+	b, err := hex.DecodeString(descriptor)
+	if err != nil || len(b) != descriptorSize {
+		return fmt.Errorf("invalid encryption key descriptor %q", descriptor)
+	}
+	p := policy{
+		contentsMode:  modeAES256XTS,
+		filenamesMode: modeAES256CTS,
+		flags:         policyPad32,
+	}
+	copy(p.keyDescriptor[:], b)
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), fsIocSetEncryptionPolicy, uintptr(unsafe.Pointer(&p))); errno != 0 {
+		if errno == syscall.EOPNOTSUPP || errno == syscall.ENOTTY {
+			return ErrNotSupported
+		}
+		return fmt.Errorf("error encrypting %s: %v", dir, errno)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package fscrypt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDescriptor(t *testing.T) {
+	if d := Descriptor(make([]byte, KeySize)); d != "f574a77464017311" {
+		t.Fatalf("Expected the descriptor f574a77464017311, got %s", d)
+	}
+
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != KeySize || bytes.Equal(key, other) {
+		t.Fatalf("Expected random keys of %d bytes, got %x and %x", KeySize, key, other)
+	}
+	if Descriptor(key) == Descriptor(other) {
+		t.Fatalf("Expected different descriptors for different keys, got %s", Descriptor(key))
+	}
+}
+
+func TestSetPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fscrypt-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPolicy(dir, Descriptor(key)); err != nil {
+		if err == ErrNotSupported {
+			t.Skip("the filesystem of the temporary directory doesn't support encryption")
+		}
+		t.Fatal(err)
+	}
+	descriptor, err := AddKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveKey(descriptor)
+
+	file := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(file, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(file); err != nil || string(data) != "secret" {
+		t.Fatalf("Expected the content of the encrypted file, got %v: %q", err, data)
+	}
+
+	if err := SetPolicy(dir, "0011"); err == nil {
+		t.Fatal("Expected an error setting a policy with an invalid descriptor")
+	}
+}
+
+func TestAddKey(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddKey(key[:32]); err == nil {
+		t.Fatal("Expected an error adding a key of an invalid size")
+	}
+	descriptor, err := AddKey(key)
+	if err != nil {
+		if err == ErrNotSupported {
+			t.Skip("the keyrings aren't supported")
+		}
+		t.Fatal(err)
+	}
+	if descriptor != Descriptor(key) {
+		t.Fatalf("Expected the descriptor %s, got %s", Descriptor(key), descriptor)
+	}
+	if err := RemoveKey(descriptor); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveKey(descriptor); err != nil {
+		t.Fatalf("Expected no error removing a missing key, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package fscrypt
+
+// AddKey adds key to the session keyring of the process, for the directories
+// encrypted with it to be read and written by the process and its children.
+// It returns the descriptor of the key.
+func AddKey(key []byte) (string, error) {
+	return "", ErrNotSupported
+}
+
+// RemoveKey removes the key of descriptor from the keyrings, so that the
+// files encrypted with it can't be read anymore.
+func RemoveKey(descriptor string) error {
+	return ErrNotSupported
+}
+
+// SetPolicy encrypts the files of the empty directory dir, and of its
+// subdirectories, with the key of descriptor.
+func SetPolicy(dir, descriptor string) error {
+	return ErrNotSupported
+}
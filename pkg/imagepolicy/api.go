@@ -0,0 +1,35 @@
+package imagepolicy
+
+const (
+	// ImagePolicyImplements is the extension point implemented by the image
+	// policy plugins.
+	ImagePolicyImplements = "ImagePolicy"
+
+	// ImagePolicyCheck is the method of the plugins checking an image, before
+	// a container is created from it.
+	ImagePolicyCheck = "ImagePolicy.Check"
+)
+
+// Request is the image of a container being created, sent to the image
+// policy plugins.
+type Request struct {
+	// Image is the image as given for the container, a name or an ID
+	Image string `json:"Image"`
+
+	ID      string            `json:"ID"`
+	Digests []string          `json:"Digests,omitempty"` // the digests of the image, such as busybox@sha256:...
+	Tags    []string          `json:"Tags,omitempty"`
+	Labels  map[string]string `json:"Labels,omitempty"`
+}
+
+// Response is the decision of an image policy plugin.
+type Response struct {
+	// Allow allows the creation of containers from the image
+	Allow bool `json:"Allow"`
+
+	// Msg is the message explaining a denial to the client
+	Msg string `json:"Msg,omitempty"`
+
+	// Err is an error of the plugin, denying the image
+	Err string `json:"Err,omitempty"`
+}
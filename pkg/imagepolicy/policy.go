@@ -0,0 +1,92 @@
+// Package imagepolicy checks the images of the containers with external
+// plugins, such as vulnerability scanners, before the containers are created.
+package imagepolicy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// Policy checks the images with the image policy plugins, each of which must
+// allow an image. The decisions are cached per image for a time, the content
+// of an image and so its digests never changing.
+type Policy struct {
+	names []string
+	ttl   time.Duration
+
+	sync.Mutex
+	cache map[string]decision
+}
+
+type decision struct {
+	plugin  string // the plugin denying the image, empty if it's allowed
+	msg     string
+	expires time.Time
+}
+
+func (d decision) err(image string) error {
+	if d.plugin == "" {
+		return nil
+	}
+	return fmt.Errorf("Image %s denied by image policy plugin %s: %s", image, d.plugin, d.msg)
+}
+
+// New returns the policy of the plugins with the given names, in the order in
+// which they check the images, caching their decisions for ttl.
+func New(names []string, ttl time.Duration) *Policy {
+	return &Policy{
+		names: names,
+		ttl:   ttl,
+		cache: make(map[string]decision),
+	}
+}
+
+// Check returns an error if a plugin denies the image, or if it fails to
+// check it. The failures aren't cached.
+func (p *Policy) Check(req *Request) error {
+	if len(p.names) == 0 {
+		return nil
+	}
+
+	p.Lock()
+	d, ok := p.cache[req.ID]
+	p.Unlock()
+	if ok && time.Now().Before(d.expires) {
+		return d.err(req.Image)
+	}
+
+	d = decision{expires: time.Now().Add(p.ttl)}
+	for _, name := range p.names {
+		res, err := check(name, req)
+		if err != nil {
+			return fmt.Errorf("Error checking image %s with image policy plugin %s: %v", req.Image, name, err)
+		}
+		if res.Err != "" {
+			return fmt.Errorf("Error checking image %s with image policy plugin %s: %s", req.Image, name, res.Err)
+		}
+		if !res.Allow {
+			d.plugin, d.msg = name, res.Msg
+			break
+		}
+	}
+
+	p.Lock()
+	p.cache[req.ID] = d
+	p.Unlock()
+	return d.err(req.Image)
+}
+
+func check(name string, req *Request) (*Response, error) {
+	plugin, err := plugins.Get(name, ImagePolicyImplements)
+	if err != nil {
+		return nil, err
+	}
+	res := &Response{}
+	if err := plugin.Client.Call(ImagePolicyCheck, req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
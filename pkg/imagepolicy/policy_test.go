@@ -0,0 +1,122 @@
+package imagepolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// policyPlugin is an image policy plugin denying the images with the label
+// "deny", counting its checks.
+type policyPlugin struct {
+	sync.Mutex
+	checks int
+	server *httptest.Server
+}
+
+func newPolicyPlugin(name string) *policyPlugin {
+	p := &policyPlugin{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Plugin.Activate", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"Implements": ["ImagePolicy"]}`)
+	})
+	mux.HandleFunc("/"+ImagePolicyCheck, func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p.Lock()
+		p.checks++
+		p.Unlock()
+
+		res := Response{Allow: true}
+		if msg, ok := req.Labels["deny"]; ok {
+			res = Response{Allow: false, Msg: msg}
+		}
+		if msg, ok := req.Labels["error"]; ok {
+			res = Response{Err: msg}
+		}
+		json.NewEncoder(w).Encode(res)
+	})
+	p.server = httptest.NewServer(mux)
+	plugins.Register(name, p.server.URL)
+	return p
+}
+
+func (p *policyPlugin) Checks() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.checks
+}
+
+func TestPolicy(t *testing.T) {
+	p1 := newPolicyPlugin("test-policy-1")
+	defer p1.server.Close()
+	p2 := newPolicyPlugin("test-policy-2")
+	defer p2.server.Close()
+
+	policy := New([]string{"test-policy-1", "test-policy-2"}, time.Hour)
+	allowed := &Request{Image: "busybox", ID: "allowed"}
+	if err := policy.Check(allowed); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.Check(allowed); err != nil {
+		t.Fatal(err)
+	}
+	if p1.Checks() != 1 || p2.Checks() != 1 {
+		t.Fatalf("Expected each plugin to check the image once, got %d and %d checks", p1.Checks(), p2.Checks())
+	}
+
+	// the first plugin denying the image stops the checks
+	denied := &Request{Image: "vulnerable", ID: "denied", Labels: map[string]string{"deny": "CVE-2015-0001"}}
+	expected := "Image vulnerable denied by image policy plugin test-policy-1: CVE-2015-0001"
+	for i := 0; i < 2; i++ {
+		if err := policy.Check(denied); err == nil || err.Error() != expected {
+			t.Fatalf("Expected %q, got %v", expected, err)
+		}
+	}
+	if p1.Checks() != 2 || p2.Checks() != 1 {
+		t.Fatalf("Expected the denial to be cached, got %d and %d checks", p1.Checks(), p2.Checks())
+	}
+
+	// the errors of the plugins deny the image, without being cached
+	failed := &Request{Image: "failed", ID: "failed", Labels: map[string]string{"error": "scanner unavailable"}}
+	for i := 0; i < 2; i++ {
+		if err := policy.Check(failed); err == nil || !strings.Contains(err.Error(), "scanner unavailable") {
+			t.Fatalf("Expected the error of the plugin, got %v", err)
+		}
+	}
+	if p1.Checks() != 4 {
+		t.Fatalf("Expected the errors not to be cached, got %d checks", p1.Checks())
+	}
+}
+
+func TestPolicyCacheExpires(t *testing.T) {
+	p := newPolicyPlugin("test-policy-ttl")
+	defer p.server.Close()
+
+	policy := New([]string{"test-policy-ttl"}, 0)
+	req := &Request{Image: "busybox", ID: "ttl"}
+	for i := 0; i < 2; i++ {
+		if err := policy.Check(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.Checks() != 2 {
+		t.Fatalf("Expected the image to be checked again once its decision expired, got %d checks", p.Checks())
+	}
+}
+
+func TestPolicyWithoutPlugins(t *testing.T) {
+	if err := New(nil, time.Hour).Check(&Request{Image: "busybox", ID: "none"}); err != nil {
+		t.Fatal(err)
+	}
+}
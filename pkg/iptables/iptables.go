@@ -149,7 +149,7 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 		return ChainError{Chain: "FORWARD", Output: output}
 	}
 
-	if output, err := Raw("-t", string(Filter), string(action), c.Name,
+	if output, err := programRule(Filter, c.Name, action,
 		"!", "-i", c.Bridge,
 		"-o", c.Bridge,
 		"-p", proto,
@@ -161,7 +161,7 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 		return ChainError{Chain: "FORWARD", Output: output}
 	}
 
-	if output, err := Raw("-t", string(Nat), string(action), "POSTROUTING",
+	if output, err := programRule(Nat, "POSTROUTING", action,
 		"-p", proto,
 		"-s", destAddr,
 		"-d", destAddr,
@@ -178,7 +178,7 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 // Add reciprocal ACCEPT rule for two supplied IP addresses.
 // Traffic is allowed from ip1 to ip2 and vice-versa
 func (c *Chain) Link(action Action, ip1, ip2 net.IP, port int, proto string) error {
-	if output, err := Raw("-t", string(Filter), string(action), c.Name,
+	if output, err := programRule(Filter, c.Name, action,
 		"-i", c.Bridge, "-o", c.Bridge,
 		"-p", proto,
 		"-s", ip1.String(),
@@ -189,7 +189,7 @@ func (c *Chain) Link(action Action, ip1, ip2 net.IP, port int, proto string) err
 	} else if len(output) != 0 {
 		return fmt.Errorf("Error iptables forward: %s", output)
 	}
-	if output, err := Raw("-t", string(Filter), string(action), c.Name,
+	if output, err := programRule(Filter, c.Name, action,
 		"-i", c.Bridge, "-o", c.Bridge,
 		"-p", proto,
 		"-s", ip2.String(),
@@ -203,6 +203,16 @@ func (c *Chain) Link(action Action, ip1, ip2 net.IP, port int, proto string) err
 	return nil
 }
 
+// programRule adds or deletes the rule of a chain. A rule which exists
+// isn't added again, such as the rules of the containers left running by a
+// previous daemon, which are added again when the containers are restored.
+func programRule(table Table, chain string, action Action, rule ...string) ([]byte, error) {
+	if action != Delete && Exists(table, chain, rule...) {
+		return nil, nil
+	}
+	return Raw(append([]string{"-t", string(table), string(action), chain}, rule...)...)
+}
+
 // Add linking rule to nat/PREROUTING chain.
 func (c *Chain) Prerouting(action Action, args ...string) error {
 	a := []string{"-t", string(Nat), string(action), "PREROUTING"}
@@ -4,13 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 )
 
 type JSONLog struct {
-	Log     string    `json:"log,omitempty"`
-	Stream  string    `json:"stream,omitempty"`
-	Created time.Time `json:"time"`
+	Log     string            `json:"log,omitempty"`
+	Stream  string            `json:"stream,omitempty"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+	Created time.Time         `json:"time"`
 }
 
 func (jl *JSONLog) Format(format string) (string, error) {
@@ -27,10 +31,31 @@ func (jl *JSONLog) Format(format string) (string, error) {
 func (jl *JSONLog) Reset() {
 	jl.Log = ""
 	jl.Stream = ""
+	jl.Attrs = nil
 	jl.Created = time.Time{}
 }
 
-func WriteLog(src io.Reader, dst io.Writer, format string, since time.Time) error {
+// FormatDetails formats the attributes of a log as the details shown by
+// `docker logs --details`: comma-separated key=value pairs sorted by key,
+// with the keys and values escaped like in URL queries.
+func FormatDetails(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = url.QueryEscape(k) + "=" + url.QueryEscape(attrs[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// WriteLog writes the JSON logs of src to dst in the given format, skipping
+// the logs before since, and stopping at the first log after until, unless
+// they are zero. Unless attrs is nil, the logs are prefixed with their
+// details, the attributes of the logs of src being attrs.
+func WriteLog(src io.Reader, dst io.Writer, format string, since, until time.Time, attrs map[string]string) error {
 	dec := json.NewDecoder(src)
 	l := &JSONLog{}
 	for {
@@ -44,6 +69,12 @@ func WriteLog(src io.Reader, dst io.Writer, format string, since time.Time) erro
 		if !since.IsZero() && l.Created.Before(since) {
 			continue
 		}
+		if !until.IsZero() && l.Created.After(until) {
+			return nil
+		}
+		if attrs != nil {
+			l.Log = FormatDetails(attrs) + " " + l.Log
+		}
 
 		line, err := l.Format(format)
 		if err != nil {
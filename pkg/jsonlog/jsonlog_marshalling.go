@@ -57,6 +57,7 @@ package jsonlog
 
 import (
 	"bytes"
+	"encoding/json"
 	"unicode/utf8"
 
 	"github.com/docker/docker/pkg/timeutils"
@@ -74,6 +75,7 @@ func (mj *JSONLog) MarshalJSON() ([]byte, error) {
 func (mj *JSONLog) MarshalJSONBuf(buf *bytes.Buffer) error {
 	var (
 		err       error
+		attrs     []byte
 		timestamp string
 		first     bool = true
 	)
@@ -96,6 +98,18 @@ func (mj *JSONLog) MarshalJSONBuf(buf *bytes.Buffer) error {
 		buf.WriteString(`"stream":`)
 		ffjson_WriteJsonString(buf, mj.Stream)
 	}
+	if len(mj.Attrs) != 0 {
+		if first == true {
+			first = false
+		} else {
+			buf.WriteString(`,`)
+		}
+		buf.WriteString(`"attrs":`)
+		if attrs, err = json.Marshal(mj.Attrs); err != nil {
+			return err
+		}
+		buf.Write(attrs)
+	}
 	if first == true {
 		first = false
 	} else {
@@ -21,7 +21,7 @@ func TestWriteLog(t *testing.T) {
 	}
 	w := bytes.NewBuffer(nil)
 	format := timeutils.RFC3339NanoFixed
-	if err := WriteLog(&buf, w, format, time.Time{}); err != nil {
+	if err := WriteLog(&buf, w, format, time.Time{}, time.Time{}, nil); err != nil {
 		t.Fatal(err)
 	}
 	res := w.String()
@@ -52,7 +52,7 @@ func BenchmarkWriteLog(b *testing.B) {
 	b.SetBytes(int64(r.Len()))
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if err := WriteLog(r, w, format, time.Time{}); err != nil {
+		if err := WriteLog(r, w, format, time.Time{}, time.Time{}, nil); err != nil {
 			b.Fatal(err)
 		}
 		b.StopTimer()
@@ -60,3 +60,47 @@ func BenchmarkWriteLog(b *testing.B) {
 		b.StartTimer()
 	}
 }
+
+func TestJSONLogAttrs(t *testing.T) {
+	created := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := &JSONLog{Log: "line\n", Stream: "stdout", Attrs: map[string]string{"env": "prod", "com.example.app": "web"}, Created: created}
+	b, err := l.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"log":"line\n","stream":"stdout","attrs":{"com.example.app":"web","env":"prod"},"time":"2015-06-01T12:00:00Z"}`
+	if string(b) != expected {
+		t.Fatalf("Expected %s, got %s", expected, b)
+	}
+
+	var buf bytes.Buffer
+	lb := &JSONLogBytes{Log: []byte("line\n"), Stream: "stdout", Attrs: []byte(`{"com.example.app":"web","env":"prod"}`), Created: `"2015-06-01T12:00:00Z"`}
+	if err := lb.MarshalJSONBuf(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, buf.String())
+	}
+
+	var decoded JSONLog
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Attrs["env"] != "prod" || decoded.Attrs["com.example.app"] != "web" {
+		t.Fatalf("Expected the attributes to be decoded, got %v", decoded.Attrs)
+	}
+}
+
+func TestWriteLogDetails(t *testing.T) {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.Encode(JSONLog{Log: "line\n", Stream: "stdout", Created: time.Now()})
+	w := bytes.NewBuffer(nil)
+	attrs := map[string]string{"env": "prod env", "com.example.app": "web"}
+	if err := WriteLog(&buf, w, "", time.Time{}, time.Time{}, attrs); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "com.example.app=web,env=prod+env line\n"; w.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, w.String())
+	}
+}
@@ -2,16 +2,18 @@ package jsonlog
 
 import (
 	"bytes"
+	"encoding/json"
 	"unicode/utf8"
 )
 
 // JSONLogBytes is based on JSONLog.
 // It allows marshalling JSONLog from Log as []byte
-// and an already marshalled Created timestamp.
+// and already marshalled Attrs and Created timestamp.
 type JSONLogBytes struct {
-	Log     []byte `json:"log,omitempty"`
-	Stream  string `json:"stream,omitempty"`
-	Created string `json:"time"`
+	Log     []byte          `json:"log,omitempty"`
+	Stream  string          `json:"stream,omitempty"`
+	Attrs   json.RawMessage `json:"attrs,omitempty"`
+	Created string          `json:"time"`
 }
 
 // MarshalJSONBuf is based on the same method from JSONLog
@@ -38,6 +40,15 @@ func (mj *JSONLogBytes) MarshalJSONBuf(buf *bytes.Buffer) error {
 		buf.WriteString(`"stream":`)
 		ffjson_WriteJsonString(buf, mj.Stream)
 	}
+	if len(mj.Attrs) != 0 {
+		if first == true {
+			first = false
+		} else {
+			buf.WriteString(`,`)
+		}
+		buf.WriteString(`"attrs":`)
+		buf.Write(mj.Attrs)
+	}
 	if first == true {
 		first = false
 	} else {
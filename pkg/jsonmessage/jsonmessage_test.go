@@ -1,6 +1,9 @@
 package jsonmessage
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -36,3 +39,20 @@ func TestProgress(t *testing.T) {
 		t.Fatalf("Expected %q, got %q", expected, jp4.String())
 	}
 }
+
+func TestDisplayJSONMessagesStreamAux(t *testing.T) {
+	in := strings.NewReader(`{"status":"Loading"}{"aux":{"ID":"id"}}{"status":"Done"}`)
+	var (
+		out bytes.Buffer
+		aux []string
+	)
+	if err := DisplayJSONMessagesStream(in, &out, 0, false, func(m *json.RawMessage) { aux = append(aux, string(*m)) }); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Loading\nDone\n" {
+		t.Fatalf("Expected the aux messages not to be displayed, got %q", out.String())
+	}
+	if len(aux) != 1 || aux[0] != `{"ID":"id"}` {
+		t.Fatalf("Expected the aux message to be passed to the callback, got %v", aux)
+	}
+}
@@ -44,6 +44,16 @@ func NewListenBuffer(proto, addr string, activate <-chan struct{}) (net.Listener
 	}, nil
 }
 
+// Wrap returns the net.Listener l, which is already listening such as a socket
+// activated by systemd, accepting its connections once the channel passed is
+// activated like NewListenBuffer.
+func Wrap(l net.Listener, activate <-chan struct{}) net.Listener {
+	return &defaultListener{
+		wrapped:  l,
+		activate: activate,
+	}
+}
+
 // defaultListener is the buffered wrapper around the net.Listener
 type defaultListener struct {
 	wrapped  net.Listener    // The net.Listener wrapped by listenbuffer
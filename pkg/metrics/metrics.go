@@ -0,0 +1,284 @@
+// Package metrics provides counters, gauges and histograms exposed in the
+// text format of Prometheus.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric is a metric exposed by a Registry.
+type Metric interface {
+	// Name returns the name of the metric.
+	Name() string
+
+	// write writes the metric in the text format.
+	write(w io.Writer) error
+}
+
+// DefaultBuckets are the buckets of the histograms of durations in seconds,
+// from 5ms to 10s.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// desc is the description of a metric, and of its labels.
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) Name() string {
+	return d.name
+}
+
+func (d *desc) writeHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, helpEscaper.Replace(d.help), d.name, d.typ)
+	return err
+}
+
+// key returns the key of the series of the given label values.
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s has labels %v, got the values %v", d.name, d.labels, labelValues))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// labelPairs returns the label pairs of the series of the given key, with the
+// extra pairs.
+func (d *desc) labelPairs(key string, extra ...string) string {
+	var pairs []string
+	if len(d.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, d.labels[i], labelEscaper.Replace(value)))
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// values is a set of series, by their keys.
+type values struct {
+	sync.Mutex
+	desc
+	values map[string]float64
+}
+
+func (v *values) add(delta float64, labelValues []string) {
+	key := v.key(labelValues)
+	v.Lock()
+	v.values[key] += delta
+	v.Unlock()
+}
+
+func (v *values) set(value float64, labelValues []string) {
+	key := v.key(labelValues)
+	v.Lock()
+	v.values[key] = value
+	v.Unlock()
+}
+
+func (v *values) write(w io.Writer) error {
+	v.Lock()
+	defer v.Unlock()
+	return writeValues(w, &v.desc, v.values)
+}
+
+func writeValues(w io.Writer, d *desc, values map[string]float64) error {
+	if err := d.writeHeader(w); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(values) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", d.name, d.labelPairs(key), formatFloat(values[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counter is a metric which only increases, such as a number of requests.
+type Counter struct {
+	values
+}
+
+// NewCounter returns a counter, with a series for each set of values of the
+// given labels.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{values{desc: desc{name, help, "counter", labels}, values: make(map[string]float64)}}
+}
+
+// Inc increments the series of the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+// Add adds the positive value v to the series of the given label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("counter %s can't decrease", c.name))
+	}
+	c.add(v, labelValues)
+}
+
+// Gauge is a metric which goes up and down, such as a number of containers.
+type Gauge struct {
+	values
+}
+
+// NewGauge returns a gauge, with a series for each set of values of the given
+// labels.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{values{desc: desc{name, help, "gauge", labels}, values: make(map[string]float64)}}
+}
+
+// Set sets the series of the given label values.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.set(v, labelValues)
+}
+
+// Add adds v to the series of the given label values.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.add(v, labelValues)
+}
+
+// Inc increments the series of the given label values.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.add(1, labelValues)
+}
+
+// Dec decrements the series of the given label values.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.add(-1, labelValues)
+}
+
+// GaugeFunc is a gauge computed when it is exposed, with a series for each
+// value of its label.
+type GaugeFunc struct {
+	desc
+	fn func() map[string]float64
+}
+
+// NewGaugeFunc returns a gauge with the series returned by fn, by the values
+// of the label.
+func NewGaugeFunc(name, help, label string, fn func() map[string]float64) *GaugeFunc {
+	return &GaugeFunc{desc: desc{name, help, "gauge", []string{label}}, fn: fn}
+}
+
+func (g *GaugeFunc) write(w io.Writer) error {
+	return writeValues(w, &g.desc, g.fn())
+}
+
+// Histogram samples observations, such as durations, in buckets.
+type Histogram struct {
+	sync.Mutex
+	desc
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // the observations in each bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram returns a histogram of the given upper bounds of the buckets,
+// in increasing order, with a series for each set of values of the given
+// labels.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+// Observe adds the observation v to the series of the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.Lock()
+	defer h.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveSince observes the seconds elapsed since start.
+func (h *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.Lock()
+	defer h.Unlock()
+
+	if err := h.writeHeader(w); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(key, "le", formatFloat(upper)), cumulative); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labelPairs(key, "le", "+Inf"), s.count,
+			h.name, h.labelPairs(key), formatFloat(s.sum),
+			h.name, h.labelPairs(key), s.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
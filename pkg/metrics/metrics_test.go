@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func expectOutput(t *testing.T, r *Registry, expected string) {
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestCounter(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounter("pulls_total", "The number of pulls.", "result")
+	r.MustRegister(c)
+
+	c.Inc("success")
+	c.Add(2, "success")
+	c.Inc("fail\"ed")
+	expectOutput(t, r, `# HELP pulls_total The number of pulls.
+# TYPE pulls_total counter
+pulls_total{result="fail\"ed"} 1
+pulls_total{result="success"} 3
+`)
+}
+
+func TestGauge(t *testing.T) {
+	r := NewRegistry()
+	g := NewGauge("containers", "The number of containers.")
+	states := NewGaugeFunc("states", "The containers by state.", "state", func() map[string]float64 {
+		return map[string]float64{"running": 2, "stopped": 1}
+	})
+	r.MustRegister(states, g)
+
+	g.Set(3)
+	g.Inc()
+	g.Dec()
+	g.Add(-0.5)
+	expectOutput(t, r, `# HELP containers The number of containers.
+# TYPE containers gauge
+containers 2.5
+# HELP states The containers by state.
+# TYPE states gauge
+states{state="running"} 2
+states{state="stopped"} 1
+`)
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := NewHistogram("duration_seconds", "The durations.", []float64{0.1, 1}, "op")
+	r.MustRegister(h)
+
+	h.Observe(0.05, "get")
+	h.Observe(0.1, "get")
+	h.Observe(0.5, "get")
+	h.Observe(2, "get")
+	expectOutput(t, r, `# HELP duration_seconds The durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{op="get",le="0.1"} 2
+duration_seconds_bucket{op="get",le="1"} 3
+duration_seconds_bucket{op="get",le="+Inf"} 4
+duration_seconds_sum{op="get"} 2.65
+duration_seconds_count{op="get"} 4
+`)
+}
+
+func TestLabelValues(t *testing.T) {
+	c := NewCounter("requests_total", "The requests.", "method", "route")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for missing label values")
+		}
+	}()
+	c.Inc("GET")
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(NewCounter("total", "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(NewGauge("total", "")); err == nil {
+		t.Fatal("expected an error registering a metric twice")
+	}
+	r.Unregister("total")
+	if err := r.Register(NewGauge("total", "")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounter("total", "Multi\nline.")
+	r.MustRegister(c)
+	c.Inc()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	r.Handler().ServeHTTP(w, req)
+	if w.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("expected the content type of the text format, got %q", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "# HELP total Multi\\nline.\n") || !strings.HasSuffix(w.Body.String(), "total 1\n") {
+		t.Fatalf("unexpected output %q", w.Body.String())
+	}
+}
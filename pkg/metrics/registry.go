@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ContentType is the content type of the text format.
+const ContentType = "text/plain; version=0.0.4"
+
+// Registry is a set of metrics, exposed together.
+type Registry struct {
+	sync.Mutex
+	metrics map[string]Metric
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]Metric)}
+}
+
+// Register adds the metric m to the registry. It fails if a metric of the
+// same name is already registered.
+func (r *Registry) Register(m Metric) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, exists := r.metrics[m.Name()]; exists {
+		return fmt.Errorf("metric %s is already registered", m.Name())
+	}
+	r.metrics[m.Name()] = m
+	return nil
+}
+
+// MustRegister adds the metrics to the registry, and panics if one of them is
+// already registered.
+func (r *Registry) MustRegister(metrics ...Metric) {
+	for _, m := range metrics {
+		if err := r.Register(m); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister removes the metric of the given name from the registry.
+func (r *Registry) Unregister(name string) {
+	r.Lock()
+	delete(r.metrics, name)
+	r.Unlock()
+}
+
+// Write writes the metrics of the registry to w in the text format, sorted
+// by their names.
+func (r *Registry) Write(w io.Writer) error {
+	r.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]Metric, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.Unlock()
+
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler returns an http.Handler exposing the metrics of the registry.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		if err := r.Write(&buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		buf.WriteTo(w)
+	})
+}
+
+// DefaultRegistry is the registry of the metrics of the daemon.
+var DefaultRegistry = NewRegistry()
+
+// Register adds the metric m to the default registry.
+func Register(m Metric) error {
+	return DefaultRegistry.Register(m)
+}
+
+// MustRegister adds the metrics to the default registry, and panics if one of
+// them is already registered.
+func MustRegister(metrics ...Metric) {
+	DefaultRegistry.MustRegister(metrics...)
+}
+
+// Handler returns an http.Handler exposing the metrics of the default
+// registry.
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
@@ -1055,9 +1055,9 @@ func (f *FlagSet) Parse(arguments []string) error {
 
 // ParseFlags is a utility function that adds a help flag if withHelp is true,
 // calls cmd.Parse(args) and prints a relevant error message if there are
-// incorrect number of arguments. It returns error only if error handling is
-// set to ContinueOnError and parsing fails. If error handling is set to
-// ExitOnError, it's safe to ignore the return value.
+// incorrect number of arguments. It returns error if error handling is set
+// to ContinueOnError and parsing fails, or ErrHelp if the usage printed for
+// the help flag doesn't exit.
 func (cmd *FlagSet) ParseFlags(args []string, withHelp bool) error {
 	var help *bool
 	if withHelp {
@@ -1068,8 +1068,9 @@ func (cmd *FlagSet) ParseFlags(args []string, withHelp bool) error {
 	}
 	if help != nil && *help {
 		cmd.Usage()
-		// just in case Usage does not exit
-		os.Exit(0)
+		// Usage doesn't exit when it only reports the flags, the caller
+		// stops at ErrHelp
+		return ErrHelp
 	}
 	if str := cmd.CheckArgs(); str != "" {
 		cmd.ReportError(str, withHelp)
@@ -30,6 +30,8 @@ func ParseHost(defaultTCPAddr, defaultUnixAddr, addr string) (string, error) {
 		return ParseUnixAddr(addrParts[1], defaultUnixAddr)
 	case "fd":
 		return addr, nil
+	case "ssh":
+		return ParseSSHAddr(addrParts[1])
 	default:
 		return "", fmt.Errorf("Invalid bind address format: %s", addr)
 	}
@@ -46,6 +48,29 @@ func ParseUnixAddr(addr string, defaultAddr string) (string, error) {
 	return fmt.Sprintf("unix://%s", addr), nil
 }
 
+// ParseSSHAddr parses the address of a host reached through ssh, as
+// [user@]host[:port].
+func ParseSSHAddr(addr string) (string, error) {
+	addr = strings.TrimPrefix(addr, "ssh://")
+	host := addr
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		if i == 0 {
+			return "", fmt.Errorf("Invalid ssh address, the user is empty: %s", addr)
+		}
+		host = host[i+1:]
+	}
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		if p, err := strconv.Atoi(host[i+1:]); err != nil || p <= 0 || p > 65535 {
+			return "", fmt.Errorf("Invalid ssh address, bad port: %s", addr)
+		}
+		host = host[:i]
+	}
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		return "", fmt.Errorf("Invalid ssh address format: %s", addr)
+	}
+	return "ssh://" + addr, nil
+}
+
 func ParseTCPAddr(addr string, defaultAddr string) (string, error) {
 	addr = strings.TrimPrefix(addr, "tcp://")
 	if strings.Contains(addr, "://") || addr == "" {
@@ -34,6 +34,16 @@ func TestParseHost(t *testing.T) {
 	if addr, err := ParseHost(defaultHttpHost, defaultUnix, "unix://"); err != nil || addr != "unix:///var/run/docker.sock" {
 		t.Errorf("unix:///var/run/docker.sock -> expected unix:///var/run/docker.sock, got %s", addr)
 	}
+	for _, ssh := range []string{"ssh://host", "ssh://user@host", "ssh://user@host:2222", "ssh://[::1]:22"} {
+		if addr, err := ParseHost(defaultHttpHost, defaultUnix, ssh); err != nil || addr != ssh {
+			t.Errorf("%s -> expected %s, got %s, %v", ssh, ssh, addr, err)
+		}
+	}
+	for _, ssh := range []string{"ssh://", "ssh://user@", "ssh://@host", "ssh://host:port", "ssh://host/path"} {
+		if addr, err := ParseHost(defaultHttpHost, defaultUnix, ssh); err == nil {
+			t.Errorf("%s -> expected an error, got %s", ssh, addr)
+		}
+	}
 	if addr, err := ParseHost(defaultHttpHost, defaultUnix, "udp://127.0.0.1"); err == nil {
 		t.Errorf("udp protocol address expected error return, but err == nil. Got %s", addr)
 	}
@@ -0,0 +1,136 @@
+// Package platform parses the OS/architecture platforms of images, such as
+// linux/arm64, and tells the ones the host can run.
+package platform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Platform is the OS and architecture an image is built for.
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string // the variant of the architecture, such as v7 for arm
+}
+
+// the names the architectures are also known by, such as the ones of uname
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x86-64":  "amd64",
+	"aarch64": "arm64",
+	"armhf":   "arm",
+	"armel":   "arm",
+	"i386":    "386",
+	"i686":    "386",
+}
+
+// Parse parses a platform of the form os/arch[/variant], such as linux/arm64
+// or linux/arm/v7.
+func Parse(s string) (*Platform, error) {
+	parts := strings.Split(strings.ToLower(s), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid platform %q, expected os/arch[/variant]", s)
+	}
+	p := &Platform{OS: parts[0], Architecture: parts[1]}
+	if alias, ok := archAliases[p.Architecture]; ok {
+		p.Architecture = alias
+	}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// Host returns the platform of the host.
+func Host() *Platform {
+	return &Platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}
+}
+
+func (p *Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Match returns whether an image of the OS and architecture os and arch is
+// of the platform. Old images have no platform, they're linux/amd64 ones.
+func (p *Platform) Match(os, arch string) bool {
+	if os == "" {
+		os = "linux"
+	}
+	if arch == "" {
+		arch = "amd64"
+	}
+	if alias, ok := archAliases[arch]; ok {
+		arch = alias
+	}
+	return p.OS == os && p.Architecture == arch
+}
+
+// binfmtDir is where the binfmt_misc handlers of the kernel are registered.
+var binfmtDir = "/proc/sys/fs/binfmt_misc"
+
+// the names of the architectures in the QEMU user emulators, as registered
+// with binfmt_misc
+var qemuArchs = map[string]string{
+	"amd64":    "x86_64",
+	"386":      "i386",
+	"arm64":    "aarch64",
+	"arm":      "arm",
+	"ppc64le":  "ppc64le",
+	"s390x":    "s390x",
+	"riscv64":  "riscv64",
+	"mips64le": "mips64el",
+}
+
+// CanRun returns whether the host runs the binaries of the architecture arch
+// of Linux, natively or emulated by a binfmt_misc handler. The handler must be
+// registered with the F flag, for the emulator to be found from the mount
+// namespace of the containers.
+func CanRun(arch string) bool {
+	if alias, ok := archAliases[arch]; ok {
+		arch = alias
+	}
+	if arch == "" || arch == runtime.GOARCH || (arch == "386" && runtime.GOARCH == "amd64") {
+		return true
+	}
+	name, ok := qemuArchs[arch]
+	if !ok {
+		return false
+	}
+	data, err := ioutil.ReadFile(filepath.Join(binfmtDir, "qemu-"+name))
+	if err != nil {
+		return false
+	}
+	var enabled, fixed bool
+	for _, line := range strings.Split(string(data), "\n") {
+		switch {
+		case line == "enabled":
+			enabled = true
+		case strings.HasPrefix(line, "flags:"):
+			fixed = strings.Contains(strings.TrimPrefix(line, "flags:"), "F")
+		}
+	}
+	return enabled && fixed
+}
+
+// Check returns an error telling why the host can't run the images of the OS
+// and architecture os and arch, natively nor emulated, or nil if it can.
+func Check(os, arch string) error {
+	if os != "" && os != runtime.GOOS {
+		return fmt.Errorf("%s images don't run on %s hosts", os, runtime.GOOS)
+	}
+	if !CanRun(arch) {
+		if alias, ok := archAliases[arch]; ok {
+			arch = alias
+		}
+		return fmt.Errorf("%s images need a binfmt_misc emulator of %s registered with the F flag on %s hosts", arch, arch, runtime.GOARCH)
+	}
+	return nil
+}
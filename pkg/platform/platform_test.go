@@ -0,0 +1,104 @@
+package platform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	valid := map[string]string{
+		"linux/arm64":   "linux/arm64",
+		"linux/aarch64": "linux/arm64",
+		"Linux/X86_64":  "linux/amd64",
+		"linux/arm/v7":  "linux/arm/v7",
+	}
+	for s, expected := range valid {
+		p, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Error parsing %q: %v", s, err)
+		}
+		if p.String() != expected {
+			t.Fatalf("Expected %q to be %q, got %q", s, expected, p)
+		}
+	}
+	for _, s := range []string{"", "linux", "linux/", "/arm64", "linux/arm/v7/x"} {
+		if _, err := Parse(s); err == nil {
+			t.Fatalf("Expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	p := &Platform{OS: "linux", Architecture: "amd64"}
+	if !p.Match("", "") || !p.Match("linux", "x86_64") {
+		t.Fatal("Expected the images without platform to be linux/amd64 ones")
+	}
+	if p.Match("linux", "arm64") || p.Match("windows", "amd64") {
+		t.Fatal("Expected the images of other platforms not to match")
+	}
+}
+
+func TestCanRun(t *testing.T) {
+	if runtime.GOARCH == "riscv64" {
+		t.Skip("the host is a riscv64 one")
+	}
+	dir, err := ioutil.TempDir("", "docker-binfmt-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { binfmtDir = d }(binfmtDir)
+	binfmtDir = dir
+
+	if !CanRun(runtime.GOARCH) || !CanRun("") {
+		t.Fatal("Expected the host to run its own architecture")
+	}
+	if CanRun("riscv64") {
+		t.Fatal("Expected no emulator without binfmt_misc handler")
+	}
+
+	handler := filepath.Join(dir, "qemu-riscv64")
+	if err := ioutil.WriteFile(handler, []byte("enabled\ninterpreter /usr/bin/qemu-riscv64\nflags: OC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if CanRun("riscv64") {
+		t.Fatal("Expected the handler without the F flag not to run the containers")
+	}
+	if err := ioutil.WriteFile(handler, []byte("disabled\ninterpreter /usr/bin/qemu-riscv64\nflags: OCF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if CanRun("riscv64") {
+		t.Fatal("Expected the disabled handler not to run the containers")
+	}
+	if err := ioutil.WriteFile(handler, []byte("enabled\ninterpreter /usr/bin/qemu-riscv64\nflags: OCF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !CanRun("riscv64") {
+		t.Fatal("Expected the emulator to run riscv64")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if runtime.GOARCH == "riscv64" {
+		t.Skip("the host is a riscv64 one")
+	}
+	defer func(d string) { binfmtDir = d }(binfmtDir)
+	binfmtDir = "/nonexistent"
+
+	if err := Check("", ""); err != nil {
+		t.Fatalf("Expected the images without platform to run, got %v", err)
+	}
+	if err := Check(runtime.GOOS, runtime.GOARCH); err != nil {
+		t.Fatalf("Expected the images of the host to run, got %v", err)
+	}
+	if err := Check("windows", runtime.GOARCH); err == nil || runtime.GOOS == "windows" {
+		t.Fatal("Expected the images of another OS not to run")
+	}
+	if err := Check(runtime.GOOS, "riscv64"); err == nil || !strings.Contains(err.Error(), "binfmt_misc emulator of riscv64") {
+		t.Fatalf("Expected the missing emulator to be reported, got %v", err)
+	}
+}
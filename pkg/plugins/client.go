@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -72,6 +73,60 @@ func (c *Client) Call(serviceMethod string, args interface{}, ret interface{}) e
 	}
 }
 
+// Stream calls the given method of the plugin and returns the body of its
+// response, for the methods streaming their results. The caller must close
+// it.
+func (c *Client) Stream(serviceMethod string, args interface{}) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(args); err != nil {
+		return nil, err
+	}
+	resp, err := c.do(serviceMethod, &buf)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// SendStream calls the given method of the plugin with the data read from
+// data as the body of the request, for the methods taking a stream, and
+// decodes the response into ret once data is exhausted. The request isn't
+// retried, as its body can't be sent twice.
+func (c *Client) SendStream(serviceMethod string, data io.Reader, ret interface{}) error {
+	resp, err := c.do(serviceMethod, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(&ret)
+}
+
+// do sends a request with the given body to the method of the plugin,
+// returning its response if it succeeded.
+func (c *Client) do(serviceMethod string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest("POST", "/"+serviceMethod, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", versionMimetype)
+	req.URL.Scheme = "http"
+	req.URL.Host = c.addr
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		remoteErr, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Plugin Error: %s", remoteErr)
+	}
+	return resp, nil
+}
+
 func backoff(retries int) time.Duration {
 	b, max := float64(1), float64(defaultTimeOut)
 	for b < max && retries > 0 {
@@ -1,10 +1,13 @@
 package plugins
 
 import (
+	"encoding/json"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -61,3 +64,65 @@ func TestEchoInputOutput(t *testing.T) {
 		t.Fatalf("Expected %v, was %v\n", m, output)
 	}
 }
+
+func TestStream(t *testing.T) {
+	addr := setupRemotePluginServer()
+	defer teardownRemotePluginServer()
+
+	mux.HandleFunc("/Test.Stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", versionMimetype)
+		io.Copy(w, r.Body)
+		io.WriteString(w, "more")
+	})
+
+	c := NewClient(addr)
+	body, err := c.Stream("Test.Stream", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "\"hello\"\nmore" {
+		t.Fatalf("Expected the streamed response, got %q", b)
+	}
+}
+
+func TestSendStream(t *testing.T) {
+	addr := setupRemotePluginServer()
+	defer teardownRemotePluginServer()
+
+	mux.HandleFunc("/Test.SendStream", func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", versionMimetype)
+		json.NewEncoder(w).Encode(map[string]int{"Len": len(b)})
+	})
+	mux.HandleFunc("/Test.Fail", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed", http.StatusInternalServerError)
+	})
+
+	c := NewClient(addr)
+	r, w := io.Pipe()
+	go func() {
+		for i := 0; i < 10; i++ {
+			io.WriteString(w, "0123456789")
+		}
+		w.Close()
+	}()
+	var ret map[string]int
+	if err := c.SendStream("Test.SendStream", r, &ret); err != nil {
+		t.Fatal(err)
+	}
+	if ret["Len"] != 100 {
+		t.Fatalf("Expected the plugin to read 100 bytes, got %d", ret["Len"])
+	}
+
+	if err := c.SendStream("Test.Fail", strings.NewReader("data"), &ret); err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Fatalf("Expected the error of the plugin, got %v", err)
+	}
+}
@@ -14,10 +14,13 @@ var (
 type plugins struct {
 	sync.Mutex
 	plugins map[string]*Plugin
+	// installed are the addresses of the plugins installed in the daemon,
+	// found before those of the local registry
+	installed map[string]string
 }
 
 var (
-	storage          = plugins{plugins: make(map[string]*Plugin)}
+	storage          = plugins{plugins: make(map[string]*Plugin), installed: make(map[string]string)}
 	extpointHandlers = make(map[string]func(string, *Client))
 )
 
@@ -53,10 +56,13 @@ func (p *Plugin) activate() error {
 }
 
 func load(name string) (*Plugin, error) {
-	registry := newLocalRegistry("")
-	pl, err := registry.Plugin(name)
-	if err != nil {
-		return nil, err
+	pl := &Plugin{Name: name, Addr: storage.installed[name]}
+	if pl.Addr == "" {
+		registry := newLocalRegistry("")
+		var err error
+		if pl, err = registry.Plugin(name); err != nil {
+			return nil, err
+		}
 	}
 	if err := pl.activate(); err != nil {
 		return nil, err
@@ -98,3 +104,20 @@ func Get(name, imp string) (*Plugin, error) {
 func Handle(iface string, fn func(string, *Client)) {
 	extpointHandlers[iface] = fn
 }
+
+// Register makes the plugin installed at the address addr available under
+// name, before the plugins of the local registry.
+func Register(name, addr string) {
+	storage.Lock()
+	defer storage.Unlock()
+	storage.installed[name] = addr
+	delete(storage.plugins, name)
+}
+
+// Unregister removes a plugin made available by Register.
+func Unregister(name string) {
+	storage.Lock()
+	defer storage.Unlock()
+	delete(storage.installed, name)
+	delete(storage.plugins, name)
+}
@@ -0,0 +1,33 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	addr := setupRemotePluginServer()
+	defer teardownRemotePluginServer()
+
+	mux.HandleFunc("/Plugin.Activate", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"Implements": ["VolumeDriver"]}`)
+	})
+
+	Register("registered-plugin", addr)
+	p, err := Get("registered-plugin", "VolumeDriver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "registered-plugin" || p.Addr != addr {
+		t.Fatalf("Expected the registered plugin at %s, got %s at %s", addr, p.Name, p.Addr)
+	}
+	if _, err := Get("registered-plugin", "LogDriver"); err != ErrNotImplements {
+		t.Fatalf("Expected %v, got %v", ErrNotImplements, err)
+	}
+
+	Unregister("registered-plugin")
+	if _, err := Get("registered-plugin", "VolumeDriver"); err != ErrNotFound {
+		t.Fatalf("Expected %v once unregistered, got %v", ErrNotFound, err)
+	}
+}
@@ -0,0 +1,93 @@
+// Package ratelimit limits the bandwidth of the readers sharing a token
+// bucket.
+package ratelimit
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// maxChunk is the most bytes read at once by a limited reader, so that the
+// transfers sharing a limiter take turns.
+const maxChunk = 32 * 1024
+
+// Limiter is a token bucket refilled at a rate of bytes per second, holding
+// up to a second of transfer. The bytes transferred beyond its tokens are
+// reserved, the next transfers waiting for the bucket to refill.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+
+	// for mocking in unit tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewLimiter returns a limiter of rate bytes per second, or nil, no limit,
+// when rate isn't positive.
+func NewLimiter(rate int64) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &Limiter{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// Wait blocks until the transfer of n bytes fits in the rate of the limiter.
+func (l *Limiter) Wait(n int) {
+	l.mu.Lock()
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		l.sleep(wait)
+	}
+}
+
+type reader struct {
+	io.ReadCloser
+	limiter *Limiter
+}
+
+// NewReadCloser returns a reader of rc whose bandwidth is limited by
+// limiter, shared with the other readers of the limiter. It returns rc when
+// limiter is nil.
+func NewReadCloser(rc io.ReadCloser, limiter *Limiter) io.ReadCloser {
+	if limiter == nil {
+		return rc
+	}
+	return &reader{rc, limiter}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	chunk := maxChunk
+	if rate := int(r.limiter.rate); rate < chunk {
+		chunk = rate
+	}
+	if len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.limiter.Wait(n)
+	}
+	return n, err
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// mockClock is the time of a limiter, advanced by its sleeps.
+type mockClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func newMockLimiter(rate int64) (*Limiter, *mockClock) {
+	clock := &mockClock{now: time.Unix(0, 0)}
+	l := NewLimiter(rate)
+	l.last = clock.now
+	l.now = func() time.Time { return clock.now }
+	l.sleep = func(d time.Duration) {
+		clock.now = clock.now.Add(d)
+		clock.slept += d
+	}
+	return l, clock
+}
+
+func TestNewLimiterNoLimit(t *testing.T) {
+	if NewLimiter(0) != nil || NewLimiter(-1) != nil {
+		t.Fatal("Expected no limiter without a positive rate")
+	}
+	rc := ioutil.NopCloser(bytes.NewReader(nil))
+	if NewReadCloser(rc, nil) != rc {
+		t.Fatal("Expected the reader itself without a limiter")
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l, clock := newMockLimiter(1000)
+
+	// the bucket starts full
+	l.Wait(1000)
+	if clock.slept != 0 {
+		t.Fatalf("Expected no wait within the bucket, slept %v", clock.slept)
+	}
+	l.Wait(500)
+	if clock.slept != 500*time.Millisecond {
+		t.Fatalf("Expected to wait 500ms, slept %v", clock.slept)
+	}
+
+	// the bucket holds a second of transfer at most
+	clock.now = clock.now.Add(time.Minute)
+	clock.slept = 0
+	l.Wait(3000)
+	if clock.slept != 2*time.Second {
+		t.Fatalf("Expected to wait 2s, slept %v", clock.slept)
+	}
+}
+
+func TestReaderSharedLimit(t *testing.T) {
+	l, clock := newMockLimiter(64 * 1024)
+	data := make([]byte, 256*1024)
+	var readers []*reader
+	for i := 0; i < 2; i++ {
+		readers = append(readers, NewReadCloser(ioutil.NopCloser(bytes.NewReader(data)), l).(*reader))
+	}
+
+	// the two readers transfer 512KB at 64KB per second, after the first
+	// second of the bucket
+	p := make([]byte, 1024*1024)
+	total := 0
+	for done := false; !done; {
+		done = true
+		for _, r := range readers {
+			n, _ := r.Read(p)
+			if n > maxChunk {
+				t.Fatalf("Expected reads of at most %d bytes, got %d", maxChunk, n)
+			}
+			total += n
+			if n > 0 {
+				done = false
+			}
+		}
+	}
+	if total != 2*len(data) {
+		t.Fatalf("Expected %d bytes, got %d", 2*len(data), total)
+	}
+	if clock.slept != 7*time.Second {
+		t.Fatalf("Expected to wait 7s, slept %v", clock.slept)
+	}
+}
@@ -0,0 +1,287 @@
+package seccomp
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Instruction is an instruction of a BPF program, a struct sock_filter.
+type Instruction struct {
+	Code uint16
+	Jt   uint8
+	Jf   uint8
+	K    uint32
+}
+
+// Filter is the BPF program of a profile, run by the kernel on the struct
+// seccomp_data of each syscall.
+type Filter []Instruction
+
+const (
+	// the offsets of the fields of struct seccomp_data
+	offsetNr   = 0
+	offsetArch = 4
+	offsetArgs = 16
+
+	// the architectures of struct seccomp_data, the x32 syscalls being the
+	// x86_64 ones with x32SyscallBit set
+	auditArchI386   = 0x40000003
+	auditArchX86_64 = 0xc000003e
+	x32SyscallBit   = 0x40000000
+
+	bpfLdAbs = 0x20 // BPF_LD | BPF_W | BPF_ABS
+	bpfAndK  = 0x54 // BPF_ALU | BPF_AND | BPF_K
+	bpfJa    = 0x05 // BPF_JMP | BPF_JA
+	bpfJeqK  = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+	bpfJgtK  = 0x25 // BPF_JMP | BPF_JGT | BPF_K
+	bpfJgeK  = 0x35 // BPF_JMP | BPF_JGE | BPF_K
+	bpfRetK  = 0x06 // BPF_RET | BPF_K
+
+	retKill  = 0x00000000
+	retTrap  = 0x00030000
+	retErrno = 0x00050000
+	retTrace = 0x7ff00000
+	retAllow = 0x7fff0000
+
+	// maxInstructions is BPF_MAXINSNS, the size limit of a program
+	maxInstructions = 4096
+)
+
+var auditArchs = map[Arch]uint32{
+	ArchX86:    auditArchI386,
+	ArchX86_64: auditArchX86_64,
+	ArchX32:    auditArchX86_64,
+}
+
+// Compile returns the BPF program of the profile.
+func (p *Profile) Compile() (Filter, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	archs, err := p.archs()
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[Arch]bool)
+	for _, arch := range archs {
+		listed[arch] = true
+	}
+
+	a := &assembler{}
+	a.load(offsetArch)
+	done := make(map[uint32]bool)
+	for _, arch := range archs {
+		auditArch := auditArchs[arch]
+		if done[auditArch] {
+			continue
+		}
+		done[auditArch] = true
+
+		next := a.newLabel()
+		a.branchUnless(bpfJeqK, auditArch, next)
+		a.load(offsetNr)
+		if auditArch == auditArchX86_64 {
+			// the x32 syscalls are killed unless x32 is in the profile
+			x32 := a.newLabel()
+			a.branchIf(bpfJgeK, x32SyscallBit, x32)
+			p.compileRules(a, ArchX86_64, listed[ArchX86_64])
+			a.bind(x32)
+			p.compileRules(a, ArchX32, listed[ArchX32])
+		} else {
+			p.compileRules(a, arch, true)
+		}
+		a.bind(next)
+	}
+	// the syscalls of the architectures not in the profile
+	a.ret(retKill)
+	return a.assemble()
+}
+
+// compileRules appends the rules of the syscalls of arch, the accumulator
+// holding the number of the syscall.
+func (p *Profile) compileRules(a *assembler, arch Arch, listed bool) {
+	if !listed {
+		a.ret(retKill)
+		return
+	}
+	table := syscallTables[arch]
+	for _, s := range p.Syscalls {
+		nr, ok := table[s.Name]
+		if !ok {
+			continue
+		}
+		next := a.newLabel()
+		a.jump(bpfJeqK, nr, 0, next)
+		if len(s.Args) == 0 {
+			a.ret(actionValue(s.Action))
+			a.bind(next)
+			continue
+		}
+		fail := a.newLabel()
+		for _, arg := range s.Args {
+			compileArg(a, arg, fail)
+		}
+		a.ret(actionValue(s.Action))
+		a.bind(fail)
+		a.load(offsetNr)
+		a.bind(next)
+	}
+	a.ret(actionValue(p.DefaultAction))
+}
+
+// compileArg appends the comparison of an argument, jumping to fail if it
+// doesn't match. The arguments are 64 bits, compared as their high then low
+// 32 bits in little endian.
+func compileArg(a *assembler, arg *Arg, fail label) {
+	lo := uint32(offsetArgs + 8*arg.Index)
+	hi := lo + 4
+	vlo, vhi := uint32(arg.Value), uint32(arg.Value>>32)
+	pass := a.newLabel()
+	switch arg.Op {
+	case OpEqualTo:
+		a.load(hi)
+		a.jump(bpfJeqK, vhi, 0, fail)
+		a.load(lo)
+		a.jump(bpfJeqK, vlo, 0, fail)
+	case OpNotEqual:
+		a.load(hi)
+		a.jump(bpfJeqK, vhi, 0, pass)
+		a.load(lo)
+		a.jump(bpfJeqK, vlo, fail, 0)
+	case OpGreaterThan, OpGreaterEqual:
+		a.load(hi)
+		a.jump(bpfJgtK, vhi, pass, 0)
+		a.jump(bpfJeqK, vhi, 0, fail)
+		a.load(lo)
+		if arg.Op == OpGreaterThan {
+			a.jump(bpfJgtK, vlo, 0, fail)
+		} else {
+			a.jump(bpfJgeK, vlo, 0, fail)
+		}
+	case OpLessThan, OpLessEqual:
+		a.load(hi)
+		a.jump(bpfJgtK, vhi, fail, 0)
+		a.jump(bpfJeqK, vhi, 0, pass)
+		a.load(lo)
+		if arg.Op == OpLessThan {
+			a.jump(bpfJgeK, vlo, fail, 0)
+		} else {
+			a.jump(bpfJgtK, vlo, fail, 0)
+		}
+	case OpMaskedEqual:
+		a.load(hi)
+		a.and(vhi)
+		a.jump(bpfJeqK, uint32(arg.ValueTwo>>32), 0, fail)
+		a.load(lo)
+		a.and(vlo)
+		a.jump(bpfJeqK, uint32(arg.ValueTwo), 0, fail)
+	}
+	a.bind(pass)
+}
+
+func actionValue(action Action) uint32 {
+	switch action {
+	case ActTrap:
+		return retTrap
+	case ActErrno:
+		return retErrno | uint32(syscall.EPERM)
+	case ActTrace:
+		return retTrace | uint32(syscall.EPERM)
+	case ActAllow:
+		return retAllow
+	}
+	return retKill
+}
+
+// label is the target of jumps, bound to the position of an instruction.
+// The label 0 is the next instruction.
+type label int
+
+type asmInstruction struct {
+	Instruction
+	jt, jf label
+	ja     label
+}
+
+// assembler builds a BPF program whose jumps are to labels.
+type assembler struct {
+	instructions []asmInstruction
+	labels       []int
+}
+
+func (a *assembler) newLabel() label {
+	a.labels = append(a.labels, -1)
+	return label(len(a.labels))
+}
+
+// bind binds the label to the position of the next instruction.
+func (a *assembler) bind(l label) {
+	a.labels[l-1] = len(a.instructions)
+}
+
+func (a *assembler) emit(i asmInstruction) {
+	a.instructions = append(a.instructions, i)
+}
+
+func (a *assembler) load(offset uint32) {
+	a.emit(asmInstruction{Instruction: Instruction{Code: bpfLdAbs, K: offset}})
+}
+
+func (a *assembler) and(k uint32) {
+	a.emit(asmInstruction{Instruction: Instruction{Code: bpfAndK, K: k}})
+}
+
+func (a *assembler) ret(k uint32) {
+	a.emit(asmInstruction{Instruction: Instruction{Code: bpfRetK, K: k}})
+}
+
+// jump appends a conditional jump, whose targets are at most 255
+// instructions away.
+func (a *assembler) jump(code uint16, k uint32, jt, jf label) {
+	a.emit(asmInstruction{Instruction: Instruction{Code: code, K: k}, jt: jt, jf: jf})
+}
+
+// branchIf jumps to the label, which may be far away, if the condition is
+// true.
+func (a *assembler) branchIf(code uint16, k uint32, l label) {
+	skip := a.newLabel()
+	a.jump(code, k, 0, skip)
+	a.emit(asmInstruction{Instruction: Instruction{Code: bpfJa}, ja: l})
+	a.bind(skip)
+}
+
+// branchUnless jumps to the label, which may be far away, if the condition
+// is false.
+func (a *assembler) branchUnless(code uint16, k uint32, l label) {
+	skip := a.newLabel()
+	a.jump(code, k, skip, 0)
+	a.emit(asmInstruction{Instruction: Instruction{Code: bpfJa}, ja: l})
+	a.bind(skip)
+}
+
+// assemble resolves the jumps to the labels.
+func (a *assembler) assemble() (Filter, error) {
+	if len(a.instructions) > maxInstructions {
+		return nil, fmt.Errorf("the seccomp profile is too large, its filter has %d instructions out of %d", len(a.instructions), maxInstructions)
+	}
+	filter := make(Filter, len(a.instructions))
+	for i, ins := range a.instructions {
+		offset := func(l label) int {
+			if l == 0 {
+				return 0
+			}
+			return a.labels[l-1] - (i + 1)
+		}
+		jt, jf := offset(ins.jt), offset(ins.jf)
+		if jt > 255 || jf > 255 {
+			return nil, fmt.Errorf("the jump of instruction %d of the seccomp filter is too far", i)
+		}
+		filter[i] = ins.Instruction
+		filter[i].Jt = uint8(jt)
+		filter[i].Jf = uint8(jf)
+		if ins.ja != 0 {
+			filter[i].K = uint32(offset(ins.ja))
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,174 @@
+// Package seccomp compiles the seccomp profiles of the containers, which
+// filter the syscalls of their processes, to the BPF programs installed in the
+// kernel.
+//
+// A profile is written in JSON, in the format of libseccomp:
+//
+//	{
+//		"defaultAction": "SCMP_ACT_ERRNO",
+//		"architectures": ["SCMP_ARCH_X86_64", "SCMP_ARCH_X86"],
+//		"syscalls": [
+//			{"name": "read", "action": "SCMP_ACT_ALLOW"},
+//			{"name": "personality", "action": "SCMP_ACT_ALLOW", "args": [
+//				{"index": 0, "value": 8, "op": "SCMP_CMP_EQ"}
+//			]}
+//		]
+//	}
+//
+// The action of a syscall is the action of its first rule whose args all
+// match, or the default action. The syscalls of the architectures which aren't
+// in the profile are killed.
+package seccomp
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+)
+
+// Action is what is done with a syscall.
+type Action string
+
+const (
+	ActKill  Action = "SCMP_ACT_KILL"  // kill the process
+	ActTrap  Action = "SCMP_ACT_TRAP"  // send SIGSYS to the process
+	ActErrno Action = "SCMP_ACT_ERRNO" // fail the syscall with EPERM
+	ActTrace Action = "SCMP_ACT_TRACE" // notify the ptrace tracer of the process
+	ActAllow Action = "SCMP_ACT_ALLOW" // run the syscall
+)
+
+// Arch is the architecture of the syscalls.
+type Arch string
+
+const (
+	ArchX86    Arch = "SCMP_ARCH_X86"
+	ArchX86_64 Arch = "SCMP_ARCH_X86_64"
+	ArchX32    Arch = "SCMP_ARCH_X32"
+)
+
+// Operator compares an argument of a syscall to the value of an Arg.
+type Operator string
+
+const (
+	OpNotEqual     Operator = "SCMP_CMP_NE"
+	OpLessThan     Operator = "SCMP_CMP_LT"
+	OpLessEqual    Operator = "SCMP_CMP_LE"
+	OpEqualTo      Operator = "SCMP_CMP_EQ"
+	OpGreaterEqual Operator = "SCMP_CMP_GE"
+	OpGreaterThan  Operator = "SCMP_CMP_GT"
+	OpMaskedEqual  Operator = "SCMP_CMP_MASKED_EQ" // the argument masked with Value is ValueTwo
+)
+
+// Arg matches the argument Index of a syscall.
+type Arg struct {
+	Index    uint     `json:"index"`
+	Value    uint64   `json:"value"`
+	ValueTwo uint64   `json:"valueTwo"`
+	Op       Operator `json:"op"`
+}
+
+// Syscall is a rule of a profile, giving the action of the syscall Name when
+// its arguments match all the Args.
+type Syscall struct {
+	Name   string `json:"name"`
+	Action Action `json:"action"`
+	Args   []*Arg `json:"args"`
+}
+
+// Profile is a seccomp profile. The architectures default to the native
+// architecture.
+type Profile struct {
+	DefaultAction Action     `json:"defaultAction"`
+	Architectures []Arch     `json:"architectures"`
+	Syscalls      []*Syscall `json:"syscalls"`
+}
+
+// maxArgs is the number of the arguments of a syscall.
+const maxArgs = 6
+
+// NativeArch returns the architecture of the daemon, or "" if seccomp isn't
+// supported on it.
+func NativeArch() Arch {
+	switch runtime.GOARCH {
+	case "amd64":
+		return ArchX86_64
+	case "386":
+		return ArchX86
+	}
+	return ""
+}
+
+// LoadProfile parses and checks the JSON profile in body.
+func LoadProfile(body []byte) (*Profile, error) {
+	var p Profile
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("Decoding seccomp profile failed: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks the actions, architectures and rules of the profile. The
+// name of a syscall must be known on at least one of the architectures.
+func (p *Profile) Validate() error {
+	if err := validateAction(p.DefaultAction); err != nil {
+		return err
+	}
+	archs, err := p.archs()
+	if err != nil {
+		return err
+	}
+	for _, s := range p.Syscalls {
+		if err := validateAction(s.Action); err != nil {
+			return err
+		}
+		known := false
+		for _, arch := range archs {
+			if _, ok := syscallTables[arch][s.Name]; ok {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown syscall %q in the seccomp profile", s.Name)
+		}
+		for _, arg := range s.Args {
+			if arg.Index >= maxArgs {
+				return fmt.Errorf("invalid index %d of an argument of syscall %q, a syscall has %d arguments", arg.Index, s.Name, maxArgs)
+			}
+			switch arg.Op {
+			case OpNotEqual, OpLessThan, OpLessEqual, OpEqualTo, OpGreaterEqual, OpGreaterThan, OpMaskedEqual:
+			default:
+				return fmt.Errorf("invalid operator %q of an argument of syscall %q", arg.Op, s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func validateAction(action Action) error {
+	switch action {
+	case ActKill, ActTrap, ActErrno, ActTrace, ActAllow:
+		return nil
+	}
+	return fmt.Errorf("invalid seccomp action %q", action)
+}
+
+// archs returns the architectures of the profile, or the native one.
+func (p *Profile) archs() ([]Arch, error) {
+	if len(p.Architectures) == 0 {
+		arch := NativeArch()
+		if arch == "" {
+			return nil, fmt.Errorf("seccomp is not supported on %s", runtime.GOARCH)
+		}
+		return []Arch{arch}, nil
+	}
+	for _, arch := range p.Architectures {
+		if _, ok := syscallTables[arch]; !ok {
+			return nil, fmt.Errorf("unsupported architecture %q in the seccomp profile", arch)
+		}
+	}
+	return p.Architectures, nil
+}
@@ -0,0 +1,44 @@
+// +build linux
+
+package seccomp
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	prGetSeccomp      = 21 // PR_GET_SECCOMP
+	prSetSeccomp      = 22 // PR_SET_SECCOMP
+	seccompModeFilter = 2  // SECCOMP_MODE_FILTER
+)
+
+// IsEnabled returns whether the kernel filters syscalls with seccomp.
+func IsEnabled() bool {
+	// the kernel is built without seccomp
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prGetSeccomp, 0, 0); errno == syscall.EINVAL {
+		return false
+	}
+	// a filter is checked before it's installed: the kernel supports
+	// filters if a nil one is invalid
+	_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, 0)
+	return errno == syscall.EFAULT
+}
+
+// InstallFilter filters the syscalls of the current thread with the filter,
+// which is kept by the programs it executes. The thread must have
+// CAP_SYS_ADMIN, or no new privileges.
+func InstallFilter(filter Filter) error {
+	instructions := make([]syscall.SockFilter, len(filter))
+	for i, ins := range filter {
+		instructions[i] = syscall.SockFilter{Code: ins.Code, Jt: ins.Jt, Jf: ins.Jf, K: ins.K}
+	}
+	prog := syscall.SockFprog{
+		Len:    uint16(len(instructions)),
+		Filter: &instructions[0],
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package seccomp
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// run runs the filter on a syscall, like the kernel.
+func run(t *testing.T, filter Filter, auditArch, nr uint32, args ...uint64) uint32 {
+	data := make([]byte, offsetArgs+8*maxArgs)
+	binary.LittleEndian.PutUint32(data[offsetNr:], nr)
+	binary.LittleEndian.PutUint32(data[offsetArch:], auditArch)
+	for i, arg := range args {
+		binary.LittleEndian.PutUint64(data[offsetArgs+8*i:], arg)
+	}
+
+	var acc uint32
+	for pc := 0; pc < len(filter); pc++ {
+		ins := filter[pc]
+		jump := func(cond bool) {
+			if cond {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+		}
+		switch ins.Code {
+		case bpfLdAbs:
+			acc = binary.LittleEndian.Uint32(data[ins.K:])
+		case bpfAndK:
+			acc &= ins.K
+		case bpfJa:
+			pc += int(ins.K)
+		case bpfJeqK:
+			jump(acc == ins.K)
+		case bpfJgtK:
+			jump(acc > ins.K)
+		case bpfJgeK:
+			jump(acc >= ins.K)
+		case bpfRetK:
+			return ins.K
+		default:
+			t.Fatalf("Unexpected instruction %+v", ins)
+		}
+	}
+	t.Fatal("The filter didn't return")
+	return 0
+}
+
+func TestLoadProfileErrors(t *testing.T) {
+	for profile, expected := range map[string]string{
+		`{`:                                  "Decoding seccomp profile failed",
+		`{"defaultAction": "SCMP_ACT_DENY"}`: `invalid seccomp action "SCMP_ACT_DENY"`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "architectures": ["SCMP_ARCH_PPC"]}`:                                                `unsupported architecture "SCMP_ARCH_PPC"`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "read", "action": "SCMP_ACT_NONE"}]}`:                         `invalid seccomp action "SCMP_ACT_NONE"`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "doesnotexist", "action": "SCMP_ACT_KILL"}]}`:                 `unknown syscall "doesnotexist"`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "read", "action": "SCMP_ACT_KILL", "args": [{"index": 6}]}]}`: `invalid index 6`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "read", "action": "SCMP_ACT_KILL", "args": [{"op": "EQ"}]}]}`: `invalid operator "EQ"`,
+	} {
+		if _, err := LoadProfile([]byte(profile)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected an error with %q loading %s, got %v", expected, profile, err)
+		}
+	}
+}
+
+func TestCompileArchitectures(t *testing.T) {
+	p, err := LoadProfile([]byte(`{
+		"defaultAction": "SCMP_ACT_ALLOW",
+		"architectures": ["SCMP_ARCH_X86_64", "SCMP_ARCH_X86"],
+		"syscalls": [
+			{"name": "mount", "action": "SCMP_ACT_ERRNO"},
+			{"name": "arch_prctl", "action": "SCMP_ACT_TRAP"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := p.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		auditArch, nr, expected uint32
+	}{
+		{auditArchX86_64, syscallTables[ArchX86_64]["mount"], retErrno | 1},
+		{auditArchX86_64, syscallTables[ArchX86_64]["arch_prctl"], retTrap},
+		{auditArchX86_64, syscallTables[ArchX86_64]["read"], retAllow},
+		{auditArchI386, syscallTables[ArchX86]["mount"], retErrno | 1},
+		{auditArchI386, syscallTables[ArchX86]["read"], retAllow},
+		// x32 isn't in the profile
+		{auditArchX86_64, syscallTables[ArchX32]["read"], retKill},
+		// neither is ARM
+		{0x40000028, 3, retKill},
+	} {
+		if action := run(t, filter, c.auditArch, c.nr); action != c.expected {
+			t.Fatalf("Expected the action %#x for syscall %d of arch %#x, got %#x", c.expected, c.nr, c.auditArch, action)
+		}
+	}
+}
+
+func TestCompileArgs(t *testing.T) {
+	const big = 0x100000002
+	nr := syscallTables[ArchX86_64]["ioctl"]
+	for _, c := range []struct {
+		op      Operator
+		value   uint64
+		matches []uint64
+		others  []uint64
+	}{
+		{OpEqualTo, big, []uint64{big}, []uint64{2, 0x200000002, big + 1}},
+		{OpNotEqual, big, []uint64{2, 0x200000002, 0}, []uint64{big}},
+		{OpGreaterThan, big, []uint64{big + 1, 0x200000000}, []uint64{big, 0xffffffff, 3}},
+		{OpGreaterEqual, big, []uint64{big, 0x200000000}, []uint64{big - 1, 0xffffffff}},
+		{OpLessThan, big, []uint64{big - 1, 0xffffffff, 0}, []uint64{big, 0x200000000, 0x100000003}},
+		{OpLessEqual, big, []uint64{big, 0xffffffff}, []uint64{big + 1, 0x200000000}},
+	} {
+		p := &Profile{
+			DefaultAction: ActAllow,
+			Architectures: []Arch{ArchX86_64},
+			Syscalls: []*Syscall{
+				{Name: "ioctl", Action: ActErrno, Args: []*Arg{{Index: 1, Value: c.value, Op: c.op}}},
+			},
+		}
+		filter, err := p.Compile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, arg := range c.matches {
+			if action := run(t, filter, auditArchX86_64, nr, 0, arg); action != retErrno|1 {
+				t.Fatalf("Expected %#x %s %#x to match", arg, c.op, c.value)
+			}
+		}
+		for _, arg := range c.others {
+			if action := run(t, filter, auditArchX86_64, nr, 0, arg); action != retAllow {
+				t.Fatalf("Expected %#x %s %#x not to match", arg, c.op, c.value)
+			}
+		}
+	}
+}
+
+func TestCompileRulesOrder(t *testing.T) {
+	// the first rule whose args all match gives the action
+	p := &Profile{
+		DefaultAction: ActKill,
+		Architectures: []Arch{ArchX86_64},
+		Syscalls: []*Syscall{
+			{Name: "socket", Action: ActErrno, Args: []*Arg{
+				{Index: 0, Value: 16, Op: OpEqualTo},
+				{Index: 2, Value: 9, Op: OpEqualTo},
+			}},
+			{Name: "socket", Action: ActAllow, Args: []*Arg{{Index: 0, Value: 1, Op: OpGreaterEqual}}},
+			{Name: "read", Action: ActAllow},
+		},
+	}
+	filter, err := p.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := syscallTables[ArchX86_64]["socket"]
+	for _, c := range []struct {
+		nr       uint32
+		args     []uint64
+		expected uint32
+	}{
+		{socket, []uint64{16, 3, 9}, retErrno | 1},
+		{socket, []uint64{16, 3, 0}, retAllow},
+		{socket, []uint64{2, 1, 9}, retAllow},
+		{socket, []uint64{0, 1, 9}, retKill},
+		{syscallTables[ArchX86_64]["read"], nil, retAllow},
+		{syscallTables[ArchX86_64]["write"], nil, retKill},
+	} {
+		if action := run(t, filter, auditArchX86_64, c.nr, c.args...); action != c.expected {
+			t.Fatalf("Expected the action %#x for syscall %d with %v, got %#x", c.expected, c.nr, c.args, action)
+		}
+	}
+}
+
+func TestDefaultProfile(t *testing.T) {
+	if NativeArch() != ArchX86_64 {
+		t.Skip("the test checks the x86_64 syscalls")
+	}
+	filter, err := DefaultProfile().Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x86_64 := syscallTables[ArchX86_64]
+	for _, c := range []struct {
+		name     string
+		args     []uint64
+		expected uint32
+	}{
+		{"read", nil, retAllow},
+		{"execve", nil, retAllow},
+		{"mount", nil, retErrno | 1},
+		{"keyctl", nil, retErrno | 1},
+		{"ptrace", nil, retErrno | 1},
+		{"unshare", nil, retErrno | 1},
+		// a thread
+		{"clone", []uint64{0x003d0f00}, retAllow},
+		// CLONE_NEWUSER
+		{"clone", []uint64{0x10000000}, retErrno | 1},
+		{"personality", []uint64{0x0008}, retAllow},
+		{"personality", []uint64{0x0040000}, retErrno | 1},
+	} {
+		if action := run(t, filter, auditArchX86_64, x86_64[c.name], c.args...); action != c.expected {
+			t.Fatalf("Expected the action %#x for %s %v, got %#x", c.expected, c.name, c.args, action)
+		}
+	}
+	if action := run(t, filter, auditArchX86_64, syscallTables[ArchX32]["read"]); action != retAllow {
+		t.Fatalf("Expected the x32 syscalls to be allowed, got %#x", action)
+	}
+	if action := run(t, filter, auditArchI386, syscallTables[ArchX86]["mount"]); action != retErrno|1 {
+		t.Fatalf("Expected the x86 syscalls to be filtered, got %#x", action)
+	}
+}
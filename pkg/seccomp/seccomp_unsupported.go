@@ -0,0 +1,15 @@
+// +build !linux
+
+package seccomp
+
+import "fmt"
+
+// IsEnabled returns whether the kernel filters syscalls with seccomp.
+func IsEnabled() bool {
+	return false
+}
+
+// InstallFilter filters the syscalls of the current thread with the filter.
+func InstallFilter(filter Filter) error {
+	return fmt.Errorf("seccomp is only supported on Linux")
+}
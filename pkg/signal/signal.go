@@ -1,8 +1,13 @@
 package signal
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 )
 
 func CatchAll(sigc chan os.Signal) {
@@ -17,3 +22,37 @@ func StopCatch(sigc chan os.Signal) {
 	signal.Stop(sigc)
 	close(sigc)
 }
+
+// ParseSignal returns the signal of a number, or of a name with or without
+// its SIG prefix in any case: 10, USR1, SIGUSR1 and sigusr1 are all SIGUSR1
+// on linux.
+func ParseSignal(rawSignal string) (syscall.Signal, error) {
+	if s, err := strconv.ParseUint(rawSignal, 10, 8); err == nil {
+		if s == 0 {
+			return -1, fmt.Errorf("Invalid signal: %s", rawSignal)
+		}
+		return syscall.Signal(s), nil
+	}
+	sig, ok := SignalMap[strings.TrimPrefix(strings.ToUpper(rawSignal), "SIG")]
+	if !ok {
+		return -1, fmt.Errorf("Invalid signal: %s", rawSignal)
+	}
+	return sig, nil
+}
+
+// Name returns the name of the signal without its SIG prefix, or "" if it
+// isn't in SignalMap. Of the aliases of a signal, such as CHLD and CLD, the
+// first in alphabetical order is returned.
+func Name(sig os.Signal) string {
+	var names []string
+	for name, s := range SignalMap {
+		if s == sig {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
@@ -0,0 +1,49 @@
+// +build linux
+
+package signal
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	for raw, expected := range map[string]syscall.Signal{
+		"10":      syscall.SIGUSR1,
+		"USR1":    syscall.SIGUSR1,
+		"SIGUSR1": syscall.SIGUSR1,
+		"sigusr1": syscall.SIGUSR1,
+		"Term":    syscall.SIGTERM,
+		"34":      syscall.Signal(34),
+	} {
+		sig, err := ParseSignal(raw)
+		if err != nil {
+			t.Fatalf("ParseSignal(%q) failed: %v", raw, err)
+		}
+		if sig != expected {
+			t.Fatalf("ParseSignal(%q) = %v, expected %v", raw, sig, expected)
+		}
+	}
+	for _, raw := range []string{"", "0", "-1", "256", "SIG", "SIGFOO", "KILL2"} {
+		if _, err := ParseSignal(raw); err == nil {
+			t.Fatalf("ParseSignal(%q) should fail", raw)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	for sig, expected := range map[syscall.Signal]string{
+		syscall.SIGKILL: "KILL",
+		syscall.SIGCHLD: "CHLD",
+		syscall.SIGABRT: "ABRT",
+		syscall.SIGIO:   "IO",
+		syscall.SIGSYS:  "SYS",
+	} {
+		if name := Name(sig); name != expected {
+			t.Fatalf("Name(%v) = %q, expected %q", sig, name, expected)
+		}
+	}
+	if name := Name(syscall.Signal(34)); name != "" {
+		t.Fatalf("Name of a real-time signal = %q, expected none", name)
+	}
+}
@@ -86,6 +86,24 @@ func (sf *StreamFormatter) FormatProgress(id, action string, progress *jsonmessa
 	return []byte(action + " " + progress.String() + endl)
 }
 
+// FormatAux formats v as the aux field of a JSON message, the result of the
+// request the stream answers. It is not streamed without JSON.
+func (sf *StreamFormatter) FormatAux(v interface{}) []byte {
+	if !sf.json {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sf.FormatError(err)
+	}
+	aux := json.RawMessage(data)
+	b, err := json.Marshal(&jsonmessage.JSONMessage{Aux: &aux})
+	if err != nil {
+		return sf.FormatError(err)
+	}
+	return append(b, streamNewlineBytes...)
+}
+
 type StdoutFormater struct {
 	io.Writer
 	*StreamFormatter
@@ -91,3 +91,14 @@ func TestJSONFormatProgress(t *testing.T) {
 		t.Fatal("Original progress not equals progress from FormatProgress")
 	}
 }
+
+func TestJSONFormatAux(t *testing.T) {
+	sf := NewJSONStreamFormatter()
+	res := sf.FormatAux(map[string]string{"ID": "id"})
+	if string(res) != `{"aux":{"ID":"id"}}`+"\r\n" {
+		t.Fatalf("%q", res)
+	}
+	if res := NewStreamFormatter().FormatAux(map[string]string{"ID": "id"}); len(res) != 0 {
+		t.Fatalf("Expected no aux without JSON, got %q", res)
+	}
+}
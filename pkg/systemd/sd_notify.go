@@ -23,6 +23,7 @@ func SdNotify(state string) error {
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
 	_, err = conn.Write([]byte(state))
 	if err != nil {
@@ -6,10 +6,15 @@ import (
 	"time"
 )
 
-// GetTimestamp tries to parse given string as RFC3339 time
-// or Unix timestamp (with seconds precision), if successful
-//returns a Unix timestamp as string otherwise returns value back.
+// GetTimestamp tries to parse given string as RFC3339 time, Unix timestamp
+// (with seconds precision) or duration relative to now, such as 10m for ten
+// minutes ago, if successful returns a Unix timestamp as string otherwise
+// returns value back.
 func GetTimestamp(value string) string {
+	if d, err := time.ParseDuration(value); value != "0" && err == nil {
+		return strconv.FormatInt(time.Now().Add(-d).Unix(), 10)
+	}
+
 	var format string
 	if strings.Contains(value, ".") {
 		format = time.RFC3339Nano
@@ -1,7 +1,9 @@
 package timeutils
 
 import (
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestGetTimestamp(t *testing.T) {
@@ -34,3 +36,24 @@ func TestGetTimestamp(t *testing.T) {
 		}
 	}
 }
+
+func TestGetTimestampRelative(t *testing.T) {
+	now := time.Now()
+	cases := map[string]time.Duration{
+		"10m":   10 * time.Minute,
+		"1h30m": 90 * time.Minute,
+		"45s":   45 * time.Second,
+		"-1h":   -time.Hour,
+	}
+
+	for in, d := range cases {
+		o, err := strconv.ParseInt(GetTimestamp(in), 10, 64)
+		if err != nil {
+			t.Fatalf("wrong value for '%s': %v", in, err)
+		}
+		expected := now.Add(-d).Unix()
+		if o < expected-1 || o > expected+1 {
+			t.Fatalf("wrong value for '%s'. expected about:'%d' got:'%d'", in, expected, o)
+		}
+	}
+}
@@ -104,3 +104,13 @@ func (u *Ulimit) GetRlimit() (*Rlimit, error) {
 func (u *Ulimit) String() string {
 	return fmt.Sprintf("%s=%d:%d", u.Name, u.Soft, u.Hard)
 }
+
+// Name returns the name of the resource of the rlimit, such as RLIMIT_NOFILE.
+func (r *Rlimit) Name() string {
+	for name, t := range ulimitNameMapping {
+		if t == r.Type {
+			return "RLIMIT_" + strings.ToUpper(name)
+		}
+	}
+	return fmt.Sprintf("RLIMIT_%d", r.Type)
+}
@@ -53,3 +53,14 @@ func TestStringOutput(t *testing.T) {
 		t.Fatal("expected String to return nofile=512:1024, but got", s)
 	}
 }
+
+func TestRlimitName(t *testing.T) {
+	u := &Ulimit{"nofile", 1024, 512}
+	r, err := u.GetRlimit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := r.Name(); name != "RLIMIT_NOFILE" {
+		t.Fatal("expected Name to return RLIMIT_NOFILE, but got", name)
+	}
+}
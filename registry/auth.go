@@ -20,6 +20,7 @@ type RequestAuthorization struct {
 	resource         string
 	scope            string
 	actions          []string
+	additionalScopes []string
 
 	tokenLock       sync.Mutex
 	tokenCache      string
@@ -36,6 +37,16 @@ func NewRequestAuthorization(authConfig *cliconfig.AuthConfig, registryEndpoint
 	}
 }
 
+// AddScope requests an additional "resource:scope:actions" grant alongside
+// the primary one, e.g. pull access on the source repository of a blob mount.
+func (auth *RequestAuthorization) AddScope(resource, scope string, actions []string) {
+	auth.tokenLock.Lock()
+	defer auth.tokenLock.Unlock()
+	auth.additionalScopes = append(auth.additionalScopes, fmt.Sprintf("%s:%s:%s", resource, scope, strings.Join(actions, ",")))
+	// Any cached token was issued for the previous set of scopes.
+	auth.tokenExpiration = time.Time{}
+}
+
 func (auth *RequestAuthorization) getToken() (string, error) {
 	auth.tokenLock.Lock()
 	defer auth.tokenLock.Unlock()
@@ -58,7 +69,8 @@ func (auth *RequestAuthorization) getToken() (string, error) {
 			for k, v := range challenge.Parameters {
 				params[k] = v
 			}
-			params["scope"] = fmt.Sprintf("%s:%s:%s", auth.resource, auth.scope, strings.Join(auth.actions, ","))
+			scopes := append([]string{fmt.Sprintf("%s:%s:%s", auth.resource, auth.scope, strings.Join(auth.actions, ","))}, auth.additionalScopes...)
+			params["scope"] = strings.Join(scopes, " ")
 			token, err := getToken(auth.authConfig.Username, auth.authConfig.Password, params, auth.registryEndpoint, client, factory)
 			if err != nil {
 				return "", err
@@ -297,6 +309,14 @@ func tryV2TokenAuthLogin(authConfig *cliconfig.AuthConfig, params map[string]str
 // this method matches a auth configuration to a server address or a url
 func ResolveAuthConfig(config *cliconfig.ConfigFile, index *IndexInfo) cliconfig.AuthConfig {
 	configKey := index.GetAuthConfigKey()
+	// the credentials kept by a credential helper are asked to it
+	if config.CredentialHelper(configKey) != "" {
+		c, err := config.GetAuthConfig(configKey)
+		if err != nil {
+			logrus.Warnf("Error getting the credentials of %s: %v", configKey, err)
+		}
+		return c
+	}
 	// First try the happy case
 	if c, found := config.AuthConfigs[configKey]; found || index.Official {
 		return c
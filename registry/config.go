@@ -18,6 +18,10 @@ import (
 type Options struct {
 	Mirrors            opts.ListOpts
 	InsecureRegistries opts.ListOpts
+	HTTPProxy          string
+	HTTPSProxy         string
+	NoProxy            string
+	RegistryProxies    opts.ListOpts
 }
 
 const (
@@ -51,6 +55,11 @@ func (options *Options) InstallFlags() {
 	flag.Var(&options.Mirrors, []string{"-registry-mirror"}, "Preferred Docker registry mirror")
 	options.InsecureRegistries = opts.NewListOpts(ValidateIndexName)
 	flag.Var(&options.InsecureRegistries, []string{"-insecure-registry"}, "Enable insecure registry communication")
+	flag.Var(proxyOpt{&options.HTTPProxy}, []string{"-http-proxy"}, "Proxy of the HTTP requests to the registries")
+	flag.Var(proxyOpt{&options.HTTPSProxy}, []string{"-https-proxy"}, "Proxy of the HTTPS requests to the registries")
+	flag.StringVar(&options.NoProxy, []string{"-no-proxy"}, "", "Comma-separated hosts of the registries reached without proxy")
+	options.RegistryProxies = opts.NewListOpts(ValidateRegistryProxy)
+	flag.Var(&options.RegistryProxies, []string{"-registry-proxy"}, "Proxy of all the requests to a registry, REGISTRY=PROXY")
 }
 
 type netIPNet net.IPNet
@@ -74,6 +83,8 @@ func (ipnet *netIPNet) UnmarshalJSON(b []byte) (err error) {
 type ServiceConfig struct {
 	InsecureRegistryCIDRs []*netIPNet           `json:"InsecureRegistryCIDRs"`
 	IndexConfigs          map[string]*IndexInfo `json:"IndexConfigs"`
+	Proxy                 *ProxyConfig          `json:"Proxy,omitempty"`
+	RegistryProxies       map[string]string     `json:"RegistryProxies,omitempty"`
 }
 
 // NewServiceConfig returns a new instance of ServiceConfig
@@ -82,6 +93,7 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 		options = &Options{
 			Mirrors:            opts.NewListOpts(nil),
 			InsecureRegistries: opts.NewListOpts(nil),
+			RegistryProxies:    opts.NewListOpts(nil),
 		}
 	}
 
@@ -95,6 +107,18 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 	config := &ServiceConfig{
 		InsecureRegistryCIDRs: make([]*netIPNet, 0),
 		IndexConfigs:          make(map[string]*IndexInfo, 0),
+		RegistryProxies:       make(map[string]string),
+	}
+	if options.HTTPProxy != "" || options.HTTPSProxy != "" || options.NoProxy != "" {
+		config.Proxy = &ProxyConfig{
+			HTTPProxy:  options.HTTPProxy,
+			HTTPSProxy: options.HTTPSProxy,
+			NoProxy:    options.NoProxy,
+		}
+	}
+	for _, p := range options.RegistryProxies.GetAll() {
+		parts := strings.SplitN(p, "=", 2)
+		config.RegistryProxies[parts[0]] = parts[1]
 	}
 	// Split --insecure-registry into CIDR and registry-specific settings.
 	for _, r := range options.InsecureRegistries.GetAll() {
@@ -122,6 +146,9 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 		Official: true,
 	}
 
+	for name, index := range config.IndexConfigs {
+		index.Proxy = config.proxyConfig(name)
+	}
 	return config
 }
 
@@ -285,6 +312,7 @@ func (config *ServiceConfig) NewIndexInfo(indexName string) (*IndexInfo, error)
 		Official: false,
 	}
 	index.Secure = config.isSecureIndex(indexName)
+	index.Proxy = config.proxyConfig(indexName)
 	return index, nil
 }
 
@@ -49,6 +49,7 @@ func NewEndpoint(index *IndexInfo) (*Endpoint, error) {
 	if err != nil {
 		return nil, err
 	}
+	endpoint.Proxy = index.Proxy
 	if err := validateEndpoint(endpoint); err != nil {
 		return nil, err
 	}
@@ -114,6 +115,7 @@ type Endpoint struct {
 	IsSecure       bool
 	AuthChallenges []*AuthorizationChallenge
 	URLBuilder     *v2.URLBuilder
+	Proxy          *ProxyConfig // the proxies of the requests, nil for those of the environment
 }
 
 // Get the formated URL for the root of this registry Endpoint
@@ -176,7 +178,7 @@ func (e *Endpoint) pingV1(factory *requestdecorator.RequestFactory) (RegistryInf
 		return RegistryInfo{Standalone: false}, err
 	}
 
-	resp, _, err := doRequest(req, nil, ConnectTimeout, e.IsSecure)
+	resp, _, err := doRequest(req, nil, ConnectTimeout, e.IsSecure, e.Proxy)
 	if err != nil {
 		return RegistryInfo{Standalone: false}, err
 	}
@@ -224,7 +226,7 @@ func (e *Endpoint) pingV2(factory *requestdecorator.RequestFactory) (RegistryInf
 		return RegistryInfo{}, err
 	}
 
-	resp, _, err := doRequest(req, nil, ConnectTimeout, e.IsSecure)
+	resp, _, err := doRequest(req, nil, ConnectTimeout, e.IsSecure, e.Proxy)
 	if err != nil {
 		return RegistryInfo{}, err
 	}
@@ -274,7 +276,7 @@ func (e *Endpoint) HTTPClient() *http.Client {
 	return &http.Client{
 		Transport: &http.Transport{
 			DisableKeepAlives: true,
-			Proxy:             http.ProxyFromEnvironment,
+			Proxy:             e.Proxy.proxyFunc(),
 			TLSClientConfig:   &tlsConfig,
 		},
 		CheckRedirect: AddRequiredHeadersToRedirectedRequests,
@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyConfig is the configuration of the proxies of the requests of the
+// daemon to a registry. It replaces HTTP_PROXY, HTTPS_PROXY and NO_PROXY of
+// the environment of the daemon, which are used when it's not set.
+type ProxyConfig struct {
+	HTTPProxy  string `json:"HTTPProxy,omitempty"`
+	HTTPSProxy string `json:"HTTPSProxy,omitempty"`
+	NoProxy    string `json:"NoProxy,omitempty"`
+}
+
+// proxyFunc returns the function choosing the proxy of the requests, for the
+// Proxy of the http.Transport: the HTTPS proxy for the https requests,
+// falling back to the HTTP proxy, none for the hosts of NoProxy.
+func (config *ProxyConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	if config == nil {
+		return http.ProxyFromEnvironment
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := config.HTTPProxy
+		if req.URL.Scheme == "https" && config.HTTPSProxy != "" {
+			proxy = config.HTTPSProxy
+		}
+		if proxy == "" || !useProxy(req.URL.Host, config.NoProxy) {
+			return nil, nil
+		}
+		return url.Parse(proxy)
+	}
+}
+
+// useProxy returns whether the requests to addr, host[:port], go through the
+// proxy: the local host is reached directly, as are the hosts matching one
+// of the comma-separated entries of noProxy, "*" matching all the hosts. The
+// entries are hosts matching themselves and their subdomains, IP addresses
+// or CIDRs, and may have a port.
+func useProxy(addr, noProxy string) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, ""
+	}
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	ip := net.ParseIP(host)
+	if host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return false
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return false
+		}
+		if _, ipnet, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && ipnet.Contains(ip) {
+				return false
+			}
+			continue
+		}
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			if p != port {
+				continue
+			}
+			entry = h
+		}
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || (ip == nil && strings.HasSuffix(host, "."+entry)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateProxy validates the URL of a proxy, an http:// one when it has no
+// scheme.
+func ValidateProxy(val string) (string, error) {
+	if !strings.Contains(val, "://") {
+		val = "http://" + val
+	}
+	u, err := url.Parse(val)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a valid proxy URL", val)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+		return "", fmt.Errorf("Unsupported proxy scheme %s", u.Scheme)
+	}
+	if u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("Invalid proxy %s, expected [scheme://][user:password@]host[:port]", val)
+	}
+	u.Path = ""
+	return u.String(), nil
+}
+
+// ValidateRegistryProxy validates the proxy of a registry, REGISTRY=PROXY.
+func ValidateRegistryProxy(val string) (string, error) {
+	parts := strings.SplitN(val, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", fmt.Errorf("Invalid registry proxy %s, expected REGISTRY=PROXY", val)
+	}
+	name, err := ValidateIndexName(parts[0])
+	if err != nil {
+		return "", err
+	}
+	proxy, err := ValidateProxy(parts[1])
+	if err != nil {
+		return "", err
+	}
+	return name + "=" + proxy, nil
+}
+
+// proxyOpt is an option setting the URL of a proxy.
+type proxyOpt struct {
+	value *string
+}
+
+func (o proxyOpt) Set(val string) error {
+	proxy, err := ValidateProxy(val)
+	if err != nil {
+		return err
+	}
+	*o.value = proxy
+	return nil
+}
+
+func (o proxyOpt) String() string {
+	return *o.value
+}
+
+// proxyConfig returns the configuration of the proxies of the registry of
+// indexName: its own proxy, used for all its requests, or the proxies of the
+// daemon. It's nil when neither is set, the environment setting the proxies.
+func (config *ServiceConfig) proxyConfig(indexName string) *ProxyConfig {
+	if proxy, ok := config.RegistryProxies[indexName]; ok {
+		return &ProxyConfig{HTTPProxy: proxy, HTTPSProxy: proxy}
+	}
+	return config.Proxy
+}
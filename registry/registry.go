@@ -31,7 +31,7 @@ const (
 	ConnectTimeout
 )
 
-func newClient(jar http.CookieJar, roots *x509.CertPool, certs []tls.Certificate, timeout TimeoutType, secure bool) *http.Client {
+func newClient(jar http.CookieJar, roots *x509.CertPool, certs []tls.Certificate, timeout TimeoutType, secure bool, proxy *ProxyConfig) *http.Client {
 	tlsConfig := tls.Config{
 		RootCAs: roots,
 		// Avoid fallback to SSL protocols < TLS1.0
@@ -45,7 +45,7 @@ func newClient(jar http.CookieJar, roots *x509.CertPool, certs []tls.Certificate
 
 	httpTransport := &http.Transport{
 		DisableKeepAlives: true,
-		Proxy:             http.ProxyFromEnvironment,
+		Proxy:             proxy.proxyFunc(),
 		TLSClientConfig:   &tlsConfig,
 	}
 
@@ -84,7 +84,7 @@ func newClient(jar http.CookieJar, roots *x509.CertPool, certs []tls.Certificate
 	}
 }
 
-func doRequest(req *http.Request, jar http.CookieJar, timeout TimeoutType, secure bool) (*http.Response, *http.Client, error) {
+func doRequest(req *http.Request, jar http.CookieJar, timeout TimeoutType, secure bool, proxy *ProxyConfig) (*http.Response, *http.Client, error) {
 	var (
 		pool  *x509.CertPool
 		certs []tls.Certificate
@@ -144,7 +144,7 @@ func doRequest(req *http.Request, jar http.CookieJar, timeout TimeoutType, secur
 	}
 
 	if len(certs) == 0 {
-		client := newClient(jar, pool, nil, timeout, secure)
+		client := newClient(jar, pool, nil, timeout, secure, proxy)
 		res, err := client.Do(req)
 		if err != nil {
 			return nil, nil, err
@@ -152,7 +152,7 @@ func doRequest(req *http.Request, jar http.CookieJar, timeout TimeoutType, secur
 		return res, client, nil
 	}
 
-	client := newClient(jar, pool, certs, timeout, secure)
+	client := newClient(jar, pool, certs, timeout, secure, proxy)
 	res, err := client.Do(req)
 	return res, client, err
 }
@@ -108,6 +108,8 @@ func init() {
 
 	// /v2/
 	r.HandleFunc("/v2/version", handlerGetPing).Methods("GET")
+	r.HandleFunc("/v2/{repository:.+}/blobs/uploads/", handlerBlobUpload).Methods("POST")
+	r.HandleFunc("/v2/{repository:.+}/blobs/uploads/{uuid:[^/]+}", handlerBlobUploadCancel).Methods("DELETE")
 
 	testHTTPServer = httptest.NewServer(handlerAccessLog(r))
 	testHTTPSServer = httptest.NewTLSServer(handlerAccessLog(r))
@@ -175,6 +177,7 @@ func makeServiceConfig(mirrors []string, insecureRegistries []string) *ServiceCo
 	options := &Options{
 		Mirrors:            opts.NewListOpts(nil),
 		InsecureRegistries: opts.NewListOpts(nil),
+		RegistryProxies:    opts.NewListOpts(nil),
 	}
 	if mirrors != nil {
 		for _, mirror := range mirrors {
@@ -444,6 +447,20 @@ func handlerAuth(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, "OK", 200)
 }
 
+func handlerBlobUpload(w http.ResponseWriter, r *http.Request) {
+	repositoryName := mux.Vars(r)["repository"]
+	if from := r.URL.Query().Get("from"); from == REPO && r.URL.Query().Get("mount") != "" {
+		w.WriteHeader(201)
+		return
+	}
+	w.Header().Set("Location", makeURL("/v2/"+repositoryName+"/blobs/uploads/test-upload"))
+	w.WriteHeader(202)
+}
+
+func handlerBlobUploadCancel(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(204)
+}
+
 func handlerSearch(w http.ResponseWriter, r *http.Request) {
 	result := &SearchResults{
 		Query:      "fakequery",
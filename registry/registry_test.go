@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/docker/distribution/digest"
 	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/pkg/parsers/filters"
 	"github.com/docker/docker/pkg/requestdecorator"
 )
 
@@ -280,6 +283,29 @@ func TestPushImageLayerRegistry(t *testing.T) {
 	}
 }
 
+func TestMountV2ImageBlob(t *testing.T) {
+	r := spawnTestRegistrySession(t)
+	ep, err := newEndpoint(makeURL("/v2/"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := NewRequestAuthorization(r.GetAuthConfig(true), ep, "repository", "foo42/baz", []string{"pull", "push"})
+	auth.AddScope("repository", REPO, []string{"pull"})
+	dgst := digest.Digest("sha256:" + imageID)
+
+	mounted, err := r.MountV2ImageBlob(ep, "foo42/baz", REPO, dgst, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, mounted, true, "Expected blob to be mounted from "+REPO)
+
+	mounted, err = r.MountV2ImageBlob(ep, "foo42/baz", "foo42/unknown", dgst, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, mounted, false, "Expected mount from an unknown repository to fall back to a push")
+}
+
 func TestValidateRepositoryName(t *testing.T) {
 	validRepoNames := []string{
 		"docker/docker",
@@ -730,7 +756,7 @@ func TestPushImageJSONIndex(t *testing.T) {
 
 func TestSearchRepositories(t *testing.T) {
 	r := spawnTestRegistrySession(t)
-	results, err := r.SearchRepositories("fakequery")
+	results, err := r.SearchRepositories("fakequery", 25)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -742,6 +768,51 @@ func TestSearchRepositories(t *testing.T) {
 	assertEqual(t, results.Results[0].StarCount, 42, "Expected 'fakeimage' to have 42 stars")
 }
 
+func TestSearchFilterMatcher(t *testing.T) {
+	results := []SearchResult{
+		{Name: "official", IsOfficial: true, StarCount: 100},
+		{Name: "automated", IsAutomated: true, StarCount: 10},
+		{Name: "trusted", IsTrusted: true, StarCount: 1},
+		{Name: "other"},
+	}
+	for filter, expected := range map[string][]string{
+		"":                          {"official", "automated", "trusted", "other"},
+		`{"is-official":["true"]}`:  {"official"},
+		`{"is-official":["false"]}`: {"automated", "trusted", "other"},
+		`{"is-automated":["1"]}`:    {"automated", "trusted"},
+		`{"stars":["10"]}`:          {"official", "automated"},
+		`{"stars":["5","50"]}`:      {"official"},
+	} {
+		args, err := filters.FromParam(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		match, err := searchFilterMatcher(args)
+		if err != nil {
+			t.Fatalf("Unexpected error for the filter %s: %v", filter, err)
+		}
+		var names []string
+		for _, result := range results {
+			if match(result) {
+				names = append(names, result.Name)
+			}
+		}
+		if !reflect.DeepEqual(names, expected) {
+			t.Fatalf("Expected %v for the filter %s, got %v", expected, filter, names)
+		}
+	}
+
+	for _, filter := range []string{`{"unknown":["1"]}`, `{"is-official":["yes"]}`, `{"stars":["-1"]}`} {
+		args, err := filters.FromParam(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := searchFilterMatcher(args); err == nil {
+			t.Fatalf("Expected an error for the filter %s", filter)
+		}
+	}
+}
+
 func TestValidRemoteName(t *testing.T) {
 	validRepositoryNames := []string{
 		// Sanity check.
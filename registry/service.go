@@ -1,6 +1,22 @@
 package registry
 
-import "github.com/docker/docker/cliconfig"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+// DefaultSearchLimit is the number of results of a search without a limit.
+const DefaultSearchLimit = 25
+
+var acceptedSearchFilterTags = map[string]struct{}{
+	"is-automated": {},
+	"is-official":  {},
+	"stars":        {},
+}
 
 type Service struct {
 	Config *ServiceConfig
@@ -35,9 +51,23 @@ func (s *Service) Auth(authConfig *cliconfig.AuthConfig) (string, error) {
 	return Login(authConfig, endpoint, HTTPRequestFactory(nil))
 }
 
-// Search queries the public registry for images matching the specified
-// search terms, and returns the results.
-func (s *Service) Search(term string, authConfig *cliconfig.AuthConfig, headers map[string][]string) (*SearchResults, error) {
+// Search queries the public registry for at most limit images matching the
+// specified search terms, and returns the results matching the filters:
+// the official images with is-official, the automated builds with
+// is-automated, or the images with at least the given number of stars.
+func (s *Service) Search(term string, limit int, filter string, authConfig *cliconfig.AuthConfig, headers map[string][]string) (*SearchResults, error) {
+	if limit < 1 || limit > 100 {
+		return nil, fmt.Errorf("Bad parameter: limit %d is outside the range of [1, 100]", limit)
+	}
+	searchFilters, err := filters.FromParam(filter)
+	if err != nil {
+		return nil, err
+	}
+	match, err := searchFilterMatcher(searchFilters)
+	if err != nil {
+		return nil, err
+	}
+
 	repoInfo, err := s.ResolveRepository(term)
 	if err != nil {
 		return nil, err
@@ -51,7 +81,80 @@ func (s *Service) Search(term string, authConfig *cliconfig.AuthConfig, headers
 	if err != nil {
 		return nil, err
 	}
-	return r.SearchRepositories(repoInfo.GetSearchTerm())
+	results, err := r.SearchRepositories(repoInfo.GetSearchTerm(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []SearchResult{}
+	for _, result := range results.Results {
+		if match(result) {
+			filtered = append(filtered, result)
+		}
+	}
+	results.Results = filtered
+	results.NumResults = len(filtered)
+	return results, nil
+}
+
+// searchFilterMatcher returns the function matching the search results with
+// the filters.
+func searchFilterMatcher(searchFilters filters.Args) (func(SearchResult) bool, error) {
+	for name := range searchFilters {
+		if _, ok := acceptedSearchFilterTags[name]; !ok {
+			return nil, fmt.Errorf("Bad parameter: invalid filter '%s'", name)
+		}
+	}
+
+	var (
+		official, automated         bool
+		filtOfficial, filtAutomated bool
+		stars                       int
+	)
+	for _, value := range searchFilters["is-official"] {
+		b, err := parseBoolFilter("is-official", value)
+		if err != nil {
+			return nil, err
+		}
+		official, filtOfficial = b, true
+	}
+	for _, value := range searchFilters["is-automated"] {
+		b, err := parseBoolFilter("is-automated", value)
+		if err != nil {
+			return nil, err
+		}
+		automated, filtAutomated = b, true
+	}
+	for _, value := range searchFilters["stars"] {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("Bad parameter: invalid filter 'stars=%s'", value)
+		}
+		if n > stars {
+			stars = n
+		}
+	}
+
+	return func(result SearchResult) bool {
+		if filtOfficial && result.IsOfficial != official {
+			return false
+		}
+		// the trusted builds are the automated builds of the older registries
+		if filtAutomated && (result.IsAutomated || result.IsTrusted) != automated {
+			return false
+		}
+		return result.StarCount >= stars
+	}, nil
+}
+
+func parseBoolFilter(name, value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("Bad parameter: invalid filter '%s=%s'", name, value)
 }
 
 // ResolveRepository splits a repository name into its components
@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/Sirupsen/logrus"
@@ -31,6 +32,7 @@ func (r *Session) V2RegistryEndpoint(index *IndexInfo) (ep *Endpoint, err error)
 		if err != nil {
 			return
 		}
+		ep.Proxy = index.Proxy
 		err = validateEndpoint(ep)
 		if err != nil {
 			return
@@ -81,6 +83,11 @@ func (r *Session) GetV2ImageManifest(ep *Endpoint, imageName, tagName string, au
 	if err != nil {
 		return nil, "", err
 	}
+	// the registries only serve the manifest lists to the clients accepting
+	// them
+	req.Header.Add("Accept", MediaTypeManifestList)
+	req.Header.Add("Accept", MediaTypeSignedManifest)
+	req.Header.Add("Accept", "application/json")
 	if err := auth.Authorize(req); err != nil {
 		return nil, "", err
 	}
@@ -251,6 +258,74 @@ func (r *Session) PutV2ImageBlob(ep *Endpoint, imageName string, dgst digest.Dig
 	return nil
 }
 
+// MountV2ImageBlob asks the registry to link a blob that already exists in
+// the repository fromImageName into imageName, avoiding a re-upload.
+// - Blob was mounted (true, nil)
+// - Registry declined the mount, the blob must be pushed (false, nil)
+// - Failed with error
+func (r *Session) MountV2ImageBlob(ep *Endpoint, imageName, fromImageName string, dgst digest.Digest, auth *RequestAuthorization) (bool, error) {
+	routeURL, err := getV2Builder(ep).BuildBlobUploadURL(imageName, url.Values{
+		"mount": []string{dgst.String()},
+		"from":  []string{fromImageName},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	method := "POST"
+	logrus.Debugf("[registry] Calling %q %s", method, routeURL)
+	req, err := r.reqFactory.NewRequest(method, routeURL, nil)
+	if err != nil {
+		return false, err
+	}
+	if err := auth.Authorize(req); err != nil {
+		return false, err
+	}
+	res, _, err := r.doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusCreated:
+		return true, nil
+	case http.StatusAccepted:
+		// The registry does not support mounting or could not find the
+		// blob in the source repository and started a regular upload
+		// instead. That session is abandoned, PutV2ImageBlob will
+		// initiate its own.
+		if location := res.Header.Get("Location"); location != "" {
+			r.cancelBlobUpload(location, auth)
+		}
+		return false, nil
+	case http.StatusUnauthorized:
+		return false, errLoginRequired
+	case http.StatusNotFound, http.StatusForbidden:
+		// No access to the source repository, fall back to a push.
+		return false, nil
+	}
+	return false, httputils.NewHTTPRequestError(fmt.Sprintf("Server error: %d trying to mount %s blob - %s from %s", res.StatusCode, imageName, dgst, fromImageName), res)
+}
+
+// cancelBlobUpload releases an upload session which will not be used.
+func (r *Session) cancelBlobUpload(location string, auth *RequestAuthorization) {
+	logrus.Debugf("[registry] Calling %q %s", "DELETE", location)
+	req, err := r.reqFactory.NewRequest("DELETE", location, nil)
+	if err != nil {
+		return
+	}
+	if err := auth.Authorize(req); err != nil {
+		return
+	}
+	res, _, err := r.doRequest(req)
+	if err != nil {
+		logrus.Debugf("Error cancelling blob upload %s: %s", location, err)
+		return
+	}
+	res.Body.Close()
+}
+
 // initiateBlobUpload gets the blob upload location for the given image name.
 func (r *Session) initiateBlobUpload(ep *Endpoint, imageName string, auth *RequestAuthorization) (location string, err error) {
 	routeURL, err := getV2Builder(ep).BuildBlobUploadURL(imageName)
@@ -298,6 +373,19 @@ func (r *Session) initiateBlobUpload(ep *Endpoint, imageName string, auth *Reque
 
 // Finally Push the (signed) manifest of the blobs we've just pushed
 func (r *Session) PutV2ImageManifest(ep *Endpoint, imageName, tagName string, signedManifest, rawManifest []byte, auth *RequestAuthorization) (digest.Digest, error) {
+	return r.putV2Manifest(ep, imageName, tagName, "", signedManifest, rawManifest, auth)
+}
+
+// PutV2ManifestList pushes the manifest list with the tag tagName, the
+// manifests it points at must be in the repository imageName.
+func (r *Session) PutV2ManifestList(ep *Endpoint, imageName, tagName string, list []byte, auth *RequestAuthorization) (digest.Digest, error) {
+	return r.putV2Manifest(ep, imageName, tagName, MediaTypeManifestList, list, list, auth)
+}
+
+// putV2Manifest pushes the manifest of the media type mediaType, or of the
+// default one of the registry if empty. The digest returned by the registry
+// is checked against the raw manifest.
+func (r *Session) putV2Manifest(ep *Endpoint, imageName, tagName, mediaType string, manifest, rawManifest []byte, auth *RequestAuthorization) (digest.Digest, error) {
 	routeURL, err := getV2Builder(ep).BuildManifestURL(imageName, tagName)
 	if err != nil {
 		return "", err
@@ -305,10 +393,13 @@ func (r *Session) PutV2ImageManifest(ep *Endpoint, imageName, tagName string, si
 
 	method := "PUT"
 	logrus.Debugf("[registry] Calling %q %s", method, routeURL)
-	req, err := r.reqFactory.NewRequest(method, routeURL, bytes.NewReader(signedManifest))
+	req, err := r.reqFactory.NewRequest(method, routeURL, bytes.NewReader(manifest))
 	if err != nil {
 		return "", err
 	}
+	if mediaType != "" {
+		req.Header.Set("Content-Type", mediaType)
+	}
 	if err := auth.Authorize(req); err != nil {
 		return "", err
 	}
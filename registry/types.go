@@ -50,6 +50,38 @@ type ManifestData struct {
 	SchemaVersion int                `json:"schemaVersion"`
 }
 
+const (
+	// MediaTypeSignedManifest is the media type of the signed manifests of
+	// the images.
+	MediaTypeSignedManifest = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+	// MediaTypeManifestList is the media type of the manifest lists, pointing
+	// at the manifests of an image for several platforms.
+	MediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// ManifestPlatform is the platform of the image of a manifest.
+type ManifestPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// ManifestDescriptor points at a manifest by its digest.
+type ManifestDescriptor struct {
+	MediaType string            `json:"mediaType"`
+	Size      int64             `json:"size"`
+	Digest    string            `json:"digest"`
+	Platform  *ManifestPlatform `json:"platform,omitempty"`
+}
+
+// ManifestList points at the manifests of an image for several platforms,
+// for the clients to pull the one of their platform with a single tag.
+type ManifestList struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	MediaType     string               `json:"mediaType"`
+	Manifests     []ManifestDescriptor `json:"manifests"`
+}
+
 type APIVersion int
 
 func (av APIVersion) String() string {
@@ -99,6 +131,7 @@ type IndexInfo struct {
 	Mirrors  []string
 	Secure   bool
 	Official bool
+	Proxy    *ProxyConfig `json:",omitempty"` // the proxies of the requests to the registry
 }
 
 type RepositoryInfo struct {
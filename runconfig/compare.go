@@ -65,5 +65,21 @@ func Compare(a, b *Config) bool {
 			return false
 		}
 	}
+	if (a.Healthcheck == nil) != (b.Healthcheck == nil) {
+		return false
+	}
+	if a.Healthcheck != nil {
+		if a.Healthcheck.Interval != b.Healthcheck.Interval ||
+			a.Healthcheck.Timeout != b.Healthcheck.Timeout ||
+			a.Healthcheck.Retries != b.Healthcheck.Retries ||
+			len(a.Healthcheck.Test) != len(b.Healthcheck.Test) {
+			return false
+		}
+		for i := range a.Healthcheck.Test {
+			if a.Healthcheck.Test[i] != b.Healthcheck.Test[i] {
+				return false
+			}
+		}
+	}
 	return true
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/nat"
 )
@@ -128,6 +129,21 @@ type Config struct {
 	MacAddress      string
 	OnBuild         []string
 	Labels          map[string]string
+	Healthcheck     *HealthConfig `json:",omitempty"`
+}
+
+// HealthConfig holds the configuration of the check that the daemon runs
+// periodically in a container to tell whether it is healthy.
+type HealthConfig struct {
+	// Test is the check to run: {} to inherit the check of the image,
+	// {"NONE"} to disable it, {"CMD", args...} to exec the arguments or
+	// {"CMD-SHELL", command} to run the command with the shell.
+	Test []string `json:",omitempty"`
+
+	// Zero values mean the defaults of the daemon, or those of the image.
+	Interval time.Duration `json:",omitempty"` // time between two checks
+	Timeout  time.Duration `json:",omitempty"` // time before a check is considered hung
+	Retries  int           `json:",omitempty"` // failures in a row to be considered unhealthy
 }
 
 type ContainerConfigWrapper struct {
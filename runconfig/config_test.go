@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/nat"
 )
@@ -257,6 +258,41 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeHealthcheck(t *testing.T) {
+	configImage := &Config{
+		Healthcheck: &HealthConfig{
+			Test:     []string{"CMD-SHELL", "/check.sh"},
+			Interval: time.Minute,
+			Retries:  2,
+		},
+	}
+	configUser := &Config{
+		Healthcheck: &HealthConfig{
+			Interval: time.Second,
+		},
+	}
+
+	if err := Merge(configUser, configImage); err != nil {
+		t.Fatal(err)
+	}
+
+	health := configUser.Healthcheck
+	if len(health.Test) != 2 || health.Test[1] != "/check.sh" {
+		t.Fatalf("Expected the test of the image, got %v", health.Test)
+	}
+	if health.Interval != time.Second || health.Retries != 2 {
+		t.Fatalf("Expected interval 1s and 2 retries, got %v and %d", health.Interval, health.Retries)
+	}
+
+	configUser = &Config{}
+	if err := Merge(configUser, configImage); err != nil {
+		t.Fatal(err)
+	}
+	if configUser.Healthcheck != configImage.Healthcheck {
+		t.Fatalf("Expected the health check of the image, got %#v", configUser.Healthcheck)
+	}
+}
+
 func TestDecodeContainerConfig(t *testing.T) {
 	fixtures := []struct {
 		file       string
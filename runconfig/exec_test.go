@@ -0,0 +1,45 @@
+package runconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+func parseExec(args []string) (*ExecConfig, error) {
+	cmd := flag.NewFlagSet("exec", flag.ContinueOnError)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.Usage = nil
+	return ParseExec(cmd, args)
+}
+
+func TestParseExecEnv(t *testing.T) {
+	f, err := ioutil.TempFile("", "env-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("FOO=file\nBAR=file\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	config, err := parseExec([]string{"--env-file", f.Name(), "-e", "FOO=flag", "container", "env"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the variables of the flags come after those of the files, to override them
+	if expected := []string{"FOO=file", "BAR=file", "FOO=flag"}; !reflect.DeepEqual(config.Env, expected) {
+		t.Fatalf("Expected the environment %v, got %v", expected, config.Env)
+	}
+	if expected := []string{"env"}; !reflect.DeepEqual(config.Cmd, expected) {
+		t.Fatalf("Expected the command %v, got %v", expected, config.Cmd)
+	}
+
+	if _, err := parseExec([]string{"--env-file", "/nonexistent", "container", "env"}); err == nil {
+		t.Fatal("Expected an error with a missing env file")
+	}
+}
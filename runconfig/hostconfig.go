@@ -3,6 +3,7 @@ package runconfig
 import (
 	"encoding/json"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/docker/docker/nat"
@@ -118,6 +119,43 @@ func (n PidMode) Valid() bool {
 	return true
 }
 
+// GpuMode is the GPUs of the host exposed to a container: none if empty,
+// "all", or "device=0,1" for the GPUs of the given indexes.
+type GpuMode string
+
+func (n GpuMode) IsNone() bool {
+	return n == ""
+}
+
+func (n GpuMode) IsAll() bool {
+	return n == "all"
+}
+
+// Devices returns the indexes of the GPUs of the mode "device=".
+func (n GpuMode) Devices() []string {
+	parts := strings.SplitN(string(n), "=", 2)
+	if len(parts) != 2 || parts[0] != "device" {
+		return nil
+	}
+	return strings.Split(parts[1], ",")
+}
+
+func (n GpuMode) Valid() bool {
+	if n.IsNone() || n.IsAll() {
+		return true
+	}
+	devices := n.Devices()
+	if len(devices) == 0 {
+		return false
+	}
+	for _, d := range devices {
+		if _, err := strconv.ParseUint(d, 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 type DeviceMapping struct {
 	PathOnHost        string
 	PathInContainer   string
@@ -228,7 +266,15 @@ type HostConfig struct {
 	ReadonlyRootfs  bool
 	Ulimits         []*ulimit.Ulimit
 	LogConfig       LogConfig
-	CgroupParent    string // Parent cgroup.
+	CgroupParent    string   // Parent cgroup.
+	VolumeDriver    string   // Volume driver plugin of the named volumes created for the container.
+	AutoRemove      bool     // Remove the container, with its volumes, when it exits.
+	Secrets         []string // Names of the secrets of the daemon in /run/secrets in the container.
+	Runtime         string   // OCI runtime of the daemon running the container, "" or "native" for the native driver.
+	Gpus            GpuMode  // GPUs of the host exposed to the container.
+	// SkipPlatformCheck starts the container even if the host can't run the
+	// platform of its image.
+	SkipPlatformCheck bool
 }
 
 func MergeConfigs(config *Config, hostConfig *HostConfig) *ContainerConfigWrapper {
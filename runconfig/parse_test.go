@@ -2,7 +2,11 @@ package runconfig
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
@@ -63,3 +67,91 @@ func TestConflictContainerNetworkAndLinks(t *testing.T) {
 		t.Fatalf("Expected error ErrConflictContainerNetworkAndLinks, got: %s", err)
 	}
 }
+
+func TestParseHealth(t *testing.T) {
+	checkOk := func(args ...string) *HealthConfig {
+		config, _, _, err := parseRun(args)
+		if err != nil {
+			t.Fatalf("%#v: %v", args, err)
+		}
+		return config.Healthcheck
+	}
+	checkError := func(expected string, args ...string) {
+		_, _, _, err := parseRun(args)
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Expected error %q for %#v, got %v", expected, args, err)
+		}
+	}
+
+	if health := checkOk("img", "cmd"); health != nil {
+		t.Fatalf("Expected no health check, got %#v", health)
+	}
+
+	health := checkOk("--no-healthcheck", "img", "cmd")
+	if len(health.Test) != 1 || health.Test[0] != "NONE" {
+		t.Fatalf("Expected the health check to be disabled, got %#v", health)
+	}
+
+	health = checkOk("--health-cmd=/check.sh -q", "--health-interval=2s", "--health-timeout=3s", "--health-retries=4", "img", "cmd")
+	if len(health.Test) != 2 || health.Test[0] != "CMD-SHELL" || health.Test[1] != "/check.sh -q" {
+		t.Fatalf("Unexpected health check command: %#v", health.Test)
+	}
+	if health.Interval != 2*time.Second || health.Timeout != 3*time.Second || health.Retries != 4 {
+		t.Fatalf("Unexpected health check options: %#v", health)
+	}
+
+	// options without a command override those of the image's check
+	health = checkOk("--health-interval=1m", "img", "cmd")
+	if len(health.Test) != 0 || health.Interval != time.Minute {
+		t.Fatalf("Unexpected health check: %#v", health)
+	}
+
+	checkError("--no-healthcheck conflicts with --health-* options",
+		"--no-healthcheck", "--health-cmd=/check.sh", "img", "cmd")
+	checkError("--health-retries cannot be negative",
+		"--health-retries=-1", "img", "cmd")
+}
+
+func TestParseSeccompProfile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "docker-seccomp-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	profile := filepath.Join(tmpDir, "profile.json")
+	if err := ioutil.WriteFile(profile, []byte("{\n\t\"defaultAction\": \"SCMP_ACT_ALLOW\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, hostConfig, _, err := parseRun([]string{"--security-opt=seccomp=" + profile, "--security-opt=seccomp:unconfined", "--security-opt=label:disable", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{`seccomp={"defaultAction":"SCMP_ACT_ALLOW"}`, "seccomp:unconfined", "label:disable"}
+	if strings.Join(hostConfig.SecurityOpt, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Expected security options %q, got %q", expected, hostConfig.SecurityOpt)
+	}
+
+	if _, _, _, err := parseRun([]string{"--security-opt=seccomp=" + filepath.Join(tmpDir, "missing.json"), "img", "cmd"}); err == nil || !strings.Contains(err.Error(), "Opening seccomp profile") {
+		t.Fatalf("Expected an error opening the seccomp profile, got %v", err)
+	}
+}
+
+func TestParseGpus(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--gpus=device=0,2", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if devices := hostConfig.Gpus.Devices(); len(devices) != 2 || devices[0] != "0" || devices[1] != "2" {
+		t.Fatalf("Expected the GPUs 0 and 2, got %v", devices)
+	}
+	if _, hostConfig, _, err = parseRun([]string{"--gpus=all", "img", "cmd"}); err != nil || !hostConfig.Gpus.IsAll() {
+		t.Fatalf("Expected all the GPUs, got %v: %q", err, hostConfig.Gpus)
+	}
+
+	for _, gpus := range []string{"some", "device=", "device=0,", "device=gpu0"} {
+		if _, _, _, err := parseRun([]string{"--gpus=" + gpus, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for the GPUs %q", gpus)
+		}
+	}
+}
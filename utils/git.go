@@ -13,28 +13,42 @@ import (
 	"github.com/docker/docker/pkg/urlutil"
 )
 
-func GitClone(remoteURL string) (string, error) {
+// GitClone clones the repository at remoteURL into a temporary directory,
+// which it returns along with the directory to use as the build context.
+// The URL fragment selects a ref to check out and a subdirectory of the
+// repository as the context, as in `#branch:subdir`. Submodules are checked
+// out at the commit the ref points them to.
+func GitClone(remoteURL string) (root string, contextDir string, err error) {
 	if !urlutil.IsGitTransport(remoteURL) {
 		remoteURL = "https://" + remoteURL
 	}
-	root, err := ioutil.TempDir("", "docker-build-git")
+	root, err = ioutil.TempDir("", "docker-build-git")
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(root)
+		}
+	}()
 
 	u, err := url.Parse(remoteURL)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	fragment := u.Fragment
 	clone := cloneArgs(u, root)
 
 	if output, err := git(clone...); err != nil {
-		return "", fmt.Errorf("Error trying to use git: %s (%s)", err, output)
+		return "", "", fmt.Errorf("Error trying to use git: %s (%s)", err, output)
 	}
 
-	return checkoutGit(fragment, root)
+	contextDir, err = checkoutGit(fragment, root)
+	if err != nil {
+		return "", "", err
+	}
+	return root, contextDir, nil
 }
 
 func cloneArgs(remoteURL *url.URL, root string) []string {
@@ -62,8 +76,18 @@ func cloneArgs(remoteURL *url.URL, root string) []string {
 func checkoutGit(fragment, root string) (string, error) {
 	refAndDir := strings.SplitN(fragment, ":", 2)
 
-	if len(refAndDir[0]) != 0 {
-		if output, err := gitWithinDir(root, "checkout", refAndDir[0]); err != nil {
+	if ref := refAndDir[0]; len(ref) != 0 {
+		if _, err := gitWithinDir(root, "checkout", ref); err != nil {
+			// Refs other than branches and tags, like refs/pull/1/head,
+			// are not fetched by the clone.
+			if output, err := gitWithinDir(root, "fetch", "origin", ref); err != nil {
+				return "", fmt.Errorf("Error trying to use git: %s (%s)", err, output)
+			}
+			if output, err := gitWithinDir(root, "checkout", "FETCH_HEAD"); err != nil {
+				return "", fmt.Errorf("Error trying to use git: %s (%s)", err, output)
+			}
+		}
+		if output, err := gitWithinDir(root, "submodule", "update", "--init", "--recursive"); err != nil {
 			return "", fmt.Errorf("Error trying to use git: %s (%s)", err, output)
 		}
 	}
@@ -85,7 +109,10 @@ func checkoutGit(fragment, root string) (string, error) {
 
 func gitWithinDir(dir string, args ...string) ([]byte, error) {
 	a := []string{"--work-tree", dir, "--git-dir", filepath.Join(dir, ".git")}
-	return git(append(a, args...)...)
+	cmd := exec.Command("git", append(a, args...)...)
+	// git submodule has to be run from within the work tree
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
 }
 
 func git(args ...string) ([]byte, error) {
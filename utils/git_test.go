@@ -173,3 +173,84 @@ func TestCheckoutGit(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckoutGitFetchesRefAndSubmodules(t *testing.T) {
+	// allow submodules to be cloned from a local path
+	os.Setenv("GIT_CONFIG_COUNT", "1")
+	os.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	os.Setenv("GIT_CONFIG_VALUE_0", "always")
+	defer os.Unsetenv("GIT_CONFIG_COUNT")
+
+	root, err := ioutil.TempDir("", "docker-build-git-checkout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	initRepo := func(dir, file, content string) {
+		if _, err := git("init", dir); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{
+			{"config", "user.email", "test@docker.com"},
+			{"config", "user.name", "Docker test"},
+		} {
+			if _, err := gitWithinDir(dir, args...); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := gitWithinDir(dir, "add", "-A"); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := gitWithinDir(dir, "commit", "-m", "First commit"); err != nil {
+			t.Fatalf("%v: %s", err, out)
+		}
+	}
+
+	subDir := filepath.Join(root, "sub")
+	initRepo(subDir, "file", "submodule")
+
+	upstream := filepath.Join(root, "upstream")
+	initRepo(upstream, "Dockerfile", "FROM scratch")
+
+	// a commit which is only reachable from a ref outside of refs/heads,
+	// which adds a submodule
+	if _, err := gitWithinDir(upstream, "checkout", "-b", "pull"); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := gitWithinDir(upstream, "submodule", "add", subDir, "sub"); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if _, err := gitWithinDir(upstream, "commit", "-m", "Add submodule"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitWithinDir(upstream, "update-ref", "refs/pull/1/head", "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitWithinDir(upstream, "checkout", "master"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitWithinDir(upstream, "branch", "-D", "pull"); err != nil {
+		t.Fatal(err)
+	}
+
+	clone := filepath.Join(root, "clone")
+	if out, err := git("clone", "--recursive", upstream, clone); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	r, err := checkoutGit("refs/pull/1/head:sub", clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(r, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "submodule" {
+		t.Fatalf("Expected submodule, was %v\n", string(b))
+	}
+}
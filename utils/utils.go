@@ -199,17 +199,27 @@ func ReplaceOrAppendEnvValues(defaults, overrides []string) []string {
 // can be read and returns an error if some files can't be read
 // symlinks which point to non-existing files don't trigger an error
 func ValidateContextDirectory(srcPath string, excludes []string) error {
+	patterns, patDirs, exceptions, err := fileutils.CleanPatterns(excludes)
+	if err != nil {
+		return err
+	}
+
 	return filepath.Walk(filepath.Join(srcPath, "."), func(filePath string, f os.FileInfo, err error) error {
 		// skip this directory/file if it's not in the path, it won't get added to the context
 		if relFilePath, err := filepath.Rel(srcPath, filePath); err != nil {
 			return err
-		} else if skip, err := fileutils.Matches(relFilePath, excludes); err != nil {
-			return err
-		} else if skip {
-			if f.IsDir() {
-				return filepath.SkipDir
+		} else if relFilePath != "." {
+			skip, err := fileutils.OptimizedMatches(relFilePath, patterns, patDirs)
+			if err != nil {
+				return err
+			}
+			if skip {
+				// an exception may re-include files of an excluded directory
+				if f.IsDir() && !exceptions {
+					return filepath.SkipDir
+				}
+				return nil
 			}
-			return nil
 		}
 
 		if err != nil {
@@ -239,6 +249,21 @@ func ValidateContextDirectory(srcPath string, excludes []string) error {
 	})
 }
 
+// DockerIgnoreFile returns the name, relative to the build context root, of
+// the ignore file applying to the Dockerfile dockerfileName: the file named
+// after the Dockerfile with a .dockerignore suffix, like
+// "app.Dockerfile.dockerignore", if there is one, .dockerignore otherwise.
+// This lets the Dockerfiles of a repository each have their own context.
+func DockerIgnoreFile(root, dockerfileName string) string {
+	if dockerfileName != "" {
+		name := dockerfileName + ".dockerignore"
+		if _, err := os.Lstat(filepath.Join(root, name)); err == nil {
+			return name
+		}
+	}
+	return ".dockerignore"
+}
+
 // Reads a .dockerignore file and returns the list of file patterns
 // to ignore. Note this will trim whitespace from each line as well
 // as use GO's "clean" func to get the shortest/cleanest path for each.
@@ -261,7 +286,11 @@ func ReadDockerIgnore(path string) ([]string, error) {
 		if pattern == "" {
 			continue
 		}
-		pattern = filepath.Clean(pattern)
+		if fileutils.Exclusion(pattern) {
+			pattern = "!" + filepath.Clean(pattern[1:])
+		} else {
+			pattern = filepath.Clean(pattern)
+		}
 		excludes = append(excludes, pattern)
 	}
 	if err = scanner.Err(); err != nil {
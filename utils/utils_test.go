@@ -98,3 +98,75 @@ func TestReadDockerIgnore(t *testing.T) {
 		t.Fatalf("Fourth element is not lastfile")
 	}
 }
+
+func TestReadDockerIgnoreCleansExceptions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dockerignore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	diName := filepath.Join(tmpDir, ".dockerignore")
+	if err := ioutil.WriteFile(diName, []byte("./docs\n!./docs//README.md\n"), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	di, err := ReadDockerIgnore(diName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(di) != 2 || di[0] != "docs" || di[1] != "!docs/README.md" {
+		t.Fatalf("Expected [docs !docs/README.md], got %v", di)
+	}
+}
+
+func TestDockerIgnoreFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dockerignore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if name := DockerIgnoreFile(tmpDir, "Dockerfile.app"); name != ".dockerignore" {
+		t.Fatalf("Expected .dockerignore, got %s", name)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "Dockerfile.app.dockerignore"), []byte("*"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if name := DockerIgnoreFile(tmpDir, "Dockerfile.app"); name != "Dockerfile.app.dockerignore" {
+		t.Fatalf("Expected Dockerfile.app.dockerignore, got %s", name)
+	}
+	if name := DockerIgnoreFile(tmpDir, "Dockerfile"); name != ".dockerignore" {
+		t.Fatalf("Expected .dockerignore, got %s", name)
+	}
+}
+
+func TestValidateContextDirectoryWithExceptions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dockerignore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	unreadable := filepath.Join(tmpDir, "docs", "README.md")
+	if err := ioutil.WriteFile(unreadable, []byte("readme"), 0000); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := os.Open(unreadable); err == nil {
+		f.Close()
+		t.Skip("files can't be made unreadable, running as root?")
+	}
+
+	// the excluded directory has to be walked, as a later exception may
+	// re-include some of its files
+	if err := ValidateContextDirectory(tmpDir, []string{"docs"}); err != nil {
+		t.Fatalf("Expected the excluded file not to be checked, got %v", err)
+	}
+	if err := ValidateContextDirectory(tmpDir, []string{"docs", "!docs/README.md"}); err == nil {
+		t.Fatal("Expected an error for the re-included unreadable file")
+	}
+}
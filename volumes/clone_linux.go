@@ -0,0 +1,107 @@
+// +build linux
+
+package volumes
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/docker/docker/pkg/system"
+)
+
+// ficlone is the FICLONE ioctl, sharing the extents of a file with another
+// file on the filesystems supporting it, such as btrfs and xfs.
+const ficlone = 0x40049409
+
+// cloneRegular copies a regular file, as a reflink if the filesystem supports
+// it, or else by copying its content.
+func cloneRegular(srcPath, dstPath string, mode os.FileMode) error {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dstFile.Fd(), ficlone, srcFile.Fd()); errno == 0 {
+		return nil
+	}
+	_, err = io.Copy(dstFile, srcFile)
+	return err
+}
+
+// cloneDir copies the content of the directory srcDir to the directory
+// dstDir, with the ownership, the modes and the times of the files.
+func cloneDir(srcDir, dstDir string) error {
+	return filepath.Walk(srcDir, func(srcPath string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(srcDir, srcPath)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dstDir, relPath)
+
+		stat, ok := f.Sys().(*syscall.Stat_t)
+		if !ok {
+			return fmt.Errorf("Unable to get raw syscall.Stat_t data for %s", srcPath)
+		}
+
+		switch f.Mode() & os.ModeType {
+		case 0: // Regular file
+			if err := cloneRegular(srcPath, dstPath, f.Mode()); err != nil {
+				return err
+			}
+		case os.ModeDir:
+			if err := os.Mkdir(dstPath, f.Mode()); err != nil && !os.IsExist(err) {
+				return err
+			}
+		case os.ModeSymlink:
+			link, err := os.Readlink(srcPath)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, dstPath); err != nil {
+				return err
+			}
+		case os.ModeNamedPipe, os.ModeSocket:
+			if err := syscall.Mkfifo(dstPath, stat.Mode); err != nil {
+				return err
+			}
+		case os.ModeDevice:
+			if err := syscall.Mknod(dstPath, stat.Mode, int(stat.Rdev)); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Unknown file type for %s", srcPath)
+		}
+
+		if err := os.Lchown(dstPath, int(stat.Uid), int(stat.Gid)); err != nil {
+			return err
+		}
+
+		// There is no Lchmod, and the mode must be set after the owner
+		isSymlink := f.Mode()&os.ModeSymlink != 0
+		if !isSymlink {
+			if err := os.Chmod(dstPath, f.Mode()); err != nil {
+				return err
+			}
+		}
+
+		ts := []syscall.Timespec{stat.Atim, stat.Mtim}
+		if isSymlink {
+			return system.LUtimesNano(dstPath, ts)
+		}
+		return system.UtimesNano(dstPath, ts)
+	})
+}
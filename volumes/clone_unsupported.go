@@ -0,0 +1,9 @@
+// +build !linux
+
+package volumes
+
+import "fmt"
+
+func cloneDir(srcDir, dstDir string) error {
+	return fmt.Errorf("Cloning the local volumes is not supported on this platform")
+}
@@ -0,0 +1,101 @@
+package volumes
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/docker/docker/pkg/mount"
+)
+
+// validLocalOptions are the mount options of the local volumes: the type of
+// the filesystem, its mount options and its device, as given to mount(8).
+var validLocalOptions = map[string]bool{
+	"type":   true,
+	"o":      true,
+	"device": true,
+}
+
+// validateLocalOptions checks the mount options of a local volume. A local
+// volume with options mounts a filesystem, such as nfs or tmpfs, over its
+// path while containers use it.
+func validateLocalOptions(opts map[string]string) error {
+	if len(opts) == 0 {
+		return nil
+	}
+	for key := range opts {
+		if !validLocalOptions[key] {
+			return fmt.Errorf("Bad parameter: invalid option %s for the local volume driver", key)
+		}
+	}
+	if opts["type"] == "" || opts["device"] == "" {
+		return fmt.Errorf("Bad parameter: the type and device options are required to mount a local volume")
+	}
+	return nil
+}
+
+// mountLocal mounts the filesystem of a local volume with options, the first
+// time a container uses it.
+func (v *Volume) mountLocal() error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.mounts == 0 {
+		// the filesystem is left mounted for the containers kept running by
+		// a previous daemon with live restore
+		if mounted, err := mount.Mounted(v.Path); err == nil && mounted {
+			v.mounts++
+			return nil
+		}
+		opts, err := resolveAddr(v.Options["o"])
+		if err != nil {
+			return err
+		}
+		if err := mount.Mount(v.Options["device"], v.Path, v.Options["type"], opts); err != nil {
+			return fmt.Errorf("Error mounting volume %s: %v", v.DisplayName(), err)
+		}
+	}
+	v.mounts++
+	return nil
+}
+
+// unmountLocal unmounts the filesystem of a local volume with options, once
+// the last container using it released it.
+func (v *Volume) unmountLocal() error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.mounts == 0 {
+		return nil
+	}
+	v.mounts--
+	if v.mounts > 0 {
+		return nil
+	}
+	if err := mount.Unmount(v.Path); err != nil {
+		return fmt.Errorf("Error unmounting volume %s: %v", v.DisplayName(), err)
+	}
+	return nil
+}
+
+// resolveAddr replaces the host name of the addr mount option, used by the
+// network filesystems such as nfs, with its IP address, as the kernel doesn't
+// resolve it.
+func resolveAddr(opts string) (string, error) {
+	parts := strings.Split(opts, ",")
+	for i, opt := range parts {
+		if !strings.HasPrefix(opt, "addr=") {
+			continue
+		}
+		addr := strings.TrimPrefix(opt, "addr=")
+		if net.ParseIP(addr) != nil {
+			continue
+		}
+		ip, err := net.ResolveIPAddr("ip", addr)
+		if err != nil {
+			return "", fmt.Errorf("Error resolving the address %s: %v", addr, err)
+		}
+		parts[i] = "addr=" + ip.String()
+	}
+	return strings.Join(parts, ","), nil
+}
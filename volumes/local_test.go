@@ -0,0 +1,118 @@
+package volumes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/pkg/mount"
+)
+
+func TestValidateLocalOptions(t *testing.T) {
+	for _, opts := range []map[string]string{
+		nil,
+		{"type": "tmpfs", "device": "tmpfs"},
+		{"type": "nfs", "o": "addr=192.168.0.1,rw", "device": ":/export"},
+	} {
+		if err := validateLocalOptions(opts); err != nil {
+			t.Fatalf("%v: %v", opts, err)
+		}
+	}
+	for _, opts := range []map[string]string{
+		{"type": "tmpfs", "device": "tmpfs", "size": "1m"},
+		{"type": "tmpfs"},
+		{"device": ":/export", "o": "addr=192.168.0.1"},
+	} {
+		if err := validateLocalOptions(opts); err == nil {
+			t.Fatalf("%v: expected invalid options", opts)
+		}
+	}
+}
+
+func TestResolveAddr(t *testing.T) {
+	for opts, expected := range map[string]string{
+		"":                     "",
+		"size=1m":              "size=1m",
+		"addr=192.168.0.1,rw":  "addr=192.168.0.1,rw",
+		"rw,addr=127.0.0.1":    "rw,addr=127.0.0.1",
+		"rw,addr=localhost,ro": "rw,addr=127.0.0.1,ro",
+	} {
+		resolved, err := resolveAddr(opts)
+		if err != nil {
+			t.Fatalf("%s: %v", opts, err)
+		}
+		if resolved != expected {
+			t.Fatalf("%s: expected %s, got %s", opts, expected, resolved)
+		}
+	}
+}
+
+func TestRepositoryLocalVolumeOptions(t *testing.T) {
+	root, err := ioutil.TempDir(os.TempDir(), "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	repo, err := newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.FindOrCreateNamedVolume("data", "", map[string]string{"type": "tmpfs"}, nil, true); err == nil {
+		t.Fatal("expected an error creating a volume with invalid options")
+	}
+	if v := repo.GetByName("data"); v != nil {
+		t.Fatalf("expected no volume created with invalid options, got %v", v)
+	}
+
+	opts := map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "size=1m"}
+	v, err := repo.FindOrCreateNamedVolume("data", "", opts, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Mountable() || v.DriverName() != DefaultDriver {
+		t.Fatalf("expected a mountable local volume, got %+v", v)
+	}
+
+	// the options are kept with the volume
+	repo, err = newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v = repo.GetByName("data")
+	if v == nil || v.Options["type"] != "tmpfs" || v.Options["o"] != "size=1m" {
+		t.Fatalf("expected the options of the volume to be restored, got %+v", v)
+	}
+
+	if os.Getuid() != 0 {
+		t.Skip("mounting the volume requires root")
+	}
+	// the filesystem is mounted once, until the last unmount
+	for i := 0; i < 2; i++ {
+		if err := v.Mount(); err != nil {
+			t.Skip(err)
+		}
+	}
+	if mounted, err := mount.Mounted(v.Path); err != nil || !mounted {
+		t.Fatalf("expected the volume to be mounted, got %v, %v", mounted, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(v.Path, "file"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unmount(); err != nil {
+		t.Fatal(err)
+	}
+	if mounted, _ := mount.Mounted(v.Path); !mounted {
+		t.Fatal("expected the volume to stay mounted while it is used")
+	}
+	if err := v.Unmount(); err != nil {
+		t.Fatal(err)
+	}
+	if mounted, _ := mount.Mounted(v.Path); mounted {
+		t.Fatal("expected the volume to be unmounted")
+	}
+	if _, err := os.Stat(filepath.Join(v.Path, "file")); !os.IsNotExist(err) {
+		t.Fatalf("expected the data of the tmpfs to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package volumes
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// pluginExtPoint is the extension point implemented by the volume driver
+// plugins.
+const pluginExtPoint = "VolumeDriver"
+
+// pluginDriver is a volume driver plugin, which stores the named volumes
+// created with it, and mounts them on the host for the containers.
+type pluginDriver struct {
+	name   string
+	client *plugins.Client
+}
+
+type pluginRequest struct {
+	Name string
+	From string            `json:",omitempty"`
+	Opts map[string]string `json:",omitempty"`
+}
+
+type pluginResponse struct {
+	Mountpoint string
+	Err        string
+}
+
+var pluginDrivers = struct {
+	sync.Mutex
+	drivers map[string]*pluginDriver
+}{drivers: make(map[string]*pluginDriver)}
+
+// getPluginDriver returns the volume driver plugin name, activating it the
+// first time it is used.
+func getPluginDriver(name string) (*pluginDriver, error) {
+	pluginDrivers.Lock()
+	defer pluginDrivers.Unlock()
+
+	if d, ok := pluginDrivers.drivers[name]; ok {
+		return d, nil
+	}
+	p, err := plugins.Get(name, pluginExtPoint)
+	if err != nil {
+		return nil, fmt.Errorf("Error looking up volume driver %s: %v", name, err)
+	}
+	d := &pluginDriver{name: name, client: p.Client}
+	pluginDrivers.drivers[name] = d
+	return d, nil
+}
+
+func (d *pluginDriver) call(method, volume string) (string, error) {
+	return d.callRequest(method, &pluginRequest{Name: volume})
+}
+
+func (d *pluginDriver) callRequest(method string, req *pluginRequest) (string, error) {
+	volume := req.Name
+	var res pluginResponse
+	if err := d.client.Call("VolumeDriver."+method, req, &res); err != nil {
+		return "", fmt.Errorf("volume driver %s: %s of volume %s failed: %v", d.name, method, volume, err)
+	}
+	if res.Err != "" {
+		return "", fmt.Errorf("volume driver %s: %s of volume %s failed: %s", d.name, method, volume, res.Err)
+	}
+	return res.Mountpoint, nil
+}
+
+// Create creates the volume in the storage of the plugin, with the driver
+// options given to the plugin, as a copy of the volume from of the plugin if
+// it isn't empty. Copying a volume is optional for the plugins.
+func (d *pluginDriver) Create(volume, from string, opts map[string]string) error {
+	_, err := d.callRequest("Create", &pluginRequest{Name: volume, From: from, Opts: opts})
+	return err
+}
+
+// Remove removes the volume, and its data, from the storage of the plugin.
+func (d *pluginDriver) Remove(volume string) error {
+	_, err := d.call("Remove", volume)
+	return err
+}
+
+// Path returns the path on the host where the plugin mounts the volume.
+func (d *pluginDriver) Path(volume string) (string, error) {
+	return d.call("Path", volume)
+}
+
+// Mount mounts the volume on the host for a container, returning its path.
+func (d *pluginDriver) Mount(volume string) (string, error) {
+	return d.call("Mount", volume)
+}
+
+// Unmount releases the volume mounted for a container.
+func (d *pluginDriver) Unmount(volume string) error {
+	_, err := d.call("Unmount", volume)
+	return err
+}
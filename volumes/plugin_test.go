@@ -0,0 +1,196 @@
+package volumes
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// testPlugin is a volume driver plugin keeping its volumes in memory.
+type testPlugin struct {
+	sync.Mutex
+	root    string
+	volumes map[string]int    // the number of mounts of each volume
+	from    map[string]string // the volume each volume was copied from
+}
+
+func newTestPlugin(t *testing.T, name, root string) (*testPlugin, *httptest.Server) {
+	p := &testPlugin{root: root, volumes: make(map[string]int), from: make(map[string]string)}
+	mux := http.NewServeMux()
+	handle := func(method string, fn func(req pluginRequest) (string, string)) {
+		mux.HandleFunc("/VolumeDriver."+method, func(w http.ResponseWriter, r *http.Request) {
+			var req pluginRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			p.Lock()
+			defer p.Unlock()
+			mountpoint, err := fn(req)
+			json.NewEncoder(w).Encode(pluginResponse{Mountpoint: mountpoint, Err: err})
+		})
+	}
+	handle("Create", func(req pluginRequest) (string, string) {
+		if req.From != "" {
+			if _, exists := p.volumes[req.From]; !exists {
+				return "", "no such volume"
+			}
+			p.from[req.Name] = req.From
+		}
+		p.volumes[req.Name] = 0
+		return "", ""
+	})
+	handle("Path", func(req pluginRequest) (string, string) {
+		name := req.Name
+		return filepath.Join(p.root, name), ""
+	})
+	handle("Mount", func(req pluginRequest) (string, string) {
+		name := req.Name
+		p.volumes[name]++
+		return filepath.Join(p.root, name), ""
+	})
+	handle("Unmount", func(req pluginRequest) (string, string) {
+		name := req.Name
+		p.volumes[name]--
+		return "", ""
+	})
+	handle("Remove", func(req pluginRequest) (string, string) {
+		name := req.Name
+		if p.volumes[name] > 0 {
+			return "", "volume is mounted"
+		}
+		delete(p.volumes, name)
+		return "", ""
+	})
+	server := httptest.NewServer(mux)
+
+	pluginDrivers.Lock()
+	pluginDrivers.drivers[name] = &pluginDriver{name: name, client: plugins.NewClient(server.URL)}
+	pluginDrivers.Unlock()
+	return p, server
+}
+
+func TestRepositoryPluginVolumes(t *testing.T) {
+	root, err := ioutil.TempDir(os.TempDir(), "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	repo, err := newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, server := newTestPlugin(t, "test-plugin", filepath.Join(root, "plugin"))
+	defer server.Close()
+
+	v, err := repo.FindOrCreateNamedVolume("data", "test-plugin", nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Driver != "test-plugin" || v.DriverName() != "test-plugin" {
+		t.Fatalf("expected a volume of the test-plugin driver, got %q", v.Driver)
+	}
+	expected := filepath.Join(root, "plugin", "data")
+	if v.Path != expected {
+		t.Fatalf("expected the volume at the path of the plugin %s, got %s", expected, v.Path)
+	}
+	if _, exists := p.volumes["data"]; !exists {
+		t.Fatal("expected the plugin to create the volume")
+	}
+	if v2 := repo.Get(v.Path); v2 != v {
+		t.Fatalf("expected the volume to be found by its path even if it isn't mounted, got %v", v2)
+	}
+
+	// the existing volume is found without a driver, but not with another
+	if v2, err := repo.FindOrCreateNamedVolume("data", "", nil, nil, true); err != nil || v2 != v {
+		t.Fatalf("expected the existing volume, got %v, %v", v2, err)
+	}
+	if _, err := repo.FindOrCreateNamedVolume("data", DefaultDriver, nil, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.FindOrCreateNamedVolume("data", "other-plugin", nil, nil, true); err == nil {
+		t.Fatal("expected an error finding the volume with another driver")
+	}
+
+	if err := v.Mount(); err != nil {
+		t.Fatal(err)
+	}
+	if p.volumes["data"] != 1 {
+		t.Fatalf("expected the plugin to mount the volume, got %d mounts", p.volumes["data"])
+	}
+
+	// the plugin refuses to remove a mounted volume, which is then kept
+	if err := repo.Delete(v.Path); err == nil {
+		t.Fatal("expected an error removing a mounted volume")
+	}
+	if v2 := repo.GetByName("data"); v2 != v {
+		t.Fatalf("expected the volume to be kept, got %v", v2)
+	}
+
+	if err := v.Unmount(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(v.Path); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := p.volumes["data"]; exists {
+		t.Fatal("expected the plugin to remove the volume")
+	}
+	if v2 := repo.GetByName("data"); v2 != nil {
+		t.Fatalf("expected the volume to be removed, got %v", v2)
+	}
+}
+
+func TestRepositoryClonePluginVolume(t *testing.T) {
+	root, err := ioutil.TempDir(os.TempDir(), "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	repo, err := newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, server := newTestPlugin(t, "test-plugin", filepath.Join(root, "plugin"))
+	defer server.Close()
+
+	src, err := repo.FindOrCreateNamedVolume("data", "test-plugin", nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CloneVolume(src, "", nil, true); err == nil {
+		t.Fatal("expected an error copying a volume of a plugin to an anonymous volume")
+	}
+
+	// the plugin copies the volume
+	v, err := repo.CloneVolume(src, "test-data", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Driver != "test-plugin" || v.From != "data" {
+		t.Fatalf("expected a copy of the volume by the plugin, got %+v", v)
+	}
+	if p.from["test-data"] != "data" {
+		t.Fatalf("expected the plugin to copy the volume data, got %v", p.from)
+	}
+}
+
+func TestLocalVolumeMount(t *testing.T) {
+	// the local volumes are always on the host, without a plugin
+	v := &Volume{Path: "/var/lib/docker/vfs/dir/test"}
+	if err := v.Mount(); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unmount(); err != nil {
+		t.Fatal(err)
+	}
+	if v.DriverName() != DefaultDriver {
+		t.Fatalf("expected the %s driver, got %s", DefaultDriver, v.DriverName())
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/graphdriver"
@@ -39,7 +42,24 @@ func NewRepository(configPath string, driver graphdriver.Driver) (*Repository, e
 	return repo, repo.restore()
 }
 
+// validVolumeName is the format of the names of the named volumes.
+var validVolumeName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// ValidateName checks that name is a valid name for a named volume, at least
+// 2 characters among letters, digits, '_', '.' and '-', starting with a
+// letter or a digit.
+func ValidateName(name string) error {
+	if !validVolumeName.MatchString(name) {
+		return fmt.Errorf("Invalid volume name %q: names must be at least 2 characters among [a-zA-Z0-9_.-], starting with a letter or a digit", name)
+	}
+	return nil
+}
+
 func (r *Repository) newVolume(path string, writable bool) (*Volume, error) {
+	return r.newNamedVolume("", path, nil, nil, writable)
+}
+
+func (r *Repository) newNamedVolume(name, path string, opts, labels map[string]string, writable bool) (*Volume, error) {
 	var (
 		isBindMount bool
 		err         error
@@ -65,7 +85,11 @@ func (r *Repository) newVolume(path string, writable bool) (*Volume, error) {
 
 	v := &Volume{
 		ID:          id,
+		Name:        name,
 		Path:        path,
+		Options:     opts,
+		Labels:      labels,
+		CreatedAt:   time.Now().UTC(),
 		repository:  r,
 		Writable:    writable,
 		containers:  make(map[string]struct{}),
@@ -104,11 +128,60 @@ func (r *Repository) restore() error {
 				continue
 			}
 		}
+		if vol.CreatedAt.IsZero() {
+			// the volumes of older daemons were created with their config
+			vol.CreatedAt = v.ModTime().UTC()
+		}
 		r.add(vol)
 	}
 	return nil
 }
 
+// GetByName returns the named volume with the given name, or else the
+// anonymous volume with the given ID, or nil if there is none.
+func (r *Repository) GetByName(name string) *Volume {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.getByName(name)
+}
+
+func (r *Repository) getByName(name string) *Volume {
+	var anonymous *Volume
+	for _, v := range r.volumes {
+		if v.IsBindMount {
+			continue
+		}
+		if v.Name == name {
+			return v
+		}
+		if v.Name == "" && v.ID == name {
+			anonymous = v
+		}
+	}
+	return anonymous
+}
+
+// List returns the named and the anonymous volumes, but not the bind
+// mounts, sorted by name, the anonymous volumes being named by their ID.
+func (r *Repository) List() []*Volume {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var volumes volumesByName
+	for _, v := range r.volumes {
+		if !v.IsBindMount {
+			volumes = append(volumes, v)
+		}
+	}
+	sort.Sort(volumes)
+	return volumes
+}
+
+type volumesByName []*Volume
+
+func (s volumesByName) Len() int           { return len(s) }
+func (s volumesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s volumesByName) Less(i, j int) bool { return s[i].DisplayName() < s[j].DisplayName() }
+
 func (r *Repository) Get(path string) *Volume {
 	r.lock.Lock()
 	vol := r.get(path)
@@ -117,10 +190,13 @@ func (r *Repository) Get(path string) *Volume {
 }
 
 func (r *Repository) get(path string) *Volume {
-	path, err := filepath.EvalSymlinks(path)
-	if err != nil {
+	if realPath, err := filepath.EvalSymlinks(path); err == nil {
+		path = realPath
+	} else if !os.IsNotExist(err) {
 		return nil
 	}
+	// the path of a volume of a volume driver plugin may only exist while
+	// the volume is mounted
 	return r.volumes[filepath.Clean(path)]
 }
 
@@ -134,11 +210,7 @@ func (r *Repository) add(volume *Volume) {
 func (r *Repository) Delete(path string) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	path, err := filepath.EvalSymlinks(path)
-	if err != nil {
-		return err
-	}
-	volume := r.get(filepath.Clean(path))
+	volume := r.get(path)
 	if volume == nil {
 		return fmt.Errorf("Volume %s does not exist", path)
 	}
@@ -147,12 +219,28 @@ func (r *Repository) Delete(path string) error {
 	if len(containers) > 0 {
 		return fmt.Errorf("Volume %s is being used and cannot be removed: used by containers %s", volume.Path, containers)
 	}
+	return r.remove(volume)
+}
+
+// remove removes the volume and its data, with the lock of the repository.
+func (r *Repository) remove(volume *Volume) error {
+	// a volume driver plugin may fail to remove the volume, which is then
+	// kept
+	if volume.Driver != "" {
+		d, err := getPluginDriver(volume.Driver)
+		if err != nil {
+			return err
+		}
+		if err := d.Remove(volume.Name); err != nil {
+			return err
+		}
+	}
 
 	if err := os.RemoveAll(volume.configPath); err != nil {
 		return err
 	}
 
-	if !volume.IsBindMount {
+	if volume.Driver == "" && !volume.IsBindMount {
 		if err := r.driver.Remove(volume.ID); err != nil {
 			if !os.IsNotExist(err) {
 				return err
@@ -177,6 +265,137 @@ func (r *Repository) createNewVolumePath(id string) (string, error) {
 	return path, nil
 }
 
+// CreateVolume creates a new anonymous volume with the given labels.
+func (r *Repository) CreateVolume(labels map[string]string, writable bool) (*Volume, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.newNamedVolume("", "", nil, labels, writable)
+}
+
+// FindOrCreateNamedVolume returns the named volume with the given name,
+// creating it with the volume driver plugin driver, or with the local driver
+// if driver is empty, and the given driver options and labels if it doesn't
+// exist yet.
+func (r *Repository) FindOrCreateNamedVolume(name, driver string, opts, labels map[string]string, writable bool) (*Volume, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if driver == DefaultDriver {
+		driver = ""
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if v := r.getByName(name); v != nil && v.Name == name {
+		if driver != "" && v.Driver != driver {
+			return nil, fmt.Errorf("Conflict: volume %s already exists with the %s driver", name, v.DriverName())
+		}
+		return v, nil
+	}
+	if driver != "" {
+		return r.newPluginVolume(name, driver, "", opts, labels, writable)
+	}
+	if err := validateLocalOptions(opts); err != nil {
+		return nil, err
+	}
+	return r.newNamedVolume(name, "", opts, labels, writable)
+}
+
+// CloneVolume creates the named volume with the given name, or an anonymous
+// volume if name is empty, as a copy of the volume src with the given labels.
+// The local volumes are copied by the daemon, as reflinks where the
+// filesystem supports them, and the volumes of the volume driver plugins by
+// the plugins supporting it.
+func (r *Repository) CloneVolume(src *Volume, name string, labels map[string]string, writable bool) (*Volume, error) {
+	if name != "" {
+		if err := ValidateName(name); err != nil {
+			return nil, err
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if name != "" {
+		if v := r.getByName(name); v != nil && v.Name == name {
+			return nil, fmt.Errorf("Conflict: volume %s already exists", name)
+		}
+	}
+	if src.Driver != "" {
+		if name == "" {
+			return nil, fmt.Errorf("Bad parameter: the volumes of the %s volume driver must be named", src.Driver)
+		}
+		return r.newPluginVolume(name, src.Driver, src.Name, nil, labels, writable)
+	}
+	if src.IsBindMount || len(src.Options) > 0 {
+		return nil, fmt.Errorf("Bad parameter: volume %s can't be cloned, its data isn't stored by the daemon", src.DisplayName())
+	}
+
+	v, err := r.newNamedVolume(name, "", nil, labels, writable)
+	if err != nil {
+		return nil, err
+	}
+	v.From = src.DisplayName()
+	if err := cloneDir(src.Path, v.Path); err != nil {
+		if err := r.remove(v); err != nil {
+			logrus.Errorf("Error removing volume %s: %v", v.DisplayName(), err)
+		}
+		return nil, fmt.Errorf("Error cloning volume %s: %v", src.DisplayName(), err)
+	}
+	if err := v.ToDisk(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// newPluginVolume creates the named volume with the volume driver plugin
+// driver, at the path where the plugin mounts it, as a copy of the volume
+// from if it isn't empty.
+func (r *Repository) newPluginVolume(name, driver, from string, opts, labels map[string]string, writable bool) (*Volume, error) {
+	d, err := getPluginDriver(driver)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Create(name, from, opts); err != nil {
+		return nil, err
+	}
+	path, err := d.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, fmt.Errorf("volume driver %s returned no path for volume %s", driver, name)
+	}
+	path = filepath.Clean(path)
+	if r.get(path) != nil {
+		return nil, fmt.Errorf("volume driver %s returned the path %s of another volume for volume %s", driver, path, name)
+	}
+
+	id := stringid.GenerateRandomID()
+	v := &Volume{
+		ID:         id,
+		Name:       name,
+		Driver:     driver,
+		Path:       path,
+		From:       from,
+		Options:    opts,
+		Labels:     labels,
+		CreatedAt:  time.Now().UTC(),
+		repository: r,
+		Writable:   writable,
+		containers: make(map[string]struct{}),
+		configPath: r.configPath + "/" + id,
+	}
+
+	if err := v.initialize(); err != nil {
+		return nil, err
+	}
+
+	r.add(v)
+	return v, nil
+}
+
 func (r *Repository) FindOrCreateVolume(path string, writable bool) (*Volume, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -152,6 +152,136 @@ func TestRepositoryDelete(t *testing.T) {
 
 }
 
+func TestRepositoryNamedVolumes(t *testing.T) {
+	root, err := ioutil.TempDir(os.TempDir(), "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	repo, err := newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := repo.FindOrCreateNamedVolume("data", "", nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "data" || v.IsBindMount {
+		t.Fatalf("expected a named volume, got %+v", v)
+	}
+
+	// the same name finds the same volume
+	v2, err := repo.FindOrCreateNamedVolume("data", "", nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2 != v {
+		t.Fatalf("expected the named volume %s, got %s", v.Path, v2.Path)
+	}
+	if v2 := repo.GetByName("data"); v2 != v {
+		t.Fatalf("expected the named volume %s, got %v", v.Path, v2)
+	}
+
+	// an anonymous volume is found by its ID
+	anonymous, err := repo.FindOrCreateVolume("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2 := repo.GetByName(anonymous.ID); v2 != anonymous {
+		t.Fatalf("expected the anonymous volume %s, got %v", anonymous.Path, v2)
+	}
+
+	// bind mounts are neither found nor listed
+	bind, err := repo.FindOrCreateVolume(filepath.Join(root, "bind"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2 := repo.GetByName(bind.ID); v2 != nil {
+		t.Fatalf("expected the bind mount not to be found, got %s", v2.Path)
+	}
+	if v2 := repo.GetByName("nothing"); v2 != nil {
+		t.Fatalf("expected no volume, got %s", v2.Path)
+	}
+
+	list := repo.List()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 volumes, got %d", len(list))
+	}
+	for _, v2 := range list {
+		if v2 != v && v2 != anonymous {
+			t.Fatalf("unexpected volume %s", v2.Path)
+		}
+	}
+
+	// the name is restored with the volume
+	repo, err = newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2 := repo.GetByName("data"); v2 == nil || v2.Path != v.Path {
+		t.Fatalf("expected the named volume %s to be restored, got %v", v.Path, v2)
+	}
+}
+
+func TestRepositoryVolumeLabels(t *testing.T) {
+	root, err := ioutil.TempDir(os.TempDir(), "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	repo, err := newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anonymous, err := repo.CreateVolume(map[string]string{"env": "prod"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anonymous.Name != "" || anonymous.Labels["env"] != "prod" {
+		t.Fatalf("expected an anonymous volume with labels, got %+v", anonymous)
+	}
+
+	v, err := repo.FindOrCreateNamedVolume("data", "", nil, map[string]string{"env": "dev"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the labels of an existing volume are kept
+	v, err = repo.FindOrCreateNamedVolume("data", "", nil, map[string]string{"env": "test"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Labels["env"] != "dev" {
+		t.Fatalf("expected the labels of the existing volume, got %v", v.Labels)
+	}
+
+	// the labels are restored with the volumes
+	repo, err = newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := repo.GetByName(anonymous.ID); v == nil || v.Labels["env"] != "prod" {
+		t.Fatalf("expected the labels of the anonymous volume to be restored, got %v", v)
+	}
+	if v := repo.GetByName("data"); v == nil || v.Labels["env"] != "dev" {
+		t.Fatalf("expected the labels of the named volume to be restored, got %v", v)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"data", "my-data_1.0", "0data"} {
+		if err := ValidateName(name); err != nil {
+			t.Fatalf("expected %q to be a valid name: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "d", "-data", ".data", "my data", "data/1", "my:data"} {
+		if err := ValidateName(name); err == nil {
+			t.Fatalf("expected %q to be an invalid name", name)
+		}
+	}
+}
+
 func newRepo(root string) (*Repository, error) {
 	configPath := filepath.Join(root, "repo-config")
 	graphDir := filepath.Join(root, "repo-graph")
@@ -162,3 +292,79 @@ func newRepo(root string) (*Repository, error) {
 	}
 	return NewRepository(configPath, driver)
 }
+
+func TestRepositoryCloneVolume(t *testing.T) {
+	root, err := ioutil.TempDir(os.TempDir(), "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	repo, err := newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := repo.FindOrCreateNamedVolume("data", "", nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(src.Path, "db"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src.Path, "db", "table"), []byte("rows"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("db/table", filepath.Join(src.Path, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := repo.CloneVolume(src, "test-data", map[string]string{"env": "test"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "test-data" || v.From != "data" || v.Labels["env"] != "test" || v.Path == src.Path {
+		t.Fatalf("expected a copy of the volume data, got %+v", v)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(v.Path, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "rows" {
+		t.Fatalf("expected the data of the volume to be copied, got %q", data)
+	}
+	fi, err := os.Stat(filepath.Join(v.Path, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0700 {
+		t.Fatalf("expected the mode of the directories to be copied, got %v", fi.Mode())
+	}
+
+	// the copy is independent of the volume copied
+	if err := ioutil.WriteFile(filepath.Join(v.Path, "db", "table"), []byte("changed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(src.Path, "db", "table")); string(data) != "rows" {
+		t.Fatalf("expected the volume copied to be unchanged, got %q", data)
+	}
+
+	if _, err := repo.CloneVolume(src, "test-data", nil, true); err == nil {
+		t.Fatal("expected an error copying a volume to an existing volume")
+	}
+	anonymous, err := repo.CloneVolume(src, "", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anonymous.Name != "" || anonymous.From != "data" {
+		t.Fatalf("expected an anonymous copy of the volume, got %+v", anonymous)
+	}
+
+	// the origin of the copies is restored with the volumes
+	repo, err = newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := repo.GetByName("test-data"); v == nil || v.From != "data" {
+		t.Fatalf("expected the origin of the copy to be restored, got %v", v)
+	}
+}
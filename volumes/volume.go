@@ -2,24 +2,102 @@ package volumes
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/docker/docker/pkg/symlink"
 )
 
+// DefaultDriver is the name of the driver of the volumes stored locally by
+// the daemon.
+const DefaultDriver = "local"
+
 type Volume struct {
 	ID          string
+	Name        string // empty for the anonymous volumes and the bind mounts
+	Driver      string // the volume driver plugin of a named volume, empty for the local volumes
 	Path        string
+	From        string            // the volume this volume was cloned from
+	Options     map[string]string // the options of the volume driver
+	Labels      map[string]string
+	CreatedAt   time.Time
 	IsBindMount bool
 	Writable    bool
 	containers  map[string]struct{}
 	configPath  string
 	repository  *Repository
+	mounts      int // the number of mounts of a local volume with options
 	lock        sync.Mutex
 }
 
+// DisplayName returns the name of a named volume, or the ID of an anonymous
+// volume.
+func (v *Volume) DisplayName() string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return v.ID
+}
+
+// DriverName returns the name of the volume driver plugin of the volume, or
+// DefaultDriver for a local volume.
+func (v *Volume) DriverName() string {
+	if v.Driver != "" {
+		return v.Driver
+	}
+	return DefaultDriver
+}
+
+// Mountable returns whether the volume is only mounted on the host while
+// containers use it: the volumes of the volume driver plugins, and the local
+// volumes with mount options.
+func (v *Volume) Mountable() bool {
+	return v.Driver != "" || len(v.Options) > 0
+}
+
+// Mount mounts a volume of a volume driver plugin, or the filesystem of a
+// local volume with mount options, on the host for a container to use it.
+// The other local volumes are always on the host.
+func (v *Volume) Mount() error {
+	if v.Driver == "" {
+		if len(v.Options) == 0 {
+			return nil
+		}
+		return v.mountLocal()
+	}
+	d, err := getPluginDriver(v.Driver)
+	if err != nil {
+		return err
+	}
+	path, err := d.Mount(v.Name)
+	if err != nil {
+		return err
+	}
+	if filepath.Clean(path) != v.Path {
+		d.Unmount(v.Name)
+		return fmt.Errorf("volume driver %s mounted volume %s at %s instead of %s", v.Driver, v.Name, path, v.Path)
+	}
+	return nil
+}
+
+// Unmount releases a volume mounted by Mount.
+func (v *Volume) Unmount() error {
+	if v.Driver == "" {
+		if len(v.Options) == 0 {
+			return nil
+		}
+		return v.unmountLocal()
+	}
+	d, err := getPluginDriver(v.Driver)
+	if err != nil {
+		return err
+	}
+	return d.Unmount(v.Name)
+}
+
 func (v *Volume) IsDir() (bool, error) {
 	stat, err := os.Stat(v.Path)
 	if err != nil {
@@ -57,7 +135,8 @@ func (v *Volume) initialize() error {
 	v.lock.Lock()
 	defer v.lock.Unlock()
 
-	if _, err := os.Stat(v.Path); err != nil {
+	// the path of a volume of a volume driver plugin belongs to the plugin
+	if _, err := os.Stat(v.Path); err != nil && v.Driver == "" {
 		if !os.IsNotExist(err) {
 			return err
 		}